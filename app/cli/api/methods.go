@@ -1,1604 +1,637 @@
 package api
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
 	"io"
-	"log"
-	"net/http"
+	"net/url"
 	"plandex/types"
-	"strings"
+	"time"
 
 	"github.com/plandex/plandex/shared"
 )
 
 func (a *Api) StartTrial() (*shared.StartTrialResponse, *shared.ApiError) {
-	serverUrl := cloudApiHost + "/accounts/start_trial"
-
-	log.Println("Sending request to", serverUrl)
-
-	resp, err := unauthenticatedClient.Post(serverUrl, "application/json", nil)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
-	}
-
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		apiErr := handleApiError(resp, errorBody)
+	var res shared.StartTrialResponse
+	apiErr := callUnauthenticatedOperation(cloudApiHost, shared.ApiOperationStartTrial, nil, &res)
+	if apiErr != nil {
 		return nil, apiErr
 	}
 
-	var startTrialResponse shared.StartTrialResponse
-	err = json.NewDecoder(resp.Body).Decode(&startTrialResponse)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
-	}
-
-	return &startTrialResponse, nil
+	return &res, nil
 }
 
 func (a *Api) CreateProject(req shared.CreateProjectRequest) (*shared.CreateProjectResponse, *shared.ApiError) {
-	serverUrl := getApiHost() + "/projects"
-
-	reqBytes, err := json.Marshal(req)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
-	}
-
-	resp, err := authenticatedFastClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		apiErr := handleApiError(resp, errorBody)
-		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
-		if tokenRefreshed {
-			return a.CreateProject(req)
-		}
+	var res shared.CreateProjectResponse
+	apiErr := callOperation(authenticatedFastClient, shared.ApiOperationCreateProject, nil, nil, req, &res)
+	if apiErr != nil {
 		return nil, apiErr
 	}
 
-	var respBody shared.CreateProjectResponse
-	err = json.NewDecoder(resp.Body).Decode(&respBody)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
-	}
-
-	return &respBody, nil
+	return &res, nil
 }
 
 func (a *Api) ListProjects() ([]*shared.Project, *shared.ApiError) {
-	serverUrl := getApiHost() + "/projects"
-	resp, err := authenticatedFastClient.Get(serverUrl)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		apiErr := handleApiError(resp, errorBody)
-		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
-		if tokenRefreshed {
-			return a.ListProjects()
-		}
+	var res []*shared.Project
+	apiErr := callOperation(authenticatedFastClient, shared.ApiOperationListProjects, nil, nil, nil, &res)
+	if apiErr != nil {
 		return nil, apiErr
 	}
 
-	var projects []*shared.Project
-	err = json.NewDecoder(resp.Body).Decode(&projects)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
-	}
-
-	return projects, nil
+	return res, nil
 }
 
 func (a *Api) SetProjectPlan(projectId string, req shared.SetProjectPlanRequest) *shared.ApiError {
-	serverUrl := fmt.Sprintf("%s/projects/%s/set_plan", getApiHost(), projectId)
-	reqBytes, err := json.Marshal(req)
-	if err != nil {
-		return &shared.ApiError{Msg: fmt.Sprintf("error marshalling request: %v", err)}
-	}
-
-	request, err := http.NewRequest(http.MethodPut, serverUrl, bytes.NewBuffer(reqBytes))
-	if err != nil {
-		return &shared.ApiError{Msg: fmt.Sprintf("error creating request: %v", err)}
-	}
-	request.Header.Set("Content-Type", "application/json")
-
-	resp, err := authenticatedFastClient.Do(request)
-	if err != nil {
-		return &shared.ApiError{Msg: fmt.Sprintf("error sending request: %v", err)}
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		apiErr := handleApiError(resp, errorBody)
-		didRefresh, apiErr := refreshTokenIfNeeded(apiErr)
-		if didRefresh {
-			return a.SetProjectPlan(projectId, req)
-		}
-		return apiErr
-	}
-
-	return nil
+	return callOperation(authenticatedFastClient, shared.ApiOperationSetProjectPlan, []string{projectId}, nil, req, nil)
 }
 
 func (a *Api) RenameProject(projectId string, req shared.RenameProjectRequest) *shared.ApiError {
-	serverUrl := fmt.Sprintf("%s/projects/%s/rename", getApiHost(), projectId)
-	reqBytes, err := json.Marshal(req)
-	if err != nil {
-		return &shared.ApiError{Msg: fmt.Sprintf("error marshalling request: %v", err)}
-	}
-
-	request, err := http.NewRequest(http.MethodPut, serverUrl, bytes.NewBuffer(reqBytes))
-	if err != nil {
-		return &shared.ApiError{Msg: fmt.Sprintf("error creating request: %v", err)}
-	}
-	request.Header.Set("Content-Type", "application/json")
-
-	resp, err := authenticatedFastClient.Do(request)
-	if err != nil {
-		return &shared.ApiError{Msg: fmt.Sprintf("error sending request: %v", err)}
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		apiErr := handleApiError(resp, errorBody)
-
-		didRefresh, apiErr := refreshTokenIfNeeded(apiErr)
-		if didRefresh {
-			return a.RenameProject(projectId, req)
-		}
-		return apiErr
-	}
-
-	return nil
+	return callOperation(authenticatedFastClient, shared.ApiOperationRenameProject, []string{projectId}, nil, req, nil)
 }
-func (a *Api) ListPlans(projectIds []string) ([]*shared.Plan, *shared.ApiError) {
-	serverUrl := fmt.Sprintf("%s/plans?", getApiHost())
-	parts := []string{}
-	for _, projectId := range projectIds {
-		parts = append(parts, fmt.Sprintf("projectId=%s", projectId))
-	}
-	serverUrl += strings.Join(parts, "&")
-
-	resp, err := authenticatedFastClient.Get(serverUrl)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
 
-		apiErr := handleApiError(resp, errorBody)
-
-		didRefresh, apiErr := refreshTokenIfNeeded(apiErr)
-		if didRefresh {
-			return a.ListPlans(projectIds)
-		}
+func (a *Api) ListPlans(projectIds []string) ([]*shared.Plan, *shared.ApiError) {
+	var res []*shared.Plan
+	apiErr := callOperation(authenticatedFastClient, shared.ApiOperationListPlans, nil, url.Values{"projectId": projectIds}, nil, &res)
+	if apiErr != nil {
 		return nil, apiErr
 	}
 
-	var plans []*shared.Plan
-	err = json.NewDecoder(resp.Body).Decode(&plans)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
-	}
-
-	return plans, nil
+	return res, nil
 }
 
 func (a *Api) ListArchivedPlans(projectIds []string) ([]*shared.Plan, *shared.ApiError) {
-	serverUrl := fmt.Sprintf("%s/plans/archive?", getApiHost())
-	parts := []string{}
-	for _, projectId := range projectIds {
-		parts = append(parts, fmt.Sprintf("projectId=%s", projectId))
-	}
-	serverUrl += strings.Join(parts, "&")
-
-	resp, err := authenticatedFastClient.Get(serverUrl)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		apiErr := handleApiError(resp, errorBody)
-		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
-		if tokenRefreshed {
-			return a.ListArchivedPlans(projectIds)
-		}
+	var res []*shared.Plan
+	apiErr := callOperation(authenticatedFastClient, shared.ApiOperationListArchivedPlans, nil, url.Values{"projectId": projectIds}, nil, &res)
+	if apiErr != nil {
 		return nil, apiErr
 	}
 
-	var plans []*shared.Plan
-	err = json.NewDecoder(resp.Body).Decode(&plans)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
-	}
-
-	return plans, nil
+	return res, nil
 }
 
 func (a *Api) ListPlansRunning(projectIds []string, includeRecent bool) (*shared.ListPlansRunningResponse, *shared.ApiError) {
-	serverUrl := fmt.Sprintf("%s/plans/ps?", getApiHost())
-	parts := []string{}
-	for _, projectId := range projectIds {
-		parts = append(parts, fmt.Sprintf("projectId=%s", projectId))
-	}
-	serverUrl += strings.Join(parts, "&")
+	query := url.Values{"projectId": projectIds}
 	if includeRecent {
-		serverUrl += "&recent=true"
-	}
-
-	resp, err := authenticatedFastClient.Get(serverUrl)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+		query.Set("recent", "true")
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		apiErr := handleApiError(resp, errorBody)
-		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
-		if tokenRefreshed {
-			return a.ListPlansRunning(projectIds, includeRecent)
-		}
+	var res shared.ListPlansRunningResponse
+	apiErr := callOperation(authenticatedFastClient, shared.ApiOperationListPlansRunning, nil, query, nil, &res)
+	if apiErr != nil {
 		return nil, apiErr
 	}
 
-	var respBody *shared.ListPlansRunningResponse
-	err = json.NewDecoder(resp.Body).Decode(&respBody)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
-	}
-
-	return respBody, nil
+	return &res, nil
 }
 
 func (a *Api) GetCurrentBranchByPlanId(projectId string, req shared.GetCurrentBranchByPlanIdRequest) (map[string]*shared.Branch, *shared.ApiError) {
-	serverUrl := fmt.Sprintf("%s/projects/%s/plans/current_branches", getApiHost(), projectId)
-
-	reqBytes, err := json.Marshal(req)
-	if err != nil {
-		return nil, &shared.ApiError{Msg: fmt.Sprintf("error marshalling request: %v", err)}
-	}
-
-	request, err := http.NewRequest(http.MethodPost, serverUrl, bytes.NewBuffer(reqBytes))
-
-	if err != nil {
-		return nil, &shared.ApiError{Msg: fmt.Sprintf("error creating request: %v", err)}
-	}
-
-	request.Header.Set("Content-Type", "application/json")
-
-	resp, err := authenticatedFastClient.Do(request)
-
-	if err != nil {
-		return nil, &shared.ApiError{Msg: fmt.Sprintf("error sending request: %v", err)}
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-
-		apiErr := handleApiError(resp, errorBody)
-
-		didRefresh, apiErr := refreshTokenIfNeeded(apiErr)
-		if didRefresh {
-			return a.GetCurrentBranchByPlanId(projectId, req)
-		}
+	var res map[string]*shared.Branch
+	apiErr := callOperation(authenticatedFastClient, shared.ApiOperationGetCurrentBranchByPlanId, []string{projectId}, nil, req, &res)
+	if apiErr != nil {
 		return nil, apiErr
 	}
 
-	var respBody map[string]*shared.Branch
-	err = json.NewDecoder(resp.Body).Decode(&respBody)
-	if err != nil {
-		return nil, &shared.ApiError{Msg: fmt.Sprintf("error decoding response: %v", err)}
-	}
-
-	return respBody, nil
+	return res, nil
 }
 
 func (a *Api) CreatePlan(projectId string, req shared.CreatePlanRequest) (*shared.CreatePlanResponse, *shared.ApiError) {
-	serverUrl := fmt.Sprintf("%s/projects/%s/plans", getApiHost(), projectId)
-	reqBytes, err := json.Marshal(req)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
+	var res shared.CreatePlanResponse
+	apiErr := callOperation(authenticatedFastClient, shared.ApiOperationCreatePlan, []string{projectId}, nil, req, &res)
+	if apiErr != nil {
+		return nil, apiErr
 	}
 
-	resp, err := authenticatedFastClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
-	}
-	defer resp.Body.Close()
+	return &res, nil
+}
 
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		apiErr := handleApiError(resp, errorBody)
-		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
-		if tokenRefreshed {
-			return a.CreatePlan(projectId, req)
-		}
+func (a *Api) ImportPlanBundle(projectId string, req shared.ImportPlanRequest) (*shared.CreatePlanResponse, *shared.ApiError) {
+	var res shared.CreatePlanResponse
+	apiErr := callOperation(authenticatedSlowClient, shared.ApiOperationImportPlanBundle, []string{projectId}, nil, req, &res)
+	if apiErr != nil {
 		return nil, apiErr
 	}
 
-	var respBody shared.CreatePlanResponse
-	err = json.NewDecoder(resp.Body).Decode(&respBody)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
-	}
-
-	return &respBody, nil
+	return &res, nil
 }
 
-func (a *Api) GetPlan(planId string) (*shared.Plan, *shared.ApiError) {
-	serverUrl := fmt.Sprintf("%s/plans/%s", getApiHost(), planId)
-
-	resp, err := authenticatedFastClient.Get(serverUrl)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+func (a *Api) ExportPlanBundle(planId string) (*shared.PlanBundle, *shared.ApiError) {
+	var res shared.PlanBundle
+	apiErr := callOperation(authenticatedSlowClient, shared.ApiOperationExportPlanBundle, []string{planId}, nil, nil, &res)
+	if apiErr != nil {
+		return nil, apiErr
 	}
 
-	defer resp.Body.Close()
+	return &res, nil
+}
 
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		apiErr := handleApiError(resp, errorBody)
-		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
-		if tokenRefreshed {
-			return a.GetPlan(planId)
-		}
+func (a *Api) GetPlan(planId string) (*shared.Plan, *shared.ApiError) {
+	var res shared.Plan
+	apiErr := callOperation(authenticatedFastClient, shared.ApiOperationGetPlan, []string{planId}, nil, nil, &res)
+	if apiErr != nil {
 		return nil, apiErr
 	}
 
-	var plan shared.Plan
-	err = json.NewDecoder(resp.Body).Decode(&plan)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
-	}
-
-	return &plan, nil
+	return &res, nil
 }
 
 func (a *Api) DeletePlan(planId string) *shared.ApiError {
-	serverUrl := fmt.Sprintf("%s/plans/%s", getApiHost(), planId)
-
-	req, err := http.NewRequest(http.MethodDelete, serverUrl, nil)
-	if err != nil {
-		return &shared.ApiError{Msg: fmt.Sprintf("error creating request: %v", err)}
-	}
-
-	resp, err := authenticatedFastClient.Do(req)
-	if err != nil {
-		return &shared.ApiError{Msg: fmt.Sprintf("error sending request: %v", err)}
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		apiErr := handleApiError(resp, errorBody)
-
-		didRefresh, apiErr := refreshTokenIfNeeded(apiErr)
-		if didRefresh {
-			return a.DeletePlan(planId)
-		}
-		return apiErr
-	}
-
-	return nil
+	return callOperation(authenticatedFastClient, shared.ApiOperationDeletePlan, []string{planId}, nil, nil, nil)
 }
 
 func (a *Api) DeleteAllPlans(projectId string) *shared.ApiError {
-	serverUrl := fmt.Sprintf("%s/projects/%s/plans", getApiHost(), projectId)
-
-	req, err := http.NewRequest(http.MethodDelete, serverUrl, nil)
-	if err != nil {
-		return &shared.ApiError{Msg: fmt.Sprintf("error creating request: %v", err)}
-	}
-
-	resp, err := authenticatedFastClient.Do(req)
-	if err != nil {
-		return &shared.ApiError{Msg: fmt.Sprintf("error sending request: %v", err)}
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		apiErr := handleApiError(resp, errorBody)
-
-		didRefresh, apiErr := refreshTokenIfNeeded(apiErr)
-
-		if didRefresh {
-			return a.DeleteAllPlans(projectId)
-		}
-		return apiErr
-	}
-
-	return nil
+	return callOperation(authenticatedFastClient, shared.ApiOperationDeleteAllPlans, []string{projectId}, nil, nil, nil)
 }
 
 func (a *Api) TellPlan(planId, branch string, req shared.TellPlanRequest, onStream types.OnStreamPlan) *shared.ApiError {
-
-	serverUrl := fmt.Sprintf("%s/plans/%s/%s/tell", getApiHost(), planId, branch)
-	reqBytes, err := json.Marshal(req)
-	if err != nil {
-		return &shared.ApiError{Msg: fmt.Sprintf("error marshalling request: %v", err)}
-	}
-
-	request, err := http.NewRequest(http.MethodPost, serverUrl, bytes.NewBuffer(reqBytes))
-	if err != nil {
-		return &shared.ApiError{Msg: fmt.Sprintf("error creating request: %v", err)}
-	}
-	request.Header.Set("Content-Type", "application/json")
-
-	var client *http.Client
-	if req.ConnectStream {
-		client = authenticatedStreamingClient
-	} else {
-		client = authenticatedFastClient
-	}
-
-	resp, err := client.Do(request)
-	if err != nil {
-		return &shared.ApiError{Msg: fmt.Sprintf("error sending request: %v", err)}
-	}
-
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		apiErr := handleApiError(resp, errorBody)
-
-		didRefresh, apiErr := refreshTokenIfNeeded(apiErr)
-
-		if didRefresh {
-			return a.TellPlan(planId, branch, req, onStream)
-		}
-		return apiErr
-	}
-
-	if req.ConnectStream {
-		log.Println("Connecting stream")
-		connectPlanRespStream(resp.Body, onStream)
-	} else {
-		// log.Println("Background exec - not connecting stream")
-		resp.Body.Close()
-	}
-
-	return nil
+	return streamOperation(shared.ApiOperationProposePlan, []string{planId, branch}, req, req.ConnectStream, onStream)
 }
 
 func (a *Api) BuildPlan(planId, branch string, req shared.BuildPlanRequest, onStream types.OnStreamPlan) *shared.ApiError {
-
-	log.Println("Calling BuildPlan")
-
-	serverUrl := fmt.Sprintf("%s/plans/%s/%s/build", getApiHost(), planId, branch)
-	reqBytes, err := json.Marshal(req)
-	if err != nil {
-		return &shared.ApiError{Msg: fmt.Sprintf("error marshalling request: %v", err)}
-	}
-
-	request, err := http.NewRequest(http.MethodPatch, serverUrl, bytes.NewBuffer(reqBytes))
-	if err != nil {
-		return &shared.ApiError{Msg: fmt.Sprintf("error creating request: %v", err)}
-	}
-	request.Header.Set("Content-Type", "application/json")
-
-	var client *http.Client
-	if req.ConnectStream {
-		client = authenticatedStreamingClient
-	} else {
-		client = authenticatedFastClient
-	}
-
-	resp, err := client.Do(request)
-	if err != nil {
-		return &shared.ApiError{Msg: fmt.Sprintf("error sending request: %v", err)}
-	}
-
-	if resp.StatusCode >= 400 {
-		log.Println("Error response from build plan", resp.StatusCode)
-
-		errorBody, _ := io.ReadAll(resp.Body)
-		apiErr := handleApiError(resp, errorBody)
-
-		didRefresh, apiErr := refreshTokenIfNeeded(apiErr)
-
-		if didRefresh {
-			return a.BuildPlan(planId, branch, req, onStream)
-		}
-		return apiErr
-	}
-
-	if req.ConnectStream {
-		log.Println("Connecting stream")
-		connectPlanRespStream(resp.Body, onStream)
-	} else {
-		// log.Println("Background exec - not connecting stream")
-		resp.Body.Close()
-	}
-
-	return nil
+	return streamOperation(shared.ApiOperationBuildPlan, []string{planId, branch}, req, req.ConnectStream, onStream)
 }
 
 func (a *Api) RespondMissingFile(planId, branch string, req shared.RespondMissingFileRequest) *shared.ApiError {
-	serverUrl := fmt.Sprintf("%s/plans/%s/%s/respond_missing_file", getApiHost(), planId, branch)
-
-	reqBytes, err := json.Marshal(req)
-	if err != nil {
-		return &shared.ApiError{Msg: fmt.Sprintf("error marshalling request: %v", err)}
-	}
-
-	request, err := http.NewRequest(http.MethodPost, serverUrl, bytes.NewBuffer(reqBytes))
-	if err != nil {
-		return &shared.ApiError{Msg: fmt.Sprintf("error creating request: %v", err)}
-	}
-	request.Header.Set("Content-Type", "application/json")
-
-	resp, err := authenticatedFastClient.Do(request)
-	if err != nil {
-		return &shared.ApiError{Msg: fmt.Sprintf("error sending request: %v", err)}
-	}
-
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		apiErr := handleApiError(resp, errorBody)
-
-		didRefresh, apiErr := refreshTokenIfNeeded(apiErr)
-
-		if didRefresh {
-			return a.RespondMissingFile(planId, branch, req)
-		}
-		return apiErr
-	}
-
-	return nil
+	return callOperation(authenticatedFastClient, shared.ApiOperationRespondMissingFile, []string{planId, branch}, nil, req, nil)
+}
 
+func (a *Api) RespondClarify(planId, branch string, req shared.RespondClarifyRequest) *shared.ApiError {
+	return callOperation(authenticatedFastClient, shared.ApiOperationRespondClarify, []string{planId, branch}, nil, req, nil)
 }
 
 func (a *Api) ConnectPlan(planId, branch string, onStream types.OnStreamPlan) *shared.ApiError {
-	serverUrl := fmt.Sprintf("%s/plans/%s/%s/connect", getApiHost(), planId, branch)
-
-	req, err := http.NewRequest(http.MethodPatch, serverUrl, nil)
-	if err != nil {
-		return &shared.ApiError{Msg: fmt.Sprintf("error creating request: %v", err)}
-	}
-
-	resp, err := authenticatedStreamingClient.Do(req)
-	if err != nil {
-		return &shared.ApiError{Msg: fmt.Sprintf("error sending request: %v", err)}
-	}
-
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		apiErr := handleApiError(resp, errorBody)
-
-		didRefresh, apiErr := refreshTokenIfNeeded(apiErr)
-
-		if didRefresh {
-			return a.ConnectPlan(planId, branch, onStream)
-		}
-
-		return apiErr
-	}
-
-	connectPlanRespStream(resp.Body, onStream)
-
-	return nil
+	return streamOperation(shared.ApiOperationConnectPlan, []string{planId, branch}, nil, true, onStream)
 }
 
 func (a *Api) StopPlan(planId, branch string) *shared.ApiError {
-	serverUrl := fmt.Sprintf("%s/plans/%s/%s/stop", getApiHost(), planId, branch)
-
-	req, err := http.NewRequest(http.MethodDelete, serverUrl, nil)
-	if err != nil {
-		return &shared.ApiError{Msg: fmt.Sprintf("error creating request: %v", err)}
-	}
-
-	resp, err := authenticatedFastClient.Do(req)
-	if err != nil {
-		return &shared.ApiError{Msg: fmt.Sprintf("error sending request: %v", err)}
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		apiErr := handleApiError(resp, errorBody)
-		didRefresh, apiErr := refreshTokenIfNeeded(apiErr)
-		if didRefresh {
-			return a.StopPlan(planId, branch)
-		}
-		return apiErr
-	}
-
-	return nil
-}
+	return callOperation(authenticatedFastClient, shared.ApiOperationAbortPlan, []string{planId, branch}, nil, nil, nil)
+}
 
 func (a *Api) GetCurrentPlanState(planId, branch string) (*shared.CurrentPlanState, *shared.ApiError) {
-	serverUrl := fmt.Sprintf("%s/plans/%s/%s/current_plan", getApiHost(), planId, branch)
-
-	resp, err := authenticatedFastClient.Get(serverUrl)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		apiErr := handleApiError(resp, errorBody)
-		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
-		if tokenRefreshed {
-			return a.GetCurrentPlanState(planId, branch)
-		}
-		return nil, apiErr
-	}
-
-	var state shared.CurrentPlanState
-	err = json.NewDecoder(resp.Body).Decode(&state)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
-	}
-
-	return &state, nil
-}
-
-func (a *Api) ApplyPlan(planId, branch string) *shared.ApiError {
-	serverUrl := fmt.Sprintf("%s/plans/%s/%s/apply", getApiHost(), planId, branch)
-
-	req, err := http.NewRequest(http.MethodPatch, serverUrl, nil)
-	if err != nil {
-		return &shared.ApiError{Msg: fmt.Sprintf("error creating request: %v", err)}
-	}
-
-	resp, err := authenticatedFastClient.Do(req)
-	if err != nil {
-		return &shared.ApiError{Msg: fmt.Sprintf("error sending request: %v", err)}
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		apiErr := handleApiError(resp, errorBody)
-
-		didRefresh, apiErr := refreshTokenIfNeeded(apiErr)
-		if didRefresh {
-			return a.ApplyPlan(planId, branch)
-		}
-		return apiErr
-	}
-
-	return nil
-}
-
-func (a *Api) ArchivePlan(planId string) *shared.ApiError {
-	serverUrl := fmt.Sprintf("%s/plans/%s/archive", getApiHost(), planId)
-
-	req, err := http.NewRequest(http.MethodPatch, serverUrl, nil)
-	if err != nil {
-		return &shared.ApiError{Msg: fmt.Sprintf("error creating request: %v", err)}
-	}
-
-	resp, err := authenticatedFastClient.Do(req)
-	if err != nil {
-		return &shared.ApiError{Msg: fmt.Sprintf("error sending request: %v", err)}
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		apiErr := handleApiError(resp, errorBody)
-
-		didRefresh, apiErr := refreshTokenIfNeeded(apiErr)
-		if didRefresh {
-			return a.ArchivePlan(planId)
-		}
-		return apiErr
-	}
-
-	return nil
-}
-
-func (a *Api) RejectAllChanges(planId, branch string) *shared.ApiError {
-	serverUrl := fmt.Sprintf("%s/plans/%s/%s/reject_all", getApiHost(), planId, branch)
-
-	req, err := http.NewRequest(http.MethodPatch, serverUrl, nil)
-	if err != nil {
-		return &shared.ApiError{Msg: fmt.Sprintf("error creating request: %v", err)}
-	}
-
-	resp, err := authenticatedFastClient.Do(req)
-	if err != nil {
-		return &shared.ApiError{Msg: fmt.Sprintf("error sending request: %v", err)}
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		apiErr := handleApiError(resp, errorBody)
-
-		didRefresh, apiErr := refreshTokenIfNeeded(apiErr)
-		if didRefresh {
-			return a.RejectAllChanges(planId, branch)
-		}
-		return apiErr
-	}
-
-	return nil
-}
-
-func (a *Api) RejectFile(planId, branch, filePath string) *shared.ApiError {
-	serverUrl := fmt.Sprintf("%s/plans/%s/%s/reject_file", getApiHost(), planId, branch)
-
-	reqBytes, err := json.Marshal(shared.RejectFileRequest{FilePath: filePath})
-
-	if err != nil {
-		return &shared.ApiError{Msg: fmt.Sprintf("error marshalling request: %v", err)}
-	}
-
-	req, err := http.NewRequest(http.MethodPatch, serverUrl, bytes.NewBuffer(reqBytes))
-	if err != nil {
-		return &shared.ApiError{Msg: fmt.Sprintf("error creating request: %v", err)}
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := authenticatedFastClient.Do(req)
-	if err != nil {
-		return &shared.ApiError{Msg: fmt.Sprintf("error sending request: %v", err)}
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		apiErr := handleApiError(resp, errorBody)
-		didRefresh, apiErr := refreshTokenIfNeeded(apiErr)
-		if didRefresh {
-			a.RejectFile(planId, branch, filePath)
-		}
-		return apiErr
-	}
-
-	return nil
-}
-
-func (a *Api) LoadContext(planId, branch string, req shared.LoadContextRequest) (*shared.LoadContextResponse, *shared.ApiError) {
-	serverUrl := fmt.Sprintf("%s/plans/%s/%s/context", getApiHost(), planId, branch)
-	reqBytes, err := json.Marshal(req)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
-	}
-
-	// use the slow client since we may be uploading relatively large files
-	resp, err := authenticatedSlowClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		apiErr := handleApiError(resp, errorBody)
-		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
-		if tokenRefreshed {
-			return a.LoadContext(planId, branch, req)
-		}
-		return nil, apiErr
-	}
-
-	var loadContextResponse shared.LoadContextResponse
-	err = json.NewDecoder(resp.Body).Decode(&loadContextResponse)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
-	}
-
-	return &loadContextResponse, nil
-}
-
-func (a *Api) UpdateContext(planId, branch string, req shared.UpdateContextRequest) (*shared.UpdateContextResponse, *shared.ApiError) {
-	serverUrl := fmt.Sprintf("%s/plans/%s/%s/context", getApiHost(), planId, branch)
-
-	reqBytes, err := json.Marshal(req)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
-	}
-
-	request, err := http.NewRequest(http.MethodPut, serverUrl, bytes.NewBuffer(reqBytes))
-
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %v", err)}
-	}
-
-	request.Header.Set("Content-Type", "application/json")
-
-	// use the slow client since we may be uploading relatively large files
-	resp, err := authenticatedSlowClient.Do(request)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		apiErr := handleApiError(resp, errorBody)
-		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
-		if tokenRefreshed {
-			return a.UpdateContext(planId, branch, req)
-		}
+	var res shared.CurrentPlanState
+	apiErr := callOperation(authenticatedFastClient, shared.ApiOperationGetPlanStatus, []string{planId, branch}, nil, nil, &res)
+	if apiErr != nil {
 		return nil, apiErr
 	}
 
-	var updateContextResponse shared.UpdateContextResponse
-	err = json.NewDecoder(resp.Body).Decode(&updateContextResponse)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
-	}
-
-	return &updateContextResponse, nil
-}
-
-func (a *Api) DeleteContext(planId, branch string, req shared.DeleteContextRequest) (*shared.DeleteContextResponse, *shared.ApiError) {
-	serverUrl := fmt.Sprintf("%s/plans/%s/%s/context", getApiHost(), planId, branch)
-	reqBytes, err := json.Marshal(req)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
-	}
-
-	request, err := http.NewRequest(http.MethodDelete, serverUrl, bytes.NewBuffer(reqBytes))
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %v", err)}
-	}
-	request.Header.Set("Content-Type", "application/json")
-
-	resp, err := authenticatedFastClient.Do(request)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		apiErr := handleApiError(resp, errorBody)
-		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
-		if tokenRefreshed {
-			return a.DeleteContext(planId, branch, req)
-		}
-		return nil, apiErr
-	}
-
-	var deleteContextResponse shared.DeleteContextResponse
-	err = json.NewDecoder(resp.Body).Decode(&deleteContextResponse)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
-	}
-
-	return &deleteContextResponse, nil
-}
-
-func (a *Api) ListContext(planId, branch string) ([]*shared.Context, *shared.ApiError) {
-	serverUrl := fmt.Sprintf("%s/plans/%s/%s/context", getApiHost(), planId, branch)
-
-	resp, err := authenticatedFastClient.Get(serverUrl)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		apiErr := handleApiError(resp, errorBody)
-		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
-		if tokenRefreshed {
-			return a.ListContext(planId, branch)
-		}
-		return nil, apiErr
-	}
-
-	var contexts []*shared.Context
-	err = json.NewDecoder(resp.Body).Decode(&contexts)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
-	}
-
-	return contexts, nil
-}
-
-func (a *Api) ListConvo(planId, branch string) ([]*shared.ConvoMessage, *shared.ApiError) {
-	serverUrl := fmt.Sprintf("%s/plans/%s/%s/convo", getApiHost(), planId, branch)
-
-	resp, err := authenticatedFastClient.Get(serverUrl)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		apiErr := handleApiError(resp, errorBody)
-		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
-		if tokenRefreshed {
-			return a.ListConvo(planId, branch)
-		}
-		return nil, apiErr
-	}
-
-	var convos []*shared.ConvoMessage
-	err = json.NewDecoder(resp.Body).Decode(&convos)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
-	}
-
-	return convos, nil
-}
-
-func (a *Api) ListLogs(planId, branch string) (*shared.LogResponse, *shared.ApiError) {
-	serverUrl := fmt.Sprintf("%s/plans/%s/%s/logs", getApiHost(), planId, branch)
-
-	resp, err := authenticatedFastClient.Get(serverUrl)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
-	}
-
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		apiErr := handleApiError(resp, errorBody)
-		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
-		if tokenRefreshed {
-			return a.ListLogs(planId, branch)
-		}
-		return nil, apiErr
-	}
-
-	var logs shared.LogResponse
-	err = json.NewDecoder(resp.Body).Decode(&logs)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
-	}
-
-	return &logs, nil
-}
-
-func (a *Api) RewindPlan(planId, branch string, req shared.RewindPlanRequest) (*shared.RewindPlanResponse, *shared.ApiError) {
-	serverUrl := fmt.Sprintf("%s/plans/%s/%s/rewind", getApiHost(), planId, branch)
-	reqBytes, err := json.Marshal(req)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
-	}
-
-	request, err := http.NewRequest(http.MethodPatch, serverUrl, bytes.NewBuffer(reqBytes))
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %v", err)}
-	}
-	request.Header.Set("Content-Type", "application/json")
-
-	resp, err := authenticatedFastClient.Do(request)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
-	}
-
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		apiErr := handleApiError(resp, errorBody)
-		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
-		if tokenRefreshed {
-			return a.RewindPlan(planId, branch, req)
-		}
-		return nil, apiErr
-	}
-
-	var rewindPlanResponse shared.RewindPlanResponse
-	err = json.NewDecoder(resp.Body).Decode(&rewindPlanResponse)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
-	}
-
-	return &rewindPlanResponse, nil
-}
-
-func (a *Api) SignIn(req shared.SignInRequest, customHost string) (*shared.SessionResponse, *shared.ApiError) {
-	host := customHost
-	if host == "" {
-		host = cloudApiHost
-	}
-	serverUrl := host + "/accounts/sign_in"
-	reqBytes, err := json.Marshal(req)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
-	}
-
-	resp, err := unauthenticatedClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		apiErr := handleApiError(resp, errorBody)
-		return nil, apiErr
-	}
-
-	var sessionResponse shared.SessionResponse
-	err = json.NewDecoder(resp.Body).Decode(&sessionResponse)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
-	}
-
-	return &sessionResponse, nil
-}
-
-func (a *Api) CreateAccount(req shared.CreateAccountRequest, customHost string) (*shared.SessionResponse, *shared.ApiError) {
-	host := customHost
-	if host == "" {
-		host = cloudApiHost
-	}
-	serverUrl := host + "/accounts"
-	reqBytes, err := json.Marshal(req)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
-	}
-
-	resp, err := unauthenticatedClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		apiErr := handleApiError(resp, errorBody)
-		return nil, apiErr
-	}
-
-	var sessionResponse shared.SessionResponse
-	err = json.NewDecoder(resp.Body).Decode(&sessionResponse)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
-	}
-
-	return &sessionResponse, nil
-}
-
-func (a *Api) ConvertTrial(req shared.ConvertTrialRequest) (*shared.SessionResponse, *shared.ApiError) {
-	serverUrl := getApiHost() + "/accounts/convert_trial"
-	reqBytes, err := json.Marshal(req)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
-	}
-
-	resp, err := authenticatedFastClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		apiErr := handleApiError(resp, errorBody)
-		return nil, apiErr
-	}
-
-	var sessionResponse shared.SessionResponse
-	err = json.NewDecoder(resp.Body).Decode(&sessionResponse)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
-	}
-
-	return &sessionResponse, nil
-}
-
-func (a *Api) CreateOrg(req shared.CreateOrgRequest) (*shared.CreateOrgResponse, *shared.ApiError) {
-	serverUrl := getApiHost() + "/orgs"
-	reqBytes, err := json.Marshal(req)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
-	}
-
-	resp, err := authenticatedFastClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
-	}
-	defer resp.Body.Close()
+	return &res, nil
+}
 
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		apiErr := handleApiError(resp, errorBody)
-		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
-		if tokenRefreshed {
-			return a.CreateOrg(req)
-		}
+func (a *Api) ListConvoMessages(planId, branch string, q shared.HistoryQuery) (*shared.ConvoPage, *shared.ApiError) {
+	var res shared.ConvoPage
+	apiErr := callOperation(authenticatedFastClient, shared.ApiOperationListConvoMessages, []string{planId, branch}, q.Values(), nil, &res)
+	if apiErr != nil {
 		return nil, apiErr
 	}
 
-	var createOrgResponse shared.CreateOrgResponse
-	err = json.NewDecoder(resp.Body).Decode(&createOrgResponse)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	return &res, nil
+}
+
+func (a *Api) ListPlanEvents(planId, branch string, q shared.HistoryQuery) (*shared.PlanEventPage, *shared.ApiError) {
+	var res shared.PlanEventPage
+	apiErr := callOperation(authenticatedFastClient, shared.ApiOperationListPlanEvents, []string{planId, branch}, q.Values(), nil, &res)
+	if apiErr != nil {
+		return nil, apiErr
 	}
 
-	return &createOrgResponse, nil
+	return &res, nil
 }
 
-func (a *Api) GetOrgSession() *shared.ApiError {
-	serverUrl := getApiHost() + "/orgs/session"
-	resp, err := authenticatedFastClient.Get(serverUrl)
-	if err != nil {
-		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
-	}
-	defer resp.Body.Close()
+func (a *Api) ApplyPlan(planId, branch string) *shared.ApiError {
+	return callOperation(authenticatedFastClient, shared.ApiOperationConfirmPlan, []string{planId, branch}, nil, nil, nil)
+}
 
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		apiErr := handleApiError(resp, errorBody)
-		return apiErr
-	}
+func (a *Api) ArchivePlan(planId string) *shared.ApiError {
+	return callOperation(authenticatedFastClient, shared.ApiOperationArchivePlan, []string{planId}, nil, nil, nil)
+}
 
-	return nil
+func (a *Api) SharePlan(planId string) *shared.ApiError {
+	return callOperation(authenticatedFastClient, shared.ApiOperationSharePlan, []string{planId}, nil, nil, nil)
 }
 
-func (a *Api) ListOrgs() ([]*shared.Org, *shared.ApiError) {
-	serverUrl := getApiHost() + "/orgs"
-	resp, err := authenticatedFastClient.Get(serverUrl)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
-	}
-	defer resp.Body.Close()
+func (a *Api) UnsharePlan(planId string) *shared.ApiError {
+	return callOperation(authenticatedFastClient, shared.ApiOperationUnsharePlan, []string{planId}, nil, nil, nil)
+}
 
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		apiErr := handleApiError(resp, errorBody)
-		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
-		if tokenRefreshed {
-			return a.ListOrgs()
-		}
+func (a *Api) ListSharedPlans() ([]*shared.Plan, *shared.ApiError) {
+	var res []*shared.Plan
+	apiErr := callOperation(authenticatedFastClient, shared.ApiOperationListSharedPlans, nil, nil, nil, &res)
+	if apiErr != nil {
 		return nil, apiErr
 	}
 
-	var orgs []*shared.Org
-	err = json.NewDecoder(resp.Body).Decode(&orgs)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
-	}
+	return res, nil
+}
 
-	return orgs, nil
+func (a *Api) RejectAllChanges(planId, branch string) *shared.ApiError {
+	return callOperation(authenticatedFastClient, shared.ApiOperationRejectAllChanges, []string{planId, branch}, nil, nil, nil)
 }
 
-func (a *Api) DeleteUser(userId string) *shared.ApiError {
-	serverUrl := fmt.Sprintf("%s/orgs/users/%s", getApiHost(), userId)
-	req, err := http.NewRequest(http.MethodDelete, serverUrl, nil)
-	if err != nil {
-		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %v", err)}
-	}
+func (a *Api) RejectFile(planId, branch, filePath string) *shared.ApiError {
+	return callOperation(authenticatedFastClient, shared.ApiOperationRejectFile, []string{planId, branch}, nil, shared.RejectFileRequest{FilePath: filePath}, nil)
+}
 
-	resp, err := authenticatedFastClient.Do(req)
-	if err != nil {
-		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
-	}
-	defer resp.Body.Close()
+func (a *Api) RecordCommandResult(planId, branch string, req shared.RecordCommandResultRequest) *shared.ApiError {
+	return callOperation(authenticatedFastClient, shared.ApiOperationRecordCommandResult, []string{planId, branch}, nil, req, nil)
+}
 
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		apiErr := handleApiError(resp, errorBody)
-		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
-		if tokenRefreshed {
-			return a.DeleteUser(userId)
-		}
-		return apiErr
+func (a *Api) LoadContext(planId, branch string, req shared.LoadContextRequest) (*shared.LoadContextResponse, *shared.ApiError) {
+	var res shared.LoadContextResponse
+	apiErr := callOperation(authenticatedSlowClient, shared.ApiOperationLoadContext, []string{planId, branch}, nil, req, &res)
+	if apiErr != nil {
+		return nil, apiErr
 	}
 
-	return nil
+	return &res, nil
 }
 
-func (a *Api) ListOrgRoles() ([]*shared.OrgRole, *shared.ApiError) {
-	serverUrl := getApiHost() + "/orgs/roles"
-	resp, err := authenticatedFastClient.Get(serverUrl)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
+func (a *Api) UpdateContext(planId, branch string, req shared.UpdateContextRequest) (*shared.UpdateContextResponse, *shared.ApiError) {
+	var res shared.UpdateContextResponse
+	apiErr := callOperation(authenticatedSlowClient, shared.ApiOperationUpdateContext, []string{planId, branch}, nil, req, &res)
+	if apiErr != nil {
+		return nil, apiErr
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
+	return &res, nil
+}
 
-		apiErr := handleApiError(resp, errorBody)
-		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
-		if tokenRefreshed {
-			return a.ListOrgRoles()
-		}
+func (a *Api) DeleteContext(planId, branch string, req shared.DeleteContextRequest) (*shared.DeleteContextResponse, *shared.ApiError) {
+	var res shared.DeleteContextResponse
+	apiErr := callOperation(authenticatedFastClient, shared.ApiOperationDeleteContext, []string{planId, branch}, nil, req, &res)
+	if apiErr != nil {
 		return nil, apiErr
 	}
 
-	var roles []*shared.OrgRole
-	err = json.NewDecoder(resp.Body).Decode(&roles)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %s", err)}
-	}
-
-	return roles, nil
+	return &res, nil
 }
 
-func (a *Api) InviteUser(req shared.InviteRequest) *shared.ApiError {
-	serverUrl := getApiHost() + "/invites"
-	reqBytes, err := json.Marshal(req)
-	if err != nil {
-		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
-	}
-
-	resp, err := authenticatedFastClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
-	if err != nil {
-		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+func (a *Api) ListContext(planId, branch string) ([]*shared.Context, *shared.ApiError) {
+	var res []*shared.Context
+	apiErr := callOperation(authenticatedFastClient, shared.ApiOperationListContext, []string{planId, branch}, nil, nil, &res)
+	if apiErr != nil {
+		return nil, apiErr
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		apiErr := handleApiError(resp, errorBody)
+	return res, nil
+}
 
-		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
-		if tokenRefreshed {
-			return a.InviteUser(req)
-		}
-		return apiErr
+func (a *Api) ListConvo(planId, branch string) ([]*shared.ConvoMessage, *shared.ApiError) {
+	var res []*shared.ConvoMessage
+	apiErr := callOperation(authenticatedFastClient, shared.ApiOperationListConvo, []string{planId, branch}, nil, nil, &res)
+	if apiErr != nil {
+		return nil, apiErr
 	}
 
-	return nil
+	return res, nil
 }
 
-func (a *Api) ListPendingInvites() ([]*shared.Invite, *shared.ApiError) {
-	serverUrl := getApiHost() + "/invites/pending"
-	resp, err := authenticatedFastClient.Get(serverUrl)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+func (a *Api) ListLogs(planId, branch string) (*shared.LogResponse, *shared.ApiError) {
+	var res shared.LogResponse
+	apiErr := callOperation(authenticatedFastClient, shared.ApiOperationListLogs, []string{planId, branch}, nil, nil, &res)
+	if apiErr != nil {
+		return nil, apiErr
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		apiErr := handleApiError(resp, errorBody)
-		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
-		if tokenRefreshed {
-			return a.ListPendingInvites()
-		}
+	return &res, nil
+}
+
+func (a *Api) RewindPlan(planId, branch string, req shared.RewindPlanRequest) (*shared.RewindPlanResponse, *shared.ApiError) {
+	var res shared.RewindPlanResponse
+	apiErr := callOperation(authenticatedFastClient, shared.ApiOperationRewindPlan, []string{planId, branch}, nil, req, &res)
+	if apiErr != nil {
 		return nil, apiErr
 	}
 
-	var invites []*shared.Invite
-	err = json.NewDecoder(resp.Body).Decode(&invites)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	return &res, nil
+}
+
+func (a *Api) SignIn(req shared.SignInRequest, customHost string) (*shared.SessionResponse, *shared.ApiError) {
+	var res shared.SessionResponse
+	apiErr := callUnauthenticatedOperation(accountHost(customHost), shared.ApiOperationSignIn, req, &res)
+	if apiErr != nil {
+		return nil, apiErr
 	}
 
-	return invites, nil
+	return &res, nil
 }
 
-func (a *Api) ListAcceptedInvites() ([]*shared.Invite, *shared.ApiError) {
-	serverUrl := getApiHost() + "/invites/accepted"
-	resp, err := authenticatedFastClient.Get(serverUrl)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+func (a *Api) CreateAccount(req shared.CreateAccountRequest, customHost string) (*shared.SessionResponse, *shared.ApiError) {
+	var res shared.SessionResponse
+	apiErr := callUnauthenticatedOperation(accountHost(customHost), shared.ApiOperationCreateAccount, req, &res)
+	if apiErr != nil {
+		return nil, apiErr
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		apiErr := handleApiError(resp, errorBody)
-		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
-		if tokenRefreshed {
-			return a.ListAcceptedInvites()
-		}
+	return &res, nil
+}
+
+func (a *Api) ConvertTrial(req shared.ConvertTrialRequest) (*shared.SessionResponse, *shared.ApiError) {
+	var res shared.SessionResponse
+	apiErr := callOperationNoRefresh(authenticatedFastClient, shared.ApiOperationConvertTrial, nil, nil, req, &res)
+	if apiErr != nil {
 		return nil, apiErr
 	}
 
-	var invites []*shared.Invite
-	err = json.NewDecoder(resp.Body).Decode(&invites)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	return &res, nil
+}
+
+func (a *Api) CreateOrg(req shared.CreateOrgRequest) (*shared.CreateOrgResponse, *shared.ApiError) {
+	var res shared.CreateOrgResponse
+	apiErr := callOperation(authenticatedFastClient, shared.ApiOperationCreateOrg, nil, nil, req, &res)
+	if apiErr != nil {
+		return nil, apiErr
 	}
 
-	return invites, nil
+	return &res, nil
 }
 
-func (a *Api) ListAllInvites() ([]*shared.Invite, *shared.ApiError) {
-	serverUrl := getApiHost() + "/invites/all"
-	resp, err := authenticatedFastClient.Get(serverUrl)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
-	}
-	defer resp.Body.Close()
+func (a *Api) GetOrgSession() *shared.ApiError {
+	return callOperationNoRefresh(authenticatedFastClient, shared.ApiOperationGetOrgSession, nil, nil, nil, nil)
+}
 
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		apiErr := handleApiError(resp, errorBody)
-		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
-		if tokenRefreshed {
-			return a.ListAllInvites()
-		}
+func (a *Api) ListOrgs() ([]*shared.Org, *shared.ApiError) {
+	var res []*shared.Org
+	apiErr := callOperation(authenticatedFastClient, shared.ApiOperationListOrgs, nil, nil, nil, &res)
+	if apiErr != nil {
 		return nil, apiErr
 	}
 
-	var invites []*shared.Invite
-	err = json.NewDecoder(resp.Body).Decode(&invites)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
-	}
-
-	return invites, nil
+	return res, nil
 }
 
-func (a *Api) DeleteInvite(inviteId string) *shared.ApiError {
-	serverUrl := fmt.Sprintf("%s/invites/%s", getApiHost(), inviteId)
-	req, err := http.NewRequest(http.MethodDelete, serverUrl, nil)
-	if err != nil {
-		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %v", err)}
-	}
+func (a *Api) DeleteUser(userId string) *shared.ApiError {
+	return callOperation(authenticatedFastClient, shared.ApiOperationDeleteOrgUser, []string{userId}, nil, nil, nil)
+}
 
-	resp, err := authenticatedFastClient.Do(req)
-	if err != nil {
-		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+func (a *Api) ListOrgRoles() ([]*shared.OrgRole, *shared.ApiError) {
+	var res []*shared.OrgRole
+	apiErr := callOperation(authenticatedFastClient, shared.ApiOperationListOrgRoles, nil, nil, nil, &res)
+	if apiErr != nil {
+		return nil, apiErr
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		apiErr := handleApiError(resp, errorBody)
+	return res, nil
+}
 
-		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
-		if tokenRefreshed {
-			return a.DeleteInvite(inviteId)
-		}
-		return apiErr
+func (a *Api) GetOrgUsage(since time.Time) (*shared.OrgUsageResponse, *shared.ApiError) {
+	var res shared.OrgUsageResponse
+	apiErr := callOperation(authenticatedFastClient, shared.ApiOperationGetOrgUsage, nil, sinceQuery(since), nil, &res)
+	if apiErr != nil {
+		return nil, apiErr
 	}
 
-	return nil
+	return &res, nil
 }
 
-func (a *Api) CreateEmailVerification(email, customHost, userId string) (*shared.CreateEmailVerificationResponse, *shared.ApiError) {
-	host := customHost
-	if host == "" {
-		host = cloudApiHost
-	}
-	serverUrl := host + "/accounts/email_verifications"
-	req := shared.CreateEmailVerificationRequest{Email: email, UserId: userId}
-	reqBytes, err := json.Marshal(req)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
+func (a *Api) GetPlanUsage(planId string, since time.Time) (*shared.PlanUsageResponse, *shared.ApiError) {
+	var res shared.PlanUsageResponse
+	apiErr := callOperation(authenticatedFastClient, shared.ApiOperationGetPlanUsage, []string{planId}, sinceQuery(since), nil, &res)
+	if apiErr != nil {
+		return nil, apiErr
 	}
 
-	resp, err := unauthenticatedClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
-	}
-	defer resp.Body.Close()
+	return &res, nil
+}
 
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		return nil, handleApiError(resp, errorBody)
+func (a *Api) ListPlanPullRequests(planId string) ([]*shared.PlanPullRequest, *shared.ApiError) {
+	var res []*shared.PlanPullRequest
+	apiErr := callOperation(authenticatedFastClient, shared.ApiOperationListPlanPullRequests, []string{planId}, nil, nil, &res)
+	if apiErr != nil {
+		return nil, apiErr
 	}
 
-	var verificationResponse shared.CreateEmailVerificationResponse
-	err = json.NewDecoder(resp.Body).Decode(&verificationResponse)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	return res, nil
+}
+
+func (a *Api) CreatePlanPullRequest(planId string, req shared.CreatePlanPullRequestRequest) (*shared.PlanPullRequest, *shared.ApiError) {
+	var res shared.PlanPullRequest
+	apiErr := callOperation(authenticatedFastClient, shared.ApiOperationCreatePlanPullRequest, []string{planId}, nil, req, &res)
+	if apiErr != nil {
+		return nil, apiErr
 	}
 
-	return &verificationResponse, nil
+	return &res, nil
 }
 
-func (a *Api) SignOut() *shared.ApiError {
-	serverUrl := getApiHost() + "/accounts/sign_out"
-
-	req, err := http.NewRequest(http.MethodPost, serverUrl, nil)
-	if err != nil {
-		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %v", err)}
+func (a *Api) GetOrgSlackSettings() (*shared.OrgSlackSettings, *shared.ApiError) {
+	var res shared.OrgSlackSettings
+	apiErr := callOperation(authenticatedFastClient, shared.ApiOperationGetOrgSlackSettings, nil, nil, nil, &res)
+	if apiErr != nil {
+		return nil, apiErr
 	}
 
-	resp, err := authenticatedFastClient.Do(req)
-	if err != nil {
-		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
-	}
-	defer resp.Body.Close()
+	return &res, nil
+}
 
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		return handleApiError(resp, errorBody)
-	}
+func (a *Api) UpdateOrgSlackSettings(req shared.UpdateOrgSlackSettingsRequest) *shared.ApiError {
+	return callOperation(authenticatedFastClient, shared.ApiOperationUpdateOrgSlackSettings, nil, nil, req, nil)
+}
 
-	return nil
+func (a *Api) InviteUser(req shared.InviteRequest) *shared.ApiError {
+	return callOperation(authenticatedFastClient, shared.ApiOperationInviteUser, nil, nil, req, nil)
 }
 
-func (a *Api) ListUsers() (*shared.ListUsersResponse, *shared.ApiError) {
-	serverUrl := getApiHost() + "/users"
-	resp, err := authenticatedFastClient.Get(serverUrl)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+func (a *Api) ListPendingInvites() ([]*shared.Invite, *shared.ApiError) {
+	var res []*shared.Invite
+	apiErr := callOperation(authenticatedFastClient, shared.ApiOperationListPendingInvites, nil, nil, nil, &res)
+	if apiErr != nil {
+		return nil, apiErr
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		apiErr := handleApiError(resp, errorBody)
-		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
-		if tokenRefreshed {
-			return a.ListUsers()
-		}
+	return res, nil
+}
+
+func (a *Api) ListAcceptedInvites() ([]*shared.Invite, *shared.ApiError) {
+	var res []*shared.Invite
+	apiErr := callOperation(authenticatedFastClient, shared.ApiOperationListAcceptedInvites, nil, nil, nil, &res)
+	if apiErr != nil {
 		return nil, apiErr
 	}
 
-	var r *shared.ListUsersResponse
-	err = json.NewDecoder(resp.Body).Decode(&r)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	return res, nil
+}
+
+func (a *Api) ListAllInvites() ([]*shared.Invite, *shared.ApiError) {
+	var res []*shared.Invite
+	apiErr := callOperation(authenticatedFastClient, shared.ApiOperationListAllInvites, nil, nil, nil, &res)
+	if apiErr != nil {
+		return nil, apiErr
 	}
 
-	return r, nil
+	return res, nil
 }
 
-func (a *Api) ListBranches(planId string) ([]*shared.Branch, *shared.ApiError) {
-	serverUrl := fmt.Sprintf("%s/plans/%s/branches", getApiHost(), planId)
+func (a *Api) DeleteInvite(inviteId string) *shared.ApiError {
+	return callOperation(authenticatedFastClient, shared.ApiOperationDeleteInvite, []string{inviteId}, nil, nil, nil)
+}
 
-	resp, err := authenticatedFastClient.Get(serverUrl)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
+func (a *Api) CreateEmailVerification(email, customHost, userId string) (*shared.CreateEmailVerificationResponse, *shared.ApiError) {
+	var res shared.CreateEmailVerificationResponse
+	apiErr := callUnauthenticatedOperation(accountHost(customHost), shared.ApiOperationCreateEmailVerification, shared.CreateEmailVerificationRequest{Email: email, UserId: userId}, &res)
+	if apiErr != nil {
+		return nil, apiErr
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
+	return &res, nil
+}
+
+func (a *Api) SignOut() *shared.ApiError {
+	return callOperationNoRefresh(authenticatedFastClient, shared.ApiOperationSignOut, nil, nil, nil, nil)
+}
 
-		apiErr := handleApiError(resp, errorBody)
-		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
-		if tokenRefreshed {
-			return a.ListBranches(planId)
-		}
+func (a *Api) ListUsers() (*shared.ListUsersResponse, *shared.ApiError) {
+	var res shared.ListUsersResponse
+	apiErr := callOperation(authenticatedFastClient, shared.ApiOperationListUsers, nil, nil, nil, &res)
+	if apiErr != nil {
 		return nil, apiErr
 	}
 
-	var branches []*shared.Branch
-	err = json.NewDecoder(resp.Body).Decode(&branches)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %s", err)}
+	return &res, nil
+}
+
+func (a *Api) ListBranches(planId string) ([]*shared.Branch, *shared.ApiError) {
+	var res []*shared.Branch
+	apiErr := callOperation(authenticatedFastClient, shared.ApiOperationListBranches, []string{planId}, nil, nil, &res)
+	if apiErr != nil {
+		return nil, apiErr
 	}
 
-	return branches, nil
+	return res, nil
 }
 
 func (a *Api) CreateBranch(planId, branch string, req shared.CreateBranchRequest) *shared.ApiError {
-	serverUrl := fmt.Sprintf("%s/plans/%s/%s/branches", getApiHost(), planId, branch)
+	return callOperation(authenticatedFastClient, shared.ApiOperationCreateBranch, []string{planId, branch}, nil, req, nil)
+}
 
-	reqBytes, err := json.Marshal(req)
-	if err != nil {
-		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %s", err)}
-	}
+func (a *Api) DeleteBranch(planId, branch string) *shared.ApiError {
+	return callOperation(authenticatedFastClient, shared.ApiOperationDeleteBranch, []string{planId, branch}, nil, nil, nil)
+}
 
-	resp, err := authenticatedFastClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
-	if err != nil {
-		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
+func (a *Api) GetSettings(planId, branch string) (*shared.PlanSettings, *shared.ApiError) {
+	var res shared.PlanSettings
+	apiErr := callOperation(authenticatedFastClient, shared.ApiOperationGetSettings, []string{planId, branch}, nil, nil, &res)
+	if apiErr != nil {
+		return nil, apiErr
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
+	return &res, nil
+}
 
-		apiErr := handleApiError(resp, errorBody)
-		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
-		if tokenRefreshed {
-			return a.CreateBranch(planId, branch, req)
-		}
-		return apiErr
+func (a *Api) UpdateSettings(planId, branch string, req shared.UpdateSettingsRequest) (*shared.UpdateSettingsResponse, *shared.ApiError) {
+	var res shared.UpdateSettingsResponse
+	apiErr := callOperation(authenticatedFastClient, shared.ApiOperationUpdateSettings, []string{planId, branch}, nil, req, &res)
+	if apiErr != nil {
+		return nil, apiErr
 	}
 
-	return nil
+	return &res, nil
 }
 
-func (a *Api) DeleteBranch(planId, branch string) *shared.ApiError {
-	serverUrl := fmt.Sprintf("%s/plans/%s/branches/%s", getApiHost(), planId, branch)
-
-	req, err := http.NewRequest(http.MethodDelete, serverUrl, nil)
-	if err != nil {
-		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %s", err)}
+func (a *Api) CheckModels(req shared.CheckModelsRequest) (*shared.CheckModelsResponse, *shared.ApiError) {
+	var res shared.CheckModelsResponse
+	apiErr := callOperation(authenticatedSlowClient, shared.ApiOperationCheckModels, nil, nil, req, &res)
+	if apiErr != nil {
+		return nil, apiErr
 	}
 
-	resp, err := authenticatedFastClient.Do(req)
+	return &res, nil
+}
+
+// CheckHealth calls the server's unauthenticated health endpoint
+func (a *Api) CheckHealth() *shared.ApiError {
+	serverUrl := getApiHost() + "/health"
+	resp, err := unauthenticatedClient.Get(serverUrl)
 	if err != nil {
-		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
 		errorBody, _ := io.ReadAll(resp.Body)
-
-		apiErr := handleApiError(resp, errorBody)
-		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
-		if tokenRefreshed {
-			return a.DeleteBranch(planId, branch)
-		}
-		return apiErr
+		return handleApiError(resp, errorBody)
 	}
 
 	return nil
 }
 
-func (a *Api) GetSettings(planId, branch string) (*shared.PlanSettings, *shared.ApiError) {
-	serverUrl := fmt.Sprintf("%s/plans/%s/%s/settings", getApiHost(), planId, branch)
-
-	resp, err := authenticatedFastClient.Get(serverUrl)
-
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
+func (a *Api) Doctor(planId, branch string) (*shared.DoctorResponse, *shared.ApiError) {
+	var query url.Values
+	if planId != "" {
+		query = url.Values{"planId": {planId}, "branch": {branch}}
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
-		apiErr := handleApiError(resp, errorBody)
-		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
-		if tokenRefreshed {
-			return a.GetSettings(planId, branch)
-		}
+	var res shared.DoctorResponse
+	apiErr := callOperation(authenticatedFastClient, shared.ApiOperationDoctor, nil, query, nil, &res)
+	if apiErr != nil {
 		return nil, apiErr
 	}
 
-	var settings shared.PlanSettings
-	err = json.NewDecoder(resp.Body).Decode(&settings)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %s", err)}
+	return &res, nil
+}
+
+func (a *Api) GenCommitMsg(req shared.GenCommitMsgRequest) (*shared.GenCommitMsgResponse, *shared.ApiError) {
+	var res shared.GenCommitMsgResponse
+	apiErr := callOperation(authenticatedSlowClient, shared.ApiOperationGenCommitMsg, nil, nil, req, &res)
+	if apiErr != nil {
+		return nil, apiErr
 	}
 
-	return &settings, nil
+	return &res, nil
 }
 
-func (a *Api) UpdateSettings(planId, branch string, req shared.UpdateSettingsRequest) (*shared.UpdateSettingsResponse, *shared.ApiError) {
-	serverUrl := fmt.Sprintf("%s/plans/%s/%s/settings", getApiHost(), planId, branch)
-
-	reqBytes, err := json.Marshal(req)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %s", err)}
+func (a *Api) GenPullRequest(req shared.GenPullRequestRequest) (*shared.GenPullRequestResponse, *shared.ApiError) {
+	var res shared.GenPullRequestResponse
+	apiErr := callOperation(authenticatedSlowClient, shared.ApiOperationGenPullRequest, nil, nil, req, &res)
+	if apiErr != nil {
+		return nil, apiErr
 	}
 
-	// log.Println("UpdateSettings", string(reqBytes))
+	return &res, nil
+}
 
-	request, err := http.NewRequest(http.MethodPut, serverUrl, bytes.NewBuffer(reqBytes))
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %s", err)}
+func (a *Api) Review(req shared.ReviewRequest) (*shared.ReviewResponse, *shared.ApiError) {
+	var res shared.ReviewResponse
+	apiErr := callOperation(authenticatedSlowClient, shared.ApiOperationReview, nil, nil, req, &res)
+	if apiErr != nil {
+		return nil, apiErr
 	}
-	request.Header.Set("Content-Type", "application/json")
 
-	resp, err := authenticatedFastClient.Do(request)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %s", err)}
+	return &res, nil
+}
+
+func (a *Api) SecurityScan(req shared.SecurityScanRequest) (*shared.SecurityScanResponse, *shared.ApiError) {
+	var res shared.SecurityScanResponse
+	apiErr := callOperation(authenticatedSlowClient, shared.ApiOperationSecurityScan, nil, nil, req, &res)
+	if apiErr != nil {
+		return nil, apiErr
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode >= 400 {
-		errorBody, _ := io.ReadAll(resp.Body)
+	return &res, nil
+}
 
-		apiErr := handleApiError(resp, errorBody)
-		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
-		if tokenRefreshed {
-			return a.UpdateSettings(planId, branch, req)
-		}
+func (a *Api) GetOrgBudget() (*shared.OrgBudget, *shared.ApiError) {
+	var res shared.OrgBudget
+	apiErr := callOperation(authenticatedFastClient, shared.ApiOperationGetOrgBudget, nil, nil, nil, &res)
+	if apiErr != nil {
 		return nil, apiErr
 	}
 
-	var updateRes shared.UpdateSettingsResponse
-	err = json.NewDecoder(resp.Body).Decode(&updateRes)
-	if err != nil {
-		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %s", err)}
-	}
+	return &res, nil
+}
+
+func (a *Api) UpdateOrgBudget(req shared.UpdateOrgBudgetRequest) *shared.ApiError {
+	return callOperation(authenticatedFastClient, shared.ApiOperationUpdateOrgBudget, nil, nil, req, nil)
+}
 
-	return &updateRes, nil
+// accountHost is where sign in and account creation requests go: the self-hosted server's host if one was given, or the cloud
+func accountHost(customHost string) string {
+	if customHost == "" {
+		return cloudApiHost
+	}
+	return customHost
+}
 
+func sinceQuery(since time.Time) url.Values {
+	if since.IsZero() {
+		return nil
+	}
+	return url.Values{"since": {since.Format(time.RFC3339)}}
 }
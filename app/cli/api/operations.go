@@ -0,0 +1,143 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"plandex/types"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// operationUrl builds the url for an operation described in shared.ApiOperations on the current api host, filling in its path parameters in order
+func operationUrl(op shared.ApiOperation, pathParams []string, query url.Values) string {
+	return operationHostUrl(getApiHost(), op, pathParams, query)
+}
+
+func operationHostUrl(host string, op shared.ApiOperation, pathParams []string, query url.Values) string {
+	serverUrl := host + op.ResolvePath(pathParams...)
+	if len(query) > 0 {
+		serverUrl += "?" + query.Encode()
+	}
+	return serverUrl
+}
+
+// callOperation sends a request for an operation with a JSON response, or no response body if res is nil, refreshing the auth token and retrying once if it's expired
+func callOperation(client *http.Client, op shared.ApiOperation, pathParams []string, query url.Values, body any, res any) *shared.ApiError {
+	return doOperation(client, getApiHost(), op, pathParams, query, body, res, true)
+}
+
+// callOperationNoRefresh is like callOperation, but returns an expired token's error instead of refreshing it. It's used for calls that check or end the session.
+func callOperationNoRefresh(client *http.Client, op shared.ApiOperation, pathParams []string, query url.Values, body any, res any) *shared.ApiError {
+	return doOperation(client, getApiHost(), op, pathParams, query, body, res, false)
+}
+
+// callUnauthenticatedOperation sends a request for an operation that doesn't need a session, like signing in, to the given host
+func callUnauthenticatedOperation(host string, op shared.ApiOperation, body any, res any) *shared.ApiError {
+	return doOperation(unauthenticatedClient, host, op, nil, nil, body, res, false)
+}
+
+func doOperation(client *http.Client, host string, op shared.ApiOperation, pathParams []string, query url.Values, body any, res any, refresh bool) *shared.ApiError {
+	var reqBody io.Reader
+	if body != nil {
+		reqBytes, err := json.Marshal(body)
+		if err != nil {
+			return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
+		}
+		reqBody = bytes.NewBuffer(reqBytes)
+	}
+
+	req, err := http.NewRequest(op.Method, operationHostUrl(host, op, pathParams, query), reqBody)
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %v", err)}
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		if !refresh {
+			return apiErr
+		}
+
+		didRefresh, apiErr := refreshTokenIfNeeded(apiErr)
+		if didRefresh {
+			return doOperation(client, host, op, pathParams, query, body, res, refresh)
+		}
+		return apiErr
+	}
+
+	if res == nil {
+		return nil
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(res)
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return nil
+}
+
+// streamOperation sends a request for a streaming operation. If connect is true, the stream's messages are passed to onStream until it finishes. Otherwise the plan keeps running on the server and the response is closed right away.
+func streamOperation(op shared.ApiOperation, pathParams []string, body any, connect bool, onStream types.OnStreamPlan) *shared.ApiError {
+	var reqBody io.Reader
+	if body != nil {
+		reqBytes, err := json.Marshal(body)
+		if err != nil {
+			return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
+		}
+		reqBody = bytes.NewBuffer(reqBytes)
+	}
+
+	req, err := http.NewRequest(op.Method, operationUrl(op, pathParams, nil), reqBody)
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %v", err)}
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	client := authenticatedFastClient
+	if connect {
+		client = authenticatedStreamingClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		apiErr := handleApiError(resp, errorBody)
+
+		didRefresh, apiErr := refreshTokenIfNeeded(apiErr)
+		if didRefresh {
+			return streamOperation(op, pathParams, body, connect, onStream)
+		}
+		return apiErr
+	}
+
+	if connect {
+		log.Printf("Connecting %s stream\n", op.Id)
+		connectPlanRespStream(resp.Body, onStream)
+	} else {
+		resp.Body.Close()
+	}
+
+	return nil
+}
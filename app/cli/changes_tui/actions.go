@@ -186,7 +186,9 @@ func (m *changesUIModel) updateMainView(scrollReplacement bool) {
 	if m.selectedNewFile() || m.selectedFullFile() {
 		var updatedFile string
 
-		if m.selectedNewFile() {
+		if m.selectedRemovedFile() {
+			updatedFile = "This file will be deleted when the plan is applied."
+		} else if m.selectedNewFile() {
 			updatedFile = m.selectionInfo.currentRes.Content
 		} else {
 			updatedFile = m.currentPlan.CurrentPlanFiles.Files[m.selectionInfo.currentPath]
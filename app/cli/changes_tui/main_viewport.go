@@ -78,7 +78,21 @@ func (m changesUIModel) renderMainViewHeader() string {
 		BorderForeground(borderColor)
 
 	var header string
-	if m.selectedFullFile() {
+	movedFrom := m.currentPlan.CurrentPlanFiles.MovedFrom[m.selectionInfo.currentPath]
+
+	if m.selectedRemovedFile() {
+		header = fmt.Sprintf(" 🗑️  Delete file: %s", m.selectionInfo.currentPath)
+		for path, from := range m.currentPlan.CurrentPlanFiles.MovedFrom {
+			if from == m.selectionInfo.currentPath {
+				header = fmt.Sprintf(" 🚚 Move file: %s → %s", from, path)
+				break
+			}
+		}
+	} else if movedFrom != "" && (m.selectedNewFile() || m.selectedFullFile()) {
+		header = fmt.Sprintf(" 🚚 Moved from %s: %s", movedFrom, m.selectionInfo.currentPath)
+	} else if m.selectedFullFile() && m.unchangedPaths[m.selectionInfo.currentPath] {
+		header = fmt.Sprintf(" ⚪ Unchanged: %s already matches the plan and won't be written", m.selectionInfo.currentPath)
+	} else if m.selectedFullFile() {
 		numChanges := m.currentPlan.PlanResult.NumPendingForPath(m.selectionInfo.currentPath)
 		if m.hasNewFile() {
 			numChanges++
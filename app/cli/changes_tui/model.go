@@ -1,6 +1,8 @@
 package changes_tui
 
 import (
+	"plandex/lib"
+
 	"github.com/charmbracelet/bubbles/help"
 	bubbleKey "github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/spinner"
@@ -17,6 +19,7 @@ type changesUIModel struct {
 	selectedReplacementIndex int
 	selectedViewport         int
 	currentPlan              *shared.CurrentPlanState
+	unchangedPaths           map[string]bool
 	changeOldViewport        viewport.Model
 	changeNewViewport        viewport.Model
 	fileViewport             viewport.Model
@@ -65,6 +68,7 @@ func initialModel(currentPlan *shared.CurrentPlanState) *changesUIModel {
 
 	initialState := changesUIModel{
 		currentPlan:              currentPlan,
+		unchangedPaths:           lib.GetUnchangedPaths(currentPlan),
 		selectedFileIndex:        0,
 		selectedReplacementIndex: 0,
 		help:                     help.New(),
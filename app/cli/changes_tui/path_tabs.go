@@ -32,6 +32,12 @@ func (m changesUIModel) renderPathTabs() string {
 		pathColor := term.ColorHiGreen
 		bgColor := color.BgGreen
 
+		if m.unchangedPaths[paths[i]] {
+			tab = " ⚪ " + path + " (unchanged)  "
+			pathColor = color.FgWhite
+			bgColor = color.BgBlack
+		}
+
 		if selected {
 			tab = color.New(color.Bold, bgColor, color.FgHiWhite).Sprint(tab)
 		} else {
@@ -1,6 +1,7 @@
 package changes_tui
 
 import (
+	"plandex/lib"
 	"plandex/types"
 	"time"
 
@@ -53,6 +54,7 @@ func (m changesUIModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 		m.currentPlan = msg.planState
+		m.unchangedPaths = lib.GetUnchangedPaths(msg.planState)
 
 		if len(msg.planState.PlanResult.SortedPaths) == 0 {
 			return m, tea.Quit
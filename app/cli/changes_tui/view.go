@@ -184,6 +184,10 @@ func (m changesUIModel) selectedNewFile() bool {
 		m.selectionInfo.currentRes.Content != ""
 }
 
+func (m changesUIModel) selectedRemovedFile() bool {
+	return m.selectedFullFile() && m.currentPlan.CurrentPlanFiles.Removed[m.selectionInfo.currentPath]
+}
+
 func (m changesUIModel) selectedFullFile() bool {
 	return !m.selectedNewFile() && m.selectionInfo != nil && m.selectionInfo.currentRep == nil
 }
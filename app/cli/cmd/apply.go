@@ -4,16 +4,37 @@ import (
 	"fmt"
 	"plandex/auth"
 	"plandex/lib"
+	"plandex/term"
 
 	"github.com/spf13/cobra"
 )
 
 var autoConfirm bool
+var noVerify bool
+var autoCommit bool
+var applyGitBranch string
+var applyStash bool
+var applySandbox bool
+var applyAllowRisky bool
+var applyNoCommit bool
+var applyNoPrompt bool
+var applyCommentIssue bool
 
 func init() {
 	applyCmd.Flags().BoolVarP(&autoConfirm, "yes", "y", false, "Automatically confirm unless plan is outdated")
+	applyCmd.Flags().BoolVarP(&autoCommit, "commit", "c", false, "Commit the updated files with a generated message without asking")
+	applyCmd.Flags().StringVar(&applyGitBranch, "branch", "", "Switch to this git branch, creating it if needed, and commit the changes there")
+	applyCmd.Flags().BoolVar(&applyNoCommit, "no-commit", false, "Leave the updated files uncommitted without asking")
+	applyCmd.Flags().BoolVar(&applyStash, "stash", false, "Stash uncommitted changes, apply and commit the plan, then restore them on top")
+	applyCmd.Flags().BoolVar(&applySandbox, "sandbox", false, "Apply and verify in a temporary git worktree, copying the changes back only once verification passes")
+	applyCmd.Flags().BoolVar(&applyAllowRisky, "allow-risky", false, "Apply files flagged for risky operations like network calls or shell commands without asking")
+	applyCmd.Flags().BoolVar(&applyNoPrompt, "no-prompt", false, "Fail instead of asking about files flagged for risky operations")
+	applyCmd.Flags().BoolVar(&applyCommentIssue, "comment-issue", false, "Comment on the issues linked to the plan with a summary of the applied changes")
+	applyCmd.Flags().BoolVar(&noVerify, "no-verify", false, "Skip the project's verification command after applying")
 
 	RootCmd.AddCommand(applyCmd)
+
+	addNotifyFlag(applyCmd)
 }
 
 var applyCmd = &cobra.Command{
@@ -32,5 +53,64 @@ func apply(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	lib.MustApplyPlan(lib.CurrentPlanId, lib.CurrentBranch, autoConfirm)
+	flags := lib.ApplyFlags{
+		AutoConfirm: autoConfirm,
+		AutoCommit:  autoCommit,
+		GitBranch:   applyGitBranch,
+		Stash:       applyStash,
+		AllowRisky:  applyAllowRisky,
+		NoCommit:    applyNoCommit,
+		NoPrompt:    applyNoPrompt,
+	}
+
+	if applySandbox {
+		if applyCommentIssue {
+			term.OutputErrorAndExit("--comment-issue can't be used with --sandbox")
+		}
+
+		settings, err := lib.LoadProjectSettings()
+		if err != nil {
+			term.OutputErrorAndExit("Error loading project settings: %v", err)
+		}
+
+		if settings.VerifyCmd == "" || noVerify {
+			term.OutputErrorAndExit("Applying in a sandbox needs the project's verification command to decide whether to keep the changes. Set one with 'plandex verify --set <command>'")
+		}
+		lib.MustCheckProjectCommandsApproved(settings)
+
+		lib.MustApplyInSandbox(lib.CurrentPlanId, lib.CurrentBranch, flags, settings.VerifyCmd, getMaxVerifyFixes(settings))
+		return
+	}
+
+	applied := lib.MustApplyPlan(lib.CurrentPlanId, lib.CurrentBranch, flags)
+
+	if applied && applyCommentIssue {
+		// after verification, so changes that fix verification failures are included. Also an exit hook, since the verify loop can exit early once changes are already applied.
+		commentOnIssues := func() { lib.MustCommentOnPlanIssues(lib.CurrentPlanId, lib.CurrentBranch) }
+		removeHook := term.OnExit(commentOnIssues)
+		defer func() {
+			removeHook()
+			commentOnIssues()
+		}()
+	}
+
+	if !applied || noVerify {
+		return
+	}
+
+	settings, err := lib.LoadProjectSettings()
+	if err != nil {
+		term.OutputErrorAndExit("Error loading project settings: %v", err)
+	}
+
+	if settings.VerifyCmd != "" {
+		lib.MustCheckProjectCommandsApproved(settings)
+		lib.MustRunVerifyLoop(lib.CurrentPlanId, lib.CurrentBranch, settings.VerifyCmd, getMaxVerifyFixes(settings), lib.ApplyFlags{
+			AutoConfirm: true,
+			AutoCommit:  autoCommit || applyGitBranch != "" || applyStash,
+			NoCommit:    applyNoCommit,
+			AllowRisky:  applyAllowRisky,
+			NoPrompt:    applyNoPrompt,
+		})
+	}
 }
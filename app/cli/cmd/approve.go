@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+	"plandex/types"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	RootCmd.AddCommand(approveCmd)
+}
+
+var approveCmd = &cobra.Command{
+	Use:   "approve",
+	Short: "Approve the project's commands to run on this machine",
+	Long: `Show the project's hooks, formatters, linters, and verify and test commands, and approve them to run on this machine.
+
+The commands come from .plandex/project.json, so they can arrive with a clone or someone else's commit. None of them run until they're approved, and any change to them needs approval again. Changes made with plandex commands, like 'plandex hooks add', keep the commands approved if they were approved before.`,
+	Args: cobra.NoArgs,
+	Run:  approve,
+}
+
+func approve(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	settings, err := lib.LoadProjectSettings()
+	if err != nil {
+		term.OutputErrorAndExit("Error loading project settings: %v", err)
+	}
+
+	lines := lib.ProjectCommandLines(settings)
+	if len(lines) == 0 {
+		fmt.Println("🤷‍♂️ The project has no commands to approve")
+		return
+	}
+
+	approved, err := lib.IsProjectCommandsApproved(settings)
+	if err != nil {
+		term.OutputErrorAndExit("Error checking whether the project's commands are approved: %v", err)
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Runs as", "Command"})
+	for _, line := range lines {
+		table.Append(line[:])
+	}
+	table.Render()
+	fmt.Println()
+
+	if approved {
+		fmt.Println("✅ These commands are approved to run on this machine")
+		return
+	}
+
+	confirmed, err := term.ConfirmYesNo("Allow them to run on this machine?")
+	if err != nil {
+		term.OutputErrorAndExit("Error getting confirmation: %v", err)
+	}
+	if !confirmed {
+		return
+	}
+
+	err = lib.ApproveProjectCommands(settings)
+	if err != nil {
+		term.OutputErrorAndExit("Error approving the project's commands: %v", err)
+	}
+
+	fmt.Println("✅ Approved the project's commands")
+}
+
+// projectCommandsApproved is checked before a plandex command changes the project's commands, so the change keeps them approved only if they already were
+func projectCommandsApproved(settings *types.CurrentProjectSettings) bool {
+	approved, err := lib.IsProjectCommandsApproved(settings)
+	if err != nil {
+		term.OutputErrorAndExit("Error checking whether the project's commands are approved: %v", err)
+	}
+	return approved
+}
@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/fs"
+	"plandex/lib"
+	"plandex/term"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+// prompt and reply excerpts are cut off after this many lines
+const blameExcerptLines = 12
+
+var blameCmd = &cobra.Command{
+	Use:   "blame <file> [line or range]",
+	Short: "Show the prompts and replies that produced a file's lines",
+	Long: `Show the prompts and replies that produced a file's lines, e.g. 'plandex blame main.go 10-20'.
+
+Lines are traced through git blame to the commits made when applying plans, so only changes that were committed by 'plandex apply' can be traced.`,
+	Args: cobra.RangeArgs(1, 2),
+	Run:  blame,
+}
+
+func init() {
+	RootCmd.AddCommand(blameCmd)
+}
+
+type blameGroup struct {
+	start, end int
+	sha        string
+}
+
+func blame(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if !fs.ProjectRootIsGitRepo() {
+		term.OutputErrorAndExit("The project isn't in a git repository")
+	}
+
+	absPath, err := filepath.Abs(args[0])
+	if err != nil {
+		term.OutputErrorAndExit("Error resolving path: %v", err)
+	}
+	path, err := filepath.Rel(fs.ProjectRoot, absPath)
+	if err != nil || strings.HasPrefix(path, "..") {
+		term.OutputErrorAndExit("%s isn't in the project", args[0])
+	}
+
+	start, end := 1, 0
+	if len(args) > 1 {
+		start, end, err = lib.ParseLineRange(args[1])
+		if err != nil {
+			term.OutputErrorAndExit("%v", err)
+		}
+	}
+
+	term.StartSpinner("")
+
+	lines, err := lib.GitBlameLines(path, start, end)
+	if err != nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("%v", err)
+	}
+
+	commits, err := lib.GetProjectAppliedCommits()
+	if err != nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("Error loading applied commits: %v", err)
+	}
+
+	var groups []*blameGroup
+	for _, line := range lines {
+		last := len(groups) - 1
+		if last >= 0 && groups[last].sha == line.Sha && groups[last].end == line.Num-1 {
+			groups[last].end = line.Num
+		} else {
+			groups = append(groups, &blameGroup{start: line.Num, end: line.Num, sha: line.Sha})
+		}
+	}
+
+	convosByPlanBranch := map[string][]*shared.ConvoMessage{}
+	planNames := map[string]string{}
+
+	getConvo := func(planId, branch string) []*shared.ConvoMessage {
+		key := planId + "|" + branch
+		if convo, ok := convosByPlanBranch[key]; ok {
+			return convo
+		}
+		convo, apiErr := api.Client.ListConvo(planId, branch)
+		if apiErr != nil {
+			term.StopSpinner()
+			term.OutputErrorAndExit("Error getting conversation: %v", apiErr.Msg)
+		}
+		convosByPlanBranch[key] = convo
+		return convo
+	}
+
+	getPlanName := func(planId string) string {
+		if name, ok := planNames[planId]; ok {
+			return name
+		}
+		plan, apiErr := api.Client.GetPlan(planId)
+		name := planId
+		if apiErr == nil {
+			name = plan.Name
+		}
+		planNames[planId] = name
+		return name
+	}
+
+	var out strings.Builder
+	numTraced := 0
+
+	for _, group := range groups {
+		lineLabel := fmt.Sprintf("Line %d", group.start)
+		if group.end > group.start {
+			lineLabel = fmt.Sprintf("Lines %d-%d", group.start, group.end)
+		}
+
+		applied := commits[group.sha]
+		if applied == nil {
+			fmt.Fprintf(&out, "%s · %s · %s\n\n", color.New(color.Bold).Sprint(lineLabel), group.sha[:7], color.New(color.FgHiBlack).Sprint("not from a plan"))
+			continue
+		}
+		numTraced++
+
+		fmt.Fprintf(&out, "%s · %s · plan %s (%s)\n",
+			color.New(color.Bold, term.ColorHiCyan).Sprint(lineLabel),
+			group.sha[:7],
+			color.New(color.Bold).Sprint(getPlanName(applied.PlanId)),
+			applied.Commit.Branch,
+		)
+
+		convo := getConvo(applied.PlanId, applied.Commit.Branch)
+		for _, convoMessageId := range applied.Commit.ConvoMessageIdsByPath[filepath.ToSlash(path)] {
+			prompt, reply := findBlamePromptAndReply(convo, convoMessageId)
+			if reply == nil {
+				continue
+			}
+
+			if prompt != nil {
+				color.New(color.Bold, term.ColorHiMagenta).Fprintln(&out, "💬 Prompt")
+				fmt.Fprintln(&out, blameExcerpt(prompt.Message, ""))
+			}
+			color.New(color.Bold, term.ColorHiGreen).Fprintln(&out, "🤖 Reply")
+			fmt.Fprintln(&out, blameExcerpt(reply.Message, path))
+		}
+		fmt.Fprintln(&out)
+	}
+
+	term.StopSpinner()
+
+	if numTraced == 0 {
+		fmt.Println("🤷‍♂️ None of these lines were committed by applying a plan")
+		return
+	}
+
+	term.PageOutput(out.String())
+}
+
+// findBlamePromptAndReply returns a reply and the user message it answered
+func findBlamePromptAndReply(convo []*shared.ConvoMessage, replyId string) (*shared.ConvoMessage, *shared.ConvoMessage) {
+	var prompt *shared.ConvoMessage
+	for _, msg := range convo {
+		if msg.Role == "user" {
+			prompt = msg
+		} else if msg.Id == replyId {
+			return prompt, msg
+		}
+	}
+	return nil, nil
+}
+
+// blameExcerpt returns the start of a message, or the part starting at the first mention of near if it's set and found
+func blameExcerpt(msg, near string) string {
+	lines := strings.Split(strings.TrimSpace(msg), "\n")
+
+	from := 0
+	if near != "" {
+		for i, line := range lines {
+			if strings.Contains(line, near) {
+				from = i
+				break
+			}
+		}
+	}
+
+	to := min(from+blameExcerptLines, len(lines))
+	res := "  " + strings.Join(lines[from:to], "\n  ")
+	if to < len(lines) {
+		res += "\n  ..."
+	}
+	return res
+}
@@ -2,8 +2,9 @@ package cmd
 
 import (
 	"fmt"
-	"os"
+	"path/filepath"
 	"plandex/auth"
+	"plandex/fs"
 	"plandex/lib"
 	"plandex/plan_exec"
 	"plandex/term"
@@ -13,6 +14,7 @@ import (
 )
 
 var buildBg bool
+var buildDryRun bool
 
 var buildCmd = &cobra.Command{
 	Use:     "build",
@@ -26,10 +28,13 @@ var buildCmd = &cobra.Command{
 func init() {
 	RootCmd.AddCommand(buildCmd)
 	buildCmd.Flags().BoolVar(&buildBg, "bg", false, "Execute autonomously in the background")
+	buildCmd.Flags().BoolVar(&buildDryRun, "dry-run", false, "Save drafted files and diffs under the .plandex directory for review, without touching project files")
+
+	addNotifyFlag(buildCmd)
 }
 
 func build(cmd *cobra.Command, args []string) {
-	if os.Getenv("OPENAI_API_KEY") == "" {
+	if !lib.HasApiKey() {
 		term.OutputNoApiKeyMsgAndExit()
 	}
 
@@ -41,6 +46,10 @@ func build(cmd *cobra.Command, args []string) {
 		return
 	}
 
+	if buildDryRun && buildBg {
+		term.OutputErrorAndExit("--dry-run can't be used with --bg")
+	}
+
 	didBuild, err := plan_exec.Build(plan_exec.ExecParams{
 		CurrentPlanId: lib.CurrentPlanId,
 		CurrentBranch: lib.CurrentBranch,
@@ -53,6 +62,36 @@ func build(cmd *cobra.Command, args []string) {
 		term.OutputErrorAndExit("Error building plan: %v", err)
 	}
 
+	if buildDryRun {
+		// builds never write to the project, so a dry run is a build followed by saving what apply would write
+		term.StartSpinner("")
+		dir, numFiles, err := lib.WriteDryRun(lib.CurrentPlanId, lib.CurrentBranch)
+		term.StopSpinner()
+
+		if err != nil {
+			term.OutputErrorAndExit("Error saving dry run: %v", err)
+		}
+
+		if rel, err := filepath.Rel(fs.ProjectRoot, dir); err == nil {
+			dir = rel
+		}
+
+		suffix := ""
+		if numFiles != 1 {
+			suffix = "s"
+		}
+
+		fmt.Println()
+		fmt.Printf("🧪 Dry run saved to %s\n", dir)
+		fmt.Printf("• %d drafted file%s in %s\n", numFiles, suffix, filepath.Join(dir, "files"))
+		fmt.Printf("• diff in %s\n", filepath.Join(dir, "changes.diff"))
+		fmt.Println()
+		fmt.Println("No project files were changed")
+		fmt.Println()
+		term.PrintCmds("", "changes", "apply")
+		return
+	}
+
 	if !didBuild {
 		fmt.Println()
 		term.PrintCmds("", "log", "tell", "continue")
@@ -64,6 +103,8 @@ func build(cmd *cobra.Command, args []string) {
 		fmt.Println()
 		term.PrintCmds("", "ps", "connect", "stop")
 	} else {
+		lib.MaybeRunLintLoop(lib.CurrentPlanId, lib.CurrentBranch)
+
 		fmt.Println()
 		term.PrintCmds("", "changes", "apply", "log")
 	}
@@ -23,6 +23,7 @@ var cdCmd = &cobra.Command{
 	Use:     "cd [name-or-index]",
 	Aliases: []string{"set-plan"},
 	Short:   "Set current plan by name or index",
+	Long:    `Set the current plan by name or index. Plans your teammates have shared with the org can be opened by name too, even from another project, so you can review and apply them in your own checkout.`,
 	Args:    cobra.MaximumNArgs(1),
 	Run:     cd,
 }
@@ -46,18 +47,40 @@ func cd(cmd *cobra.Command, args []string) {
 		term.OutputErrorAndExit("Error getting plans: %v", apiErr)
 	}
 
-	if len(plans) == 0 {
-		fmt.Println("🤷‍♂️ No plans")
-		fmt.Println()
-		term.PrintCmds("", "new")
-		return
-	}
-
 	if nameOrIdx == "" {
+		// plans teammates shared from other projects can be opened here too
+		term.StartSpinner("")
+		sharedPlans, apiErr := api.Client.ListSharedPlans()
+		term.StopSpinner()
+
+		if apiErr != nil {
+			term.OutputErrorAndExit("Error getting shared plans: %v", apiErr)
+		}
+
+		for _, p := range sharedPlans {
+			if p.ProjectId != lib.CurrentProjectId {
+				plans = append(plans, p)
+			}
+		}
+
+		if len(plans) == 0 {
+			fmt.Println("🤷‍♂️ No plans")
+			fmt.Println()
+			term.PrintCmds("", "new")
+			return
+		}
+
+		ownerNames := lib.GetPlanOwnerNames(plans)
 
 		opts := make([]string, len(plans))
+		plansByOpt := make(map[string]*shared.Plan, len(plans))
 		for i, plan := range plans {
-			opts[i] = plan.Name
+			opt := plan.Name
+			if !lib.IsOwnPlan(plan) {
+				opt = fmt.Sprintf("%s (%s)", plan.Name, lib.PlanOwnerLabel(plan, ownerNames))
+			}
+			opts[i] = opt
+			plansByOpt[opt] = plan
 		}
 
 		selected, err := term.SelectFromList("Select a plan", opts)
@@ -66,12 +89,7 @@ func cd(cmd *cobra.Command, args []string) {
 			term.OutputErrorAndExit("Error selecting plan: %v", err)
 		}
 
-		for _, p := range plans {
-			if p.Name == selected {
-				plan = p
-				break
-			}
-		}
+		plan = plansByOpt[selected]
 	} else {
 		// see if it's an index
 		idx, err := strconv.Atoi(nameOrIdx)
@@ -83,10 +101,15 @@ func cd(cmd *cobra.Command, args []string) {
 				term.OutputErrorAndExit("Plan index out of range")
 			}
 		} else {
-			for _, p := range plans {
-				if p.Name == nameOrIdx {
-					plan = p
-					break
+			plan = lib.FindPlan(plans, nameOrIdx)
+
+			if plan == nil {
+				term.StartSpinner("")
+				plan, apiErr = lib.FindSharedPlan(nameOrIdx)
+				term.StopSpinner()
+
+				if apiErr != nil {
+					term.OutputErrorAndExit("Error getting shared plans: %v", apiErr)
 				}
 			}
 		}
@@ -113,6 +136,14 @@ func cd(cmd *cobra.Command, args []string) {
 
 	fmt.Println("✅ Changed current plan to " + color.New(term.ColorHiGreen, color.Bold).Sprint(plan.Name))
 
+	if !lib.IsOwnPlan(plan) {
+		fmt.Printf("👥 %s\n", lib.PlanOwnerLabel(plan, lib.GetPlanOwnerNames([]*shared.Plan{plan})))
+	}
+
+	if override := lib.GetPlanOverride(); override != "" {
+		fmt.Printf("⚠️  Commands in this terminal will still use %s until PLANDEX_PLAN is unset\n", color.New(color.Bold).Sprint(override))
+	}
+
 	fmt.Println()
 	term.PrintCmds("", "current")
 }
@@ -15,6 +15,8 @@ import (
 
 func init() {
 	RootCmd.AddCommand(changesCmd)
+
+	addNotifyFlag(changesCmd)
 }
 
 var changesCmd = &cobra.Command{
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/plan_exec"
+	"plandex/term"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var chatPromptFile string
+var chatBg bool
+
+var chatCmd = &cobra.Command{
+	Use:   "chat [prompt]",
+	Short: "Ask questions or discuss code without making a plan or changing files",
+	Long: `Ask questions, discuss designs, or get code explained using the plan's context and conversation.
+
+Chat replies are added to the plan's conversation, so a later 'plandex tell' can build on them, but they never describe or build changes to files. Replies use the chat model, which is cheaper than the planner by default. Change it with 'plandex set-model chat'.`,
+	Args: cobra.RangeArgs(0, 1),
+	Run:  doChat,
+}
+
+func init() {
+	RootCmd.AddCommand(chatCmd)
+
+	chatCmd.Flags().StringVarP(&chatPromptFile, "file", "f", "", "File containing prompt")
+	chatCmd.Flags().BoolVar(&chatBg, "bg", false, "Execute in the background")
+
+	addNotifyFlag(chatCmd)
+}
+
+func doChat(cmd *cobra.Command, args []string) {
+	if !lib.HasApiKey() {
+		term.OutputNoApiKeyMsgAndExit()
+	}
+
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		fmt.Println("🤷‍♂️ No current plan")
+		return
+	}
+
+	var prompt string
+
+	if len(args) > 0 {
+		prompt = args[0]
+	} else if chatPromptFile != "" {
+		bytes, err := os.ReadFile(chatPromptFile)
+		if err != nil {
+			term.OutputErrorAndExit("Error reading prompt file: %v", err)
+		}
+		prompt = string(bytes)
+	} else {
+		prompt = getEditorPrompt()
+	}
+
+	if prompt == "" {
+		fmt.Println("🤷‍♂️ No prompt to send")
+		return
+	}
+
+	plan_exec.TellPlan(plan_exec.ExecParams{
+		CurrentPlanId: lib.CurrentPlanId,
+		CurrentBranch: lib.CurrentBranch,
+		CheckOutdatedContext: func(maybeContexts []*shared.Context) (bool, bool) {
+			return lib.MustCheckOutdatedContext(false, maybeContexts)
+		},
+		ChatOnly: true,
+	}, prompt, chatBg, true, true, false)
+}
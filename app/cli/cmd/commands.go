@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"fmt"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+
+	"github.com/fatih/color"
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var commandsList bool
+
+var commandsCmd = &cobra.Command{
+	Use:     "commands",
+	Aliases: []string{"cmds"},
+	Short:   "Run the shell commands the plan suggested",
+	Long: `Run the shell commands the plan's replies suggested, like installing dependencies or running migrations.
+
+Each command is shown before it runs and only runs once you confirm it. Its output is added to the plan's conversation, so the next reply knows how it went. Skipped commands are offered again next time.`,
+	Args: cobra.NoArgs,
+	Run:  commands,
+}
+
+func init() {
+	RootCmd.AddCommand(commandsCmd)
+
+	commandsCmd.Flags().BoolVarP(&commandsList, "list", "l", false, "List the commands that haven't been run without running them")
+}
+
+func commands(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		fmt.Println("🤷‍♂️ No current plan")
+		return
+	}
+
+	term.StartSpinner("")
+	state, apiErr := api.Client.GetCurrentPlanState(lib.CurrentPlanId, lib.CurrentBranch)
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting current plan state: %v", apiErr.Msg)
+	}
+
+	pending := lib.GetPendingCommands(state.ConvoMessageDescriptions)
+
+	if len(pending) == 0 {
+		fmt.Println("🤷‍♂️ No commands to run")
+		return
+	}
+
+	if commandsList {
+		for i, command := range pending {
+			printPlanCommand(i+1, command.PlanCommand)
+		}
+		fmt.Println()
+		term.PrintCmds("", "commands")
+		return
+	}
+
+	var numRan int
+	for i, command := range pending {
+		fmt.Println()
+		printPlanCommand(i+1, command.PlanCommand)
+		fmt.Println()
+
+		run, canceled, err := term.ConfirmYesNoCancel("Run it?")
+		if err != nil {
+			term.OutputErrorAndExit("Error getting confirmation: %v", err)
+		}
+		if canceled {
+			break
+		}
+		if !run {
+			continue
+		}
+
+		fmt.Println()
+		output, exitCode, err := lib.RunPlanCommand(command.Command)
+		fmt.Println()
+
+		if err != nil {
+			term.OutputErrorAndExit("Error running command: %v", err)
+		}
+
+		if exitCode == 0 {
+			fmt.Println("✅ Command succeeded")
+		} else {
+			color.New(color.Bold, term.ColorHiRed).Printf("❌ Command failed with exit code %d\n", exitCode)
+		}
+
+		term.StartSpinner("")
+		apiErr := api.Client.RecordCommandResult(lib.CurrentPlanId, lib.CurrentBranch, shared.RecordCommandResultRequest{
+			ConvoMessageId: command.ConvoMessageId,
+			CommandIndex:   command.Index,
+			ExitCode:       exitCode,
+			Output:         output,
+		})
+		term.StopSpinner()
+
+		if apiErr != nil {
+			term.OutputErrorAndExit("Error adding command output to the plan: %v", apiErr.Msg)
+		}
+
+		numRan++
+	}
+
+	if numRan > 0 {
+		fmt.Println()
+		fmt.Printf("📝 Output of %d command(s) added to the conversation\n", numRan)
+		fmt.Println()
+		term.PrintCmds("", "tell", "continue", "convo")
+	}
+}
+
+func printPlanCommand(num int, command *shared.PlanCommand) {
+	fmt.Printf("%d. %s\n", num, color.New(color.Bold, term.ColorHiCyan).Sprint(command.Command))
+	if command.Reason != "" {
+		fmt.Printf("   %s\n", command.Reason)
+	}
+}
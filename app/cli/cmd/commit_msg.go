@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/fs"
+	"plandex/lib"
+	"plandex/term"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var commitMsgFromPlan bool
+var commitMsgConventional bool
+
+var commitMsgCmd = &cobra.Command{
+	Use:   "commit-msg",
+	Short: "Generate a commit message for staged changes",
+	Long: `Generate a commit message for the staged changes in the project's git repository, or for the current plan's pending changes with --from-plan.
+
+The message is printed on its own, so it can be passed to git, e.g. git commit -m "$(plandex commit-msg)"`,
+	Args: cobra.NoArgs,
+	Run:  commitMsg,
+}
+
+func init() {
+	RootCmd.AddCommand(commitMsgCmd)
+	commitMsgCmd.Flags().BoolVar(&commitMsgFromPlan, "from-plan", false, "Use the current plan's pending changes instead of the staged diff")
+	commitMsgCmd.Flags().BoolVar(&commitMsgConventional, "conventional", false, "Format the message as a conventional commit")
+}
+
+func commitMsg(cmd *cobra.Command, args []string) {
+	apiKey := os.Getenv("OPENAI_API_KEY")
+	if apiKey == "" {
+		term.OutputNoApiKeyMsgAndExit()
+	}
+
+	auth.MustResolveAuthWithOrg()
+	lib.MaybeResolveProject()
+
+	var diff string
+	var err error
+	if commitMsgFromPlan {
+		if lib.CurrentPlanId == "" {
+			fmt.Println("🤷‍♂️ No current plan")
+			return
+		}
+
+		diff, err = lib.GetPlanDiff(lib.CurrentPlanId, lib.CurrentBranch)
+		if err != nil {
+			term.OutputErrorAndExit("Error getting plan diff: %v", err)
+		}
+
+		if strings.TrimSpace(diff) == "" {
+			fmt.Println("🤷‍♂️ The plan has no pending changes")
+			return
+		}
+	} else {
+		dir := fs.ProjectRoot
+		if dir == "" {
+			dir = fs.Cwd
+		}
+
+		diff, err = lib.GitStagedDiff(dir)
+		if err != nil {
+			term.OutputErrorAndExit("%v", err)
+		}
+
+		if strings.TrimSpace(diff) == "" {
+			fmt.Println("🤷‍♂️ No staged changes")
+			return
+		}
+	}
+
+	modelSet := lib.MustGetPlanModelSet()
+
+	res, apiErr := api.Client.GenCommitMsg(shared.GenCommitMsgRequest{
+		ApiKey:       apiKey,
+		ModelSet:     modelSet,
+		Diff:         diff,
+		Conventional: commitMsgConventional,
+	})
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error generating commit message: %v", apiErr.Msg)
+	}
+
+	fmt.Println(res.CommitMsg)
+}
@@ -0,0 +1,240 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/fs"
+	"plandex/lib"
+	"plandex/term"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var compareModels []string
+var comparePromptFile string
+var compareBuilds bool
+
+var compareCmd = &cobra.Command{
+	Use:   "compare [prompt]",
+	Short: "Run the same prompt against two models and compare the results",
+	Args:  cobra.RangeArgs(0, 1),
+	Run:   compare,
+}
+
+func init() {
+	RootCmd.AddCommand(compareCmd)
+
+	compareCmd.Flags().StringSliceVarP(&compareModels, "models", "m", nil, "The two models to compare, separated by a comma")
+	compareCmd.Flags().StringVarP(&comparePromptFile, "file", "f", "", "File containing prompt")
+	compareCmd.Flags().BoolVarP(&compareBuilds, "builds", "b", false, "Also use each model as the builder")
+}
+
+func compare(cmd *cobra.Command, args []string) {
+	if !lib.HasApiKey() {
+		term.OutputNoApiKeyMsgAndExit()
+	}
+
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		fmt.Println("🤷‍♂️ No current plan")
+		return
+	}
+
+	if len(compareModels) != 2 {
+		term.OutputErrorAndExit("Specify exactly two models with --models, e.g. --models gpt-4-turbo,gpt-4o")
+	}
+
+	if compareModels[0] == compareModels[1] {
+		term.OutputErrorAndExit("Specify two different models to compare")
+	}
+
+	for _, modelName := range compareModels {
+		if _, ok := shared.AvailableModelsByName[modelName]; !ok {
+			term.OutputErrorAndExit("Unknown model: %s. Run 'plandex models available' to see available models.", modelName)
+		}
+
+		if compareBuilds && !shared.GetModelCapabilities(modelName).ToolCalls {
+			term.OutputErrorAndExit("%s doesn't support tool calls, so it can't be used for builds", modelName)
+		}
+	}
+
+	var prompt string
+	if len(args) > 0 {
+		prompt = args[0]
+	} else if comparePromptFile != "" {
+		bytes, err := os.ReadFile(comparePromptFile)
+		if err != nil {
+			term.OutputErrorAndExit("Error reading prompt file: %v", err)
+		}
+		prompt = string(bytes)
+	} else {
+		prompt = getEditorPrompt()
+	}
+
+	if prompt == "" {
+		fmt.Println("🤷‍♂️ No prompt to send")
+		return
+	}
+
+	term.StartSpinner("")
+	contexts, apiErr := api.Client.ListContext(lib.CurrentPlanId, lib.CurrentBranch)
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting context: %v", apiErr.Msg)
+	}
+
+	anyOutdated, didUpdate := lib.MustCheckOutdatedContext(false, contexts)
+	if anyOutdated && !didUpdate {
+		term.StopSpinner()
+		fmt.Println("Prompt not sent")
+		return
+	}
+
+	paths, err := fs.GetProjectPaths(fs.GetBaseDirForContexts(contexts))
+	if err != nil {
+		term.OutputErrorAndExit("Error getting project paths: %v", err)
+	}
+
+	baseModelSet, _, err := lib.GetModelSet(lib.CurrentPlanId, lib.CurrentBranch)
+	if err != nil {
+		term.OutputErrorAndExit("%v", err)
+	}
+	term.StopSpinner()
+
+	// run one at a time so latency numbers aren't skewed by the other model's requests
+	ts := time.Now().Unix()
+	var results []*lib.CompareResult
+	for _, modelName := range compareModels {
+		term.StartSpinner(fmt.Sprintf("⚖️  Running %s...", modelName))
+		res := lib.RunComparison(lib.CompareParams{
+			Prompt:        prompt,
+			ModelName:     modelName,
+			Branch:        fmt.Sprintf("compare-%s-%d", modelName, ts),
+			ProjectPaths:  paths.ActivePaths,
+			CompareBuilds: compareBuilds,
+			BaseModelSet:  *baseModelSet,
+		})
+		term.StopSpinner()
+		results = append(results, res)
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Model", "Branch", "Latency", "First Reply", "Prompt Tokens", "Completion Tokens", "Files"})
+	for _, res := range results {
+		if res.Err != nil {
+			table.Append([]string{res.ModelName, res.Branch, "❌ " + res.Err.Error(), "", "", "", ""})
+			continue
+		}
+		table.Append([]string{
+			res.ModelName,
+			res.Branch,
+			res.Latency.Round(time.Millisecond).String(),
+			res.TimeToFirstChunk.Round(time.Millisecond).String(),
+			strconv.Itoa(res.Usage.PromptTokens),
+			strconv.Itoa(res.Usage.CompletionTokens),
+			strconv.Itoa(len(res.Files)),
+		})
+	}
+	fmt.Println()
+	table.Render()
+	fmt.Println()
+
+	a, b := results[0], results[1]
+	if a.Err == nil && b.Err == nil {
+		printCompareDiffs(a, b)
+	}
+
+	term.PrintCmds("", "checkout", "changes")
+}
+
+func printCompareDiffs(a, b *lib.CompareResult) {
+	pathsSet := map[string]bool{}
+	for path := range a.Files {
+		pathsSet[path] = true
+	}
+	for path := range b.Files {
+		pathsSet[path] = true
+	}
+
+	if len(pathsSet) == 0 {
+		fmt.Println("🤷‍♂️ Neither model made any file changes")
+		return
+	}
+
+	var paths []string
+	for path := range pathsSet {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	tempDir, err := os.MkdirTemp("", "plandex-compare-*")
+	if err != nil {
+		term.OutputErrorAndExit("Error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	for _, path := range paths {
+		color.New(color.Bold).Printf("📄 %s\n", path)
+
+		aContent, aOk := a.Files[path]
+		bContent, bOk := b.Files[path]
+
+		if !aOk {
+			fmt.Printf("Only changed by %s\n\n", b.ModelName)
+			continue
+		}
+		if !bOk {
+			fmt.Printf("Only changed by %s\n\n", a.ModelName)
+			continue
+		}
+		if aContent == bContent {
+			fmt.Print("Identical\n\n")
+			continue
+		}
+
+		diff, err := diffContents(tempDir, a.ModelName, aContent, b.ModelName, bContent)
+		if err != nil {
+			term.OutputErrorAndExit("Error diffing %s: %v", path, err)
+		}
+		fmt.Println(diff)
+	}
+}
+
+func diffContents(tempDir, aName, aContent, bName, bContent string) (string, error) {
+	aPath := filepath.Join(tempDir, aName)
+	bPath := filepath.Join(tempDir, bName)
+
+	err := os.WriteFile(aPath, []byte(aContent), 0644)
+	if err != nil {
+		return "", fmt.Errorf("error writing temp file: %v", err)
+	}
+	err = os.WriteFile(bPath, []byte(bContent), 0644)
+	if err != nil {
+		return "", fmt.Errorf("error writing temp file: %v", err)
+	}
+
+	// git diff --no-index exits 1 when the files differ
+	diffCmd := exec.Command("git", "diff", "--no-index", "--color=always", aName, bName)
+	diffCmd.Dir = tempDir
+	out, err := diffCmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 1 {
+			return "", fmt.Errorf("error running git diff: %v", err)
+		}
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
@@ -25,6 +25,7 @@ var connectCmd = &cobra.Command{
 func init() {
 	RootCmd.AddCommand(connectCmd)
 
+	addNotifyFlag(connectCmd)
 }
 
 func connect(cmd *cobra.Command, args []string) {
@@ -42,7 +43,12 @@ func connect(cmd *cobra.Command, args []string) {
 		return
 	}
 
+	connectToStream(planId, branch)
+}
+
+func connectToStream(planId, branch string) {
 	term.StartSpinner("")
+	stream.SetStreamPlan(planId, branch)
 	apiErr := api.Client.ConnectPlan(planId, branch, stream.OnStreamPlan)
 	term.StopSpinner()
 
@@ -50,6 +56,8 @@ func connect(cmd *cobra.Command, args []string) {
 		term.OutputErrorAndExit("Error connecting to stream: %v", apiErr)
 	}
 
+	lib.HandOffStreamOnHangup(planId, branch)
+
 	go func() {
 		err := streamtui.StartStreamUI("", false)
 
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"fmt"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+	"plandex/types"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var containerReadOnly bool
+var containerNoNetwork bool
+var containerRuntime string
+
+func init() {
+	RootCmd.AddCommand(containerCmd)
+	containerCmd.AddCommand(containerSetCmd)
+	containerCmd.AddCommand(containerOffCmd)
+
+	containerSetCmd.Flags().BoolVar(&containerReadOnly, "readonly", false, "Mount the project read-only instead of copying it into the container")
+	containerSetCmd.Flags().BoolVar(&containerNoNetwork, "no-network", false, "Run commands without network access")
+	containerSetCmd.Flags().StringVar(&containerRuntime, "runtime", "", "Container runtime to use, like podman (default docker)")
+}
+
+var containerCmd = &cobra.Command{
+	Use:   "container",
+	Short: "Show the container commands run in",
+	Long: `Show the container that verification, test, lint, and suggested commands run in.
+
+With a container set, those commands can't change anything on the host. Formatters still run on the host, since they need to rewrite the project's files.`,
+	Args: cobra.NoArgs,
+	Run:  container,
+}
+
+var containerSetCmd = &cobra.Command{
+	Use:   "set <image>",
+	Short: "Run commands in a container from this image",
+	Long: `Run verification, test, lint, and suggested commands in a container from this image, like 'plandex container set golang:1.22'.
+
+By default, the project is copied into the container before each command, so commands can write build output and caches, but nothing they write reaches the project. With --readonly, the project is mounted read-only instead, which is faster for big projects but fails commands that write to it.`,
+	Args: cobra.ExactArgs(1),
+	Run:  containerSet,
+}
+
+var containerOffCmd = &cobra.Command{
+	Use:   "off",
+	Short: "Run commands on the host again",
+	Args:  cobra.NoArgs,
+	Run:   containerOff,
+}
+
+func container(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	settings, err := lib.LoadProjectSettings()
+	if err != nil {
+		term.OutputErrorAndExit("Error loading project settings: %v", err)
+	}
+
+	if settings.Container == nil || settings.Container.Image == "" {
+		fmt.Println("🤷‍♂️ Commands run on the host")
+		fmt.Println()
+		fmt.Println("Run them in a container with 'plandex container set <image>'")
+		return
+	}
+
+	fmt.Println(describeContainer(settings.Container))
+}
+
+func containerSet(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	settings, err := lib.LoadProjectSettings()
+	if err != nil {
+		term.OutputErrorAndExit("Error loading project settings: %v", err)
+	}
+
+	mount := lib.ContainerMountCopy
+	if containerReadOnly {
+		mount = lib.ContainerMountReadOnly
+	}
+
+	settings.Container = &types.ContainerSettings{
+		Image:     args[0],
+		Mount:     mount,
+		Runtime:   containerRuntime,
+		NoNetwork: containerNoNetwork,
+	}
+
+	err = lib.WriteProjectSettings(settings)
+	if err != nil {
+		term.OutputErrorAndExit("Error saving project settings: %v", err)
+	}
+
+	fmt.Println("✅ " + describeContainer(settings.Container))
+}
+
+func containerOff(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	settings, err := lib.LoadProjectSettings()
+	if err != nil {
+		term.OutputErrorAndExit("Error loading project settings: %v", err)
+	}
+
+	if settings.Container == nil {
+		fmt.Println("🤷‍♂️ Commands already run on the host")
+		return
+	}
+
+	settings.Container = nil
+
+	err = lib.WriteProjectSettings(settings)
+	if err != nil {
+		term.OutputErrorAndExit("Error saving project settings: %v", err)
+	}
+
+	fmt.Println("✅ Commands will run on the host")
+}
+
+func describeContainer(container *types.ContainerSettings) string {
+	mount := "a copy of the project"
+	if container.Mount == lib.ContainerMountReadOnly {
+		mount = "the project mounted read-only"
+	}
+
+	network := ""
+	if container.NoNetwork {
+		network = ", without network access"
+	}
+
+	return fmt.Sprintf("🐳 Commands run in %s with %s%s", color.New(color.Bold, term.ColorHiCyan).Sprint(container.Image), mount, network)
+}
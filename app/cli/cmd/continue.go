@@ -2,21 +2,28 @@ package cmd
 
 import (
 	"fmt"
-	"os"
+	"plandex/api"
 	"plandex/auth"
 	"plandex/lib"
 	"plandex/plan_exec"
 	"plandex/term"
+	"sort"
+	"strings"
 
 	"github.com/plandex/plandex/shared"
 	"github.com/spf13/cobra"
 )
 
+var continueSubtask int
+
 var continueCmd = &cobra.Command{
 	Use:     "continue",
 	Aliases: []string{"c"},
 	Short:   "Continue the plan",
-	Run:     doContinue,
+	Long: `Continue the plan from where it left off.
+
+If the plan is still running, for example because the terminal that started it was closed, continue connects to its stream instead of sending anything. Otherwise it summarizes what's done so far and resumes: changes that were described but not built yet are built first, and an interrupted prompt is answered again. If the plan has subtasks, the model picks up the first one that isn't done.`,
+	Run: doContinue,
 }
 
 func init() {
@@ -25,10 +32,13 @@ func init() {
 	continueCmd.Flags().BoolVarP(&tellStop, "stop", "s", false, "Stop after a single reply")
 	continueCmd.Flags().BoolVarP(&tellNoBuild, "no-build", "n", false, "Don't build files")
 	continueCmd.Flags().BoolVar(&tellBg, "bg", false, "Execute autonomously in the background")
+	continueCmd.Flags().IntVar(&continueSubtask, "subtask", 0, "Work on this subtask next, by its number in 'plandex subtasks'")
+
+	addNotifyFlag(continueCmd)
 }
 
 func doContinue(cmd *cobra.Command, args []string) {
-	if os.Getenv("OPENAI_API_KEY") == "" {
+	if !lib.HasApiKey() {
 		term.OutputNoApiKeyMsgAndExit()
 	}
 
@@ -40,11 +50,105 @@ func doContinue(cmd *cobra.Command, args []string) {
 		return
 	}
 
+	if mustConnectIfRunning() {
+		return
+	}
+
+	state := mustGetCurrentPlanState()
+	printResumeSummary(state)
+
+	// targeting a subtask takes a prompt, so it's sent as a new prompt rather than a plain continue
+	var prompt string
+	if continueSubtask != 0 {
+		subtasks := state.Subtasks
+		if continueSubtask < 1 || continueSubtask > len(subtasks) {
+			term.OutputErrorAndExit("No subtask %d. See 'plandex subtasks'.", continueSubtask)
+		}
+		subtask := subtasks[continueSubtask-1]
+		prompt = fmt.Sprintf("Continue with subtask %d: %s. Work only on this subtask for now.", continueSubtask, subtask.Title)
+	}
+
 	plan_exec.TellPlan(plan_exec.ExecParams{
 		CurrentPlanId: lib.CurrentPlanId,
 		CurrentBranch: lib.CurrentBranch,
 		CheckOutdatedContext: func(maybeContexts []*shared.Context) (bool, bool) {
 			return lib.MustCheckOutdatedContext(false, maybeContexts)
 		},
-	}, "", tellBg, tellStop, tellNoBuild, true)
+	}, prompt, tellBg, tellStop, tellNoBuild, prompt == "")
+}
+
+// mustConnectIfRunning connects to the current branch's stream if the server is still working on it. It returns false if nothing is running.
+func mustConnectIfRunning() bool {
+	term.StartSpinner("")
+	res, apiErr := api.Client.ListPlansRunning([]string{lib.CurrentProjectId}, false)
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting running plans: %v", apiErr.Msg)
+	}
+
+	for _, b := range res.Branches {
+		if b.PlanId == lib.CurrentPlanId && b.Name == lib.CurrentBranch {
+			fmt.Println("⚡️ This plan is still running. Connecting to it.")
+			fmt.Println()
+			connectToStream(lib.CurrentPlanId, lib.CurrentBranch)
+			return true
+		}
+	}
+
+	return false
+}
+
+func printResumeSummary(state *shared.CurrentPlanState) {
+	var built []string
+	if state.PlanResult != nil {
+		for _, path := range state.PlanResult.SortedPaths {
+			for _, result := range state.PlanResult.FileResultsByPath[path] {
+				if result.IsPending() {
+					built = append(built, path)
+					break
+				}
+			}
+		}
+	}
+
+	var unbuilt []string
+	for path := range state.NumBuildsPendingByPath() {
+		unbuilt = append(unbuilt, path)
+	}
+	sort.Strings(unbuilt)
+
+	numDone := 0
+	for _, subtask := range state.Subtasks {
+		if subtask.Done {
+			numDone++
+		}
+	}
+
+	if len(built) == 0 && len(unbuilt) == 0 && len(state.Subtasks) == 0 {
+		return
+	}
+
+	if len(built) > 0 {
+		fmt.Printf("✅ Built %d file(s): %s\n", len(built), strings.Join(built, ", "))
+	}
+	if len(unbuilt) > 0 {
+		if tellNoBuild {
+			fmt.Printf("🏗️  Not built yet: %s\n", strings.Join(unbuilt, ", "))
+		} else {
+			fmt.Printf("🏗️  Building first: %s\n", strings.Join(unbuilt, ", "))
+		}
+	}
+	if len(state.Subtasks) > 0 {
+		fmt.Printf("📋 %d of %d subtasks done\n", numDone, len(state.Subtasks))
+		if continueSubtask == 0 {
+			for i, subtask := range state.Subtasks {
+				if !subtask.Done {
+					fmt.Printf("➡️  Next: %d. %s\n", i+1, subtask.Title)
+					break
+				}
+			}
+		}
+	}
+	fmt.Println()
 }
@@ -10,18 +10,38 @@ import (
 	"time"
 
 	"github.com/fatih/color"
+	"github.com/plandex/plandex/shared"
 	"github.com/spf13/cobra"
 )
 
+var convoSearch string
+var convoSince string
+var convoUntil string
+var convoRole string
+var convoLast int
+
 // convoCmd represents the convo command
 var convoCmd = &cobra.Command{
 	Use:   "convo",
 	Short: "Display complete conversation history",
-	Run:   convo,
+	Long: `Display the plan's conversation history.
+
+Search it with --search, or narrow it with --since, --until, and --role. --since and --until take a time ago like 2h or 3d, a date like 2024-05-01, or an RFC 3339 time. Use --last to show only the most recent messages. Messages keep their numbers in the full conversation.`,
+	Example: `  plandex convo --search "rate limit"
+  plandex convo --since 2d --role user
+  plandex convo --last 5`,
+	Args: cobra.NoArgs,
+	Run:  convo,
 }
 
 func init() {
 	RootCmd.AddCommand(convoCmd)
+
+	convoCmd.Flags().StringVarP(&convoSearch, "search", "s", "", "Only show messages containing this text")
+	convoCmd.Flags().StringVar(&convoSince, "since", "", "Only show messages from after this time")
+	convoCmd.Flags().StringVar(&convoUntil, "until", "", "Only show messages from before this time")
+	convoCmd.Flags().StringVar(&convoRole, "role", "", "Only show messages from 'user' or 'assistant'")
+	convoCmd.Flags().IntVarP(&convoLast, "last", "n", 0, "Only show this many of the most recent messages")
 }
 
 const stoppedEarlyMsg = "You stopped the reply early"
@@ -30,16 +50,50 @@ func convo(cmd *cobra.Command, args []string) {
 	auth.MustResolveAuthWithOrg()
 	lib.MustResolveProject()
 
-	term.StartSpinner("")
-	conversation, apiErr := api.Client.ListConvo(lib.CurrentPlanId, lib.CurrentBranch)
-	term.StopSpinner()
+	filtered := convoSearch != "" || convoSince != "" || convoUntil != "" || convoRole != "" || convoLast > 0
+
+	var conversation []*shared.ConvoMessage
+	var apiErr *shared.ApiError
+	if filtered {
+		q := shared.HistoryQuery{Text: convoSearch, Role: convoRole}
+		var err error
+		if convoSince != "" {
+			q.Since, err = lib.ParseHistoryTime(convoSince)
+			if err != nil {
+				term.OutputErrorAndExit("Invalid --since: %v", err)
+			}
+		}
+		if convoUntil != "" {
+			q.Until, err = lib.ParseHistoryTime(convoUntil)
+			if err != nil {
+				term.OutputErrorAndExit("Invalid --until: %v", err)
+			}
+		}
+
+		term.StartSpinner("")
+		conversation, apiErr = lib.ListConvoHistory(q, convoLast)
+		term.StopSpinner()
+
+		// servers from before conversation search was added
+		if apiErr != nil && apiErr.Status == 404 {
+			term.OutputErrorAndExit("The server doesn't support searching the conversation. Upgrade it, or run 'plandex convo' without filters.")
+		}
+	} else {
+		term.StartSpinner("")
+		conversation, apiErr = api.Client.ListConvo(lib.CurrentPlanId, lib.CurrentBranch)
+		term.StopSpinner()
+	}
 
 	if apiErr != nil {
 		term.OutputErrorAndExit("Error loading conversation: %v", apiErr.Msg)
 	}
 
 	if len(conversation) == 0 {
-		fmt.Println("🤷‍♂️ No conversation history")
+		if filtered {
+			fmt.Println("🤷‍♂️ No matching messages")
+		} else {
+			fmt.Println("🤷‍♂️ No conversation history")
+		}
 		return
 	}
 
@@ -68,7 +122,12 @@ func convo(cmd *cobra.Command, args []string) {
 			formattedTs = msg.CreatedAt.Local().Format("Yesterday | 3:04pm MST")
 		}
 
-		header := fmt.Sprintf("#### %d | %s | %s | %d 🪙 ", i+1,
+		num := i + 1
+		if filtered && msg.Num > 0 {
+			num = msg.Num
+		}
+
+		header := fmt.Sprintf("#### %d | %s | %s | %d 🪙 ", num,
 			author, formattedTs, msg.Tokens)
 
 		// convMarkdown = append(convMarkdown, header, msg.Message, "")
@@ -88,10 +147,15 @@ func convo(cmd *cobra.Command, args []string) {
 
 	convo = strings.ReplaceAll(convo, stoppedEarlyMsg, color.New(term.ColorHiRed).Sprint(stoppedEarlyMsg))
 
+	sizeLabel := "  Conversation size →"
+	if filtered {
+		sizeLabel = fmt.Sprintf("  %d matching messages →", len(conversation))
+	}
+
 	output :=
 		fmt.Sprintf("\n%s", convo) +
 			term.GetDivisionLine() +
-			color.New(color.Bold, term.ColorHiCyan).Sprint("  Conversation size →") + fmt.Sprintf(" %d 🪙", totalTokens) + "\n\n"
+			color.New(color.Bold, term.ColorHiCyan).Sprint(sizeLabel) + fmt.Sprintf(" %d 🪙", totalTokens) + "\n\n"
 
 	term.PageOutput(output)
 }
@@ -58,6 +58,9 @@ func del(cmd *cobra.Command, args []string) {
 		term.OutputErrorAndExit("Error getting plans: %v", apiErr)
 	}
 
+	// teammates' shared plans are listed too, but only your own can be deleted
+	plans = lib.OwnPlans(plans)
+
 	if len(plans) == 0 {
 		fmt.Println("🤷‍♂️ No plans")
 		fmt.Println()
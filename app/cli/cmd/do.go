@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/plan_exec"
+	"plandex/term"
+	"plandex/types"
+	"sort"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var doBg bool
+var doStop bool
+var doNoBuild bool
+var doVars []string
+
+var doCmd = &cobra.Command{
+	Use:   "do [preset] [args...]",
+	Short: "Send a prompt from a named preset, e.g. 'plandex do add-tests pkg/foo'",
+	Long: `Send a prompt from a named preset, e.g. 'plandex do add-tests pkg/foo'. With no arguments, the available presets are listed.
+
+Presets are defined under "presets" in ~/.plandex-home/config.json, or in the project's .plandex/project.json, which takes precedence:
+
+  "presets": {
+    "add-tests": {
+      "description": "write tests for a package",
+      "prompt": "Write thorough unit tests for {{args}}, following the conventions of the existing tests.",
+      "context": ["go.mod", "testutil/*.go"],
+      "models": {"planner": "gpt-4o"}
+    }
+  }
+
+A preset can use "template": "<name>" in place of "prompt" to send a template from .plandex/templates (see 'plandex templates'). Arguments replace {{args}} in the prompt, and other variables are set with --var, like --var file=main.go:10-20. Arguments that are paths are loaded into context along with the preset's context paths, which are relative to the project root and can be globs. Models are used for this prompt in place of the plan's models.`,
+	Run: doPreset,
+}
+
+func init() {
+	RootCmd.AddCommand(doCmd)
+
+	doCmd.Flags().BoolVarP(&doStop, "stop", "s", false, "Stop after a single reply")
+	doCmd.Flags().BoolVarP(&doNoBuild, "no-build", "n", false, "Don't build files")
+	doCmd.Flags().BoolVar(&doBg, "bg", false, "Execute autonomously in the background")
+	doCmd.Flags().StringArrayVar(&doVars, "var", nil, "Set a template variable, like --var key=value or --var file=path[:line-range]")
+
+	addNotifyFlag(doCmd)
+}
+
+func doPreset(cmd *cobra.Command, args []string) {
+	lib.MaybeResolveProject()
+
+	presets, err := lib.GetPresets()
+	if err != nil {
+		term.OutputErrorAndExit("Error loading presets: %v", err)
+	}
+
+	if len(args) == 0 {
+		listPresets(presets)
+		return
+	}
+
+	name := args[0]
+	preset, ok := presets[name]
+	if !ok {
+		term.OutputErrorAndExit("No preset named %s", name)
+	}
+
+	if !lib.HasApiKey() {
+		term.OutputNoApiKeyMsgAndExit()
+	}
+
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		fmt.Println("🤷‍♂️ No current plan")
+		return
+	}
+
+	presetArgs := args[1:]
+
+	vars, err := lib.GetTemplateVars(doVars)
+	if err != nil {
+		term.OutputErrorAndExit("%v", err)
+	}
+
+	prompt, err := lib.GetPresetPrompt(preset, presetArgs, vars)
+	if err != nil {
+		term.OutputErrorAndExit("Error getting preset prompt: %v", err)
+	}
+
+	contexts, apiErr := api.Client.ListContext(lib.CurrentPlanId, lib.CurrentBranch)
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting context: %v", apiErr.Msg)
+	}
+
+	paths, err := lib.GetPresetContextPaths(preset, presetArgs, contexts)
+	if err != nil {
+		term.OutputErrorAndExit("Error getting preset context: %v", err)
+	}
+
+	if len(paths) > 0 {
+		lib.MustLoadContext(paths, &types.LoadContextParams{})
+		fmt.Println()
+	}
+
+	plan_exec.TellPlan(plan_exec.ExecParams{
+		CurrentPlanId: lib.CurrentPlanId,
+		CurrentBranch: lib.CurrentBranch,
+		CheckOutdatedContext: func(maybeContexts []*shared.Context) (bool, bool) {
+			return lib.MustCheckOutdatedContext(false, maybeContexts)
+		},
+		ModelSetOverride: lib.MustGetPresetModelSet(preset),
+	}, prompt, doBg, doStop, doNoBuild, false)
+}
+
+func listPresets(presets map[string]*types.Preset) {
+	if len(presets) == 0 {
+		fmt.Println("🤷‍♂️ No presets")
+		fmt.Println()
+		fmt.Println("Add presets under \"presets\" in ~/.plandex-home/config.json or .plandex/project.json. See 'plandex do --help'.")
+		return
+	}
+
+	var names []string
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Preset", "Description", "Models"})
+
+	for _, name := range names {
+		preset := presets[name]
+
+		var models []string
+		for role, model := range preset.Models {
+			models = append(models, fmt.Sprintf("%s: %s", role, model))
+		}
+		sort.Strings(models)
+
+		table.Append([]string{name, preset.Description, strings.Join(models, ", ")})
+	}
+
+	table.Render()
+}
@@ -0,0 +1,274 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/fs"
+	"plandex/lib"
+	"plandex/term"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose problems with your setup",
+	Long: `Diagnose problems with your setup and suggest a fix for each one.
+
+Checks that the server is reachable and you're signed in, the server's database, storage, and git, your api key and the models your plan uses, what the terminal supports, the project's and current plan's local state, and git. Exits with status 1 if any check fails.`,
+	Args: cobra.NoArgs,
+	Run:  doctor,
+}
+
+func init() {
+	RootCmd.AddCommand(doctorCmd)
+}
+
+func doctor(cmd *cobra.Command, args []string) {
+	term.StartSpinner("🩺 Running checks...")
+
+	serverChecks, signedIn := doctorAuthChecks()
+
+	// local state is checked before the project is resolved, since resolving exits on a corrupt file
+	projectChecks, resolvable := lib.CheckProjectFiles()
+	if resolvable {
+		lib.MaybeResolveProject()
+		projectChecks = append(projectChecks, lib.CheckPlanInfo())
+	}
+
+	if signedIn {
+		serverChecks = append(serverChecks, doctorServerSideChecks()...)
+	}
+
+	var providerChecks []*shared.DoctorCheck
+	if signedIn {
+		providerChecks = doctorProviderChecks()
+	} else {
+		providerChecks = []*shared.DoctorCheck{{
+			Name:    "Api key and models",
+			Ok:      true,
+			Message: "skipped until the server is reachable and you're signed in",
+		}}
+	}
+
+	terminalChecks := lib.CheckTerminal()
+	gitChecks := lib.CheckGit()
+
+	term.StopSpinner()
+
+	numFailed := 0
+	for _, section := range []struct {
+		name   string
+		checks []*shared.DoctorCheck
+	}{
+		{"Server", serverChecks},
+		{"Provider", providerChecks},
+		{"Terminal", terminalChecks},
+		{"Project", projectChecks},
+		{"Git", gitChecks},
+	} {
+		numFailed += printDoctorSection(section.name, section.checks)
+	}
+
+	if numFailed == 0 {
+		color.New(color.Bold, term.ColorHiGreen).Println("🩺 Everything looks good")
+		return
+	}
+
+	suffix := "s"
+	if numFailed == 1 {
+		suffix = ""
+	}
+	color.New(color.Bold, term.ColorHiRed).Printf("🩺 %d check%s failed\n", numFailed, suffix)
+	os.Exit(1)
+}
+
+// doctorAuthChecks checks that the server is reachable and accepts the current session. signedIn is false if the checks that need the server can't run.
+func doctorAuthChecks() (checks []*shared.DoctorCheck, signedIn bool) {
+	if _, err := os.Stat(fs.HomeAuthPath); os.IsNotExist(err) {
+		return []*shared.DoctorCheck{{
+			Name:    "Auth",
+			Message: "not signed in",
+			Fix:     "Sign in with 'plandex sign-in'",
+		}}, false
+	}
+
+	term.StopSpinner()
+	auth.MustResolveAuthWithOrg()
+	term.StartSpinner("🩺 Running checks...")
+
+	host := auth.Current.Host
+	if auth.Current.IsCloud {
+		host = "Plandex Cloud"
+	}
+
+	check := &shared.DoctorCheck{Name: "Server", Ok: true}
+	start := time.Now()
+	apiErr := api.Client.CheckHealth()
+	if apiErr != nil {
+		check.Ok = false
+		check.Message = fmt.Sprintf("%s isn't reachable: %s", host, apiErr.Msg)
+		if auth.Current.IsCloud {
+			check.Fix = "Check your network connection and any proxy settings"
+		} else {
+			check.Fix = fmt.Sprintf("Check that the server at %s is running, or sign in to another with 'plandex sign-in'", host)
+		}
+		return append(checks, check), false
+	}
+	check.Message = fmt.Sprintf("%s responded in %s", host, time.Since(start).Round(time.Millisecond))
+	checks = append(checks, check)
+
+	check = &shared.DoctorCheck{Name: "Auth", Ok: true}
+	apiErr = api.Client.GetOrgSession()
+	if apiErr != nil {
+		check.Ok = false
+		check.Message = fmt.Sprintf("session for %s was rejected: %s", auth.Current.Email, apiErr.Msg)
+		check.Fix = "Sign in again with 'plandex sign-in'"
+		return append(checks, check), false
+	}
+	check.Message = fmt.Sprintf("%s in %s", auth.Current.Email, auth.Current.OrgName)
+	checks = append(checks, check)
+
+	return checks, true
+}
+
+// doctorServerSideChecks runs the server's checks of its database, storage, and git, and of the current plan if there is one
+func doctorServerSideChecks() []*shared.DoctorCheck {
+	res, apiErr := api.Client.Doctor(lib.CurrentPlanId, lib.CurrentBranch)
+	if apiErr != nil {
+		// servers from before the doctor endpoint was added
+		if apiErr.Status == 404 || apiErr.Status == 405 {
+			return []*shared.DoctorCheck{{
+				Name:    "Server checks",
+				Ok:      true,
+				Message: "skipped, the server doesn't support them. Upgrade it for more checks.",
+			}}
+		}
+		return []*shared.DoctorCheck{{
+			Name:    "Server checks",
+			Message: apiErr.Msg,
+			Fix:     "Check the server's logs",
+		}}
+	}
+
+	checks := res.Checks
+	if res.Version != "" {
+		checks = append([]*shared.DoctorCheck{{Name: "Server version", Ok: true, Message: res.Version}}, checks...)
+	}
+
+	return checks
+}
+
+// doctorProviderChecks checks the api key and the models the current plan would actually use
+func doctorProviderChecks() []*shared.DoctorCheck {
+	if lib.MockEnabled {
+		return []*shared.DoctorCheck{{Name: "Api key", Ok: true, Message: "mock mode, so no api key is needed"}}
+	}
+
+	apiKey := os.Getenv("OPENAI_API_KEY")
+	if apiKey == "" {
+		return []*shared.DoctorCheck{{
+			Name:    "Api key",
+			Message: "OPENAI_API_KEY isn't set",
+			Fix:     "Run 'export OPENAI_API_KEY=your-api-key'. Generate a key at https://platform.openai.com/api-keys",
+		}}
+	}
+
+	modelSet, _, err := lib.GetModelSet(lib.CurrentPlanId, lib.CurrentBranch)
+	if err != nil {
+		return []*shared.DoctorCheck{{
+			Name:    "Plan settings",
+			Message: err.Error(),
+			Fix:     "Check the current plan with 'plandex current' and 'plandex set-model'",
+		}}
+	}
+
+	res, apiErr := api.Client.CheckModels(shared.CheckModelsRequest{
+		ApiKey:   apiKey,
+		ModelSet: modelSet,
+	})
+	if apiErr != nil {
+		return []*shared.DoctorCheck{{
+			Name:    "Api key",
+			Message: fmt.Sprintf("error checking models: %s", apiErr.Msg),
+			Fix:     "Check the server's logs",
+		}}
+	}
+
+	// the server stops at the first problem with the key or quota, whose error is the last one it returns
+	lastErr := ""
+	if len(res.Errors) > 0 {
+		lastErr = res.Errors[len(res.Errors)-1]
+	}
+
+	checks := []*shared.DoctorCheck{{
+		Name:    "Api key",
+		Ok:      res.ApiKeyValid,
+		Message: fmt.Sprintf("%s at %s", res.Provider, res.BaseUrl),
+	}}
+	if !res.ApiKeyValid {
+		checks[0].Fix = shared.Capitalize(lastErr)
+		return checks
+	}
+
+	anyAvailable := false
+	for _, m := range res.Models {
+		check := &shared.DoctorCheck{
+			Name:    fmt.Sprintf("Model (%s)", m.Role),
+			Ok:      m.Available,
+			Message: m.ModelName,
+		}
+		if m.Available {
+			anyAvailable = true
+		} else {
+			check.Message += " isn't available for this api key"
+			check.Fix = fmt.Sprintf("Pick another with 'plandex set-model %s'. See 'plandex models available'.", m.Role)
+		}
+		checks = append(checks, check)
+	}
+
+	if anyAvailable {
+		check := &shared.DoctorCheck{Name: "Quota", Ok: res.QuotaOk, Message: "ok"}
+		if !res.QuotaOk {
+			check.Message = "the quota check failed"
+			check.Fix = shared.Capitalize(lastErr)
+		}
+		checks = append(checks, check)
+	}
+
+	return checks
+}
+
+func printDoctorSection(name string, checks []*shared.DoctorCheck) int {
+	color.New(color.Bold, term.ColorHiCyan).Println(name)
+
+	numFailed := 0
+	for _, check := range checks {
+		if check.Ok && check.Warning {
+			fmt.Printf("  ⚠️  %s · %s\n", color.New(color.Bold, term.ColorHiYellow).Sprint(check.Name), check.Message)
+			if check.Fix != "" {
+				fmt.Printf("     👉 %s\n", check.Fix)
+			}
+			continue
+		}
+		if check.Ok {
+			fmt.Printf("  ✅ %s · %s\n", color.New(color.Bold).Sprint(check.Name), check.Message)
+			continue
+		}
+
+		numFailed++
+		fmt.Printf("  ❌ %s · %s\n", color.New(color.Bold, term.ColorHiRed).Sprint(check.Name), check.Message)
+		if check.Fix != "" {
+			fmt.Printf("     👉 %s\n", check.Fix)
+		}
+	}
+
+	fmt.Println()
+
+	return numFailed
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/plan_exec"
+	"plandex/term"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var explainSave bool
+
+var explainCmd = &cobra.Command{
+	Use:   "explain <path>[:line-range]",
+	Short: "Explain a file or range of lines",
+	Long: `Explain a file or range of lines using the plan's context, e.g. 'plandex explain main.go:10-20'.
+
+Explanations use the chat model and aren't added to the plan's conversation, so the plan is left as it was. Use --save to keep the explanation in the conversation for later prompts to build on.`,
+	Args: cobra.ExactArgs(1),
+	Run:  explain,
+}
+
+func init() {
+	RootCmd.AddCommand(explainCmd)
+
+	explainCmd.Flags().BoolVar(&explainSave, "save", false, "Add the explanation to the plan's conversation")
+
+	addNotifyFlag(explainCmd)
+}
+
+func explain(cmd *cobra.Command, args []string) {
+	if !lib.HasApiKey() {
+		term.OutputNoApiKeyMsgAndExit()
+	}
+
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		fmt.Println("🤷‍♂️ No current plan")
+		return
+	}
+
+	sel, err := lib.ParseFileSelection(args[0])
+	if err != nil {
+		term.OutputErrorAndExit("%v", err)
+	}
+
+	prompt, err := lib.GetExplainPrompt(sel)
+	if err != nil {
+		term.OutputErrorAndExit("%v", err)
+	}
+
+	plan_exec.TellPlan(plan_exec.ExecParams{
+		CurrentPlanId: lib.CurrentPlanId,
+		CurrentBranch: lib.CurrentBranch,
+		CheckOutdatedContext: func(maybeContexts []*shared.Context) (bool, bool) {
+			return lib.MustCheckOutdatedContext(false, maybeContexts)
+		},
+		ChatOnly:  true,
+		Ephemeral: !explainSave,
+	}, prompt, false, true, true, false)
+}
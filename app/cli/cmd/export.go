@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+
+	"github.com/spf13/cobra"
+)
+
+var exportFormat string
+var exportOutput string
+var exportJson bool
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the current plan for sharing or other tools",
+}
+
+var exportConvoCmd = &cobra.Command{
+	Use:   "convo",
+	Short: "Export the plan's conversation as Markdown or HTML",
+	Long: `Export the current plan's full conversation as a Markdown or HTML document.
+
+Each reply that made changes is followed by its summary, the files it changed, and the commands it suggested. Messages are annotated with their tokens and the estimated cost of each reply.
+
+The document is printed on its own, or written to a file with --output.`,
+	Args: cobra.NoArgs,
+	Run:  exportConvo,
+}
+
+var exportPlanCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Export the plan as a machine-readable document",
+	Long: `Export the current plan branch as a machine-readable document for dashboards, review bots, and audit pipelines.
+
+The document includes a summary of the pending changes, each reply's description and files, the plan's subtasks, a diff of each pending file against the project, and the plan's token usage and estimated cost by phase as recorded by the server.`,
+	Args: cobra.NoArgs,
+	Run:  exportPlan,
+}
+
+func init() {
+	RootCmd.AddCommand(exportCmd)
+	exportCmd.AddCommand(exportConvoCmd)
+	exportCmd.AddCommand(exportPlanCmd)
+
+	exportConvoCmd.Flags().StringVar(&exportFormat, "format", lib.ExportFormatMarkdown, "Document format: md or html")
+	exportConvoCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "Write the document to this file instead of printing it")
+
+	exportPlanCmd.Flags().BoolVar(&exportJson, "json", false, "Output the plan as JSON")
+	exportPlanCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "Write the document to this file instead of printing it")
+}
+
+func exportConvo(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		fmt.Println("🤷‍♂️ No current plan")
+		return
+	}
+
+	doc, err := lib.GetConvoExport(lib.CurrentPlanId, lib.CurrentBranch, exportFormat)
+	if err != nil {
+		term.OutputErrorAndExit("Error exporting conversation: %v", err)
+	}
+
+	if exportOutput == "" {
+		fmt.Print(doc)
+		return
+	}
+
+	err = os.WriteFile(exportOutput, []byte(doc), 0644)
+	if err != nil {
+		term.OutputErrorAndExit("Error writing conversation: %v", err)
+	}
+
+	fmt.Printf("✅ Exported the conversation to %s\n", exportOutput)
+}
+
+func exportPlan(cmd *cobra.Command, args []string) {
+	if !exportJson {
+		term.OutputErrorAndExit("Choose an output format: --json")
+	}
+
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		fmt.Println("🤷‍♂️ No current plan")
+		return
+	}
+
+	export, err := lib.GetPlanExport(lib.CurrentPlanId, lib.CurrentBranch)
+	if err != nil {
+		term.OutputErrorAndExit("Error exporting plan: %v", err)
+	}
+
+	bytes, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		term.OutputErrorAndExit("Error marshalling plan: %v", err)
+	}
+
+	if exportOutput == "" {
+		fmt.Println(string(bytes))
+		return
+	}
+
+	err = os.WriteFile(exportOutput, append(bytes, '\n'), 0644)
+	if err != nil {
+		term.OutputErrorAndExit("Error writing plan: %v", err)
+	}
+
+	fmt.Printf("✅ Exported the plan to %s\n", exportOutput)
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+	"sort"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	RootCmd.AddCommand(formattersCmd)
+	formattersCmd.AddCommand(formattersSetCmd)
+	formattersCmd.AddCommand(formattersRmCmd)
+}
+
+var formattersCmd = &cobra.Command{
+	Use:   "formatters",
+	Short: "List the formatters run on files after apply",
+	Args:  cobra.NoArgs,
+	Run:   formatters,
+}
+
+var formattersSetCmd = &cobra.Command{
+	Use:   "set <extension> <command>",
+	Short: "Set the formatter for a file extension",
+	Long: `Set the formatter for a file extension, like 'plandex formatters set .go "gofmt -w"'.
+
+After apply, the command runs from the project root on each written file with the extension. The file's path replaces {file} in the command, or is appended to the end if there's no {file}.`,
+	Args: cobra.ExactArgs(2),
+	Run:  formattersSet,
+}
+
+var formattersRmCmd = &cobra.Command{
+	Use:   "rm <extension>",
+	Short: "Remove the formatter for a file extension",
+	Args:  cobra.ExactArgs(1),
+	Run:   formattersRm,
+}
+
+func formatters(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	settings, err := lib.LoadProjectSettings()
+	if err != nil {
+		term.OutputErrorAndExit("Error loading project settings: %v", err)
+	}
+
+	if len(settings.Formatters) == 0 {
+		fmt.Println("🤷‍♂️ No formatters set")
+		fmt.Println()
+		fmt.Println("Set one with 'plandex formatters set .go \"gofmt -w\"'")
+		return
+	}
+
+	var exts []string
+	for ext := range settings.Formatters {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Extension", "Command"})
+	for _, ext := range exts {
+		table.Append([]string{ext, settings.Formatters[ext]})
+	}
+	table.Render()
+}
+
+func formattersSet(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	ext := lib.NormalizeFormatterExt(args[0])
+	if ext == "" || ext == "." {
+		term.OutputErrorAndExit("Invalid extension: %s", args[0])
+	}
+
+	settings, err := lib.LoadProjectSettings()
+	if err != nil {
+		term.OutputErrorAndExit("Error loading project settings: %v", err)
+	}
+
+	wasApproved := projectCommandsApproved(settings)
+
+	if settings.Formatters == nil {
+		settings.Formatters = map[string]string{}
+	}
+	settings.Formatters[ext] = args[1]
+
+	err = lib.WriteProjectCommandSettings(settings, wasApproved)
+	if err != nil {
+		term.OutputErrorAndExit("Error saving project settings: %v", err)
+	}
+
+	fmt.Printf("✅ %s files will be formatted with '%s' after apply\n", ext, args[1])
+}
+
+func formattersRm(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	ext := lib.NormalizeFormatterExt(args[0])
+
+	settings, err := lib.LoadProjectSettings()
+	if err != nil {
+		term.OutputErrorAndExit("Error loading project settings: %v", err)
+	}
+
+	wasApproved := projectCommandsApproved(settings)
+
+	if _, ok := settings.Formatters[ext]; !ok {
+		fmt.Printf("🤷‍♂️ No formatter set for %s\n", ext)
+		return
+	}
+
+	delete(settings.Formatters, ext)
+
+	err = lib.WriteProjectCommandSettings(settings, wasApproved)
+	if err != nil {
+		term.OutputErrorAndExit("Error saving project settings: %v", err)
+	}
+
+	fmt.Printf("✅ Removed the formatter for %s\n", ext)
+}
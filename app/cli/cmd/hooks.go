@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+	"plandex/types"
+	"strconv"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	RootCmd.AddCommand(hooksCmd)
+	hooksCmd.AddCommand(hooksAddCmd)
+	hooksCmd.AddCommand(hooksRmCmd)
+}
+
+var hooksCmd = &cobra.Command{
+	Use:   "hooks",
+	Short: "List the hooks run before and after apply",
+	Args:  cobra.NoArgs,
+	Run:   hooks,
+}
+
+var hooksAddCmd = &cobra.Command{
+	Use:   "add <pre-apply|post-apply> <command>",
+	Short: "Add a hook run before or after apply",
+	Long: `Add a hook run before or after apply, like 'plandex hooks add post-apply "make generate"'.
+
+Hooks run from the project root in the order they were added. Pre-apply hooks run once the apply is confirmed, before any files are written, and a hook that fails stops the apply. Post-apply hooks run after the files are written and formatted, before they're committed. A post-apply hook that fails is reported but doesn't undo the apply.
+
+Each hook gets these environment variables, with paths relative to the project root and separated by newlines:
+
+  PLANDEX_HOOK           pre-apply or post-apply
+  PLANDEX_PLAN_ID        the plan being applied
+  PLANDEX_BRANCH         the plan's branch
+  PLANDEX_FILES          files the apply writes
+  PLANDEX_REMOVED_FILES  files the apply removes`,
+	Args: cobra.ExactArgs(2),
+	Run:  hooksAdd,
+}
+
+var hooksRmCmd = &cobra.Command{
+	Use:   "rm <pre-apply|post-apply> <index>",
+	Short: "Remove a hook by its index in 'plandex hooks'",
+	Args:  cobra.ExactArgs(2),
+	Run:   hooksRm,
+}
+
+func hooks(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	settings, err := lib.LoadProjectSettings()
+	if err != nil {
+		term.OutputErrorAndExit("Error loading project settings: %v", err)
+	}
+
+	if len(settings.PreApplyHooks) == 0 && len(settings.PostApplyHooks) == 0 {
+		fmt.Println("🤷‍♂️ No hooks set")
+		fmt.Println()
+		fmt.Println("Add one with 'plandex hooks add post-apply \"make generate\"'")
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Hook", "#", "Command"})
+	for _, hook := range lib.ApplyHooks {
+		for i, cmdLine := range *getApplyHooks(settings, hook) {
+			table.Append([]string{string(hook), strconv.Itoa(i + 1), cmdLine})
+		}
+	}
+	table.Render()
+}
+
+func hooksAdd(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	hook := mustParseApplyHook(args[0])
+
+	settings, err := lib.LoadProjectSettings()
+	if err != nil {
+		term.OutputErrorAndExit("Error loading project settings: %v", err)
+	}
+
+	wasApproved := projectCommandsApproved(settings)
+
+	hooks := getApplyHooks(settings, hook)
+	*hooks = append(*hooks, args[1])
+
+	err = lib.WriteProjectCommandSettings(settings, wasApproved)
+	if err != nil {
+		term.OutputErrorAndExit("Error saving project settings: %v", err)
+	}
+
+	fmt.Printf("✅ '%s' will run as a %s hook\n", args[1], hook)
+}
+
+func hooksRm(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	hook := mustParseApplyHook(args[0])
+
+	settings, err := lib.LoadProjectSettings()
+	if err != nil {
+		term.OutputErrorAndExit("Error loading project settings: %v", err)
+	}
+
+	wasApproved := projectCommandsApproved(settings)
+
+	hooks := getApplyHooks(settings, hook)
+
+	idx, err := strconv.Atoi(args[1])
+	if err != nil || idx < 1 || idx > len(*hooks) {
+		term.OutputErrorAndExit("No %s hook with index %s", hook, args[1])
+	}
+
+	removed := (*hooks)[idx-1]
+	*hooks = append((*hooks)[:idx-1], (*hooks)[idx:]...)
+
+	err = lib.WriteProjectCommandSettings(settings, wasApproved)
+	if err != nil {
+		term.OutputErrorAndExit("Error saving project settings: %v", err)
+	}
+
+	fmt.Printf("✅ Removed %s hook '%s'\n", hook, removed)
+}
+
+func mustParseApplyHook(s string) lib.ApplyHook {
+	for _, hook := range lib.ApplyHooks {
+		if string(hook) == s {
+			return hook
+		}
+	}
+	term.OutputErrorAndExit("Invalid hook %s, expected pre-apply or post-apply", s)
+	return ""
+}
+
+func getApplyHooks(settings *types.CurrentProjectSettings, hook lib.ApplyHook) *[]string {
+	if hook == lib.ApplyHookPre {
+		return &settings.PreApplyHooks
+	}
+	return &settings.PostApplyHooks
+}
@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+	"sort"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var lintMaxFixes int
+var lintAuto bool
+
+func init() {
+	RootCmd.AddCommand(lintCmd)
+	lintCmd.AddCommand(lintSetCmd)
+	lintCmd.AddCommand(lintRmCmd)
+	lintCmd.AddCommand(lintLsCmd)
+
+	lintCmd.Flags().IntVar(&lintMaxFixes, "max-fixes", 0, fmt.Sprintf("Set the maximum number of automatic fix attempts (default %d)", lib.DefaultMaxLintFixes))
+	lintCmd.Flags().BoolVar(&lintAuto, "auto", false, "Set whether linters run automatically when a reply's changes finish building")
+
+	addNotifyFlag(lintCmd)
+}
+
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Lint the plan's pending changes and fix problems",
+	Long: `Run the project's linters and typecheckers on the files the plan's pending changes touch, before they're applied.
+
+Changes are checked in a sandbox copy of the project, with the project's gitignored directories, like node_modules, linked in so linters can load installed dependencies. Problems are sent back to the plan for a fix pass, up to --max-fixes times.
+
+To also run linters automatically when a reply's changes finish building, use 'plandex lint --auto'. Turn it off with 'plandex lint --auto=false'.`,
+	Args: cobra.NoArgs,
+	Run:  lint,
+}
+
+var lintSetCmd = &cobra.Command{
+	Use:   "set <extension> <command>",
+	Short: "Set the linter for a file extension",
+	Long: `Set the linter or typechecker for a file extension, like 'plandex lint set .py "ruff check"' or 'plandex lint set .go "go vet"'.
+
+The command runs from the project root. If it has a {file} placeholder, it runs once for each changed file with the extension. Otherwise, it runs once with all of them appended. A non-zero exit means there are problems to fix.`,
+	Args: cobra.ExactArgs(2),
+	Run:  lintSet,
+}
+
+var lintRmCmd = &cobra.Command{
+	Use:   "rm <extension>",
+	Short: "Remove the linter for a file extension",
+	Args:  cobra.ExactArgs(1),
+	Run:   lintRm,
+}
+
+var lintLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "List the linters set for the project",
+	Args:  cobra.NoArgs,
+	Run:   lintLs,
+}
+
+func lint(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	settings, err := lib.LoadProjectSettings()
+	if err != nil {
+		term.OutputErrorAndExit("Error loading project settings: %v", err)
+	}
+
+	wasApproved := projectCommandsApproved(settings)
+
+	if cmd.Flags().Changed("max-fixes") {
+		if lintMaxFixes < 0 {
+			term.OutputErrorAndExit("--max-fixes can't be negative")
+		}
+		settings.MaxLintFixes = &lintMaxFixes
+
+		err = lib.WriteProjectCommandSettings(settings, wasApproved)
+		if err != nil {
+			term.OutputErrorAndExit("Error saving project settings: %v", err)
+		}
+
+		fmt.Printf("✅ Lint problems will get up to %d fix attempts\n", lintMaxFixes)
+	}
+
+	if cmd.Flags().Changed("auto") {
+		settings.AutoLint = lintAuto
+
+		err = lib.WriteProjectCommandSettings(settings, wasApproved)
+		if err != nil {
+			term.OutputErrorAndExit("Error saving project settings: %v", err)
+		}
+
+		if lintAuto {
+			fmt.Println("✅ Linters will run automatically when a reply's changes finish building")
+		} else {
+			fmt.Println("✅ Linters will only run with 'plandex lint'")
+		}
+	}
+
+	if cmd.Flags().Changed("max-fixes") || cmd.Flags().Changed("auto") {
+		return
+	}
+
+	if len(settings.Linters) == 0 {
+		fmt.Println("🤷‍♂️ No linters set")
+		fmt.Println()
+		fmt.Println("Set one with 'plandex lint set .go \"go vet\"'")
+		return
+	}
+
+	if lib.CurrentPlanId == "" {
+		fmt.Println("🤷‍♂️ No current plan")
+		return
+	}
+
+	if !lib.HasApiKey() {
+		term.OutputNoApiKeyMsgAndExit()
+	}
+
+	lib.MustCheckProjectCommandsApproved(settings)
+
+	if lib.MustRunLintLoop(lib.CurrentPlanId, lib.CurrentBranch, settings.Linters, lib.GetMaxLintFixes(settings)) {
+		fmt.Println()
+		term.PrintCmds("", "changes", "apply")
+	}
+}
+
+func lintLs(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	settings, err := lib.LoadProjectSettings()
+	if err != nil {
+		term.OutputErrorAndExit("Error loading project settings: %v", err)
+	}
+
+	if len(settings.Linters) == 0 {
+		fmt.Println("🤷‍♂️ No linters set")
+		fmt.Println()
+		fmt.Println("Set one with 'plandex lint set .go \"go vet\"'")
+		return
+	}
+
+	var exts []string
+	for ext := range settings.Linters {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Extension", "Command"})
+	for _, ext := range exts {
+		table.Append([]string{ext, settings.Linters[ext]})
+	}
+	table.Render()
+}
+
+func lintSet(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	ext := lib.NormalizeFormatterExt(args[0])
+	if ext == "" || ext == "." {
+		term.OutputErrorAndExit("Invalid extension: %s", args[0])
+	}
+
+	settings, err := lib.LoadProjectSettings()
+	if err != nil {
+		term.OutputErrorAndExit("Error loading project settings: %v", err)
+	}
+
+	wasApproved := projectCommandsApproved(settings)
+
+	if settings.Linters == nil {
+		settings.Linters = map[string]string{}
+	}
+	settings.Linters[ext] = args[1]
+
+	err = lib.WriteProjectCommandSettings(settings, wasApproved)
+	if err != nil {
+		term.OutputErrorAndExit("Error saving project settings: %v", err)
+	}
+
+	fmt.Printf("✅ Changes to %s files will be checked with '%s' before they're applied\n", ext, args[1])
+}
+
+func lintRm(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	ext := lib.NormalizeFormatterExt(args[0])
+
+	settings, err := lib.LoadProjectSettings()
+	if err != nil {
+		term.OutputErrorAndExit("Error loading project settings: %v", err)
+	}
+
+	wasApproved := projectCommandsApproved(settings)
+
+	if _, ok := settings.Linters[ext]; !ok {
+		fmt.Printf("🤷‍♂️ No linter set for %s\n", ext)
+		return
+	}
+
+	delete(settings.Linters, ext)
+
+	err = lib.WriteProjectCommandSettings(settings, wasApproved)
+	if err != nil {
+		term.OutputErrorAndExit("Error saving project settings: %v", err)
+	}
+
+	fmt.Printf("✅ Removed the linter for %s\n", ext)
+}
@@ -15,14 +15,20 @@ var (
 	namesOnly       bool
 	note            string
 	forceSkipIgnore bool
+	loadCommits     int
+	loadSymbols     string
 )
 
 var contextLoadCmd = &cobra.Command{
-	Use:     "load [files-or-urls...]",
+	Use:     "load [files-urls-or-provider-queries...]",
 	Aliases: []string{"l", "add"},
 	Short:   "Load context from various inputs",
-	Long:    `Load context from a file path, a directory, a URL, a string, or piped data.`,
-	Run:     contextLoad,
+	Long: `Load context from a file path, a directory, a URL, a string, piped data, or recent git commits.
+
+Pass <provider>:<query>, like 'wiki:payments retries', to load context from a context provider plugin such as a search of an internal wiki, a schema registry, or an API catalog. Each item it returns is loaded as a note, replacing the one loaded under the same name before. See 'plandex providers' to add providers and for the protocol they speak.
+
+With --symbols, an existing ctags or LSIF index is loaded as the project's symbol map: each file with the classes, functions, and types it defines. This gives the plan an overview of a large repo without loading its files. ctags indexes can be in the tags format or universal-ctags' json output. The map is reparsed when the index changes, along with the rest of the context, and loading another index replaces it.`,
+	Run: contextLoad,
 }
 
 func init() {
@@ -30,6 +36,8 @@ func init() {
 	contextLoadCmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "Search directories recursively")
 	contextLoadCmd.Flags().BoolVar(&namesOnly, "tree", false, "Load directory tree with file names only")
 	contextLoadCmd.Flags().BoolVarP(&forceSkipIgnore, "force", "f", false, "Load files even when ignored by .gitignore or .plandexignore")
+	contextLoadCmd.Flags().IntVar(&loadCommits, "commits", 0, "Load the messages and diffs of this many recent commits")
+	contextLoadCmd.Flags().StringVar(&loadSymbols, "symbols", "", "Load a ctags or LSIF index as the project's symbol map")
 	RootCmd.AddCommand(contextLoadCmd)
 }
 
@@ -42,11 +50,33 @@ func contextLoad(cmd *cobra.Command, args []string) {
 		return
 	}
 
+	if loadSymbols != "" {
+		term.StartSpinner("🗺️  Loading symbol map...")
+		res, symbolMap, err := lib.LoadSymbolIndexContext(loadSymbols)
+		term.StopSpinner()
+		if err != nil {
+			term.OutputErrorAndExit("Failed to load symbol map: %v", err)
+		}
+		if res.MaxTokensExceeded {
+			term.OutputErrorAndExit("The symbol map would exceed the token limit (%d) by %d 🪙. Index fewer files, like only the dirs the plan needs.", res.MaxTokens, res.TotalTokens-res.MaxTokens)
+		}
+
+		fmt.Printf("✅ Loaded %d symbols in %d files from %s\n", symbolMap.NumSymbols(), len(symbolMap.Symbols), loadSymbols)
+
+		// the index can be loaded on its own
+		if len(args) == 0 && note == "" && loadCommits == 0 && !lib.HasPipedInput() {
+			fmt.Println()
+			term.PrintCmds("", "ls", "tell")
+			return
+		}
+	}
+
 	lib.MustLoadContext(args, &types.LoadContextParams{
 		Note:            note,
 		Recursive:       recursive,
 		NamesOnly:       namesOnly,
 		ForceSkipIgnore: forceSkipIgnore,
+		Commits:         loadCommits,
 	})
 
 	fmt.Println()
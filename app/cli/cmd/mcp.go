@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+	"plandex/version"
+
+	"github.com/spf13/cobra"
+)
+
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Serve plandex's tools to AI clients over the Model Context Protocol",
+	Long: `Serve plandex's tools to AI clients like editors and chat apps over the Model Context Protocol, on stdin and stdout.
+
+Add it to a client as a stdio server that runs 'plandex mcp' in the project's directory. The client can then call these tools against the project's current plan:
+
+  propose_plan   send a prompt and wait for the changes to be built
+  get_diff       get a diff of the pending changes
+  apply_plan     write the pending changes to the project and commit them
+
+To go the other way and load context from MCP servers, add them with 'plandex mcp add'.`,
+	Args: cobra.NoArgs,
+	Run:  serveMcp,
+}
+
+func init() {
+	RootCmd.AddCommand(mcpCmd)
+}
+
+func serveMcp(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	err := lib.ServeMcp(version.Version)
+	if err != nil {
+		term.OutputErrorAndExit("Error serving MCP: %v", err)
+	}
+}
@@ -0,0 +1,342 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+	"plandex/types"
+	"sort"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var mcpPromptTool string
+var mcpPromptArg string
+var mcpEnv []string
+
+func init() {
+	mcpCmd.AddCommand(mcpLsCmd)
+	mcpCmd.AddCommand(mcpAddCmd)
+	mcpCmd.AddCommand(mcpRmCmd)
+	mcpCmd.AddCommand(mcpApproveCmd)
+	mcpCmd.AddCommand(mcpToolsCmd)
+	mcpCmd.AddCommand(mcpLoadCmd)
+
+	mcpAddCmd.Flags().StringVar(&mcpPromptTool, "prompt-tool", "", "Call this tool with each new prompt and load its result into context")
+	mcpAddCmd.Flags().StringVar(&mcpPromptArg, "prompt-arg", "query", "The argument the prompt is passed to --prompt-tool as")
+	mcpAddCmd.Flags().StringArrayVar(&mcpEnv, "env", nil, "Set an environment variable for the server, like --env KEY=value. Values are stored in plain text in .plandex/project.json, so leave secrets out and set them in your own environment, which the server inherits")
+}
+
+var mcpLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "List the MCP servers plandex can load context from",
+	Args:  cobra.NoArgs,
+	Run:   mcpLs,
+}
+
+var mcpAddCmd = &cobra.Command{
+	Use:   "add <name> <command> [args...]",
+	Short: "Add an MCP server that plandex can load context from",
+	Long: `Add an MCP server that plandex can load context from, like a database schema reader or an internal docs search. The server is started with the command and spoken to over stdio. Put '--' before the command if it has flags of its own.
+
+Load a tool's result into context with 'plandex mcp load', or pass --prompt-tool to call a tool with each new prompt, like a docs search with the prompt as its query. Adding a server approves it to run with each prompt on this machine.`,
+	Example: `  plandex mcp add docs --prompt-tool search -- npx -y @acme/docs-mcp
+  plandex mcp add db --env DATABASE_URL=postgres://localhost/app -- ./bin/schema-mcp`,
+	Args: cobra.MinimumNArgs(2),
+	Run:  mcpAdd,
+}
+
+var mcpRmCmd = &cobra.Command{
+	Use:   "rm <name>",
+	Short: "Remove an MCP server",
+	Args:  cobra.ExactArgs(1),
+	Run:   mcpRm,
+}
+
+var mcpApproveCmd = &cobra.Command{
+	Use:   "approve <name>",
+	Short: "Approve an MCP server to run with each prompt",
+	Long: `Approve an MCP server in the project's settings to run with each prompt on this machine.
+
+Servers with a prompt tool are started before every prompt, so one that came with the project, like from a teammate's commit, doesn't run until you approve it. Approval is stored in your home directory, not the project, and changing the server's command, args, env, or prompt tool needs approval again.`,
+	Args: cobra.ExactArgs(1),
+	Run:  mcpApprove,
+}
+
+var mcpToolsCmd = &cobra.Command{
+	Use:   "tools <name>",
+	Short: "List an MCP server's tools",
+	Args:  cobra.ExactArgs(1),
+	Run:   mcpTools,
+}
+
+var mcpLoadCmd = &cobra.Command{
+	Use:   "load <name> <tool> [arg=value...]",
+	Short: "Call a tool on an MCP server and load its result into context",
+	Long: `Call a tool on an MCP server and load its result into context as a note. Loading the same tool's result again replaces it.
+
+Arguments are passed as arg=value. Values that are valid JSON, like numbers, booleans, or arrays, are passed as JSON; anything else is passed as a string.`,
+	Example: `  plandex mcp load db describe_table table=users`,
+	Args:    cobra.MinimumNArgs(2),
+	Run:     mcpLoad,
+}
+
+func mcpLs(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	settings, err := lib.LoadProjectSettings()
+	if err != nil {
+		term.OutputErrorAndExit("Error loading project settings: %v", err)
+	}
+
+	if len(settings.McpServers) == 0 {
+		fmt.Println("🤷‍♂️ No MCP servers")
+		fmt.Println()
+		fmt.Println("Add one with 'plandex mcp add <name> -- <command> [args...]'")
+		return
+	}
+
+	var names []string
+	for name := range settings.McpServers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Name", "Command", "Prompt Tool", "Approved"})
+	anyUnapproved := false
+	for _, name := range names {
+		config := settings.McpServers[name]
+		promptTool := ""
+		approvedLabel := ""
+		if config.PromptTool != "" {
+			promptTool = fmt.Sprintf("%s (%s)", config.PromptTool, config.PromptArg)
+
+			approved, err := lib.IsMcpServerApproved(name, config)
+			if err != nil {
+				term.OutputErrorAndExit("Error checking MCP server approvals: %v", err)
+			}
+			if approved {
+				approvedLabel = "yes"
+			} else {
+				approvedLabel = "no"
+				anyUnapproved = true
+			}
+		}
+		table.Append([]string{name, strings.Join(append([]string{config.Command}, config.Args...), " "), promptTool, approvedLabel})
+	}
+	table.Render()
+
+	if anyUnapproved {
+		fmt.Println()
+		fmt.Println("Servers that aren't approved are skipped with each prompt. Approve one with 'plandex mcp approve <name>'")
+	}
+}
+
+func mcpAdd(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	name := args[0]
+
+	env, err := lib.ParseEnvFlags(mcpEnv)
+	if err != nil {
+		term.OutputErrorAndExit("%v", err)
+	}
+
+	config := &types.McpServerConfig{
+		Command: args[1],
+		Args:    args[2:],
+		Env:     env,
+	}
+	if mcpPromptTool != "" {
+		config.PromptTool = mcpPromptTool
+		config.PromptArg = mcpPromptArg
+	}
+
+	err = lib.UpdateProjectSettings(func(settings *types.CurrentProjectSettings) {
+		if settings.McpServers == nil {
+			settings.McpServers = map[string]*types.McpServerConfig{}
+		}
+		settings.McpServers[name] = config
+	})
+	if err != nil {
+		term.OutputErrorAndExit("Error saving project settings: %v", err)
+	}
+
+	err = lib.ApproveMcpServer(name, config)
+	if err != nil {
+		term.OutputErrorAndExit("Error approving MCP server: %v", err)
+	}
+
+	fmt.Printf("✅ Added MCP server %s\n", name)
+	if config.PromptTool != "" {
+		fmt.Printf("Each new prompt will be passed to %s as '%s', and the result loaded into context\n", config.PromptTool, config.PromptArg)
+	}
+}
+
+func mcpRm(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	name := args[0]
+
+	settings, err := lib.LoadProjectSettings()
+	if err != nil {
+		term.OutputErrorAndExit("Error loading project settings: %v", err)
+	}
+
+	if _, ok := settings.McpServers[name]; !ok {
+		fmt.Printf("🤷‍♂️ No MCP server named %s\n", name)
+		return
+	}
+
+	delete(settings.McpServers, name)
+
+	err = lib.WriteProjectSettings(settings)
+	if err != nil {
+		term.OutputErrorAndExit("Error saving project settings: %v", err)
+	}
+
+	err = lib.RevokeMcpServer(name)
+	if err != nil {
+		term.OutputErrorAndExit("Error removing MCP server approval: %v", err)
+	}
+
+	fmt.Printf("✅ Removed MCP server %s\n", name)
+}
+
+func mcpApprove(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	name := args[0]
+
+	settings, err := lib.LoadProjectSettings()
+	if err != nil {
+		term.OutputErrorAndExit("Error loading project settings: %v", err)
+	}
+
+	config, ok := settings.McpServers[name]
+	if !ok {
+		fmt.Printf("🤷‍♂️ No MCP server named %s\n", name)
+		return
+	}
+
+	fmt.Printf("%s runs:\n\n", name)
+	fmt.Printf("  %s\n", strings.Join(append([]string{config.Command}, config.Args...), " "))
+	if len(config.Env) > 0 {
+		var keys []string
+		for k := range config.Env {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		fmt.Printf("\n  with env: %s\n", strings.Join(keys, ", "))
+	}
+	if config.PromptTool != "" {
+		fmt.Printf("\n  calling %s with each prompt\n", config.PromptTool)
+	}
+	fmt.Println()
+
+	confirmed, err := term.ConfirmYesNo("Allow it to run on this machine?")
+	if err != nil {
+		term.OutputErrorAndExit("Error getting confirmation: %v", err)
+	}
+	if !confirmed {
+		return
+	}
+
+	err = lib.ApproveMcpServer(name, config)
+	if err != nil {
+		term.OutputErrorAndExit("Error approving MCP server: %v", err)
+	}
+
+	fmt.Printf("✅ Approved MCP server %s\n", name)
+}
+
+func mcpTools(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	term.StartSpinner("")
+	client, err := lib.StartMcpClient(args[0])
+	if err != nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("Error starting MCP server: %v", err)
+	}
+	defer client.Close()
+
+	tools, err := client.ListTools()
+	term.StopSpinner()
+	if err != nil {
+		term.OutputErrorAndExit("Error listing tools: %v", err)
+	}
+
+	if len(tools) == 0 {
+		fmt.Printf("🤷‍♂️ %s has no tools\n", args[0])
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(true)
+	table.SetHeader([]string{"Tool", "Arguments", "Description"})
+	for _, tool := range tools {
+		var toolArgs []string
+		if props, ok := tool.InputSchema["properties"].(map[string]any); ok {
+			for arg := range props {
+				toolArgs = append(toolArgs, arg)
+			}
+		}
+		sort.Strings(toolArgs)
+		table.Append([]string{tool.Name, strings.Join(toolArgs, ", "), tool.Description})
+	}
+	table.Render()
+}
+
+func mcpLoad(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		term.OutputErrorAndExit("No current plan")
+	}
+
+	server, tool := args[0], args[1]
+
+	toolArgs := map[string]any{}
+	for _, kv := range args[2:] {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || k == "" {
+			term.OutputErrorAndExit("Invalid argument %s, expected arg=value", kv)
+		}
+
+		var parsed any
+		if json.Unmarshal([]byte(v), &parsed) == nil {
+			toolArgs[k] = parsed
+		} else {
+			toolArgs[k] = v
+		}
+	}
+
+	term.StartSpinner(fmt.Sprintf("🔌 Calling %s on %s...", tool, server))
+	res, err := lib.LoadMcpToolContext(server, tool, toolArgs)
+	term.StopSpinner()
+
+	if err != nil {
+		term.OutputErrorAndExit("%v", err)
+	}
+
+	if res.MaxTokensExceeded {
+		overage := res.TotalTokens - res.MaxTokens
+		term.OutputErrorAndExit("Loading the result would add %d 🪙 and exceed token limit (%d) by %d 🪙", res.TokensAdded, res.MaxTokens, overage)
+	}
+
+	fmt.Printf("✅ Loaded the result of %s from %s into context (%d 🪙)\n", tool, server, res.TokensAdded)
+}
@@ -7,6 +7,7 @@ import (
 	"plandex/auth"
 	"plandex/lib"
 	"plandex/term"
+	"strings"
 
 	"github.com/fatih/color"
 	"github.com/olekukonko/tablewriter"
@@ -16,6 +17,7 @@ import (
 
 func init() {
 	RootCmd.AddCommand(modelsCmd)
+	modelsCmd.AddCommand(modelsAvailableCmd)
 }
 
 var modelsCmd = &cobra.Command{
@@ -24,6 +26,12 @@ var modelsCmd = &cobra.Command{
 	Run:   models,
 }
 
+var modelsAvailableCmd = &cobra.Command{
+	Use:   "available",
+	Short: "List available models",
+	Run:   modelsAvailable,
+}
+
 func models(cmd *cobra.Command, args []string) {
 
 	auth.MustResolveAuthWithOrg()
@@ -38,12 +46,13 @@ func models(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	modelSet := settings.ModelSet
-	if modelSet == nil {
-		modelSet = &shared.DefaultModelSet
+	modelSet, source, resolveErr := lib.ResolveModelSet(settings.ModelSet)
+	if resolveErr != nil {
+		term.OutputErrorAndExit("%v", resolveErr)
 	}
 
 	color.New(color.Bold, term.ColorHiCyan).Println("🤖 Models")
+	fmt.Printf("Using %s\n", source)
 	table := tablewriter.NewWriter(os.Stdout)
 	table.SetAutoWrapText(false)
 	table.SetHeader([]string{"Role", "Provider", "Model", "Temperature", "Top P"})
@@ -64,6 +73,8 @@ func models(cmd *cobra.Command, args []string) {
 	addModelRow(string(shared.ModelRoleName), modelSet.Namer.ModelRoleConfig)
 	addModelRow(string(shared.ModelRoleCommitMsg), modelSet.CommitMsg.ModelRoleConfig)
 	addModelRow(string(shared.ModelRoleExecStatus), modelSet.ExecStatus.ModelRoleConfig)
+	addModelRow(string(shared.ModelRoleChat), modelSet.GetChatRoleConfig())
+	addModelRow(string(shared.ModelRoleReviewer), modelSet.GetReviewerRoleConfig().ModelRoleConfig)
 	table.Render()
 
 	fmt.Println()
@@ -80,7 +91,7 @@ func models(cmd *cobra.Command, args []string) {
 	table.Render()
 	fmt.Println()
 
-	color.New(color.Bold, term.ColorHiCyan).Println("⚙️  Planner Overrides")
+	color.New(color.Bold, term.ColorHiCyan).Println("⚙️  Overrides")
 	table = tablewriter.NewWriter(os.Stdout)
 	table.SetAutoWrapText(false)
 	table.SetHeader([]string{"Name", "Value"})
@@ -99,9 +110,53 @@ func models(cmd *cobra.Command, args []string) {
 	} else {
 		table.Append([]string{"Reserved Output Tokens", fmt.Sprintf("%d", *settings.ModelOverrides.ReservedOutputTokens)})
 	}
+	if settings.ModelOverrides.BuildEditFormat == nil {
+		table.Append([]string{"Build Edit Format", "no override"})
+	} else {
+		table.Append([]string{"Build Edit Format", string(*settings.ModelOverrides.BuildEditFormat)})
+	}
 	table.Render()
 
 	fmt.Println()
-	term.PrintCmds("", "set-model")
+	term.PrintCmds("", "set-model", "models available")
 
 }
+
+func modelsAvailable(cmd *cobra.Command, args []string) {
+	color.New(color.Bold, term.ColorHiCyan).Println("🤖 Available Models")
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Provider", "Model", "Context Window", "$ / 1M Input", "$ / 1M Output", "Capabilities"})
+
+	for _, m := range shared.AvailableModels {
+		pricing := shared.ModelPricingByName[m.ModelName]
+		capabilities := shared.GetModelCapabilities(m.ModelName)
+
+		var caps []string
+		if capabilities.Streaming {
+			caps = append(caps, "streaming")
+		}
+		if capabilities.ToolCalls {
+			caps = append(caps, "tools")
+		}
+		if capabilities.JsonMode {
+			caps = append(caps, "json")
+		}
+		if !capabilities.Streaming {
+			caps = append(caps, "reasoning (no streaming)")
+		}
+
+		table.Append([]string{
+			string(m.Provider),
+			m.ModelName,
+			fmt.Sprintf("%d 🪙", m.MaxTokens),
+			fmt.Sprintf("%.2f", pricing.InputPerMillion),
+			fmt.Sprintf("%.2f", pricing.OutputPerMillion),
+			strings.Join(caps, ", "),
+		})
+	}
+	table.Render()
+
+	fmt.Println()
+	term.PrintCmds("", "set-model", "models")
+}
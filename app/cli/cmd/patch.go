@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var patchOutput string
+
+var patchCmd = &cobra.Command{
+	Use:   "patch",
+	Short: "Export the plan's pending changes as a patch",
+	Long: `Export the current plan's pending changes as a unified patch, including new and deleted files.
+
+The patch is printed on its own, or written to a file with --output, and can be applied without plandex using 'git apply' or 'patch -p1'.`,
+	Args: cobra.NoArgs,
+	Run:  patch,
+}
+
+func init() {
+	RootCmd.AddCommand(patchCmd)
+	patchCmd.Flags().StringVarP(&patchOutput, "output", "o", "", "Write the patch to this file instead of printing it")
+}
+
+func patch(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		fmt.Println("🤷‍♂️ No current plan")
+		return
+	}
+
+	patch, err := lib.GetPlanPatch(lib.CurrentPlanId, lib.CurrentBranch)
+	if err != nil {
+		term.OutputErrorAndExit("Error getting plan patch: %v", err)
+	}
+
+	if strings.TrimSpace(patch) == "" {
+		fmt.Fprintln(os.Stderr, "🤷‍♂️ The plan has no pending changes")
+		return
+	}
+
+	if patchOutput == "" {
+		fmt.Print(patch)
+		return
+	}
+
+	err = os.WriteFile(patchOutput, []byte(patch), 0644)
+	if err != nil {
+		term.OutputErrorAndExit("Error writing patch: %v", err)
+	}
+
+	fmt.Printf("✅ Wrote the plan's changes to %s\n", patchOutput)
+	fmt.Println()
+	fmt.Printf("Apply them elsewhere with 'git apply %s'\n", patchOutput)
+}
@@ -108,12 +108,38 @@ func plans(cmd *cobra.Command, args []string) {
 
 	term.StartSpinner("")
 	plans, apiErr := api.Client.ListPlans(projectIds)
+	var sharedPlans []*shared.Plan
+	if apiErr == nil {
+		sharedPlans, apiErr = api.Client.ListSharedPlans()
+	}
 	term.StopSpinner()
 
 	if apiErr != nil {
 		term.OutputErrorAndExit("Error getting plans: %v", apiErr)
 	}
 
+	// plans teammates shared from projects that aren't in or around this directory
+	listedProjectIds := make(map[string]bool, len(projectIds))
+	for _, id := range projectIds {
+		listedProjectIds[id] = true
+	}
+	var otherSharedPlans []*shared.Plan
+	for _, p := range sharedPlans {
+		if !listedProjectIds[p.ProjectId] {
+			otherSharedPlans = append(otherSharedPlans, p)
+		}
+	}
+
+	ownerNames := lib.GetPlanOwnerNames(append(plans, otherSharedPlans...))
+
+	if len(plans) == 0 && len(otherSharedPlans) > 0 {
+		fmt.Println("🤷‍♂️ No plans in current directory")
+		printOtherSharedPlans(otherSharedPlans, ownerNames)
+		fmt.Println()
+		term.PrintCmds("", "new", "cd")
+		return
+	}
+
 	if len(plans) == 0 {
 		fmt.Println("🤷‍♂️ No plans")
 		fmt.Println()
@@ -177,6 +203,12 @@ func plans(cmd *cobra.Command, args []string) {
 				name = p.Name
 			}
 
+			if !lib.IsOwnPlan(p) {
+				name += " " + color.New(term.ColorHiMagenta).Sprintf("(%s)", lib.PlanOwnerLabel(p, ownerNames))
+			} else if p.SharedWithOrgAt != nil {
+				name += " 👥"
+			}
+
 			currentBranch := currentBranchesByPlanId[p.Id]
 
 			row := []string{
@@ -310,10 +342,24 @@ func plans(cmd *cobra.Command, args []string) {
 		fmt.Println()
 	}
 
+	if len(otherSharedPlans) > 0 {
+		printOtherSharedPlans(otherSharedPlans, ownerNames)
+	}
+
 	fmt.Println()
 	if len(currentProjectPlanIds) > 0 {
-		term.PrintCmds("", "new", "cd", "delete-plan")
+		term.PrintCmds("", "new", "cd", "share", "delete-plan")
 	} else {
 		term.PrintCmds("", "new")
 	}
 }
+
+func printOtherSharedPlans(plans []*shared.Plan, ownerNames map[string]string) {
+	fmt.Println()
+	color.New(color.Bold).Println("Plans shared by teammates")
+	fmt.Println("cd into one by name to review or apply it here")
+
+	for _, p := range plans {
+		fmt.Printf("  %s %s\n", color.New(term.ColorHiCyan).Sprint(p.Name), color.New(term.ColorHiMagenta).Sprintf("(%s)", lib.PlanOwnerLabel(p, ownerNames)))
+	}
+}
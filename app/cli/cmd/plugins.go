@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"plandex/auth"
+	"plandex/fs"
+	"plandex/lib"
+	"plandex/term"
+	"plandex/types"
+	"plandex/version"
+	"sort"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var pluginEnv []string
+
+var pluginsCmd = &cobra.Command{
+	Use:   "plugins",
+	Short: "List command plugins",
+	Long: `List the command plugins you can run as 'plandex <name>'. Any executable named plandex-<name> on your PATH is a plugin, as is any command added for the project with 'plandex plugins add'. Built-in commands take precedence over plugins with the same name.
+
+A plugin runs attached to the terminal with the args after its name, and plandex exits with its exit code. Flags like --plan aren't parsed for it, so use PLANDEX_PLAN to run it on another plan. It gets the current state in its environment:
+
+  PLANDEX_STATE            all of the below, and the account, as JSON
+  PLANDEX_PLUGIN_VERSION   the version of this interface, currently 1
+  PLANDEX_BIN              the plandex executable, for running plandex commands
+  PLANDEX_PROJECT_ROOT     the project's root dir, if in a project
+  PLANDEX_PLAN_ID          the current plan's id, if there is one
+  PLANDEX_BRANCH           the current plan's branch`,
+	Args: cobra.NoArgs,
+	Run:  pluginsLs,
+}
+
+var pluginsAddCmd = &cobra.Command{
+	Use:   "add <name> <command> [args...]",
+	Short: "Add a command plugin for the project",
+	Long:  `Add a command plugin for the project, run as 'plandex <name>'. Put '--' before the command if it has flags of its own. The args 'plandex <name>' is run with are passed after the ones given here. See 'plandex plugins' for the state it gets.`,
+	Example: `  plandex plugins add release -- ./scripts/plandex-release
+  plandex plugins add jira --env JIRA_PROJECT=PAY -- node tools/jira-plugin.js`,
+	Args: cobra.MinimumNArgs(2),
+	Run:  pluginsAdd,
+}
+
+var pluginsRmCmd = &cobra.Command{
+	Use:   "rm <name>",
+	Short: "Remove a command plugin",
+	Args:  cobra.ExactArgs(1),
+	Run:   pluginsRm,
+}
+
+func init() {
+	RootCmd.AddCommand(pluginsCmd)
+	pluginsCmd.AddCommand(pluginsAddCmd)
+	pluginsCmd.AddCommand(pluginsRmCmd)
+
+	pluginsAddCmd.Flags().StringArrayVar(&pluginEnv, "env", nil, "Set an environment variable for the plugin, like --env KEY=value. Values are stored in plain text in .plandex/project.json, so leave secrets out and set them in your own environment, which the plugin inherits")
+}
+
+// runPluginIfFound runs a plugin and exits with its exit code if the first arg isn't a built-in command but is a plugin's name
+func runPluginIfFound() {
+	args := os.Args[1:]
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return
+	}
+
+	if c, _, err := RootCmd.Find(args); err == nil && c != RootCmd {
+		return
+	}
+
+	config := lib.ResolvePlugin(args[0])
+	if config == nil {
+		return
+	}
+
+	// resolved without prompting or creating anything, since a plugin might not need an account or project
+	if _, err := os.Stat(fs.HomeAuthPath); err == nil {
+		auth.MustResolveAuth(false)
+	}
+	if fs.PlandexDir != "" {
+		if _, err := os.Stat(filepath.Join(fs.PlandexDir, "project.json")); err == nil {
+			lib.MaybeResolveProject()
+		}
+	}
+
+	bin, err := os.Executable()
+	if err != nil {
+		bin = os.Args[0]
+	}
+
+	state := &lib.PluginState{
+		PlandexVersion: version.Version,
+		PlandexBin:     bin,
+		Cwd:            fs.Cwd,
+		ProjectRoot:    fs.ProjectRoot,
+		ProjectId:      lib.CurrentProjectId,
+		PlanId:         lib.CurrentPlanId,
+		Branch:         lib.CurrentBranch,
+	}
+	if auth.Current != nil {
+		state.Host = auth.Current.Host
+		state.IsCloud = auth.Current.IsCloud
+		state.OrgId = auth.Current.OrgId
+		state.Email = auth.Current.Email
+	}
+
+	code, err := lib.RunPlugin(config, args[1:], state)
+	if err != nil {
+		term.OutputErrorAndExit("Error running plugin %s: %v", args[0], err)
+	}
+
+	os.Exit(code)
+}
+
+func pluginsLs(cmd *cobra.Command, args []string) {
+	var configured map[string]*types.PluginConfig
+	if fs.PlandexDir != "" {
+		settings, err := lib.LoadProjectSettings()
+		if err == nil {
+			configured = settings.Plugins
+		}
+	}
+
+	pathPlugins := lib.ListPathPlugins()
+
+	commands := map[string]string{}
+	sources := map[string]string{}
+	for name, path := range pathPlugins {
+		commands[name] = path
+		sources[name] = "PATH"
+	}
+	// configured plugins take precedence over ones on the PATH
+	for name, config := range configured {
+		commands[name] = strings.Join(append([]string{config.Command}, config.Args...), " ")
+		sources[name] = "project"
+	}
+
+	var names []string
+	for name := range commands {
+		// shadowed by a built-in command
+		if c, _, err := RootCmd.Find([]string{name}); err == nil && c != RootCmd {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if len(names) == 0 {
+		fmt.Println("🤷‍♂️ No plugins")
+		fmt.Println()
+		fmt.Println("Put plandex-<name> on your PATH, or add one with 'plandex plugins add <name> -- <command> [args...]'")
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Name", "Command", "From"})
+	for _, name := range names {
+		table.Append([]string{name, commands[name], sources[name]})
+	}
+	table.Render()
+}
+
+func pluginsAdd(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	name := args[0]
+	if !lib.IsValidPluginName(name) {
+		term.OutputErrorAndExit("Invalid plugin name %s. Use letters, numbers, dashes, or underscores, and don't start with 'context-'.", name)
+	}
+
+	if c, _, err := RootCmd.Find([]string{name}); err == nil && c != RootCmd {
+		term.OutputErrorAndExit("%s is a built-in command. Pick another name.", name)
+	}
+
+	env, err := lib.ParseEnvFlags(pluginEnv)
+	if err != nil {
+		term.OutputErrorAndExit("%v", err)
+	}
+
+	config := &types.PluginConfig{
+		Command: args[1],
+		Args:    args[2:],
+		Env:     env,
+	}
+
+	err = lib.UpdateProjectSettings(func(settings *types.CurrentProjectSettings) {
+		if settings.Plugins == nil {
+			settings.Plugins = map[string]*types.PluginConfig{}
+		}
+		settings.Plugins[name] = config
+	})
+	if err != nil {
+		term.OutputErrorAndExit("Error saving project settings: %v", err)
+	}
+
+	fmt.Printf("✅ Added plugin %s\n", name)
+	fmt.Printf("Run it with 'plandex %s'\n", name)
+}
+
+func pluginsRm(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	name := args[0]
+
+	settings, err := lib.LoadProjectSettings()
+	if err != nil {
+		term.OutputErrorAndExit("Error loading project settings: %v", err)
+	}
+
+	if _, ok := settings.Plugins[name]; !ok {
+		fmt.Printf("🤷‍♂️ No plugin named %s\n", name)
+		return
+	}
+
+	delete(settings.Plugins, name)
+
+	err = lib.WriteProjectSettings(settings)
+	if err != nil {
+		term.OutputErrorAndExit("Error saving project settings: %v", err)
+	}
+
+	fmt.Printf("✅ Removed plugin %s\n", name)
+}
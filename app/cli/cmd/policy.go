@@ -0,0 +1,323 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var policyCmd = &cobra.Command{
+	Use:   "policy",
+	Short: "Show the current plan's limits and behavior settings, and your org's daily budget",
+	Args:  cobra.NoArgs,
+	Run:   showPolicy,
+}
+
+var setPolicyCmd = &cobra.Command{
+	Use:   "set-policy [setting] [value]",
+	Short: "Update the current plan's limits and behavior settings, or your org's daily budget",
+	Long: `Update the current plan's limits and behavior settings, or your org's daily budget. Leave the value blank to go back to the default.
+
+max-daily-cost applies to every plan in your org, and only org owners and billing admins can change it.`,
+	Args: cobra.MaximumNArgs(2),
+	Run:  setPolicy,
+}
+
+func init() {
+	RootCmd.AddCommand(policyCmd)
+	RootCmd.AddCommand(setPolicyCmd)
+}
+
+func showPolicy(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		fmt.Println("🤷‍♂️ No current plan")
+		return
+	}
+
+	term.StartSpinner("")
+	settings, apiErr := api.Client.GetSettings(lib.CurrentPlanId, lib.CurrentBranch)
+	term.StopSpinner()
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting settings: %v", apiErr)
+		return
+	}
+
+	term.StartSpinner("")
+	budget, apiErr := api.Client.GetOrgBudget()
+	term.StopSpinner()
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting org budget: %v", apiErr.Msg)
+		return
+	}
+
+	policy := settings.Policy
+
+	color.New(color.Bold, term.ColorHiCyan).Println("📏 Plan Policy")
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Name", "Value"})
+	if policy.MaxPlanFiles == nil {
+		table.Append([]string{"Max Plan Files", fmt.Sprintf("default (%d)", shared.DefaultMaxPlanFiles)})
+	} else {
+		table.Append([]string{"Max Plan Files", fmt.Sprintf("%d", *policy.MaxPlanFiles)})
+	}
+	if policy.MaxPlanCost == nil {
+		table.Append([]string{"Max Plan Cost", "no budget"})
+	} else {
+		table.Append([]string{"Max Plan Cost", fmt.Sprintf("$%.2f", *policy.MaxPlanCost)})
+	}
+	if policy.ConvoPolicy == nil {
+		table.Append([]string{"Convo Policy", fmt.Sprintf("default (%s)", shared.ConvoPolicySummarize)})
+	} else {
+		table.Append([]string{"Convo Policy", string(*policy.ConvoPolicy)})
+	}
+	if policy.ConvoKeepLast == nil {
+		table.Append([]string{"Convo Keep Last", fmt.Sprintf("default (%d)", shared.DefaultConvoKeepLast)})
+	} else {
+		table.Append([]string{"Convo Keep Last", fmt.Sprintf("%d", *policy.ConvoKeepLast)})
+	}
+	if policy.MaxConcurrentBuilds == nil {
+		table.Append([]string{"Max Concurrent Builds", "no limit"})
+	} else {
+		table.Append([]string{"Max Concurrent Builds", fmt.Sprintf("%d", *policy.MaxConcurrentBuilds)})
+	}
+	table.Append([]string{"Self Review", fmt.Sprintf("%t", settings.GetSelfReview())})
+	table.Render()
+	fmt.Println()
+
+	color.New(color.Bold, term.ColorHiCyan).Println("🏢 Org Policy")
+	table = tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Name", "Value"})
+	if budget.MaxDailyCost == nil {
+		table.Append([]string{"Max Daily Cost", "no budget"})
+	} else {
+		table.Append([]string{"Max Daily Cost", fmt.Sprintf("$%.2f", *budget.MaxDailyCost)})
+	}
+	table.Render()
+
+	fmt.Println()
+	term.PrintCmds("", "set-policy")
+}
+
+func setPolicy(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	var setting, value string
+
+	var allSettings []string
+	allSettings = append(allSettings, shared.PlanPolicyPropsDasherized...)
+	allSettings = append(allSettings, shared.OrgPolicyPropsDasherized...)
+
+	if len(args) > 0 {
+		for _, s := range allSettings {
+			if shared.Compact(s) == shared.Compact(args[0]) {
+				setting = s
+				break
+			}
+		}
+		if setting == "" {
+			term.OutputErrorAndExit("Unknown policy setting: %s", args[0])
+			return
+		}
+	} else {
+		opts := []string{}
+		for _, s := range allSettings {
+			opts = append(opts, fmt.Sprintf("%s → %s", s, shared.SettingDescriptions[s]))
+		}
+
+		selection, err := term.SelectFromList("Select a setting to update:", opts)
+		if err != nil {
+			if err.Error() == "interrupt" {
+				return
+			}
+
+			term.OutputErrorAndExit("Error selecting setting: %v", err)
+			return
+		}
+
+		for i, opt := range opts {
+			if opt == selection {
+				setting = allSettings[i]
+				break
+			}
+		}
+	}
+
+	if len(args) > 1 {
+		value = args[1]
+	} else {
+		var err error
+		value, err = term.GetUserStringInput(fmt.Sprintf("Set %s (leave blank for the default)", setting))
+		if err != nil {
+			if err.Error() == "interrupt" {
+				return
+			}
+
+			term.OutputErrorAndExit("Error getting value: %v", err)
+			return
+		}
+	}
+
+	if setting == "max-daily-cost" {
+		setOrgMaxDailyCost(value)
+		return
+	}
+
+	if lib.CurrentPlanId == "" {
+		fmt.Println("🤷‍♂️ No current plan")
+		return
+	}
+
+	term.StartSpinner("")
+	originalSettings, apiErr := api.Client.GetSettings(lib.CurrentPlanId, lib.CurrentBranch)
+	term.StopSpinner()
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting current settings: %v", apiErr)
+		return
+	}
+
+	// Marshal and unmarshal to make a deep copy of the settings
+	jsonBytes, err := json.Marshal(originalSettings)
+	if err != nil {
+		term.OutputErrorAndExit("Error marshalling settings: %v", err)
+		return
+	}
+
+	var settings *shared.PlanSettings
+	err = json.Unmarshal(jsonBytes, &settings)
+	if err != nil {
+		term.OutputErrorAndExit("Error unmarshalling settings: %v", err)
+		return
+	}
+
+	err = setPlanPolicyValue(&settings.Policy, setting, value)
+	if err != nil {
+		term.OutputErrorAndExit("%v", err)
+		return
+	}
+
+	if reflect.DeepEqual(originalSettings, settings) {
+		fmt.Println("🤷‍♂️ No policy settings were updated")
+		return
+	}
+
+	term.StartSpinner("")
+	res, apiErr := api.Client.UpdateSettings(
+		lib.CurrentPlanId,
+		lib.CurrentBranch,
+		shared.UpdateSettingsRequest{
+			Settings: settings,
+		})
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error updating settings: %v", apiErr)
+		return
+	}
+
+	fmt.Println(res.Msg)
+	fmt.Println()
+	term.PrintCmds("", "policy", "log", "rewind")
+}
+
+func setPlanPolicyValue(policy *shared.PlanPolicy, setting, value string) error {
+	positiveInt := func() (*int, error) {
+		if value == "" {
+			return nil, nil
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid value for %s: %s", setting, value)
+		}
+		return &n, nil
+	}
+
+	var err error
+	switch setting {
+	case "max-plan-files":
+		policy.MaxPlanFiles, err = positiveInt()
+	case "max-plan-cost":
+		policy.MaxPlanCost, err = parseCost(setting, value)
+	case "convo-policy":
+		policy.ConvoPolicy = nil
+		if value != "" {
+			for _, p := range shared.ConvoPolicies {
+				if strings.EqualFold(string(p), value) {
+					policy.ConvoPolicy = &p
+					break
+				}
+			}
+			if policy.ConvoPolicy == nil {
+				err = fmt.Errorf("invalid value for %s: %s", setting, value)
+			}
+		}
+	case "convo-keep-last":
+		policy.ConvoKeepLast, err = positiveInt()
+	case "max-concurrent-builds":
+		policy.MaxConcurrentBuilds, err = positiveInt()
+	case "self-review":
+		policy.SelfReview = nil
+		if value != "" {
+			enabled, parseErr := strconv.ParseBool(value)
+			if parseErr != nil {
+				err = fmt.Errorf("invalid value for %s: %s", setting, value)
+			} else {
+				policy.SelfReview = &enabled
+			}
+		}
+	}
+
+	return err
+}
+
+func parseCost(setting, value string) (*float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	n, err := strconv.ParseFloat(strings.TrimPrefix(value, "$"), 64)
+	if err != nil || n <= 0 {
+		return nil, fmt.Errorf("invalid value for %s: %s", setting, value)
+	}
+	return &n, nil
+}
+
+func setOrgMaxDailyCost(value string) {
+	cost, err := parseCost("max-daily-cost", value)
+	if err != nil {
+		term.OutputErrorAndExit("%v", err)
+		return
+	}
+
+	term.StartSpinner("")
+	apiErr := api.Client.UpdateOrgBudget(shared.UpdateOrgBudgetRequest{MaxDailyCost: cost})
+	term.StopSpinner()
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error updating org budget: %v", apiErr.Msg)
+		return
+	}
+
+	if cost == nil {
+		fmt.Println("✅ Removed your org's daily budget")
+	} else {
+		fmt.Printf("✅ Your org's plans can now spend up to an estimated $%.2f per day (UTC) on model calls\n", *cost)
+	}
+	fmt.Println()
+	term.PrintCmds("", "policy")
+}
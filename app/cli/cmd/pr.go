@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/fs"
+	"plandex/lib"
+	"plandex/term"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var prBase string
+var prRemote string
+var prTitle string
+var prCommentIssue bool
+
+var prCmd = &cobra.Command{
+	Use:   "pr",
+	Short: "Push the current git branch and open a GitHub pull request",
+	Long: `Push the current git branch and open a GitHub pull request for it, with a title and description written by the model from the branch's diff and the plan's applied changes and prompts. The pull request is recorded on the plan, so teammates working on it can find it.
+
+Apply the plan to its own branch first with 'plandex apply --branch <name>'. Requires a GitHub token in GITHUB_TOKEN or GH_TOKEN.`,
+	Args: cobra.NoArgs,
+	Run:  pr,
+}
+
+func init() {
+	RootCmd.AddCommand(prCmd)
+
+	prCmd.Flags().StringVar(&prBase, "base", "", "Branch to merge into (defaults to the repository's default branch)")
+	prCmd.Flags().StringVar(&prRemote, "remote", "origin", "Git remote to push to")
+	prCmd.Flags().StringVar(&prTitle, "title", "", "Pull request title (generated if not set)")
+	prCmd.Flags().BoolVar(&prCommentIssue, "comment-issue", false, "Comment on the issues linked to the plan with a summary of the changes and a link to the pull request")
+}
+
+func pr(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		fmt.Println("🤷‍♂️ No current plan")
+		return
+	}
+
+	if !fs.ProjectRootIsGitRepo() {
+		term.OutputErrorAndExit("The project isn't in a git repository")
+	}
+
+	if os.Getenv("OPENAI_API_KEY") == "" {
+		term.OutputNoApiKeyMsgAndExit()
+	}
+
+	token := lib.GetGithubToken()
+	if token == "" {
+		term.OutputErrorAndExit("Set GITHUB_TOKEN or GH_TOKEN to a GitHub token that can open pull requests")
+	}
+
+	repo, err := lib.GetGithubRepo(prRemote)
+	if err != nil {
+		term.OutputErrorAndExit("%v", err)
+	}
+
+	gitBranch, err := lib.GetCurrentGitBranch()
+	if err != nil {
+		term.OutputErrorAndExit("%v", err)
+	}
+	if gitBranch == "HEAD" {
+		term.OutputErrorAndExit("Can't open a pull request from a detached HEAD. Apply the plan to a branch with 'plandex apply --branch <name>'")
+	}
+
+	term.StartSpinner("")
+
+	base := prBase
+	if base == "" {
+		base, err = lib.GetGithubDefaultBranch(repo, token)
+		if err != nil {
+			term.StopSpinner()
+			term.OutputErrorAndExit("Error getting default branch: %v", err)
+		}
+	}
+
+	if base == gitBranch {
+		term.StopSpinner()
+		term.OutputErrorAndExit("You're on %s, the pull request's base branch. Apply the plan to its own branch with 'plandex apply --branch <name>'", base)
+	}
+
+	title, body, err := lib.GetPullRequestContent(lib.CurrentPlanId, lib.CurrentBranch, prRemote, base, lib.MustGetPlanModelSet())
+	if err != nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("Error generating pull request: %v", err)
+	}
+	if prTitle != "" {
+		title = prTitle
+	}
+
+	err = lib.GitPushBranch(prRemote, gitBranch)
+	if err != nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("%v", err)
+	}
+
+	res, err := lib.CreateGithubPullRequest(repo, token, gitBranch, base, title, body)
+	term.StopSpinner()
+	if err != nil {
+		term.OutputErrorAndExit("Error opening pull request: %v", err)
+	}
+
+	_, apiErr := api.Client.CreatePlanPullRequest(lib.CurrentPlanId, shared.CreatePlanPullRequestRequest{
+		Url:       res.HtmlUrl,
+		Number:    res.Number,
+		GitBranch: gitBranch,
+		Base:      base,
+	})
+	if apiErr != nil {
+		fmt.Printf("⚠️  Failed to record the pull request on the plan: %v\n", apiErr.Msg)
+	}
+
+	fmt.Printf("✅ Opened pull request #%d: %s → %s\n", res.Number, gitBranch, base)
+	fmt.Println(res.HtmlUrl)
+
+	if prCommentIssue {
+		fmt.Println()
+		lib.MustCommentOnPlanIssues(lib.CurrentPlanId, lib.CurrentBranch)
+	}
+}
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+	"plandex/types"
+	"sort"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var providerEnv []string
+
+var providersCmd = &cobra.Command{
+	Use:   "providers",
+	Short: "List the context providers plandex can load context from",
+	Long: `List the context provider plugins configured for the project. Load context from one with 'plandex load <provider>:<query>'.
+
+A provider is an executable that reads a JSON request on stdin and writes a JSON response on stdout:
+
+  request   {"version": 1, "query": "...", "projectRoot": "...", "planId": "...", "branch": "..."}
+  response  {"items": [{"name": "...", "body": "...", "url": "..."}]}
+
+Each item is loaded as a note named <provider>:<name>, replacing the note loaded under that name before. A provider that fails sets "error" in the response, or exits non-zero with a message on stderr. Providers run in the project's root dir.
+
+Besides the ones added with 'plandex providers add', any executable named plandex-context-<name> on your PATH is a provider.`,
+	Args: cobra.NoArgs,
+	Run:  providersLs,
+}
+
+var providersAddCmd = &cobra.Command{
+	Use:   "add <name> <command> [args...]",
+	Short: "Add a context provider for the project",
+	Long:  `Add a context provider for the project, like a search of an internal wiki, a schema registry, or an API catalog. Put '--' before the command if it has flags of its own. See 'plandex providers' for the protocol it speaks.`,
+	Example: `  plandex providers add wiki -- ./scripts/wiki-search
+  plandex providers add schemas --env REGISTRY_URL=https://registry.internal -- schema-provider --format avro`,
+	Args: cobra.MinimumNArgs(2),
+	Run:  providersAdd,
+}
+
+var providersRmCmd = &cobra.Command{
+	Use:   "rm <name>",
+	Short: "Remove a context provider",
+	Args:  cobra.ExactArgs(1),
+	Run:   providersRm,
+}
+
+func init() {
+	RootCmd.AddCommand(providersCmd)
+	providersCmd.AddCommand(providersAddCmd)
+	providersCmd.AddCommand(providersRmCmd)
+
+	providersAddCmd.Flags().StringArrayVar(&providerEnv, "env", nil, "Set an environment variable for the provider, like --env KEY=value. Values are stored in plain text in .plandex/project.json, so leave secrets out and set them in your own environment, which the provider inherits")
+}
+
+func providersLs(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	settings, err := lib.LoadProjectSettings()
+	if err != nil {
+		term.OutputErrorAndExit("Error loading project settings: %v", err)
+	}
+
+	pathProviders := lib.ListPathContextProviders()
+
+	if len(settings.ContextProviders) == 0 && len(pathProviders) == 0 {
+		fmt.Println("🤷‍♂️ No context providers")
+		fmt.Println()
+		fmt.Println("Add one with 'plandex providers add <name> -- <command> [args...]', or put plandex-context-<name> on your PATH")
+		return
+	}
+
+	commands := map[string]string{}
+	sources := map[string]string{}
+	for name, path := range pathProviders {
+		commands[name] = path
+		sources[name] = "PATH"
+	}
+	// configured providers take precedence over ones on the PATH
+	for name, config := range settings.ContextProviders {
+		commands[name] = strings.Join(append([]string{config.Command}, config.Args...), " ")
+		sources[name] = "project"
+	}
+
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Name", "Command", "From"})
+	for _, name := range names {
+		table.Append([]string{name, commands[name], sources[name]})
+	}
+	table.Render()
+}
+
+func providersAdd(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	name := args[0]
+	if !lib.IsValidContextProviderName(name) {
+		term.OutputErrorAndExit("Invalid provider name %s. Use at least 2 letters, numbers, dashes, or underscores.", name)
+	}
+
+	env, err := lib.ParseEnvFlags(providerEnv)
+	if err != nil {
+		term.OutputErrorAndExit("%v", err)
+	}
+
+	config := &types.ContextProviderConfig{
+		Command: args[1],
+		Args:    args[2:],
+		Env:     env,
+	}
+
+	err = lib.UpdateProjectSettings(func(settings *types.CurrentProjectSettings) {
+		if settings.ContextProviders == nil {
+			settings.ContextProviders = map[string]*types.ContextProviderConfig{}
+		}
+		settings.ContextProviders[name] = config
+	})
+	if err != nil {
+		term.OutputErrorAndExit("Error saving project settings: %v", err)
+	}
+
+	fmt.Printf("✅ Added context provider %s\n", name)
+	fmt.Printf("Load context from it with 'plandex load %s:<query>'\n", name)
+}
+
+func providersRm(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	name := args[0]
+
+	settings, err := lib.LoadProjectSettings()
+	if err != nil {
+		term.OutputErrorAndExit("Error loading project settings: %v", err)
+	}
+
+	if _, ok := settings.ContextProviders[name]; !ok {
+		fmt.Printf("🤷‍♂️ No context provider named %s\n", name)
+		return
+	}
+
+	delete(settings.ContextProviders, name)
+
+	err = lib.WriteProjectSettings(settings)
+	if err != nil {
+		term.OutputErrorAndExit("Error saving project settings: %v", err)
+	}
+
+	fmt.Printf("✅ Removed context provider %s\n", name)
+}
@@ -3,11 +3,15 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"os/signal"
 	"plandex/api"
 	"plandex/auth"
 	"plandex/format"
 	"plandex/lib"
 	"plandex/term"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/olekukonko/tablewriter"
 	"github.com/plandex/plandex/shared"
@@ -20,16 +24,20 @@ var psCmd = &cobra.Command{
 	Run:   ps,
 }
 
+var psWatch bool
+
 func init() {
 	RootCmd.AddCommand(psCmd)
+
+	psCmd.Flags().BoolVarP(&psWatch, "watch", "w", false, "Keep refreshing the list until interrupted, to follow several plans streaming at once")
 }
 
 func ps(cmd *cobra.Command, args []string) {
 	auth.MustResolveAuthWithOrg()
 	lib.MustResolveProject()
 
-	if lib.CurrentPlanId == "" {
-		fmt.Println("🤷‍♂️ No current plan")
+	if psWatch {
+		watchPs()
 		return
 	}
 
@@ -47,7 +55,51 @@ func ps(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	table := tablewriter.NewWriter(os.Stdout)
+	fmt.Print(renderPsTable(res))
+
+	fmt.Println()
+	term.PrintCmds("", "connect", "stop")
+
+}
+
+func watchPs() {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+
+	term.AlternateScreen()
+	defer term.BackToMain()
+
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		res, apiErr := api.Client.ListPlansRunning([]string{lib.CurrentProjectId}, true)
+
+		term.MoveCursorToTopLeft()
+		term.ClearScreen()
+
+		if apiErr != nil {
+			fmt.Printf("Error getting running plans: %v\n", apiErr.Msg)
+		} else if len(res.Branches) == 0 {
+			fmt.Println("🤷‍♂️ No active or recently finished streams")
+		} else {
+			fmt.Print(renderPsTable(res))
+		}
+
+		fmt.Printf("\nUpdated %s · ctrl+c to exit\n", time.Now().Format("15:04:05"))
+
+		select {
+		case <-sigCh:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func renderPsTable(res *shared.ListPlansRunningResponse) string {
+	var buf strings.Builder
+
+	table := tablewriter.NewWriter(&buf)
 	table.SetAutoWrapText(false)
 	table.SetHeader([]string{"Pid", "Plan", "Branch", "Started", "Status"})
 
@@ -66,6 +118,8 @@ func ps(cmd *cobra.Command, args []string) {
 			status = "Stopped " + format.Time(finishedAt)
 		case shared.PlanStatusMissingFile:
 			status = "Missing file"
+		case shared.PlanStatusClarifying:
+			status = "Waiting for answers"
 		}
 
 		row := []string{
@@ -76,8 +130,9 @@ func ps(cmd *cobra.Command, args []string) {
 			status,
 		}
 
+		// the plan this terminal is on, which may differ from other terminals with --plan or PLANDEX_PLAN
 		var style []tablewriter.Colors
-		if b.Name == lib.CurrentPlanId {
+		if b.PlanId == lib.CurrentPlanId && b.Name == lib.CurrentBranch {
 			style = []tablewriter.Colors{
 				{tablewriter.FgGreenColor, tablewriter.Bold},
 			}
@@ -92,7 +147,5 @@ func ps(cmd *cobra.Command, args []string) {
 	}
 	table.Render()
 
-	fmt.Println()
-	term.PrintCmds("", "connect", "stop")
-
+	return buf.String()
 }
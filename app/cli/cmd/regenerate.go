@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"fmt"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/plan_exec"
+	"plandex/term"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var regenModel string
+var regenTemperature float32
+var regenChat bool
+var regenBg bool
+var regenStop bool
+var regenNoBuild bool
+
+var regenerateCmd = &cobra.Command{
+	Use:     "regenerate",
+	Aliases: []string{"regen"},
+	Short:   "Discard the last reply and send its prompt again",
+	Long: `Discard the last reply and send its prompt again, optionally with a different model or temperature.
+
+The plan is rewound to before the last prompt, so the replies, builds, and context changes that followed it are discarded, and the prompt is sent again in their place. Use --chat to regenerate a reply from 'plandex chat'. --model and --temperature apply to the planner, or to the chat model with --chat, for this prompt only.`,
+	Args: cobra.NoArgs,
+	Run:  regenerate,
+}
+
+func init() {
+	RootCmd.AddCommand(regenerateCmd)
+
+	regenerateCmd.Flags().StringVarP(&regenModel, "model", "m", "", "Regenerate with this model")
+	regenerateCmd.Flags().Float32VarP(&regenTemperature, "temperature", "t", 0, "Regenerate with this temperature, from 0 to 2")
+	regenerateCmd.Flags().BoolVar(&regenChat, "chat", false, "Regenerate as a chat reply")
+	regenerateCmd.Flags().BoolVarP(&regenStop, "stop", "s", false, "Stop after a single reply")
+	regenerateCmd.Flags().BoolVarP(&regenNoBuild, "no-build", "n", false, "Don't build files")
+	regenerateCmd.Flags().BoolVar(&regenBg, "bg", false, "Execute autonomously in the background")
+
+	addNotifyFlag(regenerateCmd)
+}
+
+func regenerate(cmd *cobra.Command, args []string) {
+	if !lib.HasApiKey() {
+		term.OutputNoApiKeyMsgAndExit()
+	}
+
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		fmt.Println("🤷‍♂️ No current plan")
+		return
+	}
+
+	prompt := mustGetLastPrompt()
+	if prompt == nil {
+		fmt.Println("🤷‍♂️ No prompt to regenerate")
+		return
+	}
+
+	setTemperature := cmd.Flags().Changed("temperature")
+	if setTemperature && (regenTemperature < 0 || regenTemperature > 2) {
+		term.OutputErrorAndExit("Temperature must be between 0 and 2")
+	}
+
+	var modelSet *shared.ModelSet
+	if regenModel != "" || setTemperature {
+		modelSet = lib.MustGetPlanModelSet()
+
+		role := shared.ModelRolePlanner
+		if regenChat {
+			role = shared.ModelRoleChat
+		}
+
+		if regenModel != "" {
+			model, ok := shared.AvailableModelsByName[regenModel]
+			if !ok {
+				term.OutputErrorAndExit("%s isn't an available model. See 'plandex models available'.", regenModel)
+			}
+			modelSet.SetRoleModel(role, model)
+		}
+
+		if setTemperature {
+			if regenChat {
+				if modelSet.Chat.BaseModelConfig.ModelName == "" {
+					modelSet.Chat = modelSet.GetChatRoleConfig()
+					modelSet.Chat.Role = shared.ModelRoleChat
+				}
+				modelSet.Chat.Temperature = regenTemperature
+			} else {
+				modelSet.Planner.Temperature = regenTemperature
+			}
+		}
+	}
+
+	plan_exec.TellPlan(plan_exec.ExecParams{
+		CurrentPlanId: lib.CurrentPlanId,
+		CurrentBranch: lib.CurrentBranch,
+		CheckOutdatedContext: func(maybeContexts []*shared.Context) (bool, bool) {
+			return lib.MustCheckOutdatedContext(false, maybeContexts)
+		},
+		ChatOnly:          regenChat,
+		ReplaceLastPrompt: true,
+		ModelSetOverride:  modelSet,
+	}, prompt.Message, regenBg, regenStop || regenChat, regenNoBuild || regenChat, false)
+}
+
+// mustGetLastPrompt returns the plan's most recent user message, or nil if there isn't one
+func mustGetLastPrompt() *shared.ConvoMessage {
+	term.StartSpinner("")
+	convo, apiErr := api.Client.ListConvo(lib.CurrentPlanId, lib.CurrentBranch)
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting conversation: %v", apiErr.Msg)
+	}
+
+	for i := len(convo) - 1; i >= 0; i-- {
+		if convo[i].Role == "user" {
+			return convo[i]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/plan_exec"
+	"plandex/term"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var resendChat bool
+var resendBg bool
+var resendStop bool
+var resendNoBuild bool
+
+var resendCmd = &cobra.Command{
+	Use:   "resend",
+	Short: "Edit the last prompt and send it in place of the original",
+	Long: `Open the last prompt in your editor, then send the edited prompt in place of the original.
+
+The plan is rewound to before the last prompt, so the original prompt and the replies, builds, and context changes that followed it are discarded rather than left in the conversation. Use --chat to resend a prompt from 'plandex chat'.`,
+	Args: cobra.NoArgs,
+	Run:  resend,
+}
+
+func init() {
+	RootCmd.AddCommand(resendCmd)
+
+	resendCmd.Flags().BoolVar(&resendChat, "chat", false, "Resend as a chat prompt")
+	resendCmd.Flags().BoolVarP(&resendStop, "stop", "s", false, "Stop after a single reply")
+	resendCmd.Flags().BoolVarP(&resendNoBuild, "no-build", "n", false, "Don't build files")
+	resendCmd.Flags().BoolVar(&resendBg, "bg", false, "Execute autonomously in the background")
+
+	addNotifyFlag(resendCmd)
+}
+
+func resend(cmd *cobra.Command, args []string) {
+	if !lib.HasApiKey() {
+		term.OutputNoApiKeyMsgAndExit()
+	}
+
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		fmt.Println("🤷‍♂️ No current plan")
+		return
+	}
+
+	lastPrompt := mustGetLastPrompt()
+	if lastPrompt == nil {
+		fmt.Println("🤷‍♂️ No prompt to resend")
+		return
+	}
+
+	prompt := getEditedPrompt(lastPrompt.Message)
+	if prompt == "" {
+		fmt.Println("🤷‍♂️ Prompt was empty, so nothing was sent and the plan is unchanged")
+		return
+	}
+
+	plan_exec.TellPlan(plan_exec.ExecParams{
+		CurrentPlanId: lib.CurrentPlanId,
+		CurrentBranch: lib.CurrentBranch,
+		CheckOutdatedContext: func(maybeContexts []*shared.Context) (bool, bool) {
+			return lib.MustCheckOutdatedContext(false, maybeContexts)
+		},
+		ChatOnly:          resendChat,
+		ReplaceLastPrompt: true,
+	}, prompt, resendBg, resendStop || resendChat, resendNoBuild || resendChat, false)
+}
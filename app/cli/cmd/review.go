@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/fs"
+	"plandex/lib"
+	"plandex/term"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var reviewStaged bool
+var reviewBase string
+var reviewFailOn string
+var reviewJson bool
+var reviewSarif bool
+
+var reviewCmd = &cobra.Command{
+	Use:   "review",
+	Short: "Review the current diff for problems",
+	Long: `Review the current diff for problems, along with the full content of the changed files and the current plan's context.
+
+By default, uncommitted changes to tracked files are reviewed. With --staged, only the staged changes are. With --base, the commits on the current branch since it split from the base branch are.
+
+Findings can be output as JSON or SARIF. If any finding is at or above the --fail-on severity, the command exits with status 1, so it can be used as a pre-commit hook or in CI:
+
+  plandex review --staged --fail-on high --json
+  plandex review --base main --sarif > review.sarif`,
+	Args: cobra.NoArgs,
+	Run:  review,
+}
+
+func init() {
+	RootCmd.AddCommand(reviewCmd)
+
+	reviewCmd.Flags().BoolVar(&reviewStaged, "staged", false, "Review the staged changes")
+	reviewCmd.Flags().StringVar(&reviewBase, "base", "", "Review the current branch's commits since it split from this branch")
+	reviewCmd.Flags().StringVar(&reviewFailOn, "fail-on", string(shared.ReviewSeverityHigh), "Exit with status 1 if any finding is at or above this severity: info, low, medium, high, critical, or none")
+	reviewCmd.Flags().BoolVar(&reviewJson, "json", false, "Output findings as JSON")
+	reviewCmd.Flags().BoolVar(&reviewSarif, "sarif", false, "Output findings as SARIF")
+}
+
+func review(cmd *cobra.Command, args []string) {
+	apiKey := os.Getenv("OPENAI_API_KEY")
+	if apiKey == "" {
+		term.OutputNoApiKeyMsgAndExit()
+	}
+
+	if reviewStaged && reviewBase != "" {
+		term.OutputErrorAndExit("Only one of --staged and --base can be used")
+	}
+
+	if reviewJson && reviewSarif {
+		term.OutputErrorAndExit("Only one of --json and --sarif can be used")
+	}
+
+	// machine-readable output goes to stdout alone, so it can be piped or redirected
+	quiet := reviewJson || reviewSarif
+
+	failOn, ok := shared.ParseReviewSeverity(reviewFailOn)
+	if !ok && reviewFailOn != "none" {
+		term.OutputErrorAndExit("Invalid --fail-on severity %s", reviewFailOn)
+	}
+
+	auth.MustResolveAuthWithOrg()
+	lib.MaybeResolveProject()
+
+	dir := fs.ProjectRoot
+	if dir == "" {
+		dir = fs.Cwd
+	}
+
+	src := lib.GitReviewSource{Staged: reviewStaged, Base: reviewBase}
+
+	diff, err := lib.GitReviewDiff(dir, src)
+	if err != nil {
+		term.OutputErrorAndExit("%v", err)
+	}
+
+	if strings.TrimSpace(diff) == "" {
+		if quiet {
+			outputReviewFindings(&shared.ReviewResponse{Findings: []*shared.ReviewFinding{}})
+		} else if reviewStaged {
+			fmt.Println("🤷‍♂️ No staged changes")
+		} else {
+			fmt.Println("🤷‍♂️ No changes to review")
+		}
+		return
+	}
+
+	files, err := lib.GitReviewFiles(dir, src)
+	if err != nil {
+		term.OutputErrorAndExit("%v", err)
+	}
+
+	modelSet := lib.MustGetPlanModelSet()
+
+	var projectPrefix string
+	if lib.CurrentPlanId != "" {
+		projectPrefix, err = lib.GitRepoPrefix(fs.ProjectRoot)
+		if err != nil {
+			term.OutputErrorAndExit("%v", err)
+		}
+	}
+
+	if !quiet {
+		term.StartSpinner("🔎 Reviewing changes...")
+	}
+
+	res, apiErr := api.Client.Review(shared.ReviewRequest{
+		ApiKey:        apiKey,
+		ModelSet:      modelSet,
+		Diff:          diff,
+		Files:         files,
+		PlanId:        lib.CurrentPlanId,
+		Branch:        lib.CurrentBranch,
+		ProjectPrefix: projectPrefix,
+	})
+
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error reviewing changes: %v", apiErr.Msg)
+	}
+
+	findings := res.Findings
+
+	// most severe first, then by location
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Severity.Rank() != findings[j].Severity.Rank() {
+			return findings[i].Severity.Rank() > findings[j].Severity.Rank()
+		}
+		if findings[i].Path != findings[j].Path {
+			return findings[i].Path < findings[j].Path
+		}
+		return findings[i].Line < findings[j].Line
+	})
+
+	numBlocking := 0
+	if ok {
+		for _, finding := range findings {
+			if finding.Severity.Rank() >= failOn.Rank() {
+				numBlocking++
+			}
+		}
+	}
+
+	if quiet {
+		outputReviewFindings(res)
+	} else {
+		printReviewFindings(findings)
+	}
+
+	if numBlocking > 0 {
+		if !quiet {
+			fmt.Println()
+			suffix := ""
+			if numBlocking > 1 {
+				suffix = "s"
+			}
+			color.New(color.Bold, term.ColorHiRed).Printf("❌ %d finding%s at or above %s severity\n", numBlocking, suffix, failOn)
+		}
+		os.Exit(1)
+	}
+}
+
+// outputReviewFindings prints findings as JSON or SARIF
+func outputReviewFindings(res *shared.ReviewResponse) {
+	var v any = res
+	if reviewSarif {
+		v = lib.GetReviewSarif(res.Findings)
+	}
+
+	bytes, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		term.OutputErrorAndExit("Error marshalling findings: %v", err)
+	}
+	fmt.Println(string(bytes))
+}
+
+func printReviewFindings(findings []*shared.ReviewFinding) {
+	if len(findings) == 0 {
+		fmt.Println("✅ No problems found")
+		return
+	}
+
+	severityColors := map[shared.ReviewSeverity]color.Attribute{
+		shared.ReviewSeverityCritical: term.ColorHiRed,
+		shared.ReviewSeverityHigh:     term.ColorHiRed,
+		shared.ReviewSeverityMedium:   term.ColorHiYellow,
+		shared.ReviewSeverityLow:      term.ColorHiCyan,
+		shared.ReviewSeverityInfo:     color.FgHiBlack,
+	}
+
+	for i, finding := range findings {
+		if i > 0 {
+			fmt.Println()
+		}
+
+		location := finding.Path
+		if finding.Line > 0 {
+			location = fmt.Sprintf("%s:%d", finding.Path, finding.Line)
+		}
+
+		color.New(color.Bold, severityColors[finding.Severity]).Printf("[%s] ", strings.ToUpper(string(finding.Severity)))
+		color.New(color.Bold).Println(location)
+		fmt.Println(finding.Message)
+		if finding.Suggestion != "" {
+			fmt.Println("💡 " + finding.Suggestion)
+		}
+	}
+}
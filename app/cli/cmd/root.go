@@ -3,6 +3,7 @@ package cmd
 import (
 	"log"
 
+	"plandex/lib"
 	"plandex/term"
 
 	"github.com/spf13/cobra"
@@ -20,6 +21,8 @@ var RootCmd = &cobra.Command{
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
+	runPluginIfFound()
+
 	if err := RootCmd.Execute(); err != nil {
 		// term.OutputErrorAndExit("Error executing root command: %v", err)
 		log.Fatalf("Error executing root command: %v", err)
@@ -41,4 +44,12 @@ func init() {
 	}
 
 	RootCmd.AddCommand(helpCmd)
+
+	RootCmd.PersistentFlags().StringVar(&lib.PlanFlag, "plan", "", "Run the command on this plan (name or id) instead of the current plan. Can also be set for a whole terminal with PLANDEX_PLAN")
+	RootCmd.PersistentFlags().BoolVar(&lib.MockEnabled, "mock", false, "Simulate model replies and builds instead of calling a model (the server must allow it)")
+}
+
+// addNotifyFlag adds --notify to a command that streams a plan's replies or builds
+func addNotifyFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&lib.NotifyEnabled, "notify", false, "Show a desktop notification when the plan's stream finishes or fails, and post it to PLANDEX_NOTIFY_WEBHOOK if set")
 }
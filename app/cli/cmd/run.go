@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// exit codes for 'plandex run', so CI can tell the outcomes apart
+const (
+	runExitApplied     = 0
+	runExitPlanFailed  = 2
+	runExitBuildFailed = 3
+	runExitNoChanges   = 4
+)
+
+var runPromptFile string
+var runGitBranch string
+var runReportPath string
+var runAllowRisky bool
+var runNoApply bool
+
+var runCmd = &cobra.Command{
+	Use:   "run [prompt]",
+	Short: "Plan, build, and apply a prompt to a git branch without any interaction",
+	Long: `Plan, build, and apply a prompt to a git branch without any interaction, for CI and bots.
+
+Sends the prompt to the current plan, waits for the plan and its builds to finish, then applies and commits the changes on a new git branch. A JSON report is written to --report.
+
+Files flagged for risky operations like network calls, shell commands, hard-coded credentials, or dependency changes stop the run unless --allow-risky is passed. The flags are listed in the report.
+
+With --no-apply, the run stops once the changes are built, leaving them pending in the plan, and reports them as ready.
+
+Exit codes: 0 applied (or ready with --no-apply), 2 plan failed, 3 build failed, 4 no changes. Other errors exit with 1.`,
+	Args: cobra.RangeArgs(0, 1),
+	Run:  runHeadless,
+}
+
+func init() {
+	RootCmd.AddCommand(runCmd)
+
+	runCmd.Flags().StringVarP(&runPromptFile, "file", "f", "", "File containing prompt")
+	runCmd.Flags().StringVar(&runGitBranch, "branch", "", "Git branch to apply to (defaults to plandex/run-<timestamp>)")
+	runCmd.Flags().StringVar(&runReportPath, "report", "plandex-report.json", "Path to write the JSON report to")
+	runCmd.Flags().BoolVar(&runAllowRisky, "allow-risky", false, "Apply files flagged for risky operations instead of failing")
+	runCmd.Flags().BoolVar(&runNoApply, "no-apply", false, "Stop once the changes are built, leaving them pending in the plan")
+
+	addNotifyFlag(runCmd)
+}
+
+func runHeadless(cmd *cobra.Command, args []string) {
+	if !lib.HasApiKey() {
+		term.OutputNoApiKeyMsgAndExit()
+	}
+
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		term.OutputErrorAndExit("No current plan. Create one with 'plandex new' first")
+	}
+
+	var prompt string
+	if len(args) > 0 {
+		prompt = args[0]
+	} else if runPromptFile != "" {
+		bytes, err := os.ReadFile(runPromptFile)
+		if err != nil {
+			term.OutputErrorAndExit("Error reading prompt file: %v", err)
+		}
+		prompt = string(bytes)
+	}
+	if prompt == "" {
+		term.OutputErrorAndExit("A prompt is required, either as an argument or with --file")
+	}
+
+	gitBranch := runGitBranch
+	if gitBranch == "" {
+		gitBranch = "plandex/run-" + time.Now().Format("20060102-150405")
+	}
+
+	report := &lib.RunReport{StartedAt: time.Now()}
+
+	fmt.Println("🚀 Running plan...")
+	lib.RunPlanAndBuild(prompt, report)
+
+	switch report.Status {
+	case lib.RunStatusPlanFailed:
+		finishRun(report, runExitPlanFailed)
+	case lib.RunStatusBuildFailed:
+		finishRun(report, runExitBuildFailed)
+	case lib.RunStatusNoChanges:
+		finishRun(report, runExitNoChanges)
+	}
+
+	if runNoApply {
+		report.Status = lib.RunStatusReady
+		finishRun(report, runExitApplied)
+	}
+
+	report.GitBranch = gitBranch
+	report.Status = lib.RunStatusApplyFailed
+	writeRunReport(report)
+
+	// the apply exits on failure, so the report is finished from an exit hook to record why
+	removeHook := term.OnExit(func() {
+		report.FinishedAt = time.Now()
+		writeRunReport(report)
+	})
+
+	lib.MustApplyPlan(lib.CurrentPlanId, lib.CurrentBranch, lib.ApplyFlags{
+		AutoConfirm: true,
+		GitBranch:   gitBranch,
+		AllowRisky:  runAllowRisky,
+		NoPrompt:    true,
+		Report:      report,
+	})
+	removeHook()
+
+	info, err := lib.LoadPlanInfo(lib.CurrentPlanId)
+	if err == nil {
+		for _, commit := range info.AppliedCommits {
+			if commit.GitBranch == gitBranch && !commit.CreatedAt.Before(report.StartedAt) {
+				report.CommitSha = commit.Sha
+			}
+		}
+	}
+
+	report.Status = lib.RunStatusApplied
+	finishRun(report, runExitApplied)
+}
+
+func finishRun(report *lib.RunReport, code int) {
+	report.FinishedAt = time.Now()
+	writeRunReport(report)
+
+	fmt.Println()
+	if report.Error != "" {
+		fmt.Printf("❌ %s: %s\n", report.Status, report.Error)
+	} else {
+		fmt.Printf("✅ %s\n", report.Status)
+	}
+	if runReportPath != "" {
+		fmt.Printf("📄 Report written to %s\n", runReportPath)
+	}
+
+	os.Exit(code)
+}
+
+func writeRunReport(report *lib.RunReport) {
+	if runReportPath == "" {
+		return
+	}
+
+	bytes, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		term.OutputErrorAndExit("Error marshalling report: %v", err)
+	}
+
+	err = os.WriteFile(runReportPath, bytes, 0644)
+	if err != nil {
+		term.OutputErrorAndExit("Error writing report: %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"plandex/auth"
+	"plandex/fs"
+	"plandex/lib"
+	"plandex/term"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var serveEditorSocket string
+var serveEditorPort int
+
+var serveEditorCmd = &cobra.Command{
+	Use:   "serve-editor",
+	Short: "Serve a local JSON-RPC endpoint for editor extensions",
+	Long: `Serve a local JSON-RPC 2.0 endpoint that editor extensions can use to drive the project's current plan and render its stream in the editor.
+
+It listens on a Unix socket at .plandex/editor.sock, or the path set with --socket. With --port, it listens on that port on 127.0.0.1 instead. Since any local user can connect to a port, a random token is then written to .plandex/editor.token, readable only by you, and every request has to include it in its params as "token".
+
+Messages are newline-delimited JSON. These methods are available:
+
+  tell         send a prompt: {"prompt": "...", "stop": false, "noBuild": false}
+  connect      connect to the plan's running stream
+  stop         stop the plan's running stream
+  status       the current plan and branch, whether it's streaming, and its pending changes
+  getDiff      a diff of the pending changes: {"diff": "..."}
+  apply        apply the pending changes: {"gitBranch": "", "commit": true, "allowRisky": false}
+  proposeEdit  change a range of a file: {"path": "main.go", "startLine": 10, "endLine": 20, "instruction": "..."}
+
+proposeEdit is for inline actions like "fix this function". It waits for the plan to change the lines and returns the change as a ranged edit, {"path", "startLine", "endLine", "original", "text"}, which replaces lines startLine to endLine with text. If the plan changed lines outside the range, the edit covers them too and outsideSelection is true. The file's change is then rejected from the plan, since the editor applies the edit itself, unless "keepPending" is true. Changes to other files are left pending and listed in otherFiles.
+
+After tell or connect, each message of the stream is sent to the connection as a "stream" notification with the plan id, branch, and message, until a finished, error, or aborted message.`,
+	Args: cobra.NoArgs,
+	Run:  serveEditor,
+}
+
+func init() {
+	RootCmd.AddCommand(serveEditorCmd)
+
+	serveEditorCmd.Flags().StringVar(&serveEditorSocket, "socket", "", "Listen on a Unix socket at this path (default .plandex/editor.sock)")
+	serveEditorCmd.Flags().IntVar(&serveEditorPort, "port", 0, "Listen on this port on 127.0.0.1 instead of a Unix socket")
+}
+
+func serveEditor(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	var listener net.Listener
+	var addr, token string
+	var err error
+
+	if serveEditorPort != 0 {
+		tokenPath := filepath.Join(fs.PlandexDir, "editor.token")
+		token, err = lib.NewEditorToken(tokenPath)
+		if err != nil {
+			term.OutputErrorAndExit("Error creating editor token: %v", err)
+		}
+		defer os.Remove(tokenPath)
+
+		addr = fmt.Sprintf("127.0.0.1:%d", serveEditorPort)
+		listener, err = net.Listen("tcp", addr)
+		if err != nil {
+			term.OutputErrorAndExit("Error listening on %s: %v", addr, err)
+		}
+	} else {
+		addr = serveEditorSocket
+		if addr == "" {
+			addr = filepath.Join(fs.PlandexDir, "editor.sock")
+		}
+		listener = mustListenEditorSocket(addr)
+		defer os.Remove(addr)
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		listener.Close()
+	}()
+
+	fmt.Printf("🔌 Serving editor requests on %s · ctrl+c to exit\n", addr)
+
+	err = lib.ServeEditor(listener, token)
+	if err != nil {
+		term.OutputErrorAndExit("Error serving editor requests: %v", err)
+	}
+}
+
+// mustListenEditorSocket listens on a socket only the current user can connect to. A socket left behind by a server that exited without cleaning up is replaced, but one that's still being served is an error.
+func mustListenEditorSocket(path string) net.Listener {
+	if _, err := os.Stat(path); err == nil {
+		conn, err := net.DialTimeout("unix", path, time.Second)
+		if err == nil {
+			conn.Close()
+			term.OutputErrorAndExit("Another 'plandex serve-editor' is already listening on %s", path)
+		}
+
+		err = os.Remove(path)
+		if err != nil {
+			term.OutputErrorAndExit("Error removing stale socket %s: %v", path, err)
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		term.OutputErrorAndExit("Error listening on %s: %v", path, err)
+	}
+
+	err = os.Chmod(path, 0600)
+	if err != nil {
+		listener.Close()
+		term.OutputErrorAndExit("Error setting permissions on %s: %v", path, err)
+	}
+
+	return listener
+}
@@ -8,6 +8,7 @@ import (
 	"plandex/auth"
 	"plandex/lib"
 	"plandex/term"
+	"plandex/types"
 	"reflect"
 	"strconv"
 	"strings"
@@ -16,8 +17,11 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var setModelGlobal bool
+
 func init() {
 	RootCmd.AddCommand(modelsSetCmd)
+	modelsSetCmd.Flags().BoolVarP(&setModelGlobal, "global", "g", false, "Update the global default models used by plans without their own model settings")
 }
 
 var modelsSetCmd = &cobra.Command{
@@ -28,16 +32,38 @@ var modelsSetCmd = &cobra.Command{
 }
 
 func modelsSet(cmd *cobra.Command, args []string) {
-	auth.MustResolveAuthWithOrg()
-	lib.MustResolveProject()
+	var originalSettings *shared.PlanSettings
+	var config *types.ClientConfig
 
-	term.StartSpinner("")
-	originalSettings, apiErr := api.Client.GetSettings(lib.CurrentPlanId, lib.CurrentBranch)
-	term.StopSpinner()
+	if setModelGlobal {
+		var err error
+		config, err = lib.LoadClientConfig()
+		if err != nil {
+			term.OutputErrorAndExit("Error loading config: %v", err)
+			return
+		}
 
-	if apiErr != nil {
-		term.OutputErrorAndExit("Error getting current settings: %v", apiErr)
-		return
+		originalSettings = &shared.PlanSettings{
+			ModelSet: config.DefaultModelSet,
+		}
+	} else {
+		auth.MustResolveAuthWithOrg()
+		lib.MustResolveProject()
+
+		if lib.CurrentPlanId == "" {
+			fmt.Println("🤷‍♂️ No current plan")
+			return
+		}
+
+		var apiErr *shared.ApiError
+		term.StartSpinner("")
+		originalSettings, apiErr = api.Client.GetSettings(lib.CurrentPlanId, lib.CurrentBranch)
+		term.StopSpinner()
+
+		if apiErr != nil {
+			term.OutputErrorAndExit("Error getting current settings: %v", apiErr)
+			return
+		}
 	}
 
 	// Marshal and unmarshal to make a deep copy of the settings
@@ -90,6 +116,10 @@ func modelsSet(cmd *cobra.Command, args []string) {
 			opts = append(opts, label)
 		}
 		for _, setting := range shared.ModelOverridePropsDasherized {
+			if setModelGlobal {
+				// overrides are only stored per-plan
+				break
+			}
 			label := fmt.Sprintf("⚙️  override | %s → %s", shared.Dasherize(setting), shared.SettingDescriptions[setting])
 			opts = append(opts, label)
 		}
@@ -135,6 +165,11 @@ func modelsSet(cmd *cobra.Command, args []string) {
 		value = args[2]
 	}
 
+	if settingCompact != "" && setModelGlobal {
+		term.OutputErrorAndExit("%s can only be set for the current plan, not globally", settingDasherized)
+		return
+	}
+
 	if settingCompact != "" {
 		if value == "" {
 			var err error
@@ -183,6 +218,23 @@ func modelsSet(cmd *cobra.Command, args []string) {
 				}
 				settings.ModelOverrides.ReservedOutputTokens = &n
 			}
+		case "buildeditformat":
+			if value == "" {
+				settings.ModelOverrides.BuildEditFormat = nil
+			} else {
+				var format shared.BuildEditFormat
+				for _, f := range shared.BuildEditFormats {
+					if strings.EqualFold(string(f), value) {
+						format = f
+						break
+					}
+				}
+				if format == "" {
+					fmt.Println("Invalid value for build-edit-format:", value)
+					return
+				}
+				settings.ModelOverrides.BuildEditFormat = &format
+			}
 		}
 	}
 
@@ -286,6 +338,18 @@ func modelsSet(cmd *cobra.Command, args []string) {
 			}
 		}
 
+		if selectedModel != nil {
+			err := shared.CheckRoleModel(role, selectedModel.ModelName)
+			if err != nil {
+				term.OutputErrorAndExit("%v", err)
+				return
+			}
+		}
+
+		if settings.ModelSet == nil && !setModelGlobal {
+			settings.ModelSet = lib.MustGetDefaultModelSet()
+		}
+
 		if settings.ModelSet == nil {
 			settings.ModelSet = &shared.DefaultModelSet
 		}
@@ -293,8 +357,7 @@ func modelsSet(cmd *cobra.Command, args []string) {
 		switch role {
 		case shared.ModelRolePlanner:
 			if selectedModel != nil {
-				settings.ModelSet.Planner.BaseModelConfig = *selectedModel
-				settings.ModelSet.Planner.PlannerModelConfig = shared.PlannerModelConfigByName[selectedModel.ModelName]
+				settings.ModelSet.SetRoleModel(role, *selectedModel)
 			} else if temperature != nil {
 				settings.ModelSet.Planner.Temperature = float32(*temperature)
 			} else if topP != nil {
@@ -303,7 +366,7 @@ func modelsSet(cmd *cobra.Command, args []string) {
 
 		case shared.ModelRolePlanSummary:
 			if selectedModel != nil {
-				settings.ModelSet.PlanSummary.BaseModelConfig = *selectedModel
+				settings.ModelSet.SetRoleModel(role, *selectedModel)
 			} else if temperature != nil {
 				settings.ModelSet.PlanSummary.Temperature = float32(*temperature)
 			} else if topP != nil {
@@ -312,8 +375,7 @@ func modelsSet(cmd *cobra.Command, args []string) {
 
 		case shared.ModelRoleBuilder:
 			if selectedModel != nil {
-				settings.ModelSet.Builder.BaseModelConfig = *selectedModel
-				settings.ModelSet.Builder.TaskModelConfig = shared.TaskModelConfigByName[selectedModel.ModelName]
+				settings.ModelSet.SetRoleModel(role, *selectedModel)
 			} else if temperature != nil {
 				settings.ModelSet.Builder.Temperature = float32(*temperature)
 			} else if topP != nil {
@@ -322,8 +384,7 @@ func modelsSet(cmd *cobra.Command, args []string) {
 
 		case shared.ModelRoleName:
 			if selectedModel != nil {
-				settings.ModelSet.Namer.BaseModelConfig = *selectedModel
-				settings.ModelSet.Namer.TaskModelConfig = shared.TaskModelConfigByName[selectedModel.ModelName]
+				settings.ModelSet.SetRoleModel(role, *selectedModel)
 			} else if temperature != nil {
 				settings.ModelSet.Namer.Temperature = float32(*temperature)
 			} else if topP != nil {
@@ -332,8 +393,7 @@ func modelsSet(cmd *cobra.Command, args []string) {
 
 		case shared.ModelRoleCommitMsg:
 			if selectedModel != nil {
-				settings.ModelSet.CommitMsg.BaseModelConfig = *selectedModel
-				settings.ModelSet.CommitMsg.TaskModelConfig = shared.TaskModelConfigByName[selectedModel.ModelName]
+				settings.ModelSet.SetRoleModel(role, *selectedModel)
 			} else if temperature != nil {
 				settings.ModelSet.CommitMsg.Temperature = float32(*temperature)
 			} else if topP != nil {
@@ -342,13 +402,37 @@ func modelsSet(cmd *cobra.Command, args []string) {
 
 		case shared.ModelRoleExecStatus:
 			if selectedModel != nil {
-				settings.ModelSet.ExecStatus.BaseModelConfig = *selectedModel
-				settings.ModelSet.ExecStatus.TaskModelConfig = shared.TaskModelConfigByName[selectedModel.ModelName]
+				settings.ModelSet.SetRoleModel(role, *selectedModel)
 			} else if temperature != nil {
 				settings.ModelSet.ExecStatus.Temperature = float32(*temperature)
 			} else if topP != nil {
 				settings.ModelSet.ExecStatus.TopP = float32(*topP)
 			}
+
+		case shared.ModelRoleChat:
+			if settings.ModelSet.Chat.BaseModelConfig.ModelName == "" {
+				settings.ModelSet.Chat = settings.ModelSet.GetChatRoleConfig()
+				settings.ModelSet.Chat.Role = shared.ModelRoleChat
+			}
+			if selectedModel != nil {
+				settings.ModelSet.SetRoleModel(role, *selectedModel)
+			} else if temperature != nil {
+				settings.ModelSet.Chat.Temperature = float32(*temperature)
+			} else if topP != nil {
+				settings.ModelSet.Chat.TopP = float32(*topP)
+			}
+
+		case shared.ModelRoleReviewer:
+			if settings.ModelSet.Reviewer.BaseModelConfig.ModelName == "" {
+				settings.ModelSet.Reviewer = settings.ModelSet.GetReviewerRoleConfig()
+			}
+			if selectedModel != nil {
+				settings.ModelSet.SetRoleModel(role, *selectedModel)
+			} else if temperature != nil {
+				settings.ModelSet.Reviewer.Temperature = float32(*temperature)
+			} else if topP != nil {
+				settings.ModelSet.Reviewer.TopP = float32(*topP)
+			}
 		}
 	}
 
@@ -357,6 +441,20 @@ func modelsSet(cmd *cobra.Command, args []string) {
 		return
 	}
 
+	if setModelGlobal {
+		config.DefaultModelSet = settings.ModelSet
+		err = lib.WriteClientConfig(config)
+		if err != nil {
+			term.OutputErrorAndExit("Error updating global model settings: %v", err)
+			return
+		}
+
+		fmt.Println("✅ Updated global default models")
+		fmt.Println()
+		term.PrintCmds("", "models", "models available")
+		return
+	}
+
 	term.StartSpinner("")
 	res, apiErr := api.Client.UpdateSettings(
 		lib.CurrentPlanId,
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var shareCmd = &cobra.Command{
+	Use:   "share",
+	Short: "Share the current plan with your org",
+	Long:  `Share the current plan with everyone in your org. Teammates see it in 'plandex plans' and can open it with 'plandex cd <name>' from their own checkout, even if it's in another project, then review its changes and apply them. Use --plan to share another plan.`,
+	Args:  cobra.NoArgs,
+	Run:   share,
+}
+
+var unshareCmd = &cobra.Command{
+	Use:   "unshare",
+	Short: "Stop sharing the current plan with your org",
+	Args:  cobra.NoArgs,
+	Run:   unshare,
+}
+
+func init() {
+	RootCmd.AddCommand(shareCmd)
+	RootCmd.AddCommand(unshareCmd)
+}
+
+func share(cmd *cobra.Command, args []string) {
+	setPlanShared(true)
+}
+
+func unshare(cmd *cobra.Command, args []string) {
+	setPlanShared(false)
+}
+
+func setPlanShared(share bool) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		fmt.Println("🤷‍♂️ No current plan")
+		return
+	}
+
+	term.StartSpinner("")
+	plan, apiErr := api.Client.GetPlan(lib.CurrentPlanId)
+	if apiErr == nil {
+		if share {
+			apiErr = api.Client.SharePlan(lib.CurrentPlanId)
+		} else {
+			apiErr = api.Client.UnsharePlan(lib.CurrentPlanId)
+		}
+	}
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error updating plan: %v", apiErr.Msg)
+	}
+
+	name := color.New(color.Bold, term.ColorHiGreen).Sprint(plan.Name)
+
+	if !share {
+		fmt.Printf("✅ Stopped sharing %s\n", name)
+		return
+	}
+
+	fmt.Printf("✅ Shared %s with %s\n", name, auth.Current.OrgName)
+	fmt.Printf("Teammates can open it with 'plandex cd %s'\n", plan.Name)
+	fmt.Println()
+	term.PrintCmds("", "unshare")
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/term"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var slackIncludePrompts bool
+
+var slackCmd = &cobra.Command{
+	Use:   "slack",
+	Short: "Show whether your org's plan notifications are posted to Slack",
+	Args:  cobra.NoArgs,
+	Run:   showSlack,
+}
+
+var slackSetCmd = &cobra.Command{
+	Use:   "set <webhook-url>",
+	Short: "Post your org's finished and failed plans to a Slack incoming webhook",
+	Long:  `Post your org's finished and failed plans to a Slack incoming webhook, with each plan's name and branch, how many files were built, the tokens and cost used, and the command to review the changes. Prompts are left out unless you pass --include-prompts. Only org owners and admins can change this.`,
+	Args:  cobra.ExactArgs(1),
+	Run:   setSlack,
+}
+
+var slackUnsetCmd = &cobra.Command{
+	Use:   "unset",
+	Short: "Stop posting your org's plans to Slack",
+	Args:  cobra.NoArgs,
+	Run:   unsetSlack,
+}
+
+func init() {
+	RootCmd.AddCommand(slackCmd)
+	slackCmd.AddCommand(slackSetCmd)
+	slackCmd.AddCommand(slackUnsetCmd)
+
+	slackSetCmd.Flags().BoolVar(&slackIncludePrompts, "include-prompts", false, "Include each plan's prompt in its notification")
+}
+
+func showSlack(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+
+	term.StartSpinner("")
+	settings, apiErr := api.Client.GetOrgSlackSettings()
+	term.StopSpinner()
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting Slack settings: %v", apiErr.Msg)
+	}
+
+	if !settings.Enabled {
+		fmt.Println("🔕 Plan notifications aren't posted to Slack")
+		fmt.Println()
+		term.PrintCmds("", "slack set")
+		return
+	}
+
+	if settings.IncludePrompts {
+		fmt.Println("🔔 Finished and failed plans are posted to Slack, with their prompts")
+	} else {
+		fmt.Println("🔔 Finished and failed plans are posted to Slack, without their prompts")
+	}
+}
+
+func setSlack(cmd *cobra.Command, args []string) {
+	updateSlack(shared.UpdateOrgSlackSettingsRequest{
+		WebhookUrl:     args[0],
+		IncludePrompts: slackIncludePrompts,
+	})
+	fmt.Println("✅ Finished and failed plans will be posted to Slack")
+}
+
+func unsetSlack(cmd *cobra.Command, args []string) {
+	updateSlack(shared.UpdateOrgSlackSettingsRequest{})
+	fmt.Println("✅ Plans will no longer be posted to Slack")
+}
+
+func updateSlack(req shared.UpdateOrgSlackSettingsRequest) {
+	auth.MustResolveAuthWithOrg()
+
+	term.StartSpinner("")
+	apiErr := api.Client.UpdateOrgSlackSettings(req)
+	term.StopSpinner()
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error updating Slack settings: %v", apiErr.Msg)
+	}
+}
@@ -1 +1,121 @@
 package cmd
+
+import (
+	"fmt"
+	"os"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+	"strconv"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var statusCmd = &cobra.Command{
+	Use:     "status",
+	Aliases: []string{"s"},
+	Short:   "Show the status of the current plan",
+	Long: `Show the current plan's branch, context, and pending changes, along with the tokens it has used and their estimated cost.
+
+Usage is read from the server's records, so it includes streams that ran on other machines or while you weren't connected.`,
+	Args: cobra.NoArgs,
+	Run:  status,
+}
+
+func init() {
+	RootCmd.AddCommand(statusCmd)
+}
+
+func status(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		fmt.Println("🤷‍♂️ No current plan")
+		return
+	}
+
+	term.StartSpinner("")
+	plan, apiErr := api.Client.GetPlan(lib.CurrentPlanId)
+	if apiErr != nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("Error getting plan: %v", apiErr.Msg)
+	}
+
+	branches, apiErr := api.Client.GetCurrentBranchByPlanId(lib.CurrentProjectId, shared.GetCurrentBranchByPlanIdRequest{
+		CurrentBranchByPlanId: map[string]string{
+			lib.CurrentPlanId: lib.CurrentBranch,
+		},
+	})
+	if apiErr != nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("Error getting current branch: %v", apiErr.Msg)
+	}
+
+	currentPlanState, apiErr := api.Client.GetCurrentPlanState(lib.CurrentPlanId, lib.CurrentBranch)
+	term.StopSpinner()
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting current plan state: %v", apiErr.Msg)
+	}
+
+	totals, err := lib.GetPlanUsageTotals(lib.CurrentPlanId)
+	if err != nil {
+		term.OutputErrorAndExit("Error getting plan usage: %v", err)
+	}
+
+	fmt.Printf("%s on branch %s\n", color.New(color.Bold, term.ColorHiGreen).Sprint(plan.Name), color.New(color.Bold, term.ColorHiCyan).Sprint(lib.CurrentBranch))
+	fmt.Println()
+
+	branch := branches[lib.CurrentPlanId]
+	if branch != nil {
+		fmt.Printf("Context: %d 🪙\n", branch.ContextTokens)
+		fmt.Printf("Convo: %d 🪙\n", branch.ConvoTokens)
+	}
+
+	numPending := 0
+	if currentPlanState.CurrentPlanFiles != nil {
+		numPending = len(currentPlanState.CurrentPlanFiles.Files) + len(currentPlanState.CurrentPlanFiles.Removed)
+	}
+	fmt.Printf("Pending changes: %d file(s)\n", numPending)
+	if numCommands := len(lib.GetPendingCommands(currentPlanState.ConvoMessageDescriptions)); numCommands > 0 {
+		fmt.Printf("Suggested commands: %d not run yet (%s)\n", numCommands, color.New(color.Bold, term.ColorHiCyan).Sprint("plandex commands"))
+	}
+	fmt.Println()
+
+	if len(totals) == 0 {
+		fmt.Println("🤷‍♂️ No usage recorded for this plan yet")
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Phase", "Prompt", "Completion", "Cost"})
+
+	var total shared.UsageTotal
+	for _, phase := range shared.UsagePhases {
+		phaseTotal, ok := totals[phase]
+		if !ok {
+			continue
+		}
+		total.AddTotal(*phaseTotal)
+
+		table.Append([]string{
+			string(phase),
+			strconv.Itoa(phaseTotal.PromptTokens) + " 🪙",
+			strconv.Itoa(phaseTotal.CompletionTokens) + " 🪙",
+			phaseTotal.FormatCost(),
+		})
+	}
+
+	table.SetFooter([]string{
+		"Total",
+		strconv.Itoa(total.PromptTokens) + " 🪙",
+		strconv.Itoa(total.CompletionTokens) + " 🪙",
+		total.FormatCost(),
+	})
+	table.Render()
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+
+	"github.com/fatih/color"
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var subtasksCmd = &cobra.Command{
+	Use:   "subtasks",
+	Short: "Show the plan's subtasks and which are done",
+	Long: `Show the plan's subtasks and which are done.
+
+When a task is too large for a single reply, the plan breaks it into subtasks. Subtasks are marked done as replies complete them. Use 'plandex continue --subtask <n>' to work on a specific subtask next.`,
+	Args: cobra.NoArgs,
+	Run:  subtasks,
+}
+
+func init() {
+	RootCmd.AddCommand(subtasksCmd)
+}
+
+func subtasks(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		fmt.Println("🤷‍♂️ No current plan")
+		return
+	}
+
+	subtasks := mustGetSubtasks()
+	if len(subtasks) == 0 {
+		fmt.Println("🤷‍♂️ The plan hasn't been broken into subtasks")
+		return
+	}
+
+	numDone := 0
+	for _, subtask := range subtasks {
+		if subtask.Done {
+			numDone++
+		}
+	}
+
+	color.New(color.Bold, term.ColorHiCyan).Printf("📋 %d/%d subtasks done\n\n", numDone, len(subtasks))
+
+	current := true
+	for i, subtask := range subtasks {
+		if subtask.Done {
+			color.New(color.FgHiBlack).Printf("✅ %d. %s\n", i+1, subtask.Title)
+		} else if current {
+			color.New(color.Bold).Printf("👉 %d. %s\n", i+1, subtask.Title)
+			current = false
+		} else {
+			fmt.Printf("⬜ %d. %s\n", i+1, subtask.Title)
+		}
+	}
+
+	fmt.Println()
+	term.PrintCmds("", "continue")
+}
+
+func mustGetSubtasks() []*shared.Subtask {
+	return mustGetCurrentPlanState().Subtasks
+}
+
+func mustGetCurrentPlanState() *shared.CurrentPlanState {
+	term.StartSpinner("")
+	state, apiErr := api.Client.GetCurrentPlanState(lib.CurrentPlanId, lib.CurrentBranch)
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting current plan state: %v", apiErr.Msg)
+	}
+
+	return state
+}
@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"fmt"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var remoteUnset bool
+var pullAs string
+
+var remoteCmd = &cobra.Command{
+	Use:   "remote [url]",
+	Short: "Show or set where plans are pushed and pulled",
+	Long: `Show or set the sync remote, where 'plandex push' stores plans so you can pick them up on another machine with 'plandex pull'. It's set for every project on this machine.
+
+The remote can be:
+
+  a directory        ~/Dropbox/plandex, or a network drive
+  an s3 bucket       s3://bucket/plans, using the aws cli and its credentials
+  a git repo         git@github.com:you/plans.git, or git+https://... for https repos
+  an http(s) url     https://example.com/plans, which is sent a GET or PUT for each plan,
+                     with PLANDEX_SYNC_TOKEN as a bearer token if it's set. The list of plans
+                     at index.json is updated with If-Match, so servers should send ETags
+                     and honor If-Match and If-None-Match for concurrent pushes to be safe`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  remote,
+}
+
+var remoteLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "List the plans on the sync remote",
+	Args:  cobra.NoArgs,
+	Run:   remoteLs,
+}
+
+var pushCmd = &cobra.Command{
+	Use:   "push",
+	Short: "Push the current plan to the sync remote",
+	Long:  `Push the current plan to the sync remote, with all its branches, its conversation, context, and pending changes, and this machine's records of its applied commits and linked issues. It replaces the plan pushed under the same name before. Use --plan to push another plan.`,
+	Args:  cobra.NoArgs,
+	Run:   push,
+}
+
+var pullCmd = &cobra.Command{
+	Use:   "pull <plan>",
+	Short: "Pull a plan from the sync remote into the current project",
+	Long:  `Pull a plan pushed from another machine into the current project and make it the current plan. If the project already has a plan with that name, the pulled one is named with a suffix, like plan.2, or pass --as to name it yourself.`,
+	Args:  cobra.ExactArgs(1),
+	Run:   pull,
+}
+
+func init() {
+	RootCmd.AddCommand(remoteCmd)
+	remoteCmd.AddCommand(remoteLsCmd)
+	RootCmd.AddCommand(pushCmd)
+	RootCmd.AddCommand(pullCmd)
+
+	remoteCmd.Flags().BoolVar(&remoteUnset, "unset", false, "Stop syncing plans")
+	pullCmd.Flags().StringVar(&pullAs, "as", "", "Name the pulled plan")
+}
+
+func remote(cmd *cobra.Command, args []string) {
+	config, err := lib.LoadClientConfig()
+	if err != nil {
+		term.OutputErrorAndExit("Error loading config: %v", err)
+	}
+
+	if remoteUnset {
+		config.SyncRemote = ""
+		err = lib.WriteClientConfig(config)
+		if err != nil {
+			term.OutputErrorAndExit("Error saving config: %v", err)
+		}
+		fmt.Println("✅ Removed the sync remote")
+		return
+	}
+
+	if len(args) == 0 {
+		if config.SyncRemote == "" {
+			fmt.Println("🤷‍♂️ No sync remote")
+			fmt.Println()
+			term.PrintCmds("", "remote")
+			return
+		}
+		fmt.Println(config.SyncRemote)
+		return
+	}
+
+	remote, err := lib.NormalizeSyncRemote(args[0])
+	if err != nil {
+		term.OutputErrorAndExit("%v", err)
+	}
+
+	config.SyncRemote = remote
+	err = lib.WriteClientConfig(config)
+	if err != nil {
+		term.OutputErrorAndExit("Error saving config: %v", err)
+	}
+
+	fmt.Printf("✅ Plans will sync to %s\n", color.New(color.Bold, term.ColorHiCyan).Sprint(remote))
+	fmt.Println()
+	term.PrintCmds("", "push", "pull")
+}
+
+func remoteLs(cmd *cobra.Command, args []string) {
+	term.StartSpinner("")
+	names, remote, err := lib.ListSyncedPlans()
+	term.StopSpinner()
+
+	if err != nil {
+		term.OutputErrorAndExit("%v", err)
+	}
+
+	if len(names) == 0 {
+		fmt.Printf("🤷‍♂️ No plans on %s\n", remote)
+		return
+	}
+
+	for _, name := range names {
+		fmt.Println(name)
+	}
+}
+
+func push(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		fmt.Println("🤷‍♂️ No current plan")
+		return
+	}
+
+	term.StartSpinner("⬆️  Pushing plan...")
+	name, remote, err := lib.PushPlan(lib.CurrentPlanId)
+	term.StopSpinner()
+
+	if err != nil {
+		term.OutputErrorAndExit("Error pushing plan: %v", err)
+	}
+
+	fmt.Printf("✅ Pushed %s to %s\n", color.New(color.Bold, term.ColorHiGreen).Sprint(name), remote)
+	fmt.Printf("Pick it up on another machine with 'plandex pull %s'\n", name)
+}
+
+func pull(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	term.StartSpinner("⬇️  Pulling plan...")
+	res, synced, err := lib.PullPlan(args[0], pullAs)
+	term.StopSpinner()
+
+	if err != nil {
+		term.OutputErrorAndExit("Error pulling plan: %v", err)
+	}
+
+	err = lib.WriteCurrentPlan(res.Id)
+	if err != nil {
+		term.OutputErrorAndExit("Error setting current plan: %v", err)
+	}
+
+	// reload current plan, which will also handle setting the right branch
+	lib.MustLoadCurrentPlan()
+
+	// like 'plandex cd', so the pulled plan is current on this project's other devices that haven't picked one
+	go api.Client.SetProjectPlan(lib.CurrentProjectId, shared.SetProjectPlanRequest{PlanId: res.Id})
+	time.Sleep(50 * time.Millisecond)
+
+	pushed := synced.PushedAt.Local().Format("Jan 2, 2006 3:04pm")
+	if synced.PushedBy != "" {
+		pushed += " by " + synced.PushedBy
+	}
+
+	fmt.Printf("✅ Pulled %s, pushed %s\n", color.New(color.Bold, term.ColorHiGreen).Sprint(res.Name), pushed)
+	fmt.Println("It's now the current plan")
+	fmt.Println()
+	term.PrintCmds("", "convo", "changes", "tell")
+}
@@ -3,7 +3,6 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"os/exec"
 	"plandex/auth"
 	"plandex/lib"
 	"plandex/plan_exec"
@@ -14,21 +13,49 @@ import (
 	"github.com/spf13/cobra"
 )
 
-const defaultEditor = "vim"
-
-// const defaultEditor = "nano"
-
 var tellPromptFile string
 var tellBg bool
 var tellStop bool
 var tellNoBuild bool
+var tellIssue string
+var tellTemplate string
+var tellVars []string
+var tellAutoContinue bool
+var tellNoClarify bool
+var tellMaxSteps int
+var tellMaxTokens int
+var tellMaxCost float64
+var tellMaxFiles int
+var tellProtect []string
 
 // tellCmd represents the prompt command
 var tellCmd = &cobra.Command{
 	Use:     "tell [prompt]",
 	Aliases: []string{"t"},
 	Short:   "Send a prompt for the current plan",
-	// Long:  ``,
+	Long: `Send a prompt for the current plan.
+
+If the terminal is closed while the plan is streaming, a background process takes the stream over, records its usage, and shows a desktop notification when it finishes.
+
+If the prompt is ambiguous, Plandex may ask a few clarifying questions before making a plan. The stream pauses while you answer them inline, then the plan is made with your answers. Skip this with --no-questions. Prompts sent with --bg or --auto-continue never ask, since no one is there to answer.
+
+With --template, the prompt comes from a template in .plandex/templates (see 'plandex templates'). Variables like {{name}} in the template or prompt are filled in from --var name=value. {{branch}} is the plan's current branch, and --var file=path[:line-range] sets {{file}} to the path and {{selection}} to the file's content or the given lines.
+
+With --issue, an issue and its comments are loaded into context and the prompt asks the plan to resolve it. GitHub issues are given as a number for the origin remote or a url. Jira and Linear issues are given as a key like ENG-123 or a url, and read JIRA_BASE_URL and JIRA_API_TOKEN (plus JIRA_EMAIL for Jira Cloud) or LINEAR_API_KEY. Prefix the key with jira: or linear: if both are set up. The issue is linked to the plan, so 'plandex apply --comment-issue' or 'plandex pr --comment-issue' can post a summary of the changes back to it.
+
+With --auto-continue, the plan runs one step at a time without the stream UI. Each step's changes are built, applied without committing, and checked with the project's verification command if one is set (see 'plandex verify'), then the plan is continued. It stops when the plan is finished, verification fails, or the run reaches a limit, and prints a summary of the steps.
+
+Limits are set with --max-steps, --max-tokens, --max-cost, and --max-files, or for every run under "autoLimits" in .plandex/project.json, which the flags override:
+
+  "autoLimits": {
+    "maxSteps": 20,
+    "maxTokens": 500000,
+    "maxCost": 2.5,
+    "maxFiles": 30,
+    "protectedPaths": ["migrations", "*.lock", ".github/workflows"]
+  }
+
+Steps count replies, including replies that fix verification failures, and files count each file the run changes once. Before a step's changes to a protected path are applied, the run stops to ask. The server checks the same limits before each reply, so a run can't go past them even if the CLI is interrupted.`,
 	Args: cobra.RangeArgs(0, 1),
 	Run:  doTell,
 }
@@ -40,10 +67,22 @@ func init() {
 	tellCmd.Flags().BoolVarP(&tellStop, "stop", "s", false, "Stop after a single reply")
 	tellCmd.Flags().BoolVarP(&tellNoBuild, "no-build", "n", false, "Don't build files")
 	tellCmd.Flags().BoolVar(&tellBg, "bg", false, "Execute autonomously in the background")
+	tellCmd.Flags().StringVar(&tellIssue, "issue", "", "Load a GitHub issue (number or url), or a Jira or Linear issue (key like ENG-123 or url), into context and work on it")
+	tellCmd.Flags().StringVar(&tellTemplate, "template", "", "Send a prompt from a template in .plandex/templates")
+	tellCmd.Flags().BoolVar(&tellNoClarify, "no-questions", false, "Make a plan right away rather than asking clarifying questions first")
+	tellCmd.Flags().BoolVar(&tellAutoContinue, "auto-continue", false, "Apply, verify, and continue step by step until the plan is finished")
+	tellCmd.Flags().IntVar(&tellMaxSteps, "max-steps", 0, fmt.Sprintf("Maximum number of replies with --auto-continue (default %d)", lib.DefaultMaxAutoSteps))
+	tellCmd.Flags().IntVar(&tellMaxTokens, "max-tokens", 0, "Maximum tokens used with --auto-continue")
+	tellCmd.Flags().Float64Var(&tellMaxCost, "max-cost", 0, "Maximum estimated USD spent with --auto-continue")
+	tellCmd.Flags().IntVar(&tellMaxFiles, "max-files", 0, "Maximum files changed with --auto-continue")
+	tellCmd.Flags().StringArrayVar(&tellProtect, "protect", nil, "Ask before applying changes to paths matching this glob with --auto-continue")
+	tellCmd.Flags().StringArrayVar(&tellVars, "var", nil, "Set a template variable, like --var key=value or --var file=path[:line-range]")
+
+	addNotifyFlag(tellCmd)
 }
 
 func doTell(cmd *cobra.Command, args []string) {
-	if os.Getenv("OPENAI_API_KEY") == "" {
+	if !lib.HasApiKey() {
 		term.OutputNoApiKeyMsgAndExit()
 	}
 
@@ -55,16 +94,46 @@ func doTell(cmd *cobra.Command, args []string) {
 		return
 	}
 
+	if tellAutoContinue && (tellBg || tellStop || tellNoBuild) {
+		term.OutputErrorAndExit("--auto-continue can't be used with --bg, --stop, or --no-build")
+	}
+
+	if tellMaxSteps < 0 || tellMaxTokens < 0 || tellMaxCost < 0 || tellMaxFiles < 0 {
+		term.OutputErrorAndExit("--max-steps, --max-tokens, --max-cost, and --max-files can't be negative")
+	}
+
+	if tellTemplate != "" && len(args) > 0 {
+		term.OutputErrorAndExit("--template can't be used with a prompt argument")
+	}
+
+	var issue *lib.GithubIssue
+	var trackerIssue *lib.TrackerIssue
+	if tellIssue != "" && lib.IsTrackerIssueRef(tellIssue) {
+		trackerIssue = lib.MustLoadTrackerIssue(tellIssue)
+	} else if tellIssue != "" {
+		issue = lib.MustLoadGithubIssue(tellIssue)
+	}
+
 	var prompt string
 
 	if len(args) > 0 {
 		prompt = args[0]
+	} else if tellTemplate != "" {
+		var err error
+		prompt, err = lib.LoadTemplate(tellTemplate)
+		if err != nil {
+			term.OutputErrorAndExit("%v", err)
+		}
 	} else if tellPromptFile != "" {
 		bytes, err := os.ReadFile(tellPromptFile)
 		if err != nil {
 			term.OutputErrorAndExit("Error reading prompt file: %v", err)
 		}
 		prompt = string(bytes)
+	} else if issue != nil {
+		prompt = fmt.Sprintf("Resolve GitHub issue #%d: %s\n\nThe issue's description and comments are in context as 'issue-%d'.", issue.Number, issue.Title, issue.Number)
+	} else if trackerIssue != nil {
+		prompt = fmt.Sprintf("Resolve %s issue %s: %s\n\nThe issue's description and comments are in context as '%s'.", trackerIssue.TrackerName(), trackerIssue.Key, trackerIssue.Title, trackerIssue.ContextName())
 	} else {
 		prompt = getEditorPrompt()
 	}
@@ -74,26 +143,48 @@ func doTell(cmd *cobra.Command, args []string) {
 		return
 	}
 
+	if tellTemplate != "" || len(tellVars) > 0 {
+		vars, err := lib.GetTemplateVars(tellVars)
+		if err != nil {
+			term.OutputErrorAndExit("%v", err)
+		}
+		prompt, err = lib.RenderTemplate(prompt, vars)
+		if err != nil {
+			term.OutputErrorAndExit("%v", err)
+		}
+	}
+
+	if tellAutoContinue {
+		anyOutdated, didUpdate := lib.MustCheckOutdatedContext(false, nil)
+		if anyOutdated && !didUpdate {
+			return
+		}
+
+		limits, err := lib.GetAutoLimits(shared.AutoLimits{
+			MaxSteps:       tellMaxSteps,
+			MaxTokens:      tellMaxTokens,
+			MaxCost:        tellMaxCost,
+			MaxFiles:       tellMaxFiles,
+			ProtectedPaths: tellProtect,
+		})
+		if err != nil {
+			term.OutputErrorAndExit("Error loading project settings: %v", err)
+		}
+
+		lib.MustRunAutoContinue(lib.CurrentPlanId, lib.CurrentBranch, prompt, limits)
+		return
+	}
+
 	plan_exec.TellPlan(plan_exec.ExecParams{
 		CurrentPlanId: lib.CurrentPlanId,
 		CurrentBranch: lib.CurrentBranch,
 		CheckOutdatedContext: func(maybeContexts []*shared.Context) (bool, bool) {
 			return lib.MustCheckOutdatedContext(false, maybeContexts)
 		},
+		NoClarify: tellNoClarify,
 	}, prompt, tellBg, tellStop, tellNoBuild, false)
 }
 
-func prepareEditorCommand(editor string, filename string) *exec.Cmd {
-	switch editor {
-	case "vim":
-		return exec.Command(editor, "+normal G$", "+startinsert!", filename)
-	case "nano":
-		return exec.Command(editor, "+99999999", filename)
-	default:
-		return exec.Command(editor, filename)
-	}
-}
-
 func getEditorInstructions(editor string) string {
 
 	return "Write your prompt below, then save and exit to send it to Plandex.\n\n"
@@ -101,50 +192,20 @@ func getEditorInstructions(editor string) string {
 }
 
 func getEditorPrompt() string {
-	editor := os.Getenv("EDITOR")
-	if editor == "" {
-		editor = os.Getenv("VISUAL")
-		if editor == "" {
-			editor = defaultEditor
-		}
-	}
-
-	tempFile, err := os.CreateTemp(os.TempDir(), "plandex_prompt_*")
-	if err != nil {
-		term.OutputErrorAndExit("Failed to create temporary file: %v", err)
-	}
+	return getEditedPrompt("")
+}
 
-	instructions := getEditorInstructions(editor)
-	filename := tempFile.Name()
-	err = os.WriteFile(filename, []byte(instructions), 0644)
-	if err != nil {
-		term.OutputErrorAndExit("Failed to write instructions to temporary file: %v", err)
-	}
+// getEditedPrompt opens the editor with a prompt to change before it's sent
+func getEditedPrompt(prompt string) string {
+	instructions := getEditorInstructions(term.GetEditor())
 
-	editorCmd := prepareEditorCommand(editor, filename)
-	editorCmd.Stdin = os.Stdin
-	editorCmd.Stdout = os.Stdout
-	editorCmd.Stderr = os.Stderr
-	err = editorCmd.Run()
+	prompt, err := term.EditText(instructions+prompt, "", true)
 	if err != nil {
 		term.OutputErrorAndExit("Error opening editor: %v", err)
 	}
 
-	bytes, err := os.ReadFile(tempFile.Name())
-	if err != nil {
-		term.OutputErrorAndExit("Error reading temporary file: %v", err)
-	}
-
-	prompt := string(bytes)
-
-	err = os.Remove(tempFile.Name())
-	if err != nil {
-		term.OutputErrorAndExit("Error removing temporary file: %v", err)
-	}
-
 	prompt = strings.TrimPrefix(prompt, strings.TrimSpace(instructions))
 	prompt = strings.TrimSpace(prompt)
 
 	return prompt
-
 }
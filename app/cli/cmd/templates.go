@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"plandex/lib"
+	"plandex/term"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	RootCmd.AddCommand(templatesCmd)
+	templatesCmd.AddCommand(templatesShowCmd)
+	templatesCmd.AddCommand(templatesNewCmd)
+	templatesCmd.AddCommand(templatesEditCmd)
+	templatesCmd.AddCommand(templatesRmCmd)
+}
+
+var templatesCmd = &cobra.Command{
+	Use:   "templates",
+	Short: "List the project's prompt templates",
+	Long: `List the project's prompt templates.
+
+Templates are markdown files in .plandex/templates that can be sent with 'plandex tell --template <name>' or used by presets. Variables like {{name}} are filled in from --var name=value. These are built in:
+
+  {{branch}}     the plan's current branch
+  {{file}}       the path from --var file=path[:line-range], relative to the project root
+  {{selection}}  the content of that file, or just the given lines`,
+	Args: cobra.NoArgs,
+	Run:  templates,
+}
+
+var templatesShowCmd = &cobra.Command{
+	Use:   "show <name>",
+	Short: "Show a template",
+	Args:  cobra.ExactArgs(1),
+	Run:   templatesShow,
+}
+
+var templatesNewCmd = &cobra.Command{
+	Use:   "new <name>",
+	Short: "Create a template in your editor",
+	Args:  cobra.ExactArgs(1),
+	Run:   templatesNew,
+}
+
+var templatesEditCmd = &cobra.Command{
+	Use:   "edit <name>",
+	Short: "Edit a template in your editor",
+	Args:  cobra.ExactArgs(1),
+	Run:   templatesEdit,
+}
+
+var templatesRmCmd = &cobra.Command{
+	Use:   "rm <name>",
+	Short: "Remove a template",
+	Args:  cobra.ExactArgs(1),
+	Run:   templatesRm,
+}
+
+func templates(cmd *cobra.Command, args []string) {
+	lib.MustResolveProject()
+
+	names, err := lib.ListTemplates()
+	if err != nil {
+		term.OutputErrorAndExit("%v", err)
+	}
+
+	if len(names) == 0 {
+		fmt.Println("🤷‍♂️ No templates")
+		fmt.Println()
+		fmt.Println("Create one with 'plandex templates new <name>'")
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Template", "First Line"})
+	for _, name := range names {
+		text, err := lib.LoadTemplate(name)
+		if err != nil {
+			term.OutputErrorAndExit("%v", err)
+		}
+		firstLine, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+		table.Append([]string{name, firstLine})
+	}
+	table.Render()
+}
+
+func templatesShow(cmd *cobra.Command, args []string) {
+	lib.MustResolveProject()
+
+	text, err := lib.LoadTemplate(args[0])
+	if err != nil {
+		term.OutputErrorAndExit("%v", err)
+	}
+
+	fmt.Println(strings.TrimSpace(text))
+}
+
+func templatesNew(cmd *cobra.Command, args []string) {
+	lib.MustResolveProject()
+
+	path, err := lib.GetTemplatePath(args[0])
+	if err != nil {
+		term.OutputErrorAndExit("%v", err)
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		term.OutputErrorAndExit("Template %s already exists. Use 'plandex templates edit %s' to change it.", args[0], args[0])
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		term.OutputErrorAndExit("Error creating templates directory: %v", err)
+	}
+
+	err = os.WriteFile(path, []byte{}, 0644)
+	if err != nil {
+		term.OutputErrorAndExit("Error creating template: %v", err)
+	}
+
+	mustEditTemplate(path)
+
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		term.OutputErrorAndExit("Error reading template: %v", err)
+	}
+
+	if strings.TrimSpace(string(bytes)) == "" {
+		os.Remove(path)
+		fmt.Println("🤷‍♂️ Template was empty, so it wasn't saved")
+		return
+	}
+
+	fmt.Printf("✅ Saved template %s. Send it with 'plandex tell --template %s'\n", args[0], args[0])
+}
+
+func templatesEdit(cmd *cobra.Command, args []string) {
+	lib.MustResolveProject()
+
+	path, err := lib.GetTemplatePath(args[0])
+	if err != nil {
+		term.OutputErrorAndExit("%v", err)
+	}
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		term.OutputErrorAndExit("No template named %s. Use 'plandex templates new %s' to create it.", args[0], args[0])
+	}
+
+	mustEditTemplate(path)
+
+	fmt.Printf("✅ Saved template %s\n", args[0])
+}
+
+func templatesRm(cmd *cobra.Command, args []string) {
+	lib.MustResolveProject()
+
+	path, err := lib.GetTemplatePath(args[0])
+	if err != nil {
+		term.OutputErrorAndExit("%v", err)
+	}
+
+	err = os.Remove(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			term.OutputErrorAndExit("No template named %s", args[0])
+		}
+		term.OutputErrorAndExit("Error removing template: %v", err)
+	}
+
+	fmt.Printf("✅ Removed template %s\n", args[0])
+}
+
+func mustEditTemplate(path string) {
+	err := term.EditFile(path, true)
+	if err != nil {
+		term.OutputErrorAndExit("Error opening editor: %v", err)
+	}
+}
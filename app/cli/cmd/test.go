@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+	"plandex/types"
+
+	"github.com/spf13/cobra"
+)
+
+var testSetCmd string
+var testUnset bool
+var testMaxFixes int
+
+var testCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Run the project's tests and fix failures",
+	Long: `Run the project's test command, like 'go test ./...' or 'npm test'.
+
+If tests fail, the files named in the output are loaded into context and the output is sent to the plan so Plandex can fix the failures. The fixes are applied and the tests run again, until they pass or --max-fixes attempts have been made.`,
+	Args: cobra.NoArgs,
+	Run:  test,
+}
+
+func init() {
+	RootCmd.AddCommand(testCmd)
+
+	testCmd.Flags().StringVar(&testSetCmd, "set", "", "Set the test command for this project")
+	testCmd.Flags().BoolVar(&testUnset, "unset", false, "Remove the test command for this project")
+	testCmd.Flags().IntVar(&testMaxFixes, "max-fixes", 0, fmt.Sprintf("Set the maximum number of automatic fix attempts (default %d)", lib.DefaultMaxTestFixes))
+
+	addNotifyFlag(testCmd)
+}
+
+func test(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	settings, err := lib.LoadProjectSettings()
+	if err != nil {
+		term.OutputErrorAndExit("Error loading project settings: %v", err)
+	}
+
+	wasApproved := projectCommandsApproved(settings)
+
+	if testSetCmd != "" || testUnset || cmd.Flags().Changed("max-fixes") {
+		if testSetCmd != "" {
+			settings.TestCmd = testSetCmd
+		} else if testUnset {
+			settings.TestCmd = ""
+		}
+
+		if cmd.Flags().Changed("max-fixes") {
+			if testMaxFixes < 0 {
+				term.OutputErrorAndExit("--max-fixes can't be negative")
+			}
+			settings.MaxTestFixes = &testMaxFixes
+		}
+
+		err = lib.WriteProjectCommandSettings(settings, wasApproved)
+		if err != nil {
+			term.OutputErrorAndExit("Error saving project settings: %v", err)
+		}
+
+		if settings.TestCmd == "" {
+			fmt.Println("✅ Test command removed")
+		} else {
+			fmt.Printf("✅ Test command set to '%s' with up to %d fix attempts\n", settings.TestCmd, getMaxTestFixes(settings))
+		}
+		return
+	}
+
+	if settings.TestCmd == "" {
+		fmt.Println("🤷‍♂️ No test command set")
+		fmt.Println()
+		fmt.Println("Set one with 'plandex test --set \"go test ./...\"'")
+		return
+	}
+
+	if lib.CurrentPlanId == "" {
+		fmt.Println("🤷‍♂️ No current plan")
+		return
+	}
+
+	if !lib.HasApiKey() {
+		term.OutputNoApiKeyMsgAndExit()
+	}
+
+	lib.MustCheckProjectCommandsApproved(settings)
+
+	lib.MustRunTestLoop(lib.CurrentPlanId, lib.CurrentBranch, settings.TestCmd, getMaxTestFixes(settings), lib.ApplyFlags{AutoConfirm: true})
+}
+
+func getMaxTestFixes(settings *types.CurrentProjectSettings) int {
+	if settings.MaxTestFixes == nil {
+		return lib.DefaultMaxTestFixes
+	}
+	return *settings.MaxTestFixes
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var undoYes bool
+
+var undoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Undo the last apply, restoring files from backup",
+	Long: `Undo the last apply, restoring files from backup.
+
+Files that were overwritten or deleted are restored and files that were created are removed. This only changes files in your project--the plan itself is unchanged, and any commit made by the apply is left in place.`,
+	Args: cobra.NoArgs,
+	Run:  undo,
+}
+
+func init() {
+	RootCmd.AddCommand(undoCmd)
+
+	undoCmd.Flags().BoolVarP(&undoYes, "yes", "y", false, "Skip confirmation")
+}
+
+func undo(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		fmt.Println("🤷‍♂️ No current plan")
+		return
+	}
+
+	backup, err := lib.GetLastApplyBackup(lib.CurrentPlanId)
+	if err != nil {
+		term.OutputErrorAndExit("Error loading backup: %v", err)
+	}
+
+	if backup == nil {
+		fmt.Println("🤷‍♂️ No applied changes to undo")
+		return
+	}
+
+	color.New(color.Bold, term.ColorHiCyan).Printf("↩️  Last apply on branch %s at %s\n", backup.Branch, backup.CreatedAt.Local().Format("Jan 2 15:04:05"))
+	for _, file := range backup.Files {
+		if file.Existed {
+			fmt.Printf("• restore %s\n", file.Path)
+		} else {
+			fmt.Printf("• remove %s\n", file.Path)
+		}
+	}
+	fmt.Println()
+
+	if !undoYes {
+		confirmed, err := term.ConfirmYesNo("Undo these changes?")
+		if err != nil {
+			term.OutputErrorAndExit("failed to get confirmation user input: %s", err)
+		}
+		if !confirmed {
+			return
+		}
+	}
+
+	err = lib.RestoreApplyBackup(backup)
+	if err != nil {
+		term.OutputErrorAndExit("Error restoring backup: %v", err)
+	}
+
+	suffix := ""
+	if len(backup.Files) > 1 {
+		suffix = "s"
+	}
+	fmt.Printf("✅ Undid last apply, %d file%s restored\n", len(backup.Files), suffix)
+}
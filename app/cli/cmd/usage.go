@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+	"strconv"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var usageDays int
+var usageCurrent bool
+var usageJson bool
+var usageCsv bool
+var usageOrg bool
+
+var usageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Summarize token usage and estimated cost by day, plan, and phase",
+	Long: `Summarize the project's token usage and estimated cost by day, plan, and phase (reply, description, build, and so on).
+
+Usage is read from the server's records, so it includes usage from other machines. With --current, it covers everyone who has worked on the current plan. Otherwise org admins see everyone's usage and other members see their own. With --org, it covers all of your org's projects, including deleted plans.
+
+Use --json or --csv to export the rows.`,
+	Args: cobra.NoArgs,
+	Run:  usage,
+}
+
+func init() {
+	RootCmd.AddCommand(usageCmd)
+
+	usageCmd.Flags().IntVar(&usageDays, "days", 30, "Include this many days of usage, counting today. 0 includes all of it")
+	usageCmd.Flags().BoolVar(&usageCurrent, "current", false, "Only include the current plan")
+	usageCmd.Flags().BoolVar(&usageJson, "json", false, "Output rows as JSON")
+	usageCmd.Flags().BoolVar(&usageCsv, "csv", false, "Output rows as CSV")
+	usageCmd.Flags().BoolVar(&usageOrg, "org", false, "Use the usage recorded by the server for the whole org")
+}
+
+func usage(cmd *cobra.Command, args []string) {
+	if usageJson && usageCsv {
+		term.OutputErrorAndExit("Choose one of --json or --csv")
+	}
+
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	var planId string
+	if usageCurrent {
+		if lib.CurrentPlanId == "" {
+			fmt.Println("🤷‍♂️ No current plan")
+			return
+		}
+		planId = lib.CurrentPlanId
+	}
+
+	var since time.Time
+	if usageDays > 0 {
+		now := time.Now()
+		since = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, -(usageDays - 1))
+	}
+
+	term.StartSpinner("")
+
+	projectIds := []string{lib.CurrentProjectId}
+	if usageOrg {
+		projects, apiErr := api.Client.ListProjects()
+		if apiErr != nil {
+			term.StopSpinner()
+			term.OutputErrorAndExit("Error getting projects: %v", apiErr.Msg)
+		}
+		projectIds = nil
+		for _, project := range projects {
+			projectIds = append(projectIds, project.Id)
+		}
+	}
+
+	plans, apiErr := api.Client.ListPlans(projectIds)
+	if apiErr != nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("Error getting plans: %v", apiErr.Msg)
+	}
+
+	planNamesById := map[string]string{}
+	for _, plan := range plans {
+		planNamesById[plan.Id] = plan.Name
+	}
+
+	var rows []*lib.UsageReportRow
+	var orgUsage *shared.OrgUsageResponse
+	if usageOrg {
+		orgUsage, apiErr = api.Client.GetOrgUsage(since)
+		term.StopSpinner()
+		if apiErr != nil {
+			term.OutputErrorAndExit("Error getting org usage: %v", apiErr.Msg)
+		}
+		rows = lib.GetServerUsageReport(orgUsage.Records, planId, planNamesById)
+	} else {
+		var err error
+		rows, err = lib.GetUsageReport(planId, since, planNamesById)
+		term.StopSpinner()
+		if err != nil {
+			term.OutputErrorAndExit("Error getting usage: %v", err)
+		}
+	}
+
+	if usageJson {
+		if rows == nil {
+			rows = []*lib.UsageReportRow{}
+		}
+		bytes, err := json.MarshalIndent(rows, "", "  ")
+		if err != nil {
+			term.OutputErrorAndExit("Error marshalling usage: %v", err)
+		}
+		fmt.Println(string(bytes))
+		return
+	}
+
+	if usageCsv {
+		w := csv.NewWriter(os.Stdout)
+		w.Write([]string{"day", "plan_id", "plan", "phase", "prompt_tokens", "completion_tokens", "cost_usd", "unpriced"})
+		for _, row := range rows {
+			w.Write([]string{
+				row.Day,
+				row.PlanId,
+				row.Plan,
+				string(row.Phase),
+				strconv.Itoa(row.PromptTokens),
+				strconv.Itoa(row.CompletionTokens),
+				strconv.FormatFloat(row.Cost, 'f', 6, 64),
+				strconv.FormatBool(row.Unpriced),
+			})
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			term.OutputErrorAndExit("Error writing CSV: %v", err)
+		}
+		return
+	}
+
+	if len(rows) == 0 {
+		fmt.Println("🤷‍♂️ No usage recorded")
+		printOrgQuota(orgUsage)
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Day", "Plan", "Phase", "Prompt", "Completion", "Cost"})
+
+	var total shared.UsageTotal
+	for _, row := range rows {
+		total.AddTotal(row.UsageTotal)
+		table.Append([]string{
+			row.Day,
+			row.Plan,
+			string(row.Phase),
+			strconv.Itoa(row.PromptTokens) + " 🪙",
+			strconv.Itoa(row.CompletionTokens) + " 🪙",
+			row.FormatCost(),
+		})
+	}
+
+	table.SetFooter([]string{
+		"Total",
+		"",
+		"",
+		strconv.Itoa(total.PromptTokens) + " 🪙",
+		strconv.Itoa(total.CompletionTokens) + " 🪙",
+		total.FormatCost(),
+	})
+	table.Render()
+
+	printOrgQuota(orgUsage)
+}
+
+func printOrgQuota(orgUsage *shared.OrgUsageResponse) {
+	if orgUsage == nil || orgUsage.MonthlyTokenQuota == 0 {
+		return
+	}
+
+	fmt.Println()
+	fmt.Printf("Org quota: %d / %d 🪙 used this month\n", orgUsage.MonthTokens, orgUsage.MonthlyTokenQuota)
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+	"plandex/types"
+
+	"github.com/spf13/cobra"
+)
+
+var verifySetCmd string
+var verifyUnset bool
+var verifyMaxFixes int
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Run the project's verification command",
+	Long: `Run the project's verification command, like 'go build ./...' or 'npm test'.
+
+If it fails, its output is sent back to the plan so Plandex can fix the problem, and the fixes are applied and verified again, up to --max-fixes times. Once a command is set, it also runs automatically after each 'plandex apply'.`,
+	Args: cobra.NoArgs,
+	Run:  verify,
+}
+
+func init() {
+	RootCmd.AddCommand(verifyCmd)
+
+	verifyCmd.Flags().StringVar(&verifySetCmd, "set", "", "Set the verification command for this project")
+	verifyCmd.Flags().BoolVar(&verifyUnset, "unset", false, "Remove the verification command for this project")
+	verifyCmd.Flags().IntVar(&verifyMaxFixes, "max-fixes", 0, fmt.Sprintf("Set the maximum number of automatic fix attempts (default %d)", lib.DefaultMaxVerifyFixes))
+
+	addNotifyFlag(verifyCmd)
+}
+
+func verify(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	settings, err := lib.LoadProjectSettings()
+	if err != nil {
+		term.OutputErrorAndExit("Error loading project settings: %v", err)
+	}
+
+	wasApproved := projectCommandsApproved(settings)
+
+	if verifySetCmd != "" || verifyUnset || cmd.Flags().Changed("max-fixes") {
+		if verifySetCmd != "" {
+			settings.VerifyCmd = verifySetCmd
+		} else if verifyUnset {
+			settings.VerifyCmd = ""
+		}
+
+		if cmd.Flags().Changed("max-fixes") {
+			if verifyMaxFixes < 0 {
+				term.OutputErrorAndExit("--max-fixes can't be negative")
+			}
+			settings.MaxVerifyFixes = &verifyMaxFixes
+		}
+
+		err = lib.WriteProjectCommandSettings(settings, wasApproved)
+		if err != nil {
+			term.OutputErrorAndExit("Error saving project settings: %v", err)
+		}
+
+		if settings.VerifyCmd == "" {
+			fmt.Println("✅ Verification command removed")
+		} else {
+			fmt.Printf("✅ Verification command set to '%s' with up to %d fix attempts\n", settings.VerifyCmd, getMaxVerifyFixes(settings))
+		}
+		return
+	}
+
+	if settings.VerifyCmd == "" {
+		fmt.Println("🤷‍♂️ No verification command set")
+		fmt.Println()
+		fmt.Println("Set one with 'plandex verify --set \"go build ./...\"'")
+		return
+	}
+
+	if lib.CurrentPlanId == "" {
+		fmt.Println("🤷‍♂️ No current plan")
+		return
+	}
+
+	lib.MustCheckProjectCommandsApproved(settings)
+
+	lib.MustRunVerifyLoop(lib.CurrentPlanId, lib.CurrentBranch, settings.VerifyCmd, getMaxVerifyFixes(settings), lib.ApplyFlags{AutoConfirm: true})
+}
+
+func getMaxVerifyFixes(settings *types.CurrentProjectSettings) int {
+	if settings.MaxVerifyFixes == nil {
+		return lib.DefaultMaxVerifyFixes
+	}
+	return *settings.MaxVerifyFixes
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"log"
+	"plandex/auth"
+	"plandex/lib"
+
+	"github.com/spf13/cobra"
+)
+
+// watchStreamCmd is started in the background when the terminal showing a stream is closed, to take the stream over
+var watchStreamCmd = &cobra.Command{
+	Use:    "watch-stream <plan-id> <branch>",
+	Hidden: true,
+	Args:   cobra.ExactArgs(2),
+	Run:    watchStream,
+}
+
+func init() {
+	RootCmd.AddCommand(watchStreamCmd)
+}
+
+func watchStream(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	err := lib.WatchStream(args[0], args[1])
+	if err != nil {
+		log.Printf("Error watching stream for plan %s on branch %s: %v\n", args[0], args[1], err)
+	}
+}
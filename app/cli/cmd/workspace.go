@@ -0,0 +1,236 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	RootCmd.AddCommand(workspaceCmd)
+	workspaceCmd.AddCommand(workspaceUseCmd)
+	workspaceCmd.AddCommand(workspaceClearCmd)
+	workspaceCmd.AddCommand(workspaceSetCmd)
+	workspaceCmd.AddCommand(workspaceRmCmd)
+}
+
+var workspaceCmd = &cobra.Command{
+	Use:   "workspace",
+	Short: "List the project's workspace members and the one the plan targets",
+	Long: `List the project's workspace members and the one the current plan targets.
+
+Members are detected from go.work, package.json workspaces, pnpm-workspace.yaml, and Cargo.toml, along with any mapped in project.json with 'plandex workspace set'.`,
+	Args: cobra.NoArgs,
+	Run:  workspace,
+}
+
+var workspaceUseCmd = &cobra.Command{
+	Use:   "use <name or path>",
+	Short: "Target a workspace member with the current plan",
+	Long: `Target a workspace member with the current plan.
+
+New files the plan writes are placed inside the member, and paths loaded with 'plandex load' that don't exist from the project root are looked up inside it.`,
+	Args: cobra.ExactArgs(1),
+	Run:  workspaceUse,
+}
+
+var workspaceClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Target the whole project with the current plan",
+	Args:  cobra.NoArgs,
+	Run:   workspaceClear,
+}
+
+var workspaceSetCmd = &cobra.Command{
+	Use:   "set <name> <path>",
+	Short: "Map a workspace member in the project settings",
+	Args:  cobra.ExactArgs(2),
+	Run:   workspaceSet,
+}
+
+var workspaceRmCmd = &cobra.Command{
+	Use:   "rm <name>",
+	Short: "Remove a workspace member mapped in the project settings",
+	Args:  cobra.ExactArgs(1),
+	Run:   workspaceRm,
+}
+
+func workspace(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	members, err := lib.GetWorkspaceMembers()
+	if err != nil {
+		term.OutputErrorAndExit("Error getting workspace members: %v", err)
+	}
+
+	var target string
+	if lib.CurrentPlanId != "" {
+		target, err = lib.GetPlanWorkspaceMember()
+		if err != nil {
+			term.OutputErrorAndExit("Error getting plan's workspace member: %v", err)
+		}
+	}
+
+	if len(members) == 0 {
+		fmt.Println("🤷‍♂️ No workspace members found")
+		fmt.Println()
+		fmt.Println("Map one with 'plandex workspace set <name> <path>'")
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Name", "Path", "Source"})
+	for _, member := range members {
+		row := []string{member.Name, member.Path, member.Source}
+		if member.Path == target {
+			row[0] = "👉 " + row[0]
+			table.Rich(row, []tablewriter.Colors{
+				{tablewriter.FgGreenColor, tablewriter.Bold},
+				{tablewriter.FgGreenColor},
+				{tablewriter.FgGreenColor},
+			})
+		} else {
+			table.Append(row)
+		}
+	}
+	table.Render()
+	fmt.Println()
+
+	if lib.CurrentPlanId == "" {
+		return
+	}
+
+	if target == "" {
+		fmt.Println("The current plan targets the whole project")
+		fmt.Println()
+		term.PrintCmds("", "workspace use")
+	} else {
+		fmt.Printf("The current plan targets %s\n", color.New(color.Bold, term.ColorHiCyan).Sprint(target))
+		fmt.Println()
+		term.PrintCmds("", "workspace use", "workspace clear")
+	}
+}
+
+func workspaceUse(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		fmt.Println("🤷‍♂️ No current plan")
+		return
+	}
+
+	members, err := lib.GetWorkspaceMembers()
+	if err != nil {
+		term.OutputErrorAndExit("Error getting workspace members: %v", err)
+	}
+
+	member := lib.FindWorkspaceMember(members, args[0])
+	if member == nil {
+		term.OutputErrorAndExit("No workspace member named %s or at that path. Map it with 'plandex workspace set <name> <path>'", args[0])
+	}
+
+	setPlanWorkspaceMember(member.Path)
+
+	fmt.Printf("✅ Plan now targets %s (%s)\n", color.New(color.Bold, term.ColorHiCyan).Sprint(member.Name), member.Path)
+}
+
+func workspaceClear(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		fmt.Println("🤷‍♂️ No current plan")
+		return
+	}
+
+	setPlanWorkspaceMember("")
+
+	fmt.Println("✅ Plan now targets the whole project")
+}
+
+func setPlanWorkspaceMember(path string) {
+	term.StartSpinner("")
+
+	settings, apiErr := api.Client.GetSettings(lib.CurrentPlanId, lib.CurrentBranch)
+	if apiErr != nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("Error getting plan settings: %v", apiErr.Msg)
+	}
+
+	settings.WorkspaceMember = path
+
+	_, apiErr = api.Client.UpdateSettings(lib.CurrentPlanId, lib.CurrentBranch, shared.UpdateSettingsRequest{
+		Settings: settings,
+	})
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error updating plan settings: %v", apiErr.Msg)
+	}
+}
+
+func workspaceSet(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	name, path := args[0], filepath.ToSlash(filepath.Clean(args[1]))
+
+	info, err := os.Stat(path)
+	if err != nil || !info.IsDir() || filepath.IsAbs(path) || path == "." || strings.HasPrefix(path, "..") {
+		term.OutputErrorAndExit("%s isn't a directory in the project", args[1])
+	}
+
+	settings, err := lib.LoadProjectSettings()
+	if err != nil {
+		term.OutputErrorAndExit("Error loading project settings: %v", err)
+	}
+
+	if settings.Workspace == nil {
+		settings.Workspace = map[string]string{}
+	}
+	settings.Workspace[name] = path
+
+	err = lib.WriteProjectSettings(settings)
+	if err != nil {
+		term.OutputErrorAndExit("Error saving project settings: %v", err)
+	}
+
+	fmt.Printf("✅ Mapped workspace member %s to %s\n", name, path)
+}
+
+func workspaceRm(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	settings, err := lib.LoadProjectSettings()
+	if err != nil {
+		term.OutputErrorAndExit("Error loading project settings: %v", err)
+	}
+
+	if _, ok := settings.Workspace[args[0]]; !ok {
+		fmt.Printf("🤷‍♂️ No workspace member named %s in the project settings\n", args[0])
+		return
+	}
+
+	delete(settings.Workspace, args[0])
+
+	err = lib.WriteProjectSettings(settings)
+	if err != nil {
+		term.OutputErrorAndExit("Error saving project settings: %v", err)
+	}
+
+	fmt.Printf("✅ Removed workspace member %s\n", args[0])
+}
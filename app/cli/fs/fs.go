@@ -25,6 +25,7 @@ var CacheDir string
 var HomeDir string
 var HomeAuthPath string
 var HomeAccountsPath string
+var HomeConfigPath string
 
 func init() {
 	var err error
@@ -54,6 +55,7 @@ func init() {
 	CacheDir = filepath.Join(HomePlandexDir, "cache")
 	HomeAuthPath = filepath.Join(HomePlandexDir, "auth.json")
 	HomeAccountsPath = filepath.Join(HomePlandexDir, "accounts.json")
+	HomeConfigPath = filepath.Join(HomePlandexDir, "config.json")
 
 	err = os.MkdirAll(filepath.Join(CacheDir, "tiktoken"), os.ModePerm)
 	if err != nil {
@@ -243,6 +245,18 @@ func GetPaths(baseDir, currentDir string) (*ProjectPaths, error) {
 		}()
 	}
 
+	// outside a git repo, .gitignore files are applied while walking
+	var gitignored *gitignoreMatcher
+	if !isGitRepo {
+		gitignored = &gitignoreMatcher{}
+
+		// patterns from directories above baseDir still apply to it
+		err = gitignored.addParents(currentDir, baseDir)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// get all paths in the directory
 	numRoutines++
 	go func() {
@@ -269,6 +283,17 @@ func GetPaths(baseDir, currentDir string) (*ProjectPaths, error) {
 				if ignored != nil && ignored.MatchesPath(relPath) {
 					return filepath.SkipDir
 				}
+
+				if gitignored != nil {
+					if gitignored.matches(path, true) {
+						return filepath.SkipDir
+					}
+
+					err = gitignored.addDir(path)
+					if err != nil {
+						return err
+					}
+				}
 			} else {
 				relPath, err := filepath.Rel(currentDir, path)
 				if err != nil {
@@ -281,6 +306,10 @@ func GetPaths(baseDir, currentDir string) (*ProjectPaths, error) {
 					return nil
 				}
 
+				if gitignored != nil && gitignored.matches(path, false) {
+					return nil
+				}
+
 				if !isGitRepo {
 					mu.Lock()
 					defer mu.Unlock()
@@ -454,7 +483,7 @@ func GetBaseDirForContexts(contexts []*shared.Context) string {
 
 	for _, context := range contexts {
 		if context.FilePath != "" {
-			paths = append(paths, context.FilePath)
+			paths = append(paths, filepath.FromSlash(context.FilePath))
 		}
 	}
 
@@ -489,6 +518,31 @@ func GetBaseDirForFilePaths(paths []string) string {
 	return baseDir
 }
 
+// ProjectFilePath is where a plan's file lives on disk. Plans use forward slashes on every platform, and absolute paths, like ones with a drive letter, are accepted if they're inside the project. Ones outside it are kept beneath the project root.
+func ProjectFilePath(path string) string {
+	native := filepath.FromSlash(path)
+	if filepath.IsAbs(native) || filepath.VolumeName(native) != "" {
+		rel, err := filepath.Rel(ProjectRoot, native)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(os.PathSeparator)) {
+			return filepath.Join(ProjectRoot, rel)
+		}
+		native = strings.TrimPrefix(native, filepath.VolumeName(native))
+	}
+	return filepath.Join(ProjectRoot, native)
+}
+
+// ContextFilePath is how a path given on the command line is stored in context: relative to the current directory if it's inside it, with forward slashes, so it matches the paths in plans on every platform
+func ContextFilePath(path string) string {
+	native := filepath.Clean(filepath.FromSlash(path))
+	if filepath.IsAbs(native) {
+		rel, err := filepath.Rel(Cwd, native)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(os.PathSeparator)) {
+			native = rel
+		}
+	}
+	return filepath.ToSlash(native)
+}
+
 func findPlandex(baseDir string) string {
 	var dir string
 	if os.Getenv("PLANDEX_ENV") == "development" {
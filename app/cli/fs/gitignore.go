@@ -0,0 +1,77 @@
+package fs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	ignore "github.com/sabhiram/go-gitignore"
+)
+
+// gitignoreMatcher applies the .gitignore files found while walking a directory that isn't in a git repo, where git can't do it for us. Each file's patterns are relative to the directory it's in.
+type gitignoreMatcher struct {
+	ignores []*dirGitignore
+}
+
+type dirGitignore struct {
+	dir     string
+	ignored *ignore.GitIgnore
+}
+
+// addDir loads the .gitignore in dir, if there is one. Directories must be added top-down, as filepath.Walk visits them.
+func (m *gitignoreMatcher) addDir(dir string) error {
+	path := filepath.Join(dir, ".gitignore")
+
+	_, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("error checking for .gitignore file: %s", err)
+	}
+
+	ignored, err := ignore.CompileIgnoreFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading .gitignore file %s: %s", path, err)
+	}
+
+	m.ignores = append(m.ignores, &dirGitignore{dir: dir, ignored: ignored})
+	return nil
+}
+
+// addParents loads the .gitignore files from root down to dir's parent
+func (m *gitignoreMatcher) addParents(root, dir string) error {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return nil
+	}
+
+	current := root
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		err := m.addDir(current)
+		if err != nil {
+			return err
+		}
+		current = filepath.Join(current, part)
+	}
+	return nil
+}
+
+func (m *gitignoreMatcher) matches(path string, isDir bool) bool {
+	for _, gi := range m.ignores {
+		rel, err := filepath.Rel(gi.dir, path)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			continue
+		}
+
+		rel = filepath.ToSlash(rel)
+		if isDir {
+			rel += "/"
+		}
+
+		if gi.ignored.MatchesPath(rel) {
+			return true
+		}
+	}
+	return false
+}
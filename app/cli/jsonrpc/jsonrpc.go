@@ -0,0 +1,97 @@
+package jsonrpc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+const (
+	ErrCodeParse          = -32700
+	ErrCodeInvalidRequest = -32600
+	ErrCodeMethodNotFound = -32601
+	ErrCodeInvalidParams  = -32602
+	// a method that was called correctly but failed
+	ErrCodeServer = -32000
+	// a request without the token a server listening on a port requires
+	ErrCodeUnauthorized = -32001
+)
+
+// Message is a JSON-RPC 2.0 request, notification, or response. Notifications have no Id.
+type Message struct {
+	JsonRpc string          `json:"jsonrpc"`
+	Id      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// Conn reads and writes newline-delimited messages. Writes are safe to make from several goroutines.
+type Conn struct {
+	r  *bufio.Reader
+	w  io.Writer
+	mu sync.Mutex
+}
+
+func NewConn(r io.Reader, w io.Writer) *Conn {
+	return &Conn{r: bufio.NewReader(r), w: w}
+}
+
+// Read returns the next message. A message that isn't valid JSON is returned as an *Error with ErrCodeParse, and reading can continue after it.
+func (c *Conn) Read() (*Message, error) {
+	for {
+		line, err := c.r.ReadBytes('\n')
+		if len(line) == 0 && err != nil {
+			return nil, err
+		}
+
+		// blank lines between messages are skipped
+		if len(bytes.TrimSpace(line)) == 0 {
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		var msg Message
+		if jsonErr := json.Unmarshal(line, &msg); jsonErr != nil {
+			return nil, &Error{Code: ErrCodeParse, Message: fmt.Sprintf("invalid message: %v", jsonErr)}
+		}
+		return &msg, nil
+	}
+}
+
+func (c *Conn) Write(msg *Message) error {
+	msg.JsonRpc = "2.0"
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("error marshalling message: %v", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err = c.w.Write(append(data, '\n'))
+	return err
+}
+
+// Notify sends a notification, which has no id and gets no response
+func (c *Conn) Notify(method string, params any) error {
+	paramsJson, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("error marshalling params: %v", err)
+	}
+	return c.Write(&Message{Method: method, Params: paramsJson})
+}
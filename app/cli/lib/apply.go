@@ -1,16 +1,47 @@
 package lib
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
 	"plandex/api"
 	"plandex/fs"
 	"plandex/term"
+	"plandex/types"
+	"sort"
 	"strings"
+
+	"github.com/fatih/color"
+	"github.com/plandex/plandex/shared"
 )
 
-func MustApplyPlan(planId, branch string, autoConfirm bool) {
+type ApplyFlags struct {
+	AutoConfirm bool
+	// commit the written files without asking
+	AutoCommit bool
+	// switch to this git branch, creating it if needed, before writing files. Implies AutoCommit so the changes stay on the branch.
+	GitBranch string
+	// stash uncommitted changes before writing files and restore them on top afterward. Implies AutoCommit, since the stash can only be merged back into a clean tree.
+	Stash bool
+	// leave the written files uncommitted without asking, as when applying in a sandbox worktree that's copied back afterward
+	NoCommit bool
+	// write the files without marking the changes applied on the server, as in a sandbox worktree whose files may never be copied back
+	DeferServerApply bool
+	// list flagged security risks without asking before applying them
+	AllowRisky bool
+	// exit instead of asking about flagged security risks, for runs with no one to answer
+	NoPrompt bool
+	// for headless runs, why the apply was refused is recorded here before exiting
+	Report *RunReport
+}
+
+// MustApplyPlan writes the plan's pending changes to the project and returns whether any were applied
+func MustApplyPlan(planId, branch string, flags ApplyFlags) bool {
+	autoConfirm := flags.AutoConfirm
+	autoCommit := flags.AutoCommit
+
 	term.StartSpinner("")
 
 	currentPlanState, apiErr := api.Client.GetCurrentPlanState(planId, branch)
@@ -33,7 +64,7 @@ func MustApplyPlan(planId, branch string, autoConfirm bool) {
 				fmt.Println("This plan is currently active. Please wait for it to finish before applying.")
 				fmt.Println()
 				term.PrintCmds("", "ps", "connect")
-				return
+				return false
 			}
 		}
 
@@ -50,7 +81,7 @@ func MustApplyPlan(planId, branch string, autoConfirm bool) {
 
 		if !shouldBuild {
 			fmt.Println("Apply plan canceled")
-			os.Exit(0)
+			term.Exit(0)
 		}
 
 		_, err = buildPlanInlineFn(nil)
@@ -60,46 +91,271 @@ func MustApplyPlan(planId, branch string, autoConfirm bool) {
 		}
 	}
 
-	anyOutdated, didUpdate := MustCheckOutdatedContext(true, nil)
+	isRepo := fs.ProjectRootIsGitRepo()
+
+	// switch before anything is compared with the project's files, since the branch may already exist with different content
+	if flags.GitBranch != "" {
+		if !isRepo {
+			term.StopSpinner()
+			term.OutputErrorAndExit("Can't apply to git branch %s: the project isn't in a git repository", flags.GitBranch)
+		}
+
+		created, err := switchToGitBranch(flags.GitBranch)
+		if err != nil {
+			term.StopSpinner()
+			term.OutputErrorAndExit("Can't apply to git branch: %v", err)
+		}
 
-	if anyOutdated && !didUpdate {
 		term.StopSpinner()
-		fmt.Println("Apply plan canceled")
-		os.Exit(0)
+		if created {
+			fmt.Printf("🌿 Created and switched to git branch %s\n", flags.GitBranch)
+		} else {
+			fmt.Printf("🌿 Applying to git branch %s\n", flags.GitBranch)
+		}
+		fmt.Println()
+		term.ResumeSpinner()
+
+		autoCommit = true
+	}
+
+	if flags.Stash {
+		if !isRepo {
+			term.StopSpinner()
+			term.OutputErrorAndExit("Can't stash changes: the project isn't in a git repository")
+		}
+		autoCommit = true
+	}
+
+	// applying without confirmation merges the plan's changes with any files updated since it was built, as answering yes below does
+	if !flags.AutoConfirm {
+		anyOutdated, didUpdate := MustCheckOutdatedContext(true, nil)
+
+		if anyOutdated && !didUpdate {
+			term.StopSpinner()
+
+			shouldMerge, err := term.ConfirmYesNo("Apply anyway, merging plan changes with your updated files?")
+
+			if err != nil {
+				term.OutputErrorAndExit("failed to get confirmation user input: %s", err)
+			}
+
+			if !shouldMerge {
+				fmt.Println("Apply plan canceled")
+				term.Exit(0)
+			}
+		}
 	}
 
 	currentPlanFiles := currentPlanState.CurrentPlanFiles
-	isRepo := fs.ProjectRootIsGitRepo()
 
 	toApply := currentPlanFiles.Files
 
-	if len(toApply) == 0 {
+	var toRemove []string
+	for path := range currentPlanFiles.Removed {
+		toRemove = append(toRemove, path)
+	}
+	sort.Strings(toRemove)
+
+	unchangedPaths := GetUnchangedPaths(currentPlanState)
+
+	movedTo := map[string]string{}
+	for path, from := range currentPlanFiles.MovedFrom {
+		movedTo[from] = path
+	}
+
+	if len(toApply) == 0 && len(toRemove) == 0 {
 		term.StopSpinner()
 		fmt.Println("🤷‍♂️ No changes to apply")
-		return
+		return false
+	}
+
+	for _, path := range append(applyPathsOf(toApply), toRemove...) {
+		err := shared.ValidatePlanPath(path)
+		if err != nil {
+			term.StopSpinner()
+			term.OutputErrorAndExit("Refusing to apply plan: %v", err)
+		}
+	}
+
+	dirMode, err := getNewDirMode()
+	if err != nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("%v", err)
+	}
+
+	newDirs, err := getMissingDirs(applyPathsOf(toApply))
+	if err != nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("failed to check directories: %v", err)
+	}
+
+	linkPolicy, err := getSymlinkPolicy()
+	if err != nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("%v", err)
+	}
+
+	links, err := getSymlinkedFiles(applyPathsOf(toApply))
+	if err == nil {
+		err = checkSymlinkPolicy(links, linkPolicy)
+	}
+	if err != nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("Can't apply plan: %v", err)
+	}
+
+	// stashed changes don't need to be checked since they're set aside before writing
+	if isRepo && !autoConfirm && !flags.Stash {
+		rebuild := checkUncommittedChanges(currentPlanState, append(applyPathsOf(toApply), toRemove...))
+		if rebuild {
+			MustUpdateContext(nil)
+			_, err := buildPlanInlineFn(nil)
+			if err != nil {
+				term.OutputErrorAndExit("failed to build plan: %v", err)
+			}
+			return MustApplyPlan(planId, branch, flags)
+		}
+	}
+
+	skipFlagged := mustConfirmSecurityRisks(planId, branch, currentPlanFiles, unchangedPaths, flags)
+	if len(skipFlagged) > 0 {
+		toApply, toRemove = skipFlaggedPaths(toApply, toRemove, currentPlanFiles.MovedFrom, skipFlagged)
+
+		if len(toApply) == 0 && len(toRemove) == 0 {
+			term.StopSpinner()
+			fmt.Println("Apply plan canceled")
+			term.Exit(0)
+		}
+
+		for from, to := range movedTo {
+			if skipFlagged[to] {
+				delete(movedTo, from)
+			}
+		}
+
+		newDirs, err = getMissingDirs(applyPathsOf(toApply))
+		if err != nil {
+			term.StopSpinner()
+			term.OutputErrorAndExit("failed to check directories: %v", err)
+		}
 	}
 
 	if !autoConfirm {
 		term.StopSpinner()
-		numToApply := len(toApply)
+
+		if len(newDirs) > 0 {
+			color.New(color.Bold, term.ColorHiGreen).Println("📁 These directories will be created:")
+			for _, dir := range newDirs {
+				fmt.Println("• " + dir + string(filepath.Separator))
+			}
+			fmt.Println()
+		}
+
+		if len(links) > 0 {
+			if linkPolicy == symlinkPolicyReplace {
+				color.New(color.Bold, term.ColorHiYellow).Println("🔗 These symlinks will be replaced with regular files:")
+			} else {
+				color.New(color.Bold, term.ColorHiCyan).Println("🔗 These files are symlinks, so their targets will be updated:")
+			}
+			for _, link := range links {
+				fmt.Printf("• %s → %s\n", link.path, link.target)
+			}
+			fmt.Println()
+		}
+
+		if len(unchangedPaths) > 0 {
+			color.New(color.Bold, term.ColorHiCyan).Println("⚪ These files already match the plan and won't be written:")
+			for _, path := range applyPathsOf(toApply) {
+				if unchangedPaths[path] {
+					fmt.Println("• " + path)
+				}
+			}
+			fmt.Println()
+		}
+
+		if len(movedTo) > 0 {
+			color.New(color.Bold, term.ColorHiCyan).Println("🚚 These files will be moved:")
+			for _, path := range toRemove {
+				if to, ok := movedTo[path]; ok {
+					fmt.Printf("• %s → %s\n", path, to)
+				}
+			}
+			fmt.Println()
+		}
+
+		if len(toRemove) > len(movedTo) {
+			color.New(color.Bold, term.ColorHiRed).Println("🗑️  These files will be deleted:")
+			for _, path := range toRemove {
+				if _, ok := movedTo[path]; !ok {
+					note := ""
+					if removedFileChangedSinceProposed(currentPlanState, path) {
+						note = color.New(term.ColorHiYellow).Sprint(" (modified since the plan was proposed)")
+					}
+					fmt.Println("• " + path + note)
+				}
+			}
+			fmt.Println()
+		}
+
+		// a move touches two paths but counts as a change to one file
+		numToApply := len(toApply) - len(unchangedPaths) + len(toRemove) - len(movedTo)
 		suffix := ""
 		if numToApply > 1 {
 			suffix = "s"
 		}
-		shouldContinue, err := term.ConfirmYesNo("Apply changes to %d file%s?", numToApply, suffix)
+		var shouldContinue bool
+		var err error
+		if numToApply == 0 {
+			shouldContinue, err = term.ConfirmYesNo("No files need to be written. Mark the plan's changes as applied?")
+		} else {
+			shouldContinue, err = term.ConfirmYesNo("Apply changes to %d file%s?", numToApply, suffix)
+		}
 
 		if err != nil {
 			term.OutputErrorAndExit("failed to get confirmation user input: %s", err)
 		}
 
 		if !shouldContinue {
-			os.Exit(0)
+			term.Exit(0)
 		}
 		term.ResumeSpinner()
 	}
 
+	var hookFiles []string
+	for _, path := range applyPathsOf(toApply) {
+		if !unchangedPaths[path] {
+			hookFiles = append(hookFiles, path)
+		}
+	}
+	term.StopSpinner()
+	err = runApplyHooks(applyHookParams{
+		hook:    ApplyHookPre,
+		planId:  planId,
+		branch:  branch,
+		files:   hookFiles,
+		removed: toRemove,
+	})
+	if err != nil {
+		term.OutputErrorAndExit("%v. No files were modified.", err)
+	}
+	term.ResumeSpinner()
+
+	// files are read after stashing, so changes the plan was built with are merged out of what's written and come back with the stash
+	var stashed bool
+	if flags.Stash {
+		var err error
+		stashed, err = stashForApply()
+		if err != nil {
+			term.StopSpinner()
+			term.OutputErrorAndExit("failed to stash changes: %v", err)
+		}
+	}
+
 	onErr := func(errMsg string, errArgs ...interface{}) {
 		term.StopSpinner()
+		if stashed {
+			restoreApplyStash()
+		}
 		term.OutputErrorAndExit(errMsg, errArgs...)
 	}
 
@@ -108,108 +364,276 @@ func MustApplyPlan(planId, branch string, autoConfirm bool) {
 		term.OutputSimpleError(errMsg, unformattedErrMsg)
 	}
 
-	apiErr = api.Client.ApplyPlan(planId, branch)
-
-	if apiErr != nil {
-		onErr("failed to set pending results applied: %s", apiErr.Msg)
-		return
-	}
-
 	var updatedFiles []string
+	var unchangedFiles []string
+	toWrite := map[string]string{}
+	encodings := map[string]string{}
 	for path, content := range toApply {
-		// Compute destination path
-		dstPath := filepath.Join(fs.ProjectRoot, path)
+		dstPath := fs.ProjectFilePath(path)
 
 		content = strings.ReplaceAll(content, "\\`\\`\\`", "```")
 
-		// Check if the file exists
-		var exists bool
-		_, err := os.Stat(dstPath)
+		bytes, err := os.ReadFile(dstPath)
 		if err == nil {
-			exists = true
-		} else {
-			if os.IsNotExist(err) {
-				exists = false
-			} else {
-				onErr("failed to check if %s exists:", dstPath)
-				return
-			}
-		}
-
-		if exists {
-			// read file content
-			bytes, err := os.ReadFile(dstPath)
-
+			current, encoding, err := decodeFileContent(path, bytes)
 			if err != nil {
-				onErr("failed to read %s:", dstPath)
-				return
+				onErr("failed to read %s: %v", path, err)
+				return false
 			}
+			encodings[path] = encoding
+
+			content = matchLineEndings(current, content)
 
 			// Check if the file has changed
-			if string(bytes) == content {
-				// log.Println("File is unchanged, skipping")
+			if current == content {
+				unchangedFiles = append(unchangedFiles, path)
 				continue
-			} else {
-				updatedFiles = append(updatedFiles, path)
 			}
-		} else {
-			updatedFiles = append(updatedFiles, path)
 
-			// Create the directory if it doesn't exist
-			err := os.MkdirAll(filepath.Dir(dstPath), 0755)
-			if err != nil {
-				onErr("failed to create directory %s:", filepath.Dir(dstPath))
-				return
+			// if the file changed on disk after the changes were proposed or built, merge rather than overwriting those changes
+			context := currentPlanState.ContextsByPath[path]
+			baseSha, hasBaseSha := currentPlanState.GetBaseSha(path)
+			changedSinceProposed := hasBaseSha && shaOf(current) != baseSha
+			changedSinceBuilt := context != nil && context.Body != "" && current != context.Body
+
+			if (changedSinceProposed || changedSinceBuilt) && context == nil {
+				// without the file's context there's no common ancestor to merge with, and merging against an empty one would make every line a conflict
+				if flags.NoPrompt {
+					onErr("%s was changed since the plan's changes were proposed and has no earlier version to merge them with", path)
+					return false
+				}
+				if !confirmOverwriteChangedFile(path) {
+					term.ResumeSpinner()
+					continue
+				}
+				term.ResumeSpinner()
+			} else if changedSinceProposed || changedSinceBuilt {
+				merged, hasConflicts, err := threeWayMerge(context.Body, current, content)
+				if err != nil {
+					onErr("failed to merge changes to %s: %v", path, err)
+					return false
+				}
+
+				if hasConflicts {
+					var skip bool
+					merged, skip = resolveMergeConflicts(path, merged, content)
+					term.ResumeSpinner()
+					if skip {
+						continue
+					}
+				}
+
+				content = merged
+				if current == content {
+					unchangedFiles = append(unchangedFiles, path)
+					continue
+				}
 			}
+		} else if !os.IsNotExist(err) {
+			onErr("failed to read %s: %v", dstPath, err)
+			return false
 		}
 
-		// Write the file
-		err = os.WriteFile(dstPath, []byte(content), 0644)
+		updatedFiles = append(updatedFiles, path)
+		toWrite[path] = content
+	}
+
+	var removedFiles []string
+	for _, path := range toRemove {
+		_, err := os.Stat(fs.ProjectFilePath(path))
+		if err == nil {
+			removedFiles = append(removedFiles, path)
+		} else if !os.IsNotExist(err) {
+			onErr("failed to check if %s exists: %v", path, err)
+			return false
+		}
+	}
+	updatedFiles = append(updatedFiles, removedFiles...)
+
+	var backup *types.ApplyBackup
+	if len(updatedFiles) > 0 {
+		var err error
+		backup, err = createApplyBackup(planId, branch, updatedFiles)
 		if err != nil {
-			onErr("failed to write %s:", dstPath)
-			return
+			onErr("failed to back up files before applying: %v", err)
+			return false
+		}
+	}
+
+	contextModes := map[string]os.FileMode{}
+	for path, context := range currentPlanState.ContextsByPath {
+		if context.FileMode != 0 {
+			contextModes[path] = context.FileMode
 		}
 	}
 
+	written, err := writeFilesAtomic(writeFilesParams{
+		Files:         toWrite,
+		Encodings:     encodings,
+		Removals:      removedFiles,
+		MovedFrom:     currentPlanFiles.MovedFrom,
+		ContextModes:  contextModes,
+		DirMode:       dirMode,
+		SymlinkPolicy: linkPolicy,
+	})
+	if err != nil {
+		if backup != nil {
+			discardApplyBackup(backup)
+		}
+		onErr("failed to apply changes, no files were modified: %v", err)
+		return false
+	}
+
+	if !flags.DeferServerApply {
+		apiErr = api.Client.ApplyPlan(planId, branch)
+
+		if apiErr != nil {
+			if backup != nil {
+				discardApplyBackup(backup)
+			}
+			rollbackErr := written.rollback()
+			if rollbackErr != nil {
+				onErr("failed to set pending results applied: %s. Rolling back file changes also failed: %v", apiErr.Msg, rollbackErr)
+				return false
+			}
+			onErr("failed to set pending results applied, file changes were rolled back: %s", apiErr.Msg)
+			return false
+		}
+	}
+
+	written.finish()
+
 	term.StopSpinner()
 
+	if isRepo {
+		recordMoves(movedTo, removedFiles, toWrite)
+	}
+
+	// formatting runs before the commit so the commit has the formatted files
+	runFormatters(applyPathsOf(toWrite))
+
+	if len(updatedFiles) > 0 {
+		err = runApplyHooks(applyHookParams{
+			hook:    ApplyHookPost,
+			planId:  planId,
+			branch:  branch,
+			files:   applyPathsOf(toWrite),
+			removed: removedFiles,
+		})
+		if err != nil {
+			color.New(color.Bold, term.ColorHiYellow).Printf("⚠️  %v\n\n", err)
+		}
+	}
+
+	unchangedMsg := ""
+	if len(unchangedFiles) > 0 {
+		unchangedMsg = fmt.Sprintf(", %d unchanged", len(unchangedFiles))
+	}
+
 	if len(updatedFiles) == 0 {
-		fmt.Println("✅ Applied changes, but no files were updated")
-		return
+		if stashed {
+			restoreApplyStash()
+		}
+		fmt.Printf("✅ Applied changes, but no files were updated%s\n", unchangedMsg)
+		return true
 	} else {
-		if isRepo {
-			fmt.Println("✏️  Plandex can commit these updates with an automatically generated message.")
-			fmt.Println()
-			fmt.Println("ℹ️  Only the files that Plandex is updating will be included the commit. Any other changes, staged or unstaged, will remain exactly as they are.")
-			fmt.Println()
+		if isRepo && !flags.NoCommit {
+			confirmed := autoCommit
 
-			confirmed, err := term.ConfirmYesNo("Commit Plandex updates now?")
+			if !confirmed {
+				fmt.Println("✏️  Plandex can commit these updates with an automatically generated message.")
+				fmt.Println()
+				fmt.Println("ℹ️  Only the files that Plandex is updating will be included the commit. Any other changes, staged or unstaged, will remain exactly as they are.")
+				fmt.Println()
 
-			if err != nil {
-				onErr("failed to get confirmation user input: %s", err)
+				var err error
+				confirmed, err = term.ConfirmYesNo("Commit Plandex updates now?")
+
+				if err != nil {
+					onErr("failed to get confirmation user input: %s", err)
+				}
 			}
 
 			if confirmed {
-				// Commit the changes
-				msg := currentPlanState.PendingChangesSummaryForApply()
+				msg := getApplyCommitMsg(planId, branch, currentPlanState)
 
-				// log.Println("Committing changes with message:")
-				// log.Println(msg)
-
-				// spew.Dump(currentPlanState)
-
-				err := GitAddAndCommitPaths(fs.ProjectRoot, msg, updatedFiles, true)
-				if err != nil {
+				sha, err := commitAppliedFiles(planId, branch, msg, updatedFiles, currentPlanState)
+				if sha == "" {
 					onGitErr("Failed to commit changes:", err.Error())
+				} else {
+					fmt.Printf("📝 Committed as %s\n", sha[:min(len(sha), 7)])
+					if err != nil {
+						fmt.Printf("⚠️  Failed to record the commit on the plan: %v\n", err)
+					}
 				}
 			}
+		} else if autoCommit {
+			fmt.Println("⚠️  Not committing changes since the project isn't in a git repository")
+		}
+
+		if stashed {
+			restoreApplyStash()
 		}
 
 		suffix := ""
 		if len(updatedFiles) > 1 {
 			suffix = "s"
 		}
-		fmt.Printf("✅ Applied changes, %d file%s updated\n", len(updatedFiles), suffix)
+		fmt.Printf("✅ Applied changes, %d file%s updated%s\n", len(updatedFiles), suffix, unchangedMsg)
+		fmt.Println()
+		term.PrintCmds("", "undo")
+	}
+
+	return true
+}
+
+func applyPathsOf(files map[string]string) []string {
+	var paths []string
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func shaOf(content string) string {
+	hash := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(hash[:])
+}
+
+func removedFileChangedSinceProposed(currentPlanState *shared.CurrentPlanState, path string) bool {
+	baseSha, ok := currentPlanState.GetBaseSha(path)
+	if !ok {
+		return false
 	}
 
+	bytes, err := os.ReadFile(fs.ProjectFilePath(path))
+	if err != nil {
+		return false
+	}
+
+	current, _, err := decodeFileContent(path, bytes)
+	if err != nil {
+		return false
+	}
+
+	return shaOf(current) != baseSha
+}
+
+// recordMoves stages each applied move as a rename, like `git mv`, so the moved file keeps its history. Moves where only one side was written are left as they are.
+func recordMoves(movedTo map[string]string, removedFiles []string, written map[string]string) {
+	for _, from := range removedFiles {
+		to, ok := movedTo[from]
+		if !ok {
+			continue
+		}
+		if _, ok := written[to]; !ok {
+			continue
+		}
+
+		err := GitRecordMove(fs.ProjectRoot, from, to)
+		if err != nil {
+			color.New(term.ColorHiYellow).Printf("⚠️  Couldn't record the move of %s to %s in git: %v\n", from, to, err)
+		}
+	}
 }
@@ -0,0 +1,452 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"plandex/fs"
+	"sort"
+	"strconv"
+
+	"github.com/plandex/plandex/shared"
+)
+
+type pendingWrite struct {
+	path        string
+	dstPath     string
+	tmpPath     string
+	mode        os.FileMode
+	existed     bool
+	owner       *fileOwner
+	origContent []byte
+	renamed     bool
+	remove      bool
+
+	// set when a symlink is being replaced with a regular file, so rollback can restore the link
+	linkTarget string
+}
+
+type writeFilesParams struct {
+	Files     map[string]string
+	Encodings map[string]string // path -> encoding to write in, utf-8 if missing
+	Removals  []string
+	MovedFrom map[string]string // new path -> old path
+
+	// modes captured when files were loaded into context, used for files that no longer exist on disk
+	ContextModes map[string]os.FileMode
+
+	DirMode       os.FileMode
+	SymlinkPolicy symlinkPolicy
+}
+
+type atomicWrite struct {
+	writes      []*pendingWrite
+	dirMode     os.FileMode
+	createdDirs []string
+}
+
+// directories created during apply get this mode (before the umask is applied) unless PLANDEX_DIR_MODE is set
+const defaultNewDirMode os.FileMode = 0755
+
+func getNewDirMode() (os.FileMode, error) {
+	s := os.Getenv("PLANDEX_DIR_MODE")
+	if s == "" {
+		return defaultNewDirMode, nil
+	}
+
+	mode, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || mode > 0777 {
+		return 0, fmt.Errorf("invalid PLANDEX_DIR_MODE %q, expected an octal permission mode like 0755", s)
+	}
+
+	// a directory the owner can't enter or write to would make the apply fail partway
+	if mode&0700 != 0700 {
+		return 0, fmt.Errorf("invalid PLANDEX_DIR_MODE %q, the owner needs read, write, and execute permissions", s)
+	}
+
+	return os.FileMode(mode), nil
+}
+
+// getMissingDirs returns the directories that need to be created to write the given project paths, parents first
+func getMissingDirs(paths []string) ([]string, error) {
+	set := map[string]bool{}
+	for _, path := range paths {
+		missing, err := missingAncestors(filepath.Dir(fs.ProjectFilePath(path)))
+		if err != nil {
+			return nil, err
+		}
+		for _, dir := range missing {
+			rel, err := filepath.Rel(fs.ProjectRoot, dir)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get relative path for %s: %v", dir, err)
+			}
+			set[rel] = true
+		}
+	}
+
+	var dirs []string
+	for dir := range set {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	return dirs, nil
+}
+
+// missingAncestors returns dir and each of its ancestors that don't exist yet, deepest first
+func missingAncestors(dir string) ([]string, error) {
+	var missing []string
+	for d := dir; ; d = filepath.Dir(d) {
+		_, err := os.Stat(d)
+		if err == nil {
+			break
+		} else if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to check if %s exists: %v", d, err)
+		}
+		missing = append(missing, d)
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+	return missing, nil
+}
+
+// writeFilesAtomic writes each file to a temp file in the same directory, then renames all of them into place. Since a rename within a directory is atomic, a crash can never leave a half-written source file. If any write or rename fails, every file already renamed is restored, so the batch is all or nothing.
+//
+// Removed files are renamed aside rather than deleted, so they can still be restored until finish is called.
+//
+// Overwritten files keep their mode and, where the OS allows it, their owner. A new file takes its mode from the file it was moved from, or from when it was loaded into context, falling back to 0644.
+//
+// Files that are symlinks are handled according to the symlink policy: following a link writes to its target, leaving the link in place.
+func writeFilesAtomic(params writeFilesParams) (*atomicWrite, error) {
+	files := params.Files
+	removals := params.Removals
+	aw := &atomicWrite{dirMode: params.DirMode}
+
+	for path := range files {
+		err := shared.ValidatePlanPath(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, path := range removals {
+		err := shared.ValidatePlanPath(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Strings(removals)
+	for _, path := range removals {
+		dstPath := fs.ProjectFilePath(path)
+
+		_, err := os.Stat(dstPath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			aw.cleanup()
+			return nil, fmt.Errorf("failed to check if %s exists: %v", dstPath, err)
+		}
+
+		tmp, err := os.CreateTemp(filepath.Dir(dstPath), ".plandex-removed-*")
+		if err != nil {
+			aw.cleanup()
+			return nil, fmt.Errorf("failed to remove %s: %v", dstPath, err)
+		}
+		tmp.Close()
+
+		aw.writes = append(aw.writes, &pendingWrite{
+			path:    path,
+			dstPath: dstPath,
+			tmpPath: tmp.Name(),
+			existed: true,
+			remove:  true,
+		})
+	}
+
+	// sort for a deterministic write order
+	var paths []string
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	links, err := getSymlinkedFiles(paths)
+	if err == nil {
+		err = checkSymlinkPolicy(links, params.SymlinkPolicy)
+	}
+	if err != nil {
+		aw.cleanup()
+		return nil, err
+	}
+	linksByPath := map[string]*symlinkedFile{}
+	for _, link := range links {
+		linksByPath[link.path] = link
+	}
+
+	pathsByDst := map[string]string{}
+
+	for _, path := range paths {
+		dstPath := fs.ProjectFilePath(path)
+
+		w := &pendingWrite{
+			path:    path,
+			dstPath: dstPath,
+			mode:    0644,
+		}
+
+		if link := linksByPath[path]; link != nil {
+			if params.SymlinkPolicy == symlinkPolicyReplace {
+				w.linkTarget = link.target
+			} else {
+				dstPath = link.resolved
+				w.dstPath = dstPath
+			}
+		}
+
+		// following links could point two plan paths at the same file
+		if other, ok := pathsByDst[dstPath]; ok {
+			aw.cleanup()
+			return nil, fmt.Errorf("%s and %s are the same file, so both can't be updated", other, path)
+		}
+		pathsByDst[dstPath] = path
+
+		info, err := os.Stat(dstPath)
+		if w.linkTarget != "" {
+			// a link being replaced is restored as a link on rollback, whether or not its target exists
+			w.existed = true
+			if err == nil {
+				w.mode = info.Mode().Perm()
+			}
+		} else if err == nil {
+			w.existed = true
+			w.mode = info.Mode().Perm()
+			w.owner = getFileOwner(info)
+
+			w.origContent, err = os.ReadFile(dstPath)
+			if err != nil {
+				aw.cleanup()
+				return nil, fmt.Errorf("failed to read %s: %v", dstPath, err)
+			}
+		} else if !os.IsNotExist(err) {
+			aw.cleanup()
+			return nil, fmt.Errorf("failed to check if %s exists: %v", dstPath, err)
+		} else {
+			if mode, ok := params.ContextModes[path]; ok && mode != 0 {
+				w.mode = mode
+			}
+
+			if from, ok := params.MovedFrom[path]; ok {
+				fromInfo, err := os.Stat(fs.ProjectFilePath(from))
+				if err == nil {
+					w.mode = fromInfo.Mode().Perm()
+					w.owner = getFileOwner(fromInfo)
+				}
+			}
+
+			err = aw.mkdirAll(filepath.Dir(dstPath))
+			if err != nil {
+				aw.cleanup()
+				return nil, err
+			}
+		}
+
+		encoded, err := encodeFileContent(path, files[path], params.Encodings[path])
+		if err != nil {
+			aw.cleanup()
+			return nil, err
+		}
+
+		tmpPath, err := writeTempFile(filepath.Dir(dstPath), encoded, w.mode)
+		if err != nil {
+			aw.cleanup()
+			return nil, fmt.Errorf("failed to write %s: %v", dstPath, err)
+		}
+		w.tmpPath = tmpPath
+		w.owner.apply(tmpPath)
+
+		aw.writes = append(aw.writes, w)
+	}
+
+	for _, w := range aw.writes {
+		var err error
+		if w.remove {
+			// the placeholder only reserved a unique name
+			os.Remove(w.tmpPath)
+			err = os.Rename(w.dstPath, w.tmpPath)
+		} else {
+			err = os.Rename(w.tmpPath, w.dstPath)
+		}
+		if err != nil {
+			rollbackErr := aw.rollback()
+			if rollbackErr != nil {
+				return nil, fmt.Errorf("failed to write %s: %v. Rolling back also failed: %v", w.dstPath, err, rollbackErr)
+			}
+			return nil, fmt.Errorf("failed to write %s: %v", w.dstPath, err)
+		}
+		w.renamed = true
+	}
+
+	return aw, nil
+}
+
+// rollback restores files that were overwritten, removes files that were created, and removes any directories created along the way
+func (aw *atomicWrite) rollback() error {
+	var errs []error
+
+	for _, w := range aw.writes {
+		if !w.renamed {
+			if w.tmpPath != "" {
+				os.Remove(w.tmpPath)
+			}
+			continue
+		}
+
+		if w.remove {
+			err := os.Rename(w.tmpPath, w.dstPath)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to restore %s: %v", w.dstPath, err))
+			}
+		} else if w.linkTarget != "" {
+			err := restoreSymlink(w.dstPath, w.linkTarget)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to restore the symlink %s: %v", w.dstPath, err))
+			}
+		} else if w.existed {
+			tmpPath, err := writeTempFile(filepath.Dir(w.dstPath), w.origContent, w.mode)
+			if err == nil {
+				w.owner.apply(tmpPath)
+				err = os.Rename(tmpPath, w.dstPath)
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to restore %s: %v", w.dstPath, err))
+			}
+		} else {
+			err := os.Remove(w.dstPath)
+			if err != nil && !os.IsNotExist(err) {
+				errs = append(errs, fmt.Errorf("failed to remove %s: %v", w.dstPath, err))
+			}
+		}
+		w.renamed = false
+	}
+
+	aw.removeCreatedDirs()
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%v", errs)
+	}
+	return nil
+}
+
+// finish deletes removed files for good once the apply can no longer be rolled back
+func (aw *atomicWrite) finish() {
+	for _, w := range aw.writes {
+		if w.remove && w.renamed {
+			os.Remove(w.tmpPath)
+		}
+	}
+}
+
+func (aw *atomicWrite) cleanup() {
+	for _, w := range aw.writes {
+		if w.tmpPath != "" && !w.renamed {
+			os.Remove(w.tmpPath)
+		}
+	}
+	aw.removeCreatedDirs()
+}
+
+func (aw *atomicWrite) mkdirAll(dir string) error {
+	// track each missing ancestor so rollback can remove exactly what was created
+	missing, err := missingAncestors(dir)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(dir, aw.dirMode)
+	if err != nil {
+		return fmt.Errorf("failed to create directory %s: %v", dir, err)
+	}
+
+	aw.createdDirs = append(aw.createdDirs, missing...)
+	return nil
+}
+
+func (aw *atomicWrite) removeCreatedDirs() {
+	// deepest first; os.Remove only removes empty directories
+	sort.Slice(aw.createdDirs, func(i, j int) bool {
+		return len(aw.createdDirs[i]) > len(aw.createdDirs[j])
+	})
+	for _, dir := range aw.createdDirs {
+		os.Remove(dir)
+	}
+	aw.createdDirs = nil
+}
+
+func writeTempFile(dir string, content []byte, mode os.FileMode) (string, error) {
+	tmp, err := os.CreateTemp(dir, ".plandex-apply-*")
+	if err != nil {
+		return "", err
+	}
+	tmpPath := tmp.Name()
+
+	_, err = tmp.Write(content)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	closeErr := tmp.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpPath, mode)
+	}
+
+	if err != nil {
+		os.Remove(tmpPath)
+		return "", err
+	}
+
+	return tmpPath, nil
+}
+
+// restoreSymlink puts back a link that was replaced with a regular file, creating it under a temp name first so the swap is atomic like any other write
+func restoreSymlink(dstPath, target string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(dstPath), ".plandex-apply-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	tmp.Close()
+	os.Remove(tmpPath)
+
+	err = os.Symlink(target, tmpPath)
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(tmpPath, dstPath)
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return nil
+}
+
+// writeFileAtomic writes content to a temp file next to path and renames it into place, so readers never see a partial file
+func writeFileAtomic(path string, content []byte, mode os.FileMode) error {
+	tmpPath, err := writeTempFile(filepath.Dir(path), content, mode)
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(tmpPath, path)
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return nil
+}
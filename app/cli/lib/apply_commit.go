@@ -0,0 +1,108 @@
+package lib
+
+import (
+	"fmt"
+	"os/exec"
+	"plandex/api"
+	"plandex/fs"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// prompts longer than this are cut off in commit messages
+const maxCommitPromptChars = 1000
+
+// getApplyCommitMsg builds a commit message from the descriptions of the pending changes, followed by the prompts that produced them
+func getApplyCommitMsg(planId, branch string, currentPlanState *shared.CurrentPlanState) string {
+	msg := currentPlanState.PendingChangesSummaryForApply()
+
+	convoIds := map[string]bool{}
+	for _, result := range currentPlanState.PlanResult.Results {
+		if result.IsPending() && result.ConvoMessageId != "" {
+			convoIds[result.ConvoMessageId] = true
+		}
+	}
+
+	if len(convoIds) == 0 {
+		return msg
+	}
+
+	// the prompts are a nice to have, so the commit goes ahead with just the summary if the conversation can't be loaded
+	convo, apiErr := api.Client.ListConvo(planId, branch)
+	if apiErr != nil {
+		return msg
+	}
+
+	var prompts []string
+	var lastPrompt string
+	for _, convoMsg := range convo {
+		if convoMsg.Role == "user" {
+			lastPrompt = convoMsg.Message
+			continue
+		}
+
+		if convoIds[convoMsg.Id] && lastPrompt != "" {
+			prompts = append(prompts, lastPrompt)
+			lastPrompt = ""
+		}
+	}
+
+	for _, prompt := range prompts {
+		prompt = strings.TrimSpace(prompt)
+		if len(prompt) > maxCommitPromptChars {
+			prompt = prompt[:maxCommitPromptChars] + "..."
+		}
+		msg += "\n\n💬 Prompt:\n" + prompt
+	}
+
+	return msg
+}
+
+// commitAppliedFiles commits exactly the given paths and records the commit on the plan, along with the replies that proposed each file's changes, returning its sha
+func commitAppliedFiles(planId, branch, msg string, paths []string, currentPlanState *shared.CurrentPlanState) (string, error) {
+	err := GitAddAndCommitPaths(fs.ProjectRoot, msg, paths, true)
+	if err != nil {
+		return "", err
+	}
+
+	res, err := exec.Command("git", "-C", fs.ProjectRoot, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return "", fmt.Errorf("error getting commit sha: %v", err)
+	}
+	sha := strings.TrimSpace(string(res))
+
+	gitBranch, err := GetCurrentGitBranch()
+	if err != nil {
+		return sha, err
+	}
+
+	err = recordAppliedCommit(planId, branch, sha, gitBranch, getConvoMessageIdsByPath(currentPlanState, paths))
+	if err != nil {
+		return sha, err
+	}
+
+	return sha, nil
+}
+
+// getConvoMessageIdsByPath returns the replies that proposed the pending changes to each path, in the order they were made
+func getConvoMessageIdsByPath(currentPlanState *shared.CurrentPlanState, paths []string) map[string][]string {
+	include := map[string]bool{}
+	for _, path := range paths {
+		include[path] = true
+	}
+
+	res := map[string][]string{}
+	for _, result := range currentPlanState.PlanResult.Results {
+		if !result.IsPending() || result.ConvoMessageId == "" || !include[result.Path] {
+			continue
+		}
+
+		ids := res[result.Path]
+		if len(ids) == 0 || ids[len(ids)-1] != result.ConvoMessageId {
+			res[result.Path] = append(ids, result.ConvoMessageId)
+		}
+	}
+
+	return res
+}
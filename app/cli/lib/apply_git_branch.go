@@ -0,0 +1,51 @@
+package lib
+
+import (
+	"fmt"
+	"os/exec"
+	"plandex/fs"
+	"strings"
+)
+
+// switchToGitBranch switches the project's repo to the named branch before applying, creating it from the current HEAD if it doesn't exist yet. Uncommitted changes are carried over by git, and it refuses to switch if they would be overwritten.
+func switchToGitBranch(name string) (created bool, err error) {
+	gitMutex.Lock()
+	defer gitMutex.Unlock()
+
+	res, err := exec.Command("git", "-C", fs.ProjectRoot, "check-ref-format", "--branch", name).CombinedOutput()
+	if err != nil {
+		return false, fmt.Errorf("%s isn't a valid branch name", name)
+	}
+	name = strings.TrimSpace(string(res))
+
+	current, err := GetCurrentGitBranch()
+	if err != nil {
+		return false, err
+	}
+	if current == name {
+		return false, nil
+	}
+
+	args := []string{"-C", fs.ProjectRoot, "switch", name}
+	err = exec.Command("git", "-C", fs.ProjectRoot, "show-ref", "--verify", "--quiet", "refs/heads/"+name).Run()
+	if err != nil {
+		args = []string{"-C", fs.ProjectRoot, "switch", "-c", name}
+		created = true
+	}
+
+	res, err = exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		return false, fmt.Errorf("error switching to git branch %s: %v, output: %s", name, err, string(res))
+	}
+
+	return created, nil
+}
+
+// GetCurrentGitBranch returns the name of the checked out branch, or HEAD if it's detached
+func GetCurrentGitBranch() (string, error) {
+	res, err := exec.Command("git", "-C", fs.ProjectRoot, "rev-parse", "--abbrev-ref", "HEAD").Output()
+	if err != nil {
+		return "", fmt.Errorf("error getting current git branch: %v", err)
+	}
+	return strings.TrimSpace(string(res)), nil
+}
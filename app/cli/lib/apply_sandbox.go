@@ -0,0 +1,314 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"plandex/api"
+	"plandex/fs"
+	"plandex/term"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+type applySandbox struct {
+	// the worktree's top level directory
+	dir string
+	// the repo's top level directory, which changes are copied back to
+	repoRoot string
+	// the project root inside the worktree, which may be a subdirectory of it like the real project root is of the repo
+	projectRoot string
+
+	origProjectRoot string
+	origCwd         string
+	entered         bool
+}
+
+// MustApplyInSandbox applies the plan in a temporary git worktree holding a copy of the project, including uncommitted and untracked files, and runs the verification loop there. The changes are only copied back to the project and marked applied on the server once verification passes; if it doesn't, the project is left untouched, the changes stay pending, and the worktree is kept for inspection.
+func MustApplyInSandbox(planId, branch string, flags ApplyFlags, verifyCmd string, maxFixes int) {
+	if !fs.ProjectRootIsGitRepo() {
+		term.OutputErrorAndExit("Can't apply in a sandbox: the project isn't in a git repository")
+	}
+	if flags.GitBranch != "" || flags.Stash {
+		term.OutputErrorAndExit("Can't apply in a sandbox with --branch or --stash")
+	}
+
+	// the commit message is built before applying, while the changes are still pending
+	var commitMsg string
+	currentPlanState, apiErr := api.Client.GetCurrentPlanState(planId, branch)
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting current plan state: %v", apiErr.Msg)
+	}
+	if flags.AutoCommit {
+		commitMsg = getApplyCommitMsg(planId, branch, currentPlanState)
+	}
+
+	term.StartSpinner("🏖️  Creating sandbox...")
+	sandbox, err := createApplySandbox(planId)
+	term.StopSpinner()
+	if err != nil {
+		term.OutputErrorAndExit("Error creating sandbox: %v", err)
+	}
+
+	// an early exit leaves the project untouched, so the sandbox isn't worth keeping
+	removeExitHook := term.OnExit(func() {
+		if sandbox.entered {
+			sandbox.leave()
+		}
+		sandbox.remove()
+	})
+	defer removeExitHook()
+
+	fmt.Printf("🏖️  Applying in a sandbox at %s\n", sandbox.dir)
+	fmt.Println()
+
+	err = sandbox.enter()
+	if err != nil {
+		term.OutputErrorAndExit("Error entering sandbox: %v", err)
+	}
+
+	// the changes are only marked applied on the server once they're copied back to the project
+	sandboxFlags := ApplyFlags{AutoConfirm: flags.AutoConfirm, NoCommit: true, AllowRisky: flags.AllowRisky, DeferServerApply: true}
+	applied := MustApplyPlan(planId, branch, sandboxFlags)
+
+	passed := true
+	if applied && verifyCmd != "" {
+		sandboxFlags.AutoConfirm = true
+		passed = MustRunVerifyLoop(planId, branch, verifyCmd, maxFixes, sandboxFlags)
+	}
+
+	err = sandbox.leave()
+	if err != nil {
+		term.OutputErrorAndExit("Error leaving sandbox: %v", err)
+	}
+
+	if !applied {
+		removeExitHook()
+		sandbox.remove()
+		return
+	}
+
+	fmt.Println()
+
+	if !passed {
+		removeExitHook()
+		color.New(color.Bold, term.ColorHiRed).Println("🏖️  Verification didn't pass in the sandbox, so your project files weren't changed")
+		fmt.Println()
+		fmt.Printf("The sandbox is at %s for inspection. Remove it with 'git worktree remove --force %s'\n", sandbox.dir, sandbox.dir)
+		fmt.Println()
+		term.PrintCmds("", "rewind", "tell")
+		return
+	}
+
+	paths, err := sandbox.copyBack()
+	if err != nil {
+		removeExitHook()
+		term.OutputErrorAndExit("Error copying changes back from the sandbox at %s: %v", sandbox.dir, err)
+	}
+
+	removeExitHook()
+	sandbox.remove()
+
+	term.StartSpinner("")
+	apiErr = api.Client.ApplyPlan(planId, branch)
+	term.StopSpinner()
+	if apiErr != nil {
+		term.OutputErrorAndExit("Changes were copied to your project, but marking them applied failed, so the plan still shows them as pending: %v", apiErr.Msg)
+	}
+
+	if len(paths) == 0 {
+		fmt.Println("✅ Applied in the sandbox, but no files were updated")
+		return
+	}
+
+	suffix := ""
+	if len(paths) > 1 {
+		suffix = "s"
+	}
+	fmt.Printf("✅ Copied changes to %d file%s back from the sandbox\n", len(paths), suffix)
+
+	if flags.AutoCommit {
+		sha, err := commitAppliedFiles(planId, branch, commitMsg, paths, currentPlanState)
+		if sha == "" {
+			term.OutputSimpleError("Failed to commit changes:", err.Error())
+		} else {
+			fmt.Printf("📝 Committed as %s\n", sha[:min(len(sha), 7)])
+			if err != nil {
+				fmt.Printf("⚠️  Failed to record the commit on the plan: %v\n", err)
+			}
+		}
+	}
+
+	fmt.Println()
+	term.PrintCmds("", "undo")
+}
+
+// createApplySandbox adds a detached worktree at a snapshot of the working tree. 'git stash create' snapshots tracked changes without touching the tree or the stash list; untracked files aren't in the snapshot, so they're copied over.
+func createApplySandbox(planId string) (*applySandbox, error) {
+	gitMutex.Lock()
+	defer gitMutex.Unlock()
+
+	res, err := exec.Command("git", "-C", fs.ProjectRoot, "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return nil, fmt.Errorf("error getting repo root: %v", err)
+	}
+	repoRoot := strings.TrimSpace(string(res))
+
+	rel, err := filepath.Rel(repoRoot, fs.ProjectRoot)
+	if err != nil {
+		return nil, fmt.Errorf("error getting project path in repo: %v", err)
+	}
+
+	res, err = exec.Command("git", "-C", repoRoot, "stash", "create").Output()
+	if err != nil {
+		return nil, fmt.Errorf("error snapshotting uncommitted changes: %v", err)
+	}
+	base := strings.TrimSpace(string(res))
+	if base == "" {
+		base = "HEAD"
+	}
+
+	dir := filepath.Join(fs.HomePlandexDir, CurrentProjectId, planId, "sandboxes", time.Now().Format("20060102-150405"))
+	err = os.MkdirAll(filepath.Dir(dir), os.ModePerm)
+	if err != nil {
+		return nil, fmt.Errorf("error creating sandboxes dir: %v", err)
+	}
+
+	out, err := exec.Command("git", "-C", repoRoot, "worktree", "add", "--detach", dir, base).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("error adding worktree: %v, output: %s", err, string(out))
+	}
+
+	sandbox := &applySandbox{
+		dir:         dir,
+		repoRoot:    repoRoot,
+		projectRoot: filepath.Join(dir, rel),
+	}
+
+	res, err = exec.Command("git", "-C", repoRoot, "ls-files", "--others", "--exclude-standard", "-z").Output()
+	if err != nil {
+		sandbox.removeLocked()
+		return nil, fmt.Errorf("error listing untracked files: %v", err)
+	}
+
+	for _, path := range strings.Split(string(res), "\x00") {
+		if path == "" {
+			continue
+		}
+		err = copyFileWithMode(filepath.Join(repoRoot, path), filepath.Join(dir, path))
+		if err != nil {
+			sandbox.removeLocked()
+			return nil, fmt.Errorf("error copying untracked file %s: %v", path, err)
+		}
+	}
+
+	return sandbox, nil
+}
+
+// enter points the project root and working directory at the sandbox, so applying and verifying read and write its files instead of the project's
+func (s *applySandbox) enter() error {
+	s.origProjectRoot = fs.ProjectRoot
+	s.origCwd = fs.Cwd
+
+	err := os.Chdir(s.projectRoot)
+	if err != nil {
+		return err
+	}
+
+	fs.ProjectRoot = s.projectRoot
+	fs.Cwd = s.projectRoot
+	s.entered = true
+	return nil
+}
+
+func (s *applySandbox) leave() error {
+	s.entered = false
+	fs.ProjectRoot = s.origProjectRoot
+	fs.Cwd = s.origCwd
+	return os.Chdir(s.origCwd)
+}
+
+// copyBack copies every file that differs from the sandbox's starting snapshot to the project, and deletes the ones removed in the sandbox. Returns the changed paths relative to the project root.
+func (s *applySandbox) copyBack() ([]string, error) {
+	res, err := exec.Command("git", "-C", s.dir, "status", "--porcelain", "-z", "--untracked-files=all").Output()
+	if err != nil {
+		return nil, fmt.Errorf("error listing sandbox changes: %v", err)
+	}
+
+	var paths []string
+	for _, entry := range strings.Split(string(res), "\x00") {
+		if len(entry) < 4 {
+			continue
+		}
+		status, path := entry[:2], entry[3:]
+
+		src := filepath.Join(s.dir, path)
+		dst := filepath.Join(s.repoRoot, path)
+
+		if strings.Contains(status, "D") {
+			err = os.Remove(dst)
+			if err != nil && !os.IsNotExist(err) {
+				return paths, fmt.Errorf("error removing %s: %v", path, err)
+			}
+		} else {
+			// untracked files copied into the sandbox show up as new, but only the ones that changed need to be written
+			srcBytes, err := os.ReadFile(src)
+			if err != nil {
+				return paths, fmt.Errorf("error reading %s: %v", path, err)
+			}
+			dstBytes, err := os.ReadFile(dst)
+			if err == nil && bytes.Equal(srcBytes, dstBytes) {
+				continue
+			}
+
+			err = copyFileWithMode(src, dst)
+			if err != nil {
+				return paths, fmt.Errorf("error copying %s: %v", path, err)
+			}
+		}
+
+		rel, err := filepath.Rel(fs.ProjectRoot, dst)
+		if err != nil {
+			return paths, err
+		}
+		paths = append(paths, filepath.ToSlash(rel))
+	}
+
+	return paths, nil
+}
+
+func (s *applySandbox) remove() {
+	gitMutex.Lock()
+	defer gitMutex.Unlock()
+	s.removeLocked()
+}
+
+func (s *applySandbox) removeLocked() {
+	out, err := exec.Command("git", "-C", s.repoRoot, "worktree", "remove", "--force", s.dir).CombinedOutput()
+	if err != nil {
+		fmt.Printf("⚠️  Failed to remove the sandbox at %s: %s\n", s.dir, strings.TrimSpace(string(out)))
+	}
+}
+
+func copyFileWithMode(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(dst), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(dst, content, info.Mode().Perm())
+}
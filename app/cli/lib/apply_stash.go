@@ -0,0 +1,65 @@
+package lib
+
+import (
+	"fmt"
+	"os/exec"
+	"plandex/fs"
+	"plandex/term"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+const applyStashMsg = "plandex: stashed while applying plan"
+
+// .plandex holds the project and current plan, which have to stay in place while the plan is applied
+var applyStashPathspec = []string{"--", ".", ":(exclude).plandex"}
+
+// stashForApply stashes all uncommitted changes other than .plandex, including untracked files, so the plan's changes can be written and committed on top of a clean tree. Returns false if there was nothing to stash.
+func stashForApply() (bool, error) {
+	gitMutex.Lock()
+	defer gitMutex.Unlock()
+
+	res, err := exec.Command("git", append([]string{"-C", fs.ProjectRoot, "status", "--porcelain", "--untracked-files=all"}, applyStashPathspec...)...).Output()
+	if err != nil {
+		return false, fmt.Errorf("error checking for uncommitted changes: %v", err)
+	}
+	if strings.TrimSpace(string(res)) == "" {
+		return false, nil
+	}
+
+	out, err := exec.Command("git", append([]string{"-C", fs.ProjectRoot, "stash", "push", "--include-untracked", "-m", applyStashMsg}, applyStashPathspec...)...).CombinedOutput()
+	if err != nil {
+		return false, fmt.Errorf("error creating git stash: %v, output: %s", err, string(out))
+	}
+
+	return true, nil
+}
+
+// restoreApplyStash pops the stash made by stashForApply back on top of the applied changes. If the stash conflicts with the plan's changes, git leaves conflict markers in the files and keeps the stash, so the conflicting files are listed for the user to resolve.
+func restoreApplyStash() {
+	gitMutex.Lock()
+	defer gitMutex.Unlock()
+
+	out, err := exec.Command("git", "-C", fs.ProjectRoot, "stash", "pop").CombinedOutput()
+	if err == nil {
+		fmt.Println("📦 Restored your uncommitted changes")
+		return
+	}
+
+	res, _ := exec.Command("git", "-C", fs.ProjectRoot, "diff", "--name-only", "--diff-filter=U").Output()
+	conflicts := strings.Fields(string(res))
+
+	if len(conflicts) == 0 {
+		term.OutputSimpleError("Failed to restore your uncommitted changes:", strings.TrimSpace(string(out)))
+		fmt.Println("Your changes are still in the stash. Restore them with 'git stash pop'")
+		return
+	}
+
+	color.New(color.Bold, term.ColorHiYellow).Println("⚠️  Your uncommitted changes conflict with the plan's changes in these files:")
+	for _, path := range conflicts {
+		fmt.Println("• " + path)
+	}
+	fmt.Println()
+	fmt.Println("Resolve the conflict markers in each file, then remove the stash with 'git stash drop'")
+}
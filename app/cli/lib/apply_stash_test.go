@@ -0,0 +1,108 @@
+package lib
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"plandex/fs"
+	"testing"
+)
+
+func TestApplyStashKeepsPlandexDir(t *testing.T) {
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		out, err := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v, output: %s", args, err, out)
+		}
+	}
+	write := func(path, content string) {
+		t.Helper()
+		err := os.MkdirAll(filepath.Dir(filepath.Join(dir, path)), 0755)
+		if err == nil {
+			err = os.WriteFile(filepath.Join(dir, path), []byte(content), 0644)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	read := func(path string) string {
+		t.Helper()
+		bytes, err := os.ReadFile(filepath.Join(dir, path))
+		if err != nil {
+			t.Fatalf("reading %s: %v", path, err)
+		}
+		return string(bytes)
+	}
+
+	git("init", "-q")
+	write("main.go", "package main\n")
+	git("add", "main.go")
+	git("commit", "-qm", "init")
+
+	write(".plandex/project.json", `{"id":"project"}`)
+	write("main.go", "package main\n\n// edited\n")
+	write("notes.txt", "untracked\n")
+
+	origRoot := fs.ProjectRoot
+	fs.ProjectRoot = dir
+	defer func() { fs.ProjectRoot = origRoot }()
+
+	stashed, err := stashForApply()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stashed {
+		t.Fatal("expected changes to be stashed")
+	}
+
+	if got := read(".plandex/project.json"); got != `{"id":"project"}` {
+		t.Errorf(".plandex/project.json = %q while stashed", got)
+	}
+	if got := read("main.go"); got != "package main\n" {
+		t.Errorf("main.go = %q while stashed, expected the committed version", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "notes.txt")); !os.IsNotExist(err) {
+		t.Errorf("notes.txt should be stashed, got err %v", err)
+	}
+
+	restoreApplyStash()
+
+	if got := read(".plandex/project.json"); got != `{"id":"project"}` {
+		t.Errorf(".plandex/project.json = %q after pop", got)
+	}
+	if got := read("main.go"); got != "package main\n\n// edited\n" {
+		t.Errorf("main.go = %q after pop", got)
+	}
+	if got := read("notes.txt"); got != "untracked\n" {
+		t.Errorf("notes.txt = %q after pop", got)
+	}
+}
+
+func TestApplyStashNothingOutsidePlandexDir(t *testing.T) {
+	dir := t.TempDir()
+	out, err := exec.Command("git", "-C", dir, "init", "-q").CombinedOutput()
+	if err != nil {
+		t.Fatalf("git init: %v, output: %s", err, out)
+	}
+	err = os.MkdirAll(filepath.Join(dir, ".plandex"), 0755)
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, ".plandex", "project.json"), []byte("{}"), 0644)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	origRoot := fs.ProjectRoot
+	fs.ProjectRoot = dir
+	defer func() { fs.ProjectRoot = origRoot }()
+
+	stashed, err := stashForApply()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stashed {
+		t.Error("expected nothing to stash when only .plandex has changes")
+	}
+}
@@ -0,0 +1,39 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// ApplyInSubprocess applies the current plan's pending changes in a separate 'plandex apply' process, which can exit on errors or when a change can't be applied without asking, without taking down the server that called it. With gitBranch set, the changes are committed to that branch. Otherwise they're committed to the current branch unless commit is false. Returns apply's output.
+func ApplyInSubprocess(gitBranch string, commit, allowRisky bool) (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("error finding plandex executable: %v", err)
+	}
+
+	cmdArgs := []string{"apply", "--yes", "--no-prompt"}
+	if gitBranch != "" {
+		cmdArgs = append(cmdArgs, "--branch", gitBranch)
+	} else if commit {
+		cmdArgs = append(cmdArgs, "--commit")
+	} else {
+		cmdArgs = append(cmdArgs, "--no-commit")
+	}
+	if allowRisky {
+		cmdArgs = append(cmdArgs, "--allow-risky")
+	}
+
+	cmd := exec.Command(exe, cmdArgs...)
+	cmd.Env = append(os.Environ(), "PLANDEX_SKIP_UPGRADE=1", "PLANDEX_DISABLE_SUGGESTIONS=1")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("apply failed:\n%s", output)
+		}
+		return "", fmt.Errorf("error running apply: %v", err)
+	}
+
+	return string(output), nil
+}
@@ -0,0 +1,111 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"plandex/fs"
+	"sort"
+	"strings"
+)
+
+type symlinkPolicy string
+
+// what apply does when a file it's writing is a symlink, set with PLANDEX_SYMLINK_POLICY
+const (
+	// write through the link to its target, as long as the target is inside the project
+	symlinkPolicyFollow symlinkPolicy = "follow"
+	// replace the link with a regular file
+	symlinkPolicyReplace symlinkPolicy = "replace"
+	// refuse to apply
+	symlinkPolicyRefuse symlinkPolicy = "refuse"
+)
+
+func getSymlinkPolicy() (symlinkPolicy, error) {
+	s := strings.ToLower(strings.TrimSpace(os.Getenv("PLANDEX_SYMLINK_POLICY")))
+	switch symlinkPolicy(s) {
+	case "":
+		return symlinkPolicyFollow, nil
+	case symlinkPolicyFollow, symlinkPolicyReplace, symlinkPolicyRefuse:
+		return symlinkPolicy(s), nil
+	}
+	return "", fmt.Errorf("invalid PLANDEX_SYMLINK_POLICY %q, expected follow, replace, or refuse", s)
+}
+
+type symlinkedFile struct {
+	path string
+	// the link as stored, which may be relative to the link's directory
+	target string
+	// absolute path the link resolves to, empty if it's dangling
+	resolved string
+}
+
+// getSymlinkedFiles returns the paths that are currently symlinks on disk
+func getSymlinkedFiles(paths []string) ([]*symlinkedFile, error) {
+	var links []*symlinkedFile
+	for _, path := range paths {
+		dstPath := fs.ProjectFilePath(path)
+
+		info, err := os.Lstat(dstPath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to check %s: %v", dstPath, err)
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			continue
+		}
+
+		target, err := os.Readlink(dstPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the symlink %s: %v", dstPath, err)
+		}
+
+		resolved, err := filepath.EvalSymlinks(dstPath)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to resolve the symlink %s: %v", dstPath, err)
+		}
+
+		links = append(links, &symlinkedFile{path: path, target: target, resolved: resolved})
+	}
+
+	sort.Slice(links, func(i, j int) bool {
+		return links[i].path < links[j].path
+	})
+
+	return links, nil
+}
+
+// checkSymlinkPolicy returns an error if any of the links can't be written under the policy. Following a link is only allowed if it resolves to a file inside the project--otherwise a link could be used to write anywhere the user can.
+func checkSymlinkPolicy(links []*symlinkedFile, policy symlinkPolicy) error {
+	if len(links) == 0 || policy == symlinkPolicyReplace {
+		return nil
+	}
+
+	if policy == symlinkPolicyRefuse {
+		var paths []string
+		for _, link := range links {
+			paths = append(paths, link.path)
+		}
+		return fmt.Errorf("these files are symlinks: %s. Set PLANDEX_SYMLINK_POLICY to 'follow' or 'replace' to apply changes to them", strings.Join(paths, ", "))
+	}
+
+	root, err := filepath.EvalSymlinks(fs.ProjectRoot)
+	if err != nil {
+		return fmt.Errorf("failed to resolve the project root: %v", err)
+	}
+
+	for _, link := range links {
+		if link.resolved == "" {
+			return fmt.Errorf("%s is a symlink to %s, which doesn't exist. Set PLANDEX_SYMLINK_POLICY=replace to replace the link with a regular file", link.path, link.target)
+		}
+
+		rel, err := filepath.Rel(root, link.resolved)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("%s is a symlink to %s, which is outside the project. Set PLANDEX_SYMLINK_POLICY=replace to replace the link with a regular file", link.path, link.target)
+		}
+	}
+
+	return nil
+}
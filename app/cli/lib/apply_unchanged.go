@@ -0,0 +1,37 @@
+package lib
+
+import (
+	"os"
+	"plandex/fs"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// GetUnchangedPaths returns the plan files whose pending content already matches the file on disk, ignoring line endings. Writing them would change nothing but their mtime, which is enough to set off file watchers and rebuilds.
+func GetUnchangedPaths(currentPlanState *shared.CurrentPlanState) map[string]bool {
+	unchanged := map[string]bool{}
+
+	for path, content := range currentPlanState.CurrentPlanFiles.Files {
+		if currentPlanState.CurrentPlanFiles.Removed[path] {
+			continue
+		}
+
+		bytes, err := os.ReadFile(fs.ProjectFilePath(path))
+		if err != nil {
+			continue
+		}
+
+		current, _, err := decodeFileContent(path, bytes)
+		if err != nil {
+			continue
+		}
+
+		content = strings.ReplaceAll(content, "\\`\\`\\`", "```")
+		if matchLineEndings(current, content) == current {
+			unchanged[path] = true
+		}
+	}
+
+	return unchanged
+}
@@ -0,0 +1,160 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"plandex/fs"
+	"plandex/term"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/plandex/plandex/shared"
+)
+
+const (
+	uncommittedOptionStash   = "Stash your changes to these files, then apply"
+	uncommittedOptionRebuild = "Update context with your changes and rebuild the plan"
+	uncommittedOptionForce   = "Apply anyway"
+)
+
+type uncommittedFile struct {
+	path     string
+	staged   bool
+	unstaged bool
+}
+
+// getUncommittedFiles returns the paths with staged or unstaged changes, according to git status
+func getUncommittedFiles(paths []string) ([]*uncommittedFile, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	gitMutex.Lock()
+	defer gitMutex.Unlock()
+
+	// status paths are relative to the repo root, which may be above the project root
+	prefix, err := exec.Command("git", "-C", fs.ProjectRoot, "rev-parse", "--show-prefix").Output()
+	if err != nil {
+		return nil, fmt.Errorf("error getting git prefix: %v", err)
+	}
+
+	args := append([]string{"-C", fs.ProjectRoot, "status", "--porcelain=v1", "-z", "--"}, paths...)
+	res, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("error getting git status: %v", err)
+	}
+
+	var files []*uncommittedFile
+	entries := strings.Split(string(res), "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+
+		x, y := entry[0], entry[1]
+		files = append(files, &uncommittedFile{
+			path:     filepath.FromSlash(strings.TrimPrefix(entry[3:], strings.TrimSpace(string(prefix)))),
+			staged:   x != ' ' && x != '?',
+			unstaged: y != ' ',
+		})
+
+		// renames and copies are followed by the original path
+		if x == 'R' || x == 'C' {
+			i++
+		}
+	}
+
+	return files, nil
+}
+
+// checkUncommittedChanges warns about target files with uncommitted changes that the plan was built without. Changes the plan's context already includes are fine, but staged changes are always flagged since committing the plan's changes would sweep them into the same commit. Returns true if the plan needs to be rebuilt before applying.
+func checkUncommittedChanges(currentPlanState *shared.CurrentPlanState, paths []string) (rebuild bool) {
+	uncommitted, err := getUncommittedFiles(paths)
+	if err != nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("failed to check for uncommitted changes: %v", err)
+	}
+
+	var unseen []*uncommittedFile
+	for _, file := range uncommitted {
+		context := currentPlanState.ContextsByPath[file.path]
+
+		seen := false
+		if context != nil {
+			bytes, err := os.ReadFile(fs.ProjectFilePath(file.path))
+			if err == nil {
+				body, _, err := decodeFileContent(file.path, bytes)
+				seen = err == nil && body == context.Body
+			}
+		}
+
+		if !seen || file.staged {
+			unseen = append(unseen, file)
+		}
+	}
+
+	if len(unseen) == 0 {
+		return false
+	}
+
+	term.StopSpinner()
+
+	color.New(color.Bold, term.ColorHiYellow).Println("⚠️  These files have uncommitted changes that the plan wasn't built with:")
+	var stashPaths []string
+	for _, file := range unseen {
+		var kinds []string
+		if file.staged {
+			kinds = append(kinds, "staged")
+		}
+		if file.unstaged {
+			kinds = append(kinds, "unstaged")
+		}
+		fmt.Printf("• %s (%s)\n", file.path, strings.Join(kinds, ", "))
+		stashPaths = append(stashPaths, file.path)
+	}
+	fmt.Println()
+
+	selection, err := term.SelectFromList("How do you want to proceed?", []string{
+		uncommittedOptionStash,
+		uncommittedOptionRebuild,
+		uncommittedOptionForce,
+	})
+	if err != nil {
+		if err.Error() == "interrupt" {
+			fmt.Println("Apply plan canceled")
+			term.Exit(0)
+		}
+		term.OutputErrorAndExit("failed to get user input: %v", err)
+	}
+
+	switch selection {
+	case uncommittedOptionStash:
+		err := gitStashPaths("plandex: changes stashed before applying plan", stashPaths)
+		if err != nil {
+			term.OutputErrorAndExit("failed to stash changes: %v", err)
+		}
+		fmt.Println("📦 Stashed your changes. Restore them after applying with 'git stash pop'")
+		fmt.Println()
+	case uncommittedOptionRebuild:
+		return true
+	}
+
+	term.ResumeSpinner()
+	return false
+}
+
+func gitStashPaths(message string, paths []string) error {
+	gitMutex.Lock()
+	defer gitMutex.Unlock()
+
+	args := append([]string{"-C", fs.ProjectRoot, "stash", "push", "--include-untracked", "-m", message, "--"}, paths...)
+	res, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("error creating git stash: %v, output: %s", err, string(res))
+	}
+
+	return nil
+}
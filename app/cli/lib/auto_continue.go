@@ -0,0 +1,216 @@
+package lib
+
+import (
+	"fmt"
+	"plandex/api"
+	"plandex/term"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/plandex/plandex/shared"
+)
+
+// replies in an --auto-continue run when no limit is set
+const DefaultMaxAutoSteps = 10
+
+type autoStepResult struct {
+	desc     *shared.ConvoMessageDescription
+	applied  bool
+	verified bool
+}
+
+// MustRunAutoContinue sends prompt, then takes the plan one reply at a time. Each step's changes are applied and verified with the project's verification command before the plan is continued. It stops when the plan is finished, a step fails verification or can't be applied, changes to protected paths aren't confirmed, or the run reaches one of its limits, then summarizes the steps. Changes are applied without committing, so they can be reviewed together at the end.
+func MustRunAutoContinue(planId, branch, prompt string, limits shared.AutoLimits) {
+	settings, err := LoadProjectSettings()
+	if err != nil {
+		term.OutputErrorAndExit("Error loading project settings: %v", err)
+	}
+
+	// each step applies and verifies with the project's commands, so it's checked before the run rather than failing partway through
+	MustCheckProjectCommandsApproved(settings)
+
+	startAutoRun(limits, getServerTime(mustGetStepState(planId, branch)))
+	defer finishAutoRun()
+
+	fmt.Println(FormatAutoLimits(limits))
+
+	var steps []*autoStepResult
+	var stopReason string
+
+	for step := 1; ; step++ {
+		req := shared.TellPlanRequest{Prompt: prompt}
+		if step > 1 {
+			// the last step's changes were written to the project, so the plan needs to see them before continuing
+			mustUpdateOutdatedContextQuietly()
+			req = shared.TellPlanRequest{IsUserContinue: true}
+		}
+
+		fmt.Println()
+		color.New(color.Bold, term.ColorHiCyan).Printf("🤖 Step %d/%d\n", step, limits.MaxSteps)
+
+		startedAt := getServerTime(mustGetStepState(planId, branch))
+		err := tellAndWaitForBuild(planId, branch, req, "💬 Waiting for the reply and its changes...")
+		if limitErr := getAutoLimitError(err); limitErr != nil {
+			// the server refused the step, so there's nothing to summarize for it
+			stopReason = limitErr.Reason
+			break
+		} else if err != nil {
+			term.OutputErrorAndExit("Error running step %d: %v", step, err)
+		}
+
+		state := mustGetStepState(planId, branch)
+		result := &autoStepResult{desc: getStepDescription(state, startedAt)}
+		steps = append(steps, result)
+
+		if result.desc == nil {
+			stopReason = "the step didn't finish with a reply"
+			break
+		}
+
+		if result.desc.CommitMsg != "" {
+			fmt.Println(result.desc.CommitMsg)
+		}
+
+		if result.desc.MadePlan {
+			if !mustConfirmProtectedPaths(result.desc) {
+				stopReason = "changes to protected paths weren't confirmed"
+				break
+			}
+
+			result.applied = MustApplyPlan(planId, branch, ApplyFlags{AutoConfirm: true, NoCommit: true})
+			if !result.applied {
+				stopReason = "the step's changes couldn't be applied"
+				break
+			}
+
+			if settings.VerifyCmd != "" {
+				maxFixes := DefaultMaxVerifyFixes
+				if settings.MaxVerifyFixes != nil {
+					maxFixes = *settings.MaxVerifyFixes
+				}
+				result.verified = MustRunVerifyLoop(planId, branch, settings.VerifyCmd, maxFixes, ApplyFlags{AutoConfirm: true, NoCommit: true})
+				if !result.verified {
+					stopReason = "verification failed"
+					break
+				}
+
+				// fixes for verification failures are replies too, so they count toward the limits
+				state = mustGetStepState(planId, branch)
+			}
+		}
+
+		if result.desc.PlanFinished {
+			stopReason = "the plan is finished"
+			break
+		}
+
+		if exceeded := checkAutoLimits(state.ConvoMessageDescriptions); exceeded != nil {
+			stopReason = exceeded.Reason
+			break
+		}
+	}
+
+	printAutoContinueSummary(steps, stopReason, settings.VerifyCmd != "")
+}
+
+func mustGetStepState(planId, branch string) *shared.CurrentPlanState {
+	state, apiErr := api.Client.GetCurrentPlanState(planId, branch)
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting current plan state: %v", apiErr.Msg)
+	}
+	return state
+}
+
+// getServerTime returns the time the server loaded a plan state, falling back to the local clock for servers that don't send it
+func getServerTime(state *shared.CurrentPlanState) time.Time {
+	if state.ServerTime.IsZero() {
+		return time.Now()
+	}
+	return state.ServerTime
+}
+
+// getStepDescription returns the description of the latest reply made since startedAt
+func getStepDescription(state *shared.CurrentPlanState, startedAt time.Time) *shared.ConvoMessageDescription {
+	var latest *shared.ConvoMessageDescription
+	for _, desc := range state.ConvoMessageDescriptions {
+		if desc.CreatedAt.Before(startedAt) {
+			continue
+		}
+		if latest == nil || desc.CreatedAt.After(latest.CreatedAt) {
+			latest = desc
+		}
+	}
+	return latest
+}
+
+func mustUpdateOutdatedContextQuietly() {
+	outdatedRes, err := CheckOutdatedContext(nil)
+	if err != nil {
+		term.OutputErrorAndExit("Error checking context: %v", err)
+	}
+	if len(outdatedRes.UpdatedContexts) > 0 {
+		MustUpdateContext(nil)
+	}
+}
+
+func printAutoContinueSummary(steps []*autoStepResult, stopReason string, hasVerifyCmd bool) {
+	suffix := "s"
+	if len(steps) == 1 {
+		suffix = ""
+	}
+
+	fmt.Println()
+	color.New(color.Bold, term.ColorHiCyan).Printf("🤖 Stopped after %d step%s: %s\n", len(steps), suffix, stopReason)
+	fmt.Println()
+
+	var files []string
+	seen := map[string]bool{}
+
+	for i, step := range steps {
+		var desc string
+		switch {
+		case step.desc == nil:
+			desc = "no reply"
+		case !step.desc.MadePlan:
+			desc = "no changes"
+		case step.desc.CommitMsg != "":
+			desc = strings.Split(step.desc.CommitMsg, "\n")[0]
+		default:
+			desc = "changes"
+		}
+
+		var status string
+		if step.desc != nil && step.desc.MadePlan {
+			if !step.applied {
+				status = " | not applied"
+			} else if hasVerifyCmd && step.verified {
+				status = " | applied and verified"
+			} else if hasVerifyCmd {
+				status = " | applied, verification failed"
+			} else {
+				status = " | applied"
+			}
+
+			for _, path := range step.desc.Files {
+				if !seen[path] {
+					seen[path] = true
+					files = append(files, path)
+				}
+			}
+		}
+
+		fmt.Printf("%d. %s%s\n", i+1, desc, status)
+	}
+
+	if len(files) > 0 {
+		fmt.Println()
+		fmt.Println("Files changed:")
+		for _, path := range files {
+			fmt.Println("  " + path)
+		}
+	}
+
+	fmt.Println()
+	term.PrintCmds("", "log", "undo", "continue")
+}
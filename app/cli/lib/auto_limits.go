@@ -0,0 +1,133 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"plandex/term"
+	"strings"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// autoRunState tracks the automated run in progress, if any. Every request made while it's set carries the run, so the server checks the same limits.
+type autoRunState struct {
+	run   shared.AutoRun
+	usage shared.UsageTotal
+}
+
+var currentAutoRun *autoRunState
+
+// GetAutoLimits starts from the project's "autoLimits" setting, and any override above zero replaces the setting's value
+func GetAutoLimits(overrides shared.AutoLimits) (shared.AutoLimits, error) {
+	var limits shared.AutoLimits
+
+	settings, err := LoadProjectSettings()
+	if err != nil {
+		return limits, err
+	}
+	if settings.AutoLimits != nil {
+		limits = *settings.AutoLimits
+	}
+
+	if overrides.MaxSteps > 0 {
+		limits.MaxSteps = overrides.MaxSteps
+	}
+	if overrides.MaxTokens > 0 {
+		limits.MaxTokens = overrides.MaxTokens
+	}
+	if overrides.MaxCost > 0 {
+		limits.MaxCost = overrides.MaxCost
+	}
+	if overrides.MaxFiles > 0 {
+		limits.MaxFiles = overrides.MaxFiles
+	}
+	limits.ProtectedPaths = append(limits.ProtectedPaths, overrides.ProtectedPaths...)
+
+	if limits.MaxSteps == 0 {
+		limits.MaxSteps = DefaultMaxAutoSteps
+	}
+
+	return limits, nil
+}
+
+// startedAt comes from the server's clock, since the server's timestamps decide which replies and usage count toward the run
+func startAutoRun(limits shared.AutoLimits, startedAt time.Time) {
+	currentAutoRun = &autoRunState{
+		run: shared.AutoRun{Limits: limits, StartedAt: startedAt},
+	}
+}
+
+func finishAutoRun() {
+	currentAutoRun = nil
+}
+
+// checkAutoLimits returns the first limit the current run has reached, counting the replies and files in the plan's descriptions along with the usage streamed since the run started
+func checkAutoLimits(descs []*shared.ConvoMessageDescription) *shared.AutoLimitExceededError {
+	if currentAutoRun == nil {
+		return nil
+	}
+
+	progress := shared.GetAutoRunProgress(descs, currentAutoRun.run.StartedAt)
+	progress.Usage = currentAutoRun.usage
+
+	return currentAutoRun.run.Limits.Exceeded(progress)
+}
+
+// mustConfirmProtectedPaths asks before a step's changes to protected paths are applied. It returns false if the user declines.
+func mustConfirmProtectedPaths(desc *shared.ConvoMessageDescription) bool {
+	if currentAutoRun == nil {
+		return true
+	}
+
+	paths := append(append([]string{}, desc.Files...), desc.RemovedFiles...)
+	protected := currentAutoRun.run.Limits.GetProtectedPaths(paths)
+	if len(protected) == 0 {
+		return true
+	}
+
+	fmt.Println()
+	fmt.Println("🔒 This step changes protected paths:")
+	for _, path := range protected {
+		fmt.Println("  " + path)
+	}
+	fmt.Println()
+
+	confirmed, err := term.ConfirmYesNo("Apply these changes and keep going?")
+	if err != nil {
+		term.OutputErrorAndExit("Error getting confirmation user input: %v", err)
+	}
+	return confirmed
+}
+
+// getAutoLimitError returns the limit the server reported for a failed request, if that's why it failed
+func getAutoLimitError(err error) *shared.AutoLimitExceededError {
+	var limitErr *shared.AutoLimitExceededError
+	if errors.As(err, &limitErr) {
+		return limitErr
+	}
+	return nil
+}
+
+// FormatAutoLimits describes the limits that are set, for the start of a run
+func FormatAutoLimits(limits shared.AutoLimits) string {
+	var parts []string
+	if limits.MaxSteps > 0 {
+		parts = append(parts, fmt.Sprintf("%d replies", limits.MaxSteps))
+	}
+	if limits.MaxTokens > 0 {
+		parts = append(parts, fmt.Sprintf("%d tokens", limits.MaxTokens))
+	}
+	if limits.MaxCost > 0 {
+		parts = append(parts, fmt.Sprintf("$%.2f", limits.MaxCost))
+	}
+	if limits.MaxFiles > 0 {
+		parts = append(parts, fmt.Sprintf("%d files", limits.MaxFiles))
+	}
+
+	res := "Limits: " + strings.Join(parts, ", ")
+	if len(limits.ProtectedPaths) > 0 {
+		res += " | Protected: " + strings.Join(limits.ProtectedPaths, ", ")
+	}
+	return res
+}
@@ -0,0 +1,240 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"plandex/fs"
+	"plandex/types"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// older backups are pruned once a plan has more than this many
+const maxApplyBackups = 10
+
+// backups are kept in the home cache dir rather than the project's .plandex dir, so they're never caught up in a stash of the project's files, committed, or copied into a sandbox
+func getBackupsDir(planId string) string {
+	return filepath.Join(fs.CacheDir, "backups", planId)
+}
+
+// createApplyBackup copies the original contents of every path an apply is about to touch into a new backup directory. Paths that don't exist yet are recorded so undo can remove them.
+func createApplyBackup(planId, branch string, paths []string) (*types.ApplyBackup, error) {
+	backup := &types.ApplyBackup{
+		Id:        strconv.FormatInt(time.Now().UnixNano(), 10),
+		PlanId:    planId,
+		Branch:    branch,
+		CreatedAt: time.Now(),
+	}
+
+	dir := filepath.Join(getBackupsDir(planId), backup.Id)
+
+	err := os.MkdirAll(filepath.Join(dir, "files"), 0755)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %v", err)
+	}
+
+	for _, path := range paths {
+		srcPath := fs.ProjectFilePath(path)
+		file := &types.ApplyBackupFile{Path: path}
+
+		linfo, err := os.Lstat(srcPath)
+		if err == nil && linfo.Mode()&os.ModeSymlink != 0 {
+			file.LinkTarget, err = os.Readlink(srcPath)
+			if err != nil {
+				os.RemoveAll(dir)
+				return nil, fmt.Errorf("failed to read the symlink %s: %v", srcPath, err)
+			}
+		}
+
+		info, err := os.Stat(srcPath)
+		if err != nil {
+			if os.IsNotExist(err) && file.LinkTarget != "" {
+				// a dangling link has no content to back up, but the link itself still needs restoring
+				file.Existed = true
+				backup.Files = append(backup.Files, file)
+				continue
+			}
+			if os.IsNotExist(err) {
+				backup.Files = append(backup.Files, file)
+				continue
+			}
+			os.RemoveAll(dir)
+			return nil, fmt.Errorf("failed to check if %s exists: %v", srcPath, err)
+		}
+
+		bytes, err := os.ReadFile(srcPath)
+		if err != nil {
+			os.RemoveAll(dir)
+			return nil, fmt.Errorf("failed to read %s: %v", srcPath, err)
+		}
+
+		dstPath := filepath.Join(dir, "files", path)
+		err = os.MkdirAll(filepath.Dir(dstPath), 0755)
+		if err != nil {
+			os.RemoveAll(dir)
+			return nil, fmt.Errorf("failed to create backup directory: %v", err)
+		}
+
+		err = os.WriteFile(dstPath, bytes, 0644)
+		if err != nil {
+			os.RemoveAll(dir)
+			return nil, fmt.Errorf("failed to back up %s: %v", srcPath, err)
+		}
+
+		file.Existed = true
+		file.Mode = info.Mode().Perm()
+		backup.Files = append(backup.Files, file)
+	}
+
+	bytes, err := json.MarshalIndent(backup, "", "  ")
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to marshal backup manifest: %v", err)
+	}
+
+	err = os.WriteFile(filepath.Join(dir, "backup.json"), bytes, 0644)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to write backup manifest: %v", err)
+	}
+
+	pruneApplyBackups(planId)
+
+	return backup, nil
+}
+
+func discardApplyBackup(backup *types.ApplyBackup) {
+	os.RemoveAll(filepath.Join(getBackupsDir(backup.PlanId), backup.Id))
+}
+
+func listApplyBackupIds(planId string) ([]string, error) {
+	entries, err := os.ReadDir(getBackupsDir(planId))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read backups directory: %v", err)
+	}
+
+	var ids []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			ids = append(ids, entry.Name())
+		}
+	}
+
+	// ids are nanosecond timestamps, so sort numerically, newest first
+	sort.Slice(ids, func(i, j int) bool {
+		a, _ := strconv.ParseInt(ids[i], 10, 64)
+		b, _ := strconv.ParseInt(ids[j], 10, 64)
+		return a > b
+	})
+
+	return ids, nil
+}
+
+func pruneApplyBackups(planId string) {
+	ids, err := listApplyBackupIds(planId)
+	if err != nil {
+		return
+	}
+
+	for i, id := range ids {
+		if i >= maxApplyBackups {
+			os.RemoveAll(filepath.Join(getBackupsDir(planId), id))
+		}
+	}
+}
+
+// GetLastApplyBackup returns the most recent backup for the plan, or nil if there isn't one
+func GetLastApplyBackup(planId string) (*types.ApplyBackup, error) {
+	ids, err := listApplyBackupIds(planId)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	bytes, err := os.ReadFile(filepath.Join(getBackupsDir(planId), ids[0], "backup.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup manifest: %v", err)
+	}
+
+	var backup types.ApplyBackup
+	err = json.Unmarshal(bytes, &backup)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal backup manifest: %v", err)
+	}
+
+	return &backup, nil
+}
+
+// RestoreApplyBackup puts every file touched by an apply back the way it was: overwritten and deleted files are restored and newly created files are removed. The backup is removed once it's fully restored.
+func RestoreApplyBackup(backup *types.ApplyBackup) error {
+	dir := filepath.Join(getBackupsDir(backup.PlanId), backup.Id)
+
+	for _, file := range backup.Files {
+		dstPath := fs.ProjectFilePath(file.Path)
+
+		if !file.Existed {
+			err := os.Remove(dstPath)
+			if err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove %s: %v", dstPath, err)
+			}
+			continue
+		}
+
+		if file.LinkTarget != "" {
+			err := os.MkdirAll(filepath.Dir(dstPath), 0755)
+			if err != nil {
+				return fmt.Errorf("failed to create directory %s: %v", filepath.Dir(dstPath), err)
+			}
+
+			err = restoreSymlink(dstPath, file.LinkTarget)
+			if err != nil {
+				return fmt.Errorf("failed to restore the symlink %s: %v", dstPath, err)
+			}
+
+			// the target's content is restored below, through the link
+			resolved, err := filepath.EvalSymlinks(dstPath)
+			if err != nil {
+				// dangling, so there's no content to restore
+				continue
+			}
+			dstPath = resolved
+		}
+
+		bytes, err := os.ReadFile(filepath.Join(dir, "files", file.Path))
+		if err != nil {
+			return fmt.Errorf("failed to read backup of %s: %v", file.Path, err)
+		}
+
+		err = os.MkdirAll(filepath.Dir(dstPath), 0755)
+		if err != nil {
+			return fmt.Errorf("failed to create directory %s: %v", filepath.Dir(dstPath), err)
+		}
+
+		tmpPath, err := writeTempFile(filepath.Dir(dstPath), bytes, file.Mode)
+		if err != nil {
+			return fmt.Errorf("failed to restore %s: %v", dstPath, err)
+		}
+
+		err = os.Rename(tmpPath, dstPath)
+		if err != nil {
+			os.Remove(tmpPath)
+			return fmt.Errorf("failed to restore %s: %v", dstPath, err)
+		}
+	}
+
+	err := os.RemoveAll(dir)
+	if err != nil {
+		return fmt.Errorf("failed to remove backup: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package lib
+
+import (
+	"os"
+	"path/filepath"
+	"plandex/fs"
+	"strings"
+	"testing"
+)
+
+func TestApplyBackupOutsideProject(t *testing.T) {
+	origProjectRoot, origPlandexDir, origCacheDir := fs.ProjectRoot, fs.PlandexDir, fs.CacheDir
+	fs.ProjectRoot = t.TempDir()
+	fs.PlandexDir = filepath.Join(fs.ProjectRoot, ".plandex")
+	fs.CacheDir = t.TempDir()
+	defer func() { fs.ProjectRoot, fs.PlandexDir, fs.CacheDir = origProjectRoot, origPlandexDir, origCacheDir }()
+
+	err := os.WriteFile(filepath.Join(fs.ProjectRoot, "main.go"), []byte("package main\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	backup, err := createApplyBackup("plan-1", "main", []string{"main.go", "new.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := filepath.Join(getBackupsDir("plan-1"), backup.Id)
+	if !strings.HasPrefix(dir, fs.CacheDir) {
+		t.Errorf("expected the backup in the cache dir, got %s", dir)
+	}
+	if _, err := os.Stat(fs.PlandexDir); !os.IsNotExist(err) {
+		t.Errorf("expected nothing to be written in the project's .plandex dir, got %v", err)
+	}
+
+	// the apply writes its changes
+	os.WriteFile(filepath.Join(fs.ProjectRoot, "main.go"), []byte("package main\n\n// changed\n"), 0644)
+	os.WriteFile(filepath.Join(fs.ProjectRoot, "new.go"), []byte("package main\n"), 0644)
+
+	last, err := GetLastApplyBackup("plan-1")
+	if err != nil || last == nil || last.Id != backup.Id {
+		t.Fatalf("expected the backup to be found for undo, got %v, %v", last, err)
+	}
+
+	err = RestoreApplyBackup(last)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bytes, _ := os.ReadFile(filepath.Join(fs.ProjectRoot, "main.go"))
+	if string(bytes) != "package main\n" {
+		t.Errorf("expected main.go to be restored, got %q", bytes)
+	}
+	if _, err := os.Stat(filepath.Join(fs.ProjectRoot, "new.go")); !os.IsNotExist(err) {
+		t.Errorf("expected the new file to be removed, got %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("expected the backup to be removed once restored, got %v", err)
+	}
+}
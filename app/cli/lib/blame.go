@@ -0,0 +1,104 @@
+package lib
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"plandex/fs"
+	"plandex/types"
+	"strconv"
+	"strings"
+)
+
+type BlameLine struct {
+	Num     int
+	Sha     string
+	Content string
+}
+
+// GitBlameLines returns the commit that last changed each line in the range, which is 1-indexed and inclusive. An end of 0 means the end of the file.
+func GitBlameLines(path string, start, end int) ([]*BlameLine, error) {
+	lineRange := fmt.Sprintf("%d,", start)
+	if end > 0 {
+		lineRange += strconv.Itoa(end)
+	}
+
+	res, err := exec.Command("git", "-C", fs.ProjectRoot, "blame", "--porcelain", "-L", lineRange, "--", path).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("error running git blame: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("error running git blame: %v", err)
+	}
+
+	var lines []*BlameLine
+	var current *BlameLine
+
+	scanner := bufio.NewScanner(bytes.NewReader(res))
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// each line's content comes last, prefixed with a tab
+		if strings.HasPrefix(line, "\t") {
+			if current != nil {
+				current.Content = line[1:]
+				lines = append(lines, current)
+				current = nil
+			}
+			continue
+		}
+
+		// the header for each line is '<sha> <original line> <final line> [<lines in group>]'
+		fields := strings.Fields(line)
+		if current == nil && len(fields) >= 3 && len(fields[0]) == 40 {
+			num, err := strconv.Atoi(fields[2])
+			if err != nil {
+				continue
+			}
+			current = &BlameLine{Num: num, Sha: fields[0]}
+		}
+	}
+
+	return lines, nil
+}
+
+type PlanAppliedCommit struct {
+	PlanId string
+	Commit *types.AppliedCommit
+}
+
+// GetProjectAppliedCommits returns the commits made by applying any of the project's plans, by sha
+func GetProjectAppliedCommits() (map[string]*PlanAppliedCommit, error) {
+	dir := filepath.Join(fs.HomePlandexDir, CurrentProjectId)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]*PlanAppliedCommit{}, nil
+		}
+		return nil, fmt.Errorf("error reading project dir: %v", err)
+	}
+
+	res := map[string]*PlanAppliedCommit{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		planId := entry.Name()
+		info, err := LoadPlanInfo(planId)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, commit := range info.AppliedCommits {
+			res[commit.Sha] = &PlanAppliedCommit{PlanId: planId, Commit: commit}
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,46 @@
+package lib
+
+import (
+	"errors"
+	"os/exec"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// PendingCommand is a suggested command that hasn't been run yet, along with the reply that suggested it
+type PendingCommand struct {
+	ConvoMessageId string
+	Index          int
+	*shared.PlanCommand
+}
+
+// GetPendingCommands returns the commands suggested by the plan's replies that haven't been run, oldest reply first
+func GetPendingCommands(descs []*shared.ConvoMessageDescription) []*PendingCommand {
+	var pending []*PendingCommand
+	for _, desc := range descs {
+		for i, command := range desc.Commands {
+			if command.RanAt != nil {
+				continue
+			}
+			pending = append(pending, &PendingCommand{
+				ConvoMessageId: desc.ConvoMessageId,
+				Index:          i,
+				PlanCommand:    command,
+			})
+		}
+	}
+	return pending
+}
+
+// RunPlanCommand runs a suggested command from the project root, streaming its output to the terminal while capturing it. A non-zero exit isn't an error; it's returned as the exit code so the output can still be sent to the plan.
+func RunPlanCommand(command string) (string, int, error) {
+	output, err := runShellCmd(command)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return output, exitErr.ExitCode(), nil
+		}
+		return output, 0, err
+	}
+	return output, 0, nil
+}
@@ -0,0 +1,113 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"plandex/api"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+)
+
+type CompareParams struct {
+	Prompt        string
+	ModelName     string
+	Branch        string
+	ProjectPaths  map[string]bool
+	CompareBuilds bool
+	BaseModelSet  shared.ModelSet
+}
+
+type CompareResult struct {
+	ModelName        string
+	Branch           string
+	Latency          time.Duration
+	TimeToFirstChunk time.Duration
+	Usage            shared.ModelUsage
+	Files            map[string]string
+	Err              error
+}
+
+// RunComparison sends the prompt on a new branch that uses the given model and waits for the replies and builds to finish. The branch is left in place so the result can be checked out.
+func RunComparison(params CompareParams) *CompareResult {
+	res := &CompareResult{
+		ModelName: params.ModelName,
+		Branch:    params.Branch,
+	}
+
+	modelConfig, ok := shared.AvailableModelsByName[params.ModelName]
+	if !ok {
+		res.Err = fmt.Errorf("unknown model %s", params.ModelName)
+		return res
+	}
+
+	apiErr := api.Client.CreateBranch(CurrentPlanId, CurrentBranch, shared.CreateBranchRequest{Name: params.Branch})
+	if apiErr != nil {
+		res.Err = fmt.Errorf("error creating branch %s: %v", params.Branch, apiErr.Msg)
+		return res
+	}
+
+	modelSet := params.BaseModelSet
+	modelSet.Planner.BaseModelConfig = modelConfig
+	modelSet.Planner.PlannerModelConfig = shared.PlannerModelConfigByName[params.ModelName]
+	if params.CompareBuilds {
+		modelSet.Builder.BaseModelConfig = modelConfig
+		modelSet.Builder.TaskModelConfig = shared.TaskModelConfigByName[params.ModelName]
+	}
+
+	settings, apiErr := api.Client.GetSettings(CurrentPlanId, params.Branch)
+	if apiErr != nil {
+		res.Err = fmt.Errorf("error getting settings: %v", apiErr.Msg)
+		return res
+	}
+	settings.ModelSet = &modelSet
+
+	_, apiErr = api.Client.UpdateSettings(CurrentPlanId, params.Branch, shared.UpdateSettingsRequest{Settings: settings})
+	if apiErr != nil {
+		res.Err = fmt.Errorf("error updating settings: %v", apiErr.Msg)
+		return res
+	}
+
+	startedAt := time.Now()
+
+	err := tellAndWait(CurrentPlanId, params.Branch, shared.TellPlanRequest{
+		Prompt:        params.Prompt,
+		ConnectStream: true,
+		AutoContinue:  true,
+		ProjectPaths:  params.ProjectPaths,
+		BuildMode:     shared.BuildModeAuto,
+		ApiKey:        os.Getenv("OPENAI_API_KEY"),
+		Mock:          GetMockConfig(),
+	}, func(msg *shared.StreamMessage) {
+		switch msg.Type {
+		case shared.StreamMessageReply:
+			if res.TimeToFirstChunk == 0 {
+				res.TimeToFirstChunk = time.Since(startedAt)
+			}
+		case shared.StreamMessageUsage:
+			if msg.Usage != nil {
+				res.Usage.PromptTokens += msg.Usage.PromptTokens
+				res.Usage.CompletionTokens += msg.Usage.CompletionTokens
+			}
+		}
+	})
+	res.Latency = time.Since(startedAt)
+
+	if err != nil {
+		res.Err = err
+		return res
+	}
+
+	planState, apiErr := api.Client.GetCurrentPlanState(CurrentPlanId, params.Branch)
+	if apiErr != nil {
+		res.Err = fmt.Errorf("error getting plan state: %v", apiErr.Msg)
+		return res
+	}
+
+	res.Files = map[string]string{}
+	if planState.CurrentPlanFiles != nil {
+		res.Files = planState.CurrentPlanFiles.Files
+	}
+
+	return res
+}
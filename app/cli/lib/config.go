@@ -0,0 +1,123 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"plandex/api"
+	"plandex/fs"
+	"plandex/term"
+	"plandex/types"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func LoadClientConfig() (*types.ClientConfig, error) {
+	bytes, err := os.ReadFile(fs.HomeConfigPath)
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &types.ClientConfig{}, nil
+		}
+		return nil, fmt.Errorf("error reading config.json: %v", err)
+	}
+
+	var config types.ClientConfig
+	err = json.Unmarshal(bytes, &config)
+
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling config.json: %v", err)
+	}
+
+	return &config, nil
+}
+
+func WriteClientConfig(config *types.ClientConfig) error {
+	bytes, err := json.Marshal(config)
+
+	if err != nil {
+		return fmt.Errorf("error marshalling config: %v", err)
+	}
+
+	// written to a temp file and renamed, so a crash or a concurrent command never leaves a partial config.json
+	err = writeFileAtomic(fs.HomeConfigPath, bytes, 0644)
+
+	if err != nil {
+		return fmt.Errorf("error writing config.json: %v", err)
+	}
+
+	return nil
+}
+
+// MustGetDefaultModelSet returns the globally configured model set, or nil if
+// none is set and the server defaults should be used
+func MustGetDefaultModelSet() *shared.ModelSet {
+	modelSet, err := GetDefaultModelSet()
+
+	if err != nil {
+		term.OutputErrorAndExit("%v", err)
+	}
+
+	return modelSet
+}
+
+func GetDefaultModelSet() (*shared.ModelSet, error) {
+	config, err := LoadClientConfig()
+
+	if err != nil {
+		return nil, fmt.Errorf("error loading config: %v", err)
+	}
+
+	return config.DefaultModelSet, nil
+}
+
+type ModelSetSource string
+
+const (
+	ModelSetSourcePlan    ModelSetSource = "plan settings"
+	ModelSetSourceGlobal  ModelSetSource = "global default"
+	ModelSetSourceBuiltIn ModelSetSource = "built-in default"
+)
+
+// ResolveModelSet returns a copy of the models a plan with planModelSet uses, falling back to the global default model set and then the built-in one like the server does
+func ResolveModelSet(planModelSet *shared.ModelSet) (*shared.ModelSet, ModelSetSource, error) {
+	if planModelSet != nil {
+		modelSet := *planModelSet
+		return &modelSet, ModelSetSourcePlan, nil
+	}
+
+	defaultModelSet, err := GetDefaultModelSet()
+	if err != nil {
+		return nil, "", err
+	}
+	if defaultModelSet != nil {
+		modelSet := *defaultModelSet
+		return &modelSet, ModelSetSourceGlobal, nil
+	}
+
+	modelSet := shared.DefaultModelSet
+	return &modelSet, ModelSetSourceBuiltIn, nil
+}
+
+// GetModelSet returns a copy of the models the plan uses, or the default models if planId is empty
+func GetModelSet(planId, branch string) (*shared.ModelSet, ModelSetSource, error) {
+	var planModelSet *shared.ModelSet
+	if planId != "" {
+		settings, apiErr := api.Client.GetSettings(planId, branch)
+		if apiErr != nil {
+			return nil, "", fmt.Errorf("error getting settings: %v", apiErr.Msg)
+		}
+		planModelSet = settings.ModelSet
+	}
+
+	return ResolveModelSet(planModelSet)
+}
+
+// MustGetPlanModelSet returns a copy of the models the current plan uses, or the default models if there's no current plan
+func MustGetPlanModelSet() *shared.ModelSet {
+	modelSet, _, err := GetModelSet(CurrentPlanId, CurrentBranch)
+	if err != nil {
+		term.OutputErrorAndExit("%v", err)
+	}
+	return modelSet
+}
@@ -0,0 +1,84 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"plandex/fs"
+	"plandex/types"
+	"strings"
+)
+
+const (
+	// the project is copied into the container before the command runs, so it can write files like build output and caches, but nothing it writes reaches the project
+	ContainerMountCopy = "copy"
+	// the project is mounted read-only, which is faster for big projects but fails commands that write to it
+	ContainerMountReadOnly = "readonly"
+)
+
+const defaultContainerRuntime = "docker"
+
+// where the project is mounted and where commands run inside the container
+const containerSrcDir = "/src"
+const containerWorkDir = "/workspace"
+
+// commandForShell returns the command to run a verification, test, lint, or suggested command. If the project has a container set, it runs there, so autonomous loops can't change anything on the host; otherwise it runs through the shell from the project root.
+func commandForShell(cmdLine string) (*exec.Cmd, error) {
+	settings, err := LoadProjectSettings()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		// falling back to the host could run a command the project meant to keep in a container
+		return nil, fmt.Errorf("couldn't check whether commands should run in a container: %v", err)
+	}
+	if settings == nil || settings.Container == nil || settings.Container.Image == "" {
+		return shellCommand(cmdLine), nil
+	}
+
+	return containerCommand(settings.Container, cmdLine)
+}
+
+func containerCommand(container *types.ContainerSettings, cmdLine string) (*exec.Cmd, error) {
+	runtime := container.Runtime
+	if runtime == "" {
+		runtime = defaultContainerRuntime
+	}
+
+	path, err := exec.LookPath(runtime)
+	if err != nil {
+		return nil, fmt.Errorf("the project runs commands in a container, but %s wasn't found. Install it or turn containers off with 'plandex container off'", runtime)
+	}
+
+	args := []string{"run", "--rm", "-i"}
+	if container.NoNetwork {
+		args = append(args, "--network", "none")
+	}
+
+	switch container.Mount {
+	case ContainerMountReadOnly:
+		args = append(args,
+			"--mount", readOnlyBindMount(fs.ProjectRoot, containerWorkDir),
+			"-w", containerWorkDir,
+			container.Image,
+			"sh", "-c", cmdLine,
+		)
+	default:
+		// the command is passed as an argument rather than spliced into the script, so it needs no extra quoting
+		args = append(args,
+			"--mount", readOnlyBindMount(fs.ProjectRoot, containerSrcDir),
+			container.Image,
+			"sh", "-c", fmt.Sprintf(`mkdir -p %s && cp -a %s/. %s && cd %s && exec sh -c "$1"`, containerWorkDir, containerSrcDir, containerWorkDir, containerWorkDir),
+			"sh", cmdLine,
+		)
+	}
+
+	return exec.Command(path, args...), nil
+}
+
+// readOnlyBindMount builds a --mount value, which unlike -v doesn't split the path on ':'. Its fields are comma-separated CSV, so the source is quoted if it has a comma or quote.
+func readOnlyBindMount(source, target string) string {
+	sourceField := "source=" + source
+	if strings.ContainsAny(source, ",\"") {
+		sourceField = `"` + strings.ReplaceAll(sourceField, `"`, `""`) + `"`
+	}
+	return "type=bind," + sourceField + ",target=" + target + ",readonly"
+}
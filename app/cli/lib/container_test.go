@@ -0,0 +1,56 @@
+package lib
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"plandex/fs"
+	"strings"
+	"testing"
+)
+
+func TestReadOnlyBindMount(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"/home/me/project", "type=bind,source=/home/me/project,target=/src,readonly"},
+		{"/mnt/c:/project", "type=bind,source=/mnt/c:/project,target=/src,readonly"},
+		{`/tmp/a,b "c"`, `type=bind,"source=/tmp/a,b ""c""",target=/src,readonly`},
+	}
+
+	for _, tt := range tests {
+		got := readOnlyBindMount(tt.source, "/src")
+		if got != tt.want {
+			t.Errorf("readOnlyBindMount(%q) = %s, want %s", tt.source, got, tt.want)
+		}
+
+		// docker parses --mount as a CSV record
+		fields, err := csv.NewReader(strings.NewReader(got)).Read()
+		if err != nil {
+			t.Fatalf("parsing %s: %v", got, err)
+		}
+		if fields[1] != "source="+tt.source {
+			t.Errorf("expected the source field to parse back to %q, got %q", tt.source, fields[1])
+		}
+	}
+}
+
+func TestCommandForShellFailsClosed(t *testing.T) {
+	origPlandexDir := fs.PlandexDir
+	fs.PlandexDir = t.TempDir()
+	defer func() { fs.PlandexDir = origPlandexDir }()
+
+	// with no project settings, nothing asks for a container
+	if _, err := commandForShell("true"); err != nil {
+		t.Errorf("expected the host shell without project settings, got %v", err)
+	}
+
+	err := os.WriteFile(filepath.Join(fs.PlandexDir, "project.json"), []byte(`{"container":`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmd, err := commandForShell("true"); err == nil {
+		t.Errorf("expected an error for unreadable project settings, got %v", cmd)
+	}
+}
@@ -0,0 +1,50 @@
+package lib
+
+import (
+	"fmt"
+	"os/exec"
+	"plandex/fs"
+	"strings"
+)
+
+// a single commit's diff beyond this is cut off; the message and file list usually say enough about a large commit
+const maxCommitContextDiffChars = 10000
+
+// GetRecentCommitsContext returns the messages, changed files, and diffs of the last n commits on the current git branch, newest first, for loading into context
+func GetRecentCommitsContext(n int) (string, error) {
+	if !fs.ProjectRootIsGitRepo() {
+		return "", fmt.Errorf("the project isn't in a git repository")
+	}
+
+	res, err := exec.Command("git", "-C", fs.ProjectRoot, "log", "-n", fmt.Sprint(n), "--format=%H").Output()
+	if err != nil {
+		return "", fmt.Errorf("error listing recent commits: %v", err)
+	}
+
+	shas := strings.Fields(string(res))
+	if len(shas) == 0 {
+		return "", fmt.Errorf("the git repository has no commits")
+	}
+
+	var commits []string
+	for _, sha := range shas {
+		header, err := exec.Command("git", "-C", fs.ProjectRoot, "show", "--no-color", "--stat", "--format=commit %H%nAuthor: %an%nDate: %ad%n%n%B", sha).Output()
+		if err != nil {
+			return "", fmt.Errorf("error getting commit %s: %v", sha, err)
+		}
+
+		diff, err := exec.Command("git", "-C", fs.ProjectRoot, "show", "--no-color", "--format=", sha).Output()
+		if err != nil {
+			return "", fmt.Errorf("error getting diff for commit %s: %v", sha, err)
+		}
+
+		patch := strings.TrimSpace(string(diff))
+		if len(patch) > maxCommitContextDiffChars {
+			patch = patch[:maxCommitContextDiffChars] + "\n... (diff truncated)"
+		}
+
+		commits = append(commits, strings.TrimSpace(string(header))+"\n\n"+patch)
+	}
+
+	return strings.Join(commits, "\n\n"), nil
+}
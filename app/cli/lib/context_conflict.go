@@ -2,7 +2,6 @@ package lib
 
 import (
 	"fmt"
-	"os"
 	"plandex/api"
 	"plandex/term"
 
@@ -40,7 +39,7 @@ func checkContextConflicts(filesByPath map[string]string) (bool, error) {
 
 		if !res {
 			fmt.Println("Context update canceled")
-			os.Exit(0)
+			term.Exit(0)
 		}
 	}
 
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"plandex/api"
 	"plandex/fs"
 	"plandex/term"
@@ -32,6 +33,19 @@ func MustLoadContext(resources []string, params *types.LoadContextParams) {
 			Body:        params.Note,
 		})
 	}
+	if params.Commits > 0 {
+		body, err := GetRecentCommitsContext(params.Commits)
+		if err != nil {
+			onErr(fmt.Errorf("failed to get recent commits: %v", err))
+		}
+
+		loadContextReq = append(loadContextReq, &shared.LoadContextParams{
+			ContextType: shared.ContextNoteType,
+			Name:        fmt.Sprintf("last-%d-commits", params.Commits),
+			Body:        body,
+		})
+	}
+
 	fileInfo, err := os.Stdin.Stat()
 	if err != nil {
 		onErr(fmt.Errorf("failed to stat stdin: %v", err))
@@ -53,24 +67,34 @@ func MustLoadContext(resources []string, params *types.LoadContextParams) {
 
 	var inputUrls []string
 	var inputFilePaths []string
+	var inputProviderRefs [][2]string
 
 	if len(resources) > 0 {
 		for _, resource := range resources {
-			// so far resources are either files or urls
+			// resources are files, urls, or <provider>:<query> for context provider plugins
 			if url.IsValidURL(resource) {
 				inputUrls = append(inputUrls, resource)
+			} else if provider, query, ok := ParseContextProviderRef(resource); ok {
+				inputProviderRefs = append(inputProviderRefs, [2]string{provider, query})
 			} else {
-				inputFilePaths = append(inputFilePaths, resource)
+				// paths are walked and matched against the project's files in the platform's form, then sent with forward slashes
+				inputFilePaths = append(inputFilePaths, filepath.FromSlash(fs.ContextFilePath(resource)))
 			}
 		}
 	}
 
 	contextCh := make(chan *shared.LoadContextParams)
+	providerCh := make(chan []*shared.LoadContextParams)
 	errCh := make(chan error)
 
 	ignoredPaths := make(map[string]string)
 
 	if len(inputFilePaths) > 0 {
+		inputFilePaths, err = ResolveWorkspaceInputPaths(inputFilePaths)
+		if err != nil {
+			onErr(err)
+		}
+
 		baseDir := fs.GetBaseDirForFilePaths(inputFilePaths)
 
 		paths, err := fs.GetProjectPaths(baseDir)
@@ -133,9 +157,12 @@ func MustLoadContext(resources []string, params *types.LoadContextParams) {
 						flattenedPaths = filteredPaths
 					}
 
+					for i, path := range flattenedPaths {
+						flattenedPaths[i] = filepath.ToSlash(path)
+					}
 					body := strings.Join(flattenedPaths, "\n")
 
-					name := inputFilePath
+					name := filepath.ToSlash(inputFilePath)
 					if name == "." {
 						name = "cwd"
 					}
@@ -147,7 +174,7 @@ func MustLoadContext(resources []string, params *types.LoadContextParams) {
 						ContextType:     shared.ContextDirectoryTreeType,
 						Name:            name,
 						Body:            body,
-						FilePath:        inputFilePath,
+						FilePath:        filepath.ToSlash(inputFilePath),
 						ForceSkipIgnore: params.ForceSkipIgnore,
 					}
 				}(inputFilePath)
@@ -178,18 +205,32 @@ func MustLoadContext(resources []string, params *types.LoadContextParams) {
 			for _, path := range flattenedPaths {
 
 				go func(path string) {
+					fileMode, symlinkTarget, err := fileModeAndSymlinkTarget(path)
+					if err != nil {
+						errCh <- err
+						return
+					}
+
 					fileContent, err := os.ReadFile(path)
 					if err != nil {
 						errCh <- fmt.Errorf("failed to read the file %s: %v", path, err)
 						return
 					}
-					body := string(fileContent)
+
+					body, encoding, err := decodeFileContent(path, fileContent)
+					if err != nil {
+						errCh <- err
+						return
+					}
 
 					contextCh <- &shared.LoadContextParams{
-						ContextType: shared.ContextFileType,
-						Name:        path,
-						Body:        body,
-						FilePath:    path,
+						ContextType:   shared.ContextFileType,
+						Name:          filepath.ToSlash(path),
+						Body:          body,
+						FilePath:      filepath.ToSlash(path),
+						FileMode:      fileMode,
+						Encoding:      encoding,
+						SymlinkTarget: symlinkTarget,
 					}
 				}(path)
 			}
@@ -221,12 +262,39 @@ func MustLoadContext(resources []string, params *types.LoadContextParams) {
 		}
 	}
 
-	for i := 0; i < len(inputFilePaths)+len(inputUrls); i++ {
+	for _, ref := range inputProviderRefs {
+		go func(provider, query string) {
+			contexts, err := RunContextProvider(provider, query)
+			if err != nil {
+				errCh <- err
+				return
+			}
+			providerCh <- contexts
+		}(ref[0], ref[1])
+	}
+
+	var providerContextNames []string
+
+	for i := 0; i < len(inputFilePaths)+len(inputUrls)+len(inputProviderRefs); i++ {
 		select {
 		case err := <-errCh:
 			onErr(err)
 		case context := <-contextCh:
 			loadContextReq = append(loadContextReq, context)
+		case contexts := <-providerCh:
+			loadContextReq = append(loadContextReq, contexts...)
+			for _, context := range contexts {
+				providerContextNames = append(providerContextNames, context.Name)
+			}
+		}
+	}
+
+	// a provider's items replace the ones it loaded before, once the new ones are loaded
+	var replacedIds map[string]bool
+	if len(providerContextNames) > 0 {
+		replacedIds, err = getContextNoteIds(providerContextNames)
+		if err != nil {
+			onErr(err)
 		}
 	}
 
@@ -249,7 +317,7 @@ func MustLoadContext(resources []string, params *types.LoadContextParams) {
 		if len(ignoredPaths) > 0 {
 			printIgnoredMsg()
 		}
-		os.Exit(0)
+		term.Exit(0)
 	}
 
 	res, apiErr := api.Client.LoadContext(CurrentPlanId, CurrentBranch, loadContextReq)
@@ -265,6 +333,13 @@ func MustLoadContext(resources []string, params *types.LoadContextParams) {
 		term.OutputErrorAndExit("Update would add %d 🪙 and exceed token limit (%d) by %d 🪙\n", res.TokensAdded, res.MaxTokens, overage)
 	}
 
+	if len(replacedIds) > 0 {
+		_, apiErr = api.Client.DeleteContext(CurrentPlanId, CurrentBranch, shared.DeleteContextRequest{Ids: replacedIds})
+		if apiErr != nil {
+			term.OutputErrorAndExit("Failed to remove replaced context: %v", apiErr.Msg)
+		}
+	}
+
 	if hasConflicts {
 		term.StartSpinner("🏗️  Starting build...")
 		_, err := buildPlanInlineFn(nil)
@@ -287,3 +362,50 @@ func printIgnoredMsg() {
 	fmt.Println()
 	fmt.Println("ℹ️  " + color.New(color.FgWhite).Sprint("Due to .gitignore or .plandexignore, some paths weren't loaded.\nUse --force / -f to load ignored paths."))
 }
+
+// getContextNoteIds finds the notes with the given names
+func getContextNoteIds(names []string) (map[string]bool, error) {
+	contexts, apiErr := api.Client.ListContext(CurrentPlanId, CurrentBranch)
+	if apiErr != nil {
+		return nil, fmt.Errorf("failed to get context: %v", apiErr.Msg)
+	}
+
+	byName := map[string]bool{}
+	for _, name := range names {
+		byName[name] = true
+	}
+
+	ids := map[string]bool{}
+	for _, context := range contexts {
+		if context.ContextType == shared.ContextNoteType && byName[context.Name] {
+			ids[context.Id] = true
+		}
+	}
+
+	return ids, nil
+}
+
+// HasPipedInput is true if data is being piped to stdin, which is loaded as context
+func HasPipedInput() bool {
+	fileInfo, err := os.Stdin.Stat()
+	return err == nil && fileInfo.Mode()&os.ModeNamedPipe != 0
+}
+
+// fileModeAndSymlinkTarget returns the permissions of the file at path, and the link's target if path is a symlink. The context body is the target's content, so the link is recorded to be recreated on apply.
+func fileModeAndSymlinkTarget(path string) (os.FileMode, string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to stat the file %s: %v", path, err)
+	}
+
+	var symlinkTarget string
+	linfo, err := os.Lstat(path)
+	if err == nil && linfo.Mode()&os.ModeSymlink != 0 {
+		symlinkTarget, err = os.Readlink(path)
+		if err != nil {
+			return 0, "", fmt.Errorf("failed to read the symlink %s: %v", path, err)
+		}
+	}
+
+	return info.Mode().Perm(), symlinkTarget, nil
+}
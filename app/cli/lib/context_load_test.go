@@ -0,0 +1,41 @@
+package lib
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileModeAndSymlinkTarget(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "run.sh")
+	err := os.WriteFile(script, []byte("#!/bin/sh\n"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "link.sh")
+	err = os.Symlink("run.sh", link)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mode, target, err := fileModeAndSymlinkTarget(script)
+	if err != nil || mode != 0755 || target != "" {
+		t.Errorf("expected mode 0755 and no link target, got %v %q %v", mode, target, err)
+	}
+
+	mode, target, err = fileModeAndSymlinkTarget(link)
+	if err != nil || mode != 0755 || target != "run.sh" {
+		t.Errorf("expected the target's mode and the link target, got %v %q %v", mode, target, err)
+	}
+
+	// a mode change with the same content has to show up so the outdated context is refreshed
+	err = os.Chmod(script, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mode, _, _ = fileModeAndSymlinkTarget(link)
+	if mode != 0644 {
+		t.Errorf("expected the updated mode, got %v", mode)
+	}
+}
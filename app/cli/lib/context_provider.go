@@ -0,0 +1,185 @@
+package lib
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"plandex/fs"
+	"plandex/types"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// ContextProviderProtocolVersion is sent with each request, so providers can reject requests in a format they don't know
+const ContextProviderProtocolVersion = 1
+
+const contextProviderTimeout = 2 * time.Minute
+
+// ContextProviderRequest is written to a provider's stdin as a single JSON object, after which stdin is closed
+type ContextProviderRequest struct {
+	Version     int    `json:"version"`
+	Query       string `json:"query"`
+	ProjectRoot string `json:"projectRoot"`
+	PlanId      string `json:"planId,omitempty"`
+	Branch      string `json:"branch,omitempty"`
+}
+
+// ContextProviderItem is loaded into context as a note named <provider>:<name>
+type ContextProviderItem struct {
+	Name string `json:"name"`
+	Body string `json:"body"`
+	// where the item came from, which is noted with it
+	Url string `json:"url,omitempty"`
+}
+
+// ContextProviderResponse is written by a provider to stdout as a single JSON object. A provider that fails sets Error, or exits non-zero with a message on stderr.
+type ContextProviderResponse struct {
+	Items []*ContextProviderItem `json:"items"`
+	Error string                 `json:"error,omitempty"`
+}
+
+const contextProviderExecPrefix = "plandex-context-"
+
+var contextProviderNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]*$`)
+
+// IsValidContextProviderName is false for single letters, which would be taken for Windows drive letters in <provider>:<query>
+func IsValidContextProviderName(name string) bool {
+	return len(name) > 1 && contextProviderNameRegex.MatchString(name)
+}
+
+// ListPathContextProviders finds plandex-context-<name> executables on the PATH, mapping each name to the first one found
+func ListPathContextProviders() map[string]string {
+	return listPathExecutables(contextProviderExecPrefix, IsValidContextProviderName)
+}
+
+// ResolveContextProvider finds a provider configured for the project, or a plandex-context-<name> executable on the PATH
+func ResolveContextProvider(name string) (*types.ContextProviderConfig, error) {
+	settings, err := LoadProjectSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	if config, ok := settings.ContextProviders[name]; ok {
+		return config, nil
+	}
+
+	path, err := exec.LookPath(contextProviderExecPrefix + name)
+	if err != nil {
+		return nil, fmt.Errorf("no context provider named %s. Add one with 'plandex providers add' or put %s%s on your PATH", name, contextProviderExecPrefix, name)
+	}
+
+	return &types.ContextProviderConfig{Command: path}, nil
+}
+
+// ParseContextProviderRef splits a <provider>:<query> resource. ok is false for anything else passed to load, like a file whose name has a colon or a Windows path, or a prefix that isn't a provider.
+func ParseContextProviderRef(resource string) (provider, query string, ok bool) {
+	provider, query, found := strings.Cut(resource, ":")
+	if !found || query == "" || !IsValidContextProviderName(provider) {
+		return "", "", false
+	}
+
+	if _, err := os.Lstat(resource); err == nil {
+		return "", "", false
+	}
+
+	if _, err := ResolveContextProvider(provider); err != nil {
+		return "", "", false
+	}
+
+	return provider, query, true
+}
+
+// contextProviderContextName is the name of the note an item is loaded as. Loading the same item again replaces it.
+func contextProviderContextName(provider, itemName string) string {
+	return provider + ":" + itemName
+}
+
+// RunContextProvider runs a provider with a query and returns its items as notes to load
+func RunContextProvider(provider, query string) ([]*shared.LoadContextParams, error) {
+	config, err := ResolveContextProvider(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	reqBytes, err := json.Marshal(ContextProviderRequest{
+		Version:     ContextProviderProtocolVersion,
+		Query:       query,
+		ProjectRoot: fs.ProjectRoot,
+		PlanId:      CurrentPlanId,
+		Branch:      CurrentBranch,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling request: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), contextProviderTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, config.Command, config.Args...)
+	cmd.Dir = fs.ProjectRoot
+	cmd.Env = os.Environ()
+	for k, v := range config.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = bytes.NewReader(reqBytes)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err = cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("%s timed out after %s", provider, contextProviderTimeout)
+	} else if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, fmt.Errorf("%s failed: %s", provider, msg)
+	}
+
+	var res ContextProviderResponse
+	err = json.Unmarshal(stdout.Bytes(), &res)
+	if err != nil {
+		return nil, fmt.Errorf("%s returned invalid JSON: %v", provider, err)
+	}
+
+	if res.Error != "" {
+		return nil, fmt.Errorf("%s failed: %s", provider, res.Error)
+	}
+
+	var contexts []*shared.LoadContextParams
+	for _, item := range res.Items {
+		if strings.TrimSpace(item.Body) == "" {
+			continue
+		}
+
+		name := item.Name
+		if name == "" {
+			name = query
+		}
+
+		body := item.Body
+		if item.Url != "" {
+			body = fmt.Sprintf("From %s:\n\n%s", item.Url, body)
+		}
+
+		contexts = append(contexts, &shared.LoadContextParams{
+			ContextType: shared.ContextNoteType,
+			Name:        contextProviderContextName(provider, name),
+			Body:        body,
+		})
+	}
+
+	if len(contexts) == 0 {
+		return nil, fmt.Errorf("%s found nothing for %s", provider, query)
+	}
+
+	return contexts, nil
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"os"
+	"path/filepath"
 	"plandex/api"
 	"plandex/fs"
 	"plandex/term"
@@ -63,6 +64,9 @@ func MustCheckOutdatedContext(quiet bool, maybeContexts []*shared.Context) (cont
 		lbl = strconv.Itoa(outdatedRes.NumTrees) + " " + lbl
 		types = append(types, lbl)
 	}
+	if outdatedRes.NumSymbolMaps > 0 {
+		types = append(types, "symbol map")
+	}
 
 	var msg string
 	if len(types) <= 2 {
@@ -150,6 +154,7 @@ func checkOutdatedAndMaybeUpdateContext(doUpdate bool, maybeContexts []*shared.C
 	var numFiles int
 	var numUrls int
 	var numTrees int
+	var numSymbolMaps int
 	var mu sync.Mutex
 	var wg sync.WaitGroup
 	contextsById := map[string]*shared.Context{}
@@ -180,20 +185,33 @@ func checkOutdatedAndMaybeUpdateContext(doUpdate bool, maybeContexts []*shared.C
 			wg.Add(1)
 			go func(context *shared.Context) {
 				defer wg.Done()
-				fileContent, err := os.ReadFile(context.FilePath)
+				path := filepath.FromSlash(context.FilePath)
+				fileMode, symlinkTarget, statErr := fileModeAndSymlinkTarget(path)
+				fileContent, err := os.ReadFile(path)
 
 				mu.Lock()
 				defer mu.Unlock()
+				if statErr != nil {
+					errs = append(errs, statErr)
+					return
+				}
 				if err != nil {
 					errs = append(errs, fmt.Errorf("failed to read the file %s: %v", context.FilePath, err))
 					return
 				}
 
-				hash := sha256.Sum256(fileContent)
+				// the context's sha is of the decoded body, so decode before comparing
+				body, encoding, err := decodeFileContent(context.FilePath, fileContent)
+				if err != nil {
+					errs = append(errs, err)
+					return
+				}
+
+				hash := sha256.Sum256([]byte(body))
 				sha := hex.EncodeToString(hash[:])
 
-				if sha != context.Sha {
-					body := string(fileContent)
+				// a changed mode or link target is applied along with the body, so it makes the context outdated too
+				if sha != context.Sha || fileMode != context.FileMode || symlinkTarget != context.SymlinkTarget {
 
 					numTokens, err := shared.GetNumTokens(body)
 					if err != nil {
@@ -206,7 +224,10 @@ func checkOutdatedAndMaybeUpdateContext(doUpdate bool, maybeContexts []*shared.C
 					updatedContexts = append(updatedContexts, context)
 
 					req[context.Id] = &shared.UpdateContextParams{
-						Body: body,
+						Body:          body,
+						Encoding:      &encoding,
+						FileMode:      &fileMode,
+						SymlinkTarget: &symlinkTarget,
 					}
 				}
 			}(context)
@@ -215,7 +236,7 @@ func checkOutdatedAndMaybeUpdateContext(doUpdate bool, maybeContexts []*shared.C
 			wg.Add(1)
 			go func(context *shared.Context) {
 				defer wg.Done()
-				flattenedPaths, err := ParseInputPaths([]string{context.FilePath}, &types.LoadContextParams{
+				flattenedPaths, err := ParseInputPaths([]string{filepath.FromSlash(context.FilePath)}, &types.LoadContextParams{
 					NamesOnly:       true,
 					ForceSkipIgnore: context.ForceSkipIgnore,
 				})
@@ -243,6 +264,9 @@ func checkOutdatedAndMaybeUpdateContext(doUpdate bool, maybeContexts []*shared.C
 					flattenedPaths = filteredPaths
 				}
 
+				for i, path := range flattenedPaths {
+					flattenedPaths[i] = filepath.ToSlash(path)
+				}
 				body := strings.Join(flattenedPaths, "\n")
 				bytes := []byte(body)
 
@@ -298,6 +322,52 @@ func checkOutdatedAndMaybeUpdateContext(doUpdate bool, maybeContexts []*shared.C
 				}
 
 			}(context)
+
+		} else if isSymbolMapContext(context) {
+			// indexes of huge repos are slow to parse, so the map is only reparsed after the index is regenerated
+			info, err := os.Stat(symbolIndexAbsPath(context.FilePath))
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("failed to check the symbol index %s: %v", context.FilePath, err))
+				mu.Unlock()
+				continue
+			}
+			if !info.ModTime().After(context.UpdatedAt) {
+				continue
+			}
+
+			wg.Add(1)
+			go func(context *shared.Context) {
+				defer wg.Done()
+				m, err := readSymbolIndex(context.FilePath)
+
+				mu.Lock()
+				defer mu.Unlock()
+
+				if err != nil {
+					errs = append(errs, fmt.Errorf("failed to read the symbol index %s: %v", context.FilePath, err))
+					return
+				}
+
+				body := m.Body(context.FilePath)
+				hash := sha256.Sum256([]byte(body))
+				sha := hex.EncodeToString(hash[:])
+
+				if sha != context.Sha {
+					numTokens, err := shared.GetNumTokens(body)
+					if err != nil {
+						errs = append(errs, fmt.Errorf("failed to get the number of tokens in the symbol map %s: %v", context.FilePath, err))
+						return
+					}
+					tokenDiffsById[context.Id] = numTokens - context.NumTokens
+
+					numSymbolMaps++
+					updatedContexts = append(updatedContexts, context)
+					req[context.Id] = &shared.UpdateContextParams{
+						Body: body,
+					}
+				}
+			}(context)
 		}
 	}
 
@@ -354,6 +424,7 @@ func checkOutdatedAndMaybeUpdateContext(doUpdate bool, maybeContexts []*shared.C
 		NumFiles:        numFiles,
 		NumUrls:         numUrls,
 		NumTrees:        numTrees,
+		NumSymbolMaps:   numSymbolMaps,
 	}, nil
 }
 
@@ -46,7 +46,7 @@ func resolveProject(mustResolve, shouldCreate bool) {
 			"🤷‍♂️ No plans in current directory\nTry %s to create a plan or %s to see plans in nearby directories\n",
 			color.New(color.Bold, term.ColorHiCyan).Sprint("plandex new"),
 			color.New(color.Bold, term.ColorHiCyan).Sprint("plandex plans"))
-		os.Exit(0)
+		term.Exit(0)
 	}
 
 	if fs.PlandexDir == "" {
@@ -94,48 +94,68 @@ func resolveProject(mustResolve, shouldCreate bool) {
 }
 
 func MustLoadCurrentPlan() {
+	err := LoadCurrentPlan()
+	if err != nil {
+		term.OutputErrorAndExit("%v", err)
+	}
+}
+
+// LoadCurrentPlan is MustLoadCurrentPlan for long-running servers, which report errors to their client rather than exiting
+func LoadCurrentPlan() error {
 	if CurrentProjectId == "" {
-		term.OutputErrorAndExit("No current project")
+		return fmt.Errorf("no current project")
 	}
 
 	// Check if the file exists
 	_, err := os.Stat(HomeCurrentPlanPath)
 
+	var currentPlan types.CurrentPlanSettings
+
 	if os.IsNotExist(err) {
-		return
+		if GetPlanOverride() == "" {
+			return nil
+		}
 	} else if err != nil {
-		term.OutputErrorAndExit("error checking if current_plan.json exists: %v", err)
-	}
-
-	// Read the contents of the file
-	fileBytes, err := os.ReadFile(HomeCurrentPlanPath)
-	if err != nil {
-		term.OutputErrorAndExit("error reading current_plan.json: %v", err)
-	}
+		return fmt.Errorf("error checking if current_plan.json exists: %v", err)
+	} else {
+		// Read the contents of the file
+		fileBytes, err := os.ReadFile(HomeCurrentPlanPath)
+		if err != nil {
+			return fmt.Errorf("error reading current_plan.json: %v", err)
+		}
 
-	var currentPlan types.CurrentPlanSettings
-	err = json.Unmarshal(fileBytes, &currentPlan)
-	if err != nil {
-		term.OutputErrorAndExit("error unmarshalling current_plan.json: %v", err)
+		err = json.Unmarshal(fileBytes, &currentPlan)
+		if err != nil {
+			return fmt.Errorf("error unmarshalling current_plan.json: %v", err)
+		}
 	}
 
 	CurrentPlanId = currentPlan.Id
 
+	if override := GetPlanOverride(); override != "" {
+		CurrentPlanId, err = resolvePlanOverride(override)
+		if err != nil {
+			return err
+		}
+	}
+
 	if CurrentPlanId != "" {
 		err = loadCurrentBranch()
 
 		if err != nil {
-			term.OutputErrorAndExit("error loading current branch: %v", err)
+			return fmt.Errorf("error loading current branch: %v", err)
 		}
 
 		if CurrentBranch == "" {
 			err = WriteCurrentBranch("main")
 
 			if err != nil {
-				term.OutputErrorAndExit("error setting current branch: %v", err)
+				return fmt.Errorf("error setting current branch: %v", err)
 			}
 		}
 	}
+
+	return nil
 }
 
 func loadCurrentBranch() error {
@@ -0,0 +1,16 @@
+//go:build !windows
+
+package lib
+
+import (
+	"os"
+	"syscall"
+)
+
+// sent when the terminal is closed
+var hangupSignals = []os.Signal{syscall.SIGHUP}
+
+// detachedProcAttr starts a process in its own session, so it isn't sent the terminal's hangup
+func detachedProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
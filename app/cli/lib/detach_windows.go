@@ -0,0 +1,18 @@
+//go:build windows
+
+package lib
+
+import (
+	"os"
+	"syscall"
+)
+
+// closing the console window is delivered as SIGTERM
+var hangupSignals = []os.Signal{syscall.SIGTERM}
+
+const detachedProcess = 0x00000008
+
+// detachedProcAttr starts a process without a console, so it keeps running when the console is closed
+func detachedProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{CreationFlags: detachedProcess | syscall.CREATE_NEW_PROCESS_GROUP}
+}
@@ -0,0 +1,214 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"plandex/fs"
+	"plandex/term"
+	"plandex/types"
+	"runtime"
+	"strings"
+
+	"github.com/muesli/termenv"
+	"github.com/plandex/plandex/shared"
+	xterm "golang.org/x/term"
+)
+
+// narrower terminals wrap the stream UI and diffs badly
+const doctorMinTerminalWidth = 80
+
+// CheckTerminal checks what the stream and changes UIs need from the terminal: a tty on stdin and stdout, the alt screen, color, and enough width
+func CheckTerminal() []*shared.DoctorCheck {
+	var checks []*shared.DoctorCheck
+
+	check := &shared.DoctorCheck{Name: "Interactive terminal", Ok: true}
+	stdinTty := xterm.IsTerminal(int(os.Stdin.Fd()))
+	stdoutTty := xterm.IsTerminal(int(os.Stdout.Fd()))
+	if stdinTty && stdoutTty {
+		width, height, err := xterm.GetSize(int(os.Stdout.Fd()))
+		if err != nil {
+			check.Message = "stdin and stdout are terminals"
+		} else {
+			check.Message = fmt.Sprintf("%dx%d", width, height)
+			if width < doctorMinTerminalWidth {
+				check.Warning = true
+				check.Message += fmt.Sprintf(", narrower than %d columns", doctorMinTerminalWidth)
+				check.Fix = "Widen the terminal window so plans and diffs don't wrap"
+			}
+		}
+	} else {
+		// plandex works without a terminal, like when doctor's output is piped or it runs in CI, so this is only a warning
+		check.Warning = true
+		if !stdoutTty {
+			check.Message = "stdout isn't a terminal"
+		} else {
+			check.Message = "stdin isn't a terminal"
+		}
+		check.Fix = "Run plandex directly in a terminal rather than through a pipe, or use 'plandex tell --bg' and 'plandex build --bg' in scripts"
+	}
+	checks = append(checks, check)
+
+	check = &shared.DoctorCheck{Name: "Alt screen", Ok: true}
+	termEnv := os.Getenv("TERM")
+	if runtime.GOOS == "windows" && !term.VirtualTerminalSupported() {
+		check.Ok = false
+		check.Message = "legacy Windows console without escape sequence support, so screens are cleared rather than switched"
+		check.Fix = "Use Windows Terminal, or update to Windows 10 version 1809 or later"
+	} else if runtime.GOOS == "windows" && termEnv == "" {
+		check.Message = "Windows console"
+	} else if termEnv == "" || termEnv == "dumb" {
+		check.Ok = false
+		check.Message = fmt.Sprintf("TERM is %q, so the stream UI can't switch to the alt screen", termEnv)
+		check.Fix = "Set TERM to your terminal's type, e.g. 'export TERM=xterm-256color'"
+	} else {
+		check.Message = "TERM=" + termEnv
+	}
+	checks = append(checks, check)
+
+	check = &shared.DoctorCheck{Name: "Color", Ok: true}
+	switch profile := termenv.EnvColorProfile(); {
+	case !stdoutTty:
+		check.Message = "not checked, since stdout isn't a terminal"
+	case os.Getenv("NO_COLOR") != "":
+		check.Message = "disabled by NO_COLOR"
+	case profile == termenv.TrueColor:
+		check.Message = "true color"
+	case profile == termenv.ANSI256:
+		check.Message = "256 colors"
+	case profile == termenv.ANSI:
+		check.Message = "16 colors"
+	default:
+		check.Ok = false
+		check.Message = "no color support detected, so diffs and statuses are hard to read"
+		check.Fix = "Set COLORTERM=truecolor, or TERM to a type with color like xterm-256color"
+	}
+	checks = append(checks, check)
+
+	return checks
+}
+
+// CheckGit checks that git is installed, whether the project is in a repo, and that commits from 'plandex apply' will have an author
+func CheckGit() []*shared.DoctorCheck {
+	var checks []*shared.DoctorCheck
+
+	check := &shared.DoctorCheck{Name: "Git", Ok: true}
+	res, err := exec.Command("git", "--version").Output()
+	if err != nil {
+		check.Ok = false
+		check.Message = fmt.Sprintf("error running git: %v", err)
+		check.Fix = "Install git (https://git-scm.com/downloads) and make sure it's on your PATH"
+		return append(checks, check)
+	}
+	check.Message = strings.TrimSpace(string(res))
+	checks = append(checks, check)
+
+	dir := fs.ProjectRoot
+	if dir == "" {
+		dir = fs.Cwd
+	}
+
+	check = &shared.DoctorCheck{Name: "Git repo", Ok: true}
+	res, err = exec.Command("git", "-C", dir, "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		// plans work outside a repo, but nothing is committed on apply
+		check.Message = "not in a git repo, so applied changes won't be committed. Run 'git init' if you want them to be."
+		checks = append(checks, check)
+		return checks
+	}
+	check.Message = strings.TrimSpace(string(res))
+	checks = append(checks, check)
+
+	check = &shared.DoctorCheck{Name: "Git identity", Ok: true}
+	name, _ := exec.Command("git", "-C", dir, "config", "user.name").Output()
+	email, _ := exec.Command("git", "-C", dir, "config", "user.email").Output()
+	if strings.TrimSpace(string(name)) == "" || strings.TrimSpace(string(email)) == "" {
+		check.Ok = false
+		check.Message = "user.name or user.email isn't set, so 'plandex apply' can't commit"
+		check.Fix = "Run 'git config --global user.name \"Your Name\"' and 'git config --global user.email you@example.com'"
+	} else {
+		check.Message = fmt.Sprintf("%s <%s>", strings.TrimSpace(string(name)), strings.TrimSpace(string(email)))
+	}
+	checks = append(checks, check)
+
+	return checks
+}
+
+// CheckProjectFiles parses the project's local state files before the project is resolved, which exits on the first one that's broken. resolvable is true if the project exists and can be resolved.
+func CheckProjectFiles() (checks []*shared.DoctorCheck, resolvable bool) {
+	check := &shared.DoctorCheck{Name: "Project", Ok: true}
+
+	if fs.PlandexDir == "" {
+		check.Message = "no plans in this directory. Start one with 'plandex new'."
+		return []*shared.DoctorCheck{check}, false
+	}
+
+	path := filepath.Join(fs.PlandexDir, "project.json")
+	var settings types.CurrentProjectSettings
+	err := readDoctorJson(path, &settings)
+	if os.IsNotExist(err) {
+		// created on the next command that resolves the project
+		check.Message = fmt.Sprintf("%s will be initialized by the next command", fs.PlandexDir)
+		return []*shared.DoctorCheck{check}, false
+	} else if err != nil {
+		check.Ok = false
+		check.Message = err.Error()
+		check.Fix = fmt.Sprintf("Restore %s from a backup, or remove it to start a new project here", path)
+		return []*shared.DoctorCheck{check}, false
+	}
+	check.Message = fs.ProjectRoot
+	checks = append(checks, check)
+
+	path = filepath.Join(fs.HomePlandexDir, settings.Id, "current_plan.json")
+	var currentPlan types.CurrentPlanSettings
+	err = readDoctorJson(path, &currentPlan)
+	if err != nil && !os.IsNotExist(err) {
+		checks = append(checks, &shared.DoctorCheck{
+			Name:    "Current plan",
+			Message: err.Error(),
+			Fix:     fmt.Sprintf("Remove %s, then pick a plan with 'plandex cd'", path),
+		})
+		return checks, false
+	}
+
+	return checks, true
+}
+
+// CheckPlanInfo checks the current plan's local plan.json, which holds its usage, applied commits, and linked issues
+func CheckPlanInfo() *shared.DoctorCheck {
+	check := &shared.DoctorCheck{Name: "Current plan", Ok: true}
+
+	if CurrentPlanId == "" {
+		check.Message = "no current plan. Pick one with 'plandex cd' or start one with 'plandex new'."
+		return check
+	}
+
+	path := getPlanInfoPath(CurrentPlanId)
+	var info types.PlanInfo
+	err := readDoctorJson(path, &info)
+	if err != nil && !os.IsNotExist(err) {
+		check.Ok = false
+		check.Message = err.Error()
+		check.Fix = fmt.Sprintf("Move %s aside. Only local records like usage and applied commits are lost.", path)
+		return check
+	}
+
+	check.Message = fmt.Sprintf("branch %s", CurrentBranch)
+	return check
+}
+
+func readDoctorJson(path string, v any) error {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(bytes, v)
+	if err != nil {
+		return fmt.Errorf("%s is corrupt: %v", filepath.Base(path), err)
+	}
+
+	return nil
+}
@@ -0,0 +1,14 @@
+package lib
+
+import "testing"
+
+func TestCheckTerminalWithoutTty(t *testing.T) {
+	// go test's stdout is a pipe, like 'plandex doctor | tee log' or doctor in CI
+	check := CheckTerminal()[0]
+	if check.Name != "Interactive terminal" {
+		t.Fatalf("expected the tty check first, got %s", check.Name)
+	}
+	if !check.Ok || !check.Warning {
+		t.Errorf("expected a missing tty to be a warning that doesn't fail doctor, got ok=%v warning=%v", check.Ok, check.Warning)
+	}
+}
@@ -0,0 +1,233 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"plandex/api"
+	"plandex/fs"
+	"sort"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func GetDryRunDir(planId, branch string) string {
+	return filepath.Join(fs.PlandexDir, "dry-runs", planId, branch)
+}
+
+// WriteDryRun saves the plan's drafted version of each file, along with a unified diff against the project, to the plan's dry run directory. It only reads from the project, so nothing in the working tree changes until the plan is applied. Any previous dry run for the branch is replaced.
+func WriteDryRun(planId, branch string) (dir string, numFiles int, err error) {
+	currentPlanState, apiErr := api.Client.GetCurrentPlanState(planId, branch)
+	if apiErr != nil {
+		return "", 0, fmt.Errorf("error getting current plan state: %v", apiErr.Msg)
+	}
+
+	dir = GetDryRunDir(planId, branch)
+	err = os.RemoveAll(dir)
+	if err != nil {
+		return "", 0, fmt.Errorf("error removing previous dry run: %v", err)
+	}
+
+	diff, err := buildPlanDiff(currentPlanState.CurrentPlanFiles, false, func(path, content string) error {
+		numFiles++
+		return writeFileMkdir(filepath.Join(dir, "files", path), content)
+	})
+	if err != nil {
+		return "", 0, err
+	}
+
+	err = writeFileMkdir(filepath.Join(dir, "changes.diff"), diff)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return dir, numFiles, nil
+}
+
+// GetPlanDiff returns a unified diff of the plan's pending changes against the project
+func GetPlanDiff(planId, branch string) (string, error) {
+	currentPlanState, apiErr := api.Client.GetCurrentPlanState(planId, branch)
+	if apiErr != nil {
+		return "", fmt.Errorf("error getting current plan state: %v", apiErr.Msg)
+	}
+
+	return buildPlanDiff(currentPlanState.CurrentPlanFiles, false, nil)
+}
+
+// GetPlanPatch returns the plan's pending changes as a patch with git's a/ and b/ paths, so it can be applied to another checkout of the project with 'git apply' or 'patch -p1'
+func GetPlanPatch(planId, branch string) (string, error) {
+	currentPlanState, apiErr := api.Client.GetCurrentPlanState(planId, branch)
+	if apiErr != nil {
+		return "", fmt.Errorf("error getting current plan state: %v", apiErr.Msg)
+	}
+
+	return buildPlanDiff(currentPlanState.CurrentPlanFiles, true, nil)
+}
+
+// buildPlanDiff diffs each of the plan's files against the project, calling onDrafted with the drafted content of each file that isn't being removed. With asPatch, the staging paths in each file's header are replaced with the file's own path under git's a/ and b/ prefixes.
+func buildPlanDiff(planFiles *shared.CurrentPlanFiles, asPatch bool, onDrafted func(path, content string) error) (string, error) {
+	fileDiffs, err := buildPlanFileDiffs(planFiles, asPatch, onDrafted)
+	if err != nil {
+		return "", err
+	}
+
+	var diffs []string
+	for _, fileDiff := range fileDiffs {
+		diffs = append(diffs, fileDiff.diff)
+	}
+
+	return strings.Join(diffs, ""), nil
+}
+
+type planFileDiff struct {
+	path    string
+	existed bool
+	removed bool
+	diff    string
+}
+
+// buildPlanFileDiffs is buildPlanDiff with each file's diff kept separate, in path order
+func buildPlanFileDiffs(planFiles *shared.CurrentPlanFiles, asPatch bool, onDrafted func(path, content string) error) ([]*planFileDiff, error) {
+	// original and drafted versions are staged side by side so git can diff them with readable paths
+	stageDir, err := os.MkdirTemp("", "plandex-dry-run-*")
+	if err != nil {
+		return nil, fmt.Errorf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(stageDir)
+
+	var paths []string
+	for path := range planFiles.Files {
+		paths = append(paths, path)
+	}
+	for path := range planFiles.Removed {
+		if _, ok := planFiles.Files[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	var fileDiffs []*planFileDiff
+	for _, path := range paths {
+		original, exists, err := readProjectFileForDiff(path)
+		if err != nil {
+			return nil, err
+		}
+
+		originalPath := os.DevNull
+		if exists {
+			originalPath = filepath.Join("original", path)
+			err = writeFileMkdir(filepath.Join(stageDir, originalPath), original)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		draftedPath := os.DevNull
+		content, ok := planFiles.Files[path]
+		removed := !ok || planFiles.Removed[path]
+		if !removed {
+			content = strings.ReplaceAll(content, "\\`\\`\\`", "```")
+			if exists {
+				content = matchLineEndings(original, content)
+			}
+
+			draftedPath = filepath.Join("plandex", path)
+			err = writeFileMkdir(filepath.Join(stageDir, draftedPath), content)
+			if err != nil {
+				return nil, err
+			}
+
+			if onDrafted != nil {
+				err = onDrafted(path, content)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		diff, err := gitDiffNoIndex(stageDir, originalPath, draftedPath)
+		if err != nil {
+			return nil, fmt.Errorf("error diffing %s: %v", path, err)
+		}
+		if asPatch {
+			diff = toPatchPaths(path, diff)
+		}
+		fileDiffs = append(fileDiffs, &planFileDiff{path: path, existed: exists, removed: removed, diff: diff})
+	}
+
+	return fileDiffs, nil
+}
+
+// toPatchPaths rewrites the header of a single file's diff to refer to the file by its project path. Hunks are left alone.
+func toPatchPaths(path, diff string) string {
+	path = filepath.ToSlash(path)
+	lines := strings.SplitAfter(diff, "\n")
+
+	for i, line := range lines {
+		if strings.HasPrefix(line, "@@") {
+			break
+		}
+
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			lines[i] = fmt.Sprintf("diff --git a/%s b/%s\n", path, path)
+		case strings.HasPrefix(line, "--- ") && !strings.HasPrefix(line, "--- /dev/null"):
+			lines[i] = fmt.Sprintf("--- a/%s\n", path)
+		case strings.HasPrefix(line, "+++ ") && !strings.HasPrefix(line, "+++ /dev/null"):
+			lines[i] = fmt.Sprintf("+++ b/%s\n", path)
+		}
+	}
+
+	return strings.Join(lines, "")
+}
+
+func readProjectFileForDiff(path string) (string, bool, error) {
+	bytes, err := os.ReadFile(fs.ProjectFilePath(path))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("error reading %s: %v", path, err)
+	}
+
+	content, _, err := decodeFileContent(path, bytes)
+	if err != nil {
+		return "", false, err
+	}
+
+	return content, true, nil
+}
+
+func writeFileMkdir(path, content string) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return fmt.Errorf("error creating directory %s: %v", filepath.Dir(path), err)
+	}
+
+	err = os.WriteFile(path, []byte(content), 0644)
+	if err != nil {
+		return fmt.Errorf("error writing %s: %v", path, err)
+	}
+
+	return nil
+}
+
+func gitDiffNoIndex(dir, a, b string) (string, error) {
+	cmd := exec.Command("git", "diff", "--no-index", "--no-color", "--src-prefix=", "--dst-prefix=", "--", a, b)
+	cmd.Dir = dir
+
+	out, err := cmd.Output()
+	if err != nil {
+		// exit status 1 just means the files differ
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return string(out), nil
+		}
+		return "", err
+	}
+
+	return string(out), nil
+}
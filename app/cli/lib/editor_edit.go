@@ -0,0 +1,266 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"plandex/api"
+	"plandex/fs"
+	"plandex/jsonrpc"
+	"sort"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// EditorRangedEdit replaces whole lines of a file. Lines are 1-indexed and inclusive. EndLine is StartLine-1 when text is only inserted before StartLine.
+type EditorRangedEdit struct {
+	Path      string `json:"path"`
+	StartLine int    `json:"startLine"`
+	EndLine   int    `json:"endLine"`
+	// the lines being replaced, so the editor can check its buffer still matches. Each line ends with its newline, other than a last line without one.
+	Original string `json:"original"`
+	Text     string `json:"text"`
+	// the plan changed lines outside the selection, which the edit also covers
+	OutsideSelection bool `json:"outsideSelection"`
+	// other files the plan changed, which are left pending in the plan
+	OtherFiles []string `json:"otherFiles,omitempty"`
+}
+
+// editorProposeEdit asks the plan to change a range of a file and returns the change as a ranged edit for the editor to show inline. The plan's stream is forwarded as stream notifications while it works. Unless keepPending is set, the file's pending change is then rejected from the plan, since the editor applies the edit itself.
+func editorProposeEdit(c *jsonrpc.Conn, raw json.RawMessage) (any, error) {
+	var params struct {
+		// absolute or relative to the project root
+		Path        string `json:"path"`
+		StartLine   int    `json:"startLine"`
+		EndLine     int    `json:"endLine"`
+		Instruction string `json:"instruction"`
+		KeepPending bool   `json:"keepPending"`
+	}
+	if err := parseEditorParams(raw, &params); err != nil {
+		return nil, err
+	}
+	if params.Path == "" || params.Instruction == "" {
+		return nil, &jsonrpc.Error{Code: jsonrpc.ErrCodeInvalidParams, Message: "path and instruction are required"}
+	}
+	if params.StartLine < 1 || params.EndLine < params.StartLine {
+		return nil, &jsonrpc.Error{Code: jsonrpc.ErrCodeInvalidParams, Message: "startLine must be at least 1 and endLine can't be before it"}
+	}
+
+	if !HasApiKey() {
+		return nil, fmt.Errorf("OPENAI_API_KEY isn't set in the server's environment")
+	}
+
+	ref, prep, err := prepareEditorEdit(params.Path, params.StartLine, params.EndLine)
+	if err != nil {
+		return nil, err
+	}
+	path := prep.sel.Path
+
+	modelSet, err := GetDefaultModelSet()
+	if err != nil {
+		return nil, err
+	}
+
+	prompt := fmt.Sprintf("%s\n\nChange only %s, which is currently:\n\n%s\n\nLeave the rest of %s as it is, and don't change any other files unless the change can't work without it.", params.Instruction, prep.sel.Describe(), fenceCode(prep.selected), path)
+
+	// editorMu isn't held while the plan works, so other requests, like stop, can run in the meantime
+	err = tellAndWait(ref.PlanId, ref.Branch, shared.TellPlanRequest{
+		Prompt:        prompt,
+		ConnectStream: true,
+		ProjectPaths:  prep.paths.ActivePaths,
+		BuildMode:     shared.BuildModeAuto,
+		ApiKey:        os.Getenv("OPENAI_API_KEY"),
+		Mock:          GetMockConfig(),
+		ModelSet:      modelSet,
+	}, func(msg *shared.StreamMessage) {
+		// tellAndWait already records usage and skips missing files, so messages are only passed on
+		c.Notify("stream", EditorStreamEvent{PlanId: ref.PlanId, Branch: ref.Branch, Message: msg})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("plan failed: %v", err)
+	}
+
+	currentPlanState, apiErr := api.Client.GetCurrentPlanState(ref.PlanId, ref.Branch)
+	if apiErr != nil {
+		return nil, fmt.Errorf("error getting current plan state: %v", apiErr.Msg)
+	}
+
+	planFiles := currentPlanState.CurrentPlanFiles
+	updated, ok := planFiles.Files[path]
+	if !ok || planFiles.Removed[path] {
+		return nil, fmt.Errorf("the plan didn't change %s", path)
+	}
+
+	edit := getRangedEdit(path, prep.original, updated)
+	edit.OutsideSelection = edit.StartLine < params.StartLine || edit.EndLine > params.EndLine
+
+	for otherPath := range planFiles.Files {
+		if otherPath != path {
+			edit.OtherFiles = append(edit.OtherFiles, otherPath)
+		}
+	}
+	sort.Strings(edit.OtherFiles)
+
+	if !params.KeepPending {
+		apiErr = api.Client.RejectFile(ref.PlanId, ref.Branch, path)
+		if apiErr != nil {
+			return nil, fmt.Errorf("error rejecting pending change to %s: %v", path, apiErr.Msg)
+		}
+	}
+
+	return edit, nil
+}
+
+type editorEditPrep struct {
+	sel      *FileSelection
+	selected string
+	// the whole file before the edit
+	original string
+	paths    *fs.ProjectPaths
+}
+
+// prepareEditorEdit loads the current plan, reads the selection, and makes sure the file is in the plan's context and the context is up to date. It holds editorMu since the context helpers work on the current plan's globals.
+func prepareEditorEdit(paramPath string, startLine, endLine int) (EditorStreamRef, *editorEditPrep, error) {
+	editorMu.Lock()
+	defer editorMu.Unlock()
+
+	if err := requireCurrentPlan(); err != nil {
+		return EditorStreamRef{}, nil, err
+	}
+	ref := EditorStreamRef{PlanId: CurrentPlanId, Branch: CurrentBranch}
+
+	path, err := editorProjectPath(paramPath)
+	if err != nil {
+		return ref, nil, err
+	}
+
+	prep := &editorEditPrep{sel: &FileSelection{Path: path, Start: startLine, End: endLine}}
+	prep.selected, err = prep.sel.Read()
+	if err != nil {
+		return ref, nil, err
+	}
+
+	bytes, err := os.ReadFile(fs.ProjectFilePath(path))
+	if err != nil {
+		return ref, nil, fmt.Errorf("error reading %s: %v", path, err)
+	}
+	prep.original = string(bytes)
+
+	// the edit is worked out against the file on disk, so earlier pending changes to it would be mixed in
+	currentPlanState, apiErr := api.Client.GetCurrentPlanState(ref.PlanId, ref.Branch)
+	if apiErr != nil {
+		return ref, nil, fmt.Errorf("error getting current plan state: %v", apiErr.Msg)
+	}
+	if currentPlanState.PlanResult.NumPendingForPath(path) > 0 {
+		return ref, nil, fmt.Errorf("the plan already has pending changes to %s. Apply or reject them first.", path)
+	}
+
+	err = ensureFileContext(path, prep.original)
+	if err != nil {
+		return ref, nil, err
+	}
+
+	_, err = UpdateContext(nil)
+	if err != nil {
+		return ref, nil, fmt.Errorf("error updating context: %v", err)
+	}
+
+	contexts, apiErr := api.Client.ListContext(ref.PlanId, ref.Branch)
+	if apiErr != nil {
+		return ref, nil, fmt.Errorf("error getting context: %v", apiErr.Msg)
+	}
+
+	prep.paths, err = fs.GetProjectPaths(fs.GetBaseDirForContexts(contexts))
+	if err != nil {
+		return ref, nil, fmt.Errorf("error getting project paths: %v", err)
+	}
+
+	return ref, prep, nil
+}
+
+// editorProjectPath turns an absolute or project-relative path from the editor into a clean project-relative one, and checks it doesn't point outside the project
+func editorProjectPath(path string) (string, error) {
+	abs := path
+	if !filepath.IsAbs(abs) {
+		abs = fs.ProjectFilePath(path)
+	}
+
+	rel, err := filepath.Rel(fs.ProjectRoot, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s isn't in the project", path)
+	}
+	if rel == "." {
+		return "", fmt.Errorf("%s is the project root, not a file", path)
+	}
+
+	return filepath.ToSlash(rel), nil
+}
+
+// getRangedEdit finds the lines that differ between the two versions of a file, after skipping the lines they start and end with in common
+func getRangedEdit(path, original, updated string) *EditorRangedEdit {
+	before := splitEditLines(original)
+	after := splitEditLines(updated)
+
+	prefix := 0
+	for prefix < len(before) && prefix < len(after) && before[prefix] == after[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(before)-prefix && suffix < len(after)-prefix && before[len(before)-1-suffix] == after[len(after)-1-suffix] {
+		suffix++
+	}
+
+	return &EditorRangedEdit{
+		Path:      path,
+		StartLine: prefix + 1,
+		EndLine:   len(before) - suffix,
+		Original:  strings.Join(before[prefix:len(before)-suffix], ""),
+		Text:      strings.Join(after[prefix:len(after)-suffix], ""),
+	}
+}
+
+// splitEditLines splits text into lines that keep their newlines. The empty string after a final newline isn't a line, so it's dropped, or an edit could end past the last line.
+func splitEditLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// ensureFileContext loads a file into the plan's context if it isn't there already, so the plan builds on its current content
+func ensureFileContext(path, body string) error {
+	contexts, apiErr := api.Client.ListContext(CurrentPlanId, CurrentBranch)
+	if apiErr != nil {
+		return fmt.Errorf("error getting context: %v", apiErr.Msg)
+	}
+
+	for _, context := range contexts {
+		if context.ContextType == shared.ContextFileType && context.FilePath == path {
+			return nil
+		}
+	}
+
+	info, err := os.Stat(fs.ProjectFilePath(path))
+	if err != nil {
+		return fmt.Errorf("error checking %s: %v", path, err)
+	}
+
+	_, apiErr = api.Client.LoadContext(CurrentPlanId, CurrentBranch, shared.LoadContextRequest{
+		{
+			ContextType: shared.ContextFileType,
+			Name:        path,
+			FilePath:    path,
+			Body:        body,
+			FileMode:    info.Mode().Perm(),
+		},
+	})
+	if apiErr != nil {
+		return fmt.Errorf("error loading %s into context: %v", path, apiErr.Msg)
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package lib
+
+import (
+	"path/filepath"
+	"plandex/fs"
+	"testing"
+)
+
+func TestEditorProjectPath(t *testing.T) {
+	root := t.TempDir()
+	origRoot := fs.ProjectRoot
+	fs.ProjectRoot = root
+	defer func() { fs.ProjectRoot = origRoot }()
+
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{"main.go", "main.go", false},
+		{"./cmd/../main.go", "main.go", false},
+		{"cmd/main.go", "cmd/main.go", false},
+		{filepath.Join(root, "cmd", "main.go"), "cmd/main.go", false},
+		{"../x", "", true},
+		{"cmd/../../x", "", true},
+		{"..", "", true},
+		{".", "", true},
+		{filepath.Join(filepath.Dir(root), "x"), "", true},
+		// a file name starting with dots is still in the project
+		{"..x", "..x", false},
+	}
+
+	for _, tt := range tests {
+		got, err := editorProjectPath(tt.path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("editorProjectPath(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("editorProjectPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestGetRangedEdit(t *testing.T) {
+	tests := []struct {
+		name                   string
+		original, updated      string
+		startLine, endLine     int
+		wantOriginal, wantText string
+	}{
+		{"change a middle line", "a\nb\nc\n", "a\nx\nc\n", 2, 2, "b\n", "x\n"},
+		{"change several lines", "a\nb\nc\nd\n", "a\nx\ny\nz\nd\n", 2, 3, "b\nc\n", "x\ny\nz\n"},
+		{"insert a line", "a\nb\n", "a\nx\nb\n", 2, 1, "", "x\n"},
+		{"insert at the start", "a\nb\n", "x\na\nb\n", 1, 0, "", "x\n"},
+		{"append at the end", "a\n", "a\nb\n", 2, 1, "", "b\n"},
+		{"delete a line", "a\nb\nc\n", "a\nc\n", 2, 2, "b\n", ""},
+		// the common prefix and suffix can't overlap when lines repeat
+		{"delete a repeated line", "a\na\n", "a\n", 2, 2, "a\n", ""},
+		{"insert a repeated line", "a\na\n", "a\na\na\n", 3, 2, "", "a\n"},
+		{"remove the final newline", "a\nb\n", "a\nb", 2, 2, "b\n", "b"},
+		{"add a final newline", "a\nb", "a\nb\n", 2, 2, "b", "b\n"},
+		{"change the whole file", "a\nb\n", "x\ny\n", 1, 2, "a\nb\n", "x\ny\n"},
+		{"from empty", "", "a\n", 1, 0, "", "a\n"},
+		{"to empty", "a\n", "", 1, 1, "a\n", ""},
+		{"no change", "a\nb\n", "a\nb\n", 3, 2, "", ""},
+	}
+
+	for _, tt := range tests {
+		edit := getRangedEdit("f.go", tt.original, tt.updated)
+		if edit.StartLine != tt.startLine || edit.EndLine != tt.endLine || edit.Original != tt.wantOriginal || edit.Text != tt.wantText {
+			t.Errorf("%s: got lines %d-%d, original %q, text %q; want lines %d-%d, original %q, text %q",
+				tt.name, edit.StartLine, edit.EndLine, edit.Original, edit.Text, tt.startLine, tt.endLine, tt.wantOriginal, tt.wantText)
+		}
+	}
+}
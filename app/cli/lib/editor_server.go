@@ -0,0 +1,435 @@
+package lib
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"plandex/api"
+	"plandex/fs"
+	"plandex/jsonrpc"
+	"plandex/types"
+	"sort"
+	"sync"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// EditorStreamEvent is sent to an editor as a "stream" notification for each message of a plan stream it started or connected to
+type EditorStreamEvent struct {
+	PlanId  string                `json:"planId"`
+	Branch  string                `json:"branch"`
+	Message *shared.StreamMessage `json:"message,omitempty"`
+	// set if the connection to the stream was lost, after which no more events are sent for it
+	Error string `json:"error,omitempty"`
+}
+
+// EditorStreamRef is the result of methods that start or connect to a stream, whose messages then arrive as stream notifications
+type EditorStreamRef struct {
+	PlanId string `json:"planId"`
+	Branch string `json:"branch"`
+}
+
+type EditorStatus struct {
+	PlanId    string `json:"planId"`
+	PlanName  string `json:"planName"`
+	Branch    string `json:"branch"`
+	Streaming bool   `json:"streaming"`
+	// descriptions of the pending changes
+	PendingChanges []string `json:"pendingChanges"`
+	PendingFiles   []string `json:"pendingFiles"`
+	RemovedFiles   []string `json:"removedFiles"`
+}
+
+type editorMethod func(c *jsonrpc.Conn, params json.RawMessage) (any, error)
+
+var editorMethods = map[string]editorMethod{
+	"tell":    editorTell,
+	"connect": editorConnect,
+	"stop":    editorStop,
+	"status":  editorStatus,
+	"getDiff": editorGetDiff,
+	"apply":   editorApply,
+	// selection-scoped: change a range of a file and get the change back as a ranged edit
+	"proposeEdit": editorProposeEdit,
+}
+
+// editorMu guards the current plan's globals, which each request reloads since 'plandex cd' can switch plans while the server runs, along with the context helpers that read them. Requests otherwise run concurrently, so a long apply doesn't hold up status requests. Streams keep sending notifications after the request that started them returns.
+var editorMu sync.Mutex
+
+// loadEditorPlan loads the current plan and returns it, so methods that don't change the plan's context can run without holding editorMu
+func loadEditorPlan() (EditorStreamRef, error) {
+	editorMu.Lock()
+	defer editorMu.Unlock()
+
+	if err := requireCurrentPlan(); err != nil {
+		return EditorStreamRef{}, err
+	}
+	return EditorStreamRef{PlanId: CurrentPlanId, Branch: CurrentBranch}, nil
+}
+
+// ServeEditor answers JSON-RPC requests from editor extensions on each connection to the listener until it's closed. If token isn't empty, every request has to include it in its params as "token".
+func ServeEditor(listener net.Listener, token string) error {
+	for {
+		nc, err := listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		go serveEditorConn(nc, token)
+	}
+}
+
+func serveEditorConn(nc net.Conn, token string) {
+	defer nc.Close()
+
+	c := jsonrpc.NewConn(nc, nc)
+
+	for {
+		msg, err := c.Read()
+		if err == io.EOF {
+			return
+		}
+
+		var rpcErr *jsonrpc.Error
+		if errors.As(err, &rpcErr) {
+			err = c.Write(&jsonrpc.Message{Id: json.RawMessage("null"), Error: rpcErr})
+			if err != nil {
+				return
+			}
+			continue
+		} else if err != nil {
+			log.Println("Error reading editor request:", err)
+			return
+		}
+
+		// notifications from the editor need no reply
+		if msg.Method == "" || len(msg.Id) == 0 {
+			continue
+		}
+
+		res := &jsonrpc.Message{Id: msg.Id}
+		result, rpcErr := handleEditorRequest(c, msg, token)
+		if rpcErr != nil {
+			res.Error = rpcErr
+		} else {
+			res.Result, err = json.Marshal(result)
+			if err != nil {
+				res.Error = &jsonrpc.Error{Code: jsonrpc.ErrCodeServer, Message: fmt.Sprintf("error marshalling result: %v", err)}
+			}
+		}
+
+		err = c.Write(res)
+		if err != nil {
+			log.Println("Error writing editor response:", err)
+			return
+		}
+	}
+}
+
+func handleEditorRequest(c *jsonrpc.Conn, msg *jsonrpc.Message, token string) (any, *jsonrpc.Error) {
+	if token != "" && !hasEditorToken(msg.Params, token) {
+		return nil, &jsonrpc.Error{Code: jsonrpc.ErrCodeUnauthorized, Message: "missing or invalid token"}
+	}
+
+	method, ok := editorMethods[msg.Method]
+	if !ok {
+		return nil, &jsonrpc.Error{Code: jsonrpc.ErrCodeMethodNotFound, Message: fmt.Sprintf("method not found: %s", msg.Method)}
+	}
+
+	result, err := method(c, msg.Params)
+	if err != nil {
+		var rpcErr *jsonrpc.Error
+		if errors.As(err, &rpcErr) {
+			return nil, rpcErr
+		}
+		return nil, &jsonrpc.Error{Code: jsonrpc.ErrCodeServer, Message: err.Error()}
+	}
+
+	return result, nil
+}
+
+func hasEditorToken(raw json.RawMessage, token string) bool {
+	var params struct {
+		Token string `json:"token"`
+	}
+	if len(raw) == 0 || json.Unmarshal(raw, &params) != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(params.Token), []byte(token)) == 1
+}
+
+// NewEditorToken generates the token clients of a server listening on a port have to send, and writes it to path so only the current user can read it
+func NewEditorToken(path string) (string, error) {
+	bytes := make([]byte, 32)
+	_, err := rand.Read(bytes)
+	if err != nil {
+		return "", fmt.Errorf("error generating token: %v", err)
+	}
+	token := hex.EncodeToString(bytes)
+
+	// removed first so an existing file's permissions aren't kept
+	err = os.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("error removing old token file: %v", err)
+	}
+
+	err = os.WriteFile(path, []byte(token), 0600)
+	if err != nil {
+		return "", fmt.Errorf("error writing token file: %v", err)
+	}
+
+	return token, nil
+}
+
+func parseEditorParams(raw json.RawMessage, params any) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	err := json.Unmarshal(raw, params)
+	if err != nil {
+		return &jsonrpc.Error{Code: jsonrpc.ErrCodeInvalidParams, Message: fmt.Sprintf("invalid params: %v", err)}
+	}
+	return nil
+}
+
+// editorStreamHandler forwards a stream's messages to the editor. Usage is recorded like it is for the stream UI, and missing files are skipped since the editor isn't asked about them.
+func editorStreamHandler(c *jsonrpc.Conn, planId, branch string) types.OnStreamPlan {
+	return func(params types.OnStreamPlanParams) {
+		event := EditorStreamEvent{PlanId: planId, Branch: branch}
+
+		if params.Err != nil {
+			event.Error = params.Err.Error()
+		} else {
+			msg := params.Msg
+			event.Message = msg
+
+			switch msg.Type {
+			case shared.StreamMessagePromptMissingFile:
+				log.Printf("Skipping missing file %s on branch %s\n", msg.MissingFilePath, branch)
+				apiErr := api.Client.RespondMissingFile(planId, branch, shared.RespondMissingFileRequest{
+					Choice:   shared.RespondMissingFileChoiceSkip,
+					FilePath: msg.MissingFilePath,
+				})
+				if apiErr != nil {
+					log.Println("Error skipping missing file:", apiErr.Msg)
+				}
+			}
+		}
+
+		// the editor may have disconnected, in which case the stream keeps running on the server
+		err := c.Notify("stream", event)
+		if err != nil {
+			log.Println("Error sending stream event to editor:", err)
+		}
+	}
+}
+
+func editorTell(c *jsonrpc.Conn, raw json.RawMessage) (any, error) {
+	var params struct {
+		Prompt string `json:"prompt"`
+		// stop after a single reply rather than continuing until the plan is done
+		Stop    bool `json:"stop"`
+		NoBuild bool `json:"noBuild"`
+	}
+	if err := parseEditorParams(raw, &params); err != nil {
+		return nil, err
+	}
+	if params.Prompt == "" {
+		return nil, &jsonrpc.Error{Code: jsonrpc.ErrCodeInvalidParams, Message: "prompt is required"}
+	}
+
+	if !HasApiKey() {
+		return nil, fmt.Errorf("OPENAI_API_KEY isn't set in the server's environment")
+	}
+
+	ref, paths, err := prepareEditorPrompt(params.Prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	modelSet, err := GetDefaultModelSet()
+	if err != nil {
+		return nil, err
+	}
+
+	buildMode := shared.BuildModeAuto
+	if params.NoBuild {
+		buildMode = shared.BuildModeNone
+	}
+
+	apiErr := api.Client.TellPlan(ref.PlanId, ref.Branch, shared.TellPlanRequest{
+		Prompt:        params.Prompt,
+		ConnectStream: true,
+		AutoContinue:  !params.Stop,
+		ProjectPaths:  paths.ActivePaths,
+		BuildMode:     buildMode,
+		ApiKey:        os.Getenv("OPENAI_API_KEY"),
+		Mock:          GetMockConfig(),
+		ModelSet:      modelSet,
+	}, editorStreamHandler(c, ref.PlanId, ref.Branch))
+	if apiErr != nil {
+		return nil, fmt.Errorf("error sending prompt: %v", apiErr.Msg)
+	}
+
+	return ref, nil
+}
+
+func editorConnect(c *jsonrpc.Conn, raw json.RawMessage) (any, error) {
+	ref, err := loadEditorPlan()
+	if err != nil {
+		return nil, err
+	}
+
+	apiErr := api.Client.ConnectPlan(ref.PlanId, ref.Branch, editorStreamHandler(c, ref.PlanId, ref.Branch))
+	if apiErr != nil {
+		return nil, fmt.Errorf("error connecting to stream: %v", apiErr.Msg)
+	}
+
+	return ref, nil
+}
+
+func editorStop(c *jsonrpc.Conn, raw json.RawMessage) (any, error) {
+	ref, err := loadEditorPlan()
+	if err != nil {
+		return nil, err
+	}
+
+	apiErr := api.Client.StopPlan(ref.PlanId, ref.Branch)
+	if apiErr != nil {
+		return nil, fmt.Errorf("error stopping stream: %v", apiErr.Msg)
+	}
+
+	return ref, nil
+}
+
+func editorStatus(c *jsonrpc.Conn, raw json.RawMessage) (any, error) {
+	ref, err := loadEditorPlan()
+	if err != nil {
+		return nil, err
+	}
+
+	status := EditorStatus{
+		PlanId:         ref.PlanId,
+		Branch:         ref.Branch,
+		PendingChanges: []string{},
+		PendingFiles:   []string{},
+		RemovedFiles:   []string{},
+	}
+
+	plan, apiErr := api.Client.GetPlan(ref.PlanId)
+	if apiErr != nil {
+		return nil, fmt.Errorf("error getting plan: %v", apiErr.Msg)
+	}
+	status.PlanName = plan.Name
+
+	running, apiErr := api.Client.ListPlansRunning([]string{CurrentProjectId}, false)
+	if apiErr != nil {
+		return nil, fmt.Errorf("error getting running plans: %v", apiErr.Msg)
+	}
+	for _, branch := range running.Branches {
+		if branch.PlanId == ref.PlanId && branch.Name == ref.Branch {
+			status.Streaming = true
+			break
+		}
+	}
+
+	currentPlanState, apiErr := api.Client.GetCurrentPlanState(ref.PlanId, ref.Branch)
+	if apiErr != nil {
+		return nil, fmt.Errorf("error getting current plan state: %v", apiErr.Msg)
+	}
+
+	for _, desc := range currentPlanState.ConvoMessageDescriptions {
+		if desc.AppliedAt == nil && desc.CommitMsg != "" {
+			status.PendingChanges = append(status.PendingChanges, desc.CommitMsg)
+		}
+	}
+
+	planFiles := currentPlanState.CurrentPlanFiles
+	for path := range planFiles.Files {
+		if !planFiles.Removed[path] {
+			status.PendingFiles = append(status.PendingFiles, path)
+		}
+	}
+	for path := range planFiles.Removed {
+		status.RemovedFiles = append(status.RemovedFiles, path)
+	}
+	sort.Strings(status.PendingFiles)
+	sort.Strings(status.RemovedFiles)
+
+	return status, nil
+}
+
+func editorGetDiff(c *jsonrpc.Conn, raw json.RawMessage) (any, error) {
+	ref, err := loadEditorPlan()
+	if err != nil {
+		return nil, err
+	}
+
+	diff, err := GetPlanDiff(ref.PlanId, ref.Branch)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{"diff": diff}, nil
+}
+
+func editorApply(c *jsonrpc.Conn, raw json.RawMessage) (any, error) {
+	var params struct {
+		GitBranch  string `json:"gitBranch"`
+		Commit     *bool  `json:"commit"`
+		AllowRisky bool   `json:"allowRisky"`
+	}
+	if err := parseEditorParams(raw, &params); err != nil {
+		return nil, err
+	}
+
+	if _, err := loadEditorPlan(); err != nil {
+		return nil, err
+	}
+
+	output, err := ApplyInSubprocess(params.GitBranch, params.Commit == nil || *params.Commit, params.AllowRisky)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{"output": output}, nil
+}
+
+// prepareEditorPrompt loads the current plan and brings its context up to date before a prompt, returning the plan and the project paths to send with the prompt. It holds editorMu since the context helpers work on the current plan's globals.
+func prepareEditorPrompt(prompt string) (EditorStreamRef, *fs.ProjectPaths, error) {
+	editorMu.Lock()
+	defer editorMu.Unlock()
+
+	if err := requireCurrentPlan(); err != nil {
+		return EditorStreamRef{}, nil, err
+	}
+	ref := EditorStreamRef{PlanId: CurrentPlanId, Branch: CurrentBranch}
+
+	LoadMcpPromptContext(prompt)
+
+	_, err := UpdateContext(nil)
+	if err != nil {
+		return ref, nil, fmt.Errorf("error updating context: %v", err)
+	}
+
+	contexts, apiErr := api.Client.ListContext(ref.PlanId, ref.Branch)
+	if apiErr != nil {
+		return ref, nil, fmt.Errorf("error getting context: %v", apiErr.Msg)
+	}
+
+	paths, err := fs.GetProjectPaths(fs.GetBaseDirForContexts(contexts))
+	if err != nil {
+		return ref, nil, fmt.Errorf("error getting project paths: %v", err)
+	}
+
+	return ref, paths, nil
+}
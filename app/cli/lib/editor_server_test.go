@@ -0,0 +1,64 @@
+package lib
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"plandex/jsonrpc"
+	"testing"
+)
+
+func TestEditorRequestToken(t *testing.T) {
+	tests := []struct {
+		name     string
+		params   string
+		wantCode int
+	}{
+		{"no params", "", jsonrpc.ErrCodeUnauthorized},
+		{"no token", `{}`, jsonrpc.ErrCodeUnauthorized},
+		{"wrong token", `{"token":"wrong"}`, jsonrpc.ErrCodeUnauthorized},
+		{"invalid params", `[1]`, jsonrpc.ErrCodeUnauthorized},
+		// past the token check, to the unknown method
+		{"valid token", `{"token":"secret"}`, jsonrpc.ErrCodeMethodNotFound},
+	}
+
+	for _, tt := range tests {
+		msg := &jsonrpc.Message{Id: json.RawMessage("1"), Method: "noSuchMethod", Params: json.RawMessage(tt.params)}
+		_, rpcErr := handleEditorRequest(nil, msg, "secret")
+		if rpcErr == nil || rpcErr.Code != tt.wantCode {
+			t.Errorf("%s: got %v, want error code %d", tt.name, rpcErr, tt.wantCode)
+		}
+	}
+}
+
+func TestNewEditorToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "editor.token")
+	err := os.WriteFile(path, []byte("old"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := NewEditorToken(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(token) != 64 {
+		t.Errorf("token %q should be 32 hex-encoded bytes", token)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("token file permissions = %o, want 600", perm)
+	}
+
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(bytes) != token {
+		t.Errorf("token file = %q, want %q", bytes, token)
+	}
+}
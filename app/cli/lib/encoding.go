@@ -0,0 +1,118 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// encodings are stored on contexts by name; an empty name means plain UTF-8
+const (
+	encodingUTF8BOM   = "utf-8-bom"
+	encodingUTF16LE   = "utf-16le"
+	encodingUTF16BE   = "utf-16be"
+	encodingShiftJIS  = "shift_jis"
+	encodingLatin1    = "windows-1252"
+	binarySniffLength = 8000
+)
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+func getEncoding(name string) encoding.Encoding {
+	switch name {
+	case encodingUTF16LE:
+		return unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM)
+	case encodingUTF16BE:
+		return unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM)
+	case encodingShiftJIS:
+		return japanese.ShiftJIS
+	case encodingLatin1:
+		return charmap.Windows1252
+	}
+	return nil
+}
+
+// decodeFileContent converts a file's raw bytes to UTF-8 for the model, returning the encoding it was detected in so changes can be written back the same way. Files that look binary, or that wouldn't survive a round trip back to their original encoding, are refused rather than risk corrupting them on apply.
+func decodeFileContent(path string, raw []byte) (string, string, error) {
+	if bytes.HasPrefix(raw, utf8BOM) {
+		body := raw[len(utf8BOM):]
+		if utf8.Valid(body) {
+			return string(body), encodingUTF8BOM, nil
+		}
+	}
+
+	var enc string
+	if bytes.HasPrefix(raw, []byte{0xFF, 0xFE}) {
+		enc = encodingUTF16LE
+	} else if bytes.HasPrefix(raw, []byte{0xFE, 0xFF}) {
+		enc = encodingUTF16BE
+	} else {
+		sniff := raw
+		if len(sniff) > binarySniffLength {
+			sniff = sniff[:binarySniffLength]
+		}
+		if bytes.IndexByte(sniff, 0) != -1 {
+			return "", "", fmt.Errorf("%s appears to be a binary file and can't be loaded into context", path)
+		}
+
+		if utf8.Valid(raw) {
+			return string(raw), "", nil
+		}
+
+		enc = detectLegacyEncoding(raw)
+	}
+
+	decoded, err := getEncoding(enc).NewDecoder().Bytes(raw)
+	if err != nil {
+		return "", "", fmt.Errorf("%s isn't valid UTF-8 and couldn't be decoded as %s: %v", path, enc, err)
+	}
+
+	reencoded, err := getEncoding(enc).NewEncoder().Bytes(decoded)
+	if err != nil || !bytes.Equal(reencoded, raw) {
+		return "", "", fmt.Errorf("%s isn't valid UTF-8 and its encoding couldn't be detected reliably (closest match: %s). Convert it to UTF-8 to load it into context", path, enc)
+	}
+
+	return string(decoded), enc, nil
+}
+
+// encodeFileContent converts UTF-8 content back to the encoding the file was loaded in
+func encodeFileContent(path, content, enc string) ([]byte, error) {
+	if enc == "" {
+		return []byte(content), nil
+	}
+
+	if enc == encodingUTF8BOM {
+		return append(append([]byte{}, utf8BOM...), content...), nil
+	}
+
+	e := getEncoding(enc)
+	if e == nil {
+		return nil, fmt.Errorf("unknown encoding %s for %s", enc, path)
+	}
+
+	encoded, err := e.NewEncoder().Bytes([]byte(content))
+	if err != nil {
+		return nil, fmt.Errorf("the updated %s has characters that can't be written in its original encoding (%s): %v", path, enc, err)
+	}
+
+	return encoded, nil
+}
+
+// detectLegacyEncoding guesses between the legacy encodings we support. Shift-JIS is only chosen if the whole file decodes cleanly as Shift-JIS and includes double-byte characters; anything else falls back to Windows-1252, a superset of Latin-1 in which nearly every byte is valid.
+func detectLegacyEncoding(raw []byte) string {
+	decoded, err := japanese.ShiftJIS.NewDecoder().Bytes(raw)
+	if err == nil && !bytes.ContainsRune(decoded, utf8.RuneError) {
+		for _, r := range string(decoded) {
+			if r >= 0x3000 {
+				return encodingShiftJIS
+			}
+		}
+	}
+
+	return encodingLatin1
+}
@@ -0,0 +1,15 @@
+package lib
+
+import (
+	"fmt"
+)
+
+// GetExplainPrompt builds a prompt asking for an explanation of a file or range of lines
+func GetExplainPrompt(sel *FileSelection) (string, error) {
+	content, err := sel.Read()
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Explain %s. Cover what it does, how it works, and anything non-obvious, like edge cases or how it fits in with the rest of the project. Refer to related code in context where it helps.\n\n%s", sel.Describe(), fenceCode(content)), nil
+}
@@ -0,0 +1,308 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"plandex/api"
+	"strings"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+const (
+	ExportFormatMarkdown = "md"
+	ExportFormatHtml     = "html"
+)
+
+// PlanExport is a machine-readable snapshot of a plan branch for dashboards, review bots, and audit pipelines
+type PlanExport struct {
+	PlanId     string    `json:"planId"`
+	Name       string    `json:"name"`
+	Branch     string    `json:"branch"`
+	ExportedAt time.Time `json:"exportedAt"`
+
+	// summary of the pending changes, as used for the commit when they're applied
+	Description string             `json:"description"`
+	Replies     []*PlanExportReply `json:"replies"`
+	Subtasks    []*shared.Subtask  `json:"subtasks"`
+	Files       []*PlanExportFile  `json:"files"`
+	Usage       PlanExportUsage    `json:"usage"`
+}
+
+// PlanExportReply is a reply that made changes or suggested commands
+type PlanExportReply struct {
+	ConvoMessageId string                `json:"convoMessageId"`
+	Summary        string                `json:"summary"`
+	Files          []string              `json:"files"`
+	RemovedFiles   []string              `json:"removedFiles,omitempty"`
+	MovedFiles     []*shared.MovedFile   `json:"movedFiles,omitempty"`
+	Commands       []*shared.PlanCommand `json:"commands,omitempty"`
+	Usage          *shared.ModelUsage    `json:"usage,omitempty"`
+	AppliedAt      *time.Time            `json:"appliedAt,omitempty"`
+	CreatedAt      time.Time             `json:"createdAt"`
+}
+
+// PlanExportFile is a pending change to a file, diffed against the project
+type PlanExportFile struct {
+	Path string `json:"path"`
+	// "added", "modified", "removed", or "moved"
+	Status    string `json:"status"`
+	MovedFrom string `json:"movedFrom,omitempty"`
+	Diff      string `json:"diff"`
+}
+
+// PlanExportUsage is the plan's usage as recorded by the server
+type PlanExportUsage struct {
+	ByPhase map[shared.UsagePhase]*shared.UsageTotal `json:"byPhase"`
+	Total   shared.UsageTotal                        `json:"total"`
+}
+
+// GetPlanExport gathers the plan's descriptions, subtasks, pending changes with their diffs, and usage
+func GetPlanExport(planId, branch string) (*PlanExport, error) {
+	plan, apiErr := api.Client.GetPlan(planId)
+	if apiErr != nil {
+		return nil, fmt.Errorf("error getting plan: %v", apiErr.Msg)
+	}
+
+	currentPlanState, apiErr := api.Client.GetCurrentPlanState(planId, branch)
+	if apiErr != nil {
+		return nil, fmt.Errorf("error getting current plan state: %v", apiErr.Msg)
+	}
+
+	convo, apiErr := api.Client.ListConvo(planId, branch)
+	if apiErr != nil {
+		return nil, fmt.Errorf("error loading conversation: %v", apiErr.Msg)
+	}
+
+	usageByMessageId := map[string]*shared.ModelUsage{}
+	for _, msg := range convo {
+		usageByMessageId[msg.Id] = msg.Usage
+	}
+
+	export := &PlanExport{
+		PlanId:      planId,
+		Name:        plan.Name,
+		Branch:      branch,
+		ExportedAt:  time.Now(),
+		Description: currentPlanState.PendingChangesSummaryForApply(),
+		Replies:     []*PlanExportReply{},
+		Subtasks:    currentPlanState.Subtasks,
+		Files:       []*PlanExportFile{},
+	}
+	if export.Subtasks == nil {
+		export.Subtasks = []*shared.Subtask{}
+	}
+
+	for _, desc := range currentPlanState.ConvoMessageDescriptions {
+		if !desc.MadePlan && len(desc.Commands) == 0 {
+			continue
+		}
+
+		export.Replies = append(export.Replies, &PlanExportReply{
+			ConvoMessageId: desc.ConvoMessageId,
+			Summary:        desc.CommitMsg,
+			Files:          desc.Files,
+			RemovedFiles:   desc.RemovedFiles,
+			MovedFiles:     desc.MovedFiles,
+			Commands:       desc.Commands,
+			Usage:          usageByMessageId[desc.ConvoMessageId],
+			AppliedAt:      desc.AppliedAt,
+			CreatedAt:      desc.CreatedAt,
+		})
+	}
+
+	planFiles := currentPlanState.CurrentPlanFiles
+	if planFiles != nil {
+		fileDiffs, err := buildPlanFileDiffs(planFiles, false, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, fileDiff := range fileDiffs {
+			file := &PlanExportFile{
+				Path:      fileDiff.path,
+				Status:    "modified",
+				MovedFrom: planFiles.MovedFrom[fileDiff.path],
+				Diff:      fileDiff.diff,
+			}
+
+			switch {
+			case file.MovedFrom != "":
+				file.Status = "moved"
+			case fileDiff.removed:
+				file.Status = "removed"
+			case !fileDiff.existed:
+				file.Status = "added"
+			}
+
+			export.Files = append(export.Files, file)
+		}
+	}
+
+	byPhase, err := GetPlanUsageTotals(planId)
+	if err != nil {
+		return nil, err
+	}
+	export.Usage.ByPhase = byPhase
+	for _, total := range byPhase {
+		export.Usage.Total.AddTotal(*total)
+	}
+
+	return export, nil
+}
+
+// GetConvoExport renders the plan's full conversation as a standalone document, with each reply's description, files, and usage
+func GetConvoExport(planId, branch, format string) (string, error) {
+	if format != ExportFormatMarkdown && format != ExportFormatHtml {
+		return "", fmt.Errorf("unknown format %q, expected %s or %s", format, ExportFormatMarkdown, ExportFormatHtml)
+	}
+
+	plan, apiErr := api.Client.GetPlan(planId)
+	if apiErr != nil {
+		return "", fmt.Errorf("error getting plan: %v", apiErr.Msg)
+	}
+
+	convo, apiErr := api.Client.ListConvo(planId, branch)
+	if apiErr != nil {
+		return "", fmt.Errorf("error loading conversation: %v", apiErr.Msg)
+	}
+
+	currentPlanState, apiErr := api.Client.GetCurrentPlanState(planId, branch)
+	if apiErr != nil {
+		return "", fmt.Errorf("error getting current plan state: %v", apiErr.Msg)
+	}
+
+	md := renderConvoMarkdown(plan.Name, branch, convo, currentPlanState.ConvoMessageDescriptions)
+
+	if format == ExportFormatMarkdown {
+		return md, nil
+	}
+
+	return renderExportHtml(plan.Name, md)
+}
+
+func renderConvoMarkdown(planName, branch string, convo []*shared.ConvoMessage, descs []*shared.ConvoMessageDescription) string {
+	descsByMessageId := map[string]*shared.ConvoMessageDescription{}
+	for _, desc := range descs {
+		descsByMessageId[desc.ConvoMessageId] = desc
+	}
+
+	var totalTokens int
+	var totalUsage shared.UsageTotal
+	for _, msg := range convo {
+		totalTokens += msg.Tokens
+		if msg.Usage != nil {
+			totalUsage.Add(*msg.Usage)
+		}
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s\n\n", planName)
+	fmt.Fprintf(&b, "Branch `%s` · %d messages · %d tokens · %s in replies · exported %s\n\n", branch, len(convo), totalTokens, totalUsage.FormatCost(), time.Now().Local().Format("Jan 2, 2006 3:04pm MST"))
+
+	for i, msg := range convo {
+		author := msg.Role
+		if msg.Role == "assistant" {
+			author = "Plandex"
+		} else if msg.Role == "user" {
+			author = "You"
+		}
+
+		annotations := []string{
+			msg.CreatedAt.Local().Format("Mon Jan 2, 2006 3:04pm MST"),
+			fmt.Sprintf("%d tokens", msg.Tokens),
+		}
+		if msg.Usage != nil {
+			var usage shared.UsageTotal
+			usage.Add(*msg.Usage)
+			annotations = append(annotations, fmt.Sprintf("%d prompt + %d completion tokens", msg.Usage.PromptTokens, msg.Usage.CompletionTokens), usage.FormatCost())
+		}
+
+		fmt.Fprintf(&b, "---\n\n## %d. %s\n\n", i+1, author)
+		fmt.Fprintf(&b, "_%s_\n\n", strings.Join(annotations, " · "))
+
+		b.WriteString(strings.TrimSpace(msg.Message) + "\n\n")
+
+		if msg.Stopped {
+			b.WriteString("> 🛑 You stopped the reply early\n\n")
+		}
+
+		desc := descsByMessageId[msg.Id]
+		if desc != nil && desc.MadePlan {
+			writeExportDescription(&b, desc)
+		}
+	}
+
+	return b.String()
+}
+
+func writeExportDescription(b *strings.Builder, desc *shared.ConvoMessageDescription) {
+	if desc.CommitMsg != "" {
+		fmt.Fprintf(b, "**Summary:** %s\n\n", desc.CommitMsg)
+	}
+
+	writeList := func(label string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		fmt.Fprintf(b, "**%s:**\n\n", label)
+		for _, item := range items {
+			fmt.Fprintf(b, "- `%s`\n", item)
+		}
+		b.WriteString("\n")
+	}
+
+	writeList("Files", desc.Files)
+	writeList("Removed", desc.RemovedFiles)
+
+	var moved []string
+	for _, move := range desc.MovedFiles {
+		moved = append(moved, move.From+"` → `"+move.To)
+	}
+	writeList("Moved", moved)
+
+	var commands []string
+	for _, command := range desc.Commands {
+		commands = append(commands, command.Command)
+	}
+	writeList("Commands", commands)
+
+	if desc.AppliedAt != nil {
+		fmt.Fprintf(b, "_Applied %s_\n\n", desc.AppliedAt.Local().Format("Mon Jan 2, 2006 3:04pm MST"))
+	}
+}
+
+func renderExportHtml(title, md string) (string, error) {
+	var body bytes.Buffer
+	err := goldmark.New(goldmark.WithExtensions(extension.GFM)).Convert([]byte(md), &body)
+	if err != nil {
+		return "", fmt.Errorf("error rendering html: %v", err)
+	}
+
+	return fmt.Sprintf(exportHtmlTemplate, html.EscapeString(title), body.String()), nil
+}
+
+const exportHtmlTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>%s</title>
+<style>
+body { max-width: 860px; margin: 2rem auto; padding: 0 1rem; font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; line-height: 1.5; color: #1f2328; }
+pre { background: #f6f8fa; padding: 1rem; overflow-x: auto; border-radius: 6px; }
+code { font-family: ui-monospace, SFMono-Regular, Menlo, monospace; font-size: 0.9em; }
+h2 { margin-top: 2rem; }
+hr { border: 0; border-top: 1px solid #d0d7de; }
+em { color: #656d76; }
+blockquote { margin: 0; padding-left: 1rem; border-left: 4px solid #d0d7de; color: #656d76; }
+</style>
+</head>
+<body>
+%s</body>
+</html>
+`
@@ -0,0 +1,29 @@
+//go:build !windows
+
+package lib
+
+import (
+	"os"
+	"syscall"
+)
+
+type fileOwner struct {
+	uid int
+	gid int
+}
+
+func getFileOwner(info os.FileInfo) *fileOwner {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	return &fileOwner{uid: int(stat.Uid), gid: int(stat.Gid)}
+}
+
+// apply sets the owner on a newly written file. A file written by the current user is already owned by them, so this only matters for files owned by someone else (e.g. when running as root or writing a file owned by a shared group). Failures are ignored since most users can't give files away.
+func (o *fileOwner) apply(path string) {
+	if o == nil || (o.uid == os.Getuid() && o.gid == os.Getgid()) {
+		return
+	}
+	os.Lchown(path, o.uid, o.gid)
+}
@@ -0,0 +1,14 @@
+//go:build windows
+
+package lib
+
+import "os"
+
+// ownership isn't carried over on windows, where files inherit their permissions from the directory they're written to
+type fileOwner struct{}
+
+func getFileOwner(info os.FileInfo) *fileOwner {
+	return nil
+}
+
+func (o *fileOwner) apply(path string) {}
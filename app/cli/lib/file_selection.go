@@ -0,0 +1,107 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"plandex/fs"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FileSelection is a project file or a range of its lines, given on the command line like main.go or main.go:10-20
+type FileSelection struct {
+	// relative to the project root
+	Path string
+	// lines are 1-indexed and inclusive. Start is 0 if the whole file is selected.
+	Start int
+	End   int
+}
+
+var lineRangeRegex = regexp.MustCompile(`^\d+(-\d+)?$`)
+
+// ParseFileSelection resolves a path relative to the current directory, with an optional line range after a colon
+func ParseFileSelection(arg string) (*FileSelection, error) {
+	sel := &FileSelection{}
+	target := arg
+
+	// split on the last colon only when a line range follows, so paths with colons still work
+	if idx := strings.LastIndex(target, ":"); idx > 0 && lineRangeRegex.MatchString(target[idx+1:]) {
+		var err error
+		sel.Start, sel.End, err = ParseLineRange(target[idx+1:])
+		if err != nil {
+			return nil, err
+		}
+		target = target[:idx]
+	}
+
+	absPath, err := filepath.Abs(target)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving path: %v", err)
+	}
+	sel.Path, err = filepath.Rel(fs.ProjectRoot, absPath)
+	if err != nil || strings.HasPrefix(sel.Path, "..") {
+		return nil, fmt.Errorf("%s isn't in the project", target)
+	}
+
+	return sel, nil
+}
+
+func ParseLineRange(s string) (int, int, error) {
+	parts := strings.SplitN(s, "-", 2)
+
+	start, err := strconv.Atoi(parts[0])
+	if err != nil || start < 1 {
+		return 0, 0, fmt.Errorf("invalid line range %s, expected a line like 10 or a range like 10-20", s)
+	}
+
+	end := start
+	if len(parts) == 2 {
+		end, err = strconv.Atoi(parts[1])
+		if err != nil || end < start {
+			return 0, 0, fmt.Errorf("invalid line range %s, expected a line like 10 or a range like 10-20", s)
+		}
+	}
+
+	return start, end, nil
+}
+
+// Read returns the selected content. A range that runs past the end of the file is cut off there.
+func (sel *FileSelection) Read() (string, error) {
+	bytes, err := os.ReadFile(fs.ProjectFilePath(sel.Path))
+	if err != nil {
+		return "", fmt.Errorf("error reading %s: %v", sel.Path, err)
+	}
+
+	content := string(bytes)
+	if sel.Start == 0 {
+		return content, nil
+	}
+
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	if sel.Start > len(lines) {
+		return "", fmt.Errorf("%s only has %d lines", sel.Path, len(lines))
+	}
+
+	return strings.Join(lines[sel.Start-1:min(sel.End, len(lines))], "\n"), nil
+}
+
+// Describe names the selection for a prompt, like 'lines 10-20 of main.go'
+func (sel *FileSelection) Describe() string {
+	if sel.Start == 0 {
+		return sel.Path
+	} else if sel.Start == sel.End {
+		return fmt.Sprintf("line %d of %s", sel.Start, sel.Path)
+	}
+	return fmt.Sprintf("lines %d-%d of %s", sel.Start, sel.End, sel.Path)
+}
+
+// fenceCode wraps code in a fence longer than any run of backticks in it, so it can't be closed early
+func fenceCode(content string) string {
+	fence := "```"
+	for strings.Contains(content, fence) {
+		fence += "`"
+	}
+	return fence + "\n" + content + "\n" + fence
+}
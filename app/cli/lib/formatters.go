@@ -0,0 +1,112 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"plandex/term"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// NormalizeFormatterExt accepts an extension with or without the leading dot
+func NormalizeFormatterExt(ext string) string {
+	ext = strings.ToLower(strings.TrimSpace(ext))
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
+
+// runFormatters runs the project's configured formatter on each written file with a matching extension. The file's path replaces {file} in the command, or is appended if there's no placeholder. A formatter that fails leaves its file as written and is reported, but doesn't fail the apply.
+func runFormatters(paths []string) {
+	settings, err := LoadProjectSettings()
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		color.New(color.Bold, term.ColorHiYellow).Printf("⚠️  Couldn't load the project's formatters, so files were left as written: %v\n\n", err)
+		return
+	}
+	if len(settings.Formatters) == 0 {
+		return
+	}
+	err = CheckProjectCommandsApproved(settings)
+	if err != nil {
+		color.New(color.Bold, term.ColorHiYellow).Printf("⚠️  Formatters weren't run, so files were left as written: %v\n\n", err)
+		return
+	}
+
+	sorted := append([]string{}, paths...)
+	sort.Strings(sorted)
+
+	type formatterErr struct {
+		path   string
+		err    error
+		output string
+	}
+	var errs []formatterErr
+	numFormatted := 0
+
+	for _, path := range sorted {
+		formatter := settings.Formatters[strings.ToLower(filepath.Ext(path))]
+		if formatter == "" {
+			continue
+		}
+
+		cmdLine := formatter
+		if strings.Contains(cmdLine, "{file}") {
+			cmdLine = strings.ReplaceAll(cmdLine, "{file}", shellQuote(path))
+		} else {
+			cmdLine += " " + shellQuote(path)
+		}
+
+		output, err := shellCommand(cmdLine).CombinedOutput()
+		if err != nil {
+			errs = append(errs, formatterErr{path: path, err: err, output: strings.TrimSpace(string(output))})
+			continue
+		}
+		numFormatted++
+	}
+
+	if numFormatted > 0 {
+		suffix := ""
+		if numFormatted > 1 {
+			suffix = "s"
+		}
+		fmt.Printf("🧹 Formatted %d file%s\n", numFormatted, suffix)
+	}
+
+	if len(errs) > 0 {
+		color.New(color.Bold, term.ColorHiYellow).Println("⚠️  Formatting failed for these files, so they were left as written:")
+		for _, e := range errs {
+			fmt.Printf("• %s: %v\n", e.path, e.err)
+			if e.output != "" {
+				fmt.Println(indentLines(e.output, "    "))
+			}
+		}
+	}
+
+	if numFormatted > 0 || len(errs) > 0 {
+		fmt.Println()
+	}
+}
+
+func shellQuote(s string) string {
+	if runtime.GOOS == "windows" {
+		return `"` + s + `"`
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func indentLines(s, indent string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = indent + line
+	}
+	return strings.Join(lines, "\n")
+}
@@ -1,9 +1,12 @@
 package lib
 
 import (
+	"bytes"
 	"fmt"
 	"log"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"sync"
 )
@@ -212,6 +215,41 @@ func GitCheckoutFile(path string) error {
 	return nil
 }
 
+// GitRecordMove records a file that was already moved on disk as a rename in the index, exactly as if it had been moved with `git mv`: the entry for from is moved to to, keeping its mode and content, so git status and the next commit show a rename and `git log --follow` keeps the file's history. Changes to the file's content stay unstaged. It does nothing if from isn't tracked.
+func GitRecordMove(repoDir, from, to string) error {
+	gitMutex.Lock()
+	defer gitMutex.Unlock()
+
+	res, err := exec.Command("git", "-C", repoDir, "ls-files", "--stage", "--", from).Output()
+	if err != nil {
+		return fmt.Errorf("error checking if %s is tracked: %v", from, err)
+	}
+
+	// <mode> <object> <stage>\t<path>
+	fields := strings.Fields(strings.SplitN(string(res), "\t", 2)[0])
+	if len(fields) < 2 {
+		return nil
+	}
+
+	// unlike other paths, a --cacheinfo path is relative to the repo root rather than the working directory
+	prefix, err := GitRepoPrefix(repoDir)
+	if err != nil {
+		return err
+	}
+
+	out, err := exec.Command("git", "-C", repoDir, "update-index", "--add", "--cacheinfo", fields[0]+","+fields[1]+","+prefix+filepath.ToSlash(to)).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("error adding %s to the index: %v, output: %s", to, err, string(out))
+	}
+
+	out, err = exec.Command("git", "-C", repoDir, "update-index", "--force-remove", "--", from).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("error removing %s from the index: %v, output: %s", from, err, string(out))
+	}
+
+	return nil
+}
+
 func parseConflictFiles(gitOutput string) []string {
 	var conflictFiles []string
 	lines := strings.Split(gitOutput, "\n")
@@ -233,3 +271,104 @@ func parseConflictFiles(gitOutput string) []string {
 	}
 	return conflictFiles
 }
+
+// GitReviewSource picks the changes to review. With Staged, the staged changes are reviewed. With a Base ref, the commits on the current branch since it split from Base are reviewed. Otherwise, all uncommitted changes to tracked files are.
+type GitReviewSource struct {
+	Staged bool
+	Base   string
+}
+
+func (src GitReviewSource) diffArgs() []string {
+	if src.Staged {
+		return []string{"--cached"}
+	} else if src.Base != "" {
+		return []string{src.Base + "...HEAD"}
+	}
+	return []string{"HEAD"}
+}
+
+// showArg is the object git show reads a changed file's reviewed content from. It's empty for the working tree, which is read from disk.
+func (src GitReviewSource) showArg(path string) string {
+	if src.Staged {
+		return ":" + path
+	} else if src.Base != "" {
+		return "HEAD:" + path
+	}
+	return ""
+}
+
+// GitRepoPrefix returns dir's path relative to the root of its repo, with a trailing slash, or "" at the root
+func GitRepoPrefix(dir string) (string, error) {
+	res, err := exec.Command("git", "-C", dir, "rev-parse", "--show-prefix").Output()
+	if err != nil {
+		return "", fmt.Errorf("error getting path in repo: %v", err)
+	}
+	return strings.TrimSpace(string(res)), nil
+}
+
+func GitStagedDiff(repoDir string) (string, error) {
+	return GitReviewDiff(repoDir, GitReviewSource{Staged: true})
+}
+
+func GitReviewDiff(repoDir string, src GitReviewSource) (string, error) {
+	gitMutex.Lock()
+	defer gitMutex.Unlock()
+
+	args := append([]string{"-C", repoDir, "diff", "--no-color"}, src.diffArgs()...)
+	res, err := exec.Command("git", args...).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("error getting diff: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("error getting diff: %v", err)
+	}
+
+	return string(res), nil
+}
+
+// files larger than this aren't included as review context
+const maxReviewFileBytes = 100 * 1024
+
+// GitReviewFiles returns the reviewed content of each added or modified file, by path relative to the repo root. Binary and very large files are skipped.
+func GitReviewFiles(repoDir string, src GitReviewSource) (map[string]string, error) {
+	gitMutex.Lock()
+	defer gitMutex.Unlock()
+
+	args := append([]string{"-C", repoDir, "diff", "--name-only", "--diff-filter=ACMR", "-z"}, src.diffArgs()...)
+	res, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("error listing changed files: %v", err)
+	}
+
+	// the paths are relative to the repo root even when repoDir is a subdirectory of it
+	rootRes, err := exec.Command("git", "-C", repoDir, "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return nil, fmt.Errorf("error getting repo root: %v", err)
+	}
+	repoRoot := strings.TrimSpace(string(rootRes))
+
+	files := map[string]string{}
+	for _, path := range strings.Split(string(res), "\x00") {
+		if path == "" {
+			continue
+		}
+
+		var content []byte
+		if showArg := src.showArg(path); showArg != "" {
+			content, err = exec.Command("git", "-C", repoDir, "show", showArg).Output()
+		} else {
+			content, err = os.ReadFile(filepath.Join(repoRoot, path))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading changed %s: %v", path, err)
+		}
+
+		if len(content) > maxReviewFileBytes || bytes.IndexByte(content, 0) != -1 {
+			continue
+		}
+
+		files[path] = string(content)
+	}
+
+	return files, nil
+}
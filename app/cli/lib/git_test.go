@@ -0,0 +1,117 @@
+package lib
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGitReviewFilesFromSubdirectory(t *testing.T) {
+	repo := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		out, err := exec.Command("git", append([]string{"-C", repo, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v, output: %s", args, err, out)
+		}
+	}
+	write := func(path, content string) {
+		t.Helper()
+		err := os.MkdirAll(filepath.Dir(filepath.Join(repo, path)), 0755)
+		if err == nil {
+			err = os.WriteFile(filepath.Join(repo, path), []byte(content), 0644)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init", "-q")
+	write("app/cli/main.go", "package main\n")
+	write("README.md", "# app\n")
+	git("add", ".")
+	git("commit", "-qm", "init")
+
+	write("app/cli/main.go", "package main\n\n// edited\n")
+	write("README.md", "# app\n\nedited\n")
+
+	project := filepath.Join(repo, "app", "cli")
+
+	prefix, err := GitRepoPrefix(project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prefix != "app/cli/" {
+		t.Errorf("expected prefix app/cli/, got %q", prefix)
+	}
+
+	files, err := GitReviewFiles(project, GitReviewSource{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files["app/cli/main.go"] != "package main\n\n// edited\n" {
+		t.Errorf("expected the changed file in the project by its repo path, got %v", files)
+	}
+	if files["README.md"] != "# app\n\nedited\n" {
+		t.Errorf("expected the changed file outside the project by its repo path, got %v", files)
+	}
+
+	prefix, err = GitRepoPrefix(repo)
+	if err != nil || prefix != "" {
+		t.Errorf("expected no prefix at the repo root, got %q, %v", prefix, err)
+	}
+}
+
+func TestGitRecordMove(t *testing.T) {
+	repo := t.TempDir()
+	git := func(args ...string) string {
+		t.Helper()
+		out, err := exec.Command("git", append([]string{"-C", repo, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v, output: %s", args, err, out)
+		}
+		return string(out)
+	}
+
+	project := filepath.Join(repo, "app")
+	err := os.MkdirAll(filepath.Join(project, "lib"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(project, "old.go"), []byte("package app\n"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	git("init", "-q")
+	git("add", ".")
+	git("commit", "-qm", "init")
+
+	// the apply has already moved and edited the file on disk
+	err = os.Rename(filepath.Join(project, "old.go"), filepath.Join(project, "lib", "new.go"))
+	if err == nil {
+		err = os.WriteFile(filepath.Join(project, "lib", "new.go"), []byte("package app\n\n// edited\n"), 0755)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = GitRecordMove(project, "old.go", "lib/new.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status := git("status", "--porcelain")
+	if status != "RM app/old.go -> app/lib/new.go\n" {
+		t.Errorf("expected a staged rename with the edit unstaged, got %q", status)
+	}
+	if mode := git("ls-files", "--stage", "--", "app/lib/new.go"); !strings.HasPrefix(mode, "100755 ") {
+		t.Errorf("expected the moved file to keep its mode, got %q", mode)
+	}
+
+	err = GitRecordMove(project, "untracked.go", "lib/untracked.go")
+	if err != nil {
+		t.Errorf("expected an untracked file to be skipped, got %v", err)
+	}
+}
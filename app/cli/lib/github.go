@@ -0,0 +1,161 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"plandex/fs"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const githubApiHost = "https://api.github.com"
+
+var githubClient = &http.Client{Timeout: 30 * time.Second}
+
+type GithubRepo struct {
+	Owner string
+	Name  string
+}
+
+// matches the https, ssh, and scp-style remote urls github gives out
+var githubRemoteRegex = regexp.MustCompile(`github\.com[:/]([^/]+)/([^/]+?)(?:\.git)?/?$`)
+
+func GetGithubToken() string {
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		token = os.Getenv("GH_TOKEN")
+	}
+	return token
+}
+
+// GetGithubRepo returns the github repo for a remote of the project's git repo
+func GetGithubRepo(remote string) (*GithubRepo, error) {
+	res, err := exec.Command("git", "-C", fs.ProjectRoot, "remote", "get-url", remote).Output()
+	if err != nil {
+		return nil, fmt.Errorf("error getting url for git remote %s: %v", remote, err)
+	}
+
+	url := strings.TrimSpace(string(res))
+	matches := githubRemoteRegex.FindStringSubmatch(url)
+	if matches == nil {
+		return nil, fmt.Errorf("git remote %s (%s) isn't a github repository", remote, url)
+	}
+
+	return &GithubRepo{Owner: matches[1], Name: matches[2]}, nil
+}
+
+func GitPushBranch(remote, branch string) error {
+	gitMutex.Lock()
+	defer gitMutex.Unlock()
+
+	res, err := exec.Command("git", "-C", fs.ProjectRoot, "push", "--set-upstream", remote, branch).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("error pushing %s to %s: %v, output: %s", branch, remote, err, string(res))
+	}
+
+	return nil
+}
+
+func GetGithubDefaultBranch(repo *GithubRepo, token string) (string, error) {
+	var res struct {
+		DefaultBranch string `json:"default_branch"`
+	}
+
+	err := githubRequest("GET", fmt.Sprintf("/repos/%s/%s", repo.Owner, repo.Name), token, nil, &res)
+	if err != nil {
+		return "", err
+	}
+
+	return res.DefaultBranch, nil
+}
+
+type GithubPullRequest struct {
+	Number  int    `json:"number"`
+	HtmlUrl string `json:"html_url"`
+}
+
+func CreateGithubPullRequest(repo *GithubRepo, token, head, base, title, body string) (*GithubPullRequest, error) {
+	req := map[string]string{
+		"title": title,
+		"body":  body,
+		"head":  head,
+		"base":  base,
+	}
+
+	var pr GithubPullRequest
+	err := githubRequest("POST", fmt.Sprintf("/repos/%s/%s/pulls", repo.Owner, repo.Name), token, req, &pr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pr, nil
+}
+
+func githubRequest(method, path, token string, body interface{}, res interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		bodyBytes, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("error marshalling github request: %v", err)
+		}
+		reqBody = bytes.NewReader(bodyBytes)
+	}
+
+	req, err := http.NewRequest(method, githubApiHost+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("error creating github request: %v", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := githubClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending github request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	resBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading github response: %v", err)
+	}
+
+	if resp.StatusCode >= 400 {
+		// github explains validation failures, like a pr that already exists, in the errors list
+		var errRes struct {
+			Message string `json:"message"`
+			Errors  []struct {
+				Message string `json:"message"`
+			} `json:"errors"`
+		}
+		json.Unmarshal(resBytes, &errRes)
+
+		msg := errRes.Message
+		for _, e := range errRes.Errors {
+			if e.Message != "" {
+				msg += ": " + e.Message
+			}
+		}
+		if msg == "" {
+			msg = string(resBytes)
+		}
+		return fmt.Errorf("github returned %d: %s", resp.StatusCode, msg)
+	}
+
+	err = json.Unmarshal(resBytes, res)
+	if err != nil {
+		return fmt.Errorf("error unmarshalling github response: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,151 @@
+package lib
+
+import (
+	"fmt"
+	"plandex/api"
+	"plandex/term"
+	"plandex/types"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+)
+
+type GithubIssue struct {
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	Body    string `json:"body"`
+	HtmlUrl string `json:"html_url"`
+	State   string `json:"state"`
+	User    struct {
+		Login string `json:"login"`
+	} `json:"user"`
+
+	// filled in separately from the comments endpoint
+	Comments []*GithubIssueComment `json:"-"`
+}
+
+type GithubIssueComment struct {
+	Body string `json:"body"`
+	User struct {
+		Login string `json:"login"`
+	} `json:"user"`
+}
+
+// the most comments loaded with an issue; long threads are cut off at the start, which usually has the most detail
+const maxGithubIssueComments = 100
+
+var githubIssueUrlRegex = regexp.MustCompile(`github\.com/([^/]+)/([^/]+)/issues/(\d+)`)
+
+// ParseGithubIssueRef accepts an issue number for the project's origin remote, or a full issue url
+func ParseGithubIssueRef(ref string) (*GithubRepo, int, error) {
+	ref = strings.TrimPrefix(strings.TrimSpace(ref), "#")
+
+	if matches := githubIssueUrlRegex.FindStringSubmatch(ref); matches != nil {
+		number, _ := strconv.Atoi(matches[3])
+		return &GithubRepo{Owner: matches[1], Name: matches[2]}, number, nil
+	}
+
+	number, err := strconv.Atoi(ref)
+	if err != nil || number <= 0 {
+		return nil, 0, fmt.Errorf("%s isn't an issue number or url", ref)
+	}
+
+	repo, err := GetGithubRepo("origin")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return repo, number, nil
+}
+
+func GetGithubIssue(repo *GithubRepo, token string, number int) (*GithubIssue, error) {
+	var issue GithubIssue
+	err := githubRequest("GET", fmt.Sprintf("/repos/%s/%s/issues/%d", repo.Owner, repo.Name, number), token, nil, &issue)
+	if err != nil {
+		return nil, err
+	}
+
+	err = githubRequest("GET", fmt.Sprintf("/repos/%s/%s/issues/%d/comments?per_page=%d", repo.Owner, repo.Name, number, maxGithubIssueComments), token, nil, &issue.Comments)
+	if err != nil {
+		return nil, err
+	}
+
+	return &issue, nil
+}
+
+func CreateGithubIssueComment(repo *GithubRepo, token string, number int, body string) error {
+	var res struct {
+		Id int `json:"id"`
+	}
+	return githubRequest("POST", fmt.Sprintf("/repos/%s/%s/issues/%d/comments", repo.Owner, repo.Name, number), token, map[string]string{"body": body}, &res)
+}
+
+func (issue *GithubIssue) ContextBody() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "GitHub issue #%d: %s\n", issue.Number, issue.Title)
+	fmt.Fprintf(&b, "%s\n", issue.HtmlUrl)
+	fmt.Fprintf(&b, "State: %s, opened by @%s\n\n", issue.State, issue.User.Login)
+
+	body := strings.TrimSpace(issue.Body)
+	if body == "" {
+		body = "(no description)"
+	}
+	b.WriteString(body + "\n")
+
+	for _, comment := range issue.Comments {
+		fmt.Fprintf(&b, "\n---\nComment by @%s:\n\n%s\n", comment.User.Login, strings.TrimSpace(comment.Body))
+	}
+
+	return b.String()
+}
+
+// MustLoadGithubIssue fetches an issue with its comments, loads it into the plan's context as a note, and links it to the plan, returning the issue so it can be used for the prompt
+func MustLoadGithubIssue(ref string) *GithubIssue {
+	repo, number, err := ParseGithubIssueRef(ref)
+	if err != nil {
+		term.OutputErrorAndExit("%v", err)
+	}
+
+	term.StartSpinner(fmt.Sprintf("🐙 Loading issue #%d...", number))
+
+	// public repos can be read without a token, though with a much lower rate limit
+	issue, err := GetGithubIssue(repo, GetGithubToken(), number)
+	if err != nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("Error getting issue #%d from %s/%s: %v", number, repo.Owner, repo.Name, err)
+	}
+
+	res, apiErr := api.Client.LoadContext(CurrentPlanId, CurrentBranch, shared.LoadContextRequest{
+		{
+			ContextType: shared.ContextNoteType,
+			Name:        fmt.Sprintf("issue-%d", issue.Number),
+			Body:        issue.ContextBody(),
+		},
+	})
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Failed to load issue into context: %v", apiErr.Msg)
+	}
+
+	if res.MaxTokensExceeded {
+		overage := res.TotalTokens - res.MaxTokens
+		term.OutputErrorAndExit("Loading the issue would add %d 🪙 and exceed token limit (%d) by %d 🪙", res.TokensAdded, res.MaxTokens, overage)
+	}
+
+	err = RecordPlanIssue(CurrentPlanId, &types.PlanIssue{
+		Tracker: TrackerGithub,
+		Key:     fmt.Sprintf("%s/%s#%d", repo.Owner, repo.Name, issue.Number),
+		Url:     issue.HtmlUrl,
+	})
+	if err != nil {
+		fmt.Printf("⚠️  Failed to link the issue to the plan: %v\n", err)
+	}
+
+	fmt.Printf("✅ Loaded issue #%d into context: %s\n\n", issue.Number, issue.Title)
+
+	return issue
+}
@@ -0,0 +1,71 @@
+package lib
+
+import (
+	"fmt"
+	"plandex/api"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// ParseHistoryTime parses a --since or --until value: a time before now like 2h or 3d, a date like 2024-05-01 in local time, or an RFC 3339 time
+func ParseHistoryTime(s string) (time.Time, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err == nil && n >= 0 {
+			return time.Now().AddDate(0, 0, -n), nil
+		}
+	}
+
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return time.Now().Add(-d), nil
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time %s, expected a time ago like 2h or 3d, a date like 2024-05-01, or an RFC 3339 time", s)
+}
+
+// ListConvoHistory gets the messages in the current plan's conversation that match q, oldest first. If last is above 0, only the last that many are returned.
+func ListConvoHistory(q shared.HistoryQuery, last int) ([]*shared.ConvoMessage, *shared.ApiError) {
+	if last > 0 {
+		q.Desc = true
+		q.Limit = min(last, shared.MaxHistoryPageSize)
+	} else {
+		q.Limit = shared.MaxHistoryPageSize
+	}
+
+	var messages []*shared.ConvoMessage
+	for {
+		page, apiErr := api.Client.ListConvoMessages(CurrentPlanId, CurrentBranch, q)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+
+		messages = append(messages, page.Messages...)
+
+		if page.NextOffset == 0 || (last > 0 && len(messages) >= last) {
+			break
+		}
+		q.Offset = page.NextOffset
+		if last > 0 {
+			q.Limit = min(last-len(messages), shared.MaxHistoryPageSize)
+		}
+	}
+
+	if q.Desc {
+		for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+			messages[i], messages[j] = messages[j], messages[i]
+		}
+	}
+
+	return messages, nil
+}
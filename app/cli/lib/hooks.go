@@ -0,0 +1,81 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"plandex/term"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+type ApplyHook string
+
+const (
+	ApplyHookPre  ApplyHook = "pre-apply"
+	ApplyHookPost ApplyHook = "post-apply"
+)
+
+var ApplyHooks = []ApplyHook{ApplyHookPre, ApplyHookPost}
+
+type applyHookParams struct {
+	hook    ApplyHook
+	planId  string
+	branch  string
+	files   []string
+	removed []string
+}
+
+// runApplyHooks runs the project's hooks for a point in the apply, stopping at the first that fails. Each hook's output goes straight to the terminal. Paths are newline-separated and relative to the project root:
+//
+//	PLANDEX_HOOK          pre-apply or post-apply
+//	PLANDEX_PLAN_ID       the plan being applied
+//	PLANDEX_BRANCH        the plan's branch
+//	PLANDEX_FILES         files the apply writes
+//	PLANDEX_REMOVED_FILES files the apply removes
+func runApplyHooks(params applyHookParams) error {
+	// fails closed, since a pre-apply hook may be what's guarding the apply
+	settings, err := LoadProjectSettings()
+	if err != nil {
+		return fmt.Errorf("couldn't load %s hooks: %v", params.hook, err)
+	}
+
+	hooks := settings.PreApplyHooks
+	if params.hook == ApplyHookPost {
+		hooks = settings.PostApplyHooks
+	}
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	err = CheckProjectCommandsApproved(settings)
+	if err != nil {
+		return fmt.Errorf("%s hooks weren't run: %v", params.hook, err)
+	}
+
+	env := append(os.Environ(),
+		"PLANDEX_HOOK="+string(params.hook),
+		"PLANDEX_PLAN_ID="+params.planId,
+		"PLANDEX_BRANCH="+params.branch,
+		"PLANDEX_FILES="+strings.Join(params.files, "\n"),
+		"PLANDEX_REMOVED_FILES="+strings.Join(params.removed, "\n"),
+	)
+
+	for _, hook := range hooks {
+		color.New(color.Bold, term.ColorHiCyan).Printf("🪝 Running %s hook: ", params.hook)
+		fmt.Println(hook)
+
+		cmd := shellCommand(hook)
+		cmd.Env = env
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+
+		err := cmd.Run()
+		fmt.Println()
+		if err != nil {
+			return fmt.Errorf("%s hook '%s' failed: %v", params.hook, hook, err)
+		}
+	}
+
+	return nil
+}
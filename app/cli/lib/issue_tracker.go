@@ -0,0 +1,299 @@
+package lib
+
+import (
+	"fmt"
+	"log"
+	"plandex/api"
+	"plandex/term"
+	"plandex/types"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+)
+
+const (
+	TrackerGithub = "github"
+	TrackerJira   = "jira"
+	TrackerLinear = "linear"
+)
+
+// TrackerIssue is a Jira or Linear issue
+type TrackerIssue struct {
+	Tracker string
+	// like ENG-123
+	Key string
+	// linear's internal id
+	Id       string
+	Title    string
+	Url      string
+	State    string
+	Author   string
+	Body     string
+	Comments []*TrackerIssueComment
+}
+
+type TrackerIssueComment struct {
+	Author string
+	Body   string
+}
+
+var jiraBrowseUrlRegex = regexp.MustCompile(`^(https?://.+?)/browse/([A-Za-z][A-Za-z0-9_]*-\d+)`)
+var linearIssueUrlRegex = regexp.MustCompile(`linear\.app/[^/]+/issue/([A-Za-z][A-Za-z0-9_]*-\d+)`)
+var issueKeyRegex = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*-\d+$`)
+
+// IsTrackerIssueRef is whether an --issue value refers to a Jira or Linear issue rather than a GitHub one: a key like ENG-123, a Jira or Linear url, or a key prefixed with jira: or linear:
+func IsTrackerIssueRef(ref string) bool {
+	ref = strings.TrimSpace(ref)
+	return strings.HasPrefix(ref, TrackerJira+":") ||
+		strings.HasPrefix(ref, TrackerLinear+":") ||
+		jiraBrowseUrlRegex.MatchString(ref) ||
+		linearIssueUrlRegex.MatchString(ref) ||
+		issueKeyRegex.MatchString(ref)
+}
+
+// parseTrackerIssueRef works out which tracker a ref is for. A bare key goes to whichever tracker is configured, and needs a prefix if both are.
+func parseTrackerIssueRef(ref string) (tracker, jiraBaseUrl, key string, err error) {
+	ref = strings.TrimSpace(ref)
+
+	if k, ok := strings.CutPrefix(ref, TrackerJira+":"); ok {
+		tracker, key = TrackerJira, k
+	} else if k, ok := strings.CutPrefix(ref, TrackerLinear+":"); ok {
+		tracker, key = TrackerLinear, k
+	} else if matches := linearIssueUrlRegex.FindStringSubmatch(ref); matches != nil {
+		tracker, key = TrackerLinear, matches[1]
+	} else if matches := jiraBrowseUrlRegex.FindStringSubmatch(ref); matches != nil {
+		tracker, key = TrackerJira, matches[2]
+		jiraBaseUrl, err = jiraSiteFor(matches[1])
+		if err != nil {
+			return "", "", "", err
+		}
+	} else if issueKeyRegex.MatchString(ref) {
+		key = ref
+		hasJira, hasLinear := GetJiraBaseUrl() != "", GetLinearApiKey() != ""
+		switch {
+		case hasJira && hasLinear:
+			return "", "", "", fmt.Errorf("both Jira and Linear are set up, so %s is ambiguous. Use jira:%s or linear:%s", ref, ref, ref)
+		case hasJira:
+			tracker = TrackerJira
+		case hasLinear:
+			tracker = TrackerLinear
+		default:
+			return "", "", "", fmt.Errorf("to load %s, set JIRA_BASE_URL and JIRA_API_TOKEN for Jira, or LINEAR_API_KEY for Linear", ref)
+		}
+	} else {
+		return "", "", "", fmt.Errorf("%s isn't a Jira or Linear issue key or url", ref)
+	}
+
+	key = strings.ToUpper(strings.TrimSpace(key))
+	if !issueKeyRegex.MatchString(key) {
+		return "", "", "", fmt.Errorf("%s isn't an issue key like ENG-123", key)
+	}
+
+	if tracker == TrackerJira && jiraBaseUrl == "" {
+		jiraBaseUrl = GetJiraBaseUrl()
+		if jiraBaseUrl == "" {
+			return "", "", "", fmt.Errorf("set JIRA_BASE_URL to your Jira site, like https://acme.atlassian.net, to load %s", key)
+		}
+	}
+
+	return tracker, jiraBaseUrl, key, nil
+}
+
+func GetTrackerIssue(ref string) (*TrackerIssue, error) {
+	tracker, jiraBaseUrl, key, err := parseTrackerIssueRef(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	if tracker == TrackerJira {
+		return GetJiraIssue(jiraBaseUrl, key)
+	}
+	return GetLinearIssue(key)
+}
+
+func (issue *TrackerIssue) TrackerName() string {
+	if issue.Tracker == TrackerJira {
+		return "Jira"
+	}
+	return "Linear"
+}
+
+func (issue *TrackerIssue) ContextName() string {
+	return "issue-" + issue.Key
+}
+
+func (issue *TrackerIssue) ContextBody() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s issue %s: %s\n", issue.TrackerName(), issue.Key, issue.Title)
+	fmt.Fprintf(&b, "%s\n", issue.Url)
+	fmt.Fprintf(&b, "State: %s", issue.State)
+	if issue.Author != "" {
+		fmt.Fprintf(&b, ", opened by %s", issue.Author)
+	}
+	b.WriteString("\n\n")
+
+	body := strings.TrimSpace(issue.Body)
+	if body == "" {
+		body = "(no description)"
+	}
+	b.WriteString(body + "\n")
+
+	for _, comment := range issue.Comments {
+		author := comment.Author
+		if author == "" {
+			author = "an integration"
+		}
+		fmt.Fprintf(&b, "\n---\nComment by %s:\n\n%s\n", author, strings.TrimSpace(comment.Body))
+	}
+
+	return b.String()
+}
+
+// MustLoadTrackerIssue fetches a Jira or Linear issue with its comments, loads it into the plan's context as a note, and links it to the plan so --comment-issue can report back to it
+func MustLoadTrackerIssue(ref string) *TrackerIssue {
+	term.StartSpinner("🎫 Loading issue...")
+
+	issue, err := GetTrackerIssue(ref)
+	if err != nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("Error getting issue %s: %v", ref, err)
+	}
+
+	res, apiErr := api.Client.LoadContext(CurrentPlanId, CurrentBranch, shared.LoadContextRequest{
+		{
+			ContextType: shared.ContextNoteType,
+			Name:        issue.ContextName(),
+			Body:        issue.ContextBody(),
+		},
+	})
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Failed to load issue into context: %v", apiErr.Msg)
+	}
+
+	if res.MaxTokensExceeded {
+		overage := res.TotalTokens - res.MaxTokens
+		term.OutputErrorAndExit("Loading the issue would add %d 🪙 and exceed token limit (%d) by %d 🪙", res.TokensAdded, res.MaxTokens, overage)
+	}
+
+	err = RecordPlanIssue(CurrentPlanId, &types.PlanIssue{
+		Tracker: issue.Tracker,
+		Key:     issue.Key,
+		Id:      issue.Id,
+		Url:     issue.Url,
+	})
+	if err != nil {
+		fmt.Printf("⚠️  Failed to link the issue to the plan: %v\n", err)
+	}
+
+	fmt.Printf("✅ Loaded %s issue %s into context: %s\n\n", issue.TrackerName(), issue.Key, issue.Title)
+
+	return issue
+}
+
+// MustCommentOnPlanIssues posts a summary of the plan's applied changes, with a link to its latest pull request, to each issue linked to the plan. Failures are reported without exiting, since the changes they describe were already made.
+func MustCommentOnPlanIssues(planId, branch string) {
+	info, err := LoadPlanInfo(planId)
+	if err != nil {
+		term.OutputErrorAndExit("Error loading plan info: %v", err)
+	}
+
+	if len(info.Issues) == 0 {
+		fmt.Println("🤷‍♂️ No issues are linked to this plan to comment on. Link one by sending a prompt with 'plandex tell --issue'.")
+		return
+	}
+
+	plan, apiErr := api.Client.GetPlan(planId)
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting plan: %v", apiErr.Msg)
+	}
+
+	currentPlanState, apiErr := api.Client.GetCurrentPlanState(planId, branch)
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting current plan state: %v", apiErr.Msg)
+	}
+
+	var changes []string
+	for _, desc := range currentPlanState.ConvoMessageDescriptions {
+		if desc.AppliedAt != nil && desc.CommitMsg != "" {
+			changes = append(changes, desc.CommitMsg)
+		}
+	}
+
+	// the comment still goes out without a link if the plan's pull requests can't be loaded
+	var prUrl string
+	prs, apiErr := api.Client.ListPlanPullRequests(planId)
+	if apiErr != nil {
+		log.Printf("Error listing pull requests for plan %s: %v\n", planId, apiErr.Msg)
+	} else if len(prs) > 0 {
+		prUrl = prs[len(prs)-1].Url
+	}
+
+	for _, issue := range info.Issues {
+		body := getIssueComment(issue.Tracker, plan.Name, changes, prUrl)
+
+		var err error
+		switch issue.Tracker {
+		case TrackerJira:
+			baseUrl, _, _ := strings.Cut(issue.Url, "/browse/")
+			err = CreateJiraComment(baseUrl, issue.Key, body)
+		case TrackerLinear:
+			err = CreateLinearComment(issue.Id, body)
+		case TrackerGithub:
+			err = commentOnGithubIssue(issue.Key, body)
+		default:
+			err = fmt.Errorf("unknown tracker %s", issue.Tracker)
+		}
+
+		if err != nil {
+			fmt.Printf("⚠️  Failed to comment on %s: %v\n", issue.Key, err)
+		} else {
+			fmt.Printf("💬 Commented on %s\n", issue.Key)
+		}
+	}
+}
+
+// getIssueComment writes the comment in markdown, or in wiki markup for Jira
+func getIssueComment(tracker, planName string, changes []string, prUrl string) string {
+	bullet, code := "- ", "`%s`"
+	if tracker == TrackerJira {
+		bullet, code = "* ", "{{%s}}"
+	}
+
+	var b strings.Builder
+
+	if len(changes) > 0 {
+		fmt.Fprintf(&b, "Plandex applied these changes from the plan "+code+":\n\n", planName)
+		for _, change := range changes {
+			b.WriteString(bullet + strings.ReplaceAll(strings.TrimSpace(change), "\n", "\n  ") + "\n")
+		}
+	} else {
+		fmt.Fprintf(&b, "Plandex applied changes from the plan "+code+".\n", planName)
+	}
+
+	if prUrl != "" {
+		fmt.Fprintf(&b, "\nPull request: %s\n", prUrl)
+	}
+
+	return b.String()
+}
+
+func commentOnGithubIssue(key, body string) error {
+	repoName, numberStr, ok := strings.Cut(key, "#")
+	owner, name, repoOk := strings.Cut(repoName, "/")
+	number, err := strconv.Atoi(numberStr)
+	if !ok || !repoOk || err != nil {
+		return fmt.Errorf("invalid github issue %s", key)
+	}
+
+	token := GetGithubToken()
+	if token == "" {
+		return fmt.Errorf("set GITHUB_TOKEN or GH_TOKEN to comment on GitHub issues")
+	}
+
+	return CreateGithubIssueComment(&GithubRepo{Owner: owner, Name: name}, token, number, body)
+}
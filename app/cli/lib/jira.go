@@ -0,0 +1,170 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+var jiraClient = &http.Client{Timeout: 30 * time.Second}
+
+// GetJiraBaseUrl is the Jira site issues are loaded from, like https://acme.atlassian.net
+func GetJiraBaseUrl() string {
+	return strings.TrimSuffix(os.Getenv("JIRA_BASE_URL"), "/")
+}
+
+// jiraSiteFor checks that a Jira url is on the JIRA_BASE_URL site and returns the configured base url, so the api token is never sent to a host taken from an issue link
+func jiraSiteFor(issueUrl string) (string, error) {
+	baseUrl := GetJiraBaseUrl()
+	if baseUrl == "" {
+		return "", fmt.Errorf("set JIRA_BASE_URL to your Jira site to load %s", issueUrl)
+	}
+
+	base, err := url.Parse(baseUrl)
+	if err != nil || base.Host == "" {
+		return "", fmt.Errorf("JIRA_BASE_URL %s isn't a valid url", baseUrl)
+	}
+
+	u, err := url.Parse(issueUrl)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("%s isn't a valid url", issueUrl)
+	}
+
+	if !strings.EqualFold(u.Scheme, base.Scheme) || !strings.EqualFold(u.Host, base.Host) {
+		return "", fmt.Errorf("%s isn't on the Jira site set by JIRA_BASE_URL (%s)", issueUrl, baseUrl)
+	}
+
+	return baseUrl, nil
+}
+
+type jiraIssue struct {
+	Key    string `json:"key"`
+	Fields struct {
+		Summary     string `json:"summary"`
+		Description string `json:"description"`
+		Status      struct {
+			Name string `json:"name"`
+		} `json:"status"`
+		Reporter struct {
+			DisplayName string `json:"displayName"`
+		} `json:"reporter"`
+		Comment struct {
+			Comments []struct {
+				Body   string `json:"body"`
+				Author struct {
+					DisplayName string `json:"displayName"`
+				} `json:"author"`
+			} `json:"comments"`
+		} `json:"comment"`
+	} `json:"fields"`
+}
+
+// GetJiraIssue loads an issue with its comments. Version 2 of the REST API is used since it returns descriptions and comments as text rather than Atlassian's document format.
+func GetJiraIssue(baseUrl, key string) (*TrackerIssue, error) {
+	var res jiraIssue
+	err := jiraRequest("GET", baseUrl, fmt.Sprintf("/rest/api/2/issue/%s?fields=summary,description,status,reporter,comment", url.PathEscape(key)), nil, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	issue := &TrackerIssue{
+		Tracker: TrackerJira,
+		Key:     res.Key,
+		Title:   res.Fields.Summary,
+		Url:     fmt.Sprintf("%s/browse/%s", baseUrl, res.Key),
+		State:   res.Fields.Status.Name,
+		Author:  res.Fields.Reporter.DisplayName,
+		Body:    res.Fields.Description,
+	}
+	for _, comment := range res.Fields.Comment.Comments {
+		issue.Comments = append(issue.Comments, &TrackerIssueComment{Author: comment.Author.DisplayName, Body: comment.Body})
+	}
+
+	return issue, nil
+}
+
+// CreateJiraComment adds a comment in Jira's wiki markup
+func CreateJiraComment(baseUrl, key, body string) error {
+	var res struct {
+		Id string `json:"id"`
+	}
+	return jiraRequest("POST", baseUrl, fmt.Sprintf("/rest/api/2/issue/%s/comment", url.PathEscape(key)), map[string]string{"body": body}, &res)
+}
+
+// jiraRequest authenticates with JIRA_EMAIL and JIRA_API_TOKEN for Jira Cloud, or with JIRA_API_TOKEN alone as a personal access token for Jira Server and Data Center
+func jiraRequest(method, baseUrl, path string, body any, res any) error {
+	token := os.Getenv("JIRA_API_TOKEN")
+	if token == "" {
+		return fmt.Errorf("set JIRA_API_TOKEN, and JIRA_EMAIL for Jira Cloud, to load Jira issues")
+	}
+
+	baseUrl, err := jiraSiteFor(baseUrl)
+	if err != nil {
+		return err
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		bodyBytes, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("error marshalling jira request: %v", err)
+		}
+		reqBody = bytes.NewReader(bodyBytes)
+	}
+
+	req, err := http.NewRequest(method, baseUrl+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("error creating jira request: %v", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if email := os.Getenv("JIRA_EMAIL"); email != "" {
+		req.SetBasicAuth(email, token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := jiraClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending jira request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	resBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading jira response: %v", err)
+	}
+
+	if resp.StatusCode >= 400 {
+		var errRes struct {
+			ErrorMessages []string          `json:"errorMessages"`
+			Errors        map[string]string `json:"errors"`
+		}
+		json.Unmarshal(resBytes, &errRes)
+
+		msgs := errRes.ErrorMessages
+		for field, msg := range errRes.Errors {
+			msgs = append(msgs, field+": "+msg)
+		}
+		msg := strings.Join(msgs, ", ")
+		if msg == "" {
+			msg = strings.TrimSpace(string(resBytes))
+		}
+		return fmt.Errorf("jira returned %d: %s", resp.StatusCode, msg)
+	}
+
+	err = json.Unmarshal(resBytes, res)
+	if err != nil {
+		return fmt.Errorf("error unmarshalling jira response: %v", err)
+	}
+
+	return nil
+}
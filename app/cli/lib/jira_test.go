@@ -0,0 +1,40 @@
+package lib
+
+import "testing"
+
+func TestJiraSiteFor(t *testing.T) {
+	t.Setenv("JIRA_BASE_URL", "https://acme.atlassian.net/")
+
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{"https://acme.atlassian.net", false},
+		{"https://ACME.atlassian.net", false},
+		{"https://evil.example.com", true},
+		{"https://acme.atlassian.net.evil.example.com", true},
+		{"http://acme.atlassian.net", true},
+		{"https://acme.atlassian.net:8443", true},
+	}
+
+	for _, tt := range tests {
+		baseUrl, err := jiraSiteFor(tt.url)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("jiraSiteFor(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+			continue
+		}
+		if err == nil && baseUrl != "https://acme.atlassian.net" {
+			t.Errorf("jiraSiteFor(%q) = %q, want the configured base url", tt.url, baseUrl)
+		}
+	}
+
+	_, _, _, err := parseTrackerIssueRef("https://evil.example.com/browse/ENG-1")
+	if err == nil {
+		t.Error("expected an issue url on another host to be rejected")
+	}
+
+	t.Setenv("JIRA_BASE_URL", "")
+	if _, err := jiraSiteFor("https://acme.atlassian.net"); err == nil {
+		t.Error("expected an error without JIRA_BASE_URL")
+	}
+}
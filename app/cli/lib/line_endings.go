@@ -0,0 +1,38 @@
+package lib
+
+import "strings"
+
+// matchLineEndings converts updated to use the same line endings as original (CRLF or LF, whichever the original mostly uses) and to end with a newline only if the original did. Models almost always write LF without caring about the final newline, so without this every line of a CRLF file would show up as changed.
+func matchLineEndings(original, updated string) string {
+	if original == "" {
+		return updated
+	}
+
+	numCRLF := strings.Count(original, "\r\n")
+	numLF := strings.Count(original, "\n") - numCRLF
+
+	eol := "\n"
+	if numCRLF > numLF {
+		eol = "\r\n"
+	}
+
+	res := strings.ReplaceAll(updated, "\r\n", "\n")
+	if eol == "\r\n" {
+		res = strings.ReplaceAll(res, "\n", "\r\n")
+	}
+
+	// a single-line original with no newline at all doesn't say anything about the file's convention
+	if numCRLF+numLF == 0 {
+		return res
+	}
+
+	if strings.HasSuffix(original, "\n") {
+		if !strings.HasSuffix(res, "\n") && res != "" {
+			res += eol
+		}
+	} else {
+		res = strings.TrimSuffix(res, eol)
+	}
+
+	return res
+}
@@ -0,0 +1,181 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const linearApiUrl = "https://api.linear.app/graphql"
+
+var linearClient = &http.Client{Timeout: 30 * time.Second}
+
+func GetLinearApiKey() string {
+	return os.Getenv("LINEAR_API_KEY")
+}
+
+const linearIssueQuery = `query Issue($id: String!) {
+  issue(id: $id) {
+    id
+    identifier
+    title
+    description
+    url
+    state { name }
+    creator { name }
+    comments(first: 100) { nodes { body user { name } } }
+  }
+}`
+
+const linearCommentMutation = `mutation CommentCreate($issueId: String!, $body: String!) {
+  commentCreate(input: {issueId: $issueId, body: $body}) { success }
+}`
+
+// GetLinearIssue loads an issue by its identifier, like ENG-123, with its comments
+func GetLinearIssue(identifier string) (*TrackerIssue, error) {
+	var res struct {
+		Issue *struct {
+			Id          string `json:"id"`
+			Identifier  string `json:"identifier"`
+			Title       string `json:"title"`
+			Description string `json:"description"`
+			Url         string `json:"url"`
+			State       struct {
+				Name string `json:"name"`
+			} `json:"state"`
+			Creator *struct {
+				Name string `json:"name"`
+			} `json:"creator"`
+			Comments struct {
+				Nodes []struct {
+					Body string `json:"body"`
+					User *struct {
+						Name string `json:"name"`
+					} `json:"user"`
+				} `json:"nodes"`
+			} `json:"comments"`
+		} `json:"issue"`
+	}
+
+	err := linearRequest(linearIssueQuery, map[string]any{"id": identifier}, &res)
+	if err != nil {
+		return nil, err
+	}
+	if res.Issue == nil {
+		return nil, fmt.Errorf("issue %s not found", identifier)
+	}
+
+	issue := &TrackerIssue{
+		Tracker: TrackerLinear,
+		Key:     res.Issue.Identifier,
+		Id:      res.Issue.Id,
+		Title:   res.Issue.Title,
+		Url:     res.Issue.Url,
+		State:   res.Issue.State.Name,
+		Body:    res.Issue.Description,
+	}
+	// issues made by integrations have no creator, and comments from them no user
+	if res.Issue.Creator != nil {
+		issue.Author = res.Issue.Creator.Name
+	}
+
+	// comments come newest first
+	nodes := res.Issue.Comments.Nodes
+	for i := len(nodes) - 1; i >= 0; i-- {
+		comment := &TrackerIssueComment{Body: nodes[i].Body}
+		if nodes[i].User != nil {
+			comment.Author = nodes[i].User.Name
+		}
+		issue.Comments = append(issue.Comments, comment)
+	}
+
+	return issue, nil
+}
+
+// CreateLinearComment adds a markdown comment to the issue with linear's internal id
+func CreateLinearComment(issueId, body string) error {
+	var res struct {
+		CommentCreate struct {
+			Success bool `json:"success"`
+		} `json:"commentCreate"`
+	}
+
+	err := linearRequest(linearCommentMutation, map[string]any{"issueId": issueId, "body": body}, &res)
+	if err != nil {
+		return err
+	}
+	if !res.CommentCreate.Success {
+		return fmt.Errorf("linear didn't create the comment")
+	}
+
+	return nil
+}
+
+func linearRequest(query string, variables map[string]any, res any) error {
+	apiKey := GetLinearApiKey()
+	if apiKey == "" {
+		return fmt.Errorf("set LINEAR_API_KEY to load Linear issues")
+	}
+
+	bodyBytes, err := json.Marshal(map[string]any{"query": query, "variables": variables})
+	if err != nil {
+		return fmt.Errorf("error marshalling linear request: %v", err)
+	}
+
+	req, err := http.NewRequest("POST", linearApiUrl, bytes.NewReader(bodyBytes))
+	if err != nil {
+		return fmt.Errorf("error creating linear request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// personal api keys are sent as is, without a Bearer prefix
+	req.Header.Set("Authorization", apiKey)
+
+	resp, err := linearClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending linear request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	resBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading linear response: %v", err)
+	}
+
+	// graphql errors can come with a 200 or a 400, so they're checked either way
+	var gqlRes struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	err = json.Unmarshal(resBytes, &gqlRes)
+	if err != nil {
+		if resp.StatusCode >= 400 {
+			return fmt.Errorf("linear returned %d: %s", resp.StatusCode, strings.TrimSpace(string(resBytes)))
+		}
+		return fmt.Errorf("error unmarshalling linear response: %v", err)
+	}
+
+	if len(gqlRes.Errors) > 0 {
+		var msgs []string
+		for _, e := range gqlRes.Errors {
+			msgs = append(msgs, e.Message)
+		}
+		return fmt.Errorf("linear returned an error: %s", strings.Join(msgs, ", "))
+	}
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("linear returned %d", resp.StatusCode)
+	}
+
+	err = json.Unmarshal(gqlRes.Data, res)
+	if err != nil {
+		return fmt.Errorf("error unmarshalling linear response: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,258 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"plandex/api"
+	"plandex/fs"
+	"plandex/term"
+	"plandex/types"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+const DefaultMaxLintFixes = 2
+
+type lintFailure struct {
+	cmdLine string
+	err     error
+	output  string
+}
+
+// MaybeRunLintLoop runs the lint loop on the plan's pending changes after they're built, if the project has linters set and has turned on running them automatically with 'plandex lint --auto'. If the project settings can't be loaded or the project's commands haven't been approved with 'plandex approve', it says so and reports the changes as not passing, since they weren't linted.
+func MaybeRunLintLoop(planId, branch string) bool {
+	settings, err := LoadProjectSettings()
+	if err != nil {
+		color.New(term.ColorHiRed).Printf("⚠️  Linters weren't run: couldn't load project settings: %v\n", err)
+		return false
+	}
+
+	if !settings.AutoLint || len(settings.Linters) == 0 {
+		return true
+	}
+
+	err = CheckProjectCommandsApproved(settings)
+	if err != nil {
+		color.New(term.ColorHiRed).Printf("⚠️  Linters weren't run: %v\n", err)
+		return false
+	}
+
+	return MustRunLintLoop(planId, branch, settings.Linters, GetMaxLintFixes(settings))
+}
+
+func GetMaxLintFixes(settings *types.CurrentProjectSettings) int {
+	if settings.MaxLintFixes != nil {
+		return *settings.MaxLintFixes
+	}
+	return DefaultMaxLintFixes
+}
+
+// MustRunLintLoop runs the project's linters on the files the plan's pending changes touch. The changes are written to a sandbox copy of the project, so linters see them alongside the rest of the code without anything being applied. The project's gitignored directories, like node_modules, are linked into the sandbox so linters and typecheckers can resolve installed dependencies. While there are diagnostics, they're sent to the plan for a fix pass, up to maxFixes times. Returns whether the changes passed.
+func MustRunLintLoop(planId, branch string, linters map[string]string, maxFixes int) bool {
+	if !fs.ProjectRootIsGitRepo() {
+		fmt.Println("⚠️  Skipping linters: checking changes before they're applied needs the project to be in a git repository")
+		return true
+	}
+
+	for attempt := 0; ; attempt++ {
+		failures, numLinted, err := lintPendingChanges(planId, branch, linters)
+		if err != nil {
+			term.OutputErrorAndExit("Error running linters: %v", err)
+		}
+
+		if numLinted == 0 {
+			return true
+		}
+
+		if len(failures) == 0 {
+			fmt.Println("✅ Linters passed")
+			return true
+		}
+
+		color.New(color.Bold, term.ColorHiRed).Println("❌ Linters found problems in the pending changes:")
+		for _, failure := range failures {
+			fmt.Printf("• %s: %v\n", failure.cmdLine, failure.err)
+			if failure.output != "" {
+				fmt.Println(indentLines(failure.output, "    "))
+			}
+		}
+		fmt.Println()
+
+		if attempt >= maxFixes {
+			if maxFixes > 0 {
+				fmt.Printf("Still failing after %d fix attempts\n", maxFixes)
+			}
+			fmt.Println()
+			term.PrintCmds("", "tell", "changes")
+			return false
+		}
+
+		mustAskForFixes(planId, branch, getLintFixPrompt(failures), fmt.Sprintf("🔧 Asking Plandex to fix the lint problems (attempt %d/%d)...", attempt+1, maxFixes))
+	}
+}
+
+func lintPendingChanges(planId, branch string, linters map[string]string) ([]lintFailure, int, error) {
+	currentPlanState, apiErr := api.Client.GetCurrentPlanState(planId, branch)
+	if apiErr != nil {
+		return nil, 0, fmt.Errorf("error getting current plan state: %v", apiErr.Msg)
+	}
+
+	planFiles := currentPlanState.CurrentPlanFiles
+	if planFiles == nil {
+		return nil, 0, nil
+	}
+
+	pathsByExt := map[string][]string{}
+	numLinted := 0
+	for path := range planFiles.Files {
+		ext := strings.ToLower(filepath.Ext(path))
+		if linters[ext] == "" {
+			continue
+		}
+		pathsByExt[ext] = append(pathsByExt[ext], path)
+		numLinted++
+	}
+
+	if numLinted == 0 {
+		return nil, 0, nil
+	}
+
+	term.StartSpinner(fmt.Sprintf("🔍 Linting %d changed file(s)...", numLinted))
+	defer term.StopSpinner()
+
+	sandbox, err := createApplySandbox(planId)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error creating sandbox: %v", err)
+	}
+	defer sandbox.remove()
+
+	for path, content := range planFiles.Files {
+		err = writeFileMkdir(filepath.Join(sandbox.projectRoot, path), content)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	for path := range planFiles.Removed {
+		os.Remove(filepath.Join(sandbox.projectRoot, path))
+	}
+	for _, from := range planFiles.MovedFrom {
+		os.Remove(filepath.Join(sandbox.projectRoot, from))
+	}
+
+	// linked after the pending files are written, so none of them can be written through a link into the project
+	err = linkIgnoredDirs(fs.ProjectRoot, sandbox.projectRoot)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error linking ignored directories into sandbox: %v", err)
+	}
+
+	err = sandbox.enter()
+	if err != nil {
+		return nil, 0, fmt.Errorf("error entering sandbox: %v", err)
+	}
+	defer sandbox.leave()
+
+	var exts []string
+	for ext := range pathsByExt {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+
+	var failures []lintFailure
+	for _, ext := range exts {
+		paths := pathsByExt[ext]
+		sort.Strings(paths)
+
+		for _, cmdLine := range getLintCmdLines(linters[ext], paths) {
+			cmd, err := commandForShell(cmdLine)
+			if err != nil {
+				return nil, 0, err
+			}
+
+			output, err := cmd.CombinedOutput()
+			if err == nil {
+				continue
+			}
+
+			// diagnostics name files by their sandbox paths, which mean nothing to the model
+			cleaned := strings.ReplaceAll(string(output), sandbox.projectRoot+string(os.PathSeparator), "")
+			failures = append(failures, lintFailure{
+				cmdLine: cmdLine,
+				err:     err,
+				output:  strings.TrimSpace(cleaned),
+			})
+		}
+	}
+
+	return failures, numLinted, nil
+}
+
+// linkIgnoredDirs symlinks each of the project's gitignored directories into the sandbox. A worktree only has tracked and untracked files, so without these, linters that load installed dependencies, like eslint plugins or tsc's type definitions, fail in the sandbox when they'd pass in the project. Directories that already exist in the sandbox are left alone.
+func linkIgnoredDirs(projectRoot, sandboxRoot string) error {
+	res, err := exec.Command("git", "-C", projectRoot, "ls-files", "--others", "--ignored", "--exclude-standard", "--directory", "-z").Output()
+	if err != nil {
+		return fmt.Errorf("error listing ignored directories: %v", err)
+	}
+
+	for _, path := range strings.Split(string(res), "\x00") {
+		if !strings.HasSuffix(path, "/") {
+			continue
+		}
+		path = strings.TrimSuffix(path, "/")
+
+		dst := filepath.Join(sandboxRoot, path)
+		if _, err := os.Lstat(dst); err == nil {
+			continue
+		}
+
+		err = os.MkdirAll(filepath.Dir(dst), os.ModePerm)
+		if err != nil {
+			return err
+		}
+		err = os.Symlink(filepath.Join(projectRoot, path), dst)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getLintCmdLines runs the linter once per file if it has a {file} placeholder, like formatters, and otherwise once with every file appended, which suits linters that check files together
+func getLintCmdLines(linter string, paths []string) []string {
+	if strings.Contains(linter, "{file}") {
+		cmdLines := make([]string, len(paths))
+		for i, path := range paths {
+			cmdLines[i] = strings.ReplaceAll(linter, "{file}", shellQuote(path))
+		}
+		return cmdLines
+	}
+
+	cmdLine := linter
+	for _, path := range paths {
+		cmdLine += " " + shellQuote(path)
+	}
+	return []string{cmdLine}
+}
+
+func getLintFixPrompt(failures []lintFailure) string {
+	var b strings.Builder
+	b.WriteString("Linting the files you changed found these problems:\n")
+
+	for _, failure := range failures {
+		output := failure.output
+		if len(output) > maxVerifyOutputChars {
+			output = output[:maxVerifyOutputChars] + "\n... (output truncated)"
+		}
+		if output == "" {
+			output = failure.err.Error()
+		}
+		fmt.Fprintf(&b, "\n`%s`:\n\n```\n%s\n```\n", failure.cmdLine, output)
+	}
+
+	b.WriteString("\nFix these problems in the pending changes.")
+	return b.String()
+}
@@ -0,0 +1,63 @@
+package lib
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestLinkIgnoredDirs(t *testing.T) {
+	project := t.TempDir()
+	sandbox := t.TempDir()
+
+	write := func(root, path, content string) {
+		t.Helper()
+		err := os.MkdirAll(filepath.Dir(filepath.Join(root, path)), 0755)
+		if err == nil {
+			err = os.WriteFile(filepath.Join(root, path), []byte(content), 0644)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out, err := exec.Command("git", "-C", project, "init", "-q").CombinedOutput()
+	if err != nil {
+		t.Fatalf("git init: %v, output: %s", err, out)
+	}
+	write(project, ".gitignore", "node_modules/\ndist/\n.env\n")
+	write(project, "node_modules/eslint/index.js", "module.exports = {}\n")
+	write(project, "web/package.json", "{}\n")
+	write(project, "web/node_modules/react/index.js", "module.exports = {}\n")
+	write(project, "dist/app.js", "built\n")
+	write(project, ".env", "SECRET=1\n")
+	write(project, "src/app.ts", "export {}\n")
+
+	// a pending change inside an ignored directory is already written to the sandbox
+	write(sandbox, "dist/app.js", "pending\n")
+	write(sandbox, "web/package.json", "{}\n")
+
+	err = linkIgnoredDirs(project, sandbox)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"node_modules", "web/node_modules"} {
+		target, err := os.Readlink(filepath.Join(sandbox, path))
+		if err != nil {
+			t.Errorf("expected %s to be linked: %v", path, err)
+			continue
+		}
+		if target != filepath.Join(project, path) {
+			t.Errorf("%s links to %s, expected the project's", path, target)
+		}
+	}
+
+	if info, err := os.Lstat(filepath.Join(sandbox, "dist")); err != nil || info.Mode()&os.ModeSymlink != 0 {
+		t.Errorf("expected the sandbox's own dist to be left alone, got %v, %v", info, err)
+	}
+	if _, err := os.Lstat(filepath.Join(sandbox, ".env")); !os.IsNotExist(err) {
+		t.Errorf("expected ignored files not to be linked, got %v", err)
+	}
+}
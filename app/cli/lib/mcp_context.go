@@ -0,0 +1,229 @@
+package lib
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"plandex/api"
+	"plandex/mcp"
+	"plandex/term"
+	"plandex/types"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/plandex/plandex/shared"
+)
+
+// StartMcpClient starts one of the project's configured MCP servers
+func StartMcpClient(name string) (*mcp.Client, error) {
+	settings, err := LoadProjectSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	config, ok := settings.McpServers[name]
+	if !ok {
+		return nil, fmt.Errorf("no MCP server named %s. Add one with 'plandex mcp add'", name)
+	}
+
+	return startMcpClient(config)
+}
+
+func startMcpClient(config *types.McpServerConfig) (*mcp.Client, error) {
+	env := os.Environ()
+	for k, v := range config.Env {
+		env = append(env, k+"="+v)
+	}
+
+	return mcp.StartClient(config.Command, config.Args, env, log.Writer())
+}
+
+func mcpApprovalKey(name string) string {
+	return CurrentProjectId + "/" + name
+}
+
+// mcpServerFingerprint identifies everything about a server's config that decides what runs, so approving one version doesn't approve a changed command, args, or env
+func mcpServerFingerprint(config *types.McpServerConfig) string {
+	bytes, _ := json.Marshal(config)
+	sum := sha256.Sum256(bytes)
+	return hex.EncodeToString(sum[:])
+}
+
+// IsMcpServerApproved returns whether the user approved the server's current config to run with each prompt
+func IsMcpServerApproved(name string, config *types.McpServerConfig) (bool, error) {
+	clientConfig, err := LoadClientConfig()
+	if err != nil {
+		return false, err
+	}
+	return clientConfig.McpServerApprovals[mcpApprovalKey(name)] == mcpServerFingerprint(config), nil
+}
+
+// ApproveMcpServer records the user's approval for the server's current config to run with each prompt in this project
+func ApproveMcpServer(name string, config *types.McpServerConfig) error {
+	clientConfig, err := LoadClientConfig()
+	if err != nil {
+		return err
+	}
+	if clientConfig.McpServerApprovals == nil {
+		clientConfig.McpServerApprovals = map[string]string{}
+	}
+	clientConfig.McpServerApprovals[mcpApprovalKey(name)] = mcpServerFingerprint(config)
+	return WriteClientConfig(clientConfig)
+}
+
+// RevokeMcpServer removes the user's approval for the server, if there is one
+func RevokeMcpServer(name string) error {
+	clientConfig, err := LoadClientConfig()
+	if err != nil {
+		return err
+	}
+	if _, ok := clientConfig.McpServerApprovals[mcpApprovalKey(name)]; !ok {
+		return nil
+	}
+	delete(clientConfig.McpServerApprovals, mcpApprovalKey(name))
+	return WriteClientConfig(clientConfig)
+}
+
+// mcpContextName is the name of the context a tool's result is loaded as. Loading the same tool's result again replaces it.
+func mcpContextName(server, tool string) string {
+	return fmt.Sprintf("mcp-%s-%s", server, tool)
+}
+
+// LoadMcpToolContext calls a tool on a configured MCP server and loads its result into the plan's context as a note
+func LoadMcpToolContext(server, tool string, args map[string]any) (*shared.LoadContextResponse, error) {
+	client, err := StartMcpClient(server)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	return callMcpToolIntoContext(client, server, tool, args)
+}
+
+func callMcpToolIntoContext(client *mcp.Client, server, tool string, args map[string]any) (*shared.LoadContextResponse, error) {
+	res, err := client.CallTool(tool, args)
+	if err != nil {
+		return nil, fmt.Errorf("error calling %s on %s: %v", tool, server, err)
+	}
+
+	text := res.Text()
+	if res.IsError {
+		return nil, fmt.Errorf("%s on %s failed: %s", tool, server, text)
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("%s on %s returned no text", tool, server)
+	}
+
+	var argsDesc []string
+	for k, v := range args {
+		desc := fmt.Sprintf("%v", v)
+		if len(desc) > 80 {
+			desc = desc[:80] + "..."
+		}
+		argsDesc = append(argsDesc, fmt.Sprintf("%s=%s", k, desc))
+	}
+	sort.Strings(argsDesc)
+
+	body := fmt.Sprintf("Result of the %s tool from the %s MCP server", tool, server)
+	if len(argsDesc) > 0 {
+		body += fmt.Sprintf(" (%s)", strings.Join(argsDesc, ", "))
+	}
+	body += ":\n\n" + text
+
+	name := mcpContextName(server, tool)
+
+	contexts, apiErr := api.Client.ListContext(CurrentPlanId, CurrentBranch)
+	if apiErr != nil {
+		return nil, fmt.Errorf("error getting context: %v", apiErr.Msg)
+	}
+
+	for _, context := range contexts {
+		if context.ContextType == shared.ContextNoteType && context.Name == name {
+			res, apiErr := api.Client.UpdateContext(CurrentPlanId, CurrentBranch, shared.UpdateContextRequest{
+				context.Id: {Body: body},
+			})
+			if apiErr != nil {
+				return nil, fmt.Errorf("error updating context: %v", apiErr.Msg)
+			}
+			return res, nil
+		}
+	}
+
+	loadRes, apiErr := api.Client.LoadContext(CurrentPlanId, CurrentBranch, shared.LoadContextRequest{
+		{
+			ContextType: shared.ContextNoteType,
+			Name:        name,
+			Body:        body,
+		},
+	})
+	if apiErr != nil {
+		return nil, fmt.Errorf("error loading context: %v", apiErr.Msg)
+	}
+	return loadRes, nil
+}
+
+// LoadMcpPromptContext calls the prompt tool of each configured MCP server that has one, loading the results into context before the prompt is sent. Servers only run once the user has approved them with 'plandex mcp approve' or added them with 'plandex mcp add', since the project's settings can come from anyone with access to the repo. A server that isn't approved or fails is reported and skipped, so it can't block the prompt.
+func LoadMcpPromptContext(prompt string) {
+	settings, err := LoadProjectSettings()
+	if err != nil {
+		log.Println("Error loading project settings:", err)
+		return
+	}
+
+	var names []string
+	for name, config := range settings.McpServers {
+		if config.PromptTool != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		config := settings.McpServers[name]
+
+		approved, err := IsMcpServerApproved(name, config)
+		if err != nil {
+			color.New(term.ColorHiYellow).Printf("⚠️  Skipped context from MCP server %s: couldn't check whether it's approved: %v\n", name, err)
+			continue
+		}
+		if !approved {
+			color.New(term.ColorHiYellow).Printf("⚠️  Skipped context from MCP server %s: it hasn't been approved to run with each prompt. Check its command with 'plandex mcp ls', then approve it with 'plandex mcp approve %s'\n", name, name)
+			continue
+		}
+
+		argName := config.PromptArg
+		if argName == "" {
+			argName = "query"
+		}
+
+		term.StartSpinner(fmt.Sprintf("🔌 Calling %s on %s...", config.PromptTool, name))
+
+		err = func() error {
+			client, err := startMcpClient(config)
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			res, err := callMcpToolIntoContext(client, name, config.PromptTool, map[string]any{argName: prompt})
+			if err != nil {
+				return err
+			}
+			if res.MaxTokensExceeded {
+				return fmt.Errorf("the result would exceed the token limit (%d) by %d 🪙", res.MaxTokens, res.TotalTokens-res.MaxTokens)
+			}
+			return nil
+		}()
+
+		term.StopSpinner()
+
+		if err != nil {
+			log.Printf("MCP prompt context from %s failed: %v\n", name, err)
+			color.New(term.ColorHiYellow).Printf("⚠️  Skipped context from MCP server %s: %v\n", name, err)
+		}
+	}
+}
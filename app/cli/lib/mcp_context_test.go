@@ -0,0 +1,50 @@
+package lib
+
+import (
+	"path/filepath"
+	"plandex/fs"
+	"plandex/types"
+	"testing"
+)
+
+func TestMcpServerApproval(t *testing.T) {
+	origConfigPath, origProjectId := fs.HomeConfigPath, CurrentProjectId
+	fs.HomeConfigPath = filepath.Join(t.TempDir(), "config.json")
+	CurrentProjectId = "project"
+	defer func() { fs.HomeConfigPath, CurrentProjectId = origConfigPath, origProjectId }()
+
+	config := &types.McpServerConfig{Command: "npx", Args: []string{"-y", "docs-mcp"}, PromptTool: "search"}
+
+	check := func(name string, config *types.McpServerConfig, want bool) {
+		t.Helper()
+		approved, err := IsMcpServerApproved(name, config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if approved != want {
+			t.Errorf("approved = %v, want %v", approved, want)
+		}
+	}
+
+	check("docs", config, false)
+
+	if err := ApproveMcpServer("docs", config); err != nil {
+		t.Fatal(err)
+	}
+	check("docs", config, true)
+	check("other", config, false)
+
+	// a changed config, like one pulled from someone else's commit, needs approval again
+	changed := *config
+	changed.Env = map[string]string{"NODE_OPTIONS": "--require ./evil.js"}
+	check("docs", &changed, false)
+
+	CurrentProjectId = "other-project"
+	check("docs", config, false)
+	CurrentProjectId = "project"
+
+	if err := RevokeMcpServer("docs"); err != nil {
+		t.Fatal(err)
+	}
+	check("docs", config, false)
+}
@@ -0,0 +1,142 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"plandex/mcp"
+
+	"github.com/fatih/color"
+)
+
+// NewMcpServer returns an MCP server with tools to propose changes to the current plan, review their diff, and apply them
+func NewMcpServer(version string) *mcp.Server {
+	s := mcp.NewServer("plandex", version)
+
+	s.AddTool(mcp.Tool{
+		Name:        "propose_plan",
+		Description: "Send a prompt to the project's current Plandex plan. Waits for the plan to reply and build its changes, then reports the changes and files that are ready to apply. Nothing is written to the project until apply_plan is called.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"prompt": map[string]any{"type": "string", "description": "The task to plan, as you'd describe it to a developer"},
+			},
+			"required": []string{"prompt"},
+		},
+	}, mcpProposePlan)
+
+	s.AddTool(mcp.Tool{
+		Name:        "get_diff",
+		Description: "Get a unified diff of the current plan's pending changes against the project's files.",
+		InputSchema: map[string]any{"type": "object", "properties": map[string]any{}},
+	}, mcpGetDiff)
+
+	s.AddTool(mcp.Tool{
+		Name:        "apply_plan",
+		Description: "Write the current plan's pending changes to the project's files and commit them. Files flagged for risky operations like network calls or shell commands aren't applied; a person has to review and apply those with 'plandex apply'.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"git_branch": map[string]any{"type": "string", "description": "Switch to this git branch, creating it if needed, and commit the changes there"},
+				"commit":     map[string]any{"type": "boolean", "description": "Commit the changes with a generated message (default true)"},
+			},
+		},
+	}, mcpApplyPlan)
+
+	return s
+}
+
+// ServeMcp answers MCP requests on stdin and stdout. Everything else the CLI prints goes to stderr while serving, since stdout carries the protocol.
+func ServeMcp(version string) error {
+	out, colorOut := os.Stdout, color.Output
+	os.Stdout, color.Output = os.Stderr, os.Stderr
+	defer func() {
+		os.Stdout, color.Output = out, colorOut
+	}()
+
+	return NewMcpServer(version).Serve(os.Stdin, out)
+}
+
+// the current plan is reloaded for each call, since it may be changed with 'plandex cd' while the server is running
+func requireCurrentPlan() error {
+	err := LoadCurrentPlan()
+	if err != nil {
+		return err
+	}
+	if CurrentPlanId == "" {
+		return fmt.Errorf("no current plan. Create one with 'plandex new' first")
+	}
+	return nil
+}
+
+func mcpProposePlan(args json.RawMessage) (string, error) {
+	var params struct {
+		Prompt string `json:"prompt"`
+	}
+	if len(args) > 0 {
+		if err := json.Unmarshal(args, &params); err != nil {
+			return "", fmt.Errorf("invalid arguments: %v", err)
+		}
+	}
+	if params.Prompt == "" {
+		return "", fmt.Errorf("prompt is required")
+	}
+
+	if !HasApiKey() {
+		return "", fmt.Errorf("OPENAI_API_KEY isn't set in the server's environment")
+	}
+
+	if err := requireCurrentPlan(); err != nil {
+		return "", err
+	}
+
+	report, err := RunPlanAndBuildInSubprocess(params.Prompt)
+	if err != nil {
+		return "", err
+	}
+
+	if report.Status == RunStatusPlanFailed || report.Status == RunStatusBuildFailed {
+		return "", fmt.Errorf("%s: %s", report.Status, report.Error)
+	}
+
+	bytes, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("error marshalling report: %v", err)
+	}
+
+	return string(bytes), nil
+}
+
+func mcpGetDiff(args json.RawMessage) (string, error) {
+	if err := requireCurrentPlan(); err != nil {
+		return "", err
+	}
+
+	diff, err := GetPlanDiff(CurrentPlanId, CurrentBranch)
+	if err != nil {
+		return "", err
+	}
+
+	if diff == "" {
+		return "The plan has no pending changes", nil
+	}
+	return diff, nil
+}
+
+func mcpApplyPlan(args json.RawMessage) (string, error) {
+	params := struct {
+		GitBranch string `json:"git_branch"`
+		Commit    *bool  `json:"commit"`
+	}{}
+	if len(args) > 0 {
+		if err := json.Unmarshal(args, &params); err != nil {
+			return "", fmt.Errorf("invalid arguments: %v", err)
+		}
+	}
+
+	if err := requireCurrentPlan(); err != nil {
+		return "", err
+	}
+
+	return ApplyInSubprocess(params.GitBranch, params.Commit == nil || *params.Commit, false)
+}
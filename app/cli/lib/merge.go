@@ -0,0 +1,120 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"plandex/term"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+const (
+	mergeOptionEditor    = "Resolve conflicts in your editor"
+	mergeOptionPlan      = "Use the plan's version (discard your changes to this file)"
+	mergeOptionKeep      = "Keep your version (skip the plan's changes to this file)"
+	mergeOptionMarkers   = "Write the file with conflict markers to resolve later"
+	conflictMarkerPrefix = "<<<<<<< "
+)
+
+// threeWayMerge merges the plan's version of a file with changes made on disk since the plan was built, using the context the plan was built against as the common ancestor
+func threeWayMerge(base, current, planned string) (string, bool, error) {
+	dir, err := os.MkdirTemp("", "plandex-merge-*")
+	if err != nil {
+		return "", false, fmt.Errorf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{"current": current, "base": base, "planned": planned}
+	for name, content := range files {
+		err = os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		if err != nil {
+			return "", false, fmt.Errorf("error writing temp file: %v", err)
+		}
+	}
+
+	cmd := exec.Command("git", "merge-file", "-p",
+		"-L", "your changes", "-L", "original", "-L", "plandex",
+		"current", "base", "planned")
+	cmd.Dir = dir
+
+	out, err := cmd.Output()
+	if err != nil {
+		// merge-file exits with the number of conflicts, or a negative status on error
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 && exitErr.ExitCode() < 128 {
+			return string(out), true, nil
+		}
+		return "", false, fmt.Errorf("error running git merge-file: %v", err)
+	}
+
+	return string(out), false, nil
+}
+
+// resolveMergeConflicts asks how to handle a file with conflicting changes. It returns the content to write, or skip=true if the file should be left as is.
+func resolveMergeConflicts(path, merged, planned string) (content string, skip bool) {
+	for {
+		term.StopSpinner()
+		color.New(color.Bold, term.ColorHiYellow).Printf("⚠️  %s was changed since the plan was built and the changes conflict\n", path)
+
+		selection, err := term.SelectFromList("How do you want to handle it?", []string{
+			mergeOptionEditor,
+			mergeOptionPlan,
+			mergeOptionKeep,
+			mergeOptionMarkers,
+		})
+		if err != nil {
+			if err.Error() == "interrupt" {
+				fmt.Println("Apply plan canceled")
+				term.Exit(0)
+			}
+			term.OutputErrorAndExit("failed to get user input: %v", err)
+		}
+
+		switch selection {
+		case mergeOptionPlan:
+			return planned, false
+		case mergeOptionKeep:
+			return "", true
+		case mergeOptionMarkers:
+			return merged, false
+		case mergeOptionEditor:
+			resolved, err := term.EditText(merged, filepath.Ext(path), false)
+			if err != nil {
+				term.OutputSimpleError("Error opening editor:", err.Error())
+				continue
+			}
+
+			if strings.Contains(resolved, conflictMarkerPrefix) {
+				fmt.Println("The file still has conflict markers")
+				merged = resolved
+				continue
+			}
+
+			return resolved, false
+		}
+	}
+}
+
+// confirmOverwriteChangedFile asks whether to overwrite a file that changed since the plan's changes were proposed, when there's no earlier version to merge them with. It returns false if the user keeps their version.
+func confirmOverwriteChangedFile(path string) bool {
+	term.StopSpinner()
+	color.New(color.Bold, term.ColorHiYellow).Printf("⚠️  %s was changed since the plan's changes were proposed, and there's no earlier version of it to merge them with\n", path)
+
+	selection, err := term.SelectFromList("How do you want to handle it?", []string{
+		mergeOptionPlan,
+		mergeOptionKeep,
+	})
+	if err != nil {
+		if err.Error() == "interrupt" {
+			fmt.Println("Apply plan canceled")
+			term.Exit(0)
+		}
+		term.OutputErrorAndExit("failed to get user input: %v", err)
+	}
+
+	return selection == mergeOptionPlan
+}
@@ -0,0 +1,37 @@
+package lib
+
+import (
+	"encoding/json"
+	"os"
+	"plandex/term"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// set by the --mock flag. Replies and builds are simulated by the server, so no API key is needed.
+var MockEnabled bool
+
+// GetMockConfig returns the config to send with tell and build requests when --mock is set, or nil. Settings can be overridden with the PLANDEX_MOCK_CONFIG env var, e.g.
+// {"seed": 7, "numFiles": 5, "chunkDelayMs": 0}
+func GetMockConfig() *shared.MockConfig {
+	if !MockEnabled {
+		return nil
+	}
+
+	config := shared.DefaultMockConfig
+
+	if s := os.Getenv("PLANDEX_MOCK_CONFIG"); s != "" {
+		err := json.Unmarshal([]byte(s), &config)
+		if err != nil {
+			term.OutputErrorAndExit("Error parsing PLANDEX_MOCK_CONFIG: %v", err)
+		}
+	}
+
+	config = config.WithDefaults()
+	return &config
+}
+
+// HasApiKey is whether model calls can be made, either with OPENAI_API_KEY or simulated with --mock
+func HasApiKey() bool {
+	return MockEnabled || os.Getenv("OPENAI_API_KEY") != ""
+}
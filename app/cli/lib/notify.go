@@ -0,0 +1,115 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"plandex/api"
+	"plandex/term"
+	"sync"
+	"time"
+
+	"github.com/gen2brain/beeep"
+)
+
+// set by the --notify flag of the commands that stream a plan. When a stream finishes or fails, a desktop notification is shown and, if PLANDEX_NOTIFY_WEBHOOK is set, the event is posted there too.
+var NotifyEnabled bool
+
+var notifyClient = &http.Client{Timeout: 10 * time.Second}
+
+var pendingNotifications sync.WaitGroup
+
+// NotifyEvent is posted as JSON to PLANDEX_NOTIFY_WEBHOOK
+type NotifyEvent struct {
+	// "finished" or "error"
+	Event    string `json:"event"`
+	PlanId   string `json:"planId"`
+	PlanName string `json:"planName"`
+	Branch   string `json:"branch"`
+	Error    string `json:"error,omitempty"`
+	Text     string `json:"text"`
+}
+
+// NotifyStreamDone sends the notifications for a stream that finished, or failed with errMsg. They're sent in the background, so looking up the plan's name and posting the webhook don't hold up the stream UI; WaitForNotifications, or exiting through term.Exit, waits for them to go out. Failures are only logged, since the stream's result is still shown in the terminal.
+func NotifyStreamDone(planId, branch, errMsg string) {
+	if !NotifyEnabled {
+		return
+	}
+
+	pendingNotifications.Add(1)
+	removeExitHook := term.OnExit(WaitForNotifications)
+
+	go func() {
+		defer pendingNotifications.Done()
+		defer removeExitHook()
+		sendStreamDoneNotifications(planId, branch, errMsg)
+	}()
+}
+
+// WaitForNotifications waits for notifications sent with NotifyStreamDone to go out
+func WaitForNotifications() {
+	pendingNotifications.Wait()
+}
+
+func sendStreamDoneNotifications(planId, branch, errMsg string) {
+	planName := planId
+	plan, apiErr := api.Client.GetPlan(planId)
+	if apiErr != nil {
+		log.Println("Error getting plan for notification:", apiErr.Msg)
+	} else {
+		planName = plan.Name
+	}
+
+	event := NotifyEvent{
+		Event:    "finished",
+		PlanId:   planId,
+		PlanName: planName,
+		Branch:   branch,
+		Error:    errMsg,
+	}
+
+	title := "✅ Plandex plan finished"
+	event.Text = fmt.Sprintf("%s (%s) is ready to review", planName, branch)
+	if errMsg != "" {
+		event.Event = "error"
+		title = "❌ Plandex plan failed"
+		event.Text = fmt.Sprintf("%s (%s): %s", planName, branch, errMsg)
+	}
+
+	err := beeep.Notify(title, event.Text, "")
+	if err != nil {
+		log.Println("Error showing desktop notification:", err)
+	}
+
+	webhookUrl := os.Getenv("PLANDEX_NOTIFY_WEBHOOK")
+	if webhookUrl == "" {
+		return
+	}
+
+	err = postNotifyWebhook(webhookUrl, event)
+	if err != nil {
+		log.Println("Error posting notification webhook:", err)
+	}
+}
+
+func postNotifyWebhook(webhookUrl string, event NotifyEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("error marshalling event: %v", err)
+	}
+
+	res, err := notifyClient.Post(webhookUrl, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error sending request: %v", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		return fmt.Errorf("webhook responded with %d", res.StatusCode)
+	}
+
+	return nil
+}
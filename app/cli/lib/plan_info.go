@@ -0,0 +1,155 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"plandex/api"
+	"plandex/fs"
+	"plandex/types"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func getPlanInfoPath(planId string) string {
+	return filepath.Join(fs.HomePlandexDir, CurrentProjectId, planId, "plan.json")
+}
+
+func LoadPlanInfo(planId string) (*types.PlanInfo, error) {
+	bytes, err := os.ReadFile(getPlanInfoPath(planId))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &types.PlanInfo{}, nil
+		}
+		return nil, fmt.Errorf("error reading plan.json: %v", err)
+	}
+
+	var info types.PlanInfo
+	err = json.Unmarshal(bytes, &info)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling plan.json: %v", err)
+	}
+
+	return &info, nil
+}
+
+func recordAppliedCommit(planId, branch, sha, gitBranch string, convoMessageIdsByPath map[string][]string) error {
+	return updatePlanInfo(planId, func(info *types.PlanInfo) {
+		info.AppliedCommits = append(info.AppliedCommits, &types.AppliedCommit{
+			Sha:       sha,
+			Branch:    branch,
+			GitBranch: gitBranch,
+			CreatedAt: time.Now(),
+
+			ConvoMessageIdsByPath: convoMessageIdsByPath,
+		})
+	})
+}
+
+// GetPlanUsageTotals adds up the usage the server has recorded for the plan by phase
+func GetPlanUsageTotals(planId string) (map[shared.UsagePhase]*shared.UsageTotal, error) {
+	res, apiErr := api.Client.GetPlanUsage(planId, time.Time{})
+	if apiErr != nil {
+		return nil, fmt.Errorf("error getting plan usage: %v", apiErr.Msg)
+	}
+
+	totals := map[shared.UsagePhase]*shared.UsageTotal{}
+	for _, record := range res.Records {
+		if totals[record.Phase] == nil {
+			totals[record.Phase] = &shared.UsageTotal{}
+		}
+		totals[record.Phase].Add(record.ModelUsage)
+	}
+
+	return totals, nil
+}
+
+// RecordPlanIssue links an issue to the plan, replacing an earlier record of the same issue
+func RecordPlanIssue(planId string, issue *types.PlanIssue) error {
+	return updatePlanInfo(planId, func(info *types.PlanInfo) {
+		for i, existing := range info.Issues {
+			if existing.Tracker == issue.Tracker && existing.Key == issue.Key {
+				info.Issues[i] = issue
+				return
+			}
+		}
+		info.Issues = append(info.Issues, issue)
+	})
+}
+
+// a lock older than this was left behind by a process that exited while holding it
+const planInfoLockStaleAfter = 10 * time.Second
+
+// updatePlanInfo loads plan.json, applies update, and writes it back while holding the plan's lock file, so streams for different branches of the plan, or commands in other terminals, don't overwrite each other's records
+func updatePlanInfo(planId string, update func(info *types.PlanInfo)) error {
+	unlock, err := lockPlanInfo(planId)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	info, err := LoadPlanInfo(planId)
+	if err != nil {
+		return err
+	}
+
+	update(info)
+
+	return writePlanInfo(planId, info)
+}
+
+func lockPlanInfo(planId string) (func(), error) {
+	path := getPlanInfoPath(planId) + ".lock"
+
+	err := os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		return nil, fmt.Errorf("error creating plan dir: %v", err)
+	}
+
+	deadline := time.Now().Add(planInfoLockStaleAfter)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("error locking plan.json: %v", err)
+		}
+
+		stat, statErr := os.Stat(path)
+		if statErr == nil && time.Since(stat.ModTime()) > planInfoLockStaleAfter {
+			os.Remove(path)
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for lock on plan.json")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func writePlanInfo(planId string, info *types.PlanInfo) error {
+	bytes, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshalling plan info: %v", err)
+	}
+
+	path := getPlanInfoPath(planId)
+
+	err = os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("error creating plan dir: %v", err)
+	}
+
+	// written to a temp file and renamed, so commands reading plan.json while another records to it never see a partial file
+	err = writeFileAtomic(path, bytes, 0644)
+	if err != nil {
+		return fmt.Errorf("error writing plan.json: %v", err)
+	}
+
+	return nil
+}
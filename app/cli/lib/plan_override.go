@@ -0,0 +1,50 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"plandex/api"
+	"strings"
+)
+
+// set by the --plan flag. Like the PLANDEX_PLAN env var, it points a single command at a plan other than the project's current one, so several plans can stream at once from different terminals without 'plandex cd' switching the plan under each of them.
+var PlanFlag string
+
+// GetPlanOverride returns the plan name or id set with --plan or PLANDEX_PLAN, or an empty string if the project's current plan should be used. The flag takes precedence.
+func GetPlanOverride() string {
+	if PlanFlag != "" {
+		return strings.TrimSpace(PlanFlag)
+	}
+	return strings.TrimSpace(os.Getenv("PLANDEX_PLAN"))
+}
+
+// the override's plan id, so the plan list is only fetched once per command
+var overridePlanId string
+
+func resolvePlanOverride(nameOrId string) (string, error) {
+	if overridePlanId != "" {
+		return overridePlanId, nil
+	}
+
+	plans, apiErr := api.Client.ListPlans([]string{CurrentProjectId})
+	if apiErr != nil {
+		return "", fmt.Errorf("error getting plans: %v", apiErr.Msg)
+	}
+
+	if plan := FindPlan(plans, nameOrId); plan != nil {
+		overridePlanId = plan.Id
+		return plan.Id, nil
+	}
+
+	// a plan a teammate shared with the org can be used from any project
+	plan, apiErr := FindSharedPlan(nameOrId)
+	if apiErr != nil {
+		return "", fmt.Errorf("error getting shared plans: %v", apiErr.Msg)
+	}
+	if plan != nil {
+		overridePlanId = plan.Id
+		return plan.Id, nil
+	}
+
+	return "", fmt.Errorf("plan %s not found in this project", nameOrId)
+}
@@ -0,0 +1,149 @@
+package lib
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"plandex/fs"
+	"plandex/types"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// PluginProtocolVersion is passed to plugins as PLANDEX_PLUGIN_VERSION and in PLANDEX_STATE, so they can check they understand the state they're given
+const PluginProtocolVersion = 1
+
+const pluginExecPrefix = "plandex-"
+
+var pluginNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]*$`)
+
+// PluginState is passed to plugins as JSON in PLANDEX_STATE. Fields for a project, plan, or account are empty if there isn't one.
+type PluginState struct {
+	Version        int    `json:"version"`
+	PlandexVersion string `json:"plandexVersion"`
+	// the plandex executable, for plugins that run plandex commands
+	PlandexBin  string `json:"plandexBin"`
+	Cwd         string `json:"cwd"`
+	ProjectRoot string `json:"projectRoot,omitempty"`
+	ProjectId   string `json:"projectId,omitempty"`
+	PlanId      string `json:"planId,omitempty"`
+	Branch      string `json:"branch,omitempty"`
+	Host        string `json:"host,omitempty"`
+	IsCloud     bool   `json:"isCloud,omitempty"`
+	OrgId       string `json:"orgId,omitempty"`
+	Email       string `json:"email,omitempty"`
+}
+
+// IsValidPluginName is false for names taken by other plandex executables, like context providers and the server
+func IsValidPluginName(name string) bool {
+	return pluginNameRegex.MatchString(name) &&
+		!strings.HasPrefix(name, strings.TrimPrefix(contextProviderExecPrefix, pluginExecPrefix)) &&
+		name != "server"
+}
+
+// ListPathPlugins finds plandex-<name> executables on the PATH, mapping each name to the first one found
+func ListPathPlugins() map[string]string {
+	return listPathExecutables(pluginExecPrefix, IsValidPluginName)
+}
+
+// ResolvePlugin finds a plugin configured for the project, or a plandex-<name> executable on the PATH. It returns nil if there's neither.
+func ResolvePlugin(name string) *types.PluginConfig {
+	if !IsValidPluginName(name) {
+		return nil
+	}
+
+	if fs.PlandexDir != "" {
+		settings, err := LoadProjectSettings()
+		if err == nil {
+			if config, ok := settings.Plugins[name]; ok {
+				return config
+			}
+		}
+	}
+
+	path, err := exec.LookPath(pluginExecPrefix + name)
+	if err != nil {
+		return nil
+	}
+
+	return &types.PluginConfig{Command: path}
+}
+
+// RunPlugin runs a plugin attached to the terminal with the args it was invoked with, and returns its exit code
+func RunPlugin(config *types.PluginConfig, args []string, state *PluginState) (int, error) {
+	state.Version = PluginProtocolVersion
+	stateBytes, err := json.Marshal(state)
+	if err != nil {
+		return 1, fmt.Errorf("error marshalling state: %v", err)
+	}
+
+	cmd := exec.Command(config.Command, append(append([]string{}, config.Args...), args...)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	cmd.Env = os.Environ()
+	for k, v := range map[string]string{
+		"PLANDEX_PLUGIN_VERSION": fmt.Sprint(PluginProtocolVersion),
+		"PLANDEX_STATE":          string(stateBytes),
+		"PLANDEX_BIN":            state.PlandexBin,
+		"PLANDEX_PROJECT_ROOT":   state.ProjectRoot,
+		"PLANDEX_PLAN_ID":        state.PlanId,
+		"PLANDEX_BRANCH":         state.Branch,
+	} {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	for k, v := range config.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+
+	// the plugin gets ctrl+c from the terminal too, and decides for itself whether to exit
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt)
+	defer signal.Stop(sigCh)
+
+	err = cmd.Run()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode(), nil
+		}
+		return 1, err
+	}
+
+	return 0, nil
+}
+
+// listPathExecutables finds executables on the PATH named prefix + a name that valid accepts, mapping each name to the first one found
+func listPathExecutables(prefix string, valid func(string) bool) map[string]string {
+	res := map[string]string{}
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasPrefix(entry.Name(), prefix) {
+				continue
+			}
+			name := strings.TrimPrefix(entry.Name(), prefix)
+			if runtime.GOOS == "windows" {
+				name = strings.TrimSuffix(name, filepath.Ext(name))
+			}
+			if _, ok := res[name]; ok || !valid(name) {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			if _, err := exec.LookPath(path); err != nil {
+				continue
+			}
+			res[name] = path
+		}
+	}
+	return res
+}
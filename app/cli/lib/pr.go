@@ -0,0 +1,86 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"plandex/api"
+	"plandex/fs"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// prompts longer than this are cut off before they're sent to write the pull request
+const maxPrPromptChars = 500
+
+// GetPullRequestContent has the model write a pull request title and body from the diff between base and the current git branch, along with the plan's applied changes and the prompts in its conversation
+func GetPullRequestContent(planId, branch, remote, base string, modelSet *shared.ModelSet) (title, body string, err error) {
+	plan, apiErr := api.Client.GetPlan(planId)
+	if apiErr != nil {
+		return "", "", fmt.Errorf("error getting plan: %v", apiErr.Msg)
+	}
+
+	currentPlanState, apiErr := api.Client.GetCurrentPlanState(planId, branch)
+	if apiErr != nil {
+		return "", "", fmt.Errorf("error getting current plan state: %v", apiErr.Msg)
+	}
+
+	convo, apiErr := api.Client.ListConvo(planId, branch)
+	if apiErr != nil {
+		return "", "", fmt.Errorf("error getting conversation: %v", apiErr.Msg)
+	}
+
+	var changes []string
+	for _, desc := range currentPlanState.ConvoMessageDescriptions {
+		if desc.AppliedAt != nil && desc.CommitMsg != "" {
+			changes = append(changes, desc.CommitMsg)
+		}
+	}
+
+	var prompts []string
+	for _, msg := range convo {
+		if msg.Role != "user" {
+			continue
+		}
+		prompt := strings.TrimSpace(msg.Message)
+		if len(prompt) > maxPrPromptChars {
+			prompt = prompt[:maxPrPromptChars] + "..."
+		}
+		prompts = append(prompts, prompt)
+	}
+
+	diff, err := GitReviewDiff(fs.ProjectRoot, GitReviewSource{Base: pullRequestDiffBase(remote, base)})
+	if err != nil {
+		return "", "", err
+	}
+	if strings.TrimSpace(diff) == "" {
+		return "", "", fmt.Errorf("there are no changes between %s and the current branch", base)
+	}
+
+	res, apiErr := api.Client.GenPullRequest(shared.GenPullRequestRequest{
+		ApiKey:   os.Getenv("OPENAI_API_KEY"),
+		ModelSet: modelSet,
+		Diff:     diff,
+		PlanName: plan.Name,
+		Changes:  changes,
+		Prompts:  prompts,
+	})
+	if apiErr != nil {
+		return "", "", fmt.Errorf("error writing pull request: %v", apiErr.Msg)
+	}
+
+	body = strings.TrimSpace(res.Body) + "\n\n" + fmt.Sprintf("_Opened from the Plandex plan `%s`._\n", plan.Name)
+
+	return res.Title, body, nil
+}
+
+// pullRequestDiffBase is the remote's copy of base if it has been fetched, since the local branch may be behind it, or the local branch otherwise
+func pullRequestDiffBase(remote, base string) string {
+	remoteBase := remote + "/" + base
+	err := exec.Command("git", "-C", fs.ProjectRoot, "rev-parse", "--verify", "--quiet", remoteBase).Run()
+	if err == nil {
+		return remoteBase
+	}
+	return base
+}
@@ -0,0 +1,178 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"plandex/fs"
+	"plandex/term"
+	"plandex/types"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// GetPresets returns the presets from the global config, overridden by any with the same name in the project's settings
+func GetPresets() (map[string]*types.Preset, error) {
+	config, err := LoadClientConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	presets := map[string]*types.Preset{}
+	for name, preset := range config.Presets {
+		presets[name] = preset
+	}
+
+	if fs.PlandexDir != "" {
+		settings, err := LoadProjectSettings()
+		if err != nil {
+			return nil, err
+		}
+		for name, preset := range settings.Presets {
+			presets[name] = preset
+		}
+	}
+
+	for name, preset := range presets {
+		if preset == nil || (strings.TrimSpace(preset.Prompt) == "" && preset.Template == "") {
+			return nil, fmt.Errorf("preset %s has no prompt or template", name)
+		}
+		if preset.Prompt != "" && preset.Template != "" {
+			return nil, fmt.Errorf("preset %s has both a prompt and a template", name)
+		}
+	}
+
+	return presets, nil
+}
+
+// GetPresetPrompt fills in a preset's prompt or template. {{args}} is the preset's arguments, and other variables come from vars, as with 'plandex tell --template'.
+func GetPresetPrompt(preset *types.Preset, args []string, vars map[string]string) (string, error) {
+	prompt := preset.Prompt
+	if preset.Template != "" {
+		var err error
+		prompt, err = LoadTemplate(preset.Template)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	joined := strings.Join(args, " ")
+
+	hasArgsVar := false
+	for _, match := range templateVarRegex.FindAllStringSubmatch(prompt, -1) {
+		if match[1] == "args" {
+			hasArgsVar = true
+			break
+		}
+	}
+
+	// args are rendered as a variable rather than substituted beforehand, so anything that looks like a variable in them is sent as is
+	renderVars := map[string]string{}
+	for key, value := range vars {
+		renderVars[key] = value
+	}
+	renderVars["args"] = joined
+
+	prompt, err := RenderTemplate(prompt, renderVars)
+	if err != nil {
+		return "", err
+	}
+
+	if !hasArgsVar && joined != "" {
+		prompt = prompt + "\n\n" + joined
+	}
+
+	return prompt, nil
+}
+
+// GetPresetContextPaths returns the files to load for a preset, relative to the current directory. Directories are loaded recursively, and files that are already in context are skipped.
+func GetPresetContextPaths(preset *types.Preset, args []string, contexts []*shared.Context) ([]string, error) {
+	var inputPaths []string
+
+	for _, pattern := range preset.Context {
+		matches, err := filepath.Glob(filepath.Join(fs.ProjectRoot, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("invalid context pattern %s: %v", pattern, err)
+		}
+		for _, match := range matches {
+			rel, err := filepath.Rel(fs.Cwd, match)
+			if err != nil {
+				return nil, fmt.Errorf("error resolving %s: %v", match, err)
+			}
+			inputPaths = append(inputPaths, rel)
+		}
+	}
+
+	// arguments can be anything, like a function name, so only the ones that exist are treated as paths
+	for _, arg := range args {
+		if _, err := os.Stat(arg); err == nil {
+			inputPaths = append(inputPaths, arg)
+		}
+	}
+
+	if len(inputPaths) == 0 {
+		return nil, nil
+	}
+
+	paths, err := ParseInputPaths(inputPaths, &types.LoadContextParams{Recursive: true})
+	if err != nil {
+		return nil, err
+	}
+
+	loaded := map[string]bool{}
+	for _, context := range contexts {
+		if context.FilePath != "" {
+			loaded[filepath.Clean(context.FilePath)] = true
+		}
+	}
+
+	var res []string
+	seen := map[string]bool{}
+	for _, path := range paths {
+		path = filepath.Clean(path)
+		if loaded[path] || seen[path] {
+			continue
+		}
+		seen[path] = true
+		res = append(res, path)
+	}
+
+	return res, nil
+}
+
+// MustGetPresetModelSet applies a preset's models to the plan's current model set. It returns nil if the preset doesn't set any models.
+func MustGetPresetModelSet(preset *types.Preset) *shared.ModelSet {
+	if len(preset.Models) == 0 {
+		return nil
+	}
+
+	modelSet := MustGetPlanModelSet()
+
+	for role, modelName := range preset.Models {
+		validRole := false
+		for _, r := range shared.AllModelRoles {
+			if r == role {
+				validRole = true
+				break
+			}
+		}
+		if !validRole {
+			term.OutputErrorAndExit("Preset has an unknown model role %s", role)
+		}
+
+		model, ok := shared.AvailableModelsByName[modelName]
+		if !ok {
+			term.OutputErrorAndExit("Preset uses %s for the %s role, which isn't an available model. See 'plandex models available'.", modelName, role)
+		}
+
+		err := shared.CheckRoleModel(role, model.ModelName)
+		if err != nil {
+			term.OutputErrorAndExit("Preset's %s model: %v", role, err)
+		}
+
+		modelSet.SetRoleModel(role, model)
+	}
+
+	return modelSet
+}
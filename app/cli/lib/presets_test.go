@@ -0,0 +1,33 @@
+package lib
+
+import (
+	"plandex/types"
+	"testing"
+)
+
+func TestGetPresetPrompt(t *testing.T) {
+	tests := []struct {
+		name   string
+		prompt string
+		args   []string
+		vars   map[string]string
+		want   string
+	}{
+		{"args replaced", "Test {{args}} well", []string{"a.go", "b.go"}, nil, "Test a.go b.go well"},
+		{"args appended", "Write tests", []string{"a.go"}, nil, "Write tests\n\na.go"},
+		{"vars rendered", "Explain {{file}} in {{args}}", []string{"detail"}, map[string]string{"file": "main.go"}, "Explain main.go in detail"},
+		{"variables in args aren't rendered", "Fix {{args}}", []string{"{{file}}"}, map[string]string{"file": "main.go"}, "Fix {{file}}"},
+		{"variables in appended args aren't rendered", "Fix it", []string{"{{missing}}"}, nil, "Fix it\n\n{{missing}}"},
+	}
+
+	for _, tt := range tests {
+		got, err := GetPresetPrompt(&types.Preset{Prompt: tt.prompt}, tt.args, tt.vars)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package lib
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"plandex/term"
+	"plandex/types"
+	"sort"
+)
+
+var ErrProjectCommandsNotApproved = errors.New("the project's hooks, formatters, linters, and verify and test commands haven't been approved to run on this machine. Check them and approve them with 'plandex approve'")
+
+// projectCommands is everything in the project's settings that runs a command on this machine
+type projectCommands struct {
+	VerifyCmd      string            `json:"verifyCmd,omitempty"`
+	TestCmd        string            `json:"testCmd,omitempty"`
+	Formatters     map[string]string `json:"formatters,omitempty"`
+	PreApplyHooks  []string          `json:"preApplyHooks,omitempty"`
+	PostApplyHooks []string          `json:"postApplyHooks,omitempty"`
+	Linters        map[string]string `json:"linters,omitempty"`
+	AutoLint       bool              `json:"autoLint,omitempty"`
+}
+
+func getProjectCommands(settings *types.CurrentProjectSettings) projectCommands {
+	return projectCommands{
+		VerifyCmd:      settings.VerifyCmd,
+		TestCmd:        settings.TestCmd,
+		Formatters:     settings.Formatters,
+		PreApplyHooks:  settings.PreApplyHooks,
+		PostApplyHooks: settings.PostApplyHooks,
+		Linters:        settings.Linters,
+		AutoLint:       settings.AutoLint,
+	}
+}
+
+func (commands projectCommands) empty() bool {
+	return commands.VerifyCmd == "" && commands.TestCmd == "" && len(commands.Formatters) == 0 && len(commands.PreApplyHooks) == 0 && len(commands.PostApplyHooks) == 0 && len(commands.Linters) == 0
+}
+
+// projectCommandsFingerprint identifies every command the project's settings run, so approving one version doesn't approve a changed or added command
+func projectCommandsFingerprint(settings *types.CurrentProjectSettings) string {
+	bytes, _ := json.Marshal(getProjectCommands(settings))
+	sum := sha256.Sum256(bytes)
+	return hex.EncodeToString(sum[:])
+}
+
+// IsProjectCommandsApproved returns whether the user approved the project's current commands to run on this machine. A project without any commands has nothing to approve.
+func IsProjectCommandsApproved(settings *types.CurrentProjectSettings) (bool, error) {
+	if getProjectCommands(settings).empty() {
+		return true, nil
+	}
+	clientConfig, err := LoadClientConfig()
+	if err != nil {
+		return false, err
+	}
+	return clientConfig.ProjectCommandApprovals[CurrentProjectId] == projectCommandsFingerprint(settings), nil
+}
+
+// ApproveProjectCommands records the user's approval for the project's current commands to run on this machine
+func ApproveProjectCommands(settings *types.CurrentProjectSettings) error {
+	clientConfig, err := LoadClientConfig()
+	if err != nil {
+		return err
+	}
+	if clientConfig.ProjectCommandApprovals == nil {
+		clientConfig.ProjectCommandApprovals = map[string]string{}
+	}
+	clientConfig.ProjectCommandApprovals[CurrentProjectId] = projectCommandsFingerprint(settings)
+	return WriteClientConfig(clientConfig)
+}
+
+// CheckProjectCommandsApproved returns ErrProjectCommandsNotApproved if the project's current commands haven't been approved to run on this machine
+func CheckProjectCommandsApproved(settings *types.CurrentProjectSettings) error {
+	approved, err := IsProjectCommandsApproved(settings)
+	if err != nil {
+		return fmt.Errorf("couldn't check whether the project's commands are approved: %v", err)
+	}
+	if !approved {
+		return ErrProjectCommandsNotApproved
+	}
+	return nil
+}
+
+func MustCheckProjectCommandsApproved(settings *types.CurrentProjectSettings) {
+	err := CheckProjectCommandsApproved(settings)
+	if err != nil {
+		term.OutputErrorAndExit("%v", err)
+	}
+}
+
+// WriteProjectCommandSettings writes settings after the user changed the project's commands with a plandex command. Since the user made the change, the changed commands stay approved if the ones before it were, but commands that arrived with the project, like from someone else's commit, still need 'plandex approve'.
+func WriteProjectCommandSettings(settings *types.CurrentProjectSettings, wasApproved bool) error {
+	err := WriteProjectSettings(settings)
+	if err != nil {
+		return err
+	}
+
+	if !wasApproved {
+		return nil
+	}
+
+	err = ApproveProjectCommands(settings)
+	if err != nil {
+		return fmt.Errorf("error approving the project's commands: %v", err)
+	}
+	return nil
+}
+
+// ProjectCommandLines lists each of the project's commands with what it runs for, for showing before approval
+func ProjectCommandLines(settings *types.CurrentProjectSettings) [][2]string {
+	var lines [][2]string
+
+	for _, hook := range settings.PreApplyHooks {
+		lines = append(lines, [2]string{string(ApplyHookPre) + " hook", hook})
+	}
+	for _, hook := range settings.PostApplyHooks {
+		lines = append(lines, [2]string{string(ApplyHookPost) + " hook", hook})
+	}
+
+	addByExt := func(kind string, byExt map[string]string) {
+		var exts []string
+		for ext := range byExt {
+			exts = append(exts, ext)
+		}
+		sort.Strings(exts)
+		for _, ext := range exts {
+			lines = append(lines, [2]string{fmt.Sprintf("%s for %s", kind, ext), byExt[ext]})
+		}
+	}
+	addByExt("formatter", settings.Formatters)
+
+	linterKind := "linter"
+	if settings.AutoLint {
+		linterKind = "automatic linter"
+	}
+	addByExt(linterKind, settings.Linters)
+
+	if settings.VerifyCmd != "" {
+		lines = append(lines, [2]string{"verify", settings.VerifyCmd})
+	}
+	if settings.TestCmd != "" {
+		lines = append(lines, [2]string{"test", settings.TestCmd})
+	}
+
+	return lines
+}
@@ -0,0 +1,70 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"plandex/fs"
+	"plandex/types"
+	"strings"
+)
+
+func LoadProjectSettings() (*types.CurrentProjectSettings, error) {
+	bytes, err := os.ReadFile(filepath.Join(fs.PlandexDir, "project.json"))
+	if err != nil {
+		return nil, fmt.Errorf("error reading project.json: %w", err)
+	}
+
+	var settings types.CurrentProjectSettings
+	err = json.Unmarshal(bytes, &settings)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling project.json: %v", err)
+	}
+
+	return &settings, nil
+}
+
+func WriteProjectSettings(settings *types.CurrentProjectSettings) error {
+	bytes, err := json.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("error marshalling project settings: %v", err)
+	}
+
+	// written to a temp file and renamed, so a crash or a concurrent command never leaves a partial project.json
+	err = writeFileAtomic(filepath.Join(fs.PlandexDir, "project.json"), bytes, 0644)
+	if err != nil {
+		return fmt.Errorf("error writing project.json: %v", err)
+	}
+
+	return nil
+}
+
+// UpdateProjectSettings loads project.json, applies update to it, and writes it back
+func UpdateProjectSettings(update func(settings *types.CurrentProjectSettings)) error {
+	settings, err := LoadProjectSettings()
+	if err != nil {
+		return err
+	}
+
+	update(settings)
+
+	return WriteProjectSettings(settings)
+}
+
+// ParseEnvFlags parses --env KEY=value flags into a map, or nil if there are none
+func ParseEnvFlags(flags []string) (map[string]string, error) {
+	if len(flags) == 0 {
+		return nil, nil
+	}
+
+	env := map[string]string{}
+	for _, kv := range flags {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid --env %s, expected KEY=value", kv)
+		}
+		env[k] = v
+	}
+	return env, nil
+}
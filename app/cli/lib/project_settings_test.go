@@ -0,0 +1,93 @@
+package lib
+
+import (
+	"os"
+	"path/filepath"
+	"plandex/fs"
+	"plandex/types"
+	"testing"
+)
+
+func TestWriteProjectSettings(t *testing.T) {
+	origPlandexDir := fs.PlandexDir
+	fs.PlandexDir = t.TempDir()
+	defer func() { fs.PlandexDir = origPlandexDir }()
+
+	err := os.WriteFile(filepath.Join(fs.PlandexDir, "project.json"), []byte(`{"formatters":{".go":"gofmt -w"}}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	settings, err := LoadProjectSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings.Formatters[".py"] = "black"
+
+	err = WriteProjectSettings(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := LoadProjectSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.Formatters[".go"] != "gofmt -w" || reloaded.Formatters[".py"] != "black" {
+		t.Errorf("expected the settings to round trip, got %v", reloaded.Formatters)
+	}
+
+	entries, _ := os.ReadDir(fs.PlandexDir)
+	if len(entries) != 1 {
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		t.Errorf("expected only project.json to be left, got %v", names)
+	}
+}
+
+func TestUpdateProjectSettings(t *testing.T) {
+	origPlandexDir := fs.PlandexDir
+	fs.PlandexDir = t.TempDir()
+	defer func() { fs.PlandexDir = origPlandexDir }()
+
+	err := os.WriteFile(filepath.Join(fs.PlandexDir, "project.json"), []byte(`{"formatters":{".go":"gofmt -w"}}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = UpdateProjectSettings(func(settings *types.CurrentProjectSettings) {
+		settings.Plugins = map[string]*types.PluginConfig{"jira": {Command: "jira-plugin"}}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	settings, err := LoadProjectSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.Formatters[".go"] != "gofmt -w" || settings.Plugins["jira"] == nil {
+		t.Errorf("expected the update to keep the other settings, got %+v", settings)
+	}
+}
+
+func TestParseEnvFlags(t *testing.T) {
+	env, err := ParseEnvFlags(nil)
+	if env != nil || err != nil {
+		t.Errorf("expected nil for no flags, got %v %v", env, err)
+	}
+
+	env, err = ParseEnvFlags([]string{"URL=https://x.internal/?a=b", "EMPTY="})
+	if err != nil || env["URL"] != "https://x.internal/?a=b" || env["EMPTY"] != "" || len(env) != 2 {
+		t.Errorf("expected values to be split at the first =, got %v %v", env, err)
+	}
+
+	for _, flag := range []string{"NOVALUE", "=value"} {
+		_, err = ParseEnvFlags([]string{flag})
+		if err == nil {
+			t.Errorf("expected %q to be invalid", flag)
+		}
+	}
+}
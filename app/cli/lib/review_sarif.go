@@ -0,0 +1,134 @@
+package lib
+
+import (
+	"plandex/version"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// the subset of SARIF 2.1.0 needed to report review findings, so they can be uploaded to code scanning tools
+
+type SarifLog struct {
+	Schema  string      `json:"$schema"`
+	Version string      `json:"version"`
+	Runs    []*SarifRun `json:"runs"`
+}
+
+type SarifRun struct {
+	Tool    SarifTool      `json:"tool"`
+	Results []*SarifResult `json:"results"`
+}
+
+type SarifTool struct {
+	Driver SarifDriver `json:"driver"`
+}
+
+type SarifDriver struct {
+	Name           string       `json:"name"`
+	Version        string       `json:"version"`
+	InformationUri string       `json:"informationUri"`
+	Rules          []*SarifRule `json:"rules"`
+}
+
+type SarifRule struct {
+	Id               string       `json:"id"`
+	ShortDescription SarifMessage `json:"shortDescription"`
+}
+
+type SarifResult struct {
+	RuleId     string           `json:"ruleId"`
+	Level      string           `json:"level"`
+	Message    SarifMessage     `json:"message"`
+	Locations  []*SarifLocation `json:"locations"`
+	Properties SarifResultProps `json:"properties"`
+}
+
+type SarifResultProps struct {
+	Severity   shared.ReviewSeverity `json:"severity"`
+	Suggestion string                `json:"suggestion,omitempty"`
+}
+
+type SarifMessage struct {
+	Text string `json:"text"`
+}
+
+type SarifLocation struct {
+	PhysicalLocation SarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type SarifPhysicalLocation struct {
+	ArtifactLocation SarifArtifactLocation `json:"artifactLocation"`
+	Region           *SarifRegion          `json:"region,omitempty"`
+}
+
+type SarifArtifactLocation struct {
+	Uri string `json:"uri"`
+}
+
+type SarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+const sarifReviewRuleId = "plandex-review"
+
+var sarifLevelsBySeverity = map[shared.ReviewSeverity]string{
+	shared.ReviewSeverityCritical: "error",
+	shared.ReviewSeverityHigh:     "error",
+	shared.ReviewSeverityMedium:   "warning",
+	shared.ReviewSeverityLow:      "note",
+	shared.ReviewSeverityInfo:     "note",
+}
+
+// GetReviewSarif converts review findings to a SARIF log with a single run. Suggested fixes are appended to each message, since SARIF fixes need exact replacement ranges.
+func GetReviewSarif(findings []*shared.ReviewFinding) *SarifLog {
+	results := []*SarifResult{}
+	for _, finding := range findings {
+		message := finding.Message
+		if finding.Suggestion != "" {
+			message += "\n\nSuggestion: " + finding.Suggestion
+		}
+
+		location := &SarifLocation{
+			PhysicalLocation: SarifPhysicalLocation{
+				ArtifactLocation: SarifArtifactLocation{Uri: finding.Path},
+			},
+		}
+		if finding.Line > 0 {
+			location.PhysicalLocation.Region = &SarifRegion{StartLine: finding.Line}
+		}
+
+		results = append(results, &SarifResult{
+			RuleId:    sarifReviewRuleId,
+			Level:     sarifLevelsBySeverity[finding.Severity],
+			Message:   SarifMessage{Text: message},
+			Locations: []*SarifLocation{location},
+			Properties: SarifResultProps{
+				Severity:   finding.Severity,
+				Suggestion: finding.Suggestion,
+			},
+		})
+	}
+
+	return &SarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []*SarifRun{
+			{
+				Tool: SarifTool{
+					Driver: SarifDriver{
+						Name:           "plandex",
+						Version:        version.Version,
+						InformationUri: "https://plandex.ai",
+						Rules: []*SarifRule{
+							{
+								Id:               sarifReviewRuleId,
+								ShortDescription: SarifMessage{Text: "Problem found by plandex review"},
+							},
+						},
+					},
+				},
+				Results: results,
+			},
+		},
+	}
+}
@@ -0,0 +1,162 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"plandex/api"
+	"plandex/fs"
+	"sort"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+)
+
+type RunStatus string
+
+const (
+	RunStatusApplied RunStatus = "applied"
+	// built and waiting to be applied, as reported by the MCP propose_plan tool
+	RunStatusReady       RunStatus = "ready"
+	RunStatusNoChanges   RunStatus = "no_changes"
+	RunStatusPlanFailed  RunStatus = "plan_failed"
+	RunStatusBuildFailed RunStatus = "build_failed"
+	// written before applying, so a report is left behind if apply exits early
+	RunStatusApplyFailed RunStatus = "apply_failed"
+)
+
+// RunReport is the summary written by 'plandex run' for CI to pick up
+type RunReport struct {
+	Status    RunStatus `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	PlanId    string    `json:"planId"`
+	Branch    string    `json:"branch"`
+	GitBranch string    `json:"gitBranch,omitempty"`
+	CommitSha string    `json:"commitSha,omitempty"`
+	Prompt    string    `json:"prompt"`
+
+	// descriptions of the changes the plan made
+	Changes      []string `json:"changes,omitempty"`
+	Files        []string `json:"files,omitempty"`
+	FailedFiles  []string `json:"failedFiles,omitempty"`
+	RemovedFiles []string `json:"removedFiles,omitempty"`
+
+	// the risky operations that stopped the apply, when it's run without --allow-risky
+	SecurityFlags []*shared.SecurityFlag `json:"securityFlags,omitempty"`
+
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+}
+
+// RunPlanAndBuild sends the prompt to the current plan without any prompts or stream UI, waits for the plan and its builds to finish, and reports what's ready to apply. Any pending changes from earlier prompts are included.
+func RunPlanAndBuild(prompt string, report *RunReport) {
+	report.PlanId = CurrentPlanId
+	report.Branch = CurrentBranch
+	report.Prompt = prompt
+
+	fail := func(status RunStatus, err error) {
+		report.Status = status
+		report.Error = err.Error()
+	}
+
+	LoadMcpPromptContext(prompt)
+
+	_, err := UpdateContext(nil)
+	if err != nil {
+		fail(RunStatusPlanFailed, fmt.Errorf("error updating context: %v", err))
+		return
+	}
+
+	contexts, apiErr := api.Client.ListContext(CurrentPlanId, CurrentBranch)
+	if apiErr != nil {
+		fail(RunStatusPlanFailed, fmt.Errorf("error getting context: %v", apiErr.Msg))
+		return
+	}
+
+	paths, err := fs.GetProjectPaths(fs.GetBaseDirForContexts(contexts))
+	if err != nil {
+		fail(RunStatusPlanFailed, fmt.Errorf("error getting project paths: %v", err))
+		return
+	}
+
+	// errors after the replies finish come from building
+	repliesFinished := false
+	err = tellAndWait(CurrentPlanId, CurrentBranch, shared.TellPlanRequest{
+		Prompt:        prompt,
+		ConnectStream: true,
+		AutoContinue:  true,
+		ProjectPaths:  paths.ActivePaths,
+		BuildMode:     shared.BuildModeAuto,
+		ApiKey:        os.Getenv("OPENAI_API_KEY"),
+		Mock:          GetMockConfig(),
+		ModelSet:      MustGetDefaultModelSet(),
+	}, func(msg *shared.StreamMessage) {
+		if msg.Type == shared.StreamMessageRepliesFinished {
+			repliesFinished = true
+		}
+	})
+	if err != nil {
+		if repliesFinished {
+			fail(RunStatusBuildFailed, err)
+		} else {
+			fail(RunStatusPlanFailed, err)
+		}
+		return
+	}
+
+	// lint fixes change the pending files, so linting comes before they're reported
+	lintPassed := MaybeRunLintLoop(CurrentPlanId, CurrentBranch)
+
+	currentPlanState, apiErr := api.Client.GetCurrentPlanState(CurrentPlanId, CurrentBranch)
+	if apiErr != nil {
+		fail(RunStatusBuildFailed, fmt.Errorf("error getting current plan state: %v", apiErr.Msg))
+		return
+	}
+
+	for _, desc := range currentPlanState.ConvoMessageDescriptions {
+		if desc.AppliedAt == nil && desc.CommitMsg != "" {
+			report.Changes = append(report.Changes, desc.CommitMsg)
+		}
+	}
+
+	failed := map[string]bool{}
+	for _, result := range currentPlanState.PlanResult.Results {
+		if result.IsPending() && result.AnyFailed {
+			failed[result.Path] = true
+		}
+	}
+	for path := range failed {
+		report.FailedFiles = append(report.FailedFiles, path)
+	}
+	sort.Strings(report.FailedFiles)
+
+	planFiles := currentPlanState.CurrentPlanFiles
+	for path := range planFiles.Files {
+		if !planFiles.Removed[path] {
+			report.Files = append(report.Files, path)
+		}
+	}
+	for path := range planFiles.Removed {
+		report.RemovedFiles = append(report.RemovedFiles, path)
+	}
+	sort.Strings(report.Files)
+	sort.Strings(report.RemovedFiles)
+
+	if currentPlanState.HasPendingBuilds() {
+		fail(RunStatusBuildFailed, fmt.Errorf("the plan still has changes that weren't built"))
+		return
+	}
+
+	if len(report.FailedFiles) > 0 {
+		fail(RunStatusBuildFailed, fmt.Errorf("some changes couldn't be built"))
+		return
+	}
+
+	if !lintPassed {
+		fail(RunStatusBuildFailed, fmt.Errorf("linters still report problems in the changes"))
+		return
+	}
+
+	if len(report.Files) == 0 && len(report.RemovedFiles) == 0 {
+		report.Status = RunStatusNoChanges
+	}
+}
@@ -0,0 +1,52 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// RunPlanAndBuildInSubprocess is RunPlanAndBuild in a separate 'plandex run --no-apply' process, like ApplyInSubprocess, so errors that exit can't take down the server that called it. Returns the run's report.
+func RunPlanAndBuildInSubprocess(prompt string) (*RunReport, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("error finding plandex executable: %v", err)
+	}
+
+	dir, err := os.MkdirTemp("", "plandex-run-")
+	if err != nil {
+		return nil, fmt.Errorf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the prompt goes in a file so its length isn't limited by the command line
+	promptPath := filepath.Join(dir, "prompt.txt")
+	err = os.WriteFile(promptPath, []byte(prompt), 0600)
+	if err != nil {
+		return nil, fmt.Errorf("error writing prompt: %v", err)
+	}
+	reportPath := filepath.Join(dir, "report.json")
+
+	cmd := exec.Command(exe, "run", "--no-apply", "--file", promptPath, "--report", reportPath, "--plan", CurrentPlanId)
+	cmd.Env = append(os.Environ(), "PLANDEX_SKIP_UPGRADE=1", "PLANDEX_DISABLE_SUGGESTIONS=1")
+	output, runErr := cmd.CombinedOutput()
+
+	// the report is written for every outcome other than errors exiting with 1
+	bytes, err := os.ReadFile(reportPath)
+	if err != nil {
+		if runErr != nil {
+			return nil, fmt.Errorf("run failed:\n%s", output)
+		}
+		return nil, fmt.Errorf("error reading run report: %v", err)
+	}
+
+	var report RunReport
+	err = json.Unmarshal(bytes, &report)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling run report: %v", err)
+	}
+
+	return &report, nil
+}
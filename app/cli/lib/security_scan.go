@@ -0,0 +1,176 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"plandex/api"
+	"plandex/term"
+	"sort"
+
+	"github.com/fatih/color"
+	"github.com/plandex/plandex/shared"
+)
+
+const (
+	SecurityScanRules = "rules"
+	SecurityScanModel = "model"
+	SecurityScanOff   = "off"
+)
+
+// getSecurityFlags checks the files about to be written with the rule-based scan, and with a model pass too if the project's "securityScan" setting is "model". A failed model pass falls back to the rules' flags.
+func getSecurityFlags(planId, branch string, planFiles *shared.CurrentPlanFiles, unchangedPaths map[string]bool) ([]*shared.SecurityFlag, error) {
+	settings, err := LoadProjectSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	mode := settings.SecurityScan
+	if mode == "" {
+		mode = SecurityScanRules
+	}
+	if mode == SecurityScanOff {
+		return nil, nil
+	}
+
+	var flags []*shared.SecurityFlag
+	for _, path := range applyPathsOf(planFiles.Files) {
+		if unchangedPaths[path] || planFiles.Removed[path] {
+			continue
+		}
+
+		original, _, err := readProjectFileForDiff(path)
+		if err != nil {
+			return nil, err
+		}
+
+		flags = append(flags, shared.FlagSecurityRisks(path, original, planFiles.Files[path])...)
+	}
+
+	if mode != SecurityScanModel {
+		return flags, nil
+	}
+
+	apiKey := os.Getenv("OPENAI_API_KEY")
+	if apiKey == "" {
+		return nil, fmt.Errorf("the model security scan needs OPENAI_API_KEY to be set")
+	}
+
+	diff, err := buildPlanDiff(planFiles, false, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	modelSet, _, err := GetModelSet(planId, branch)
+	if err != nil {
+		return nil, err
+	}
+
+	res, apiErr := api.Client.SecurityScan(shared.SecurityScanRequest{
+		ApiKey:   apiKey,
+		ModelSet: modelSet,
+		Diff:     diff,
+	})
+	if apiErr != nil {
+		color.New(term.ColorHiYellow).Printf("⚠️  Model security scan failed, so only rule-based checks were used: %v\n\n", apiErr.Msg)
+		return flags, nil
+	}
+
+	return append(flags, res.Flags...), nil
+}
+
+// mustConfirmSecurityRisks lists the risky operations in the files about to be written and asks before applying them, even when the apply is otherwise confirmed automatically. With AllowRisky, the flags are only listed. It returns the flagged paths to skip if the user declines, or nil to apply everything.
+func mustConfirmSecurityRisks(planId, branch string, planFiles *shared.CurrentPlanFiles, unchangedPaths map[string]bool, flags ApplyFlags) map[string]bool {
+	securityFlags, err := getSecurityFlags(planId, branch, planFiles, unchangedPaths)
+	if err != nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("Error checking changes for security risks: %v", err)
+	}
+
+	if len(securityFlags) == 0 {
+		return nil
+	}
+
+	term.StopSpinner()
+
+	sort.SliceStable(securityFlags, func(i, j int) bool {
+		if securityFlags[i].Path != securityFlags[j].Path {
+			return securityFlags[i].Path < securityFlags[j].Path
+		}
+		return securityFlags[i].Line < securityFlags[j].Line
+	})
+
+	flaggedPaths := map[string]bool{}
+	color.New(color.Bold, term.ColorHiYellow).Println("🛡️  These changes include risky operations:")
+	for i, flag := range securityFlags {
+		if i == 0 || securityFlags[i-1].Path != flag.Path {
+			flaggedPaths[flag.Path] = true
+			color.New(color.Bold).Println("• " + flag.Path)
+		}
+
+		location := ""
+		if flag.Line > 0 {
+			location = fmt.Sprintf("line %d: ", flag.Line)
+		}
+		source := ""
+		if flag.Source == shared.SecurityFlagSourceModel {
+			source = color.New(color.FgHiBlack).Sprint(" (model)")
+		}
+		fmt.Printf("  [%s] %s%s%s\n", flag.Risk, location, flag.Message, source)
+	}
+	fmt.Println()
+
+	if flags.AllowRisky {
+		term.ResumeSpinner()
+		return nil
+	}
+
+	suffix := ""
+	if len(flaggedPaths) > 1 {
+		suffix = "s"
+	}
+
+	if flags.NoPrompt {
+		msg := fmt.Sprintf("Refusing to apply %d flagged file%s without --allow-risky", len(flaggedPaths), suffix)
+		if flags.Report != nil {
+			flags.Report.Error = msg
+			flags.Report.SecurityFlags = securityFlags
+		}
+		term.OutputErrorAndExit("%s", msg)
+	}
+
+	confirmed, err := term.ConfirmYesNo("Apply %d flagged file%s? Declining skips them and applies the rest", len(flaggedPaths), suffix)
+	if err != nil {
+		term.OutputErrorAndExit("failed to get confirmation user input: %s", err)
+	}
+
+	term.ResumeSpinner()
+
+	if confirmed {
+		return nil
+	}
+	return flaggedPaths
+}
+
+// skipFlaggedPaths leaves the flagged files out of what's applied. A skipped move keeps its original file too, so declining a moved file doesn't delete it.
+func skipFlaggedPaths(toApply map[string]string, toRemove []string, movedFrom map[string]string, skip map[string]bool) (map[string]string, []string) {
+	keepFrom := map[string]bool{}
+	filtered := map[string]string{}
+	for path, content := range toApply {
+		if skip[path] {
+			if from, ok := movedFrom[path]; ok {
+				keepFrom[from] = true
+			}
+			continue
+		}
+		filtered[path] = content
+	}
+
+	var filteredRemove []string
+	for _, path := range toRemove {
+		if !keepFrom[path] {
+			filteredRemove = append(filteredRemove, path)
+		}
+	}
+
+	return filtered, filteredRemove
+}
@@ -0,0 +1,34 @@
+package lib
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSkipFlaggedPaths(t *testing.T) {
+	toApply := map[string]string{
+		"main.go":       "package main",
+		"net/client.go": "package net",
+		"pkg/moved.go":  "package pkg",
+	}
+	toRemove := []string{"old/moved.go", "unused.go"}
+	movedFrom := map[string]string{"pkg/moved.go": "old/moved.go"}
+
+	applied, removed := skipFlaggedPaths(toApply, toRemove, movedFrom, map[string]bool{
+		"net/client.go": true,
+		"pkg/moved.go":  true,
+	})
+
+	if !reflect.DeepEqual(applied, map[string]string{"main.go": "package main"}) {
+		t.Errorf("expected only main.go to be applied, got %v", applied)
+	}
+
+	// the skipped move's original file stays, while the unrelated removal still happens
+	if !reflect.DeepEqual(removed, []string{"unused.go"}) {
+		t.Errorf("expected only unused.go to be removed, got %v", removed)
+	}
+
+	if len(toApply) != 3 {
+		t.Errorf("expected the plan's files to be left as they were, got %v", toApply)
+	}
+}
@@ -0,0 +1,95 @@
+package lib
+
+import (
+	"plandex/api"
+	"plandex/auth"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// IsOwnPlan is true if the signed in user created the plan, rather than a teammate who shared it with the org
+func IsOwnPlan(plan *shared.Plan) bool {
+	return auth.Current == nil || plan.OwnerId == auth.Current.UserId
+}
+
+// OwnPlans filters plans to the ones the current user owns, since listing a project's plans also returns plans teammates shared in it
+func OwnPlans(plans []*shared.Plan) []*shared.Plan {
+	var res []*shared.Plan
+	for _, plan := range plans {
+		if IsOwnPlan(plan) {
+			res = append(res, plan)
+		}
+	}
+	return res
+}
+
+// FindPlan looks up a plan by name or id. A teammate's plan can have the same name as one of yours, so your own plans are checked first.
+func FindPlan(plans []*shared.Plan, nameOrId string) *shared.Plan {
+	for _, plan := range plans {
+		if IsOwnPlan(plan) && (plan.Name == nameOrId || plan.Id == nameOrId) {
+			return plan
+		}
+	}
+	for _, plan := range plans {
+		if plan.Name == nameOrId || plan.Id == nameOrId {
+			return plan
+		}
+	}
+	return nil
+}
+
+// FindSharedPlan looks for a plan a teammate shared with the org by name or id. Shared plans can be opened from any project, so a teammate's plan can be reviewed and applied from your own checkout.
+func FindSharedPlan(nameOrId string) (*shared.Plan, *shared.ApiError) {
+	plans, apiErr := api.Client.ListSharedPlans()
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	for _, plan := range plans {
+		if plan.Id == nameOrId || plan.Name == nameOrId {
+			return plan, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// GetPlanOwnerNames maps the owners of teammates' plans to their names, falling back to their emails. It's best effort, so an empty map is returned if the org's users can't be listed.
+func GetPlanOwnerNames(plans []*shared.Plan) map[string]string {
+	names := map[string]string{}
+
+	needsNames := false
+	for _, plan := range plans {
+		if !IsOwnPlan(plan) {
+			needsNames = true
+			break
+		}
+	}
+	if !needsNames {
+		return names
+	}
+
+	res, apiErr := api.Client.ListUsers()
+	if apiErr != nil {
+		return names
+	}
+
+	for _, user := range res.Users {
+		if user.Name != "" {
+			names[user.Id] = user.Name
+		} else {
+			names[user.Id] = user.Email
+		}
+	}
+
+	return names
+}
+
+// PlanOwnerLabel describes who a teammate's plan belongs to, like "shared by Jo"
+func PlanOwnerLabel(plan *shared.Plan, ownerNames map[string]string) string {
+	name := ownerNames[plan.OwnerId]
+	if name == "" {
+		name = "a teammate"
+	}
+	return "shared by " + name
+}
@@ -0,0 +1,38 @@
+package lib
+
+import (
+	"plandex/auth"
+	"plandex/types"
+	"testing"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func TestFindPlanPrefersOwnPlans(t *testing.T) {
+	origAuth := auth.Current
+	auth.Current = &types.ClientAuth{ClientAccount: types.ClientAccount{UserId: "me"}}
+	defer func() { auth.Current = origAuth }()
+
+	teammates := &shared.Plan{Id: "1", Name: "refactor", OwnerId: "teammate"}
+	mine := &shared.Plan{Id: "2", Name: "refactor", OwnerId: "me"}
+	onlyTeammates := &shared.Plan{Id: "3", Name: "docs", OwnerId: "teammate"}
+	plans := []*shared.Plan{teammates, mine, onlyTeammates}
+
+	if plan := FindPlan(plans, "refactor"); plan != mine {
+		t.Errorf("FindPlan(refactor) = %v, want your own plan", plan)
+	}
+	if plan := FindPlan(plans, "1"); plan != teammates {
+		t.Errorf("FindPlan(1) = %v, want the teammate's plan by id", plan)
+	}
+	if plan := FindPlan(plans, "docs"); plan != onlyTeammates {
+		t.Errorf("FindPlan(docs) = %v, want the teammate's plan when you have none by that name", plan)
+	}
+	if plan := FindPlan(plans, "missing"); plan != nil {
+		t.Errorf("FindPlan(missing) = %v, want nil", plan)
+	}
+
+	own := OwnPlans(plans)
+	if len(own) != 1 || own[0] != mine {
+		t.Errorf("OwnPlans() = %v, want only your plan", own)
+	}
+}
@@ -0,0 +1,97 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"plandex/api"
+	"plandex/fs"
+	"plandex/term"
+	"plandex/types"
+	"sync"
+)
+
+// the stream a watcher takes over if the terminal is closed. A command can show more than one stream in turn, so the latest one is handed off.
+var handoffPlanId, handoffBranch string
+var handoffMu sync.Mutex
+var handoffOnce sync.Once
+
+// HandOffStreamOnHangup watches for the terminal showing a plan's stream being closed. If it is, a background watcher is started to take over the stream, so its usage is still recorded and a notification is shown when it finishes, rather than the plan running on the server with no one connected.
+func HandOffStreamOnHangup(planId, branch string) {
+	handoffMu.Lock()
+	handoffPlanId, handoffBranch = planId, branch
+	handoffMu.Unlock()
+
+	handoffOnce.Do(func() {
+		ch := make(chan os.Signal, 1)
+		signal.Notify(ch, hangupSignals...)
+
+		go func() {
+			<-ch
+
+			handoffMu.Lock()
+			planId, branch := handoffPlanId, handoffBranch
+			handoffMu.Unlock()
+
+			log.Printf("Terminal closed, handing off stream for plan %s on branch %s to a watcher\n", planId, branch)
+
+			err := startStreamWatcher(planId, branch)
+			if err != nil {
+				log.Println("Error starting stream watcher:", err)
+			}
+
+			term.Exit(0)
+		}()
+	})
+}
+
+func startStreamWatcher(planId, branch string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("error finding plandex executable: %v", err)
+	}
+
+	cmd := exec.Command(exe, "watch-stream", planId, branch)
+	cmd.Dir = fs.Cwd
+	cmd.Env = append(os.Environ(), "PLANDEX_SKIP_UPGRADE=1")
+	cmd.SysProcAttr = detachedProcAttr()
+
+	err = cmd.Start()
+	if err != nil {
+		return fmt.Errorf("error starting watcher: %v", err)
+	}
+
+	return cmd.Process.Release()
+}
+
+// WatchStream connects to a running stream with no UI and waits for it to finish, recording its usage and skipping missing files, then shows a notification unless it was stopped. A stream that already finished can't be connected to, and isn't notified about.
+func WatchStream(planId, branch string) error {
+	connected := false
+	err := waitForStream(planId, branch, nil, func(onStream types.OnStreamPlan) error {
+		apiErr := api.Client.ConnectPlan(planId, branch, onStream)
+		if apiErr != nil {
+			return fmt.Errorf("error connecting to stream: %v", apiErr.Msg)
+		}
+		connected = true
+		return nil
+	})
+
+	if !connected {
+		return err
+	} else if errors.Is(err, errStreamAborted) {
+		return nil
+	}
+
+	var errMsg string
+	if err != nil {
+		errMsg = err.Error()
+	}
+
+	NotifyEnabled = true
+	NotifyStreamDone(planId, branch, errMsg)
+
+	return err
+}
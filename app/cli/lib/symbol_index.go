@@ -0,0 +1,549 @@
+package lib
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	neturl "net/url"
+	"os"
+	"path/filepath"
+	"plandex/api"
+	"plandex/fs"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// symbolMapContextName is the name of the note a symbol index is loaded as. Loading an index again replaces it.
+const symbolMapContextName = "symbol-map"
+
+type SymbolIndexFormat string
+
+const (
+	SymbolIndexFormatCtags SymbolIndexFormat = "ctags"
+	SymbolIndexFormatLsif  SymbolIndexFormat = "LSIF"
+)
+
+type indexedSymbol struct {
+	Name  string
+	Kind  string
+	Scope string
+	// 1-indexed, or 0 if the index doesn't record it
+	Line int
+}
+
+// SymbolMap is the definitions in an index, by project-relative path
+type SymbolMap struct {
+	Format  SymbolIndexFormat
+	Symbols map[string][]*indexedSymbol
+}
+
+// ParseSymbolIndex reads an existing ctags or LSIF index. ctags indexes can be in the classic tags format or universal-ctags' json output. LSIF dumps can be json lines or a single json array. Symbols in files outside the project, and local symbols like parameters, are skipped.
+func ParseSymbolIndex(path string) (*SymbolMap, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening symbol index: %v", err)
+	}
+	defer f.Close()
+
+	reader := bufio.NewReaderSize(f, 1024*1024)
+
+	first, err := peekNonSpace(reader)
+	if err == io.EOF {
+		return nil, fmt.Errorf("%s is empty", path)
+	} else if err != nil {
+		return nil, fmt.Errorf("error reading symbol index: %v", err)
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving %s: %v", path, err)
+	}
+	indexDir := filepath.Dir(absPath)
+
+	if first == '[' {
+		return parseLsif(reader, indexDir)
+	}
+
+	if first == '{' {
+		// universal-ctags json and LSIF are both json lines, so the first object tells them apart
+		line, err := reader.Peek(4096)
+		if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+			return nil, fmt.Errorf("error reading symbol index: %v", err)
+		}
+		if strings.Contains(string(line), `"_type"`) {
+			return parseCtagsJson(reader, indexDir)
+		}
+		return parseLsif(reader, indexDir)
+	}
+
+	return parseCtags(reader, indexDir)
+}
+
+func peekNonSpace(reader *bufio.Reader) (byte, error) {
+	for {
+		b, err := reader.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+		if !strings.ContainsRune(" \t\r\n", rune(b[0])) {
+			return b[0], nil
+		}
+		reader.Discard(1)
+	}
+}
+
+// projectRelPath resolves a path from an index to a path relative to the project root. Relative paths are relative to root, the dir the index was generated in. ok is false for paths outside the project.
+func projectRelPath(path, root string) (rel string, ok bool) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+
+	// an index generated on another machine, like in CI, has paths under its own checkout
+	for _, base := range []string{fs.ProjectRoot, root} {
+		rel, err := filepath.Rel(base, path)
+		if err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel), true
+		}
+	}
+
+	return "", false
+}
+
+// kinds that are only visible inside a function, which would crowd out the definitions the model can actually use
+var skippedSymbolKinds = map[string]bool{
+	"local":     true,
+	"parameter": true,
+	"label":     true,
+}
+
+// the single-letter ctags kinds that mean the same thing across most languages. Other letters are kept as they are.
+var ctagsKindLetters = map[string]string{
+	"c": "class",
+	"d": "macro",
+	"e": "enumerator",
+	"f": "function",
+	"g": "enum",
+	"i": "interface",
+	"l": "local",
+	"m": "member",
+	"n": "namespace",
+	"s": "struct",
+	"t": "type",
+	"v": "variable",
+}
+
+// the kinds of enclosing symbols that ctags names scope fields for, like class:Foo. Extension fields with other names, like language, inherits, or implementation, aren't scopes.
+var ctagsScopeKinds = map[string]bool{
+	"class":     true,
+	"struct":    true,
+	"union":     true,
+	"enum":      true,
+	"interface": true,
+	"namespace": true,
+	"module":    true,
+	"package":   true,
+	"function":  true,
+	"method":    true,
+	"type":      true,
+	"trait":     true,
+	"object":    true,
+	"record":    true,
+	"protocol":  true,
+}
+
+func ctagsKind(kind string) string {
+	if name, ok := ctagsKindLetters[kind]; ok {
+		return name
+	}
+	return kind
+}
+
+func (m *SymbolMap) add(path, root string, symbol *indexedSymbol) {
+	if skippedSymbolKinds[symbol.Kind] || symbol.Name == "" {
+		return
+	}
+
+	rel, ok := projectRelPath(path, root)
+	if !ok {
+		return
+	}
+
+	m.Symbols[rel] = append(m.Symbols[rel], symbol)
+}
+
+// parseCtags parses the classic tags format: name, file, and address separated by tabs, then ;" and the extension fields
+func parseCtags(reader io.Reader, root string) (*SymbolMap, error) {
+	m := &SymbolMap{Format: SymbolIndexFormatCtags, Symbols: map[string][]*indexedSymbol{}}
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "!_TAG_") {
+			continue
+		}
+
+		parts := strings.SplitN(line, "\t", 3)
+		if len(parts) < 3 {
+			return nil, fmt.Errorf("not a ctags or LSIF index: unexpected line %q", truncateSymbolLine(line))
+		}
+
+		symbol := &indexedSymbol{Name: parts[0]}
+		address := parts[2]
+		var fields []string
+
+		// the address is an ex command, which can itself contain tabs, so the fields start after its ;" terminator
+		if i := strings.LastIndex(address, ";\"\t"); i != -1 {
+			fields = strings.Split(address[i+3:], "\t")
+			address = address[:i]
+		} else {
+			address = strings.TrimSuffix(address, ";\"")
+		}
+
+		if n, err := strconv.Atoi(address); err == nil {
+			symbol.Line = n
+		}
+
+		for _, field := range fields {
+			key, value, hasKey := strings.Cut(field, ":")
+			switch {
+			case !hasKey:
+				symbol.Kind = ctagsKind(field)
+			case key == "kind":
+				symbol.Kind = ctagsKind(value)
+			case key == "line":
+				if n, err := strconv.Atoi(value); err == nil {
+					symbol.Line = n
+				}
+			case key == "scope":
+				// with --fields=+Z, the scope field is scope:<kind>:<name>
+				if _, name, ok := strings.Cut(value, ":"); ok {
+					symbol.Scope = name
+				} else {
+					symbol.Scope = value
+				}
+			case ctagsScopeKinds[key]:
+				// otherwise scope fields are named for the kind of the enclosing symbol, like class:Foo
+				symbol.Scope = value
+			}
+			// other fields, like language:Go from --fields=+l or inherits:Base from +i, don't name a scope
+		}
+
+		m.add(parts[1], root, symbol)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading tags: %v", err)
+	}
+
+	return m, nil
+}
+
+// parseCtagsJson parses the output of universal-ctags with --output-format=json
+func parseCtagsJson(reader io.Reader, root string) (*SymbolMap, error) {
+	m := &SymbolMap{Format: SymbolIndexFormatCtags, Symbols: map[string][]*indexedSymbol{}}
+
+	decoder := json.NewDecoder(reader)
+	for {
+		var tag struct {
+			Type  string `json:"_type"`
+			Name  string `json:"name"`
+			Path  string `json:"path"`
+			Line  int    `json:"line"`
+			Kind  string `json:"kind"`
+			Scope string `json:"scope"`
+		}
+		err := decoder.Decode(&tag)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("error decoding ctags json: %v", err)
+		}
+
+		if tag.Type != "tag" {
+			continue
+		}
+
+		m.add(tag.Path, root, &indexedSymbol{
+			Name:  tag.Name,
+			Kind:  ctagsKind(tag.Kind),
+			Scope: tag.Scope,
+			Line:  tag.Line,
+		})
+	}
+
+	return m, nil
+}
+
+// LSP symbol kinds, as used in LSIF range tags
+var lsifSymbolKinds = map[int]string{
+	1:  "file",
+	2:  "module",
+	3:  "namespace",
+	4:  "package",
+	5:  "class",
+	6:  "method",
+	7:  "property",
+	8:  "field",
+	9:  "constructor",
+	10: "enum",
+	11: "interface",
+	12: "function",
+	13: "variable",
+	14: "constant",
+	22: "enum member",
+	23: "struct",
+	24: "event",
+	25: "operator",
+	26: "type parameter",
+}
+
+type lsifElement struct {
+	Id    json.RawMessage   `json:"id"`
+	Type  string            `json:"type"`
+	Label string            `json:"label"`
+	Uri   string            `json:"uri"`
+	OutV  json.RawMessage   `json:"outV"`
+	InVs  []json.RawMessage `json:"inVs"`
+	// metaData vertices
+	ProjectRoot string `json:"projectRoot"`
+	// range vertices
+	Start *struct {
+		Line int `json:"line"`
+	} `json:"start"`
+	Tag *struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+		Kind int    `json:"kind"`
+	} `json:"tag"`
+}
+
+// lsifId normalizes an element id, which LSIF allows to be a number or a string
+func lsifId(raw json.RawMessage) string {
+	return strings.Trim(string(raw), `"`)
+}
+
+// parseLsif collects the ranges tagged as definitions and the documents that contain them. Everything else in the dump, like references and hover results, is skipped as it's decoded, so huge dumps don't have to fit in memory.
+func parseLsif(reader *bufio.Reader, root string) (*SymbolMap, error) {
+	m := &SymbolMap{Format: SymbolIndexFormatLsif, Symbols: map[string][]*indexedSymbol{}}
+
+	decoder := json.NewDecoder(reader)
+
+	if first, _ := peekNonSpace(reader); first == '[' {
+		if _, err := decoder.Token(); err != nil {
+			return nil, fmt.Errorf("error decoding LSIF: %v", err)
+		}
+	}
+
+	documents := map[string]string{}
+	definitions := map[string]*indexedSymbol{}
+	contains := map[string][]string{}
+
+	for decoder.More() {
+		var el lsifElement
+		err := decoder.Decode(&el)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding LSIF: %v", err)
+		}
+
+		switch {
+		case el.Type == "vertex" && el.Label == "metaData":
+			if el.ProjectRoot != "" {
+				if u, err := neturl.Parse(el.ProjectRoot); err == nil && u.Path != "" {
+					root = filepath.FromSlash(u.Path)
+				}
+			}
+		case el.Type == "vertex" && el.Label == "document":
+			documents[lsifId(el.Id)] = el.Uri
+		case el.Type == "vertex" && el.Label == "range":
+			if el.Tag == nil || el.Tag.Type != "definition" {
+				continue
+			}
+			symbol := &indexedSymbol{Name: el.Tag.Text, Kind: lsifSymbolKinds[el.Tag.Kind]}
+			if el.Start != nil {
+				symbol.Line = el.Start.Line + 1
+			}
+			definitions[lsifId(el.Id)] = symbol
+		case el.Type == "edge" && el.Label == "contains":
+			outV := lsifId(el.OutV)
+			for _, inV := range el.InVs {
+				contains[outV] = append(contains[outV], lsifId(inV))
+			}
+		}
+	}
+
+	if len(documents) == 0 {
+		return nil, fmt.Errorf("not a ctags or LSIF index: no LSIF documents found")
+	}
+
+	for docId, rangeIds := range contains {
+		uri, ok := documents[docId]
+		if !ok {
+			// projects contain documents too
+			continue
+		}
+
+		path := uri
+		if u, err := neturl.Parse(uri); err == nil && u.Scheme == "file" {
+			path = filepath.FromSlash(u.Path)
+		}
+
+		for _, rangeId := range rangeIds {
+			if symbol, ok := definitions[rangeId]; ok {
+				m.add(path, root, symbol)
+			}
+		}
+	}
+
+	return m, nil
+}
+
+func truncateSymbolLine(line string) string {
+	if len(line) > 80 {
+		return line[:80] + "..."
+	}
+	return line
+}
+
+func (m *SymbolMap) NumSymbols() int {
+	n := 0
+	for _, symbols := range m.Symbols {
+		n += len(symbols)
+	}
+	return n
+}
+
+// Body formats the map as a note, with each file followed by its symbols in the order they're defined
+func (m *SymbolMap) Body(indexPath string) string {
+	var paths []string
+	for path := range m.Symbols {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Symbol map of the project, imported from the %s index %s. Each file is followed by the symbols it defines, as kind, name, and line.\n", m.Format, indexPath)
+
+	for _, path := range paths {
+		symbols := m.Symbols[path]
+		sort.SliceStable(symbols, func(i, j int) bool {
+			return symbols[i].Line < symbols[j].Line
+		})
+
+		fmt.Fprintf(&b, "\n%s\n", path)
+		for _, symbol := range symbols {
+			b.WriteString(" ")
+			if symbol.Kind != "" {
+				b.WriteString(" " + symbol.Kind)
+			}
+			b.WriteString(" ")
+			if symbol.Scope != "" {
+				b.WriteString(symbol.Scope + ".")
+			}
+			b.WriteString(symbol.Name)
+			if symbol.Line > 0 {
+				fmt.Fprintf(&b, ":%d", symbol.Line)
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	return b.String()
+}
+
+// LoadSymbolIndexContext loads a ctags or LSIF index into the plan's context as the project's symbol map, replacing the one loaded before. The index's path is kept with the note, so the map is refreshed along with the rest of the context when the index is regenerated.
+func LoadSymbolIndexContext(indexPath string) (*shared.LoadContextResponse, *SymbolMap, error) {
+	indexPath, err := symbolIndexContextPath(indexPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	m, err := readSymbolIndex(indexPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(m.Symbols) == 0 {
+		return nil, nil, fmt.Errorf("%s has no symbols for files in the project", indexPath)
+	}
+
+	body := m.Body(indexPath)
+
+	contexts, apiErr := api.Client.ListContext(CurrentPlanId, CurrentBranch)
+	if apiErr != nil {
+		return nil, nil, fmt.Errorf("error getting context: %v", apiErr.Msg)
+	}
+
+	for _, context := range contexts {
+		if context.ContextType == shared.ContextNoteType && context.Name == symbolMapContextName {
+			// the index path can only be set on load, so a map from a different index is loaded fresh
+			if context.FilePath == indexPath {
+				res, apiErr := api.Client.UpdateContext(CurrentPlanId, CurrentBranch, shared.UpdateContextRequest{
+					context.Id: {Body: body},
+				})
+				if apiErr != nil {
+					return nil, nil, fmt.Errorf("error updating context: %v", apiErr.Msg)
+				}
+				return res, m, nil
+			}
+
+			_, apiErr := api.Client.DeleteContext(CurrentPlanId, CurrentBranch, shared.DeleteContextRequest{
+				Ids: map[string]bool{context.Id: true},
+			})
+			if apiErr != nil {
+				return nil, nil, fmt.Errorf("error removing the previous symbol map: %v", apiErr.Msg)
+			}
+		}
+	}
+
+	res, apiErr := api.Client.LoadContext(CurrentPlanId, CurrentBranch, shared.LoadContextRequest{
+		{
+			ContextType: shared.ContextNoteType,
+			Name:        symbolMapContextName,
+			FilePath:    indexPath,
+			Body:        body,
+		},
+	})
+	if apiErr != nil {
+		return nil, nil, fmt.Errorf("error loading context: %v", apiErr.Msg)
+	}
+
+	return res, m, nil
+}
+
+// symbolIndexContextPath is the path a symbol map's index is kept under: relative to the project root for an index in the project, so it's found from any dir in the project, otherwise absolute
+func symbolIndexContextPath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("error resolving %s: %v", path, err)
+	}
+
+	rel, err := filepath.Rel(fs.ProjectRoot, abs)
+	if err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel), nil
+	}
+
+	return abs, nil
+}
+
+func symbolIndexAbsPath(indexPath string) string {
+	path := filepath.FromSlash(indexPath)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(fs.ProjectRoot, path)
+	}
+	return path
+}
+
+// readSymbolIndex parses an index from the path it's kept under
+func readSymbolIndex(indexPath string) (*SymbolMap, error) {
+	return ParseSymbolIndex(symbolIndexAbsPath(indexPath))
+}
+
+// isSymbolMapContext is true for a symbol map that can be refreshed from its index
+func isSymbolMapContext(context *shared.Context) bool {
+	return context.ContextType == shared.ContextNoteType && context.Name == symbolMapContextName && context.FilePath != ""
+}
@@ -0,0 +1,102 @@
+package lib
+
+import (
+	"bufio"
+	"plandex/fs"
+	"strings"
+	"testing"
+)
+
+func withProjectRoot(t *testing.T, root string) {
+	t.Helper()
+	orig := fs.ProjectRoot
+	fs.ProjectRoot = root
+	t.Cleanup(func() { fs.ProjectRoot = orig })
+}
+
+func TestParseCtags(t *testing.T) {
+	root := "/work/project"
+	withProjectRoot(t, root)
+
+	tags := strings.Join([]string{
+		"!_TAG_FILE_FORMAT\t2\t/extended format/",
+		"Server\tserver/server.go\t/^type Server struct {$/;\"\tkind:struct\tline:12\tlanguage:Go",
+		"Start\tserver/server.go\t/^func (s *Server) Start() error {$/;\"\tf\tline:20\tlanguage:Go\tstruct:Server\tsignature:()",
+		"Widget\tui/widget.py\t40;\"\tc\tinherits:Base\tlanguage:Python",
+		"render\tui/widget.py\t44;\"\tm\tclass:Widget\tlanguage:Python\tinherits:Base",
+		"draw\tui/shape.h\t9;\"\tp\tscope:class:Shape\timplementation:pure virtual",
+		"tmp\tserver/server.go\t22;\"\tl\tfunction:Start",
+		"Outside\t/elsewhere/x.go\t1;\"\tf",
+	}, "\n")
+
+	m, err := parseCtags(strings.NewReader(tags), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := m.Body("tags")
+	for _, want := range []string{
+		"  struct Server:12\n",
+		"  function Server.Start:20\n",
+		"  class Widget:40\n",
+		"  member Widget.render:44\n",
+		"  p Shape.draw:9\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in the map, got:\n%s", want, body)
+		}
+	}
+
+	for _, unwanted := range []string{"Go.", "Python.", "Base.", "pure virtual", "tmp", "Outside"} {
+		if strings.Contains(body, unwanted) {
+			t.Errorf("expected no %q in the map, got:\n%s", unwanted, body)
+		}
+	}
+
+	if _, err := parseCtags(strings.NewReader("not an index"), root); err == nil {
+		t.Error("expected an error for a file that isn't an index")
+	}
+}
+
+func TestParseLsif(t *testing.T) {
+	root := "/work/project"
+	withProjectRoot(t, root)
+
+	dump := strings.Join([]string{
+		`{"id":1,"type":"vertex","label":"metaData","projectRoot":"file:///work/project"}`,
+		`{"id":2,"type":"vertex","label":"document","uri":"file:///work/project/src/app.ts"}`,
+		`{"id":"3","type":"vertex","label":"range","start":{"line":4},"tag":{"type":"definition","text":"App","kind":5}}`,
+		`{"id":4,"type":"vertex","label":"range","start":{"line":9},"tag":{"type":"definition","text":"arg","kind":13}}`,
+		`{"id":5,"type":"vertex","label":"range","start":{"line":20},"tag":{"type":"reference","text":"App"}}`,
+		`{"id":6,"type":"edge","label":"contains","outV":2,"inVs":["3",4,5]}`,
+		`{"id":7,"type":"vertex","label":"document","uri":"file:///usr/lib/node_modules/x.d.ts"}`,
+		`{"id":8,"type":"vertex","label":"range","start":{"line":0},"tag":{"type":"definition","text":"X","kind":12}}`,
+		`{"id":9,"type":"edge","label":"contains","outV":7,"inVs":[8]}`,
+	}, "\n")
+
+	for name, input := range map[string]string{
+		"json lines": dump,
+		"json array": "[" + strings.ReplaceAll(dump, "\n", ",\n") + "]",
+	} {
+		m, err := parseLsif(bufio.NewReader(strings.NewReader(input)), root)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		symbols := m.Symbols["src/app.ts"]
+		if len(symbols) != 2 {
+			t.Fatalf("%s: expected the 2 definitions in src/app.ts, got %v", name, m.Symbols)
+		}
+		body := m.Body("dump.lsif")
+		if !strings.Contains(body, "  class App:5\n") || !strings.Contains(body, "  variable arg:10\n") {
+			t.Errorf("%s: unexpected map:\n%s", name, body)
+		}
+		if len(m.Symbols) != 1 {
+			t.Errorf("%s: expected files outside the project to be skipped, got %v", name, m.Symbols)
+		}
+	}
+
+	if _, err := parseLsif(bufio.NewReader(strings.NewReader(`{"id":1,"type":"vertex","label":"range"}`)), root); err == nil {
+		t.Error("expected an error for a dump with no documents")
+	}
+}
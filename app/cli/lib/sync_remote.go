@@ -0,0 +1,565 @@
+package lib
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"plandex/api"
+	"plandex/fs"
+	"plandex/types"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// SyncedPlanVersion is the version of the format plans are pushed in
+const SyncedPlanVersion = 1
+
+// SyncedPlan is what 'plandex push' stores on the sync remote for each plan
+type SyncedPlan struct {
+	Version int                `json:"version"`
+	Bundle  *shared.PlanBundle `json:"bundle"`
+	// the plan's records from the machine it was pushed from, like usage and applied commits
+	PlanInfo *types.PlanInfo `json:"planInfo,omitempty"`
+	PushedBy string          `json:"pushedBy,omitempty"`
+	PushedAt time.Time       `json:"pushedAt"`
+}
+
+var errSyncNotFound = errors.New("not found")
+
+// syncRemote stores pushed plans by key
+type syncRemote interface {
+	get(key string) ([]byte, error)
+	put(key string, data []byte) error
+	list() ([]string, error)
+}
+
+// PLANDEX_SYNC_TOKEN is sent as a bearer token to http(s) remotes
+const syncTokenEnvVar = "PLANDEX_SYNC_TOKEN"
+
+const syncKeyExt = ".plandex.json"
+
+// syncKey is the file name a plan is stored under. Characters that aren't safe in file names and urls are percent-encoded rather than replaced, so every plan name gets its own key, like "my%20plan" for "my plan" and "my-plan" for "my-plan", and the name can be read back from the key.
+func syncKey(planName string) string {
+	var b strings.Builder
+	for i := 0; i < len(planName); i++ {
+		c := planName[i]
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '.' || c == '_' || c == '-' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String() + syncKeyExt
+}
+
+// syncKeyName reads a plan's name back from its key
+func syncKeyName(key string) string {
+	escaped := strings.TrimSuffix(key, syncKeyExt)
+	name, err := neturl.PathUnescape(escaped)
+	if err != nil {
+		return escaped
+	}
+	return name
+}
+
+// NormalizeSyncRemote checks that a remote is one plandex can sync to, and makes a directory remote's path absolute
+func NormalizeSyncRemote(remote string) (string, error) {
+	switch {
+	case strings.HasPrefix(remote, "s3://"):
+		if strings.TrimPrefix(remote, "s3://") == "" {
+			return "", fmt.Errorf("s3 remotes need a bucket, like s3://bucket/plans")
+		}
+		return strings.TrimSuffix(remote, "/"), nil
+	case isGitSyncRemote(remote), strings.HasPrefix(remote, "http://"), strings.HasPrefix(remote, "https://"):
+		return strings.TrimSuffix(remote, "/"), nil
+	}
+
+	path := strings.TrimPrefix(remote, "file://")
+	if strings.HasPrefix(path, "~/") {
+		path = filepath.Join(fs.HomeDir, path[2:])
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("invalid path %s: %v", remote, err)
+	}
+	return path, nil
+}
+
+func isGitSyncRemote(remote string) bool {
+	return strings.HasPrefix(remote, "git+") ||
+		strings.HasPrefix(remote, "git@") ||
+		strings.HasPrefix(remote, "ssh://") ||
+		strings.HasSuffix(remote, ".git")
+}
+
+func getSyncRemote() (syncRemote, string, error) {
+	config, err := LoadClientConfig()
+	if err != nil {
+		return nil, "", err
+	}
+
+	remote := config.SyncRemote
+	if remote == "" {
+		return nil, "", fmt.Errorf("no sync remote. Set one with 'plandex remote <url>'")
+	}
+
+	switch {
+	case strings.HasPrefix(remote, "s3://"):
+		return &s3SyncRemote{url: remote}, remote, nil
+	case isGitSyncRemote(remote):
+		url := strings.TrimPrefix(remote, "git+")
+		sum := sha256.Sum256([]byte(url))
+		return &gitSyncRemote{
+			url: url,
+			dir: filepath.Join(fs.CacheDir, "sync", hex.EncodeToString(sum[:8])),
+		}, remote, nil
+	case strings.HasPrefix(remote, "http://"), strings.HasPrefix(remote, "https://"):
+		return &httpSyncRemote{url: remote}, remote, nil
+	default:
+		return &dirSyncRemote{dir: remote}, remote, nil
+	}
+}
+
+// PushPlan stores the plan with all its branches, and its local records, on the sync remote under its name, replacing what was pushed under that name before
+func PushPlan(planId string) (name, remote string, err error) {
+	r, remote, err := getSyncRemote()
+	if err != nil {
+		return "", "", err
+	}
+
+	bundle, apiErr := api.Client.ExportPlanBundle(planId)
+	if apiErr != nil {
+		return "", "", fmt.Errorf("error exporting plan: %v", apiErr.Msg)
+	}
+
+	info, err := LoadPlanInfo(planId)
+	if err != nil {
+		return "", "", err
+	}
+
+	synced := SyncedPlan{
+		Version:  SyncedPlanVersion,
+		Bundle:   bundle,
+		PlanInfo: info,
+		PushedAt: time.Now().UTC(),
+	}
+	if email, err := exec.Command("git", "config", "user.email").Output(); err == nil {
+		synced.PushedBy = strings.TrimSpace(string(email))
+	}
+
+	data, err := json.Marshal(synced)
+	if err != nil {
+		return "", "", fmt.Errorf("error marshalling plan: %v", err)
+	}
+
+	err = r.put(syncKey(bundle.Name), data)
+	if err != nil {
+		return "", "", fmt.Errorf("error pushing to %s: %v", remote, err)
+	}
+
+	return bundle.Name, remote, nil
+}
+
+// PullPlan imports a plan from the sync remote into the current project, as asName if it's set, and restores its local records
+func PullPlan(name, asName string) (*shared.CreatePlanResponse, *SyncedPlan, error) {
+	r, remote, err := getSyncRemote()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := r.get(syncKey(name))
+	if err == errSyncNotFound {
+		return nil, nil, fmt.Errorf("no plan named %s on %s. See 'plandex remote ls'.", name, remote)
+	} else if err != nil {
+		return nil, nil, fmt.Errorf("error pulling from %s: %v", remote, err)
+	}
+
+	var synced SyncedPlan
+	err = json.Unmarshal(data, &synced)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error unmarshalling plan: %v", err)
+	}
+	if synced.Version > SyncedPlanVersion || synced.Bundle == nil {
+		return nil, nil, fmt.Errorf("%s was pushed by a newer version of plandex. Upgrade to pull it.", name)
+	}
+
+	res, apiErr := api.Client.ImportPlanBundle(CurrentProjectId, shared.ImportPlanRequest{
+		Name:   asName,
+		Bundle: synced.Bundle,
+	})
+	if apiErr != nil {
+		return nil, nil, fmt.Errorf("error importing plan: %v", apiErr.Msg)
+	}
+
+	if synced.PlanInfo != nil {
+		err = writePlanInfo(res.Id, synced.PlanInfo)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return res, &synced, nil
+}
+
+// ListSyncedPlans lists the names of the plans on the sync remote
+func ListSyncedPlans() ([]string, string, error) {
+	r, remote, err := getSyncRemote()
+	if err != nil {
+		return nil, "", err
+	}
+
+	keys, err := r.list()
+	if err != nil {
+		return nil, "", fmt.Errorf("error listing %s: %v", remote, err)
+	}
+
+	var names []string
+	for _, key := range keys {
+		if strings.HasSuffix(key, syncKeyExt) {
+			names = append(names, syncKeyName(key))
+		}
+	}
+	sort.Strings(names)
+
+	return names, remote, nil
+}
+
+// dirSyncRemote is a local or mounted directory, like one synced by Dropbox or a network drive
+type dirSyncRemote struct {
+	dir string
+}
+
+func (r *dirSyncRemote) get(key string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(r.dir, key))
+	if os.IsNotExist(err) {
+		return nil, errSyncNotFound
+	}
+	return data, err
+}
+
+func (r *dirSyncRemote) put(key string, data []byte) error {
+	err := os.MkdirAll(r.dir, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	// renamed into place so a pull on another machine never reads a partial file
+	tmpPath, err := writeTempFile(r.dir, data, 0644)
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(tmpPath, filepath.Join(r.dir, key))
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return nil
+}
+
+func (r *dirSyncRemote) list() ([]string, error) {
+	entries, err := os.ReadDir(r.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			keys = append(keys, entry.Name())
+		}
+	}
+	return keys, nil
+}
+
+// s3SyncRemote runs the aws cli, so it uses the same credentials and profiles
+type s3SyncRemote struct {
+	url string
+}
+
+func (r *s3SyncRemote) aws(stdin []byte, args ...string) ([]byte, error) {
+	if _, err := exec.LookPath("aws"); err != nil {
+		return nil, fmt.Errorf("s3 remotes need the aws cli. Install it from https://aws.amazon.com/cli/")
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("aws", append([]string{"s3"}, args...)...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if strings.Contains(msg, "(404)") || strings.Contains(msg, "Not Found") || strings.Contains(msg, "NoSuchKey") {
+			return nil, errSyncNotFound
+		}
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, errors.New(msg)
+	}
+
+	return stdout.Bytes(), nil
+}
+
+func (r *s3SyncRemote) get(key string) ([]byte, error) {
+	return r.aws(nil, "cp", r.url+"/"+key, "-")
+}
+
+func (r *s3SyncRemote) put(key string, data []byte) error {
+	_, err := r.aws(data, "cp", "-", r.url+"/"+key)
+	return err
+}
+
+func (r *s3SyncRemote) list() ([]string, error) {
+	out, err := r.aws(nil, "ls", r.url+"/")
+	if err == errSyncNotFound {
+		return nil, nil
+	} else if err != nil {
+		// ls exits 1 when nothing matches the prefix
+		if strings.TrimSpace(err.Error()) == "exit status 1" {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var keys []string
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		// "2024-05-01 12:00:00 1234 key", or "PRE dir/" for prefixes
+		if len(fields) >= 4 {
+			keys = append(keys, fields[len(fields)-1])
+		}
+	}
+	return keys, nil
+}
+
+// gitSyncRemote keeps a clone of the repo in the cache dir, and commits and pushes a file for each plan
+type gitSyncRemote struct {
+	url string
+	dir string
+}
+
+func (r *gitSyncRemote) git(args ...string) (string, error) {
+	out, err := exec.Command("git", append([]string{"-C", r.dir}, args...)...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s failed: %v, output: %s", args[0], err, strings.TrimSpace(string(out)))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// sync clones the repo, or fetches and resets the clone to the remote branch, and returns the branch
+func (r *gitSyncRemote) sync() (string, error) {
+	if _, err := os.Stat(filepath.Join(r.dir, ".git")); os.IsNotExist(err) {
+		err = os.MkdirAll(filepath.Dir(r.dir), os.ModePerm)
+		if err != nil {
+			return "", err
+		}
+		out, err := exec.Command("git", "clone", "--quiet", r.url, r.dir).CombinedOutput()
+		if err != nil {
+			return "", fmt.Errorf("git clone failed: %v, output: %s", err, strings.TrimSpace(string(out)))
+		}
+	} else if _, err := r.git("fetch", "--quiet", "origin"); err != nil {
+		return "", err
+	}
+
+	branch, err := r.git("symbolic-ref", "--short", "HEAD")
+	if err != nil {
+		return "", err
+	}
+
+	// an empty repo has no remote branch until the first push
+	if _, err := r.git("rev-parse", "--verify", "--quiet", "origin/"+branch); err == nil {
+		if _, err := r.git("reset", "--quiet", "--hard", "origin/"+branch); err != nil {
+			return "", err
+		}
+	}
+
+	return branch, nil
+}
+
+func (r *gitSyncRemote) get(key string) ([]byte, error) {
+	if _, err := r.sync(); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(r.dir, key))
+	if os.IsNotExist(err) {
+		return nil, errSyncNotFound
+	}
+	return data, err
+}
+
+func (r *gitSyncRemote) put(key string, data []byte) error {
+	branch, err := r.sync()
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(filepath.Join(r.dir, key), data, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := r.git("add", key); err != nil {
+		return err
+	}
+
+	if status, err := r.git("status", "--porcelain", "--", key); err != nil {
+		return err
+	} else if status == "" {
+		return nil
+	}
+
+	commitArgs := []string{"commit", "--quiet", "-m", "Push plan " + syncKeyName(key)}
+	// commits need an author, which may not be set up on every machine that pushes
+	if email, _ := r.git("config", "user.email"); email == "" {
+		commitArgs = append([]string{"-c", "user.name=Plandex", "-c", "user.email=plandex@localhost"}, commitArgs...)
+	}
+	if _, err := r.git(commitArgs...); err != nil {
+		return err
+	}
+
+	_, err = r.git("push", "--quiet", "origin", "HEAD:"+branch)
+	return err
+}
+
+func (r *gitSyncRemote) list() ([]string, error) {
+	if _, err := r.sync(); err != nil {
+		return nil, err
+	}
+
+	return (&dirSyncRemote{dir: r.dir}).list()
+}
+
+// httpSyncRemote GETs and PUTs each plan at <url>/<key>, and keeps a list of keys at <url>/index.json. The index is updated with a conditional PUT, using the ETag it was read with, so pushes from two machines at once can't drop each other's keys. Servers that don't send ETags get unconditional updates.
+type httpSyncRemote struct {
+	url string
+}
+
+const httpSyncIndexKey = "index.json"
+
+// how many times a push re-reads and retries the index when another push changed it first
+const httpSyncIndexAttempts = 5
+
+var errSyncConflict = errors.New("changed since it was read")
+
+func (r *httpSyncRemote) do(method, key string, body []byte, header http.Header) ([]byte, http.Header, error) {
+	req, err := http.NewRequest(method, r.url+"/"+neturl.PathEscape(key), bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if token := os.Getenv(syncTokenEnvVar); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, resp.Header, errSyncNotFound
+	} else if resp.StatusCode == http.StatusPreconditionFailed {
+		return nil, resp.Header, errSyncConflict
+	} else if resp.StatusCode >= 400 {
+		return nil, resp.Header, fmt.Errorf("%s %s returned %s: %s", method, key, resp.Status, strings.TrimSpace(string(data)))
+	}
+
+	return data, resp.Header, nil
+}
+
+func (r *httpSyncRemote) get(key string) ([]byte, error) {
+	data, _, err := r.do(http.MethodGet, key, nil, nil)
+	return data, err
+}
+
+func (r *httpSyncRemote) put(key string, data []byte) error {
+	_, _, err := r.do(http.MethodPut, key, data, nil)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; attempt < httpSyncIndexAttempts; attempt++ {
+		keys, etag, exists, err := r.getIndex()
+		if err != nil {
+			return err
+		}
+		for _, existing := range keys {
+			if existing == key {
+				return nil
+			}
+		}
+
+		index, err := json.Marshal(append(keys, key))
+		if err != nil {
+			return err
+		}
+
+		header := http.Header{}
+		if etag != "" {
+			header.Set("If-Match", etag)
+		} else if !exists {
+			header.Set("If-None-Match", "*")
+		}
+
+		_, _, err = r.do(http.MethodPut, httpSyncIndexKey, index, header)
+		if err == errSyncConflict {
+			continue
+		}
+		return err
+	}
+
+	return fmt.Errorf("%s kept changing while the plan was added to it. Push again to retry.", httpSyncIndexKey)
+}
+
+func (r *httpSyncRemote) list() ([]string, error) {
+	keys, _, _, err := r.getIndex()
+	return keys, err
+}
+
+// getIndex returns the keys in the index along with its ETag, and whether it exists yet
+func (r *httpSyncRemote) getIndex() (keys []string, etag string, exists bool, err error) {
+	data, header, err := r.do(http.MethodGet, httpSyncIndexKey, nil, nil)
+	if err == errSyncNotFound {
+		return nil, "", false, nil
+	} else if err != nil {
+		return nil, "", false, err
+	}
+
+	err = json.Unmarshal(data, &keys)
+	if err != nil {
+		return nil, "", true, fmt.Errorf("invalid %s: %v", httpSyncIndexKey, err)
+	}
+	return keys, header.Get("ETag"), true, nil
+}
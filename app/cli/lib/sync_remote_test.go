@@ -0,0 +1,118 @@
+package lib
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSyncKey(t *testing.T) {
+	names := []string{"my plan", "my-plan", "my_plan", "my/plan", "my%20plan", "ünïcode", "plan.v2"}
+
+	seen := map[string]string{}
+	for _, name := range names {
+		key := syncKey(name)
+		if other, ok := seen[key]; ok {
+			t.Errorf("%q and %q both map to %s", name, other, key)
+		}
+		seen[key] = name
+
+		if strings.ContainsAny(strings.TrimSuffix(key, syncKeyExt), " /\\:") {
+			t.Errorf("key %s for %q isn't safe as a file name", key, name)
+		}
+		if got := syncKeyName(key); got != name {
+			t.Errorf("syncKeyName(%s) = %q, want %q", key, got, name)
+		}
+	}
+
+	if syncKey("my-plan") != "my-plan"+syncKeyExt {
+		t.Errorf("expected safe names to be kept as they are, got %s", syncKey("my-plan"))
+	}
+}
+
+// etagStore is a minimal http sync remote that honors If-Match and If-None-Match like a real one would
+type etagStore struct {
+	mu      sync.Mutex
+	files   map[string][]byte
+	version map[string]int
+}
+
+func (s *etagStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := strings.TrimPrefix(r.URL.Path, "/")
+	etag := fmt.Sprintf(`"%d"`, s.version[key])
+	data, exists := s.files[key]
+
+	switch r.Method {
+	case http.MethodGet:
+		if !exists {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Write(data)
+	case http.MethodPut:
+		if m := r.Header.Get("If-Match"); m != "" && (!exists || m != etag) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		if r.Header.Get("If-None-Match") == "*" && exists {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		s.files[key] = body
+		s.version[key]++
+	}
+}
+
+func TestHttpSyncRemoteConcurrentPushes(t *testing.T) {
+	store := &etagStore{files: map[string][]byte{}, version: map[string]int{}}
+	server := httptest.NewServer(store)
+	defer server.Close()
+
+	r := &httpSyncRemote{url: server.URL}
+
+	var want []string
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+	for i := 0; i < 4; i++ {
+		key := syncKey(fmt.Sprintf("plan %d", i))
+		want = append(want, key)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := r.put(key, []byte("{}")); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("put: %v", err)
+	}
+
+	keys, err := r.list()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(keys)
+	sort.Strings(want)
+	if fmt.Sprint(keys) != fmt.Sprint(want) {
+		t.Errorf("expected every pushed plan in the index, got %v", keys)
+	}
+
+	data, err := r.get(syncKey("plan 0"))
+	if err != nil || string(data) != "{}" {
+		t.Errorf("expected to get the plan back by its escaped key, got %q, %v", data, err)
+	}
+}
@@ -0,0 +1,101 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"plandex/api"
+	"plandex/types"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// errStreamAborted is returned when a stream is stopped before it finishes
+var errStreamAborted = errors.New("stream aborted")
+
+// tellAndWait sends a prompt without the stream UI and blocks until the plan finishes replying and building. Missing files are skipped since there's no one to ask about them. onMsg, if set, sees every stream message.
+func tellAndWait(planId, branch string, req shared.TellPlanRequest, onMsg func(msg *shared.StreamMessage)) error {
+	connected := false
+	err := waitForStream(planId, branch, onMsg, func(onStream types.OnStreamPlan) error {
+		apiErr := api.Client.TellPlan(planId, branch, req, onStream)
+		if apiErr != nil && apiErr.AutoLimitExceededError != nil {
+			return apiErr.AutoLimitExceededError
+		} else if apiErr != nil {
+			return fmt.Errorf("error sending prompt: %v", apiErr.Msg)
+		}
+		connected = true
+		return nil
+	})
+
+	// like the stream UI, --notify only covers streams that ran, and not ones that were stopped
+	if connected && !errors.Is(err, errStreamAborted) {
+		var errMsg string
+		if err != nil {
+			errMsg = err.Error()
+		}
+		NotifyStreamDone(planId, branch, errMsg)
+	}
+
+	return err
+}
+
+// waitForStream handles the messages of a stream begun by start, with no UI, until it finishes
+func waitForStream(planId, branch string, onMsg func(msg *shared.StreamMessage), start func(onStream types.OnStreamPlan) error) error {
+	doneCh := make(chan error, 1)
+	finish := func(err error) {
+		select {
+		case doneCh <- err:
+		default:
+		}
+	}
+
+	onStream := func(streamParams types.OnStreamPlanParams) {
+		if streamParams.Err != nil {
+			finish(streamParams.Err)
+			return
+		}
+
+		msg := streamParams.Msg
+
+		if onMsg != nil {
+			onMsg(msg)
+		}
+
+		switch msg.Type {
+		case shared.StreamMessageUsage:
+			if msg.Usage != nil {
+				if currentAutoRun != nil {
+					currentAutoRun.usage.Add(*msg.Usage)
+				}
+			}
+		case shared.StreamMessagePromptMissingFile:
+			log.Printf("Skipping missing file %s on branch %s\n", msg.MissingFilePath, branch)
+			apiErr := api.Client.RespondMissingFile(planId, branch, shared.RespondMissingFileRequest{
+				Choice:   shared.RespondMissingFileChoiceSkip,
+				FilePath: msg.MissingFilePath,
+			})
+			if apiErr != nil {
+				finish(fmt.Errorf("error skipping missing file: %v", apiErr.Msg))
+			}
+		case shared.StreamMessageError:
+			if msg.Error != nil && msg.Error.AutoLimitExceededError != nil {
+				finish(msg.Error.AutoLimitExceededError)
+			} else if msg.Error != nil {
+				finish(fmt.Errorf("%s", msg.Error.Msg))
+			} else {
+				finish(fmt.Errorf("stream error"))
+			}
+		case shared.StreamMessageAborted:
+			finish(errStreamAborted)
+		case shared.StreamMessageFinished:
+			finish(nil)
+		}
+	}
+
+	err := start(onStream)
+	if err != nil {
+		return err
+	}
+
+	return <-doneCh
+}
@@ -0,0 +1,129 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"plandex/fs"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var templateNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+var templateVarRegex = regexp.MustCompile(`\{\{\s*([a-zA-Z_][a-zA-Z0-9_-]*)\s*\}\}`)
+
+const templateExt = ".md"
+
+func getTemplatesDir() string {
+	return filepath.Join(fs.PlandexDir, "templates")
+}
+
+// GetTemplatePath returns where a template is stored, whether or not it exists yet
+func GetTemplatePath(name string) (string, error) {
+	name = strings.TrimSuffix(name, templateExt)
+	if !templateNameRegex.MatchString(name) {
+		return "", fmt.Errorf("invalid template name %s, use letters, numbers, '-', '_' or '.'", name)
+	}
+	return filepath.Join(getTemplatesDir(), name+templateExt), nil
+}
+
+func ListTemplates() ([]string, error) {
+	entries, err := os.ReadDir(getTemplatesDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading templates: %v", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != templateExt {
+			continue
+		}
+		names = append(names, strings.TrimSuffix(entry.Name(), templateExt))
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+func LoadTemplate(name string) (string, error) {
+	path, err := GetTemplatePath(name)
+	if err != nil {
+		return "", err
+	}
+
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("no template named %s. See 'plandex templates'.", name)
+		}
+		return "", fmt.Errorf("error reading template %s: %v", name, err)
+	}
+
+	return string(bytes), nil
+}
+
+// GetTemplateVars parses key=value pairs from --var flags and adds the built-in variables: {{branch}} is the plan's current branch, and setting file to a path with an optional line range, like main.go:10-20, also sets {{selection}} to the content of the file or range.
+func GetTemplateVars(pairs []string) (map[string]string, error) {
+	vars := map[string]string{
+		"branch": CurrentBranch,
+	}
+
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid variable %s, expected key=value", pair)
+		}
+		vars[key] = value
+	}
+
+	if file, ok := vars["file"]; ok {
+		sel, err := ParseFileSelection(file)
+		if err != nil {
+			return nil, err
+		}
+		vars["file"] = sel.Path
+
+		if _, ok := vars["selection"]; !ok {
+			content, err := sel.Read()
+			if err != nil {
+				return nil, err
+			}
+			vars["selection"] = fenceCode(content)
+		}
+	}
+
+	return vars, nil
+}
+
+// RenderTemplate replaces each {{var}} in text. It fails if any variable isn't set, rather than sending a prompt with a hole in it.
+func RenderTemplate(text string, vars map[string]string) (string, error) {
+	var missing []string
+	seen := map[string]bool{}
+
+	res := templateVarRegex.ReplaceAllStringFunc(text, func(match string) string {
+		key := templateVarRegex.FindStringSubmatch(match)[1]
+		if value, ok := vars[key]; ok {
+			return value
+		}
+		if !seen[key] {
+			seen[key] = true
+			missing = append(missing, key)
+		}
+		return match
+	})
+
+	if len(missing) > 0 {
+		hint := "set with --var key=value"
+		if seen["file"] || seen["selection"] {
+			hint = "set {{file}} and {{selection}} with --var file=path[:line-range]"
+		}
+		return "", fmt.Errorf("missing template variables: %s (%s)", strings.Join(missing, ", "), hint)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,139 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"plandex/api"
+	"plandex/fs"
+	"plandex/term"
+	"regexp"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+)
+
+const DefaultMaxTestFixes = 3
+
+// failing output can mention many files, like every frame of a stack trace; only the first few are loaded
+const maxFailingFilesLoaded = 10
+
+// candidate file paths in test output, like 'src/app.test.ts:12:5' or 'pkg/foo_test.go:31'
+var outputPathRegex = regexp.MustCompile(`[\w./\\-]+\.\w+`)
+
+// MustRunTestLoop runs the project's test command. While tests fail, the files named in the output are loaded into context and the output is sent to the plan as a new prompt. The resulting changes are built and applied with fixFlags, up to maxFixes times. Returns whether the tests passed.
+func MustRunTestLoop(planId, branch, testCmd string, maxFixes int, fixFlags ApplyFlags) bool {
+	return mustRunFixLoop(planId, branch, fixLoop{
+		cmd:              testCmd,
+		maxFixes:         maxFixes,
+		fixFlags:         fixFlags,
+		runningMsg:       "🧪 Running tests with '%s'",
+		passedMsg:        "✅ Tests passed",
+		failedMsg:        "❌ Tests failed: %v",
+		promptFmt:        "Running the tests with `%s` failed with this output:\n\n```\n%s\n```\n\nFix the code so the failing tests pass. Only change a test if the test itself is wrong.",
+		loadFailingFiles: true,
+	})
+}
+
+// getFailingFilePaths returns the project files named in a command's output that aren't in context yet, in the order they first appear
+func getFailingFilePaths(output string, activePaths map[string]bool, contexts []*shared.Context) []string {
+	inContext := map[string]bool{}
+	for _, context := range contexts {
+		if context.FilePath != "" {
+			inContext[context.FilePath] = true
+		}
+	}
+
+	seen := map[string]bool{}
+	var res []string
+
+	for _, match := range outputPathRegex.FindAllString(output, -1) {
+		path := filepath.ToSlash(strings.TrimPrefix(match, "./"))
+		if filepath.IsAbs(match) {
+			rel, err := filepath.Rel(fs.ProjectRoot, match)
+			if err != nil {
+				continue
+			}
+			path = filepath.ToSlash(rel)
+		}
+		path = filepath.FromSlash(path)
+
+		if seen[path] || inContext[path] || !activePaths[path] {
+			continue
+		}
+		seen[path] = true
+		res = append(res, path)
+
+		if len(res) >= maxFailingFilesLoaded {
+			break
+		}
+	}
+
+	return res
+}
+
+func mustLoadFailingFiles(planId, branch, output string) {
+	contexts, apiErr := api.Client.ListContext(planId, branch)
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting context: %v", apiErr.Msg)
+	}
+
+	paths, err := fs.GetProjectPaths(fs.ProjectRoot)
+	if err != nil {
+		term.OutputErrorAndExit("Error getting project paths: %v", err)
+	}
+
+	failingPaths := getFailingFilePaths(output, paths.ActivePaths, contexts)
+	if len(failingPaths) == 0 {
+		return
+	}
+
+	var req shared.LoadContextRequest
+	for _, path := range failingPaths {
+		absPath := fs.ProjectFilePath(path)
+
+		info, err := os.Stat(absPath)
+		if err != nil || info.IsDir() {
+			continue
+		}
+
+		raw, err := os.ReadFile(absPath)
+		if err != nil {
+			term.OutputErrorAndExit("Error reading %s: %v", path, err)
+		}
+
+		body, encoding, err := decodeFileContent(path, raw)
+		if err != nil {
+			// binary files and the like are left out rather than failing the loop
+			continue
+		}
+
+		req = append(req, &shared.LoadContextParams{
+			ContextType: shared.ContextFileType,
+			Name:        path,
+			Body:        body,
+			FilePath:    path,
+			FileMode:    info.Mode().Perm(),
+			Encoding:    encoding,
+		})
+	}
+
+	if len(req) == 0 {
+		return
+	}
+
+	term.StartSpinner("📥 Loading failing files into context...")
+	res, apiErr := api.Client.LoadContext(planId, branch, req)
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error loading failing files into context: %v", apiErr.Msg)
+	}
+
+	if res.MaxTokensExceeded {
+		fmt.Printf("⚠️  Failing files weren't loaded since they'd exceed the context limit by %d 🪙\n", res.TotalTokens-res.MaxTokens)
+		return
+	}
+
+	fmt.Printf("📥 Loaded %d failing file(s) into context\n", len(req))
+}
@@ -0,0 +1,105 @@
+package lib
+
+import (
+	"fmt"
+	"plandex/api"
+	"sort"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+)
+
+type UsageReportRow struct {
+	Day    string            `json:"day"`
+	PlanId string            `json:"planId"`
+	Plan   string            `json:"plan"`
+	Phase  shared.UsagePhase `json:"phase"`
+
+	shared.UsageTotal
+}
+
+// GetUsageReport adds up the usage the server has recorded for the project's plans by day, plan, and phase, newest day first. Days are local dates. Usage recorded before since is left out. If planId is set, only that plan's usage is included, from every user who has worked on it. Otherwise the project's plans are the ones in planNamesById, and members who can't manage billing only see their own usage.
+func GetUsageReport(planId string, since time.Time, planNamesById map[string]string) ([]*UsageReportRow, error) {
+	if planId != "" {
+		res, apiErr := api.Client.GetPlanUsage(planId, since)
+		if apiErr != nil {
+			return nil, fmt.Errorf("error getting plan usage: %v", apiErr.Msg)
+		}
+		return GetServerUsageReport(res.Records, planId, planNamesById), nil
+	}
+
+	res, apiErr := api.Client.GetOrgUsage(since)
+	if apiErr != nil {
+		return nil, fmt.Errorf("error getting usage: %v", apiErr.Msg)
+	}
+
+	var records []*shared.UsageRecord
+	for _, record := range res.Records {
+		if _, ok := planNamesById[record.PlanId]; ok {
+			records = append(records, record)
+		}
+	}
+
+	return GetServerUsageReport(records, "", planNamesById), nil
+}
+
+// GetServerUsageReport adds up usage records from the server by day, plan, and phase, newest day first. Records for plans other than planId are left out if it's set. Plans missing from planNamesById, like deleted ones, are labeled by id.
+func GetServerUsageReport(records []*shared.UsageRecord, planId string, planNamesById map[string]string) []*UsageReportRow {
+	report := usageReport{rowsByKey: map[string]*UsageReportRow{}, planNamesById: planNamesById}
+	for _, record := range records {
+		if planId == "" || record.PlanId == planId {
+			report.add(record.PlanId, record.CreatedAt, record.ModelUsage)
+		}
+	}
+	return report.rows()
+}
+
+type usageReport struct {
+	rowsByKey     map[string]*UsageReportRow
+	planNamesById map[string]string
+}
+
+func (r *usageReport) add(planId string, createdAt time.Time, usage shared.ModelUsage) {
+	day := createdAt.Local().Format("2006-01-02")
+	key := day + "|" + planId + "|" + string(usage.Phase)
+
+	row := r.rowsByKey[key]
+	if row == nil {
+		name := r.planNamesById[planId]
+		if name == "" {
+			name = planId
+		}
+
+		row = &UsageReportRow{
+			Day:    day,
+			PlanId: planId,
+			Plan:   name,
+			Phase:  usage.Phase,
+		}
+		r.rowsByKey[key] = row
+	}
+	row.Add(usage)
+}
+
+func (r *usageReport) rows() []*UsageReportRow {
+	phaseOrder := map[shared.UsagePhase]int{}
+	for i, phase := range shared.UsagePhases {
+		phaseOrder[phase] = i
+	}
+
+	var rows []*UsageReportRow
+	for _, row := range r.rowsByKey {
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Day != rows[j].Day {
+			return rows[i].Day > rows[j].Day
+		}
+		if rows[i].Plan != rows[j].Plan {
+			return rows[i].Plan < rows[j].Plan
+		}
+		return phaseOrder[rows[i].Phase] < phaseOrder[rows[j].Phase]
+	})
+
+	return rows
+}
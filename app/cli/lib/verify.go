@@ -0,0 +1,174 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"plandex/api"
+	"plandex/fs"
+	"plandex/term"
+	"runtime"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/plandex/plandex/shared"
+)
+
+const DefaultMaxVerifyFixes = 3
+
+// output beyond this is cut off before it's sent back to the plan; the first errors are usually the ones that matter
+const maxVerifyOutputChars = 8000
+
+// fixLoop describes a command that checks the plan's applied changes, and how to ask for fixes when it fails
+type fixLoop struct {
+	cmd      string
+	maxFixes int
+	fixFlags ApplyFlags
+
+	runningMsg string
+	passedMsg  string
+	failedMsg  string
+
+	// formatted with the command and its output
+	promptFmt string
+
+	// load project files named in the failing output into context, so the fix can see the code that failed
+	loadFailingFiles bool
+}
+
+// MustRunVerifyLoop runs the project's verification command. While it fails, the output is sent to the plan as a new prompt, and the resulting changes are built and applied with fixFlags, up to maxFixes times. Returns whether verification passed.
+func MustRunVerifyLoop(planId, branch, verifyCmd string, maxFixes int, fixFlags ApplyFlags) bool {
+	return mustRunFixLoop(planId, branch, fixLoop{
+		cmd:        verifyCmd,
+		maxFixes:   maxFixes,
+		fixFlags:   fixFlags,
+		runningMsg: "🧪 Verifying with '%s'",
+		passedMsg:  "✅ Verification passed",
+		failedMsg:  "❌ Verification failed: %v",
+		promptFmt:  "After applying the plan, running `%s` failed with this output:\n\n```\n%s\n```\n\nFix the problems that caused the failure.",
+	})
+}
+
+func mustRunFixLoop(planId, branch string, loop fixLoop) bool {
+	for attempt := 0; ; attempt++ {
+		fmt.Println()
+		color.New(color.Bold, term.ColorHiCyan).Printf(loop.runningMsg+"\n", loop.cmd)
+		fmt.Println()
+
+		output, err := runShellCmd(loop.cmd)
+		fmt.Println()
+
+		if err == nil {
+			fmt.Println(loop.passedMsg)
+			return true
+		}
+
+		color.New(color.Bold, term.ColorHiRed).Printf(loop.failedMsg+"\n", err)
+
+		if attempt >= loop.maxFixes {
+			if loop.maxFixes > 0 {
+				fmt.Printf("Still failing after %d fix attempts\n", loop.maxFixes)
+			}
+			fmt.Println()
+			term.PrintCmds("", "tell", "undo")
+			return false
+		}
+
+		if loop.loadFailingFiles {
+			mustLoadFailingFiles(planId, branch, output)
+		}
+
+		if len(output) > maxVerifyOutputChars {
+			output = output[:maxVerifyOutputChars] + "\n... (output truncated)"
+		}
+
+		prompt := fmt.Sprintf(loop.promptFmt, loop.cmd, output)
+		mustAskForFixes(planId, branch, prompt, fmt.Sprintf("🔧 Asking Plandex to fix the failure (attempt %d/%d)...", attempt+1, loop.maxFixes))
+
+		// fixes are applied without asking, so in an automated run their changes to protected paths need confirming like any other step's
+		if currentAutoRun != nil {
+			desc := getStepDescription(mustGetStepState(planId, branch), time.Time{})
+			if desc != nil && !mustConfirmProtectedPaths(desc) {
+				fmt.Println("🔒 Changes to protected paths weren't confirmed, so the fix wasn't applied")
+				return false
+			}
+		}
+
+		if !MustApplyPlan(planId, branch, loop.fixFlags) {
+			fmt.Println("🤷‍♂️ Plandex didn't propose any changes to fix the failure")
+			return false
+		}
+	}
+}
+
+// mustAskForFixes sends prompt to the plan and waits for the reply and its changes to be built
+func mustAskForFixes(planId, branch, prompt, spinnerMsg string) {
+	err := tellAndWaitForBuild(planId, branch, shared.TellPlanRequest{Prompt: prompt, AutoContinue: true}, spinnerMsg)
+	if err != nil {
+		term.OutputErrorAndExit("Error getting fixes: %v", err)
+	}
+}
+
+// tellAndWaitForBuild sends req's prompt, or continues the plan, and waits for the replies and their changes to be built. The rest of the request is filled in here.
+func tellAndWaitForBuild(planId, branch string, req shared.TellPlanRequest, spinnerMsg string) error {
+	term.StartSpinner(spinnerMsg)
+
+	contexts, apiErr := api.Client.ListContext(planId, branch)
+	if apiErr != nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("Error getting context: %v", apiErr.Msg)
+	}
+
+	paths, err := fs.GetProjectPaths(fs.GetBaseDirForContexts(contexts))
+	if err != nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("Error getting project paths: %v", err)
+	}
+
+	req.ConnectStream = true
+	req.ProjectPaths = paths.ActivePaths
+	req.BuildMode = shared.BuildModeAuto
+	req.ApiKey = os.Getenv("OPENAI_API_KEY")
+	req.Mock = GetMockConfig()
+	req.ModelSet = MustGetDefaultModelSet()
+	if currentAutoRun != nil {
+		req.AutoRun = &currentAutoRun.run
+	}
+
+	err = tellAndWait(planId, branch, req, nil)
+
+	term.StopSpinner()
+
+	return err
+}
+
+// runShellCmd runs the command through the shell from the project root, or in the project's container, showing its output as it runs and returning it for the plan
+func runShellCmd(cmdLine string) (string, error) {
+	cmd, err := commandForShell(cmdLine)
+	if err != nil {
+		// not the command failing, so it shouldn't be sent to the plan to fix
+		term.OutputErrorAndExit("%v", err)
+	}
+
+	var buf bytes.Buffer
+	cmd.Stdout = io.MultiWriter(os.Stdout, &buf)
+	cmd.Stderr = io.MultiWriter(os.Stderr, &buf)
+	cmd.Stdin = os.Stdin
+
+	err = cmd.Run()
+	return buf.String(), err
+}
+
+// shellCommand runs a user-configured command line through the shell from the project root
+func shellCommand(cmdLine string) *exec.Cmd {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", cmdLine)
+	} else {
+		cmd = exec.Command("sh", "-c", cmdLine)
+	}
+	cmd.Dir = fs.ProjectRoot
+	return cmd
+}
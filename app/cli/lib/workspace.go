@@ -0,0 +1,348 @@
+package lib
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"plandex/api"
+	"plandex/fs"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type WorkspaceMember struct {
+	Name string
+	// relative to the project root, with forward slashes
+	Path string
+	// where the member came from: go.work, package.json, pnpm-workspace.yaml, Cargo.toml, or project.json for members set with 'plandex workspace set'
+	Source string
+}
+
+var goModuleRegex = regexp.MustCompile(`(?m)^module\s+(\S+)`)
+var cargoPackageNameRegex = regexp.MustCompile(`(?m)^\[package\][^\[]*?^name\s*=\s*"([^"]+)"`)
+var cargoWorkspaceMembersRegex = regexp.MustCompile(`(?s)\[workspace\].*?members\s*=\s*\[(.*?)\]`)
+var quotedRegex = regexp.MustCompile(`"([^"]+)"`)
+
+// GetWorkspaceMembers returns the project's workspace members, detected from its workspace manifests and merged with the members mapped in project.json. A mapped member replaces a detected one with the same name or path.
+func GetWorkspaceMembers() ([]*WorkspaceMember, error) {
+	settings, err := LoadProjectSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	detected, err := detectWorkspaceMembers()
+	if err != nil {
+		return nil, err
+	}
+
+	byPath := map[string]*WorkspaceMember{}
+	for _, member := range detected {
+		if _, ok := byPath[member.Path]; !ok {
+			byPath[member.Path] = member
+		}
+	}
+
+	for name, path := range settings.Workspace {
+		path = normalizeWorkspacePath(path)
+		for p, member := range byPath {
+			if member.Name == name {
+				delete(byPath, p)
+			}
+		}
+		byPath[path] = &WorkspaceMember{Name: name, Path: path, Source: "project.json"}
+	}
+
+	var members []*WorkspaceMember
+	for _, member := range byPath {
+		members = append(members, member)
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].Path < members[j].Path
+	})
+
+	return members, nil
+}
+
+// FindWorkspaceMember looks a member up by name or by path
+func FindWorkspaceMember(members []*WorkspaceMember, nameOrPath string) *WorkspaceMember {
+	for _, member := range members {
+		if member.Name == nameOrPath {
+			return member
+		}
+	}
+
+	path := normalizeWorkspacePath(nameOrPath)
+	for _, member := range members {
+		if member.Path == path {
+			return member
+		}
+	}
+
+	return nil
+}
+
+// GetPlanWorkspaceMember returns the root of the workspace member the current plan targets, or an empty string if it targets the whole project
+func GetPlanWorkspaceMember() (string, error) {
+	settings, apiErr := api.Client.GetSettings(CurrentPlanId, CurrentBranch)
+	if apiErr != nil {
+		return "", fmt.Errorf("error getting plan settings: %v", apiErr.Msg)
+	}
+	return settings.WorkspaceMember, nil
+}
+
+// ResolveWorkspaceInputPaths resolves paths given to 'plandex load' relative to the plan's workspace member. A path that doesn't exist from the current directory but does exist inside the member is replaced with the member's copy, relative to the current directory.
+func ResolveWorkspaceInputPaths(inputPaths []string) ([]string, error) {
+	var missing bool
+	for _, path := range inputPaths {
+		if _, err := os.Stat(path); os.IsNotExist(err) && !filepath.IsAbs(path) {
+			missing = true
+			break
+		}
+	}
+
+	// only look up the plan's settings when there's something to resolve
+	if !missing {
+		return inputPaths, nil
+	}
+
+	member, err := GetPlanWorkspaceMember()
+	if err != nil {
+		return nil, err
+	}
+	if member == "" {
+		return inputPaths, nil
+	}
+
+	return resolveMemberInputPaths(member, inputPaths), nil
+}
+
+func resolveMemberInputPaths(member string, inputPaths []string) []string {
+	res := make([]string, len(inputPaths))
+	for i, path := range inputPaths {
+		res[i] = path
+		if filepath.IsAbs(path) {
+			continue
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			continue
+		}
+
+		// the member is relative to the project root, but input paths are relative to the current directory, which may be beneath it
+		memberPath := filepath.Join(fs.ProjectRoot, filepath.FromSlash(member), path)
+		if _, err := os.Stat(memberPath); err != nil {
+			continue
+		}
+		if rel, err := filepath.Rel(fs.Cwd, memberPath); err == nil {
+			res[i] = rel
+		} else {
+			res[i] = memberPath
+		}
+	}
+
+	return res
+}
+
+func detectWorkspaceMembers() ([]*WorkspaceMember, error) {
+	var members []*WorkspaceMember
+
+	detectors := []struct {
+		file   string
+		detect func(content string) []string
+	}{
+		{"go.work", parseGoWorkUses},
+		{"package.json", parsePackageJsonWorkspaces},
+		{"pnpm-workspace.yaml", parsePnpmWorkspacePackages},
+		{"Cargo.toml", parseCargoWorkspaceMembers},
+	}
+
+	for _, detector := range detectors {
+		bytes, err := os.ReadFile(filepath.Join(fs.ProjectRoot, detector.file))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("error reading %s: %v", detector.file, err)
+		}
+
+		for _, pattern := range detector.detect(string(bytes)) {
+			dirs, err := expandWorkspacePattern(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("error expanding %s pattern %s: %v", detector.file, pattern, err)
+			}
+
+			for _, dir := range dirs {
+				members = append(members, &WorkspaceMember{
+					Name:   getWorkspaceMemberName(dir),
+					Path:   dir,
+					Source: detector.file,
+				})
+			}
+		}
+	}
+
+	return members, nil
+}
+
+// parseGoWorkUses handles both single 'use ./dir' lines and 'use ( ... )' blocks
+func parseGoWorkUses(content string) []string {
+	var res []string
+	inBlock := false
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+
+		if inBlock {
+			if line == ")" {
+				inBlock = false
+			} else if line != "" {
+				res = append(res, strings.Trim(line, `"`))
+			}
+			continue
+		}
+
+		if line == "use (" || line == "use(" {
+			inBlock = true
+		} else if strings.HasPrefix(line, "use ") {
+			res = append(res, strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "use ")), `"`))
+		}
+	}
+
+	return res
+}
+
+// parsePackageJsonWorkspaces handles both the array form of 'workspaces' and the object form with a 'packages' list
+func parsePackageJsonWorkspaces(content string) []string {
+	var pkg struct {
+		Workspaces json.RawMessage `json:"workspaces"`
+	}
+	if json.Unmarshal([]byte(content), &pkg) != nil || len(pkg.Workspaces) == 0 {
+		return nil
+	}
+
+	var patterns []string
+	if json.Unmarshal(pkg.Workspaces, &patterns) == nil {
+		return patterns
+	}
+
+	var obj struct {
+		Packages []string `json:"packages"`
+	}
+	if json.Unmarshal(pkg.Workspaces, &obj) == nil {
+		return obj.Packages
+	}
+
+	return nil
+}
+
+// parsePnpmWorkspacePackages reads the 'packages' list; pnpm's file is simple enough that it isn't worth a yaml dependency
+func parsePnpmWorkspacePackages(content string) []string {
+	var res []string
+	inPackages := false
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "-") {
+			inPackages = strings.HasPrefix(trimmed, "packages:")
+			continue
+		}
+
+		if inPackages && strings.HasPrefix(trimmed, "-") {
+			pattern := strings.Trim(strings.TrimSpace(strings.TrimPrefix(trimmed, "-")), `"'`)
+			// exclusions like '!**/test/**' only narrow the globs, so they're ignored
+			if pattern != "" && !strings.HasPrefix(pattern, "!") {
+				res = append(res, pattern)
+			}
+		}
+	}
+
+	return res
+}
+
+func parseCargoWorkspaceMembers(content string) []string {
+	matches := cargoWorkspaceMembersRegex.FindStringSubmatch(content)
+	if matches == nil {
+		return nil
+	}
+
+	var res []string
+	for _, quoted := range quotedRegex.FindAllStringSubmatch(matches[1], -1) {
+		res = append(res, quoted[1])
+	}
+	return res
+}
+
+// expandWorkspacePattern expands a member glob to the matching directories under the project root. A trailing '/**' is treated like '/*', since nested workspace members are rare and walking the whole tree isn't worth it.
+func expandWorkspacePattern(pattern string) ([]string, error) {
+	pattern = strings.TrimSuffix(normalizeWorkspacePath(pattern), "/**")
+	if pattern == "" || pattern == "." {
+		return nil, nil
+	}
+
+	matches, err := filepath.Glob(filepath.Join(fs.ProjectRoot, filepath.FromSlash(pattern)))
+	if err != nil {
+		return nil, err
+	}
+
+	var res []string
+	for _, match := range matches {
+		info, err := os.Stat(match)
+		if err != nil || !info.IsDir() {
+			continue
+		}
+
+		rel, err := filepath.Rel(fs.ProjectRoot, match)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		res = append(res, filepath.ToSlash(rel))
+	}
+
+	return res, nil
+}
+
+// getWorkspaceMemberName uses the name the member gives itself in its own manifest, falling back to its path
+func getWorkspaceMemberName(dir string) string {
+	root := filepath.Join(fs.ProjectRoot, filepath.FromSlash(dir))
+
+	if bytes, err := os.ReadFile(filepath.Join(root, "package.json")); err == nil {
+		var pkg struct {
+			Name string `json:"name"`
+		}
+		if json.Unmarshal(bytes, &pkg) == nil && pkg.Name != "" {
+			return pkg.Name
+		}
+	}
+
+	if bytes, err := os.ReadFile(filepath.Join(root, "go.mod")); err == nil {
+		if matches := goModuleRegex.FindSubmatch(bytes); matches != nil {
+			return string(matches[1])
+		}
+	}
+
+	if bytes, err := os.ReadFile(filepath.Join(root, "Cargo.toml")); err == nil {
+		if matches := cargoPackageNameRegex.FindSubmatch(bytes); matches != nil {
+			return string(matches[1])
+		}
+	}
+
+	return dir
+}
+
+func normalizeWorkspacePath(path string) string {
+	path = filepath.ToSlash(filepath.Clean(filepath.FromSlash(strings.TrimSpace(path))))
+	return strings.TrimPrefix(path, "./")
+}
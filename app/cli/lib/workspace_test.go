@@ -0,0 +1,46 @@
+package lib
+
+import (
+	"os"
+	"path/filepath"
+	"plandex/fs"
+	"testing"
+)
+
+func TestResolveMemberInputPaths(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{filepath.Join("packages", "api"), "tools"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, file := range []string{filepath.Join("packages", "api", "main.go"), filepath.Join("tools", "gen.go")} {
+		if err := os.WriteFile(filepath.Join(root, file), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	origWd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	origRoot, origCwd := fs.ProjectRoot, fs.Cwd
+	fs.ProjectRoot, fs.Cwd = root, filepath.Join(root, "tools")
+	if err := os.Chdir(fs.Cwd); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		os.Chdir(origWd)
+		fs.ProjectRoot, fs.Cwd = origRoot, origCwd
+	}()
+
+	// run from a directory beneath the root, so the member's copy has to be relative to it rather than to the root
+	res := resolveMemberInputPaths("packages/api", []string{"main.go", "gen.go", "missing.go"})
+
+	want := []string{filepath.Join("..", "packages", "api", "main.go"), "gen.go", "missing.go"}
+	for i := range want {
+		if res[i] != want[i] {
+			t.Errorf("path %d = %q, want %q", i, res[i], want[i])
+		}
+	}
+}
@@ -53,4 +53,6 @@ func main() {
 	}
 
 	cmd.Execute()
+
+	lib.WaitForNotifications()
 }
@@ -0,0 +1,180 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"plandex/jsonrpc"
+	"plandex/version"
+	"strconv"
+	"time"
+)
+
+// how long a server gets to answer a request before it's stopped
+const clientRequestTimeout = 2 * time.Minute
+
+// how long a server gets to start and answer the initialize handshake. It's longer than most servers need, to leave room for commands like 'npx -y' that download the server on first run.
+const clientStartTimeout = 30 * time.Second
+
+// Client calls tools on an MCP server that it starts as a subprocess and speaks to over stdio
+type Client struct {
+	ServerInfo Implementation
+
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	conn   *jsonrpc.Conn
+	nextId int
+}
+
+// StartClient starts the server and completes the protocol's initialize handshake. Anything the server logs to its stderr is written to stderr.
+func StartClient(command string, args, env []string, stderr io.Writer) (*Client, error) {
+	cmd := exec.Command(command, args...)
+	cmd.Env = env
+	cmd.Stderr = stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("error getting stdin for %s: %v", command, err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("error getting stdout for %s: %v", command, err)
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		return nil, fmt.Errorf("error starting %s: %v", command, err)
+	}
+
+	c := &Client{cmd: cmd, stdin: stdin, conn: jsonrpc.NewConn(stdout, stdin)}
+
+	var res InitializeResult
+	err = c.callWithTimeout("initialize", InitializeParams{
+		ProtocolVersion: ProtocolVersion,
+		Capabilities:    map[string]any{},
+		ClientInfo:      Implementation{Name: "plandex", Version: version.Version},
+	}, &res, clientStartTimeout)
+	if err != nil {
+		c.Close()
+		return nil, fmt.Errorf("error initializing %s: %v", command, err)
+	}
+	c.ServerInfo = res.ServerInfo
+
+	err = c.conn.Write(&Message{Method: "notifications/initialized"})
+	if err != nil {
+		c.Close()
+		return nil, fmt.Errorf("error initializing %s: %v", command, err)
+	}
+
+	return c, nil
+}
+
+func (c *Client) ListTools() ([]Tool, error) {
+	var res ListToolsResult
+	err := c.call("tools/list", map[string]any{}, &res)
+	if err != nil {
+		return nil, err
+	}
+	return res.Tools, nil
+}
+
+// CallTool runs a tool. A tool that fails still returns a result, with IsError set and the failure in its content.
+func (c *Client) CallTool(name string, args map[string]any) (*CallToolResult, error) {
+	argsJson, err := json.Marshal(args)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling arguments: %v", err)
+	}
+
+	var res CallToolResult
+	err = c.call("tools/call", CallToolParams{Name: name, Arguments: argsJson}, &res)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// Close stops the server
+func (c *Client) Close() error {
+	c.stdin.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- c.cmd.Wait()
+	}()
+
+	// servers should exit when their input is closed, but they aren't waited on for long
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(2 * time.Second):
+		c.cmd.Process.Kill()
+		return <-done
+	}
+}
+
+func (c *Client) call(method string, params, result any) error {
+	return c.callWithTimeout(method, params, result, clientRequestTimeout)
+}
+
+func (c *Client) callWithTimeout(method string, params, result any, timeout time.Duration) error {
+	c.nextId++
+	id := json.RawMessage(strconv.Itoa(c.nextId))
+
+	paramsJson, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("error marshalling params: %v", err)
+	}
+
+	err = c.conn.Write(&Message{Id: id, Method: method, Params: paramsJson})
+	if err != nil {
+		return fmt.Errorf("error sending %s: %v", method, err)
+	}
+
+	resCh := make(chan *Message, 1)
+	errCh := make(chan error, 1)
+	go func() {
+		for {
+			msg, err := c.conn.Read()
+			if err != nil {
+				errCh <- err
+				return
+			}
+
+			// requests from the server, like for roots or sampling, aren't supported
+			if msg.Method != "" {
+				if len(msg.Id) > 0 {
+					c.conn.Write(&Message{Id: msg.Id, Error: &Error{Code: errCodeMethodNotFound, Message: "not supported by this client: " + msg.Method}})
+				}
+				continue
+			}
+
+			if string(msg.Id) == string(id) {
+				resCh <- msg
+				return
+			}
+		}
+	}()
+
+	select {
+	case msg := <-resCh:
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if result != nil {
+			err = json.Unmarshal(msg.Result, result)
+			if err != nil {
+				return fmt.Errorf("error parsing %s result: %v", method, err)
+			}
+		}
+		return nil
+	case err := <-errCh:
+		if err == io.EOF {
+			return fmt.Errorf("server exited before answering %s", method)
+		}
+		return fmt.Errorf("error reading %s result: %v", method, err)
+	case <-time.After(timeout):
+		c.cmd.Process.Kill()
+		return fmt.Errorf("server didn't answer %s within %s", method, timeout)
+	}
+}
@@ -0,0 +1,79 @@
+package mcp
+
+import (
+	"encoding/json"
+	"plandex/jsonrpc"
+)
+
+// ProtocolVersion is the Model Context Protocol revision spoken on both sides
+const ProtocolVersion = "2024-11-05"
+
+const (
+	errCodeInvalidRequest = jsonrpc.ErrCodeInvalidRequest
+	errCodeMethodNotFound = jsonrpc.ErrCodeMethodNotFound
+	errCodeInvalidParams  = jsonrpc.ErrCodeInvalidParams
+)
+
+// Message is a JSON-RPC 2.0 request, notification, or response, which MCP is built on
+type Message = jsonrpc.Message
+
+type Error = jsonrpc.Error
+
+type Implementation struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type InitializeParams struct {
+	ProtocolVersion string         `json:"protocolVersion"`
+	Capabilities    map[string]any `json:"capabilities"`
+	ClientInfo      Implementation `json:"clientInfo"`
+}
+
+type InitializeResult struct {
+	ProtocolVersion string         `json:"protocolVersion"`
+	Capabilities    map[string]any `json:"capabilities"`
+	ServerInfo      Implementation `json:"serverInfo"`
+}
+
+type Tool struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// a JSON schema for the tool's arguments
+	InputSchema map[string]any `json:"inputSchema"`
+}
+
+type ListToolsResult struct {
+	Tools []Tool `json:"tools"`
+}
+
+type CallToolParams struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+}
+
+// Content is a part of a tool's result. Only text is produced here, though servers may send other types.
+type Content struct {
+	Type string `json:"type"`
+	Text string `json:"text,omitempty"`
+}
+
+type CallToolResult struct {
+	Content []Content `json:"content"`
+	IsError bool      `json:"isError,omitempty"`
+}
+
+// Text joins the result's text parts
+func (r *CallToolResult) Text() string {
+	var text string
+	for _, c := range r.Content {
+		if c.Type != "text" {
+			continue
+		}
+		if text != "" {
+			text += "\n"
+		}
+		text += c.Text
+	}
+	return text
+}
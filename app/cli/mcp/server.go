@@ -0,0 +1,120 @@
+package mcp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"plandex/jsonrpc"
+)
+
+// ToolHandler runs a tool with its raw JSON arguments. A returned error is reported to the client as a failed tool result rather than a protocol error, so the model calling the tool can see it.
+type ToolHandler func(args json.RawMessage) (string, error)
+
+// Server answers MCP requests for a set of tools
+type Server struct {
+	info     Implementation
+	tools    []Tool
+	handlers map[string]ToolHandler
+}
+
+func NewServer(name, version string) *Server {
+	return &Server{
+		info:     Implementation{Name: name, Version: version},
+		handlers: map[string]ToolHandler{},
+	}
+}
+
+func (s *Server) AddTool(tool Tool, handler ToolHandler) {
+	s.tools = append(s.tools, tool)
+	s.handlers[tool.Name] = handler
+}
+
+// Serve answers requests from r on w until r is closed. Requests are handled one at a time, since tools work on the same plan and project files.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	c := jsonrpc.NewConn(r, w)
+
+	for {
+		msg, err := c.Read()
+		if err == io.EOF {
+			return nil
+		}
+
+		var protocolErr *Error
+		if errors.As(err, &protocolErr) {
+			err = c.Write(&Message{Id: json.RawMessage("null"), Error: protocolErr})
+			if err != nil {
+				return err
+			}
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		// responses to requests we didn't make, and notifications like notifications/initialized, need no reply
+		if msg.Method == "" || len(msg.Id) == 0 {
+			continue
+		}
+
+		res := &Message{Id: msg.Id}
+		result, rpcErr := s.handle(msg)
+		if rpcErr != nil {
+			res.Error = rpcErr
+		} else {
+			res.Result, err = json.Marshal(result)
+			if err != nil {
+				res.Error = &Error{Code: errCodeInvalidRequest, Message: fmt.Sprintf("error marshalling result: %v", err)}
+			}
+		}
+
+		err = c.Write(res)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) handle(msg *Message) (any, *Error) {
+	switch msg.Method {
+	case "initialize":
+		var params InitializeParams
+		if len(msg.Params) > 0 {
+			if err := json.Unmarshal(msg.Params, &params); err != nil {
+				return nil, &Error{Code: errCodeInvalidParams, Message: fmt.Sprintf("invalid params: %v", err)}
+			}
+		}
+		log.Printf("MCP client connected: %s %s\n", params.ClientInfo.Name, params.ClientInfo.Version)
+
+		return InitializeResult{
+			ProtocolVersion: ProtocolVersion,
+			Capabilities:    map[string]any{"tools": map[string]any{}},
+			ServerInfo:      s.info,
+		}, nil
+
+	case "ping":
+		return map[string]any{}, nil
+
+	case "tools/list":
+		return ListToolsResult{Tools: s.tools}, nil
+
+	case "tools/call":
+		var params CallToolParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, &Error{Code: errCodeInvalidParams, Message: fmt.Sprintf("invalid params: %v", err)}
+		}
+
+		handler, ok := s.handlers[params.Name]
+		if !ok {
+			return nil, &Error{Code: errCodeInvalidParams, Message: fmt.Sprintf("unknown tool: %s", params.Name)}
+		}
+
+		text, err := handler(params.Arguments)
+		if err != nil {
+			return CallToolResult{Content: []Content{{Type: "text", Text: err.Error()}}, IsError: true}, nil
+		}
+		return CallToolResult{Content: []Content{{Type: "text", Text: text}}}, nil
+	}
+
+	return nil, &Error{Code: errCodeMethodNotFound, Message: fmt.Sprintf("method not found: %s", msg.Method)}
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"plandex/api"
 	"plandex/fs"
+	"plandex/lib"
 	"plandex/stream"
 	streamtui "plandex/stream_tui"
 	"plandex/term"
@@ -36,10 +37,13 @@ func Build(params ExecParams, buildBg bool) (bool, error) {
 		return false, fmt.Errorf("error getting project paths: %v", err)
 	}
 
+	stream.SetStreamPlan(params.CurrentPlanId, params.CurrentBranch)
 	apiErr = api.Client.BuildPlan(params.CurrentPlanId, params.CurrentBranch, shared.BuildPlanRequest{
 		ConnectStream: !buildBg,
 		ProjectPaths:  paths.ActivePaths,
 		ApiKey:        os.Getenv("OPENAI_API_KEY"),
+		Mock:          lib.GetMockConfig(),
+		ModelSet:      lib.MustGetDefaultModelSet(),
 	}, stream.OnStreamPlan)
 
 	term.StopSpinner()
@@ -54,6 +58,8 @@ func Build(params ExecParams, buildBg bool) (bool, error) {
 	}
 
 	if !buildBg {
+		lib.HandOffStreamOnHangup(params.CurrentPlanId, params.CurrentBranch)
+
 		ch := make(chan error)
 
 		go func() {
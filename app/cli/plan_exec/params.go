@@ -6,4 +6,16 @@ type ExecParams struct {
 	CurrentPlanId        string
 	CurrentBranch        string
 	CheckOutdatedContext func(maybeContexts []*shared.Context) (bool, bool)
+
+	// replies answer in chat form and never write files or build
+	ChatOnly bool
+	// with ChatOnly, the prompt and reply aren't added to the plan's conversation
+	Ephemeral bool
+	// the prompt replaces the plan's last prompt and everything that followed it
+	ReplaceLastPrompt bool
+	// the model makes a plan right away rather than asking clarifying questions when the prompt is ambiguous
+	NoClarify bool
+
+	// used in place of the plan's model settings, e.g. for a preset
+	ModelSetOverride *shared.ModelSet
 }
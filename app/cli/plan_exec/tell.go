@@ -7,6 +7,7 @@ import (
 	"plandex/api"
 	"plandex/auth"
 	"plandex/fs"
+	"plandex/lib"
 	"plandex/stream"
 	streamtui "plandex/stream_tui"
 	"plandex/term"
@@ -22,6 +23,10 @@ func TellPlan(
 	tellNoBuild,
 	isUserContinue bool,
 ) {
+	if !isUserContinue && prompt != "" {
+		lib.LoadMcpPromptContext(prompt)
+	}
+
 	term.StartSpinner("")
 	contexts, apiErr := api.Client.ListContext(params.CurrentPlanId, params.CurrentBranch)
 
@@ -38,7 +43,7 @@ func TellPlan(
 		} else {
 			log.Println("Prompt not sent")
 		}
-		os.Exit(0)
+		term.Exit(0)
 	}
 
 	paths, err := fs.GetProjectPaths(fs.GetBaseDirForContexts(contexts))
@@ -63,14 +68,22 @@ func TellPlan(
 			term.StartSpinner("💬 Sending prompt...")
 		}
 
+		stream.SetStreamPlan(params.CurrentPlanId, params.CurrentBranch)
 		apiErr := api.Client.TellPlan(params.CurrentPlanId, params.CurrentBranch, shared.TellPlanRequest{
-			Prompt:         prompt,
-			ConnectStream:  !tellBg,
-			AutoContinue:   !tellStop,
-			ProjectPaths:   paths.ActivePaths,
-			BuildMode:      buildMode,
-			IsUserContinue: isUserContinue,
-			ApiKey:         os.Getenv("OPENAI_API_KEY"),
+			Prompt:            prompt,
+			ConnectStream:     !tellBg,
+			AutoContinue:      !tellStop,
+			ProjectPaths:      paths.ActivePaths,
+			BuildMode:         buildMode,
+			IsUserContinue:    isUserContinue,
+			ChatOnly:          params.ChatOnly,
+			Ephemeral:         params.Ephemeral,
+			ReplaceLastPrompt: params.ReplaceLastPrompt,
+			AllowClarify:      !tellBg && !params.NoClarify,
+			ApiKey:            os.Getenv("OPENAI_API_KEY"),
+			Mock:              lib.GetMockConfig(),
+			ModelSet:          lib.MustGetDefaultModelSet(),
+			ModelSetOverride:  params.ModelSetOverride,
 		}, stream.OnStreamPlan)
 
 		term.StopSpinner()
@@ -101,10 +114,12 @@ func TellPlan(
 			fmt.Println("🤷‍♂️ There's no plan yet to continue")
 			fmt.Println()
 			term.PrintCmds("", "tell")
-			os.Exit(0)
+			term.Exit(0)
 		}
 
 		if !tellBg {
+			lib.HandOffStreamOnHangup(params.CurrentPlanId, params.CurrentBranch)
+
 			go func() {
 				err := streamtui.StartStreamUI(prompt, false)
 
@@ -112,14 +127,22 @@ func TellPlan(
 					term.OutputErrorAndExit("Error starting stream UI: %v", err)
 				}
 
+				if !tellNoBuild {
+					lib.MaybeRunLintLoop(params.CurrentPlanId, params.CurrentBranch)
+				}
+
 				fmt.Println()
 
-				if tellStop {
+				if params.Ephemeral {
+					term.PrintCmds("", "explain", "chat", "tell")
+				} else if params.ChatOnly {
+					term.PrintCmds("", "chat", "tell", "log", "rewind")
+				} else if tellStop {
 					term.PrintCmds("", "continue", "changes", "apply", "log", "rewind")
 				} else {
 					term.PrintCmds("", "changes", "apply", "log", "rewind")
 				}
-				os.Exit(0)
+				term.Exit(0)
 			}()
 		}
 
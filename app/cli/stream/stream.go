@@ -1,16 +1,28 @@
 package stream
 
 import (
+	"fmt"
 	"log"
+	"plandex/lib"
 	streamtui "plandex/stream_tui"
 	"plandex/types"
 
 	"github.com/plandex/plandex/shared"
 )
 
+// the plan and branch that --notify notifications are sent for
+var streamPlanId, streamBranch string
+
+// SetStreamPlan sets the plan the next stream belongs to, so notifications can name it
+func SetStreamPlan(planId, branch string) {
+	streamPlanId = planId
+	streamBranch = branch
+}
+
 var OnStreamPlan types.OnStreamPlan = func(params types.OnStreamPlanParams) {
 	if params.Err != nil {
 		log.Println("Error in stream:", params.Err)
+		lib.NotifyStreamDone(streamPlanId, streamBranch, fmt.Sprintf("lost connection to the stream: %v", params.Err))
 		return
 	}
 
@@ -19,6 +31,13 @@ var OnStreamPlan types.OnStreamPlan = func(params types.OnStreamPlanParams) {
 		return
 	}
 
+	// notifications are started before the stream UI sees the message, since it exits once the stream is done. They're sent in the background, so they don't hold it up.
+	if params.Msg.Type == shared.StreamMessageFinished {
+		lib.NotifyStreamDone(streamPlanId, streamBranch, "")
+	} else if params.Msg.Type == shared.StreamMessageError && params.Msg.Error != nil {
+		lib.NotifyStreamDone(streamPlanId, streamBranch, params.Msg.Error.Msg)
+	}
+
 	// log.Println("Stream message:")
 	// log.Println(spew.Sdump(*params.Msg))
 
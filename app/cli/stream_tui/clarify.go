@@ -0,0 +1,91 @@
+package streamtui
+
+import (
+	"log"
+	"plandex/api"
+	"plandex/lib"
+	"plandex/term"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fatih/color"
+	"github.com/plandex/plandex/shared"
+)
+
+// startClarify pauses the stream UI to collect answers to the model's questions, one at a time
+func (m *streamUIModel) startClarify(questions []string) tea.Cmd {
+	input := textinput.New()
+	input.Prompt = " > "
+	input.Placeholder = "answer, or leave blank to let Plandex decide"
+	input.Width = m.width - 4
+
+	m.promptingClarify = true
+	m.clarifyingQuestions = questions
+	m.clarifyAnswers = nil
+	m.clarifyInput = input
+	m.processing = false
+
+	m.updateViewportDimensions()
+
+	return m.clarifyInput.Focus()
+}
+
+// clarifyKey sends keys to the answer input while questions are being answered, so typing doesn't trigger the stream UI's shortcuts
+func (m *streamUIModel) clarifyKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		m.background = true
+		return m, tea.Quit
+
+	case tea.KeyEnter:
+		m.clarifyAnswers = append(m.clarifyAnswers, m.clarifyInput.Value())
+		m.clarifyInput.Reset()
+
+		if len(m.clarifyAnswers) < len(m.clarifyingQuestions) {
+			m.updateViewportDimensions()
+			return m, nil
+		}
+
+		return m.submitClarify()
+	}
+
+	var cmd tea.Cmd
+	m.clarifyInput, cmd = m.clarifyInput.Update(msg)
+	return m, cmd
+}
+
+func (m *streamUIModel) submitClarify() (tea.Model, tea.Cmd) {
+	apiErr := api.Client.RespondClarify(lib.CurrentPlanId, lib.CurrentBranch, shared.RespondClarifyRequest{
+		Answers: m.clarifyAnswers,
+	})
+
+	if apiErr != nil {
+		log.Println("clarify prompt api error:", apiErr)
+		m.apiErr = apiErr
+		return m, tea.Quit
+	}
+
+	m.promptingClarify = false
+	m.clarifyingQuestions = nil
+	m.clarifyAnswers = nil
+	m.processing = true
+
+	m.updateViewportDimensions()
+
+	return m, m.spinner.Tick
+}
+
+func (m streamUIModel) renderClarify() string {
+	style := lipgloss.NewStyle().Width(m.width).BorderStyle(lipgloss.NormalBorder()).BorderTop(true).BorderForeground(lipgloss.Color(borderColor))
+
+	idx := len(m.clarifyAnswers)
+	question := m.clarifyingQuestions[idx]
+
+	s := color.New(term.ColorHiMagenta, color.Bold).Sprintf(" 🤔 Question %d/%d", idx+1, len(m.clarifyingQuestions))
+	s += " " + question + "\n"
+	s += m.clarifyInput.View() + "\n"
+	s += lipgloss.NewStyle().Foreground(lipgloss.Color(helpTextColor)).Render(" enter to answer • ctrl+c to background")
+
+	return style.Render(s)
+}
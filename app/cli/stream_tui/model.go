@@ -1,8 +1,11 @@
 package streamtui
 
 import (
+	"plandex/term"
+
 	bubbleKey "github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -31,8 +34,13 @@ type streamUIModel struct {
 	buildOnly bool
 	keymap    keymap
 
-	reply       string
+	reply       *replyBuffer
+	replyMd     *term.IncrementalMarkdown
 	mainDisplay string
+	// set when reply chunks have arrived since the last render
+	replyDirty bool
+	// set while a render is scheduled
+	replyRenderQueued bool
 
 	mainViewport viewport.Model
 
@@ -43,6 +51,11 @@ type streamUIModel struct {
 	building       bool
 	tokensByPath   map[string]int
 	finishedByPath map[string]bool
+	// finished files whose type the server has no syntax check for
+	syntaxUncheckedByPath map[string]bool
+
+	replyRate  *streamRate
+	buildRates map[string]*streamRate
 
 	ready  bool
 	width  int
@@ -57,8 +70,19 @@ type streamUIModel struct {
 	missingFileContent     string
 	missingFileTokens      int
 
+	promptingClarify    bool
+	clarifyingQuestions []string
+	clarifyAnswers      []string
+	clarifyInput        textinput.Model
+
 	prompt string
 
+	usageByPhase map[shared.UsagePhase]*shared.UsageTotal
+	// the plan's latest budget status, if it has a budget
+	budget *shared.BudgetStatus
+	// the conversation history sent with the prompt
+	convoHistory *shared.ConvoHistory
+
 	stopped    bool
 	background bool
 	finished   bool
@@ -96,7 +120,8 @@ func initialModel(prestartReply, prompt string, buildOnly bool) *streamUIModel {
 	initialState := streamUIModel{
 		buildOnly: buildOnly,
 		prompt:    prompt,
-		reply:     prestartReply,
+		reply:     newReplyBuffer(prestartReply),
+		replyMd:   &term.IncrementalMarkdown{},
 		keymap: keymap{
 			quit: bubbleKey.NewBinding(
 				bubbleKey.WithKeys("b", "ctrl+c"),
@@ -154,11 +179,14 @@ func initialModel(prestartReply, prompt string, buildOnly bool) *streamUIModel {
 			),
 		},
 
-		tokensByPath:   make(map[string]int),
-		finishedByPath: make(map[string]bool),
-		spinner:        s,
-		atScrollBottom: true,
-		starting:       true,
+		tokensByPath:          make(map[string]int),
+		finishedByPath:        make(map[string]bool),
+		syntaxUncheckedByPath: make(map[string]bool),
+		replyRate:             newStreamRate(),
+		buildRates:            make(map[string]*streamRate),
+		spinner:               s,
+		atScrollBottom:        true,
+		starting:              true,
 	}
 
 	return &initialState
@@ -0,0 +1,151 @@
+package streamtui
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"plandex/fs"
+	"plandex/lib"
+	"sort"
+	"strings"
+	"time"
+)
+
+// how much of the end of a reply is kept in memory and rendered. Content before it is only in the reply file.
+const replyWindowSize = 32 * 1024
+
+// how many reply files are kept in each plan's replies dir
+const maxReplyFiles = 20
+
+// replyBuffer holds a reply as it streams. The whole reply is appended to a file in the plan's dir, and only its tail is kept in memory for rendering, so long replies don't slow down the UI.
+type replyBuffer struct {
+	path string
+	file *os.File
+	// bytes written to the file
+	size int64
+
+	tail string
+	// set once content has dropped off the front of tail
+	truncated bool
+	// whether the content dropped off the front of tail ended inside a code block
+	dropInFence bool
+}
+
+func newReplyBuffer(initial string) *replyBuffer {
+	b := &replyBuffer{}
+
+	if lib.CurrentPlanId != "" {
+		dir := filepath.Join(fs.HomePlandexDir, lib.CurrentProjectId, lib.CurrentPlanId, "replies")
+		err := os.MkdirAll(dir, os.ModePerm)
+		if err == nil {
+			pruneReplyFiles(dir)
+			b.path = filepath.Join(dir, time.Now().Format("20060102-150405.000")+".md")
+			b.file, err = os.Create(b.path)
+		}
+		if err != nil {
+			// without a file, the whole reply stays in memory
+			log.Println("Error creating reply file:", err)
+			b.path = ""
+			b.file = nil
+		}
+	}
+
+	b.append(initial)
+	return b
+}
+
+func (b *replyBuffer) append(s string) {
+	if s == "" {
+		return
+	}
+
+	if b.file != nil {
+		n, err := b.file.WriteString(s)
+		b.size += int64(n)
+		if err != nil {
+			log.Println("Error writing reply file:", err)
+		}
+	}
+
+	b.tail += s
+
+	if b.file != nil && len(b.tail) > 2*replyWindowSize {
+		b.dropFront()
+	}
+}
+
+// dropFront cuts tail back to about replyWindowSize at a line boundary
+func (b *replyBuffer) dropFront() {
+	cut := len(b.tail) - replyWindowSize
+	if i := strings.IndexByte(b.tail[cut:], '\n'); i >= 0 {
+		cut += i + 1
+	}
+
+	dropped := b.tail[:cut]
+	if strings.Count(dropped, "```")%2 == 1 {
+		b.dropInFence = !b.dropInFence
+	}
+
+	b.tail = b.tail[cut:]
+	b.truncated = true
+}
+
+// trimLastLines removes the last n lines of the reply, from the file as well as the tail
+func (b *replyBuffer) trimLastLines(n int) {
+	lines := strings.Split(b.tail, "\n")
+	if len(lines) <= n {
+		return
+	}
+
+	trimmed := strings.Join(lines[:len(lines)-n], "\n")
+	removed := int64(len(b.tail) - len(trimmed))
+	b.tail = trimmed
+
+	if b.file != nil {
+		b.size -= removed
+		err := b.file.Truncate(b.size)
+		if err == nil {
+			_, err = b.file.Seek(b.size, 0)
+		}
+		if err != nil {
+			log.Println("Error trimming reply file:", err)
+		}
+	}
+}
+
+// String returns the tail to render, opening a code block first if the tail starts inside one so it still renders as code
+func (b *replyBuffer) String() string {
+	if b.dropInFence {
+		return "```\n" + b.tail
+	}
+	return b.tail
+}
+
+func (b *replyBuffer) close() {
+	if b.file == nil {
+		return
+	}
+	err := b.file.Close()
+	if err != nil {
+		log.Println("Error closing reply file:", err)
+	}
+	b.file = nil
+}
+
+func pruneReplyFiles(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) < maxReplyFiles {
+		return
+	}
+
+	// names are timestamps, so they sort oldest first
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+
+	for _, name := range names[:len(names)-maxReplyFiles+1] {
+		os.Remove(filepath.Join(dir, name))
+	}
+}
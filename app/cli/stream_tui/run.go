@@ -3,7 +3,6 @@ package streamtui
 import (
 	"fmt"
 	"log"
-	"os"
 	"plandex/term"
 	"sync"
 
@@ -27,7 +26,7 @@ func StartStreamUI(prompt string, buildOnly bool) error {
 
 	if prestartAbort {
 		fmt.Println("🛑 Stopped early")
-		os.Exit(0)
+		term.Exit(0)
 	}
 
 	initial := initialModel(prestartReply, prompt, buildOnly)
@@ -55,19 +54,54 @@ func StartStreamUI(prompt string, buildOnly bool) error {
 
 	fmt.Println()
 
+	mod.reply.close()
+
+	// the UI can quit with chunks that haven't been rendered yet
+	if mod.replyDirty {
+		mod.updateReplyDisplay()
+	}
+
 	if !mod.buildOnly {
 		fmt.Println(mod.mainDisplay)
+		if mod.reply.truncated {
+			color.New(color.FgHiBlack).Printf("📄 Full reply: %s\n", mod.reply.path)
+		}
 	}
 
 	if len(mod.finishedByPath) > 0 || len(mod.tokensByPath) > 0 {
 		fmt.Println(mod.renderStaticBuild())
 	}
 
+	printRates(mod.replyRate, mod.buildRates)
+	printConvoHistory(mod.convoHistory)
+	printUsage(mod.usageByPhase)
+	if mod.budget != nil {
+		for _, warning := range mod.budget.Warnings() {
+			color.New(color.Bold, term.ColorHiYellow).Printf("⚠️  %s\n", warning)
+		}
+	}
+
 	if mod.err != nil {
 		fmt.Println()
 		term.OutputErrorAndExit(mod.err.Error())
 	}
 
+	if mod.apiErr != nil && mod.apiErr.BudgetExceededError != nil {
+		fmt.Println()
+		color.New(color.Bold, term.ColorHiRed).Printf("💸 Budget exceeded: %s\n", mod.apiErr.BudgetExceededError.Error())
+		fmt.Println()
+		term.PrintCmds("", "set-model", "models")
+		term.Exit(1)
+	}
+
+	if mod.apiErr != nil && mod.apiErr.QuotaExceededError != nil {
+		fmt.Println()
+		color.New(color.Bold, term.ColorHiRed).Printf("💸 Quota exceeded: %s\n", mod.apiErr.QuotaExceededError.Error())
+		fmt.Println()
+		term.PrintCmds("", "usage")
+		term.Exit(1)
+	}
+
 	if mod.apiErr != nil {
 		fmt.Println()
 		term.OutputErrorAndExit("Server error: " + mod.apiErr.Msg)
@@ -77,14 +111,14 @@ func StartStreamUI(prompt string, buildOnly bool) error {
 		fmt.Println()
 		color.New(color.BgBlack, color.Bold, color.FgHiRed).Println(" 🛑 Stopped early ")
 		fmt.Println()
-		term.PrintCmds("", "log", "rewind", "tell")
-		os.Exit(0)
+		term.PrintCmds("", "log", "rewind", "regenerate", "tell")
+		term.Exit(0)
 	} else if mod.background {
 		fmt.Println()
 		color.New(color.BgBlack, color.Bold, color.FgHiGreen).Println(" ✅ Plan is active in the background ")
 		fmt.Println()
 		term.PrintCmds("", "ps", "connect", "stop")
-		os.Exit(0)
+		term.Exit(0)
 	}
 
 	return nil
@@ -121,3 +155,55 @@ func Send(msg shared.StreamMessage) {
 	// log.Printf("sending stream message to UI: %s\n", msg.Type)
 	ui.Send(msg)
 }
+
+func printRates(replyRate *streamRate, buildRates map[string]*streamRate) {
+	if !replyRate.firstAt.IsZero() {
+		color.New(color.FgHiBlack).Printf("⚡ reply: %s\n", replyRate.String())
+	}
+
+	// builds run in parallel, so their rates are averaged rather than added up
+	var numBuilds int
+	var totalPerSec float64
+	for _, rate := range buildRates {
+		if perSec := rate.perSec(); perSec > 0 {
+			numBuilds++
+			totalPerSec += perSec
+		}
+	}
+	if numBuilds > 0 {
+		color.New(color.FgHiBlack).Printf("⚡ builds: ~%.0f tokens/sec per file\n", totalPerSec/float64(numBuilds))
+	}
+}
+
+func printConvoHistory(history *shared.ConvoHistory) {
+	if history == nil || history.NumMessages == 0 {
+		return
+	}
+
+	var desc string
+	if history.Summarized {
+		desc = fmt.Sprintf("summary + last %d of %d messages", history.NumIncluded, history.NumMessages)
+	} else if history.NumIncluded < history.NumMessages {
+		desc = fmt.Sprintf("last %d of %d messages", history.NumIncluded, history.NumMessages)
+	} else {
+		desc = fmt.Sprintf("all %d messages", history.NumMessages)
+	}
+
+	color.New(color.FgHiBlack).Printf("💬 history: %s | %d 🪙 | %s\n", desc, history.Tokens, history.Policy)
+}
+
+func printUsage(usageByPhase map[shared.UsagePhase]*shared.UsageTotal) {
+	var total shared.UsageTotal
+	for _, phase := range shared.UsagePhases {
+		usage := usageByPhase[phase]
+		if usage == nil {
+			continue
+		}
+		color.New(color.FgHiBlack).Printf("🪙 %s: %d prompt tokens | %d completion tokens | %s\n", phase, usage.PromptTokens, usage.CompletionTokens, usage.FormatCost())
+		total.AddTotal(*usage)
+	}
+
+	if len(usageByPhase) > 1 {
+		color.New(color.FgHiBlack).Printf("💰 %s estimated for this exchange\n", total.FormatCost())
+	}
+}
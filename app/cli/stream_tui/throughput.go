@@ -0,0 +1,56 @@
+package streamtui
+
+import (
+	"fmt"
+	"time"
+)
+
+// streamRate tracks how quickly tokens arrive on a stream, to tell a slow model apart from a slow connection
+type streamRate struct {
+	startedAt time.Time
+	firstAt   time.Time
+	lastAt    time.Time
+	tokens    int
+}
+
+func newStreamRate() *streamRate {
+	return &streamRate{startedAt: time.Now()}
+}
+
+func (r *streamRate) add(tokens int) {
+	now := time.Now()
+	if r.firstAt.IsZero() {
+		r.firstAt = now
+	}
+	r.lastAt = now
+	r.tokens += tokens
+}
+
+// timeToFirst is how long the stream took to send its first tokens, or 0 if it hasn't yet
+func (r *streamRate) timeToFirst() time.Duration {
+	if r.firstAt.IsZero() {
+		return 0
+	}
+	return r.firstAt.Sub(r.startedAt)
+}
+
+// perSec is the rate since the first tokens arrived, or 0 until there's been long enough to measure it
+func (r *streamRate) perSec() float64 {
+	elapsed := r.lastAt.Sub(r.firstAt)
+	if elapsed < 500*time.Millisecond {
+		return 0
+	}
+	return float64(r.tokens) / elapsed.Seconds()
+}
+
+func (r *streamRate) String() string {
+	if r.firstAt.IsZero() {
+		return fmt.Sprintf("waiting for first token (%.1fs)", time.Since(r.startedAt).Seconds())
+	}
+
+	s := fmt.Sprintf("first token %.1fs", r.timeToFirst().Seconds())
+	if perSec := r.perSec(); perSec > 0 {
+		s += fmt.Sprintf(" • ~%.0f tokens/sec", perSec)
+	}
+	return s
+}
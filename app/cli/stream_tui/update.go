@@ -40,6 +40,12 @@ func (m streamUIModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case delayFileRestartMsg:
 		m.finishedByPath[msg.path] = false
 
+	case replyRenderMsg:
+		m.replyRenderQueued = false
+		if m.replyDirty {
+			m.updateReplyDisplay()
+		}
+
 	// Scroll wheel doesn't seem to work--not sure why
 	// case tea.MouseMsg:
 	// 	if !m.promptingMissingFile {
@@ -51,6 +57,10 @@ func (m streamUIModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	// 	}
 
 	case tea.KeyMsg:
+		if m.promptingClarify {
+			return m.clarifyKey(msg)
+		}
+
 		switch {
 
 		case bubbleKey.Matches(msg, m.keymap.quit):
@@ -122,14 +132,18 @@ func (m *streamUIModel) updateReplyDisplay() {
 		s += "\n\n" + strings.TrimSpace(promptTxt) + "\n"
 	}
 
-	if m.reply != "" {
-		replyMd, _ := term.GetMarkdown(m.reply)
+	if reply := m.reply.String(); reply != "" {
+		replyMd, _ := m.replyMd.Render(reply)
 		s += "\n" + color.New(color.BgBlue, color.Bold, color.FgHiWhite).Sprintf(" 🤖 Plandex reply 👇 ")
+		if m.reply.truncated {
+			s += "\n\n" + color.New(color.FgHiBlack).Sprintf("(earlier part of the reply is in %s)", m.reply.path)
+		}
 		s += "\n\n" + strings.TrimSpace(replyMd)
 	} else {
 		s += "\n"
 	}
 
+	m.replyDirty = false
 	m.mainDisplay = s
 	m.mainViewport.SetContent(s)
 	m.updateViewportDimensions()
@@ -257,15 +271,23 @@ func (m *streamUIModel) streamUpdate(msg *shared.StreamMessage) (tea.Model, tea.
 			m.buildOnly = true
 		}
 		if len(msg.InitReplies) > 0 {
-			m.reply = strings.Join(msg.InitReplies, "\n\n👉 ")
+			m.reply.close()
+			m.reply = newReplyBuffer(strings.Join(msg.InitReplies, "\n\n👉 "))
 		}
 		m.updateReplyDisplay()
 
 		checkMissingFileFn()
 
+		if len(msg.ClarifyingQuestions) > 0 {
+			return m, m.startClarify(msg.ClarifyingQuestions)
+		}
+
 	case shared.StreamMessagePromptMissingFile:
 		checkMissingFileFn()
 
+	case shared.StreamMessagePromptClarify:
+		return m, m.startClarify(msg.ClarifyingQuestions)
+
 	case shared.StreamMessageReply:
 		if m.starting {
 			m.starting = false
@@ -276,14 +298,39 @@ func (m *streamUIModel) streamUpdate(msg *shared.StreamMessage) (tea.Model, tea.
 			if m.promptedMissingFile {
 				m.promptedMissingFile = false
 			} else {
-				m.reply += "\n\n👉 "
+				m.reply.append("\n\n👉 ")
 			}
 		}
 
 		// log.Println("reply chunk:", msg.ReplyChunk)
 
-		m.reply += msg.ReplyChunk
-		m.updateReplyDisplay()
+		m.reply.append(msg.ReplyChunk)
+		// the server sends about one token per chunk
+		m.replyRate.add(1)
+		return m, m.queueReplyRender()
+
+	case shared.StreamMessageUsage:
+		if msg.Usage != nil {
+			phase := msg.Usage.Phase
+			// servers from before phases were sent only reported reply usage
+			if phase == "" {
+				phase = shared.UsagePhaseReply
+			}
+
+			if m.usageByPhase == nil {
+				m.usageByPhase = map[shared.UsagePhase]*shared.UsageTotal{}
+			}
+			if m.usageByPhase[phase] == nil {
+				m.usageByPhase[phase] = &shared.UsageTotal{}
+			}
+			m.usageByPhase[phase].Add(*msg.Usage)
+		}
+		if msg.Budget != nil {
+			m.budget = msg.Budget
+		}
+		if msg.ConvoHistory != nil {
+			m.convoHistory = msg.ConvoHistory
+		}
 
 	case shared.StreamMessageBuildInfo:
 		if m.starting {
@@ -294,9 +341,19 @@ func (m *streamUIModel) streamUpdate(msg *shared.StreamMessage) (tea.Model, tea.
 		wasFinished := m.finishedByPath[msg.BuildInfo.Path]
 		nowFinished := msg.BuildInfo.Finished
 
+		rate := m.buildRates[msg.BuildInfo.Path]
+		if rate == nil || (wasFinished && !nowFinished) {
+			rate = newStreamRate()
+			m.buildRates[msg.BuildInfo.Path] = rate
+		}
+		if msg.BuildInfo.NumTokens > 0 {
+			rate.add(msg.BuildInfo.NumTokens)
+		}
+
 		if msg.BuildInfo.Finished {
 			m.tokensByPath[msg.BuildInfo.Path] = 0
 			m.finishedByPath[msg.BuildInfo.Path] = true
+			m.syntaxUncheckedByPath[msg.BuildInfo.Path] = msg.BuildInfo.SyntaxUnchecked
 		} else {
 			if wasFinished && !nowFinished {
 				// delay for a second before marking not finished again (so check flashes green prior to restarting build)
@@ -318,6 +375,10 @@ func (m *streamUIModel) streamUpdate(msg *shared.StreamMessage) (tea.Model, tea.
 		m.processing = true
 		return m, m.spinner.Tick
 
+	case shared.StreamMessageSelfReview:
+		m.reply.append("\n\n🔎 Self-review\n\n" + msg.SelfReview)
+		return m, tea.Batch(m.queueReplyRender(), m.spinner.Tick)
+
 	case shared.StreamMessageError:
 		m.apiErr = msg.Error
 		return m, tea.Quit
@@ -339,6 +400,23 @@ func (m *streamUIModel) streamUpdate(msg *shared.StreamMessage) (tea.Model, tea.
 	return m, nil
 }
 
+// replies can stream in faster than the terminal redraws, so chunks that arrive close together are rendered at once
+const replyRenderInterval = 50 * time.Millisecond
+
+type replyRenderMsg struct{}
+
+// queueReplyRender marks the reply as changed and schedules a render, unless one is already scheduled
+func (m *streamUIModel) queueReplyRender() tea.Cmd {
+	m.replyDirty = true
+	if m.replyRenderQueued {
+		return nil
+	}
+	m.replyRenderQueued = true
+	return tea.Tick(replyRenderInterval, func(time.Time) tea.Msg {
+		return replyRenderMsg{}
+	})
+}
+
 type delayFileRestartMsg struct {
 	path string
 }
@@ -419,8 +497,7 @@ func (m *streamUIModel) selectedMissingFileOpt() (tea.Model, tea.Cmd) {
 	}
 
 	if choice == shared.RespondMissingFileChoiceSkip {
-		replyLines := strings.Split(m.reply, "\n")
-		m.reply = strings.Join(replyLines[:len(replyLines)-3], "\n")
+		m.reply.trimLastLines(3)
 		m.updateReplyDisplay()
 	}
 
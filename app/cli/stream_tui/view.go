@@ -39,13 +39,21 @@ func (m streamUIModel) renderMainView() string {
 }
 
 func (m streamUIModel) renderHelp() string {
+	if m.promptingClarify {
+		return m.renderClarify()
+	}
+
 	style := lipgloss.NewStyle().Width(m.width).Foreground(lipgloss.Color(helpTextColor)).BorderStyle(lipgloss.NormalBorder()).BorderTop(true).BorderForeground(lipgloss.Color(borderColor))
 
 	if m.buildOnly {
 		return style.Render(" (s)top • (b)ackground")
-	} else {
-		return style.Render(" (s)top • (b)ackground • (j/k) scroll • (d/u) page • (g/G) start/end")
 	}
+
+	help := " (s)top • (b)ackground • (j/k) scroll • (d/u) page • (g/G) start/end"
+	if !m.finished {
+		help += "  ⚡ " + m.replyRate.String()
+	}
+	return style.Render(help)
 }
 
 func (m streamUIModel) renderProcessing() string {
@@ -114,8 +122,14 @@ func (m streamUIModel) doRenderBuild(outputStatic bool) string {
 
 		if finished {
 			block += " ✅"
+			if m.syntaxUncheckedByPath[filePath] {
+				block += " (syntax not checked)"
+			}
 		} else if tokens > 0 {
 			block += fmt.Sprintf(" %d 🪙", tokens)
+			if rate := m.buildRates[filePath]; rate != nil && rate.perSec() > 0 {
+				block += fmt.Sprintf(" %.0f/s", rate.perSec())
+			}
 		}
 
 		maybePrefix := ""
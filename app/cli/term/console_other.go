@@ -0,0 +1,14 @@
+//go:build !windows
+
+package term
+
+// escape sequences are always processed outside of windows
+const vtEnabled = true
+
+func legacyClearScreen() {}
+
+func legacyMoveCursorToTopLeft() {}
+
+func legacyClearCurrentLine() {}
+
+func legacyMoveUpLines(numLines int) {}
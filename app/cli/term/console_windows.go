@@ -0,0 +1,101 @@
+//go:build windows
+
+package term
+
+import (
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	kernel32                       = windows.NewLazySystemDLL("kernel32.dll")
+	procSetConsoleCursorPosition   = kernel32.NewProc("SetConsoleCursorPosition")
+	procFillConsoleOutputCharacter = kernel32.NewProc("FillConsoleOutputCharacterW")
+)
+
+// false on consoles older than Windows 10 1809 that can't process escape sequences, which get the console api fallbacks below. Windows Terminal, VS Code, and other ConPTY hosts process them, as do terminals like mintty where stdout isn't a console at all.
+var vtEnabled = enableVirtualTerminal()
+
+func enableVirtualTerminal() bool {
+	enabled := true
+	for _, f := range []*os.File{os.Stdout, os.Stderr} {
+		handle := windows.Handle(f.Fd())
+
+		var mode uint32
+		if err := windows.GetConsoleMode(handle, &mode); err != nil {
+			// not a console, so escape sequences are passed through to whatever is reading them
+			continue
+		}
+
+		if mode&windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING != 0 {
+			continue
+		}
+
+		if err := windows.SetConsoleMode(handle, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING); err != nil && f == os.Stdout {
+			enabled = false
+		}
+	}
+	return enabled
+}
+
+func stdoutConsoleInfo() (windows.Handle, *windows.ConsoleScreenBufferInfo, bool) {
+	handle := windows.Handle(os.Stdout.Fd())
+	var info windows.ConsoleScreenBufferInfo
+	if err := windows.GetConsoleScreenBufferInfo(handle, &info); err != nil {
+		return handle, nil, false
+	}
+	return handle, &info, true
+}
+
+func coordArg(c windows.Coord) uintptr {
+	return uintptr(uint16(c.X)) | uintptr(uint16(c.Y))<<16
+}
+
+func setCursorPosition(handle windows.Handle, c windows.Coord) {
+	procSetConsoleCursorPosition.Call(uintptr(handle), coordArg(c))
+}
+
+func fillSpaces(handle windows.Handle, from windows.Coord, n int) {
+	var written uint32
+	procFillConsoleOutputCharacter.Call(uintptr(handle), uintptr(' '), uintptr(n), coordArg(from), uintptr(unsafe.Pointer(&written)))
+}
+
+func legacyClearScreen() {
+	handle, info, ok := stdoutConsoleInfo()
+	if !ok {
+		return
+	}
+	width := int(info.Size.X)
+	height := int(info.Window.Bottom-info.Window.Top) + 1
+	fillSpaces(handle, windows.Coord{X: 0, Y: info.Window.Top}, width*height)
+}
+
+func legacyMoveCursorToTopLeft() {
+	handle, info, ok := stdoutConsoleInfo()
+	if !ok {
+		return
+	}
+	setCursorPosition(handle, windows.Coord{X: 0, Y: info.Window.Top})
+}
+
+func legacyClearCurrentLine() {
+	handle, info, ok := stdoutConsoleInfo()
+	if !ok {
+		return
+	}
+	fillSpaces(handle, windows.Coord{X: 0, Y: info.CursorPosition.Y}, int(info.Size.X))
+}
+
+func legacyMoveUpLines(numLines int) {
+	handle, info, ok := stdoutConsoleInfo()
+	if !ok {
+		return
+	}
+	y := int(info.CursorPosition.Y) - numLines
+	if y < 0 {
+		y = 0
+	}
+	setCursorPosition(handle, windows.Coord{X: info.CursorPosition.X, Y: int16(y)})
+}
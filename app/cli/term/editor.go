@@ -0,0 +1,71 @@
+package term
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+const defaultEditor = "vim"
+
+// const defaultEditor = "nano"
+
+// GetEditor returns the editor set with EDITOR or VISUAL, falling back to vim
+func GetEditor() string {
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = os.Getenv("VISUAL")
+		if editor == "" {
+			editor = defaultEditor
+		}
+	}
+	return editor
+}
+
+// EditFile opens a file in the user's editor and waits for it to close. With atEnd, editors that support it start with the cursor at the end of the file, ready to type.
+func EditFile(path string, atEnd bool) error {
+	editor := GetEditor()
+
+	var cmd *exec.Cmd
+	switch {
+	case atEnd && editor == "vim":
+		cmd = exec.Command(editor, "+normal G$", "+startinsert!", path)
+	case atEnd && editor == "nano":
+		cmd = exec.Command(editor, "+99999999", path)
+	default:
+		cmd = exec.Command(editor, path)
+	}
+
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// EditText opens content in the user's editor through a temp file and returns it once the editor closes. The temp file gets ext, like ".go", so the editor picks the right syntax highlighting.
+func EditText(content, ext string, atEnd bool) (string, error) {
+	tmp, err := os.CreateTemp("", "plandex-edit-*"+ext)
+	if err != nil {
+		return "", fmt.Errorf("error creating temp file: %v", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	_, err = tmp.WriteString(content)
+	tmp.Close()
+	if err != nil {
+		return "", fmt.Errorf("error writing temp file: %v", err)
+	}
+
+	err = EditFile(tmpPath, atEnd)
+	if err != nil {
+		return "", err
+	}
+
+	bytes, err := os.ReadFile(tmpPath)
+	if err != nil {
+		return "", fmt.Errorf("error reading temp file: %v", err)
+	}
+
+	return string(bytes), nil
+}
@@ -11,7 +11,7 @@ import (
 
 func OutputNoApiKeyMsgAndExit() {
 	fmt.Fprintln(os.Stderr, color.New(color.Bold, ColorHiRed).Sprintln("\n🚨 OPENAI_API_KEY environment variable is not set.")+color.New().Sprintln("\nSet it with:\n\nexport OPENAI_API_KEY=your-api-key\n\nThen try again.\n\n👉 If you don't have an OpenAI account, sign up here → https://platform.openai.com/signup\n\n🔑 Generate an api key here → https://platform.openai.com/api-keys"))
-	os.Exit(1)
+	Exit(1)
 }
 
 func OutputSimpleError(msg string, args ...interface{}) {
@@ -73,11 +73,11 @@ func OutputErrorAndExit(msg string, args ...interface{}) {
 	}
 
 	fmt.Fprintln(os.Stderr, color.New(ColorHiRed, color.Bold).Sprint(displayMsg))
-	os.Exit(1)
+	Exit(1)
 }
 
 func OutputUnformattedErrorAndExit(msg string) {
 	StopSpinner()
 	fmt.Fprintln(os.Stderr, msg)
-	os.Exit(1)
+	Exit(1)
 }
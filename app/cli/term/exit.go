@@ -0,0 +1,42 @@
+package term
+
+import (
+	"os"
+	"sync"
+)
+
+var (
+	exitHooksMu    sync.Mutex
+	exitHooks      = map[int]func(){}
+	nextExitHookId int
+)
+
+// OnExit registers cleanup that has to run even when a command exits early, like removing a sandbox worktree, since os.Exit skips deferred calls. The returned function unregisters it once it's no longer needed.
+func OnExit(fn func()) (remove func()) {
+	exitHooksMu.Lock()
+	defer exitHooksMu.Unlock()
+
+	id := nextExitHookId
+	nextExitHookId++
+	exitHooks[id] = fn
+
+	return func() {
+		exitHooksMu.Lock()
+		defer exitHooksMu.Unlock()
+		delete(exitHooks, id)
+	}
+}
+
+// Exit runs the cleanup registered with OnExit, then exits with code
+func Exit(code int) {
+	exitHooksMu.Lock()
+	hooks := exitHooks
+	exitHooks = map[int]func(){}
+	exitHooksMu.Unlock()
+
+	for _, fn := range hooks {
+		fn()
+	}
+
+	os.Exit(code)
+}
@@ -10,39 +10,88 @@ import (
 )
 
 var CmdDesc = map[string][2]string{
-	"new":     {"", "start a new plan"},
-	"current": {"cu", "show current plan"},
-	"cd":      {"", "set current plan by name or index"},
-	"load":    {"l", "load files, dirs, urls, notes or piped data into context"},
-	"tell":    {"t", "describe a task, ask a question, or chat"},
-	"changes": {"ch", "review plan changes"},
+	"new":        {"", "start a new plan"},
+	"current":    {"cu", "show current plan"},
+	"cd":         {"", "set current plan by name or index, including plans teammates shared"},
+	"load":       {"l", "load files, dirs, urls, notes or piped data into context"},
+	"tell":       {"t", "describe a task, ask a question, or chat"},
+	"chat":       {"", "ask questions or discuss code without making a plan or changing files"},
+	"explain":    {"", "explain a file or range of lines"},
+	"do":         {"", "send a prompt from a named preset, with its context and models"},
+	"templates":  {"", "list, show, create, edit, or remove prompt templates"},
+	"regenerate": {"regen", "discard the last reply and send its prompt again, optionally with another model or temperature"},
+	"resend":     {"", "edit the last prompt and send it in place of the original"},
+	"subtasks":   {"", "show the plan's subtasks and which are done"},
+	"changes":    {"ch", "review plan changes"},
 	// "diffs":       {"d", "show diffs between plan and project files"},
 	// "preview":     {"pv", "preview the plan in a branch"},
-	"apply":    {"ap", "apply plan changes to project files"},
-	"continue": {"c", "continue the plan"},
-	// "status":      {"s", "show status of the plan"},
-	"rewind":        {"rw", "rewind to a previous state"},
-	"ls":            {"", "list everything in context"},
-	"rm":            {"", "remove context by name, index, or glob"},
-	"clear":         {"", "remove all context"},
-	"delete-plan":   {"dp", "delete plan by name or index"},
-	"delete-branch": {"db", "delete a branch by name or index"},
-	"plans":         {"pl", "list plans"},
-	"update":        {"u", "update outdated context"},
-	"log":           {"", "show log of plan updates"},
-	"convo":         {"", "show plan conversation"},
-	"branches":      {"br", "list plan branches"},
-	"checkout":      {"co", "checkout or create a branch"},
-	"build":         {"b", "build any pending changes"},
-	"models":        {"", "show model settings"},
-	"set-model":     {"", "update model settings"},
-	"ps":            {"", "list active and recently finished plan streams"},
-	"stop":          {"", "stop an active plan stream"},
-	"connect":       {"conn", "connect to an active plan stream"},
-	"sign-in":       {"", "sign in, accept an invite, or create an account"},
-	"invite":        {"", "invite a user to join your org"},
-	"revoke":        {"", "revoke an invite or remove a user from your org"},
-	"users":         {"", "list users and pending invites in your org"},
+	"apply":            {"ap", "apply plan changes to project files"},
+	"undo":             {"", "undo the last apply, restoring files from backup"},
+	"verify":           {"", "run the project's verification command, sending failures back to the plan to fix"},
+	"test":             {"", "run the project's tests, sending failures and failing files back to the plan to fix"},
+	"lint":             {"", "lint the plan's pending changes, sending problems back to the plan to fix"},
+	"formatters":       {"", "list, set, or remove formatters run on files after apply"},
+	"hooks":            {"", "list, add, or remove shell commands run before and after apply"},
+	"approve":          {"", "approve the project's hooks, formatters, linters, and verify and test commands to run on this machine"},
+	"container":        {"", "run verification, test, lint, and suggested commands in a container"},
+	"patch":            {"", "export the plan's pending changes as a patch for git apply"},
+	"commit-msg":       {"", "generate a commit message for staged changes or the plan's pending changes"},
+	"pr":               {"", "push the current git branch and open a GitHub pull request"},
+	"review":           {"", "review the current diff for problems, with JSON or SARIF output"},
+	"commands":         {"cmds", "run the shell commands the plan suggested, one at a time with confirmation"},
+	"continue":         {"c", "continue the plan"},
+	"usage":            {"", "summarize token usage and estimated cost by day, plan, and phase"},
+	"status":           {"s", "show the plan's context, pending changes, and estimated cost"},
+	"rewind":           {"rw", "rewind to a previous state"},
+	"ls":               {"", "list everything in context"},
+	"rm":               {"", "remove context by name, index, or glob"},
+	"clear":            {"", "remove all context"},
+	"delete-plan":      {"dp", "delete plan by name or index"},
+	"delete-branch":    {"db", "delete a branch by name or index"},
+	"plans":            {"pl", "list plans"},
+	"workspace":        {"", "list workspace members and the one the plan targets"},
+	"remote":           {"", "show or set where plans are pushed and pulled"},
+	"push":             {"", "push the current plan to the sync remote"},
+	"pull":             {"", "pull a plan from the sync remote into this project"},
+	"share":            {"", "share the current plan with your org"},
+	"unshare":          {"", "stop sharing the current plan with your org"},
+	"workspace use":    {"", "target a workspace member with the current plan"},
+	"workspace clear":  {"", "target the whole project with the current plan"},
+	"update":           {"u", "update outdated context"},
+	"log":              {"", "show log of plan updates"},
+	"convo":            {"", "show plan conversation"},
+	"export convo":     {"", "export the plan's conversation as Markdown or HTML"},
+	"export plan":      {"", "export the plan's descriptions, subtasks, diffs, and usage as JSON"},
+	"blame":            {"", "show the prompts and replies that produced a file's lines"},
+	"branches":         {"br", "list plan branches"},
+	"checkout":         {"co", "checkout or create a branch"},
+	"build":            {"b", "build any pending changes"},
+	"run":              {"", "plan, build, and apply a prompt to a git branch without interaction, for CI"},
+	"mcp":              {"", "serve plandex's tools to editors and other AI clients over MCP"},
+	"serve-editor":     {"", "serve a local JSON-RPC endpoint for editor extensions to drive plans"},
+	"plugins":          {"", "list command plugins, run as 'plandex <name>'"},
+	"plugins add":      {"", "add a command plugin for the project"},
+	"mcp add":          {"", "add an MCP server to load context from, optionally with each prompt"},
+	"mcp load":         {"", "call a tool on an MCP server and load its result into context"},
+	"mcp approve":      {"", "approve an MCP server from the project's settings to run with each prompt"},
+	"providers":        {"", "list context provider plugins to load context from"},
+	"providers add":    {"", "add a context provider plugin, loaded with 'load <provider>:<query>'"},
+	"models":           {"", "show model settings"},
+	"models available": {"", "list available models with context window, cost, and capabilities"},
+	"set-model":        {"", "update model settings"},
+	"policy":           {"", "show the plan's limits and behavior settings, and your org's daily budget"},
+	"set-policy":       {"", "update the plan's limits and behavior settings, or your org's daily budget"},
+	"doctor":           {"", "diagnose problems with your setup"},
+	"compare":          {"", "run a prompt against two models and compare latency, tokens, and file changes"},
+	"ps":               {"", "list active and recently finished plan streams"},
+	"stop":             {"", "stop an active plan stream"},
+	"connect":          {"conn", "connect to an active plan stream"},
+	"sign-in":          {"", "sign in, accept an invite, or create an account"},
+	"invite":           {"", "invite a user to join your org"},
+	"revoke":           {"", "revoke an invite or remove a user from your org"},
+	"users":            {"", "list users and pending invites in your org"},
+	"slack":            {"", "show whether your org's plans are posted to Slack"},
+	"slack set":        {"", "post your org's finished and failed plans to a Slack webhook"},
 }
 
 func PrintCmds(prefix string, cmds ...string) {
@@ -112,15 +161,15 @@ func PrintCustomHelp() {
 	fmt.Fprintln(builder)
 
 	color.New(color.Bold, color.BgCyan, color.FgHiWhite).Fprintln(builder, " Plans ")
-	printCmds(builder, " ", []color.Attribute{color.Bold, ColorHiCyan}, "new", "plans", "cd", "current", "delete-plan")
+	printCmds(builder, " ", []color.Attribute{color.Bold, ColorHiCyan}, "new", "plans", "cd", "current", "status", "usage", "delete-plan", "workspace", "remote", "push", "pull", "share")
 	fmt.Fprintln(builder)
 
 	color.New(color.Bold, color.BgCyan, color.FgHiWhite).Fprintln(builder, " Changes ")
-	printCmds(builder, " ", []color.Attribute{color.Bold, ColorHiCyan}, "changes", "apply")
+	printCmds(builder, " ", []color.Attribute{color.Bold, ColorHiCyan}, "changes", "apply", "undo", "verify", "test", "lint", "formatters", "hooks", "approve", "container", "patch", "commit-msg", "pr", "review", "commands")
 	fmt.Fprintln(builder)
 
 	color.New(color.Bold, color.BgCyan, color.FgHiWhite).Fprintln(builder, " Context ")
-	printCmds(builder, " ", []color.Attribute{color.Bold, ColorHiCyan}, "load", "ls", "rm", "update", "clear")
+	printCmds(builder, " ", []color.Attribute{color.Bold, ColorHiCyan}, "load", "ls", "rm", "update", "clear", "mcp add", "mcp load", "providers add")
 	fmt.Fprintln(builder)
 
 	color.New(color.Bold, color.BgCyan, color.FgHiWhite).Fprintln(builder, " Branches ")
@@ -128,11 +177,11 @@ func PrintCustomHelp() {
 	fmt.Fprintln(builder)
 
 	color.New(color.Bold, color.BgCyan, color.FgHiWhite).Fprintln(builder, " History ")
-	printCmds(builder, " ", []color.Attribute{color.Bold, ColorHiCyan}, "convo", "log", "rewind")
+	printCmds(builder, " ", []color.Attribute{color.Bold, ColorHiCyan}, "convo", "export convo", "export plan", "log", "rewind", "regenerate", "resend", "blame")
 	fmt.Fprintln(builder)
 
 	color.New(color.Bold, color.BgCyan, color.FgHiWhite).Fprintln(builder, " Control ")
-	printCmds(builder, " ", []color.Attribute{color.Bold, ColorHiCyan}, "tell", "continue", "build")
+	printCmds(builder, " ", []color.Attribute{color.Bold, ColorHiCyan}, "tell", "chat", "explain", "do", "templates", "continue", "subtasks", "build", "run", "mcp", "serve-editor", "plugins")
 	fmt.Fprintln(builder)
 
 	color.New(color.Bold, color.BgCyan, color.FgHiWhite).Fprintln(builder, " Streams ")
@@ -140,11 +189,11 @@ func PrintCustomHelp() {
 	fmt.Fprintln(builder)
 
 	color.New(color.Bold, color.BgCyan, color.FgHiWhite).Fprintln(builder, " AI Models ")
-	printCmds(builder, " ", []color.Attribute{color.Bold, ColorHiCyan}, "models", "set-model")
+	printCmds(builder, " ", []color.Attribute{color.Bold, ColorHiCyan}, "models", "models available", "set-model", "policy", "set-policy", "doctor")
 	fmt.Fprintln(builder)
 
 	color.New(color.Bold, color.BgCyan, color.FgHiWhite).Fprintln(builder, " Accounts ")
-	printCmds(builder, " ", []color.Attribute{color.Bold, ColorHiCyan}, "sign-in", "invite", "revoke", "users")
+	printCmds(builder, " ", []color.Attribute{color.Bold, ColorHiCyan}, "sign-in", "invite", "revoke", "users", "slack")
 	fmt.Fprintln(builder)
 
 	fmt.Print(builder.String())
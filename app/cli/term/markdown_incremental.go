@@ -0,0 +1,119 @@
+package term
+
+import (
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/glamour"
+	"golang.org/x/term"
+)
+
+// IncrementalMarkdown renders markdown that's being appended to, like a streaming reply. Blocks that are followed by a blank line outside a code block can't change anymore, so they're rendered once and cached; each render only processes the block that's still being written.
+type IncrementalMarkdown struct {
+	renderer *glamour.TermRenderer
+	width    int
+
+	// the part of the input that's been rendered into blocks
+	source string
+	blocks []string
+}
+
+func (r *IncrementalMarkdown) Render(input string) (string, error) {
+	width, _, err := term.GetSize(int(os.Stdin.Fd()))
+	if err != nil {
+		return "", err
+	}
+
+	if r.renderer == nil || width != r.width {
+		// auto style queries the terminal for its background color, so the renderer is only built again when the width changes
+		r.renderer, err = glamour.NewTermRenderer(
+			glamour.WithAutoStyle(),
+			glamour.WithWordWrap(min(width, 80)),
+		)
+		if err != nil {
+			return "", err
+		}
+		r.width = width
+		r.reset()
+	}
+
+	// the input changed before the end, like when lines were removed or the start was cut off, so cached blocks may not match it anymore
+	if !strings.HasPrefix(input, r.source) {
+		r.reset()
+	}
+
+	rest := input[len(r.source):]
+	for {
+		end := completeBlockEnd(rest)
+		if end == -1 {
+			break
+		}
+
+		block := strings.TrimSpace(rest[:end])
+		if block != "" {
+			out, err := r.renderer.Render(block)
+			if err != nil {
+				return "", err
+			}
+			r.blocks = append(r.blocks, strings.Trim(out, "\n"))
+		}
+
+		r.source += rest[:end]
+		rest = rest[end:]
+	}
+
+	parts := r.blocks
+	if strings.TrimSpace(rest) != "" {
+		out, err := r.renderer.Render(rest)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts[:len(parts):len(parts)], strings.Trim(out, "\n"))
+	}
+
+	return strings.Join(parts, "\n\n"), nil
+}
+
+func (r *IncrementalMarkdown) reset() {
+	r.source = ""
+	r.blocks = nil
+}
+
+// completeBlockEnd returns the index just past the first block in s that's followed by a blank line outside a code block, including the blank lines after it, or -1 if the first block might still be growing
+func completeBlockEnd(s string) int {
+	inFence := false
+	sawContent := false
+	pos := 0
+
+	for {
+		i := strings.IndexByte(s[pos:], '\n')
+		if i == -1 {
+			// the last line isn't finished
+			return -1
+		}
+		line := s[pos : pos+i]
+		next := pos + i + 1
+
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			inFence = !inFence
+		}
+
+		if trimmed == "" && !inFence && sawContent {
+			// take any further blank lines along with this one
+			for next < len(s) {
+				j := strings.IndexByte(s[next:], '\n')
+				if j == -1 || strings.TrimSpace(s[next:next+j]) != "" {
+					break
+				}
+				next += j + 1
+			}
+			return next
+		}
+
+		if trimmed != "" {
+			sawContent = true
+		}
+		pos = next
+	}
+}
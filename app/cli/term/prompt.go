@@ -1,8 +1,11 @@
 package term
 
 import (
+	"bufio"
 	"fmt"
+	"log"
 	"os"
+	"strings"
 
 	"github.com/cqroot/prompt"
 	"github.com/cqroot/prompt/input"
@@ -14,7 +17,7 @@ func GetUserStringInput(msg string) (string, error) {
 	res, err := prompt.New().Ask(msg).Input("")
 
 	if err != nil && err.Error() == "user quit prompt" {
-		os.Exit(0)
+		Exit(0)
 	}
 
 	return res, err
@@ -24,7 +27,7 @@ func GetUserPasswordInput(msg string) (string, error) {
 	res, err := prompt.New().Ask(msg).Input("", input.WithEchoMode(input.EchoPassword))
 
 	if err != nil && err.Error() == "user quit prompt" {
-		os.Exit(0)
+		Exit(0)
 	}
 
 	return res, err
@@ -32,20 +35,46 @@ func GetUserPasswordInput(msg string) (string, error) {
 
 func GetUserKeyInput() (rune, error) {
 	if err := keyboard.Open(); err != nil {
-		return 0, fmt.Errorf("failed to open keyboard: %s", err)
+		// mintty and other terminals that don't give windows a console, as well as piped stdin, can't be read a key at a time
+		log.Printf("failed to open keyboard, reading a line instead: %v", err)
+		return getUserLineInput()
 	}
 	defer func() {
 		_ = keyboard.Close()
 	}()
 
-	char, _, err := keyboard.GetKey()
+	char, key, err := keyboard.GetKey()
 	if err != nil {
 		return 0, fmt.Errorf("failed to read keypress: %s", err)
 	}
 
+	// the console doesn't send an interrupt while the keyboard is open on windows, so exit as an interrupt would, with 130 and the cleanup registered with OnExit
+	if key == keyboard.KeyCtrlC {
+		_ = keyboard.Close()
+		Exit(130)
+	}
+
 	return char, nil
 }
 
+// shared by every prompt, since a reader can buffer more than the line it returns, like the answers to later prompts when input is piped
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// getUserLineInput reads a line and returns its first character, for when keys can't be read one at a time
+func getUserLineInput() (rune, error) {
+	line, err := stdinReader.ReadString('\n')
+	if err != nil && line == "" {
+		return 0, fmt.Errorf("failed to read input: %s", err)
+	}
+
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return 0, nil
+	}
+
+	return []rune(line)[0], nil
+}
+
 func ConfirmYesNo(fmtStr string, fmtArgs ...interface{}) (bool, error) {
 	color.New(ColorHiMagenta, color.Bold).Printf(fmtStr+" (y)es | (n)o", fmtArgs...)
 	color.New(ColorHiMagenta, color.Bold).Print("> ")
@@ -2,7 +2,6 @@ package term
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/fatih/color"
 	"github.com/plandex-ai/survey/v2"
@@ -18,7 +17,7 @@ func SelectFromList(msg string, options []string) (string, error) {
 	err := survey.AskOne(prompt, &selected)
 	if err != nil {
 		if err.Error() == "interrupt" {
-			os.Exit(0)
+			Exit(0)
 		}
 
 		return "", err
@@ -10,46 +10,83 @@ import (
 	"golang.org/x/term"
 )
 
+// VirtualTerminalSupported is false on legacy Windows consoles that print escape sequences as text rather than processing them
+func VirtualTerminalSupported() bool {
+	return vtEnabled
+}
+
 func AlternateScreen() {
+	if !vtEnabled {
+		// legacy consoles have no alternate screen, so the main one is cleared instead
+		legacyClearScreen()
+		legacyMoveCursorToTopLeft()
+		return
+	}
+
 	// Switch to alternate screen and hide the cursor
 	fmt.Print("\x1b[?1049h\x1b[?25l")
 }
 
 func ClearScreen() {
+	if !vtEnabled {
+		legacyClearScreen()
+		return
+	}
 	fmt.Print("\x1b[2J")
 }
 
 func MoveCursorToTopLeft() {
+	if !vtEnabled {
+		legacyMoveCursorToTopLeft()
+		return
+	}
 	fmt.Print("\x1b[H")
 }
 
 func ClearCurrentLine() {
+	if !vtEnabled {
+		legacyClearCurrentLine()
+		return
+	}
 	fmt.Print("\033[2K")
 }
 
 func MoveUpLines(numLines int) {
+	if !vtEnabled {
+		legacyMoveUpLines(numLines)
+		return
+	}
 	fmt.Printf("\033[%dA", numLines)
 }
 
 func BackToMain() {
+	if !vtEnabled {
+		return
+	}
+
 	// Switch back to main screen and show the cursor on exit
 	fmt.Print("\x1b[?1049l\x1b[?25h")
 }
 
 func PageOutput(output string) {
-	cmd := exec.Command("less", "-R")
-	cmd.Env = append(os.Environ(), "LESS=FRX", "LESSCHARSET=utf-8")
-	cmd.Stdin = strings.NewReader(output)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
-		OutputErrorAndExit("Failed to page output: %v", err)
-	}
+	pageOutput(output, "-R")
 }
 
 func PageOutputReverse(output string) {
-	cmd := exec.Command("less", "-RX", "+G")
+	pageOutput(output, "-RX", "+G")
+}
+
+func pageOutput(output string, lessArgs ...string) {
+	// less isn't installed on windows unless it came with git or a package manager, so the output is printed directly
+	if _, err := exec.LookPath("less"); err != nil {
+		fmt.Print(output)
+		if !strings.HasSuffix(output, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+
+	cmd := exec.Command("less", lessArgs...)
 	cmd.Stdin = strings.NewReader(output)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -1,6 +1,8 @@
 package types
 
 import (
+	"time"
+
 	"github.com/plandex/plandex/shared"
 )
 
@@ -30,6 +32,17 @@ type ApiClient interface {
 
 	ListOrgRoles() ([]*shared.OrgRole, *shared.ApiError)
 
+	GetOrgUsage(since time.Time) (*shared.OrgUsageResponse, *shared.ApiError)
+	GetPlanUsage(planId string, since time.Time) (*shared.PlanUsageResponse, *shared.ApiError)
+
+	ListPlanPullRequests(planId string) ([]*shared.PlanPullRequest, *shared.ApiError)
+	CreatePlanPullRequest(planId string, req shared.CreatePlanPullRequestRequest) (*shared.PlanPullRequest, *shared.ApiError)
+
+	GetOrgSlackSettings() (*shared.OrgSlackSettings, *shared.ApiError)
+	UpdateOrgSlackSettings(req shared.UpdateOrgSlackSettingsRequest) *shared.ApiError
+	GetOrgBudget() (*shared.OrgBudget, *shared.ApiError)
+	UpdateOrgBudget(req shared.UpdateOrgBudgetRequest) *shared.ApiError
+
 	InviteUser(req shared.InviteRequest) *shared.ApiError
 	ListPendingInvites() ([]*shared.Invite, *shared.ApiError)
 	ListAcceptedInvites() ([]*shared.Invite, *shared.ApiError)
@@ -49,10 +62,13 @@ type ApiClient interface {
 
 	GetPlan(planId string) (*shared.Plan, *shared.ApiError)
 	CreatePlan(projectId string, req shared.CreatePlanRequest) (*shared.CreatePlanResponse, *shared.ApiError)
+	ImportPlanBundle(projectId string, req shared.ImportPlanRequest) (*shared.CreatePlanResponse, *shared.ApiError)
+	ExportPlanBundle(planId string) (*shared.PlanBundle, *shared.ApiError)
 
 	TellPlan(planId, branch string, req shared.TellPlanRequest, onStreamPlan OnStreamPlan) *shared.ApiError
 	BuildPlan(planId, branch string, req shared.BuildPlanRequest, onStreamPlan OnStreamPlan) *shared.ApiError
 	RespondMissingFile(planId, branch string, req shared.RespondMissingFileRequest) *shared.ApiError
+	RespondClarify(planId, branch string, req shared.RespondClarifyRequest) *shared.ApiError
 
 	DeletePlan(planId string) *shared.ApiError
 	DeleteAllPlans(projectId string) *shared.ApiError
@@ -60,11 +76,15 @@ type ApiClient interface {
 	StopPlan(planId, branch string) *shared.ApiError
 
 	ArchivePlan(planId string) *shared.ApiError
+	SharePlan(planId string) *shared.ApiError
+	UnsharePlan(planId string) *shared.ApiError
+	ListSharedPlans() ([]*shared.Plan, *shared.ApiError)
 
 	GetCurrentPlanState(planId, branch string) (*shared.CurrentPlanState, *shared.ApiError)
 	ApplyPlan(planId, branch string) *shared.ApiError
 	RejectAllChanges(planId, branch string) *shared.ApiError
 	RejectFile(planId, branch, filePath string) *shared.ApiError
+	RecordCommandResult(planId, branch string, req shared.RecordCommandResultRequest) *shared.ApiError
 
 	LoadContext(planId, branch string, req shared.LoadContextRequest) (*shared.LoadContextResponse, *shared.ApiError)
 	UpdateContext(planId, branch string, req shared.UpdateContextRequest) (*shared.UpdateContextResponse, *shared.ApiError)
@@ -72,6 +92,8 @@ type ApiClient interface {
 	ListContext(planId, branch string) ([]*shared.Context, *shared.ApiError)
 
 	ListConvo(planId, branch string) ([]*shared.ConvoMessage, *shared.ApiError)
+	ListConvoMessages(planId, branch string, q shared.HistoryQuery) (*shared.ConvoPage, *shared.ApiError)
+	ListPlanEvents(planId, branch string, q shared.HistoryQuery) (*shared.PlanEventPage, *shared.ApiError)
 	ListLogs(planId, branch string) (*shared.LogResponse, *shared.ApiError)
 	RewindPlan(planId, branch string, req shared.RewindPlanRequest) (*shared.RewindPlanResponse, *shared.ApiError)
 
@@ -81,4 +103,13 @@ type ApiClient interface {
 
 	GetSettings(planId, branch string) (*shared.PlanSettings, *shared.ApiError)
 	UpdateSettings(planId, branch string, req shared.UpdateSettingsRequest) (*shared.UpdateSettingsResponse, *shared.ApiError)
+
+	CheckModels(req shared.CheckModelsRequest) (*shared.CheckModelsResponse, *shared.ApiError)
+	CheckHealth() *shared.ApiError
+	Doctor(planId, branch string) (*shared.DoctorResponse, *shared.ApiError)
+
+	GenCommitMsg(req shared.GenCommitMsgRequest) (*shared.GenCommitMsgResponse, *shared.ApiError)
+	GenPullRequest(req shared.GenPullRequestRequest) (*shared.GenPullRequestResponse, *shared.ApiError)
+	Review(req shared.ReviewRequest) (*shared.ReviewResponse, *shared.ApiError)
+	SecurityScan(req shared.SecurityScanRequest) (*shared.SecurityScanResponse, *shared.ApiError)
 }
@@ -1,6 +1,11 @@
 package types
 
-import "github.com/plandex/plandex/shared"
+import (
+	"os"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+)
 
 type ClientAccount struct {
 	IsCloud  bool   `json:"isCloud"`
@@ -23,6 +28,8 @@ type LoadContextParams struct {
 	Recursive       bool
 	NamesOnly       bool
 	ForceSkipIgnore bool
+	// load the messages and diffs of this many recent commits
+	Commits int
 }
 
 type ContextOutdatedResult struct {
@@ -32,6 +39,7 @@ type ContextOutdatedResult struct {
 	NumFiles        int
 	NumUrls         int
 	NumTrees        int
+	NumSymbolMaps   int
 }
 
 const (
@@ -50,8 +58,165 @@ type PlanSettings struct {
 	Branch string `json:"branch"`
 }
 
+type AppliedCommit struct {
+	Sha    string `json:"sha"`
+	Branch string `json:"branch"`
+	// the git branch the commit was made on
+	GitBranch string    `json:"gitBranch"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	// the replies that proposed the committed changes to each file, so lines can be traced back to the conversation
+	ConvoMessageIdsByPath map[string][]string `json:"convoMessageIdsByPath,omitempty"`
+}
+
+// local info about a plan, stored in plan.json alongside its settings
+type PlanInfo struct {
+	// commits plandex made when applying the plan's changes
+	AppliedCommits []*AppliedCommit `json:"appliedCommits,omitempty"`
+	// issues loaded with 'plandex tell --issue', which --comment-issue reports back to
+	Issues []*PlanIssue `json:"issues,omitempty"`
+}
+
+type PlanIssue struct {
+	// "github", "jira", or "linear"
+	Tracker string `json:"tracker"`
+	// the issue's key, like ENG-123, or owner/repo#123 for github
+	Key string `json:"key"`
+	// linear's internal id, which comments are created with
+	Id  string `json:"id,omitempty"`
+	Url string `json:"url"`
+}
+
+type ClientConfig struct {
+	DefaultModelSet *shared.ModelSet `json:"defaultModelSet,omitempty"`
+
+	// named presets for 'plandex do' in every project. A project's own presets take precedence.
+	Presets map[string]*Preset `json:"presets,omitempty"`
+
+	// where 'plandex push' and 'plandex pull' sync plans: a directory, s3://bucket/prefix, a git repo, or an http(s) url
+	SyncRemote string `json:"syncRemote,omitempty"`
+
+	// "<project id>/<server name>" -> fingerprint of the MCP server config the user approved to run with each prompt. It's kept here rather than in the project's settings, so a config that arrives with the project doesn't run until the user approves it, and changing the config needs approval again.
+	McpServerApprovals map[string]string `json:"mcpServerApprovals,omitempty"`
+
+	// project id -> fingerprint of the project's hooks, formatters, linters, and verify and test commands the user approved to run on this machine. Kept here for the same reason as McpServerApprovals: commands that arrive with the project don't run until the user approves them.
+	ProjectCommandApprovals map[string]string `json:"projectCommandApprovals,omitempty"`
+}
+
+// Preset is a reusable prompt for 'plandex do', along with the context it needs and the models it runs with
+type Preset struct {
+	Description string `json:"description,omitempty"`
+
+	// {{args}} is replaced with the arguments passed after the preset's name. If it's missing, the arguments are added to the end.
+	Prompt string `json:"prompt,omitempty"`
+
+	// name of a template in .plandex/templates to use in place of the prompt
+	Template string `json:"template,omitempty"`
+
+	// paths or globs relative to the project root that are loaded into context before the prompt is sent. Arguments that are paths are loaded too.
+	Context []string `json:"context,omitempty"`
+
+	// role -> model name, used for this prompt in place of the plan's models
+	Models map[shared.ModelRole]string `json:"models,omitempty"`
+}
+
+type ApplyBackupFile struct {
+	Path    string      `json:"path"`
+	Existed bool        `json:"existed"`
+	Mode    os.FileMode `json:"mode"`
+
+	// set if the file was a symlink, so undo restores the link itself along with its target's content
+	LinkTarget string `json:"linkTarget,omitempty"`
+}
+
+type ApplyBackup struct {
+	Id        string             `json:"id"`
+	PlanId    string             `json:"planId"`
+	Branch    string             `json:"branch"`
+	CreatedAt time.Time          `json:"createdAt"`
+	Files     []*ApplyBackupFile `json:"files"`
+}
+
 type CurrentProjectSettings struct {
 	Id string `json:"id"`
+
+	// run after each apply to check the changes; failures are sent back to the plan to fix
+	VerifyCmd      string `json:"verifyCmd,omitempty"`
+	MaxVerifyFixes *int   `json:"maxVerifyFixes,omitempty"`
+
+	// run by 'plandex test'; failures are sent back to the plan along with the failing files
+	TestCmd      string `json:"testCmd,omitempty"`
+	MaxTestFixes *int   `json:"maxTestFixes,omitempty"`
+
+	// file extension -> command run on each file of that type after it's written
+	Formatters map[string]string `json:"formatters,omitempty"`
+
+	// shell commands run from the project root before and after apply, with the plan's files in the environment. A failed pre-apply hook stops the apply before any files are written.
+	PreApplyHooks  []string `json:"preApplyHooks,omitempty"`
+	PostApplyHooks []string `json:"postApplyHooks,omitempty"`
+
+	// file extension -> linter or typechecker run on the files of that type a plan changes, before its changes are applied; diagnostics are sent back to the plan to fix
+	Linters      map[string]string `json:"linters,omitempty"`
+	MaxLintFixes *int              `json:"maxLintFixes,omitempty"`
+	// run the linters after each reply's changes are built, not just with 'plandex lint'
+	AutoLint bool `json:"autoLint,omitempty"`
+
+	Container *ContainerSettings `json:"container,omitempty"`
+
+	// workspace member name -> its root relative to the project root; added to the members detected from go.work, package.json, pnpm-workspace.yaml, and Cargo.toml
+	Workspace map[string]string `json:"workspace,omitempty"`
+
+	Presets map[string]*Preset `json:"presets,omitempty"`
+
+	// stop conditions for 'plandex tell --auto-continue', overridden by its flags
+	AutoLimits *shared.AutoLimits `json:"autoLimits,omitempty"`
+
+	// how changes are checked for risky operations before they're applied: "rules" (the default), "model" to add a model pass, or "off"
+	SecurityScan string `json:"securityScan,omitempty"`
+
+	// name -> MCP server whose tools can be called to load context
+	McpServers map[string]*McpServerConfig `json:"mcpServers,omitempty"`
+
+	// name -> context provider plugin, loaded from with 'plandex load <name>:<query>'
+	ContextProviders map[string]*ContextProviderConfig `json:"contextProviders,omitempty"`
+
+	// name -> command plugin, run as 'plandex <name>'
+	Plugins map[string]*PluginConfig `json:"plugins,omitempty"`
+}
+
+// McpServerConfig is an MCP server started with Command and Args, and spoken to over stdio
+type McpServerConfig struct {
+	Command string            `json:"command"`
+	Args    []string          `json:"args,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+
+	// a tool called with each new prompt before it's sent, with the prompt as the PromptArg argument ("query" by default). Its result replaces the one from the previous prompt in context.
+	PromptTool string `json:"promptTool,omitempty"`
+	PromptArg  string `json:"promptArg,omitempty"`
+}
+
+// ContextProviderConfig is a context provider plugin run with Command and Args for each query. Providers that aren't configured are found on the PATH as plandex-context-<name>.
+type ContextProviderConfig struct {
+	Command string            `json:"command"`
+	Args    []string          `json:"args,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+}
+
+// PluginConfig is a command plugin run with Command and Args, followed by the args it was invoked with. Plugins that aren't configured are found on the PATH as plandex-<name>.
+type PluginConfig struct {
+	Command string            `json:"command"`
+	Args    []string          `json:"args,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+}
+
+// ContainerSettings run verification, test, lint, and suggested commands in a container instead of on the host
+type ContainerSettings struct {
+	Image string `json:"image"`
+	// "copy" (the default) or "readonly"
+	Mount string `json:"mount,omitempty"`
+	// docker by default; podman takes the same arguments
+	Runtime   string `json:"runtime,omitempty"`
+	NoNetwork bool   `json:"noNetwork,omitempty"`
 }
 
 type ChangesUIScrollReplacement struct {
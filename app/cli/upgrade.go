@@ -25,6 +25,11 @@ func checkForUpgrade() {
 		return
 	}
 
+	// the MCP server's stdout carries protocol messages, so there's no one to ask
+	if len(os.Args) > 1 && os.Args[1] == "mcp" {
+		return
+	}
+
 	if version.Version == "development" {
 		return
 	}
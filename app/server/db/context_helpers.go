@@ -204,7 +204,7 @@ func LoadContexts(params LoadContextsParams) (*shared.LoadContextResponse, []*Co
 	tokensAdded := 0
 
 	paramsByTempId := make(map[string]*shared.LoadContextParams)
-	numTokensByTempId := make(map[string]int)
+	var numTokensByTempId map[string]int
 
 	branch, err := GetDbBranch(planId, branchName)
 	if err != nil {
@@ -219,17 +219,19 @@ func LoadContexts(params LoadContextsParams) (*shared.LoadContextResponse, []*Co
 
 	maxTokens := settings.GetPlannerEffectiveMaxTokens()
 
+	counter := NewTokenCounter(planId)
 	for _, context := range *req {
 		tempId := uuid.New().String()
-		numTokens, err := shared.GetNumTokens(context.Body)
-
-		if err != nil {
-			return nil, nil, fmt.Errorf("error getting num tokens: %v", err)
-		}
-
 		paramsByTempId[tempId] = context
-		numTokensByTempId[tempId] = numTokens
+		counter.Add(tempId, shared.DefaultTokenEncoding, context.Body)
+	}
+
+	numTokensByTempId, err = counter.Wait()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting num tokens: %v", err)
+	}
 
+	for _, numTokens := range numTokensByTempId {
 		tokensAdded += numTokens
 		totalTokens += numTokens
 	}
@@ -264,6 +266,9 @@ func LoadContexts(params LoadContextsParams) (*shared.LoadContextResponse, []*Co
 				Sha:             sha,
 				Body:            params.Body,
 				ForceSkipIgnore: params.ForceSkipIgnore,
+				FileMode:        params.FileMode,
+				Encoding:        params.Encoding,
+				SymlinkTarget:   params.SymlinkTarget,
 			}
 
 			err := StoreContext(&context)
@@ -360,11 +365,16 @@ func UpdateContexts(params UpdateContextsParams) (*shared.UpdateContextResponse,
 	numUrls := 0
 	numTrees := 0
 
+	counter := NewTokenCounter(planId)
+	for id, params := range *req {
+		counter.Add(id, shared.DefaultTokenEncoding, params.Body)
+	}
+
 	var mu sync.Mutex
 	errCh := make(chan error)
 
-	for id, params := range *req {
-		go func(id string, params *shared.UpdateContextParams) {
+	for id := range *req {
+		go func(id string) {
 
 			var context *Context
 			if _, ok := contextsById[id]; ok {
@@ -379,17 +389,17 @@ func UpdateContexts(params UpdateContextsParams) (*shared.UpdateContextResponse,
 				}
 			}
 
+			updateNumTokens, err := counter.Get(id)
+			if err != nil {
+				errCh <- fmt.Errorf("error getting num tokens: %v", err)
+				return
+			}
+
 			mu.Lock()
 			defer mu.Unlock()
 
 			contextsById[id] = context
 			updatedContexts = append(updatedContexts, context.ToApi())
-			updateNumTokens, err := shared.GetNumTokens(params.Body)
-
-			if err != nil {
-				errCh <- fmt.Errorf("error getting num tokens: %v", err)
-				return
-			}
 
 			tokenDiff := updateNumTokens - context.NumTokens
 			tokenDiffsById[id] = tokenDiff
@@ -408,7 +418,7 @@ func UpdateContexts(params UpdateContextsParams) (*shared.UpdateContextResponse,
 			}
 
 			errCh <- nil
-		}(id, params)
+		}(id)
 	}
 
 	for i := 0; i < len(*req); i++ {
@@ -464,6 +474,15 @@ func UpdateContexts(params UpdateContextsParams) (*shared.UpdateContextResponse,
 
 			context.Body = params.Body
 			context.Sha = sha
+			if params.Encoding != nil {
+				context.Encoding = *params.Encoding
+			}
+			if params.FileMode != nil {
+				context.FileMode = *params.FileMode
+			}
+			if params.SymlinkTarget != nil {
+				context.SymlinkTarget = *params.SymlinkTarget
+			}
 
 			err := StoreContext(context)
 
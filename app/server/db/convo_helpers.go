@@ -13,6 +13,9 @@ import (
 	"github.com/sashabaranov/go-openai"
 )
 
+// user prompts are committed with this in the message, which is how the last one is found when it's replaced
+const userPromptCommitDesc = "💬 User prompt"
+
 func GetPlanConvo(orgId, planId string) ([]*ConvoMessage, error) {
 	var convo []*ConvoMessage
 	convoDir := getPlanConversationDir(orgId, planId)
@@ -104,7 +107,7 @@ func StoreConvoMessage(message *ConvoMessage, currentUserId, branch string, comm
 
 	var desc string
 	if message.Role == openai.ChatMessageRoleUser {
-		desc = "💬 User prompt"
+		desc = userPromptCommitDesc
 		// TODO: add user name
 	} else {
 		desc = "🤖 Plandex reply"
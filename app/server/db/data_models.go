@@ -1,6 +1,7 @@
 package db
 
 import (
+	"os"
 	"time"
 
 	"github.com/plandex/plandex/shared"
@@ -28,6 +29,13 @@ type Org struct {
 	OwnerId            string  `db:"owner_id"`
 	IsTrial            bool    `db:"is_trial"`
 
+	// where the org's plan notifications are posted, and whether they include prompts
+	SlackWebhookUrl     *string `db:"slack_webhook_url"`
+	SlackIncludePrompts bool    `db:"slack_include_prompts"`
+
+	// the most the whole org can spend on model calls per day (UTC), in estimated USD
+	MaxDailyCost *float64 `db:"max_daily_cost"`
+
 	CreatedAt time.Time `db:"created_at"`
 	UpdatedAt time.Time `db:"updated_at"`
 }
@@ -183,6 +191,31 @@ func (branch *Branch) ToApi() *shared.Branch {
 	}
 }
 
+type PlanPullRequest struct {
+	Id        string    `db:"id"`
+	OrgId     string    `db:"org_id"`
+	PlanId    string    `db:"plan_id"`
+	UserId    string    `db:"user_id"`
+	Url       string    `db:"url"`
+	Number    int       `db:"number"`
+	GitBranch string    `db:"git_branch"`
+	Base      string    `db:"base"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func (pr *PlanPullRequest) ToApi() *shared.PlanPullRequest {
+	return &shared.PlanPullRequest{
+		Id:        pr.Id,
+		PlanId:    pr.PlanId,
+		UserId:    pr.UserId,
+		Url:       pr.Url,
+		Number:    pr.Number,
+		GitBranch: pr.GitBranch,
+		Base:      pr.Base,
+		CreatedAt: pr.CreatedAt,
+	}
+}
+
 type ConvoSummary struct {
 	Id                          string    `db:"id"`
 	OrgId                       string    `db:"org_id"`
@@ -305,6 +338,9 @@ type Context struct {
 	NumTokens       int                `json:"numTokens"`
 	Body            string             `json:"body,omitempty"`
 	ForceSkipIgnore bool               `json:"forceSkipIgnore"`
+	FileMode        os.FileMode        `json:"fileMode,omitempty"`
+	Encoding        string             `json:"encoding,omitempty"`
+	SymlinkTarget   string             `json:"symlinkTarget,omitempty"`
 	CreatedAt       time.Time          `json:"createdAt"`
 	UpdatedAt       time.Time          `json:"updatedAt"`
 }
@@ -321,6 +357,9 @@ func (context *Context) ToApi() *shared.Context {
 		NumTokens:       context.NumTokens,
 		Body:            context.Body,
 		ForceSkipIgnore: context.ForceSkipIgnore,
+		FileMode:        context.FileMode,
+		Encoding:        context.Encoding,
+		SymlinkTarget:   context.SymlinkTarget,
 		CreatedAt:       context.CreatedAt,
 		UpdatedAt:       context.UpdatedAt,
 	}
@@ -337,6 +376,8 @@ type ConvoMessage struct {
 	Message   string    `json:"message"`
 	Stopped   bool      `json:"stopped"`
 	CreatedAt time.Time `json:"createdAt"`
+
+	Usage *shared.ModelUsage `json:"usage,omitempty"`
 }
 
 func (msg *ConvoMessage) ToApi() *shared.ConvoMessage {
@@ -348,25 +389,33 @@ func (msg *ConvoMessage) ToApi() *shared.ConvoMessage {
 		Num:       msg.Num,
 		Message:   msg.Message,
 		Stopped:   msg.Stopped,
+		Usage:     msg.Usage,
 		CreatedAt: msg.CreatedAt,
 	}
 }
 
 type ConvoMessageDescription struct {
-	Id                    string          `json:"id"`
-	OrgId                 string          `json:"orgId"`
-	PlanId                string          `json:"planId"`
-	ConvoMessageId        string          `json:"convoMessageId"`
-	SummarizedToMessageId string          `json:"summarizedToMessageId"`
-	MadePlan              bool            `json:"madePlan"`
-	CommitMsg             string          `json:"commitMsg"`
-	Files                 []string        `json:"files"`
-	Error                 string          `json:"error"`
-	DidBuild              bool            `json:"didBuild"`
-	BuildPathsInvalidated map[string]bool `json:"buildPathsInvalidated"`
-	AppliedAt             *time.Time      `json:"appliedAt,omitempty"`
-	CreatedAt             time.Time       `json:"createdAt"`
-	UpdatedAt             time.Time       `json:"updatedAt"`
+	Id                    string                `json:"id"`
+	OrgId                 string                `json:"orgId"`
+	PlanId                string                `json:"planId"`
+	ConvoMessageId        string                `json:"convoMessageId"`
+	SummarizedToMessageId string                `json:"summarizedToMessageId"`
+	MadePlan              bool                  `json:"madePlan"`
+	CommitMsg             string                `json:"commitMsg"`
+	Files                 []string              `json:"files"`
+	RemovedFiles          []string              `json:"removedFiles,omitempty"`
+	MovedFiles            []*shared.MovedFile   `json:"movedFiles,omitempty"`
+	BaseShasByPath        map[string]string     `json:"baseShasByPath,omitempty"`
+	Commands              []*shared.PlanCommand `json:"commands,omitempty"`
+	Error                 string                `json:"error"`
+	DidBuild              bool                  `json:"didBuild"`
+	BuildPathsInvalidated map[string]bool       `json:"buildPathsInvalidated"`
+	Subtasks              []string              `json:"subtasks,omitempty"`
+	CompletedSubtasks     []int                 `json:"completedSubtasks,omitempty"`
+	PlanFinished          bool                  `json:"planFinished,omitempty"`
+	AppliedAt             *time.Time            `json:"appliedAt,omitempty"`
+	CreatedAt             time.Time             `json:"createdAt"`
+	UpdatedAt             time.Time             `json:"updatedAt"`
 }
 
 func (desc *ConvoMessageDescription) ToApi() *shared.ConvoMessageDescription {
@@ -377,8 +426,15 @@ func (desc *ConvoMessageDescription) ToApi() *shared.ConvoMessageDescription {
 		MadePlan:              desc.MadePlan,
 		CommitMsg:             desc.CommitMsg,
 		Files:                 desc.Files,
+		RemovedFiles:          desc.RemovedFiles,
+		MovedFiles:            desc.MovedFiles,
+		BaseShasByPath:        desc.BaseShasByPath,
+		Commands:              desc.Commands,
 		DidBuild:              desc.DidBuild,
 		BuildPathsInvalidated: desc.BuildPathsInvalidated,
+		Subtasks:              desc.Subtasks,
+		CompletedSubtasks:     desc.CompletedSubtasks,
+		PlanFinished:          desc.PlanFinished,
 		Error:                 desc.Error,
 		CreatedAt:             desc.CreatedAt,
 		UpdatedAt:             desc.UpdatedAt,
@@ -393,6 +449,8 @@ type PlanFileResult struct {
 	PlanBuildId    string                `json:"planBuildId"`
 	Path           string                `json:"path"`
 	Content        string                `json:"content,omitempty"`
+	RemovedFile    bool                  `json:"removedFile,omitempty"`
+	MovedFrom      string                `json:"movedFrom,omitempty"`
 	Replacements   []*shared.Replacement `json:"replacements"`
 	AnyFailed      bool                  `json:"anyFailed"`
 	Error          string                `json:"error"`
@@ -409,6 +467,8 @@ func (res *PlanFileResult) ToApi() *shared.PlanFileResult {
 		ConvoMessageId: res.ConvoMessageId,
 		Path:           res.Path,
 		Content:        res.Content,
+		RemovedFile:    res.RemovedFile,
+		MovedFrom:      res.MovedFrom,
 		AnyFailed:      res.AnyFailed,
 		AppliedAt:      res.AppliedAt,
 		RejectedAt:     res.RejectedAt,
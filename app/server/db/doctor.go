@@ -0,0 +1,93 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+func PingDb() error {
+	if Conn == nil {
+		return fmt.Errorf("db not initialized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return Conn.PingContext(ctx)
+}
+
+// CheckBaseDirWritable writes and removes a temp file in the server's storage dir, where plans' files and git repos are kept
+func CheckBaseDirWritable() error {
+	err := os.MkdirAll(BaseDir, os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("error creating %s: %v", BaseDir, err)
+	}
+
+	f, err := os.CreateTemp(BaseDir, ".doctor-*")
+	if err != nil {
+		return fmt.Errorf("error writing to %s: %v", BaseDir, err)
+	}
+	f.Close()
+
+	return os.Remove(f.Name())
+}
+
+func GitVersion() (string, error) {
+	res, err := exec.Command("git", "--version").CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("error running git: %v", err)
+	}
+	return strings.TrimSpace(string(res)), nil
+}
+
+// CheckPlanRepo checks that a plan's dir and git repo exist on disk and that the branch is in both the repo and the db
+func CheckPlanRepo(orgId, planId, branch string) error {
+	dir := getPlanDir(orgId, planId)
+
+	_, err := os.Stat(dir)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("plan dir %s is missing", dir)
+	} else if err != nil {
+		return fmt.Errorf("error checking plan dir: %v", err)
+	}
+
+	for _, subdirFn := range [](func(orgId, planId string) string){
+		getPlanContextDir,
+		getPlanConversationDir,
+		getPlanResultsDir,
+		getPlanDescriptionsDir} {
+		subdir := subdirFn(orgId, planId)
+		if _, err := os.Stat(subdir); err != nil {
+			return fmt.Errorf("plan subdir %s is missing", filepath.Base(subdir))
+		}
+	}
+
+	res, err := exec.Command("git", "-C", dir, "rev-parse", "--git-dir").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("plan dir isn't a git repo: %s", strings.TrimSpace(string(res)))
+	}
+
+	branches, err := GitListBranches(orgId, planId)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(branches, branch) {
+		return fmt.Errorf("branch %s is missing from the plan's git repo", branch)
+	}
+
+	dbBranch, err := GetDbBranch(planId, branch)
+	if err != nil {
+		return err
+	}
+	if dbBranch == nil {
+		return fmt.Errorf("branch %s is missing from the db", branch)
+	}
+
+	return nil
+}
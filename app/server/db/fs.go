@@ -61,6 +61,8 @@ func DeletePlanDir(orgId, planId string) error {
 		return fmt.Errorf("error deleting plan dir: %v", err)
 	}
 
+	ClearPlanTokenIndex(planId)
+
 	return nil
 }
 
@@ -83,3 +85,7 @@ func getPlanResultsDir(orgId, planId string) string {
 func getPlanDescriptionsDir(orgId, planId string) string {
 	return filepath.Join(getPlanDir(orgId, planId), "descriptions")
 }
+
+func getPlanSubtasksPath(orgId, planId string) string {
+	return filepath.Join(getPlanDir(orgId, planId), "subtasks.json")
+}
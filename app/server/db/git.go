@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/fatih/color"
+	"github.com/plandex/plandex/shared"
 )
 
 func init() {
@@ -83,6 +84,20 @@ func GitAddAndCommit(orgId, planId, branch, message string) error {
 // 	return nil
 // }
 
+// GitGetHeadSha returns the sha of the plan's latest commit, or an empty string if it has none
+func GitGetHeadSha(orgId, planId string) (string, error) {
+	dir := getPlanDir(orgId, planId)
+
+	res, err := exec.Command("git", "-C", dir, "rev-parse", "--verify", "--quiet", "HEAD").Output()
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("error getting head sha for dir: %s, err: %v", dir, err)
+	}
+
+	return strings.TrimSpace(string(res)), nil
+}
+
 func GitRewindToSha(orgId, planId, branch, sha string) error {
 	dir := getPlanDir(orgId, planId)
 
@@ -94,6 +109,46 @@ func GitRewindToSha(orgId, planId, branch, sha string) error {
 	return nil
 }
 
+// GitRewindBeforeLastPrompt rewinds to the commit made just before the most recent user prompt was stored, removing the prompt along with every reply, build, and context change that followed it
+func GitRewindBeforeLastPrompt(orgId, planId, branch string) error {
+	dir := getPlanDir(orgId, planId)
+
+	res, err := exec.Command("git", "-C", dir, "log", "--pretty=%H %s").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("error getting git history for dir: %s, err: %v, output: %s", dir, err, string(res))
+	}
+
+	var promptSha string
+	for _, line := range strings.Split(strings.TrimSpace(string(res)), "\n") {
+		sha, subject, _ := strings.Cut(line, " ")
+		if strings.Contains(subject, userPromptCommitDesc) {
+			promptSha = sha
+			break
+		}
+	}
+
+	if promptSha == "" {
+		return fmt.Errorf("no prompt to rewind")
+	}
+
+	res, err = exec.Command("git", "-C", dir, "rev-parse", "--verify", "--quiet", promptSha+"^").CombinedOutput()
+	if err == nil {
+		return gitRewindToSha(dir, strings.TrimSpace(string(res)))
+	}
+
+	// the prompt was the first commit, so the branch goes back to having no commits at all
+	res, err = exec.Command("git", "-C", dir, "update-ref", "-d", "HEAD").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("error removing first commit for dir: %s, err: %v, output: %s", dir, err, string(res))
+	}
+	res, err = exec.Command("git", "-C", dir, "rm", "-r", "-q", "-f", "--ignore-unmatch", ".").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("error removing files for dir: %s, err: %v, output: %s", dir, err, string(res))
+	}
+
+	return nil
+}
+
 func GetGitCommitHistory(orgId, planId, branch string) (body string, shas []string, err error) {
 	dir := getPlanDir(orgId, planId)
 
@@ -105,6 +160,41 @@ func GetGitCommitHistory(orgId, planId, branch string) (body string, shas []stri
 	return body, shas, nil
 }
 
+// GetGitCommitEvents returns the commits on the plan's checked out branch as events, oldest first
+func GetGitCommitEvents(orgId, planId string) ([]*shared.PlanEvent, error) {
+	dir := getPlanDir(orgId, planId)
+
+	var out bytes.Buffer
+	cmd := exec.Command("git", "log", "--reverse", "--pretty=%h@@|@@%at@@|@@%B@>>>@")
+	cmd.Dir = dir
+	cmd.Stdout = &out
+	err := cmd.Run()
+	if err != nil {
+		return nil, fmt.Errorf("error getting git history for dir: %s, err: %v", dir, err)
+	}
+
+	var events []*shared.PlanEvent
+	for _, entry := range strings.Split(strings.TrimSpace(out.String()), "@>>>@") {
+		parts := strings.Split(strings.TrimSpace(entry), "@@|@@")
+		if len(parts) != 3 {
+			continue
+		}
+
+		timestamp, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		events = append(events, &shared.PlanEvent{
+			Sha:       parts[0],
+			Message:   strings.TrimSpace(parts[2]),
+			CreatedAt: time.Unix(timestamp, 0).UTC(),
+		})
+	}
+
+	return events, nil
+}
+
 func GetLatestCommit(orgId, planId, branch string) (sha, body string, err error) {
 	dir := getPlanDir(orgId, planId)
 
@@ -0,0 +1,57 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// ListConvoPage returns the page of the plan's conversation that matches the query
+func ListConvoPage(orgId, planId string, q shared.HistoryQuery) (*shared.ConvoPage, error) {
+	convo, err := GetPlanConvo(orgId, planId)
+	if err != nil {
+		return nil, fmt.Errorf("error getting plan convo: %v", err)
+	}
+
+	messages := []*shared.ConvoMessage{}
+	for _, msg := range convo {
+		if q.Role != "" && msg.Role != q.Role {
+			continue
+		}
+		if !q.Matches(msg.CreatedAt, msg.Message) {
+			continue
+		}
+		messages = append(messages, msg.ToApi())
+	}
+
+	page, nextOffset := shared.PageHistory(q, messages)
+
+	return &shared.ConvoPage{
+		Messages:   page,
+		Total:      len(messages),
+		NextOffset: nextOffset,
+	}, nil
+}
+
+// ListPlanEventsPage returns the page of the events on the plan's checked out branch that matches the query
+func ListPlanEventsPage(orgId, planId string, q shared.HistoryQuery) (*shared.PlanEventPage, error) {
+	all, err := GetGitCommitEvents(orgId, planId)
+	if err != nil {
+		return nil, err
+	}
+
+	events := []*shared.PlanEvent{}
+	for _, event := range all {
+		if q.Matches(event.CreatedAt, event.Message) {
+			events = append(events, event)
+		}
+	}
+
+	page, nextOffset := shared.PageHistory(q, events)
+
+	return &shared.PlanEventPage{
+		Events:     page,
+		Total:      len(events),
+		NextOffset: nextOffset,
+	}, nil
+}
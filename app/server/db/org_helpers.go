@@ -212,3 +212,23 @@ func ListOrgRoles(orgId string) ([]*OrgRole, error) {
 
 	return orgRoles, nil
 }
+
+// SetOrgSlackSettings sets the Slack webhook the org's plan notifications are posted to. A nil webhookUrl turns them off.
+func SetOrgSlackSettings(orgId string, webhookUrl *string, includePrompts bool) error {
+	_, err := Conn.Exec("UPDATE orgs SET slack_webhook_url = $1, slack_include_prompts = $2 WHERE id = $3", webhookUrl, includePrompts, orgId)
+	if err != nil {
+		return fmt.Errorf("error setting org slack settings: %v", err)
+	}
+
+	return nil
+}
+
+// SetOrgMaxDailyCost sets the org's daily budget. A nil maxDailyCost removes it.
+func SetOrgMaxDailyCost(orgId string, maxDailyCost *float64) error {
+	_, err := Conn.Exec("UPDATE orgs SET max_daily_cost = $1 WHERE id = $2", maxDailyCost, orgId)
+	if err != nil {
+		return fmt.Errorf("error setting org max daily cost: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,235 @@
+package db
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// ExportPlanBundle packs up the plan's repo with all its branches, along with its conversation summaries, so it can be imported on another server
+func ExportPlanBundle(plan *Plan) (*shared.PlanBundle, error) {
+	dir := getPlanDir(plan.OrgId, plan.Id)
+
+	branches, err := ListPlanBranches(plan.OrgId, plan.Id)
+	if err != nil {
+		return nil, fmt.Errorf("error listing branches: %v", err)
+	}
+
+	namesById := map[string]string{}
+	for _, branch := range branches {
+		namesById[branch.Id] = branch.Name
+	}
+
+	bundle := &shared.PlanBundle{
+		Version:    shared.PlanBundleVersion,
+		Name:       plan.Name,
+		ExportedAt: time.Now().UTC(),
+	}
+	for _, branch := range branches {
+		bundleBranch := &shared.PlanBundleBranch{Name: branch.Name}
+		if branch.ParentBranchId != nil {
+			bundleBranch.ParentBranch = namesById[*branch.ParentBranchId]
+		}
+		bundle.Branches = append(bundle.Branches, bundleBranch)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "plandex-bundle-*")
+	if err != nil {
+		return nil, fmt.Errorf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	bundlePath := filepath.Join(tmpDir, "plan.bundle")
+	res, err := exec.Command("git", "-C", dir, "bundle", "create", bundlePath, "--all").CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("error creating git bundle for dir: %s, err: %v, output: %s", dir, err, string(res))
+	}
+
+	bundle.GitBundle, err = os.ReadFile(bundlePath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading git bundle: %v", err)
+	}
+
+	var summaries []*ConvoSummary
+	err = Conn.Select(&summaries, "SELECT * FROM convo_summaries WHERE plan_id = $1 ORDER BY created_at", plan.Id)
+	if err != nil {
+		return nil, fmt.Errorf("error getting plan summaries: %v", err)
+	}
+	for _, summary := range summaries {
+		bundle.Summaries = append(bundle.Summaries, summary.ToApi())
+	}
+
+	return bundle, nil
+}
+
+// ImportPlanBundle creates a plan in the project from a bundle, with the bundle's branches and their conversations, context, and pending changes. Nothing is left behind if it fails.
+func ImportPlanBundle(orgId, projectId, userId, name string, bundle *shared.PlanBundle) (plan *Plan, err error) {
+	if bundle.Version > shared.PlanBundleVersion {
+		return nil, fmt.Errorf("bundle version %d is newer than this server supports. Upgrade the server to import it.", bundle.Version)
+	}
+	if len(bundle.GitBundle) == 0 {
+		return nil, fmt.Errorf("bundle has no plan repo")
+	}
+
+	plan, err = CreatePlan(orgId, projectId, userId, name)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err == nil {
+			return
+		}
+		if _, delErr := Conn.Exec("DELETE FROM plans WHERE id = $1", plan.Id); delErr != nil {
+			log.Printf("Error deleting partially imported plan: %v\n", delErr)
+		}
+		if delErr := DeletePlanDir(orgId, plan.Id); delErr != nil {
+			log.Printf("Error deleting partially imported plan dir: %v\n", delErr)
+		}
+	}()
+
+	// parents are created before their children, which start with the parent's token counts until they're synced below
+	branchesByName := map[string]*Branch{}
+	mainBranch, err := GetDbBranch(plan.Id, "main")
+	if err != nil {
+		return nil, fmt.Errorf("error getting main branch: %v", err)
+	}
+	branchesByName["main"] = mainBranch
+
+	remaining := bundle.Branches
+	for len(remaining) > 0 {
+		var next []*shared.PlanBundleBranch
+		for _, bundleBranch := range remaining {
+			if bundleBranch.Name == "main" {
+				continue
+			}
+
+			parentName := bundleBranch.ParentBranch
+			if parentName == "" {
+				parentName = "main"
+			}
+			parent, ok := branchesByName[parentName]
+			if !ok {
+				next = append(next, bundleBranch)
+				continue
+			}
+
+			branch, err := CreateBranch(plan, parent, bundleBranch.Name, nil)
+			if err != nil {
+				return nil, fmt.Errorf("error creating branch %s: %v", bundleBranch.Name, err)
+			}
+			branchesByName[bundleBranch.Name] = branch
+		}
+
+		if len(next) == len(remaining) {
+			return nil, fmt.Errorf("bundle has branches with missing parents, like %s", next[0].Name)
+		}
+		remaining = next
+	}
+
+	dir := getPlanDir(orgId, plan.Id)
+
+	tmpDir, err := os.MkdirTemp("", "plandex-bundle-*")
+	if err != nil {
+		return nil, fmt.Errorf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	bundlePath := filepath.Join(tmpDir, "plan.bundle")
+	err = os.WriteFile(bundlePath, bundle.GitBundle, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("error writing git bundle: %v", err)
+	}
+
+	// the branches created above are replaced by the bundle's, including the checked out one. Nothing is checked out until the bundle's trees are checked.
+	for _, args := range [][]string{
+		{"config", "core.symlinks", "false"},
+		{"symbolic-ref", "HEAD", "refs/heads/main"},
+		{"fetch", "--update-head-ok", bundlePath, "+refs/heads/*:refs/heads/*"},
+	} {
+		res, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		if err != nil {
+			return nil, fmt.Errorf("error importing git bundle for dir: %s, err: %v, output: %s", dir, err, string(res))
+		}
+	}
+
+	err = checkBundleTrees(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := exec.Command("git", "-C", dir, "reset", "--hard").CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("error checking out git bundle for dir: %s, err: %v, output: %s", dir, err, string(res))
+	}
+
+	for name := range branchesByName {
+		err = gitCheckoutBranch(dir, name)
+		if err != nil {
+			return nil, err
+		}
+
+		err = SyncPlanTokens(orgId, plan.Id, name)
+		if err != nil {
+			return nil, fmt.Errorf("error syncing tokens for branch %s: %v", name, err)
+		}
+	}
+
+	err = gitCheckoutBranch(dir, "main")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, summary := range bundle.Summaries {
+		err = StoreSummary(&ConvoSummary{
+			OrgId:                       orgId,
+			PlanId:                      plan.Id,
+			LatestConvoMessageId:        summary.LatestConvoMessageId,
+			LatestConvoMessageCreatedAt: summary.LatestConvoMessageCreatedAt,
+			Summary:                     summary.Summary,
+			Tokens:                      summary.Tokens,
+			NumMessages:                 summary.NumMessages,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return plan, nil
+}
+
+// checkBundleTrees rejects bundles with symlinks or submodules anywhere in their history. The server reads and rewrites files in the plan dir in place, so a link would let an imported plan reach files outside it.
+func checkBundleTrees(dir string) error {
+	res, err := exec.Command("git", "-C", dir, "log", "--all", "--root", "-m", "--raw", "--no-renames", "--no-abbrev", "--format=").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("error checking git bundle for dir: %s, err: %v, output: %s", dir, err, string(res))
+	}
+
+	for _, line := range strings.Split(string(res), "\n") {
+		// :<old mode> <new mode> <old sha> <new sha> <status>\t<path>
+		fields := strings.Fields(line)
+		if len(fields) < 2 || !strings.HasPrefix(fields[0], ":") {
+			continue
+		}
+
+		path := line
+		if i := strings.Index(line, "\t"); i >= 0 {
+			path = line[i+1:]
+		}
+
+		switch fields[1] {
+		case "120000":
+			return fmt.Errorf("bundle has a symlink at %s, which plans can't contain", path)
+		case "160000":
+			return fmt.Errorf("bundle has a submodule at %s, which plans can't contain", path)
+		}
+	}
+
+	return nil
+}
@@ -84,6 +84,27 @@ func CreatePlan(orgId, projectId, userId, name string) (*Plan, error) {
 	return plan, nil
 }
 
+// GetUniquePlanName suffixes name with .2, .3, etc. if the user already has a plan with that name in the project
+func GetUniquePlanName(projectId, userId, name string) (string, error) {
+	i := 2
+	originalName := name
+	for {
+		var count int
+		err := Conn.Get(&count, "SELECT COUNT(*) FROM plans WHERE project_id = $1 AND owner_id = $2 AND name = $3", projectId, userId, name)
+
+		if err != nil {
+			return "", fmt.Errorf("error checking if plan exists: %v", err)
+		}
+
+		if count == 0 {
+			return name, nil
+		}
+
+		name = originalName + "." + fmt.Sprint(i)
+		i++
+	}
+}
+
 func ListOwnedPlans(projectIds []string, userId string, archived bool) ([]*Plan, error) {
 	qs := "SELECT * FROM plans WHERE project_id = ANY($1) AND owner_id = $2"
 	qargs := []interface{}{pq.Array(projectIds), userId}
@@ -106,6 +127,45 @@ func ListOwnedPlans(projectIds []string, userId string, archived bool) ([]*Plan,
 	return plans, nil
 }
 
+// ListAccessiblePlans lists the user's own plans in the projects along with the plans their teammates have shared with the org
+func ListAccessiblePlans(projectIds []string, userId string) ([]*Plan, error) {
+	var plans []*Plan
+	err := Conn.Select(&plans, "SELECT * FROM plans WHERE project_id = ANY($1) AND (owner_id = $2 OR shared_with_org_at IS NOT NULL) AND archived_at IS NULL ORDER BY updated_at DESC", pq.Array(projectIds), userId)
+
+	if err != nil {
+		return nil, fmt.Errorf("error listing plans: %v", err)
+	}
+
+	return plans, nil
+}
+
+// ListSharedPlans lists the plans shared with the org across all its projects, other than the user's own
+func ListSharedPlans(orgId, userId string) ([]*Plan, error) {
+	var plans []*Plan
+	err := Conn.Select(&plans, "SELECT * FROM plans WHERE org_id = $1 AND owner_id != $2 AND shared_with_org_at IS NOT NULL AND archived_at IS NULL ORDER BY updated_at DESC", orgId, userId)
+
+	if err != nil {
+		return nil, fmt.Errorf("error listing shared plans: %v", err)
+	}
+
+	return plans, nil
+}
+
+func SetPlanSharedWithOrg(planId string, share bool) error {
+	var err error
+	if share {
+		_, err = Conn.Exec("UPDATE plans SET shared_with_org_at = NOW() WHERE id = $1 AND shared_with_org_at IS NULL", planId)
+	} else {
+		_, err = Conn.Exec("UPDATE plans SET shared_with_org_at = NULL WHERE id = $1", planId)
+	}
+
+	if err != nil {
+		return fmt.Errorf("error updating plan sharing: %v", err)
+	}
+
+	return nil
+}
+
 func AddPlanContextTokens(planId, branch string, addTokens int) error {
 	_, err := Conn.Exec("UPDATE branches SET context_tokens = context_tokens + $1 WHERE plan_id = $2 AND name = $3", addTokens, planId, branch)
 	if err != nil {
@@ -0,0 +1,26 @@
+package db
+
+import "fmt"
+
+func CreatePlanPullRequest(pr *PlanPullRequest) error {
+	query := `INSERT INTO plan_pull_requests (org_id, plan_id, user_id, url, number, git_branch, base) 
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	RETURNING id, created_at`
+
+	err := Conn.QueryRow(query, pr.OrgId, pr.PlanId, pr.UserId, pr.Url, pr.Number, pr.GitBranch, pr.Base).Scan(&pr.Id, &pr.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error storing pull request: %v", err)
+	}
+
+	return nil
+}
+
+func ListPlanPullRequests(planId string) ([]*PlanPullRequest, error) {
+	var prs []*PlanPullRequest
+	err := Conn.Select(&prs, "SELECT * FROM plan_pull_requests WHERE plan_id = $1 ORDER BY created_at", planId)
+	if err != nil {
+		return nil, fmt.Errorf("error listing pull requests: %v", err)
+	}
+
+	return prs, nil
+}
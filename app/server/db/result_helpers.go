@@ -61,6 +61,7 @@ func GetCurrentPlanState(params CurrentPlanStateParams) (*shared.CurrentPlanStat
 	var dbPlanFileResults []*PlanFileResult
 	var convoMessageDescriptions []*shared.ConvoMessageDescription
 	contextsByPath := map[string]*Context{}
+	var subtasks []*shared.Subtask
 
 	errCh := make(chan error)
 
@@ -124,7 +125,18 @@ func GetCurrentPlanState(params CurrentPlanStateParams) (*shared.CurrentPlanStat
 		errCh <- nil
 	}()
 
-	for i := 0; i < 3; i++ {
+	go func() {
+		res, err := GetPlanSubtasks(orgId, planId)
+		if err != nil {
+			errCh <- fmt.Errorf("error getting subtasks: %v", err)
+			return
+		}
+		subtasks = res
+
+		errCh <- nil
+	}()
+
+	for i := 0; i < 4; i++ {
 		err := <-errCh
 		if err != nil {
 			return nil, err
@@ -155,6 +167,8 @@ func GetCurrentPlanState(params CurrentPlanStateParams) (*shared.CurrentPlanStat
 		PlanResult:               planResult,
 		ConvoMessageDescriptions: convoMessageDescriptions,
 		ContextsByPath:           pendingContextsByPath,
+		Subtasks:                 subtasks,
+		ServerTime:               time.Now(),
 	}
 
 	currentPlanFiles, err := planState.GetFiles()
@@ -389,8 +403,11 @@ func ApplyPlan(orgId, userId, branchName string, plan *Plan) error {
 
 	pendingNewFilesSet := make(map[string]bool)
 	pendingUpdatedFilesSet := make(map[string]bool)
+	pendingRemovedFilesSet := make(map[string]bool)
 	for _, result := range pendingDbResults {
-		if len(result.Replacements) == 0 && result.Content != "" {
+		if result.RemovedFile {
+			pendingRemovedFilesSet[result.Path] = true
+		} else if len(result.Replacements) == 0 && result.Content != "" {
 			pendingNewFilesSet[result.Path] = true
 		} else if !pendingNewFilesSet[result.Path] {
 			pendingUpdatedFilesSet[result.Path] = true
@@ -401,7 +418,7 @@ func ApplyPlan(orgId, userId, branchName string, plan *Plan) error {
 	var updateContextRes *shared.UpdateContextResponse
 
 	var currentPlanState *shared.CurrentPlanState
-	if len(pendingNewFilesSet) > 0 || len(pendingUpdatedFilesSet) > 0 {
+	if len(pendingNewFilesSet) > 0 || len(pendingUpdatedFilesSet) > 0 || len(pendingRemovedFilesSet) > 0 {
 		res, err := GetCurrentPlanState(CurrentPlanStateParams{
 			OrgId:                    orgId,
 			PlanId:                   plan.Id,
@@ -414,6 +431,16 @@ func ApplyPlan(orgId, userId, branchName string, plan *Plan) error {
 		}
 
 		currentPlanState = res
+
+		// a file can be removed and then created again later in the plan, so go by its final state
+		for path := range pendingRemovedFilesSet {
+			if currentPlanState.CurrentPlanFiles.Removed[path] {
+				delete(pendingNewFilesSet, path)
+				delete(pendingUpdatedFilesSet, path)
+			} else {
+				delete(pendingRemovedFilesSet, path)
+			}
+		}
 	}
 
 	errCh = make(chan error)
@@ -495,6 +522,9 @@ func ApplyPlan(orgId, userId, branchName string, plan *Plan) error {
 			updateReq := shared.UpdateContextRequest{}
 			for path := range pendingUpdatedFilesSet {
 				context := contextsByPath[path]
+				if context == nil {
+					continue
+				}
 				updateReq[context.Id] = &shared.UpdateContextParams{
 					Body: currentPlanState.CurrentPlanFiles.Files[path],
 				}
@@ -522,6 +552,34 @@ func ApplyPlan(orgId, userId, branchName string, plan *Plan) error {
 
 	}
 
+	if len(pendingRemovedFilesSet) > 0 {
+		go func() {
+			var toRemove []*Context
+			removeTokens := 0
+			for path := range pendingRemovedFilesSet {
+				context := contextsByPath[path]
+				if context != nil {
+					toRemove = append(toRemove, context)
+					removeTokens += context.NumTokens
+				}
+			}
+
+			err := ContextRemove(toRemove)
+			if err != nil {
+				errCh <- fmt.Errorf("error removing context for deleted files: %v", err)
+				return
+			}
+
+			err = AddPlanContextTokens(planId, branchName, -removeTokens)
+			if err != nil {
+				errCh <- fmt.Errorf("error updating plan tokens: %v", err)
+				return
+			}
+
+			errCh <- nil
+		}()
+	}
+
 	numRoutines := len(pendingDbResults) +
 		len(convoMessageDescriptions)
 	if len(pendingNewFilesSet) > 0 {
@@ -530,6 +588,9 @@ func ApplyPlan(orgId, userId, branchName string, plan *Plan) error {
 	if len(pendingUpdatedFilesSet) > 0 {
 		numRoutines++
 	}
+	if len(pendingRemovedFilesSet) > 0 {
+		numRoutines++
+	}
 
 	for i := 0; i < numRoutines; i++ {
 		err := <-errCh
@@ -0,0 +1,42 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func GetPlanSubtasks(orgId, planId string) ([]*shared.Subtask, error) {
+	bytes, err := os.ReadFile(getPlanSubtasksPath(orgId, planId))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading subtasks: %v", err)
+	}
+
+	var subtasks []*shared.Subtask
+	err = json.Unmarshal(bytes, &subtasks)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling subtasks: %v", err)
+	}
+
+	return subtasks, nil
+}
+
+// StorePlanSubtasks writes the plan's subtasks to the plan's repo, so they're committed and rewound along with the conversation
+func StorePlanSubtasks(orgId, planId string, subtasks []*shared.Subtask) error {
+	bytes, err := json.Marshal(subtasks)
+	if err != nil {
+		return fmt.Errorf("error marshalling subtasks: %v", err)
+	}
+
+	err = os.WriteFile(getPlanSubtasksPath(orgId, planId), bytes, os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("error writing subtasks: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package db
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// limits how many texts are tokenized at once across all counters, since large texts are CPU-bound
+var tokenCountSlots = make(chan struct{}, runtime.NumCPU())
+
+type tokenCount struct {
+	done      chan struct{}
+	numTokens int
+	err       error
+}
+
+// TokenCounter counts a batch of texts in the background, so counting can overlap with loading a plan. Get and Wait block until counts are finished, so records are never stored with a count that's still in flight.
+type TokenCounter struct {
+	planId string
+
+	mu     sync.Mutex
+	counts map[string]*tokenCount
+}
+
+func NewTokenCounter(planId string) *TokenCounter {
+	return &TokenCounter{
+		planId: planId,
+		counts: map[string]*tokenCount{},
+	}
+}
+
+// Add starts counting text under key. Counts go through the plan's token index, so text that has been counted before is looked up rather than counted again.
+func (c *TokenCounter) Add(key string, encoding shared.TokenEncoding, text string) {
+	count := &tokenCount{done: make(chan struct{})}
+
+	c.mu.Lock()
+	c.counts[key] = count
+	c.mu.Unlock()
+
+	go func() {
+		defer close(count.done)
+
+		tokenCountSlots <- struct{}{}
+		defer func() { <-tokenCountSlots }()
+
+		count.numTokens, count.err = GetPlanNumTokens(c.planId, encoding, text)
+	}()
+}
+
+// Get waits for the count for key
+func (c *TokenCounter) Get(key string) (int, error) {
+	c.mu.Lock()
+	count := c.counts[key]
+	c.mu.Unlock()
+
+	if count == nil {
+		return 0, fmt.Errorf("no token count started for %s", key)
+	}
+
+	<-count.done
+	return count.numTokens, count.err
+}
+
+// Wait waits for every count that's been added and returns them by key, or the first error
+func (c *TokenCounter) Wait() (map[string]int, error) {
+	c.mu.Lock()
+	keys := make([]string, 0, len(c.counts))
+	for key := range c.counts {
+		keys = append(keys, key)
+	}
+	c.mu.Unlock()
+
+	res := make(map[string]int, len(keys))
+	for _, key := range keys {
+		numTokens, err := c.Get(key)
+		if err != nil {
+			return nil, fmt.Errorf("error counting tokens for %s: %v", key, err)
+		}
+		res[key] = numTokens
+	}
+
+	return res, nil
+}
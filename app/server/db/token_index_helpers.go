@@ -0,0 +1,107 @@
+package db
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// a plan's token index holds at most this many counts, dropping the least recently used, so plans that churn through many versions of their files don't hold on to them all
+const maxTokenIndexEntries = 2000
+
+type tokenIndexKey struct {
+	encoding shared.TokenEncoding
+	hash     [sha256.Size]byte
+}
+
+type tokenIndexEntry struct {
+	key       tokenIndexKey
+	numTokens int
+}
+
+// tokenIndex is an LRU cache of token counts. The front of order is the most recently used entry.
+type tokenIndex struct {
+	entries map[tokenIndexKey]*list.Element
+	order   *list.List
+}
+
+func newTokenIndex() *tokenIndex {
+	return &tokenIndex{
+		entries: map[tokenIndexKey]*list.Element{},
+		order:   list.New(),
+	}
+}
+
+func (index *tokenIndex) get(key tokenIndexKey) (int, bool) {
+	el, ok := index.entries[key]
+	if !ok {
+		return 0, false
+	}
+	index.order.MoveToFront(el)
+	return el.Value.(*tokenIndexEntry).numTokens, true
+}
+
+func (index *tokenIndex) add(key tokenIndexKey, numTokens int) {
+	if el, ok := index.entries[key]; ok {
+		el.Value.(*tokenIndexEntry).numTokens = numTokens
+		index.order.MoveToFront(el)
+		return
+	}
+
+	index.entries[key] = index.order.PushFront(&tokenIndexEntry{key: key, numTokens: numTokens})
+
+	if index.order.Len() > maxTokenIndexEntries {
+		oldest := index.order.Back()
+		index.order.Remove(oldest)
+		delete(index.entries, oldest.Value.(*tokenIndexEntry).key)
+	}
+}
+
+var tokenIndexMu sync.Mutex
+
+// token counts for each plan's files and context, keyed by content hash. Files are counted once when they're written or loaded as context, and later builds and replies that see the same content reuse the count.
+var tokenIndexByPlanId = map[string]*tokenIndex{}
+
+// GetPlanNumTokens counts the tokens in content that belongs to the plan, like a file or context body, using the index if it has already been counted
+func GetPlanNumTokens(planId string, encoding shared.TokenEncoding, content string) (int, error) {
+	key := tokenIndexKey{encoding: encoding, hash: sha256.Sum256([]byte(content))}
+
+	tokenIndexMu.Lock()
+	var numTokens int
+	var ok bool
+	if index := tokenIndexByPlanId[planId]; index != nil {
+		numTokens, ok = index.get(key)
+	}
+	tokenIndexMu.Unlock()
+
+	if ok {
+		return numTokens, nil
+	}
+
+	// counted outside the lock, since large files can take a while
+	numTokens, err := shared.GetNumTokensWithEncoding(encoding, content)
+	if err != nil {
+		return 0, err
+	}
+
+	tokenIndexMu.Lock()
+	defer tokenIndexMu.Unlock()
+
+	index := tokenIndexByPlanId[planId]
+	if index == nil {
+		index = newTokenIndex()
+		tokenIndexByPlanId[planId] = index
+	}
+	index.add(key, numTokens)
+
+	return numTokens, nil
+}
+
+func ClearPlanTokenIndex(planId string) {
+	tokenIndexMu.Lock()
+	defer tokenIndexMu.Unlock()
+
+	delete(tokenIndexByPlanId, planId)
+}
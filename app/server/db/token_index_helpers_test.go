@@ -0,0 +1,40 @@
+package db
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+)
+
+func TestTokenIndexEvictsLeastRecentlyUsed(t *testing.T) {
+	key := func(i int) tokenIndexKey {
+		return tokenIndexKey{hash: sha256.Sum256([]byte(fmt.Sprint(i)))}
+	}
+
+	index := newTokenIndex()
+	for i := 0; i < maxTokenIndexEntries; i++ {
+		index.add(key(i), i)
+	}
+
+	// using the oldest entry keeps it when the index overflows
+	if n, ok := index.get(key(0)); !ok || n != 0 {
+		t.Fatalf("expected entry 0 to be cached, got %d, %v", n, ok)
+	}
+	index.add(key(maxTokenIndexEntries), maxTokenIndexEntries)
+
+	if index.order.Len() != maxTokenIndexEntries || len(index.entries) != maxTokenIndexEntries {
+		t.Errorf("expected the index to stay at %d entries, got %d", maxTokenIndexEntries, index.order.Len())
+	}
+	if _, ok := index.get(key(0)); !ok {
+		t.Error("expected the recently used entry to be kept")
+	}
+	if _, ok := index.get(key(1)); ok {
+		t.Error("expected the least recently used entry to be evicted")
+	}
+	if n, ok := index.get(key(maxTokenIndexEntries)); !ok || n != maxTokenIndexEntries {
+		t.Errorf("expected the new entry to be cached, got %d, %v", n, ok)
+	}
+	if _, ok := index.get(key(2)); !ok {
+		t.Error("expected the rest of the entries to be kept")
+	}
+}
@@ -0,0 +1,166 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+)
+
+type UsageRecord struct {
+	shared.ModelUsage
+	PlanId    string    `json:"planId"`
+	Branch    string    `json:"branch"`
+	UserId    string    `json:"userId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func (record *UsageRecord) ToApi() *shared.UsageRecord {
+	return &shared.UsageRecord{
+		ModelUsage: record.ModelUsage,
+		PlanId:     record.PlanId,
+		Branch:     record.Branch,
+		UserId:     record.UserId,
+		CreatedAt:  record.CreatedAt,
+	}
+}
+
+// periods an org's running usage totals are kept for, in usage_totals
+const (
+	usagePeriodMonth = "month"
+	usagePeriodDay   = "day"
+)
+
+// StoreUsage records a model call's usage, and adds it to the org's running totals for the month and day (UTC) in the same transaction, so every server sees the same totals
+func StoreUsage(orgId string, record *UsageRecord) (err error) {
+	createdAt := record.CreatedAt.UTC()
+
+	tx, err := Conn.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+
+	// Ensure that rollback is attempted in case of failure
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("transaction rollback error: %v\n", rbErr)
+			} else {
+				log.Println("transaction rolled back")
+			}
+		}
+	}()
+
+	// calls that aren't part of a plan, like commit messages and reviews, have no plan id
+	_, err = tx.Exec(`INSERT INTO usage_records (org_id, plan_id, branch, user_id, prompt_tokens, completion_tokens, provider_reported, phase, model_name, created_at)
+	VALUES ($1, NULLIF($2, '')::uuid, $3, NULLIF($4, '')::uuid, $5, $6, $7, $8, $9, $10)`,
+		orgId, record.PlanId, record.Branch, record.UserId, record.PromptTokens, record.CompletionTokens, record.ProviderReported, string(record.Phase), record.ModelName, createdAt)
+	if err != nil {
+		return fmt.Errorf("error storing usage record: %v", err)
+	}
+
+	cost, priced := shared.EstimateCost(record.ModelUsage)
+
+	for period, start := range map[string]time.Time{
+		usagePeriodMonth: monthStart(createdAt),
+		usagePeriodDay:   dayStart(createdAt),
+	} {
+		_, err = tx.Exec(`INSERT INTO usage_totals (org_id, period, period_start, prompt_tokens, completion_tokens, cost, unpriced)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (org_id, period, period_start) DO UPDATE SET
+			prompt_tokens = usage_totals.prompt_tokens + EXCLUDED.prompt_tokens,
+			completion_tokens = usage_totals.completion_tokens + EXCLUDED.completion_tokens,
+			cost = usage_totals.cost + EXCLUDED.cost,
+			unpriced = usage_totals.unpriced OR EXCLUDED.unpriced`,
+			orgId, period, start, record.PromptTokens, record.CompletionTokens, cost, !priced)
+		if err != nil {
+			return fmt.Errorf("error updating usage totals: %v", err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return nil
+}
+
+func getOrgUsageTotal(orgId, period string, start time.Time) (shared.UsageTotal, error) {
+	var total shared.UsageTotal
+	err := Conn.QueryRow("SELECT prompt_tokens, completion_tokens, cost, unpriced FROM usage_totals WHERE org_id = $1 AND period = $2 AND period_start = $3", orgId, period, start).
+		Scan(&total.PromptTokens, &total.CompletionTokens, &total.Cost, &total.Unpriced)
+	if err == sql.ErrNoRows {
+		return shared.UsageTotal{}, nil
+	} else if err != nil {
+		return shared.UsageTotal{}, fmt.Errorf("error getting usage total: %v", err)
+	}
+
+	return total, nil
+}
+
+// GetOrgMonthTokens returns the tokens the whole org has used since the start of the month (UTC), and when the month ends
+func GetOrgMonthTokens(orgId string) (int, time.Time, error) {
+	month := monthStart(time.Now())
+
+	total, err := getOrgUsageTotal(orgId, usagePeriodMonth, month)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	return total.PromptTokens + total.CompletionTokens, month.AddDate(0, 1, 0), nil
+}
+
+// GetOrgDayUsage returns the whole org's usage since the start of the day (UTC)
+func GetOrgDayUsage(orgId string) (shared.UsageTotal, error) {
+	return getOrgUsageTotal(orgId, usagePeriodDay, dayStart(time.Now()))
+}
+
+func dayStart(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// GetPlanUsage returns the plan's usage records, oldest first
+func GetPlanUsage(orgId, planId string) ([]*UsageRecord, error) {
+	return queryUsage("WHERE org_id = $1 AND plan_id = $2", orgId, planId)
+}
+
+// GetOrgUsage returns the org's usage records from since onward, oldest first. Records of deleted plans are kept, so it accounts for everything the org has used.
+func GetOrgUsage(orgId string, since time.Time) ([]*UsageRecord, error) {
+	return queryUsage("WHERE org_id = $1 AND created_at >= $2", orgId, since.UTC())
+}
+
+func queryUsage(where string, args ...interface{}) ([]*UsageRecord, error) {
+	rows, err := Conn.Query(`SELECT COALESCE(plan_id::text, ''), branch, COALESCE(user_id::text, ''), prompt_tokens, completion_tokens, provider_reported, phase, model_name, created_at
+	FROM usage_records `+where+` ORDER BY created_at`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error getting usage records: %v", err)
+	}
+	defer rows.Close()
+
+	var records []*UsageRecord
+	for rows.Next() {
+		var record UsageRecord
+		var phase string
+		err = rows.Scan(&record.PlanId, &record.Branch, &record.UserId, &record.PromptTokens, &record.CompletionTokens, &record.ProviderReported, &phase, &record.ModelName, &record.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning usage record: %v", err)
+		}
+		record.Phase = shared.UsagePhase(phase)
+		records = append(records, &record)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("error reading usage records: %v", err)
+	}
+
+	return records, nil
+}
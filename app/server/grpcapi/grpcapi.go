@@ -0,0 +1,344 @@
+package grpcapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// ServiceName is the service declared in plandex.proto
+const ServiceName = protoPackage + ".Plandex"
+
+// NewServer returns a gRPC server with a method for each operation, typed by BuildSchema. Calls are served by the operation's HTTP handler in routes, so auth, validation, and responses are the same over both transports.
+func NewServer(routes http.Handler, ops []shared.ApiOperation, opts ...grpc.ServerOption) (*grpc.Server, error) {
+	schema, err := BuildSchema(ops)
+	if err != nil {
+		return nil, err
+	}
+
+	s := grpc.NewServer(opts...)
+	s.RegisterService(serviceDesc(routes, schema), nil)
+	return s, nil
+}
+
+// methodName is the gRPC method for an operation, like ProposePlan for proposePlan
+func methodName(op shared.ApiOperation) string {
+	return strings.ToUpper(op.Id[:1]) + op.Id[1:]
+}
+
+func serviceDesc(routes http.Handler, schema *Schema) *grpc.ServiceDesc {
+	desc := &grpc.ServiceDesc{
+		ServiceName: ServiceName,
+		HandlerType: (*any)(nil),
+		Metadata:    "plandex.proto",
+	}
+
+	for _, op := range schema.ops {
+		op := op
+		method := schema.Method(op)
+		name := string(method.Name())
+
+		if op.Streaming {
+			desc.Streams = append(desc.Streams, grpc.StreamDesc{
+				StreamName:    name,
+				ServerStreams: true,
+				Handler: func(srv any, stream grpc.ServerStream) error {
+					in := dynamicpb.NewMessage(method.Input())
+					if err := stream.RecvMsg(in); err != nil {
+						return err
+					}
+					return serveStream(stream, routes, op, method, in)
+				},
+			})
+			continue
+		}
+
+		desc.Methods = append(desc.Methods, grpc.MethodDesc{
+			MethodName: name,
+			Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+				in := dynamicpb.NewMessage(method.Input())
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+
+				call := func(ctx context.Context, req any) (any, error) {
+					return serveUnary(ctx, routes, op, method, req.(*dynamicpb.Message))
+				}
+				if interceptor == nil {
+					return call(ctx, in)
+				}
+				info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + ServiceName + "/" + name}
+				return interceptor(ctx, in, info, call)
+			},
+		})
+	}
+
+	return desc
+}
+
+func serveUnary(ctx context.Context, routes http.Handler, op shared.ApiOperation, method protoreflect.MethodDescriptor, in *dynamicpb.Message) (*dynamicpb.Message, error) {
+	req, err := httpRequest(ctx, op, in)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &responseWriter{header: http.Header{}}
+	routes.ServeHTTP(w, req)
+
+	if err := w.err(); err != nil {
+		return nil, err
+	}
+
+	res := dynamicpb.NewMessage(method.Output())
+	body := bytes.TrimSpace(w.body.Bytes())
+	if op.Response == nil || len(body) == 0 {
+		return res, nil
+	}
+
+	if method.Output().Name() == protoreflect.Name(methodName(op)+"Output") {
+		body = []byte(`{"` + itemsField + `":` + string(body) + `}`)
+	}
+	if err := unmarshalOptions.Unmarshal(body, res); err != nil {
+		log.Printf("gRPC %s: error converting response: %v\n", op.Id, err)
+		return nil, status.Errorf(codes.Internal, "error converting response: %v", err)
+	}
+
+	return res, nil
+}
+
+// the HTTP API can add response fields before clients regenerate their stubs
+var unmarshalOptions = protojson.UnmarshalOptions{DiscardUnknown: true}
+
+// serveStream sends each stream message as an event as soon as the handler writes it
+func serveStream(stream grpc.ServerStream, routes http.Handler, op shared.ApiOperation, method protoreflect.MethodDescriptor, in *dynamicpb.Message) error {
+	req, err := httpRequest(stream.Context(), op, in)
+	if err != nil {
+		return err
+	}
+
+	w := &responseWriter{
+		header: http.Header{},
+		onMessage: func(msg string) error {
+			event := dynamicpb.NewMessage(method.Output())
+			if err := unmarshalOptions.Unmarshal([]byte(msg), event); err != nil {
+				log.Printf("gRPC %s: error converting stream message: %v\n", op.Id, err)
+				return err
+			}
+			return stream.SendMsg(event)
+		},
+	}
+	routes.ServeHTTP(w, req)
+
+	return w.err()
+}
+
+// httpRequest builds the operation's HTTP request from the gRPC request's path parameter, query parameter, and body fields
+func httpRequest(ctx context.Context, op shared.ApiOperation, in *dynamicpb.Message) (*http.Request, error) {
+	fields := in.Descriptor().Fields()
+
+	var pathValues []string
+	for _, name := range op.PathParams() {
+		value := in.Get(fields.ByName(protoreflect.Name(protoFieldName(name)))).String()
+		if value == "" {
+			return nil, status.Errorf(codes.InvalidArgument, "%s is required", name)
+		}
+		pathValues = append(pathValues, url.PathEscape(value))
+	}
+
+	query := url.Values{}
+	for _, name := range op.Query {
+		list := in.Get(fields.ByName(protoreflect.Name(protoFieldName(name)))).List()
+		for i := 0; i < list.Len(); i++ {
+			query.Add(name, list.Get(i).String())
+		}
+	}
+
+	u := op.ResolvePath(pathValues...)
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var body []byte
+	if op.Request != nil {
+		var value any
+		if field := fields.ByName("body"); in.Has(field) {
+			value = jsonValue(field, in.Get(field))
+		}
+
+		var err error
+		body, err = json.Marshal(value)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "error encoding body: %v", err)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, op.Method, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "error creating request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// credentials are sent as "authorization" metadata, in the same "Bearer ..." form as the HTTP header
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if auth := md.Get("authorization"); len(auth) > 0 {
+			req.Header.Set("Authorization", auth[0])
+		}
+	}
+
+	return req, nil
+}
+
+// jsonValue converts a field's value to what encoding/json expects for the Go type it came from. protojson can't be used for this, since it writes 64-bit integers as strings.
+func jsonValue(field protoreflect.FieldDescriptor, v protoreflect.Value) any {
+	switch {
+	case field.IsList():
+		list := v.List()
+		res := make([]any, list.Len())
+		for i := range res {
+			res[i] = singularJsonValue(field, list.Get(i))
+		}
+		return res
+
+	case field.IsMap():
+		res := map[string]any{}
+		v.Map().Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+			res[k.String()] = singularJsonValue(field.MapValue(), v)
+			return true
+		})
+		return res
+	}
+
+	return singularJsonValue(field, v)
+}
+
+func singularJsonValue(field protoreflect.FieldDescriptor, v protoreflect.Value) any {
+	if field.Kind() != protoreflect.MessageKind {
+		// scalars are already the matching Go types, and []byte is written as base64 like protobuf's bytes
+		return v.Interface()
+	}
+
+	msg := v.Message()
+	switch msg.Descriptor().FullName() {
+	case "google.protobuf.Timestamp":
+		fields := msg.Descriptor().Fields()
+		seconds := msg.Get(fields.ByName("seconds")).Int()
+		nanos := msg.Get(fields.ByName("nanos")).Int()
+		return time.Unix(seconds, nanos).UTC()
+
+	case "google.protobuf.Value", "google.protobuf.Struct", "google.protobuf.ListValue":
+		// these are plain JSON, with no 64-bit integers
+		bytes, err := protojson.Marshal(msg.Interface())
+		if err != nil {
+			return nil
+		}
+		return json.RawMessage(bytes)
+	}
+
+	res := map[string]any{}
+	msg.Range(func(field protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		res[field.JSONName()] = jsonValue(field, v)
+		return true
+	})
+	return res
+}
+
+// responseWriter collects a handler's response. For streaming operations, complete messages are passed to onMessage as they're written, unless the handler responded with an error.
+type responseWriter struct {
+	header    http.Header
+	status    int
+	body      bytes.Buffer
+	onMessage func(msg string) error
+}
+
+func (w *responseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.body.Write(b)
+
+	if w.onMessage == nil || w.status >= 400 {
+		return len(b), nil
+	}
+
+	sep := []byte(shared.STREAM_MESSAGE_SEPARATOR)
+	for {
+		i := bytes.Index(w.body.Bytes(), sep)
+		if i == -1 {
+			break
+		}
+		msg := string(w.body.Next(i))
+		w.body.Next(len(sep))
+
+		if err := w.onMessage(msg); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(b), nil
+}
+
+// Flush is a no-op since messages are sent as they're written, but handlers check for it before streaming
+func (w *responseWriter) Flush() {}
+
+func (w *responseWriter) err() error {
+	if w.status < 400 {
+		return nil
+	}
+
+	msg := strings.TrimSpace(w.body.String())
+	if msg == "" {
+		msg = http.StatusText(w.status)
+	}
+
+	return status.Error(codeForHttpStatus(w.status), msg)
+}
+
+func codeForHttpStatus(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.Aborted
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	}
+
+	if httpStatus >= 500 {
+		return codes.Internal
+	}
+	return codes.FailedPrecondition
+}
@@ -0,0 +1,331 @@
+package grpcapi
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+var update = flag.Bool("update", false, "rewrite plandex.proto from shared.ApiOperations")
+
+func TestProtoIsUpToDate(t *testing.T) {
+	schema, err := BuildSchema(shared.ApiOperations)
+	if err != nil {
+		t.Fatalf("BuildSchema: %v", err)
+	}
+	generated := schema.Proto()
+
+	if *update {
+		err := os.WriteFile("plandex.proto", []byte(generated), 0644)
+		if err != nil {
+			t.Fatalf("writing plandex.proto: %v", err)
+		}
+		return
+	}
+
+	existing, err := os.ReadFile("plandex.proto")
+	if err != nil {
+		t.Fatalf("reading plandex.proto: %v", err)
+	}
+	if string(existing) != generated {
+		t.Fatal("plandex.proto is out of date with shared.ApiOperations. Regenerate it with: go test ./grpcapi -run TestProtoIsUpToDate -update")
+	}
+}
+
+func TestResponseWriterSplitsMessages(t *testing.T) {
+	var msgs []string
+	w := &responseWriter{
+		header: http.Header{},
+		onMessage: func(msg string) error {
+			msgs = append(msgs, msg)
+			return nil
+		},
+	}
+
+	sep := shared.STREAM_MESSAGE_SEPARATOR
+	writes := []string{
+		`{"a":1}` + sep + `{"b"`,
+		`:2}` + sep[:len(sep)/2],
+		sep[len(sep)/2:] + `{"c":3}` + sep + `{"d":4}` + sep,
+		`{"partial"`,
+	}
+	for _, s := range writes {
+		n, err := w.Write([]byte(s))
+		if err != nil || n != len(s) {
+			t.Fatalf("Write(%q) = %d, %v", s, n, err)
+		}
+	}
+
+	want := []string{`{"a":1}`, `{"b":2}`, `{"c":3}`, `{"d":4}`}
+	if fmt.Sprint(msgs) != fmt.Sprint(want) {
+		t.Errorf("expected messages %v, got %v", want, msgs)
+	}
+	if w.body.String() != `{"partial"` {
+		t.Errorf("expected the incomplete message to stay buffered, got %q", w.body.String())
+	}
+	if w.err() != nil {
+		t.Errorf("expected no error, got %v", w.err())
+	}
+}
+
+func TestResponseWriterErrorStatus(t *testing.T) {
+	called := false
+	w := &responseWriter{
+		header: http.Header{},
+		onMessage: func(msg string) error {
+			called = true
+			return nil
+		},
+	}
+
+	w.WriteHeader(http.StatusNotFound)
+	w.Write([]byte("plan not found" + shared.STREAM_MESSAGE_SEPARATOR))
+
+	if called {
+		t.Error("expected an error response not to be sent as stream messages")
+	}
+
+	st, _ := status.FromError(w.err())
+	if st.Code() != codes.NotFound || !strings.HasPrefix(st.Message(), "plan not found") {
+		t.Errorf("expected NotFound with the handler's message, got %v", w.err())
+	}
+}
+
+func TestCodeForHttpStatus(t *testing.T) {
+	tests := map[int]codes.Code{
+		http.StatusBadRequest:          codes.InvalidArgument,
+		http.StatusUnauthorized:        codes.Unauthenticated,
+		http.StatusForbidden:           codes.PermissionDenied,
+		http.StatusNotFound:            codes.NotFound,
+		http.StatusConflict:            codes.Aborted,
+		http.StatusTooManyRequests:     codes.ResourceExhausted,
+		http.StatusNotImplemented:      codes.Unimplemented,
+		http.StatusServiceUnavailable:  codes.Unavailable,
+		http.StatusInternalServerError: codes.Internal,
+		http.StatusBadGateway:          codes.Internal,
+		http.StatusUnprocessableEntity: codes.FailedPrecondition,
+	}
+
+	for httpStatus, want := range tests {
+		if got := codeForHttpStatus(httpStatus); got != want {
+			t.Errorf("codeForHttpStatus(%d) = %s, want %s", httpStatus, got, want)
+		}
+	}
+}
+
+type testBody struct {
+	Name      string    `json:"name"`
+	Count     int       `json:"count"`
+	Limit     *int      `json:"limit"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+var (
+	testGetOp = shared.ApiOperation{
+		Id:       "getThing",
+		Method:   http.MethodPost,
+		Path:     "/things/{thingId}",
+		Query:    []string{"tag"},
+		Request:  testBody{},
+		Response: testBody{},
+	}
+	testListOp = shared.ApiOperation{
+		Id:       "listThings",
+		Method:   http.MethodGet,
+		Path:     "/things",
+		Response: []*testBody{},
+	}
+	testStreamOp = shared.ApiOperation{
+		Id:        "watchThing",
+		Method:    http.MethodPatch,
+		Path:      "/things/{thingId}/watch",
+		Response:  testBody{},
+		Streaming: true,
+	}
+	testOps = []shared.ApiOperation{testGetOp, testListOp, testStreamOp}
+)
+
+// testRoutes stands in for the server's router, echoing what it receives
+func testRoutes() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/things/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "not signed in", http.StatusUnauthorized)
+			return
+		}
+
+		if strings.HasSuffix(r.URL.Path, "/watch") {
+			for i := 1; i <= 3; i++ {
+				fmt.Fprintf(w, `{"name":"event","count":%d}`+shared.STREAM_MESSAGE_SEPARATOR, i)
+				w.(http.Flusher).Flush()
+			}
+			return
+		}
+
+		var body testBody
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "bad body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		body.Name = strings.TrimPrefix(r.URL.Path, "/things/") + ":" + strings.Join(r.URL.Query()["tag"], ",") + ":" + body.Name
+		json.NewEncoder(w).Encode(body)
+	})
+
+	mux.HandleFunc("/things", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `[{"name":"a","count":1},{"name":"b","count":2,"unknownField":true}]`)
+	})
+
+	return mux
+}
+
+func dialTestServer(t *testing.T) (*grpc.ClientConn, *Schema) {
+	t.Helper()
+
+	schema, err := BuildSchema(testOps)
+	if err != nil {
+		t.Fatalf("BuildSchema: %v", err)
+	}
+
+	s, err := NewServer(testRoutes(), testOps)
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+
+	lis := bufconn.Listen(1 << 20)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dialing: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return conn, schema
+}
+
+func authContext() context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer token")
+}
+
+func TestUnaryCall(t *testing.T) {
+	conn, schema := dialTestServer(t)
+	method := schema.Method(testGetOp)
+
+	in := dynamicpb.NewMessage(method.Input())
+	err := protojson.Unmarshal([]byte(`{
+		"thingId": "t1",
+		"tag": ["x", "y"],
+		"body": {"name": "n", "count": 5000000000, "limit": 0, "createdAt": "2024-04-06T10:00:00Z"}
+	}`), in)
+	if err != nil {
+		t.Fatalf("building request: %v", err)
+	}
+
+	out := dynamicpb.NewMessage(method.Output())
+	err = conn.Invoke(authContext(), "/"+ServiceName+"/GetThing", in, out)
+	if err != nil {
+		t.Fatalf("Invoke: %v", err)
+	}
+
+	var res struct {
+		Name      string
+		Count     string
+		Limit     *int
+		CreatedAt string
+	}
+	bytes, _ := protojson.Marshal(out)
+	json.Unmarshal(bytes, &res)
+
+	if res.Name != "t1:x,y:n" {
+		t.Errorf("expected path, query, and body to reach the handler, got name %q", res.Name)
+	}
+	// protojson writes int64 as a string, but the handler has to get a number
+	if res.Count != "5000000000" {
+		t.Errorf("expected the 64-bit count to round trip, got %q", res.Count)
+	}
+	if res.Limit == nil || *res.Limit != 0 {
+		t.Errorf("expected an optional zero to be kept, got %v", res.Limit)
+	}
+	if res.CreatedAt != "2024-04-06T10:00:00Z" {
+		t.Errorf("expected the time to round trip, got %q", res.CreatedAt)
+	}
+
+	err = conn.Invoke(context.Background(), "/"+ServiceName+"/GetThing", in, out)
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected Unauthenticated without credentials, got %v", err)
+	}
+}
+
+func TestListCall(t *testing.T) {
+	conn, schema := dialTestServer(t)
+	method := schema.Method(testListOp)
+
+	out := dynamicpb.NewMessage(method.Output())
+	err := conn.Invoke(authContext(), "/"+ServiceName+"/ListThings", dynamicpb.NewMessage(method.Input()), out)
+	if err != nil {
+		t.Fatalf("Invoke: %v", err)
+	}
+
+	items := out.Get(method.Output().Fields().ByName(itemsField)).List()
+	if items.Len() != 2 {
+		t.Fatalf("expected the list to be wrapped in items, got %v", out)
+	}
+}
+
+func TestStreamCall(t *testing.T) {
+	conn, schema := dialTestServer(t)
+	method := schema.Method(testStreamOp)
+
+	stream, err := conn.NewStream(authContext(), &grpc.StreamDesc{ServerStreams: true}, "/"+ServiceName+"/WatchThing")
+	if err != nil {
+		t.Fatalf("NewStream: %v", err)
+	}
+
+	in := dynamicpb.NewMessage(method.Input())
+	in.Set(method.Input().Fields().ByName("thingId"), protoreflect.ValueOfString("t1"))
+	if err := stream.SendMsg(in); err != nil {
+		t.Fatalf("SendMsg: %v", err)
+	}
+	stream.CloseSend()
+
+	var counts []int64
+	for {
+		event := dynamicpb.NewMessage(method.Output())
+		err := stream.RecvMsg(event)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("RecvMsg: %v", err)
+		}
+		counts = append(counts, event.Get(method.Output().Fields().ByName("count")).Int())
+	}
+
+	if fmt.Sprint(counts) != "[1 2 3]" {
+		t.Errorf("expected 3 events in order, got %v", counts)
+	}
+}
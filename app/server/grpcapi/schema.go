@@ -0,0 +1,548 @@
+package grpcapi
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/plandex/plandex/shared"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	// registers the well-known types the schema imports
+	_ "google.golang.org/protobuf/types/known/emptypb"
+	_ "google.golang.org/protobuf/types/known/structpb"
+	_ "google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	protoPackage = "plandex.v1"
+
+	timestampProto = "google/protobuf/timestamp.proto"
+	structProto    = "google/protobuf/struct.proto"
+	emptyProto     = "google/protobuf/empty.proto"
+)
+
+// itemsField holds a list or map response, which protobuf can't use as a message on its own. Its JSON is the HTTP response wrapped in {"items": ...}.
+const itemsField = "items"
+
+// Schema is the typed gRPC service for the operations. Its messages are derived from the operations' Go types, the same way the OpenAPI document's schemas are, so the two transports can't drift apart.
+type Schema struct {
+	File    protoreflect.FileDescriptor
+	Service protoreflect.ServiceDescriptor
+
+	fileProto *descriptorpb.FileDescriptorProto
+	ops       []shared.ApiOperation
+}
+
+// BuildSchema builds the service for the operations. For each operation, the request message is named <Method>Input, with a string field for each path parameter, a repeated string field for each query parameter, and the HTTP request body in "body". The response message is the HTTP response's type, or <Method>Output with the response in "items" if it's a list or map.
+func BuildSchema(ops []shared.ApiOperation) (*Schema, error) {
+	b := &schemaBuilder{
+		file: &descriptorpb.FileDescriptorProto{
+			Name:    proto.String("plandex.proto"),
+			Package: proto.String(protoPackage),
+			Syntax:  proto.String("proto3"),
+		},
+		messages: map[string]reflect.Type{},
+		imports:  map[string]bool{},
+	}
+
+	service := &descriptorpb.ServiceDescriptorProto{Name: proto.String("Plandex")}
+	for _, op := range ops {
+		method, err := b.method(op)
+		if err != nil {
+			return nil, fmt.Errorf("operation %s: %v", op.Id, err)
+		}
+		service.Method = append(service.Method, method)
+	}
+	b.file.Service = []*descriptorpb.ServiceDescriptorProto{service}
+
+	for name := range b.imports {
+		b.file.Dependency = append(b.file.Dependency, name)
+	}
+	sort.Strings(b.file.Dependency)
+
+	file, err := protodesc.NewFile(b.file, protoregistry.GlobalFiles)
+	if err != nil {
+		return nil, fmt.Errorf("error building descriptors: %v", err)
+	}
+
+	return &Schema{
+		File:      file,
+		Service:   file.Services().Get(0),
+		fileProto: b.file,
+		ops:       ops,
+	}, nil
+}
+
+// Method returns the service's method for an operation
+func (s *Schema) Method(op shared.ApiOperation) protoreflect.MethodDescriptor {
+	return s.Service.Methods().ByName(protoreflect.Name(methodName(op)))
+}
+
+type schemaBuilder struct {
+	file     *descriptorpb.FileDescriptorProto
+	messages map[string]reflect.Type
+	imports  map[string]bool
+}
+
+func (b *schemaBuilder) method(op shared.ApiOperation) (*descriptorpb.MethodDescriptorProto, error) {
+	name := methodName(op)
+
+	params := &descriptorpb.DescriptorProto{Name: proto.String(name + "Input")}
+	if err := b.addMessage(params, nil); err != nil {
+		return nil, err
+	}
+	for _, param := range op.PathParams() {
+		params.Field = append(params.Field, scalarField(param, len(params.Field)+1, descriptorpb.FieldDescriptorProto_TYPE_STRING))
+	}
+	for _, param := range op.Query {
+		field := scalarField(param, len(params.Field)+1, descriptorpb.FieldDescriptorProto_TYPE_STRING)
+		field.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+		params.Field = append(params.Field, field)
+	}
+	if op.Request != nil {
+		err := b.addField(params, "body", "body", reflect.TypeOf(op.Request))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var output string
+	t := reflect.TypeOf(op.Response)
+	switch {
+	case t == nil:
+		output = ".google.protobuf.Empty"
+		b.imports[emptyProto] = true
+	case isMessageStruct(deref(t)):
+		var err error
+		output, err = b.messageFor(deref(t), "")
+		if err != nil {
+			return nil, err
+		}
+	default:
+		result := &descriptorpb.DescriptorProto{Name: proto.String(name + "Output")}
+		if err := b.addMessage(result, nil); err != nil {
+			return nil, err
+		}
+		if err := b.addField(result, itemsField, itemsField, t); err != nil {
+			return nil, err
+		}
+		output = "." + protoPackage + "." + result.GetName()
+	}
+
+	return &descriptorpb.MethodDescriptorProto{
+		Name:            proto.String(name),
+		InputType:       proto.String("." + protoPackage + "." + params.GetName()),
+		OutputType:      proto.String(output),
+		ServerStreaming: proto.Bool(op.Streaming),
+	}, nil
+}
+
+func (b *schemaBuilder) addMessage(msg *descriptorpb.DescriptorProto, t reflect.Type) error {
+	if existing, ok := b.messages[msg.GetName()]; ok && existing != t {
+		return fmt.Errorf("two types are named %s", msg.GetName())
+	}
+	b.messages[msg.GetName()] = t
+	b.file.MessageType = append(b.file.MessageType, msg)
+	return nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func deref(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+func isMessageStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t != timeType
+}
+
+// messageFor returns the full name of the message for a struct, adding it and the messages it refers to if they haven't been added yet. Anonymous structs are named after the field that holds them.
+func (b *schemaBuilder) messageFor(t reflect.Type, fallbackName string) (string, error) {
+	name := t.Name()
+	if name == "" {
+		name = fallbackName
+	}
+	fullName := "." + protoPackage + "." + name
+
+	if existing, ok := b.messages[name]; ok {
+		if existing != t {
+			return "", fmt.Errorf("two types are named %s", name)
+		}
+		return fullName, nil
+	}
+
+	msg := &descriptorpb.DescriptorProto{Name: proto.String(name)}
+	// added before its fields so recursive types refer back to it instead of looping
+	if err := b.addMessage(msg, t); err != nil {
+		return "", err
+	}
+
+	seen := map[string]bool{}
+	var addFields func(t reflect.Type) error
+	addFields = func(t reflect.Type) error {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			tag := field.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			jsonName, _, _ := strings.Cut(tag, ",")
+
+			// embedded structs without a name are flattened into the parent, like encoding/json does
+			if field.Anonymous && jsonName == "" && field.Type.Kind() == reflect.Struct {
+				if err := addFields(field.Type); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if jsonName == "" {
+				jsonName = field.Name
+			}
+			if seen[jsonName] {
+				continue
+			}
+			seen[jsonName] = true
+
+			if err := b.addField(msg, protoFieldName(jsonName), jsonName, field.Type); err != nil {
+				return fmt.Errorf("%s.%s: %v", name, field.Name, err)
+			}
+		}
+		return nil
+	}
+
+	if err := addFields(t); err != nil {
+		return "", err
+	}
+	return fullName, nil
+}
+
+// protoFieldName makes a JSON name into a valid field name. The field keeps the JSON name as its json_name.
+func protoFieldName(jsonName string) string {
+	var sb strings.Builder
+	for i, r := range jsonName {
+		if r == '_' || r < unicode.MaxASCII && (unicode.IsLetter(r) || i > 0 && unicode.IsDigit(r)) {
+			sb.WriteRune(r)
+		} else {
+			sb.WriteRune('_')
+		}
+	}
+	return sb.String()
+}
+
+func scalarField(name string, number int, typ descriptorpb.FieldDescriptorProto_Type) *descriptorpb.FieldDescriptorProto {
+	return &descriptorpb.FieldDescriptorProto{
+		Name:     proto.String(protoFieldName(name)),
+		JsonName: proto.String(name),
+		Number:   proto.Int32(int32(number)),
+		Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		Type:     typ.Enum(),
+	}
+}
+
+// addField adds a field for a Go type to msg. Pointers to scalars are proto3 optional fields, so a missing value can be told apart from a zero one. Lists of lists and maps of lists or maps, which protobuf can't type, use google.protobuf.ListValue and Struct, which have the same JSON.
+func (b *schemaBuilder) addField(msg *descriptorpb.DescriptorProto, name, jsonName string, t reflect.Type) error {
+	field := scalarField(jsonName, len(msg.Field)+1, descriptorpb.FieldDescriptorProto_TYPE_STRING)
+	field.Name = proto.String(name)
+
+	optional := t.Kind() == reflect.Pointer
+	t = deref(t)
+
+	switch {
+	case t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8, t.Kind() == reflect.Array:
+		field.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+		elem := deref(t.Elem())
+		if elem.Kind() == reflect.Slice && elem.Elem().Kind() != reflect.Uint8 || elem.Kind() == reflect.Map {
+			return b.setWellKnown(field, elem)
+		}
+		if err := b.setType(field, elem, msg.GetName()+exportedName(name)); err != nil {
+			return err
+		}
+
+	case t.Kind() == reflect.Map:
+		entry, err := b.mapEntry(msg.GetName(), name, t)
+		if err != nil {
+			return err
+		}
+		msg.NestedType = append(msg.NestedType, entry)
+		field.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+		field.Type = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+		field.TypeName = proto.String("." + protoPackage + "." + msg.GetName() + "." + entry.GetName())
+
+	default:
+		if err := b.setType(field, t, msg.GetName()+exportedName(name)); err != nil {
+			return err
+		}
+		if optional && field.GetType() != descriptorpb.FieldDescriptorProto_TYPE_MESSAGE {
+			field.Proto3Optional = proto.Bool(true)
+			field.OneofIndex = proto.Int32(int32(len(msg.OneofDecl)))
+			msg.OneofDecl = append(msg.OneofDecl, &descriptorpb.OneofDescriptorProto{Name: proto.String("_" + name)})
+		}
+	}
+
+	msg.Field = append(msg.Field, field)
+	return nil
+}
+
+func (b *schemaBuilder) mapEntry(parent, name string, t reflect.Type) (*descriptorpb.DescriptorProto, error) {
+	entry := &descriptorpb.DescriptorProto{
+		Name:    proto.String(exportedName(name) + "Entry"),
+		Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+	}
+
+	key := scalarField("key", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING)
+	if err := b.setType(key, t.Key(), ""); err != nil {
+		return nil, err
+	}
+	switch key.GetType() {
+	case descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, descriptorpb.FieldDescriptorProto_TYPE_BYTES,
+		descriptorpb.FieldDescriptorProto_TYPE_DOUBLE, descriptorpb.FieldDescriptorProto_TYPE_FLOAT:
+		return nil, fmt.Errorf("unsupported map key %s", t.Key())
+	}
+
+	value := scalarField("value", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING)
+	elem := deref(t.Elem())
+	if elem.Kind() == reflect.Slice && elem.Elem().Kind() != reflect.Uint8 || elem.Kind() == reflect.Map {
+		if err := b.setWellKnown(value, elem); err != nil {
+			return nil, err
+		}
+	} else if err := b.setType(value, elem, parent+exportedName(name)); err != nil {
+		return nil, err
+	}
+
+	entry.Field = []*descriptorpb.FieldDescriptorProto{key, value}
+	return entry, nil
+}
+
+func (b *schemaBuilder) setWellKnown(field *descriptorpb.FieldDescriptorProto, t reflect.Type) error {
+	field.Type = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+	b.imports[structProto] = true
+	if t.Kind() == reflect.Map {
+		if t.Key().Kind() != reflect.String {
+			return fmt.Errorf("unsupported nested map key %s", t.Key())
+		}
+		field.TypeName = proto.String(".google.protobuf.Struct")
+	} else {
+		field.TypeName = proto.String(".google.protobuf.ListValue")
+	}
+	return nil
+}
+
+// setType sets a singular field's type. anonymousName names the message for an anonymous struct.
+func (b *schemaBuilder) setType(field *descriptorpb.FieldDescriptorProto, t reflect.Type, anonymousName string) error {
+	t = deref(t)
+
+	var typ descriptorpb.FieldDescriptorProto_Type
+	switch t.Kind() {
+	case reflect.Bool:
+		typ = descriptorpb.FieldDescriptorProto_TYPE_BOOL
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		typ = descriptorpb.FieldDescriptorProto_TYPE_INT32
+	case reflect.Int, reflect.Int64:
+		typ = descriptorpb.FieldDescriptorProto_TYPE_INT64
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		typ = descriptorpb.FieldDescriptorProto_TYPE_UINT32
+	case reflect.Uint, reflect.Uint64:
+		typ = descriptorpb.FieldDescriptorProto_TYPE_UINT64
+	case reflect.Float32:
+		typ = descriptorpb.FieldDescriptorProto_TYPE_FLOAT
+	case reflect.Float64:
+		typ = descriptorpb.FieldDescriptorProto_TYPE_DOUBLE
+	case reflect.String:
+		typ = descriptorpb.FieldDescriptorProto_TYPE_STRING
+	case reflect.Slice:
+		// only []byte gets here, which encoding/json writes as base64 like protobuf's bytes
+		typ = descriptorpb.FieldDescriptorProto_TYPE_BYTES
+	case reflect.Interface:
+		typ = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+		field.TypeName = proto.String(".google.protobuf.Value")
+		b.imports[structProto] = true
+	case reflect.Struct:
+		typ = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+		if t == timeType {
+			field.TypeName = proto.String(".google.protobuf.Timestamp")
+			b.imports[timestampProto] = true
+			break
+		}
+		name, err := b.messageFor(t, anonymousName)
+		if err != nil {
+			return err
+		}
+		field.TypeName = proto.String(name)
+	default:
+		return fmt.Errorf("unsupported type %s", t)
+	}
+
+	field.Type = typ.Enum()
+	return nil
+}
+
+func exportedName(name string) string {
+	if name == "" {
+		return name
+	}
+	parts := strings.Split(name, "_")
+	for i, part := range parts {
+		if part != "" {
+			parts[i] = strings.ToUpper(part[:1]) + part[1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// Proto renders the schema as plandex.proto, for clients to generate their stubs from
+func (s *Schema) Proto() string {
+	var sb strings.Builder
+	f := s.fileProto
+
+	sb.WriteString(`// Code generated from shared.ApiOperations by grpcapi.BuildSchema. DO NOT EDIT.
+// Regenerate with: go test ./grpcapi -run TestProtoIsUpToDate -update
+//
+// The gRPC service served when the server is started with GRPC_PORT set. It mirrors
+// the HTTP endpoints described by /openapi.json and is served by the same handlers,
+// so messages have the same fields as the HTTP API's JSON:
+//
+//   - each method's <Method>Input has a string field for each path parameter, a
+//     repeated string field for each query parameter, and the request body in "body"
+//   - list and map responses are wrapped in a <Method>Output's "items" field
+//
+// Credentials go in the "authorization" metadata, in the same "Bearer ..." form as
+// the HTTP Authorization header. HTTP error statuses map to the matching gRPC codes.
+//
+// Field numbers follow the order of the Go struct fields, so compare this file when
+// upgrading the server.
+
+`)
+	fmt.Fprintf(&sb, "syntax = %q;\n\npackage %s;\n\n", f.GetSyntax(), f.GetPackage())
+	for _, dep := range f.Dependency {
+		fmt.Fprintf(&sb, "import %q;\n", dep)
+	}
+	if len(f.Dependency) > 0 {
+		sb.WriteString("\n")
+	}
+
+	for _, service := range f.Service {
+		fmt.Fprintf(&sb, "service %s {\n", service.GetName())
+		for i, method := range service.Method {
+			if i > 0 {
+				sb.WriteString("\n")
+			}
+			op := s.ops[i]
+			comment := op.Description
+			if comment == "" {
+				comment = op.Summary
+			}
+			for _, line := range commentLines(comment) {
+				fmt.Fprintf(&sb, "  // %s\n", line)
+			}
+			output := protoTypeName(method.GetOutputType())
+			if method.GetServerStreaming() {
+				output = "stream " + output
+			}
+			fmt.Fprintf(&sb, "  rpc %s(%s) returns (%s);\n", method.GetName(), protoTypeName(method.GetInputType()), output)
+		}
+		sb.WriteString("}\n")
+	}
+
+	for _, msg := range f.MessageType {
+		fmt.Fprintf(&sb, "\nmessage %s {\n", msg.GetName())
+		for _, field := range msg.Field {
+			fmt.Fprintf(&sb, "  %s\n", fieldDecl(msg, field))
+		}
+		sb.WriteString("}\n")
+	}
+
+	return sb.String()
+}
+
+func fieldDecl(msg *descriptorpb.DescriptorProto, field *descriptorpb.FieldDescriptorProto) string {
+	var decl string
+
+	typ := fieldTypeName(field)
+	for _, nested := range msg.NestedType {
+		if nested.GetOptions().GetMapEntry() && strings.HasSuffix(field.GetTypeName(), "."+nested.GetName()) {
+			typ = fmt.Sprintf("map<%s, %s>", fieldTypeName(nested.Field[0]), fieldTypeName(nested.Field[1]))
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(typ, "map<"):
+		decl = typ
+	case field.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REPEATED:
+		decl = "repeated " + typ
+	case field.GetProto3Optional():
+		decl = "optional " + typ
+	default:
+		decl = typ
+	}
+
+	decl += fmt.Sprintf(" %s = %d", field.GetName(), field.GetNumber())
+	if field.GetJsonName() != defaultJsonName(field.GetName()) {
+		decl += fmt.Sprintf(" [json_name = %q]", field.GetJsonName())
+	}
+	return decl + ";"
+}
+
+func fieldTypeName(field *descriptorpb.FieldDescriptorProto) string {
+	if field.GetType() == descriptorpb.FieldDescriptorProto_TYPE_MESSAGE {
+		return protoTypeName(field.GetTypeName())
+	}
+	return strings.ToLower(strings.TrimPrefix(field.GetType().String(), "TYPE_"))
+}
+
+func protoTypeName(fullName string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(fullName, "."+protoPackage+"."), ".")
+}
+
+// defaultJsonName is the JSON name protobuf gives a field if it isn't set, which drops underscores and capitalizes the letter after them
+func defaultJsonName(name string) string {
+	var sb strings.Builder
+	upper := false
+	for _, r := range name {
+		if r == '_' {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// commentLines wraps a comment at about 80 columns
+func commentLines(s string) []string {
+	var lines []string
+	var line string
+	for _, word := range strings.Fields(s) {
+		if line != "" && len(line)+1+len(word) > 80 {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -280,3 +280,19 @@ func authorizePlanArchive(w http.ResponseWriter, planId string, auth *types.Serv
 
 	return plan
 }
+
+func authorizePlanShare(w http.ResponseWriter, planId string, auth *types.ServerAuth) *db.Plan {
+	plan := authorizePlan(w, planId, auth)
+
+	if plan == nil {
+		return nil
+	}
+
+	if plan.OwnerId != auth.User.Id && !auth.HasPermission(types.PermissionManageAnyPlanShares) {
+		log.Println("User does not have permission to share plan")
+		http.Error(w, "User does not have permission to share plan", http.StatusForbidden)
+		return nil
+	}
+
+	return plan
+}
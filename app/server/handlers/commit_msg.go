@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"plandex-server/model"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func GenCommitMsgHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for GenCommitMsgHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	var req shared.GenCommitMsgRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Printf("Error decoding request body: %v\n", err)
+		http.Error(w, "Error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.ApiKey == "" && !model.MockMode() {
+		log.Println("API key is required")
+		http.Error(w, "API key is required", http.StatusBadRequest)
+		return
+	}
+
+	if strings.TrimSpace(req.Diff) == "" {
+		log.Println("Diff is required")
+		http.Error(w, "Diff is required", http.StatusBadRequest)
+		return
+	}
+
+	modelSet := req.ModelSet
+	if modelSet == nil {
+		modelSet = &shared.DefaultModelSet
+	}
+
+	commitMsg, err := model.GenCommitMsg(model.NewClientForRequest(req.ApiKey, nil), modelSet.CommitMsg, model.Meter{OrgId: auth.OrgId, UserId: auth.User.Id}, req.Diff, req.Conventional)
+	if writeBudgetError(w, err) {
+		return
+	}
+	if err != nil {
+		log.Printf("Error generating commit message: %v\n", err)
+		http.Error(w, "Error generating commit message: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, err := json.Marshal(shared.GenCommitMsgResponse{CommitMsg: commitMsg})
+	if err != nil {
+		log.Printf("Error marshalling response: %v\n", err)
+		http.Error(w, "Error marshalling response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully processed request for GenCommitMsgHandler")
+}
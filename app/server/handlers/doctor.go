@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"plandex-server/db"
+	"plandex-server/types"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// DoctorHandler runs the server side of 'plandex doctor'. Failed probes are reported as checks rather than errors so the cli can show all of them at once. With the planId and branch query params, the plan's storage is checked too.
+func DoctorHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for DoctorHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	res := shared.DoctorResponse{}
+
+	bytes, err := os.ReadFile("version.txt")
+	if err == nil {
+		res.Version = strings.TrimSpace(string(bytes))
+	}
+
+	// raw errors and paths describe the server itself, so on cloud only org admins see them
+	showDetails := os.Getenv("IS_CLOUD") == "" || auth.HasPermission(types.PermissionViewServerDiagnostics)
+	details := func(err error, summary string) string {
+		log.Printf("Doctor check: %s: %v\n", summary, err)
+		if showDetails {
+			return err.Error()
+		}
+		return summary
+	}
+
+	check := &shared.DoctorCheck{Name: "Database", Ok: true, Message: "connected"}
+	if err := db.PingDb(); err != nil {
+		check.Ok = false
+		check.Message = details(err, "the server can't reach its database")
+		check.Fix = "Check that the server's DATABASE_URL points to a running postgres instance"
+	}
+	res.Checks = append(res.Checks, check)
+
+	check = &shared.DoctorCheck{Name: "Server storage", Ok: true, Message: "writable"}
+	if showDetails {
+		check.Message = fmt.Sprintf("%s is writable", db.BaseDir)
+	}
+	if err := db.CheckBaseDirWritable(); err != nil {
+		check.Ok = false
+		check.Message = details(err, "the server's storage isn't writable")
+		check.Fix = "Make PLANDEX_BASE_DIR writable by the server, or point it to a dir that is"
+	}
+	res.Checks = append(res.Checks, check)
+
+	gitVersion, err := db.GitVersion()
+	check = &shared.DoctorCheck{Name: "Server git", Ok: true, Message: gitVersion}
+	if err != nil {
+		check.Ok = false
+		check.Message = details(err, "git isn't working on the server")
+		check.Fix = "Install git on the server's host and make sure it's on the PATH"
+	}
+	res.Checks = append(res.Checks, check)
+
+	planId := r.URL.Query().Get("planId")
+	branch := r.URL.Query().Get("branch")
+
+	if planId != "" {
+		if branch == "" {
+			branch = "main"
+		}
+		res.Checks = append(res.Checks, doctorPlanCheck(auth.User.Id, auth.OrgId, planId, branch, details))
+	}
+
+	bytes, err = json.Marshal(res)
+	if err != nil {
+		log.Printf("Error marshalling response: %v\n", err)
+		http.Error(w, "Error marshalling response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully processed request for DoctorHandler")
+}
+
+func doctorPlanCheck(userId, orgId, planId, branch string, details func(err error, summary string) string) *shared.DoctorCheck {
+	check := &shared.DoctorCheck{Name: "Plan storage"}
+
+	plan, err := db.ValidatePlanAccess(planId, userId, orgId)
+	if err != nil {
+		check.Message = details(fmt.Errorf("error validating plan access: %v", err), "error validating plan access")
+		return check
+	}
+	if plan == nil {
+		check.Message = "the current plan doesn't exist or you don't have access to it"
+		check.Fix = "Run 'plandex plans' and 'plandex cd' to switch to a plan you can access"
+		return check
+	}
+
+	err = db.CheckPlanRepo(orgId, planId, branch)
+	if err != nil {
+		check.Message = details(err, "the plan's files on the server are incomplete")
+		check.Fix = "The plan's files on the server are incomplete. Start a new plan with 'plandex new', or restore PLANDEX_BASE_DIR from a backup."
+		return check
+	}
+
+	check.Ok = true
+	check.Message = fmt.Sprintf("%s (%s) is intact", plan.Name, branch)
+	return check
+}
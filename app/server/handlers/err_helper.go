@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"plandex-server/model"
 
 	"github.com/plandex/plandex/shared"
 )
@@ -27,3 +28,14 @@ func writeApiError(w http.ResponseWriter, apiErr shared.ApiError) {
 		log.Printf("Error writing response: %v\n", writeErr)
 	}
 }
+
+// writeBudgetError writes err for the client if it's a *shared.QuotaExceededError or *shared.BudgetExceededError from a metered model call, and returns whether it was
+func writeBudgetError(w http.ResponseWriter, err error) bool {
+	apiErr := model.BudgetApiError(err)
+	if apiErr == nil {
+		return false
+	}
+
+	writeApiError(w, *apiErr)
+	return true
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"plandex-server/model"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func CheckModelsHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for CheckModelsHandler")
+
+	auth := authenticate(w, r, false)
+	if auth == nil {
+		return
+	}
+
+	var req shared.CheckModelsRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Printf("Error decoding request body: %v\n", err)
+		http.Error(w, "Error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.ApiKey == "" {
+		log.Println("API key is required")
+		http.Error(w, "API key is required", http.StatusBadRequest)
+		return
+	}
+
+	res := model.CheckModels(req.ApiKey, req.ModelSet)
+
+	bytes, err := json.Marshal(res)
+	if err != nil {
+		log.Printf("Error marshalling response: %v\n", err)
+		http.Error(w, "Error marshalling response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully processed request for CheckModelsHandler")
+}
@@ -6,6 +6,7 @@ import (
 	"log"
 	"net/http"
 	"plandex-server/db"
+	"plandex-server/notify"
 	"plandex-server/types"
 
 	"github.com/plandex/plandex/shared"
@@ -212,3 +213,157 @@ func ListOrgRolesHandler(w http.ResponseWriter, r *http.Request) {
 
 	w.Write(bytes)
 }
+
+func GetOrgSlackSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for GetOrgSlackSettingsHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	org, err := db.GetOrg(auth.OrgId)
+	if err != nil {
+		log.Printf("Error getting org: %v\n", err)
+		http.Error(w, "Error getting org: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, err := json.Marshal(shared.OrgSlackSettings{
+		Enabled:        org.SlackWebhookUrl != nil,
+		IncludePrompts: org.SlackIncludePrompts,
+	})
+	if err != nil {
+		log.Printf("Error marshalling response: %v\n", err)
+		http.Error(w, "Error marshalling response: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Println("Successfully got org slack settings")
+
+	w.Write(bytes)
+}
+
+func UpdateOrgSlackSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for UpdateOrgSlackSettingsHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	if !auth.HasPermission(types.PermissionManageIntegrations) {
+		log.Println("User does not have permission to manage integrations")
+		http.Error(w, "User does not have permission to manage integrations", http.StatusForbidden)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("Error reading request body: %v\n", err)
+		http.Error(w, "Error reading request body: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var req shared.UpdateOrgSlackSettingsRequest
+	err = json.Unmarshal(body, &req)
+	if err != nil {
+		log.Printf("Error unmarshalling request: %v\n", err)
+		http.Error(w, "Error unmarshalling request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var webhookUrl *string
+	if req.WebhookUrl != "" {
+		err = notify.ValidateSlackWebhookUrl(req.WebhookUrl)
+		if err != nil {
+			log.Printf("Invalid slack webhook url: %v\n", err)
+			http.Error(w, "Invalid slack webhook url: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		webhookUrl = &req.WebhookUrl
+	}
+
+	err = db.SetOrgSlackSettings(auth.OrgId, webhookUrl, req.IncludePrompts)
+	if err != nil {
+		log.Printf("Error updating org slack settings: %v\n", err)
+		http.Error(w, "Error updating org slack settings: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Println("Successfully updated org slack settings")
+}
+
+func GetOrgBudgetHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for GetOrgBudgetHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	org, err := db.GetOrg(auth.OrgId)
+	if err != nil {
+		log.Printf("Error getting org: %v\n", err)
+		http.Error(w, "Error getting org: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, err := json.Marshal(shared.OrgBudget{
+		MaxDailyCost: org.MaxDailyCost,
+	})
+	if err != nil {
+		log.Printf("Error marshalling response: %v\n", err)
+		http.Error(w, "Error marshalling response: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Println("Successfully got org budget")
+
+	w.Write(bytes)
+}
+
+func UpdateOrgBudgetHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for UpdateOrgBudgetHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	if !auth.HasPermission(types.PermissionManageBilling) {
+		log.Println("User does not have permission to manage billing")
+		http.Error(w, "User does not have permission to manage billing", http.StatusForbidden)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("Error reading request body: %v\n", err)
+		http.Error(w, "Error reading request body: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var req shared.UpdateOrgBudgetRequest
+	err = json.Unmarshal(body, &req)
+	if err != nil {
+		log.Printf("Error unmarshalling request: %v\n", err)
+		http.Error(w, "Error unmarshalling request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.MaxDailyCost != nil && *req.MaxDailyCost <= 0 {
+		log.Println("Invalid max daily cost")
+		http.Error(w, "max-daily-cost must be greater than 0", http.StatusBadRequest)
+		return
+	}
+
+	err = db.SetOrgMaxDailyCost(auth.OrgId, req.MaxDailyCost)
+	if err != nil {
+		log.Printf("Error updating org budget: %v\n", err)
+		http.Error(w, "Error updating org budget: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Println("Successfully updated org budget")
+}
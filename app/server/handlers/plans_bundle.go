@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"plandex-server/db"
+	"plandex-server/types"
+
+	"github.com/gorilla/mux"
+	"github.com/plandex/plandex/shared"
+)
+
+func ExportPlanBundleHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for ExportPlanBundleHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	vars := mux.Vars(r)
+	planId := vars["planId"]
+
+	log.Println("planId: ", planId)
+
+	plan := authorizePlan(w, planId, auth)
+	if plan == nil {
+		return
+	}
+
+	var err error
+	ctx, cancel := context.WithCancel(context.Background())
+	unlockFn := lockRepo(w, r, auth, db.LockScopeRead, ctx, cancel, false)
+	if unlockFn == nil {
+		return
+	} else {
+		defer func() {
+			(*unlockFn)(err)
+		}()
+	}
+
+	bundle, err := db.ExportPlanBundle(plan)
+
+	if err != nil {
+		log.Printf("Error exporting plan: %v\n", err)
+		http.Error(w, "Error exporting plan: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, err := json.Marshal(bundle)
+
+	if err != nil {
+		log.Printf("Error marshalling bundle: %v\n", err)
+		http.Error(w, "Error marshalling bundle: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully processed request for ExportPlanBundleHandler")
+}
+
+// bundles are sent as json, so the git bundle inside is base64 encoded
+const maxPlanBundleBytes = 256 << 20
+
+func ImportPlanBundleHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for ImportPlanBundleHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	if !auth.HasPermission(types.PermissionCreatePlan) {
+		log.Println("User does not have permission to create a plan")
+		http.Error(w, "User does not have permission to create a plan", http.StatusForbidden)
+		return
+	}
+
+	vars := mux.Vars(r)
+	projectId := vars["projectId"]
+
+	log.Println("projectId: ", projectId)
+
+	if !authorizeProject(w, projectId, auth) {
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPlanBundleBytes))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			log.Printf("Plan bundle is larger than %d bytes\n", maxPlanBundleBytes)
+			http.Error(w, fmt.Sprintf("Plan bundle is larger than the %d MB limit", maxPlanBundleBytes>>20), http.StatusRequestEntityTooLarge)
+			return
+		}
+		log.Printf("Error reading request body: %v\n", err)
+		http.Error(w, "Error reading request body", http.StatusInternalServerError)
+		return
+	}
+	defer r.Body.Close()
+
+	var requestBody shared.ImportPlanRequest
+	if err := json.Unmarshal(body, &requestBody); err != nil {
+		log.Printf("Error parsing request body: %v\n", err)
+		http.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+
+	if requestBody.Bundle == nil {
+		http.Error(w, "No bundle to import", http.StatusBadRequest)
+		return
+	}
+
+	name := requestBody.Name
+	if name == "" {
+		name = requestBody.Bundle.Name
+	}
+	if name == "" || name == "draft" {
+		name = "imported"
+	}
+
+	name, err = db.GetUniquePlanName(projectId, auth.User.Id, name)
+
+	if err != nil {
+		log.Printf("Error checking if plan exists: %v\n", err)
+		http.Error(w, "Error checking if plan exists: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	plan, err := db.ImportPlanBundle(auth.OrgId, projectId, auth.User.Id, name, requestBody.Bundle)
+
+	if err != nil {
+		log.Printf("Error importing plan: %v\n", err)
+		http.Error(w, "Error importing plan: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := shared.CreatePlanResponse{
+		Id:   plan.Id,
+		Name: plan.Name,
+	}
+
+	bytes, err := json.Marshal(resp)
+
+	if err != nil {
+		log.Printf("Error marshalling response: %v\n", err)
+		http.Error(w, "Error marshalling response: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Printf("Successfully imported plan: %v\n", plan)
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"plandex-server/db"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+)
+
+// only the end of a command's output is added to the conversation, since that's usually where errors and results are
+const maxCommandOutputChars = 8000
+
+func RecordCommandResultHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for RecordCommandResultHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	vars := mux.Vars(r)
+	planId := vars["planId"]
+	branch := vars["branch"]
+
+	log.Println("planId: ", planId, "branch: ", branch)
+
+	if authorizePlan(w, planId, auth) == nil {
+		return
+	}
+
+	var req shared.RecordCommandResultRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Printf("Error decoding request: %v\n", err)
+		http.Error(w, "Error decoding request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	unlockFn := lockRepo(w, r, auth, db.LockScopeWrite, ctx, cancel, true)
+	if unlockFn == nil {
+		return
+	} else {
+		defer func() {
+			(*unlockFn)(err)
+		}()
+	}
+
+	descs, err := db.GetConvoMessageDescriptions(auth.OrgId, planId)
+	if err != nil {
+		log.Printf("Error getting descriptions: %v\n", err)
+		http.Error(w, "Error getting descriptions: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var desc *db.ConvoMessageDescription
+	for _, d := range descs {
+		if d.ConvoMessageId == req.ConvoMessageId {
+			desc = d
+			break
+		}
+	}
+
+	if desc == nil || req.CommandIndex < 0 || req.CommandIndex >= len(desc.Commands) {
+		log.Println("Command not found")
+		http.Error(w, "Command not found", http.StatusNotFound)
+		return
+	}
+
+	command := desc.Commands[req.CommandIndex]
+	now := time.Now()
+	command.RanAt = &now
+	command.ExitCode = req.ExitCode
+
+	err = db.StoreDescription(desc)
+	if err != nil {
+		log.Printf("Error storing description: %v\n", err)
+		http.Error(w, "Error storing description: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	convo, err := db.GetPlanConvo(auth.OrgId, planId)
+	if err != nil {
+		log.Printf("Error getting plan convo: %v\n", err)
+		http.Error(w, "Error getting plan convo: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	content := formatCommandResult(command.Command, req.ExitCode, req.Output)
+
+	numTokens, err := shared.GetNumTokens(content)
+	if err != nil {
+		log.Printf("Error counting tokens: %v\n", err)
+		http.Error(w, "Error counting tokens: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	msg := &db.ConvoMessage{
+		OrgId:   auth.OrgId,
+		PlanId:  planId,
+		UserId:  auth.User.Id,
+		Role:    openai.ChatMessageRoleUser,
+		Tokens:  numTokens,
+		Num:     len(convo) + 1,
+		Message: content,
+	}
+
+	_, err = db.StoreConvoMessage(msg, auth.User.Id, branch, false)
+	if err != nil {
+		log.Printf("Error storing convo message: %v\n", err)
+		http.Error(w, "Error storing convo message: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = db.GitAddAndCommit(auth.OrgId, planId, branch, fmt.Sprintf("Message #%d | 💻 Ran `%s` | exit code %d | %d 🪙", msg.Num, command.Command, req.ExitCode, numTokens))
+	if err != nil {
+		log.Printf("Error committing command result: %v\n", err)
+		http.Error(w, "Error committing command result: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Println("Successfully recorded command result")
+}
+
+func formatCommandResult(command string, exitCode int, output string) string {
+	output = strings.TrimSpace(output)
+	truncated := false
+	if len(output) > maxCommandOutputChars {
+		output = strings.ToValidUTF8(output[len(output)-maxCommandOutputChars:], "")
+		truncated = true
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "I ran this command you suggested:\n\n```\n%s\n```\n\n", command)
+
+	if exitCode == 0 {
+		b.WriteString("It succeeded.")
+	} else {
+		fmt.Fprintf(&b, "It failed with exit code %d.", exitCode)
+	}
+
+	if output == "" {
+		b.WriteString(" It didn't print any output.")
+		return b.String()
+	}
+
+	if truncated {
+		b.WriteString(" Here's the end of its output:")
+	} else {
+		b.WriteString(" Its output:")
+	}
+	fmt.Fprintf(&b, "\n\n```\n%s\n```", output)
+
+	return b.String()
+}
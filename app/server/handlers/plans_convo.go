@@ -8,6 +8,7 @@ import (
 	"plandex-server/db"
 
 	"github.com/gorilla/mux"
+	"github.com/plandex/plandex/shared"
 )
 
 func ListConvoHandler(w http.ResponseWriter, r *http.Request) {
@@ -57,3 +58,56 @@ func ListConvoHandler(w http.ResponseWriter, r *http.Request) {
 	w.Write(bytes)
 
 }
+
+func ListConvoMessagesHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received a request for ListConvoMessagesHandler")
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	vars := mux.Vars(r)
+	planId := vars["planId"]
+
+	log.Println("planId: ", planId)
+
+	q, err := shared.ParseHistoryQuery(r.URL.Query())
+	if err != nil {
+		log.Println("Error parsing query: ", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if authorizePlan(w, planId, auth) == nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	unlockFn := lockRepo(w, r, auth, db.LockScopeRead, ctx, cancel, true)
+	if unlockFn == nil {
+		return
+	} else {
+		defer func() {
+			(*unlockFn)(err)
+		}()
+	}
+
+	page, err := db.ListConvoPage(auth.OrgId, planId, q)
+
+	if err != nil {
+		log.Println("Error getting plan convo: ", err)
+		http.Error(w, "Error getting plan convo: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, err := json.Marshal(page)
+
+	if err != nil {
+		log.Println("Error marshalling plan convo: ", err)
+		http.Error(w, "Error marshalling plan convo: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Println("Successfully processed request for ListConvoMessagesHandler")
+	w.Write(bytes)
+}
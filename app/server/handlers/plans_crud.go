@@ -95,24 +95,12 @@ func CreatePlanHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	} else {
-		i := 2
-		originalName := name
-		for {
-			var count int
-			err := db.Conn.Get(&count, "SELECT COUNT(*) FROM plans WHERE project_id = $1 AND owner_id = $2 AND name = $3", projectId, auth.User.Id, name)
-
-			if err != nil {
-				log.Printf("Error checking if plan exists: %v\n", err)
-				http.Error(w, "Error checking if plan exists: "+err.Error(), http.StatusInternalServerError)
-				return
-			}
-
-			if count == 0 {
-				break
-			}
+		name, err = db.GetUniquePlanName(projectId, auth.User.Id, name)
 
-			name = originalName + "." + fmt.Sprint(i)
-			i++
+		if err != nil {
+			log.Printf("Error checking if plan exists: %v\n", err)
+			http.Error(w, "Error checking if plan exists: "+err.Error(), http.StatusInternalServerError)
+			return
 		}
 	}
 
@@ -281,7 +269,7 @@ func ListPlansHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	plans, err := db.ListOwnedPlans(projectIds, auth.User.Id, false)
+	plans, err := db.ListAccessiblePlans(projectIds, auth.User.Id)
 
 	if err != nil {
 		log.Printf("Error listing plans: %v\n", err)
@@ -534,7 +522,7 @@ func GetCurrentBranchByPlanIdHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	plans, err := db.ListOwnedPlans([]string{projectId}, auth.User.Id, false)
+	plans, err := db.ListAccessiblePlans([]string{projectId}, auth.User.Id)
 
 	if err != nil {
 		log.Printf("Error listing plans: %v\n", err)
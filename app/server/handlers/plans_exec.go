@@ -16,10 +16,14 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
 )
 
 const TrialMaxReplies = 10
 
+// how long answers to clarifying questions wait for the plan to take them. The plan is already waiting when it asks, so this only runs out if it stopped in the meantime.
+const clarifySendTimeout = 5 * time.Second
+
 func TellPlanHandler(w http.ResponseWriter, r *http.Request) {
 	log.Println("Received request for TellPlanHandler", "ip:", host.Ip)
 
@@ -57,9 +61,18 @@ func TellPlanHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if requestBody.ApiKey == "" {
-		log.Println("API key is required")
-		http.Error(w, "API key is required", http.StatusBadRequest)
+	if requestBody.Ephemeral && !requestBody.ChatOnly {
+		http.Error(w, "Only chat replies can be ephemeral", http.StatusBadRequest)
+		return
+	}
+
+	if requestBody.ReplaceLastPrompt && (requestBody.Ephemeral || requestBody.IsUserContinue) {
+		http.Error(w, "Only new prompts that are added to the conversation can replace the last prompt", http.StatusBadRequest)
+		return
+	}
+
+	client := getModelClient(w, requestBody.ApiKey, requestBody.Mock)
+	if client == nil {
 		return
 	}
 
@@ -87,7 +100,6 @@ func TellPlanHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	client := model.NewClient(requestBody.ApiKey)
 	err = modelPlan.Tell(client, plan, branch, auth, &requestBody)
 
 	if err != nil {
@@ -138,14 +150,12 @@ func BuildPlanHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if requestBody.ApiKey == "" {
-		log.Println("API key is required")
-		http.Error(w, "API key is required", http.StatusBadRequest)
+	client := getModelClient(w, requestBody.ApiKey, requestBody.Mock)
+	if client == nil {
 		return
 	}
 
-	client := model.NewClient(requestBody.ApiKey)
-	numBuilds, err := modelPlan.Build(client, plan, branch, auth)
+	numBuilds, err := modelPlan.Build(client, plan, branch, auth, requestBody.ModelSet)
 
 	if err != nil {
 		log.Printf("Error building plan: %v\n", err)
@@ -365,3 +375,90 @@ func authorizePlanExecUpdate(w http.ResponseWriter, planId string, auth *types.S
 
 	return plan
 }
+
+// getModelClient returns a client for the provider, or a simulated one for requests that ask for it. It writes an error and returns nil if the request can't be served.
+func getModelClient(w http.ResponseWriter, apiKey string, mock *shared.MockConfig) *openai.Client {
+	if mock != nil && !model.MockAllowed() {
+		log.Println("Mock model requested but not allowed")
+		http.Error(w, "This server doesn't allow mock model calls. Start it with --mock or --allow-mock", http.StatusBadRequest)
+		return nil
+	}
+
+	if apiKey == "" && mock == nil && !model.MockMode() {
+		log.Println("API key is required")
+		http.Error(w, "API key is required", http.StatusBadRequest)
+		return nil
+	}
+
+	return model.NewClientForRequest(apiKey, mock)
+}
+
+func RespondClarifyHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for RespondClarifyHandler", "ip:", host.Ip)
+
+	vars := mux.Vars(r)
+	planId := vars["planId"]
+	branch := vars["branch"]
+	log.Println("planId: ", planId)
+	log.Println("branch: ", branch)
+	isProxy := r.URL.Query().Get("proxy") == "true"
+
+	active := modelPlan.GetActivePlan(planId, branch)
+	if active == nil {
+		if isProxy {
+			log.Println("No active plan on proxied request")
+			http.Error(w, "No active plan", http.StatusNotFound)
+			return
+		}
+
+		proxyActivePlanMethod(w, r, planId, branch, "respond_clarify")
+		return
+	}
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	plan := authorizePlan(w, planId, auth)
+	if plan == nil {
+		return
+	}
+
+	if len(active.ClarifyingQuestions) == 0 {
+		log.Println("Plan isn't waiting for answers")
+		http.Error(w, "Plan isn't waiting for answers", http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("Error reading request body: %v\n", err)
+		http.Error(w, "Error reading request body", http.StatusInternalServerError)
+		return
+	}
+	defer r.Body.Close()
+
+	var requestBody shared.RespondClarifyRequest
+	if err := json.Unmarshal(body, &requestBody); err != nil {
+		log.Printf("Error parsing request body: %v\n", err)
+		http.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+
+	// This will resume the plan with the answers. The plan can stop or time out while the request is in flight, so the send doesn't block forever.
+	log.Println("Resuming plan with answers")
+	select {
+	case active.ClarifyResponseCh <- requestBody.Answers:
+	case <-active.Ctx.Done():
+		log.Println("Plan stopped before the answers were received")
+		http.Error(w, "Plan stopped before the answers were received", http.StatusConflict)
+		return
+	case <-time.After(clarifySendTimeout):
+		log.Println("Plan didn't take the answers")
+		http.Error(w, "Plan isn't waiting for answers", http.StatusConflict)
+		return
+	}
+
+	log.Println("Successfully processed request for RespondClarifyHandler")
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"plandex-server/db"
+
+	"github.com/gorilla/mux"
+	"github.com/plandex/plandex/shared"
+)
+
+func SharePlanHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for SharePlanHandler")
+	setPlanShared(w, r, true)
+}
+
+func UnsharePlanHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for UnsharePlanHandler")
+	setPlanShared(w, r, false)
+}
+
+func setPlanShared(w http.ResponseWriter, r *http.Request, share bool) {
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	vars := mux.Vars(r)
+	planId := vars["planId"]
+
+	log.Println("planId: ", planId)
+
+	plan := authorizePlanShare(w, planId, auth)
+	if plan == nil {
+		return
+	}
+
+	err := db.SetPlanSharedWithOrg(planId, share)
+
+	if err != nil {
+		log.Printf("Error updating plan sharing: %v\n", err)
+		http.Error(w, "Error updating plan sharing: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Successfully set plan %s shared: %v\n", planId, share)
+}
+
+func ListSharedPlansHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for ListSharedPlansHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	plans, err := db.ListSharedPlans(auth.OrgId, auth.User.Id)
+
+	if err != nil {
+		log.Printf("Error listing shared plans: %v\n", err)
+		http.Error(w, "Error listing shared plans: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	apiPlans := []*shared.Plan{}
+	for _, plan := range plans {
+		apiPlans = append(apiPlans, plan.ToApi())
+	}
+
+	bytes, err := json.Marshal(apiPlans)
+
+	if err != nil {
+		log.Printf("Error marshalling plans: %v\n", err)
+		http.Error(w, "Error marshalling plans: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+}
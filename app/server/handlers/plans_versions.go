@@ -67,6 +67,64 @@ func ListLogsHandler(w http.ResponseWriter, r *http.Request) {
 	log.Println("Successfully processed request for ListLogsHandler")
 }
 
+func ListPlanEventsHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for ListPlanEventsHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	vars := mux.Vars(r)
+	planId := vars["planId"]
+
+	log.Println("planId: ", planId)
+
+	q, err := shared.ParseHistoryQuery(r.URL.Query())
+	if err != nil {
+		log.Println("Error parsing query: ", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if q.Role != "" {
+		http.Error(w, "role only filters the conversation, not events", http.StatusBadRequest)
+		return
+	}
+
+	if authorizePlan(w, planId, auth) == nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	unlockFn := lockRepo(w, r, auth, db.LockScopeRead, ctx, cancel, true)
+	if unlockFn == nil {
+		return
+	} else {
+		defer func() {
+			(*unlockFn)(err)
+		}()
+	}
+
+	page, err := db.ListPlanEventsPage(auth.OrgId, planId, q)
+
+	if err != nil {
+		log.Println("Error getting events: ", err)
+		http.Error(w, "Error getting events: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, err := json.Marshal(page)
+
+	if err != nil {
+		log.Println("Error marshalling events: ", err)
+		http.Error(w, "Error marshalling events: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+}
+
 func RewindPlanHandler(w http.ResponseWriter, r *http.Request) {
 	log.Println("Received request for RewindPlanHandler")
 
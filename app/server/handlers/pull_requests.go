@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"plandex-server/db"
+	"plandex-server/model"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/plandex/plandex/shared"
+)
+
+func GenPullRequestHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for GenPullRequestHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	var req shared.GenPullRequestRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Printf("Error decoding request body: %v\n", err)
+		http.Error(w, "Error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.ApiKey == "" && !model.MockMode() {
+		log.Println("API key is required")
+		http.Error(w, "API key is required", http.StatusBadRequest)
+		return
+	}
+
+	if strings.TrimSpace(req.Diff) == "" {
+		log.Println("Diff is required")
+		http.Error(w, "Diff is required", http.StatusBadRequest)
+		return
+	}
+
+	modelSet := req.ModelSet
+	if modelSet == nil {
+		modelSet = &shared.DefaultModelSet
+	}
+
+	res, err := model.GenPullRequest(model.NewClientForRequest(req.ApiKey, nil), modelSet.CommitMsg, model.Meter{OrgId: auth.OrgId, UserId: auth.User.Id}, req)
+	if writeBudgetError(w, err) {
+		return
+	}
+	if err != nil {
+		log.Printf("Error generating pull request: %v\n", err)
+		http.Error(w, "Error generating pull request: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, err := json.Marshal(shared.GenPullRequestResponse{Title: res.Title, Body: res.Body})
+	if err != nil {
+		log.Printf("Error marshalling response: %v\n", err)
+		http.Error(w, "Error marshalling response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully processed request for GenPullRequestHandler")
+}
+
+func ListPlanPullRequestsHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for ListPlanPullRequestsHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	planId := mux.Vars(r)["planId"]
+	if authorizePlan(w, planId, auth) == nil {
+		return
+	}
+
+	prs, err := db.ListPlanPullRequests(planId)
+	if err != nil {
+		log.Printf("Error listing pull requests: %v\n", err)
+		http.Error(w, "Error listing pull requests: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	apiPrs := []*shared.PlanPullRequest{}
+	for _, pr := range prs {
+		apiPrs = append(apiPrs, pr.ToApi())
+	}
+
+	bytes, err := json.Marshal(apiPrs)
+	if err != nil {
+		log.Printf("Error marshalling pull requests: %v\n", err)
+		http.Error(w, "Error marshalling pull requests: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully processed request for ListPlanPullRequestsHandler")
+}
+
+func CreatePlanPullRequestHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for CreatePlanPullRequestHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	planId := mux.Vars(r)["planId"]
+	plan := authorizePlan(w, planId, auth)
+	if plan == nil {
+		return
+	}
+
+	var req shared.CreatePlanPullRequestRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Printf("Error decoding request body: %v\n", err)
+		http.Error(w, "Error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.Url == "" || req.GitBranch == "" {
+		log.Println("Pull request url and git branch are required")
+		http.Error(w, "Pull request url and git branch are required", http.StatusBadRequest)
+		return
+	}
+
+	pr := &db.PlanPullRequest{
+		OrgId:     auth.OrgId,
+		PlanId:    plan.Id,
+		UserId:    auth.User.Id,
+		Url:       req.Url,
+		Number:    req.Number,
+		GitBranch: req.GitBranch,
+		Base:      req.Base,
+	}
+	err = db.CreatePlanPullRequest(pr)
+	if err != nil {
+		log.Printf("Error storing pull request: %v\n", err)
+		http.Error(w, "Error storing pull request: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, err := json.Marshal(pr.ToApi())
+	if err != nil {
+		log.Printf("Error marshalling pull request: %v\n", err)
+		http.Error(w, "Error marshalling pull request: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully processed request for CreatePlanPullRequestHandler")
+}
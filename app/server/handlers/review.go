@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+	"plandex-server/db"
+	"plandex-server/model"
+	"plandex-server/types"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func ReviewHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for ReviewHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	var req shared.ReviewRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Printf("Error decoding request body: %v\n", err)
+		http.Error(w, "Error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.ApiKey == "" && !model.MockMode() {
+		log.Println("API key is required")
+		http.Error(w, "API key is required", http.StatusBadRequest)
+		return
+	}
+
+	if strings.TrimSpace(req.Diff) == "" {
+		log.Println("Diff is required")
+		http.Error(w, "Diff is required", http.StatusBadRequest)
+		return
+	}
+
+	modelSet := req.ModelSet
+	if modelSet == nil {
+		modelSet = &shared.DefaultModelSet
+	}
+
+	var related map[string]string
+	if req.PlanId != "" {
+		if authorizePlan(w, req.PlanId, auth) == nil {
+			return
+		}
+
+		related, err = getReviewPlanFiles(auth, req.PlanId, req.Branch, req.ProjectPrefix, req.Files)
+		if err != nil {
+			log.Printf("Error getting plan context for review: %v\n", err)
+			http.Error(w, "Error getting plan context: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	findings, err := model.Review(model.NewClientForRequest(req.ApiKey, nil), modelSet.GetReviewerRoleConfig(), model.Meter{OrgId: auth.OrgId, UserId: auth.User.Id, PlanId: req.PlanId, Branch: req.Branch}, req.Diff, req.Files, related)
+	if writeBudgetError(w, err) {
+		return
+	}
+	if err != nil {
+		log.Printf("Error reviewing changes: %v\n", err)
+		http.Error(w, "Error reviewing changes: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, err := json.Marshal(shared.ReviewResponse{Findings: findings})
+	if err != nil {
+		log.Printf("Error marshalling response: %v\n", err)
+		http.Error(w, "Error marshalling response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully processed request for ReviewHandler")
+}
+
+// getReviewPlanFiles returns the plan's file context by path relative to the repo root, like the review's changed files, skipping files that are already in the review as changed files
+func getReviewPlanFiles(auth *types.ServerAuth, planId, branch, projectPrefix string, changed map[string]string) (map[string]string, error) {
+	if branch == "" {
+		branch = "main"
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	repoLockId, err := db.LockRepo(
+		db.LockRepoParams{
+			OrgId:    auth.OrgId,
+			UserId:   auth.User.Id,
+			PlanId:   planId,
+			Branch:   branch,
+			Scope:    db.LockScopeRead,
+			Ctx:      ctx,
+			CancelFn: cancel,
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error locking repo: %v", err)
+	}
+
+	defer func() {
+		err := db.UnlockRepo(repoLockId)
+		if err != nil {
+			log.Printf("Error unlocking repo: %v\n", err)
+		}
+	}()
+
+	contexts, err := db.GetPlanContexts(auth.OrgId, planId, true)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string]string{}
+	for _, dbContext := range contexts {
+		if dbContext.ContextType != shared.ContextFileType || dbContext.FilePath == "" {
+			continue
+		}
+		repoPath := path.Join(projectPrefix, dbContext.FilePath)
+		if _, ok := changed[repoPath]; ok {
+			continue
+		}
+		files[repoPath] = dbContext.Body
+	}
+
+	return files, nil
+}
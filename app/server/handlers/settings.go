@@ -44,7 +44,8 @@ func GetSettingsHandler(w http.ResponseWriter, r *http.Request) {
 		}()
 	}
 
-	settings, err := db.GetPlanSettings(plan, true)
+	// leave the model set empty if the plan doesn't have one so the client can fall back to its own default
+	settings, err := db.GetPlanSettings(plan, false)
 
 	if err != nil {
 		log.Println("Error getting settings: ", err)
@@ -169,7 +170,15 @@ func getUpdateCommitMsg(settings *shared.PlanSettings, originalSettings *shared.
 
 	// log.Println("Changes to settings:", strings.Join(changes, "\n"))
 
-	s := "⚙️  Updated model settings:"
+	label := "model settings"
+	if !reflect.DeepEqual(originalSettings.Policy, settings.Policy) {
+		label = "plan policy"
+		if !reflect.DeepEqual(originalSettings.ModelOverrides, settings.ModelOverrides) || !reflect.DeepEqual(originalSettings.ModelSet, settings.ModelSet) {
+			label = "plan settings"
+		}
+	}
+
+	s := "⚙️  Updated " + label + ":"
 
 	for _, change := range changes {
 		s += "\n" + "  • " + change
@@ -146,6 +146,10 @@ func initConnectActive(auth *types.ServerAuth, planId, branch string, w http.Res
 		msg.MissingFilePath = active.MissingFilePath
 	}
 
+	if len(active.ClarifyingQuestions) > 0 {
+		msg.ClarifyingQuestions = active.ClarifyingQuestions
+	}
+
 	bytes, err := json.Marshal(msg)
 
 	if err != nil {
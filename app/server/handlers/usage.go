@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"plandex-server/db"
+	"plandex-server/model"
+	"plandex-server/types"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/plandex/plandex/shared"
+)
+
+// GetPlanUsageHandler returns the usage the server has recorded for a plan, from every user and machine that has worked on it, oldest first. Records from before the since param are left out.
+func GetPlanUsageHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received a request for GetPlanUsageHandler")
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	planId := mux.Vars(r)["planId"]
+	if authorizePlan(w, planId, auth) == nil {
+		return
+	}
+
+	var since time.Time
+	if s := r.URL.Query().Get("since"); s != "" {
+		var err error
+		since, err = time.Parse(time.RFC3339, s)
+		if err != nil {
+			log.Printf("Invalid since param: %v\n", err)
+			http.Error(w, "Invalid since param: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	records, err := db.GetPlanUsage(auth.OrgId, planId)
+	if err != nil {
+		log.Printf("Error getting plan usage: %v\n", err)
+		http.Error(w, "Error getting plan usage: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := shared.PlanUsageResponse{Records: []*shared.UsageRecord{}}
+	for _, record := range records {
+		if !record.CreatedAt.Before(since) {
+			resp.Records = append(resp.Records, record.ToApi())
+		}
+	}
+
+	bytes, err := json.Marshal(resp)
+	if err != nil {
+		log.Printf("Error marshalling plan usage: %v\n", err)
+		http.Error(w, "Error marshalling plan usage: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Println("Successfully processed request for GetPlanUsageHandler")
+
+	w.Write(bytes)
+}
+
+func GetOrgUsageHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received a request for GetOrgUsageHandler")
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	// defaults to the start of the month, like the quota
+	now := time.Now().UTC()
+	since := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if s := r.URL.Query().Get("since"); s != "" {
+		var err error
+		since, err = time.Parse(time.RFC3339, s)
+		if err != nil {
+			log.Printf("Invalid since param: %v\n", err)
+			http.Error(w, "Invalid since param: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	records, err := db.GetOrgUsage(auth.OrgId, since)
+	if err != nil {
+		log.Printf("Error getting org usage: %v\n", err)
+		http.Error(w, "Error getting org usage: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// users who can't manage billing only see what they've used themselves
+	allUsers := auth.HasPermission(types.PermissionManageBilling)
+
+	resp := shared.OrgUsageResponse{
+		Records:           []*shared.UsageRecord{},
+		MonthlyTokenQuota: model.GetOrgMonthlyTokenQuota(),
+	}
+	for _, record := range records {
+		if allUsers || record.UserId == auth.User.Id {
+			resp.Records = append(resp.Records, record.ToApi())
+		}
+	}
+
+	resp.MonthTokens, _, err = db.GetOrgMonthTokens(auth.OrgId)
+	if err != nil {
+		log.Printf("Error getting org month tokens: %v\n", err)
+		http.Error(w, "Error getting org month tokens: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, err := json.Marshal(resp)
+	if err != nil {
+		log.Printf("Error marshalling org usage: %v\n", err)
+		http.Error(w, "Error marshalling org usage: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Println("Successfully processed request for GetOrgUsageHandler")
+
+	w.Write(bytes)
+}
@@ -1,21 +1,32 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"plandex-server/db"
+	"plandex-server/grpcapi"
 	"plandex-server/host"
+	"plandex-server/model"
 	"plandex-server/model/plan"
 	"syscall"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/plandex/plandex/shared"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 func main() {
+	mock := flag.Bool("mock", false, "simulate all model calls instead of calling a provider")
+	allowMock := flag.Bool("allow-mock", false, "let clients ask for simulated model calls with --mock, e.g. for tests against this server")
+	flag.Parse()
+
 	err := host.LoadIp()
 	if err != nil {
 		log.Fatal("Error loading IP: ", err)
@@ -31,6 +42,25 @@ func main() {
 		log.Fatal("Error running migrations: ", err)
 	}
 
+	err = model.LoadRetryPolicies()
+	if err != nil {
+		log.Fatal("Error loading retry policies: ", err)
+	}
+
+	err = model.LoadHttpConfig()
+	if err != nil {
+		log.Fatal("Error loading provider http config: ", err)
+	}
+
+	if *mock {
+		err = model.EnableMock()
+		if err != nil {
+			log.Fatal("Error enabling mock mode: ", err)
+		}
+	} else if *allowMock {
+		model.AllowClientMock()
+	}
+
 	if os.Getenv("GOENV") == "development" {
 		log.Println("In development mode.")
 	}
@@ -41,15 +71,47 @@ func main() {
 		externalPort = "8088"
 	}
 
-	go startServer(externalPort, routes())
+	// api keys are normally sent by the client, but if one is configured for the server, check it up front
+	if apiKey := os.Getenv("OPENAI_API_KEY"); apiKey != "" {
+		go func() {
+			res := model.CheckModels(apiKey, nil)
+			for _, msg := range res.Errors {
+				log.Println("Model check:", msg)
+			}
+			if len(res.Errors) == 0 {
+				log.Printf("Model check: api key and default models ok for %s\n", res.BaseUrl)
+			}
+		}()
+	}
+
+	r := routes()
+
+	go startServer(externalPort, r)
 	log.Println("Started server on port " + externalPort)
 
+	// the gRPC transport is optional, for services that embed plandex and prefer gRPC to HTTP
+	var grpcServer *grpc.Server
+	if grpcPort := os.Getenv("GRPC_PORT"); grpcPort != "" {
+		grpcServer = newGrpcServer(r)
+		go startGrpcServer(grpcPort, grpcServer)
+		log.Println("Started gRPC server on port " + grpcPort)
+	}
+
 	sigTermChan := make(chan os.Signal, 1)
 	signal.Notify(sigTermChan, syscall.SIGTERM)
 
 	go func() {
 		<-sigTermChan
 
+		// stop taking new gRPC calls, and let the ones in progress finish along with the active plans
+		grpcStopped := make(chan struct{})
+		go func() {
+			if grpcServer != nil {
+				grpcServer.GracefulStop()
+			}
+			close(grpcStopped)
+		}()
+
 		for {
 			l := plan.NumActivePlans()
 			if l == 0 {
@@ -59,6 +121,13 @@ func main() {
 			time.Sleep(1 * time.Second)
 		}
 
+		select {
+		case <-grpcStopped:
+		case <-time.After(10 * time.Second):
+			log.Println("Closing gRPC calls that are still running")
+			grpcServer.Stop()
+		}
+
 		os.Exit(0)
 	}()
 
@@ -71,3 +140,40 @@ func startServer(port string, routes *mux.Router) {
 		log.Fatalf("Failed to start server on port %s: %v", port, err)
 	}
 }
+
+// newGrpcServer serves the gRPC API over TLS if GRPC_TLS_CERT_FILE and GRPC_TLS_KEY_FILE are set, and in plain text otherwise, like when it's behind a proxy that terminates TLS
+func newGrpcServer(routes *mux.Router) *grpc.Server {
+	var opts []grpc.ServerOption
+
+	certFile := os.Getenv("GRPC_TLS_CERT_FILE")
+	keyFile := os.Getenv("GRPC_TLS_KEY_FILE")
+	if certFile != "" || keyFile != "" {
+		if certFile == "" || keyFile == "" {
+			log.Fatal("GRPC_TLS_CERT_FILE and GRPC_TLS_KEY_FILE must be set together")
+		}
+
+		creds, err := credentials.NewServerTLSFromFile(certFile, keyFile)
+		if err != nil {
+			log.Fatalf("Failed to load gRPC TLS certificate: %v", err)
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+
+	s, err := grpcapi.NewServer(routes, shared.ApiOperations, opts...)
+	if err != nil {
+		log.Fatalf("Failed to create gRPC server: %v", err)
+	}
+	return s
+}
+
+func startGrpcServer(port string, s *grpc.Server) {
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%s", port))
+	if err != nil {
+		log.Fatalf("Failed to listen on gRPC port %s: %v", port, err)
+	}
+
+	err = s.Serve(lis)
+	if err != nil {
+		log.Fatalf("Failed to start gRPC server on port %s: %v", port, err)
+	}
+}
@@ -0,0 +1,4 @@
+DELETE FROM permissions WHERE name = 'manage_integrations';
+
+ALTER TABLE orgs DROP COLUMN slack_include_prompts;
+ALTER TABLE orgs DROP COLUMN slack_webhook_url;
@@ -0,0 +1,15 @@
+ALTER TABLE orgs ADD COLUMN slack_webhook_url TEXT;
+ALTER TABLE orgs ADD COLUMN slack_include_prompts BOOLEAN NOT NULL DEFAULT FALSE;
+
+INSERT INTO permissions (name, description, resource_id) VALUES
+  ('manage_integrations', 'Configure an org''s integrations, like Slack notifications', NULL);
+
+INSERT INTO org_roles_permissions (org_role_id, permission_id)
+SELECT 
+    r.id AS org_role_id, 
+    p.id AS permission_id
+FROM
+    org_roles r, permissions p
+WHERE 
+    r.org_id IS NULL AND r.name IN ('owner', 'admin')
+    AND p.name = 'manage_integrations';
@@ -0,0 +1 @@
+ALTER TABLE orgs DROP COLUMN max_daily_cost;
@@ -0,0 +1 @@
+ALTER TABLE orgs ADD COLUMN max_daily_cost DOUBLE PRECISION;
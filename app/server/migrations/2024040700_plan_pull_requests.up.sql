@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS plan_pull_requests (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+  plan_id UUID NOT NULL REFERENCES plans(id) ON DELETE CASCADE,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  url TEXT NOT NULL,
+  number INTEGER NOT NULL,
+  git_branch VARCHAR(255) NOT NULL,
+  base VARCHAR(255) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX plan_pull_requests_plan_idx ON plan_pull_requests(plan_id);
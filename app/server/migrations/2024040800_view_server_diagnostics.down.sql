@@ -0,0 +1 @@
+DELETE FROM permissions WHERE name = 'view_server_diagnostics';
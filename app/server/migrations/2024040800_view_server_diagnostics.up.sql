@@ -0,0 +1,12 @@
+INSERT INTO permissions (name, description, resource_id) VALUES
+  ('view_server_diagnostics', 'See the server''s own errors and paths in doctor checks', NULL);
+
+INSERT INTO org_roles_permissions (org_role_id, permission_id)
+SELECT 
+    r.id AS org_role_id, 
+    p.id AS permission_id
+FROM
+    org_roles r, permissions p
+WHERE 
+    r.org_id IS NULL AND r.name IN ('owner', 'admin')
+    AND p.name = 'view_server_diagnostics';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS usage_totals;
+DROP TABLE IF EXISTS usage_records;
@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS usage_records (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+  -- not a foreign key, since an org's records are kept when its plans are deleted
+  plan_id UUID,
+  branch VARCHAR(255) NOT NULL DEFAULT '',
+  user_id UUID,
+  prompt_tokens INTEGER NOT NULL,
+  completion_tokens INTEGER NOT NULL,
+  provider_reported BOOLEAN NOT NULL,
+  phase VARCHAR(255) NOT NULL DEFAULT '',
+  model_name VARCHAR(255) NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX usage_records_org_created_idx ON usage_records(org_id, created_at);
+CREATE INDEX usage_records_plan_created_idx ON usage_records(plan_id, created_at);
+
+-- running totals per org for each month and day (UTC), updated in the same transaction as each record so quota and budget checks see usage from every server
+CREATE TABLE IF NOT EXISTS usage_totals (
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+  period VARCHAR(16) NOT NULL,
+  period_start TIMESTAMP NOT NULL,
+  prompt_tokens BIGINT NOT NULL DEFAULT 0,
+  completion_tokens BIGINT NOT NULL DEFAULT 0,
+  cost DOUBLE PRECISION NOT NULL DEFAULT 0,
+  unpriced BOOLEAN NOT NULL DEFAULT FALSE,
+  PRIMARY KEY (org_id, period, period_start)
+);
@@ -0,0 +1,115 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"plandex-server/db"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// GetBudgetStatus returns the plan's estimated spending against its budget, and the org's spending today (UTC) against its daily budget. planId is empty for calls that aren't part of a plan, which are only held to the daily budget. It returns nil if neither budget is set.
+func GetBudgetStatus(orgId, planId string, settings *shared.PlanSettings) (*shared.BudgetStatus, error) {
+	org, err := db.GetOrg(orgId)
+	if err != nil {
+		return nil, err
+	}
+
+	var maxPlanCost *float64
+	if planId != "" && settings != nil {
+		maxPlanCost = settings.Policy.MaxPlanCost
+	}
+
+	if maxPlanCost == nil && org.MaxDailyCost == nil {
+		return nil, nil
+	}
+
+	status := &shared.BudgetStatus{
+		MaxPlanCost:  maxPlanCost,
+		MaxDailyCost: org.MaxDailyCost,
+	}
+
+	if status.MaxPlanCost != nil {
+		records, err := db.GetPlanUsage(orgId, planId)
+		if err != nil {
+			return nil, err
+		}
+
+		var planTotal shared.UsageTotal
+		for _, record := range records {
+			planTotal.Add(record.ModelUsage)
+		}
+		status.PlanSpent = planTotal.Cost
+	}
+
+	if status.MaxDailyCost != nil {
+		dayTotal, err := db.GetOrgDayUsage(orgId)
+		if err != nil {
+			return nil, err
+		}
+		status.DailySpent = dayTotal.Cost
+	}
+
+	return status, nil
+}
+
+// CheckBudget is called before every model call. It returns a *shared.QuotaExceededError if the org has used up the server's monthly quota, or a *shared.BudgetExceededError if the org has reached its daily budget or the meter's plan has reached its budget. Calls already in flight are allowed to finish, so spending can run a little past a budget.
+func CheckBudget(meter Meter) error {
+	err := CheckOrgQuota(meter.OrgId)
+	if err != nil {
+		return err
+	}
+
+	settings := meter.Settings
+	if meter.PlanId != "" && settings == nil {
+		plan, err := db.GetPlan(meter.PlanId)
+		if err != nil {
+			return err
+		}
+		settings, err = db.GetPlanSettings(plan, false)
+		if err != nil {
+			return fmt.Errorf("error getting plan settings: %v", err)
+		}
+	}
+
+	status, err := GetBudgetStatus(meter.OrgId, meter.PlanId, settings)
+	if err != nil {
+		return err
+	}
+
+	if status == nil {
+		return nil
+	}
+
+	if exceeded := status.Exceeded(); exceeded != nil {
+		return exceeded
+	}
+	return nil
+}
+
+// BudgetApiError converts an error from CheckBudget into an error for the client, or returns nil if it isn't a budget or quota error
+func BudgetApiError(err error) *shared.ApiError {
+	var budgetErr *shared.BudgetExceededError
+	var quotaErr *shared.QuotaExceededError
+
+	if errors.As(err, &budgetErr) {
+		return &shared.ApiError{
+			Type:                shared.ApiErrorTypeBudgetExceeded,
+			Status:              http.StatusForbidden,
+			Msg:                 "Budget exceeded: " + budgetErr.Error(),
+			BudgetExceededError: budgetErr,
+		}
+	}
+
+	if errors.As(err, &quotaErr) {
+		return &shared.ApiError{
+			Type:               shared.ApiErrorTypeQuotaExceeded,
+			Status:             http.StatusForbidden,
+			Msg:                "Quota exceeded: " + quotaErr.Error(),
+			QuotaExceededError: quotaErr,
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+)
+
+const checkModelsTimeout = 20 * time.Second
+
+// CheckModels validates the api key and model set with cheap calls so that problems show up before the first real prompt
+func CheckModels(apiKey string, modelSet *shared.ModelSet) *shared.CheckModelsResponse {
+	if modelSet == nil {
+		modelSet = &shared.DefaultModelSet
+	}
+
+	config := openai.DefaultConfig(apiKey)
+	config.HTTPClient = providerHttpClient
+	client := openai.NewClientWithConfig(config)
+
+	res := &shared.CheckModelsResponse{
+		Provider: shared.ModelProviderOpenAI,
+		BaseUrl:  config.BaseURL,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), checkModelsTimeout)
+	defer cancel()
+
+	modelsList, err := client.ListModels(ctx)
+	if err != nil {
+		log.Printf("Error listing models: %v\n", err)
+		res.Errors = append(res.Errors, describeCheckErr(err, config.BaseURL))
+		return res
+	}
+
+	res.ApiKeyValid = true
+
+	availableIds := map[string]bool{}
+	for _, m := range modelsList.Models {
+		availableIds[m.ID] = true
+	}
+
+	roleModels := []struct {
+		role      shared.ModelRole
+		modelName string
+	}{
+		{shared.ModelRolePlanner, modelSet.Planner.BaseModelConfig.ModelName},
+		{shared.ModelRolePlanSummary, modelSet.PlanSummary.BaseModelConfig.ModelName},
+		{shared.ModelRoleBuilder, modelSet.Builder.BaseModelConfig.ModelName},
+		{shared.ModelRoleName, modelSet.Namer.BaseModelConfig.ModelName},
+		{shared.ModelRoleCommitMsg, modelSet.CommitMsg.BaseModelConfig.ModelName},
+		{shared.ModelRoleExecStatus, modelSet.ExecStatus.BaseModelConfig.ModelName},
+		{shared.ModelRoleChat, modelSet.GetChatRoleConfig().BaseModelConfig.ModelName},
+		{shared.ModelRoleReviewer, modelSet.GetReviewerRoleConfig().BaseModelConfig.ModelName},
+	}
+
+	var cheapestAvailable string
+	for _, rm := range roleModels {
+		available := availableIds[rm.modelName]
+
+		res.Models = append(res.Models, shared.ModelAvailability{
+			Role:      rm.role,
+			ModelName: rm.modelName,
+			Available: available,
+		})
+
+		if !available {
+			res.Errors = append(res.Errors, fmt.Sprintf("model %s (%s role) isn't available for this api key--pick another with 'plandex set-model %s'", rm.modelName, rm.role, rm.role))
+		} else if cheapestAvailable == "" || shared.ModelPricingByName[rm.modelName].InputPerMillion < shared.ModelPricingByName[cheapestAvailable].InputPerMillion {
+			cheapestAvailable = rm.modelName
+		}
+	}
+
+	if cheapestAvailable == "" {
+		return res
+	}
+
+	// a one token completion is the cheapest way to find out whether the account has quota left
+	_, err = client.CreateChatCompletion(ctx, AdaptRequestToModel(openai.ChatCompletionRequest{
+		Model:     cheapestAvailable,
+		MaxTokens: 1,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleUser, Content: "hi"},
+		},
+	}))
+
+	if err != nil {
+		log.Printf("Error checking quota: %v\n", err)
+		res.Errors = append(res.Errors, describeCheckErr(err, config.BaseURL))
+		return res
+	}
+
+	res.QuotaOk = true
+
+	return res
+}
+
+func describeCheckErr(err error, baseUrl string) string {
+	errStr := err.Error()
+
+	switch {
+	case strings.Contains(errStr, "status code: 401"):
+		return "the api key was rejected--check OPENAI_API_KEY or generate a new key at https://platform.openai.com/api-keys"
+	case strings.Contains(errStr, "status code: 429") && strings.Contains(errStr, "exceeded your current quota"):
+		return "the account is out of quota--add credits at https://platform.openai.com/account/billing"
+	case strings.Contains(errStr, "status code: 429"):
+		return "the api key is rate limited right now--wait a bit and try again"
+	case strings.Contains(errStr, "context deadline exceeded"):
+		return fmt.Sprintf("timed out connecting to %s", baseUrl)
+	case strings.Contains(errStr, "status code:"):
+		return fmt.Sprintf("%s returned an error: %v", baseUrl, err)
+	}
+
+	return fmt.Sprintf("couldn't reach %s: %v", baseUrl, err)
+}
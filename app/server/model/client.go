@@ -13,6 +13,7 @@ const OPENAI_STREAM_CHUNK_TIMEOUT = time.Duration(30) * time.Second
 
 func NewClient(apiKey string) *openai.Client {
 	config := openai.DefaultConfig(apiKey)
+	config.HTTPClient = providerHttpClient
 	return openai.NewClientWithConfig(config)
 }
 
@@ -39,17 +40,12 @@ func createChatCompletionStream(
 	if err != nil {
 		log.Printf("Error creating chat completion stream: %v, retry: %d\n", err, numRetry)
 
-		if isNonRetriableErr(err) {
-			return nil, err
-		}
-
-		// for retriable errors, retry with exponential backoff
-		if numRetry < 5 {
-			waitBackoff(numRetry)
+		policy := getRetryPolicy(req.Model)
+		if policy.shouldRetry(err, numRetry) {
+			policy.waitBackoff(numRetry)
 			return createChatCompletionStream(client, ctx, req, numRetry+1)
 		}
 
-		log.Println("Max retries reached - no retry")
 		return nil, err
 	}
 
@@ -80,17 +76,12 @@ func createChatCompletion(
 	if err != nil {
 		log.Printf("Error creating chat completion: %v, retry: %d\n", err, numRetry)
 
-		if isNonRetriableErr(err) {
-			return openai.ChatCompletionResponse{}, err
-		}
-
-		// for retriable errors, retry with exponential backoff
-		if numRetry < 5 {
-			waitBackoff(numRetry)
+		policy := getRetryPolicy(req.Model)
+		if policy.shouldRetry(err, numRetry) {
+			policy.waitBackoff(numRetry)
 			return createChatCompletion(client, ctx, req, numRetry+1)
 		}
 
-		log.Println("Max retries reached - no retry")
 		return openai.ChatCompletionResponse{}, err
 	}
 
@@ -124,9 +115,3 @@ func isNonRetriableErr(err error) bool {
 
 	return false
 }
-
-func waitBackoff(numRetry int) {
-	d := time.Duration(1<<uint(numRetry)) * time.Second
-	log.Printf("Retrying in %v\n", d)
-	time.Sleep(d)
-}
@@ -0,0 +1,117 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"plandex-server/model/prompts"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+)
+
+// room left in the model's context for the prompt and the reply
+const commitMsgReservedTokens = 2000
+
+func GenCommitMsg(client *openai.Client, config shared.TaskRoleConfig, meter Meter, diff string, conventional bool) (string, error) {
+	diff, err := truncateDiffForModel(config.BaseModelConfig, diff, config.BaseModelConfig.MaxTokens-commitMsgReservedTokens)
+	if err != nil {
+		return "", err
+	}
+
+	messages := []openai.ChatCompletionMessage{
+		{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: prompts.GetCommitMsgSysPrompt(conventional),
+		},
+		{
+			Role:    openai.ChatMessageRoleUser,
+			Content: prompts.GetCommitMsgPrompt(diff),
+		},
+	}
+
+	meter.Phase = shared.UsagePhaseCommitMsg
+	resp, err := CreateMeteredChatCompletion(
+		client,
+		context.Background(),
+		meter,
+		openai.ChatCompletionRequest{
+			Model: config.BaseModelConfig.ModelName,
+			Tools: []openai.Tool{
+				{
+					Type:     "function",
+					Function: &prompts.CommitMsgFn,
+				},
+			},
+			ToolChoice: openai.ToolChoice{
+				Type: "function",
+				Function: openai.ToolFunction{
+					Name: prompts.CommitMsgFn.Name,
+				},
+			},
+			Temperature:    config.Temperature,
+			TopP:           config.TopP,
+			Messages:       messages,
+			ResponseFormat: config.OpenAIResponseFormat,
+		},
+	)
+
+	if err != nil {
+		fmt.Printf("Error during commit message model call: %v\n", err)
+		return "", err
+	}
+
+	var res string
+	for _, choice := range resp.Choices {
+		if len(choice.Message.ToolCalls) == 1 &&
+			choice.Message.ToolCalls[0].Function.Name == prompts.CommitMsgFn.Name {
+			res = choice.Message.ToolCalls[0].Function.Arguments
+			break
+		}
+	}
+
+	if res == "" {
+		return "", fmt.Errorf("no writeCommitMsg function call found in response")
+	}
+
+	var commitMsgRes prompts.CommitMsgRes
+	err = json.Unmarshal([]byte(res), &commitMsgRes)
+	if err != nil {
+		return "", fmt.Errorf("error unmarshalling commit message response: %v", err)
+	}
+
+	return commitMsgRes.CommitMsg, nil
+}
+
+// truncateDiffForModel cuts a diff that's too large for the model down to roughly maxTokens. The start of a large diff is usually enough to write a good summary. It cuts at the end of a line, or at a character boundary if the first line alone is too long, so a multi-byte character is never split.
+func truncateDiffForModel(model shared.BaseModelConfig, diff string, maxTokens int) (string, error) {
+	numTokens, err := shared.GetNumTokensForModel(model, diff)
+	if err != nil {
+		return "", fmt.Errorf("error counting diff tokens: %v", err)
+	}
+
+	if numTokens <= maxTokens || maxTokens <= 0 {
+		return diff, nil
+	}
+
+	numBytes := int(float64(len(diff)) * float64(maxTokens) / float64(numTokens))
+	return truncateAtBoundary(diff, numBytes) + "\n... (diff truncated)", nil
+}
+
+// truncateAtBoundary cuts s to at most n bytes, at the end of the last whole line that fits, or at the last whole character if no line does
+func truncateAtBoundary(s string, n int) string {
+	if n >= len(s) {
+		return s
+	}
+
+	if i := strings.LastIndexByte(s[:n], '\n'); i > 0 {
+		return s[:i]
+	}
+
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateAtBoundary(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		n    int
+		want string
+	}{
+		{"fits", "a\nb\n", 10, "a\nb\n"},
+		{"cuts at the last whole line", "+first\n+second line\n", 12, "+first"},
+		{"multi-byte line", "+héllo\n+wörld\n", 12, "+héllo"},
+		{"one long line cuts between characters", "+日本語", 5, "+日"},
+		{"nothing fits", "日本", 2, ""},
+	}
+
+	for _, tt := range tests {
+		got := truncateAtBoundary(tt.s, tt.n)
+		if got != tt.want {
+			t.Errorf("%s: truncateAtBoundary(%q, %d) = %q, want %q", tt.name, tt.s, tt.n, got, tt.want)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("%s: got invalid UTF-8 %q", tt.name, got)
+		}
+	}
+
+	// every cut point of a long line of multi-byte characters stays valid
+	line := strings.Repeat("é日", 50)
+	for n := 0; n <= len(line); n++ {
+		if got := truncateAtBoundary(line, n); !utf8.ValidString(got) || len(got) > n {
+			t.Fatalf("truncateAtBoundary at %d bytes = %q", n, got)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+)
+
+// ChatCompletionStream is satisfied by a real openai stream and by fallbackStream
+type ChatCompletionStream interface {
+	Recv() (openai.ChatCompletionStreamResponse, error)
+	Close() error
+}
+
+// CreateMeteredChatCompletionStreamOrFallback checks the org's quota and budgets, then streams the reply if the model supports it. Otherwise it waits for a regular completion and replays it as a stream so that the caller and the client handle both the same way. As with CreateMeteredChatCompletionStream, the caller records the usage from the stream's usage chunk, which the replay ends with too.
+func CreateMeteredChatCompletionStreamOrFallback(
+	client *openai.Client,
+	ctx context.Context,
+	meter Meter,
+	req openai.ChatCompletionRequest,
+) (ChatCompletionStream, error) {
+	capabilities := shared.GetModelCapabilities(req.Model)
+	req = AdaptRequestToModel(req)
+
+	if capabilities.Streaming {
+		stream, err := CreateMeteredChatCompletionStream(client, ctx, meter, req)
+		if err != nil {
+			return nil, err
+		}
+		return stream, nil
+	}
+
+	err := CheckBudget(meter)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Model %s doesn't support streaming, falling back to a non-streaming request\n", req.Model)
+
+	req.Stream = false
+	req.StreamOptions = nil
+
+	resp, err := CreateChatCompletionWithRetries(client, ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no choices in response")
+	}
+
+	return newFallbackStream(resp), nil
+}
+
+// AdaptRequestToModel strips or rewrites the parts of a request the model doesn't accept
+func AdaptRequestToModel(req openai.ChatCompletionRequest) openai.ChatCompletionRequest {
+	capabilities := shared.GetModelCapabilities(req.Model)
+
+	if !capabilities.SamplingParams {
+		// zero values are omitted from the request
+		req.Temperature = 0
+		req.TopP = 0
+	}
+
+	if !capabilities.SystemMessages {
+		messages := make([]openai.ChatCompletionMessage, len(req.Messages))
+		for i, message := range req.Messages {
+			if message.Role == openai.ChatMessageRoleSystem {
+				message.Role = openai.ChatMessageRoleUser
+			}
+			messages[i] = message
+		}
+		req.Messages = messages
+	}
+
+	return req
+}
+
+// replay the reply line by line so the client still sees it progress
+type fallbackStream struct {
+	id           string
+	model        string
+	chunks       []string
+	finishReason openai.FinishReason
+	usage        openai.Usage
+	i            int
+}
+
+func newFallbackStream(resp openai.ChatCompletionResponse) *fallbackStream {
+	choice := resp.Choices[0]
+
+	return &fallbackStream{
+		id:           resp.ID,
+		model:        resp.Model,
+		chunks:       strings.SplitAfter(choice.Message.Content, "\n"),
+		finishReason: choice.FinishReason,
+		usage:        resp.Usage,
+	}
+}
+
+func (s *fallbackStream) Recv() (openai.ChatCompletionStreamResponse, error) {
+	res := openai.ChatCompletionStreamResponse{
+		ID:    s.id,
+		Model: s.model,
+	}
+
+	switch {
+	case s.i < len(s.chunks):
+		res.Choices = []openai.ChatCompletionStreamChoice{
+			{Delta: openai.ChatCompletionStreamChoiceDelta{Content: s.chunks[s.i]}},
+		}
+	case s.i == len(s.chunks):
+		finishReason := s.finishReason
+		if finishReason == "" {
+			finishReason = openai.FinishReasonStop
+		}
+		res.Choices = []openai.ChatCompletionStreamChoice{
+			{FinishReason: finishReason},
+		}
+	case s.i == len(s.chunks)+1:
+		usage := s.usage
+		res.Usage = &usage
+	default:
+		return res, io.EOF
+	}
+
+	s.i++
+	return res, nil
+}
+
+func (s *fallbackStream) Close() error {
+	return nil
+}
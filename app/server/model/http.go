@@ -0,0 +1,107 @@
+package model
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// HttpConfig tunes the transport used for calls to model providers. Durations are in seconds.
+type HttpConfig struct {
+	MaxIdleConns        int `json:"maxIdleConns"`
+	MaxIdleConnsPerHost int `json:"maxIdleConnsPerHost"`
+	MaxConnsPerHost     int `json:"maxConnsPerHost"`
+
+	IdleConnTimeout       int `json:"idleConnTimeout"`
+	DialTimeout           int `json:"dialTimeout"`
+	TLSHandshakeTimeout   int `json:"tlsHandshakeTimeout"`
+	ResponseHeaderTimeout int `json:"responseHeaderTimeout"`
+
+	DisableHttp2 bool `json:"disableHttp2"`
+
+	// if empty, the standard HTTPS_PROXY, HTTP_PROXY and NO_PROXY env vars are used
+	ProxyUrl string `json:"proxyUrl"`
+}
+
+// all calls to a provider go to the same host, so many more idle connections are kept per host than Go's default of 2. There's no overall timeout, since replies are streamed for minutes at a time; stalled streams are caught by OPENAI_STREAM_CHUNK_TIMEOUT instead. ResponseHeaderTimeout is off by default because calls that aren't streamed only send headers once the whole completion is done. A limit or timeout of 0 means none.
+var DefaultHttpConfig = HttpConfig{
+	MaxIdleConns:        100,
+	MaxIdleConnsPerHost: 20,
+	IdleConnTimeout:     90,
+	DialTimeout:         10,
+	TLSHandshakeTimeout: 10,
+}
+
+// shared by every provider client so connections are reused across requests
+var providerHttpClient = newProviderHttpClient(DefaultHttpConfig, nil)
+
+// LoadHttpConfig reads transport settings for provider calls from the PROVIDER_HTTP_CONFIG env var, e.g.
+// {"maxIdleConnsPerHost": 50, "proxyUrl": "http://proxy.internal:3128"}
+// Fields that aren't set keep their default values.
+func LoadHttpConfig() error {
+	s := os.Getenv("PROVIDER_HTTP_CONFIG")
+	if s == "" {
+		return nil
+	}
+
+	config := DefaultHttpConfig
+	err := json.Unmarshal([]byte(s), &config)
+	if err != nil {
+		return fmt.Errorf("error parsing PROVIDER_HTTP_CONFIG: %v", err)
+	}
+
+	var proxyUrl *url.URL
+	if config.ProxyUrl != "" {
+		proxyUrl, err = url.Parse(config.ProxyUrl)
+		if err != nil || proxyUrl.Host == "" {
+			return fmt.Errorf("invalid proxyUrl in PROVIDER_HTTP_CONFIG: %s", config.ProxyUrl)
+		}
+	}
+
+	providerHttpClient = newProviderHttpClient(config, proxyUrl)
+	log.Printf("Loaded provider http config: %+v\n", config)
+
+	return nil
+}
+
+func newProviderHttpClient(config HttpConfig, proxyUrl *url.URL) *http.Client {
+	proxy := http.ProxyFromEnvironment
+	if proxyUrl != nil {
+		proxy = http.ProxyURL(proxyUrl)
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   seconds(config.DialTimeout),
+		KeepAlive: 30 * time.Second,
+	}
+
+	transport := &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     !config.DisableHttp2,
+		MaxIdleConns:          config.MaxIdleConns,
+		MaxIdleConnsPerHost:   config.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       config.MaxConnsPerHost,
+		IdleConnTimeout:       seconds(config.IdleConnTimeout),
+		TLSHandshakeTimeout:   seconds(config.TLSHandshakeTimeout),
+		ResponseHeaderTimeout: seconds(config.ResponseHeaderTimeout),
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+
+	if config.DisableHttp2 {
+		// a non-nil, empty map turns off HTTP/2 upgrades
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+
+	return &http.Client{Transport: transport}
+}
+
+func seconds(n int) time.Duration {
+	return time.Duration(n) * time.Second
+}
@@ -4,10 +4,14 @@ import (
 	"fmt"
 	"plandex-server/db"
 	"strings"
+	"sync"
 
 	"github.com/plandex/plandex/shared"
 )
 
+// the wrapper around each context part only depends on its type, so its token count is cached rather than counted for every part on every reply
+var wrapperTokensByFmtStr sync.Map
+
 func FormatModelContext(context []*db.Context) (string, int, error) {
 	var contextMessages []string
 	var numTokens int
@@ -30,10 +34,17 @@ func FormatModelContext(context []*db.Context) (string, int, error) {
 			args = append(args, part.Name, part.Body)
 		}
 
-		numContextTokens, err := shared.GetNumTokens(fmt.Sprintf(fmtStr, ""))
-		if err != nil {
-			err = fmt.Errorf("failed to get the number of tokens in the context: %v", err)
-			return "", 0, err
+		var numContextTokens int
+		if cached, ok := wrapperTokensByFmtStr.Load(fmtStr); ok {
+			numContextTokens = cached.(int)
+		} else {
+			var err error
+			numContextTokens, err = shared.GetNumTokens(fmt.Sprintf(fmtStr, ""))
+			if err != nil {
+				err = fmt.Errorf("failed to get the number of tokens in the context: %v", err)
+				return "", 0, err
+			}
+			wrapperTokensByFmtStr.Store(fmtStr, numContextTokens)
 		}
 
 		numTokens += part.NumTokens + numContextTokens
@@ -0,0 +1,28 @@
+package lib
+
+import (
+	"fmt"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+)
+
+// overhead openai adds for each message and to prime the reply
+const tokensPerMessage = 3
+const tokensPerReplyPrime = 3
+
+// GetMessagesNumTokens counts the tokens the messages will use when sent to model
+func GetMessagesNumTokens(model shared.BaseModelConfig, messages []openai.ChatCompletionMessage) (int, error) {
+	numTokens := tokensPerReplyPrime
+
+	for _, message := range messages {
+		numContentTokens, err := shared.GetNumTokensForModel(model, message.Role+message.Content)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get the number of tokens in the message: %v", err)
+		}
+
+		numTokens += tokensPerMessage + numContentTokens
+	}
+
+	return numTokens, nil
+}
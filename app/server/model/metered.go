@@ -0,0 +1,119 @@
+package model
+
+import (
+	"context"
+	"log"
+	"os"
+	"plandex-server/db"
+	"strconv"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+)
+
+// Meter says who a model call is billed to. PlanId and Branch are empty for calls that aren't part of a plan, like commit messages and reviews.
+type Meter struct {
+	OrgId  string
+	UserId string
+	PlanId string
+	Branch string
+	Phase  shared.UsagePhase
+
+	// the plan's settings, for its budget. They're loaded from the plan if PlanId is set and this is nil.
+	Settings *shared.PlanSettings
+
+	// OnUsage is called after the call's usage is stored, so plan calls can send it to the client
+	OnUsage func(usage shared.ModelUsage)
+}
+
+// GetOrgMonthlyTokenQuota is the most prompt and completion tokens an org can use per month (UTC), set on the server with PLANDEX_ORG_MONTHLY_TOKEN_QUOTA. 0 means there's no quota.
+func GetOrgMonthlyTokenQuota() int {
+	quota, err := strconv.Atoi(os.Getenv("PLANDEX_ORG_MONTHLY_TOKEN_QUOTA"))
+	if err != nil || quota < 0 {
+		return 0
+	}
+	return quota
+}
+
+// CheckOrgQuota returns a *shared.QuotaExceededError if the org has used up the server's monthly quota
+func CheckOrgQuota(orgId string) error {
+	quota := GetOrgMonthlyTokenQuota()
+	if quota == 0 {
+		return nil
+	}
+
+	tokens, resetsAt, err := db.GetOrgMonthTokens(orgId)
+	if err != nil {
+		return err
+	}
+
+	if tokens >= quota {
+		return &shared.QuotaExceededError{Limit: quota, Used: tokens, ResetsAt: resetsAt}
+	}
+	return nil
+}
+
+// CreateMeteredChatCompletion checks the org's quota and budgets, makes the call, and records its usage. Every model call that isn't streamed should go through it.
+func CreateMeteredChatCompletion(
+	client *openai.Client,
+	ctx context.Context,
+	meter Meter,
+	req openai.ChatCompletionRequest,
+) (openai.ChatCompletionResponse, error) {
+	err := CheckBudget(meter)
+	if err != nil {
+		return openai.ChatCompletionResponse{}, err
+	}
+
+	resp, err := CreateChatCompletionWithRetries(client, ctx, req)
+	if err != nil {
+		return resp, err
+	}
+
+	RecordUsage(meter, shared.ModelUsage{
+		PromptTokens:     resp.Usage.PromptTokens,
+		CompletionTokens: resp.Usage.CompletionTokens,
+		ProviderReported: true,
+		ModelName:        req.Model,
+	})
+
+	return resp, nil
+}
+
+// CreateMeteredChatCompletionStream checks the org's quota and budgets before starting a stream. A stream's usage isn't known until it finishes, so the caller records it with RecordUsage.
+func CreateMeteredChatCompletionStream(
+	client *openai.Client,
+	ctx context.Context,
+	meter Meter,
+	req openai.ChatCompletionRequest,
+) (*openai.ChatCompletionStream, error) {
+	err := CheckBudget(meter)
+	if err != nil {
+		return nil, err
+	}
+
+	return CreateChatCompletionStreamWithRetries(client, ctx, req)
+}
+
+// RecordUsage stores a model call's usage against the org, and the plan if there is one. Failing to store usage is logged rather than failing the call, since the tokens are already spent.
+func RecordUsage(meter Meter, usage shared.ModelUsage) {
+	if usage.Phase == "" {
+		usage.Phase = meter.Phase
+	}
+
+	err := db.StoreUsage(meter.OrgId, &db.UsageRecord{
+		ModelUsage: usage,
+		PlanId:     meter.PlanId,
+		Branch:     meter.Branch,
+		UserId:     meter.UserId,
+		CreatedAt:  time.Now(),
+	})
+	if err != nil {
+		log.Printf("Error storing %s usage for org %s: %v\n", usage.Phase, meter.OrgId, err)
+	}
+
+	if meter.OnUsage != nil {
+		meter.OnUsage(usage)
+	}
+}
@@ -0,0 +1,526 @@
+package model
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"plandex-server/model/prompts"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+)
+
+// set when the server is started with --mock, which simulates every provider call
+var serverMockConfig *shared.MockConfig
+
+// EnableMock makes every provider call on the server simulated. Defaults can be set with the MOCK_MODEL_CONFIG env var, e.g.
+// {"seed": 7, "numFiles": 5, "errorRate": 0.1}
+func EnableMock() error {
+	config := shared.DefaultMockConfig
+
+	if s := os.Getenv("MOCK_MODEL_CONFIG"); s != "" {
+		err := json.Unmarshal([]byte(s), &config)
+		if err != nil {
+			return fmt.Errorf("error parsing MOCK_MODEL_CONFIG: %v", err)
+		}
+	}
+
+	config = config.WithDefaults()
+	serverMockConfig = &config
+	log.Printf("Mock mode: model calls are simulated with %+v\n", config)
+
+	return nil
+}
+
+// MockMode is whether the server was started with --mock
+func MockMode() bool {
+	return serverMockConfig != nil
+}
+
+// set when the server is started with --allow-mock
+var clientMockAllowed bool
+
+// AllowClientMock lets clients ask for simulated model calls, while requests that don't ask for one still call the provider
+func AllowClientMock() {
+	clientMockAllowed = true
+	log.Println("Clients can ask for simulated model calls")
+}
+
+// MockAllowed is whether clients can ask for simulated model calls, which is only the case when the server was started with --mock or --allow-mock
+func MockAllowed() bool {
+	return serverMockConfig != nil || clientMockAllowed
+}
+
+// NewClientForRequest returns a client for the provider, or a simulated one if the server is in mock mode or the request asked for one. Callers check MockAllowed before passing a request's mock config.
+func NewClientForRequest(apiKey string, mock *shared.MockConfig) *openai.Client {
+	if mock == nil {
+		mock = serverMockConfig
+	}
+	if mock != nil {
+		return NewMockClient(*mock)
+	}
+	return NewClient(apiKey)
+}
+
+func NewMockClient(config shared.MockConfig) *openai.Client {
+	clientConfig := openai.DefaultConfig("mock")
+	clientConfig.BaseURL = "http://mock.plandex.local/v1"
+	clientConfig.HTTPClient = &http.Client{Transport: &mockTransport{config: config.WithDefaults(), attemptsByHash: map[uint64]int{}}}
+	return openai.NewClientWithConfig(clientConfig)
+}
+
+// mockTransport answers the OpenAI API's models and chat completions endpoints. Each reply is generated from the seed and the request's messages, so the same prompt always gets the same reply no matter how calls are interleaved.
+type mockTransport struct {
+	config shared.MockConfig
+
+	mu sync.Mutex
+	// how many times each request has been sent, so a retry can get a different outcome than the call that failed
+	attemptsByHash map[uint64]int
+}
+
+func (t *mockTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	switch {
+	case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/models"):
+		return t.listModels(r)
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/chat/completions"):
+		return t.chatCompletion(r)
+	}
+
+	return mockErrorResponse(r, http.StatusNotFound, "mock provider doesn't handle "+r.Method+" "+r.URL.Path), nil
+}
+
+func (t *mockTransport) listModels(r *http.Request) (*http.Response, error) {
+	var names []string
+	for name := range shared.AvailableModelsByName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	list := openai.ModelsList{}
+	for _, name := range names {
+		list.Models = append(list.Models, openai.Model{ID: name, Object: "model", OwnedBy: "mock"})
+	}
+
+	return mockJsonResponse(r, http.StatusOK, list)
+}
+
+func (t *mockTransport) chatCompletion(r *http.Request) (*http.Response, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var req openai.ChatCompletionRequest
+	err = json.Unmarshal(body, &req)
+	if err != nil {
+		return mockErrorResponse(r, http.StatusBadRequest, "invalid request: "+err.Error()), nil
+	}
+
+	rng, faultRng := t.rngsFor(req)
+
+	if faultRng.Float64() < t.config.ErrorRate {
+		return mockErrorResponse(r, http.StatusInternalServerError, "simulated server error"), nil
+	}
+
+	fnName := forcedFunctionName(req)
+	var output string
+	if fnName != "" {
+		output = t.functionArgs(fnName, req, rng)
+	} else {
+		output = t.replyText(req, rng)
+	}
+
+	var promptChars int
+	for _, message := range req.Messages {
+		promptChars += len(message.Content)
+	}
+	usage := openai.Usage{
+		PromptTokens:     promptChars/4 + 1,
+		CompletionTokens: len(output)/4 + 1,
+	}
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+
+	if !req.Stream {
+		message := openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant}
+		finishReason := openai.FinishReasonStop
+		if fnName != "" {
+			message.ToolCalls = []openai.ToolCall{{
+				ID:       "call_mock",
+				Type:     openai.ToolTypeFunction,
+				Function: openai.FunctionCall{Name: fnName, Arguments: output},
+			}}
+			finishReason = openai.FinishReasonToolCalls
+		} else {
+			message.Content = output
+		}
+
+		return mockJsonResponse(r, http.StatusOK, openai.ChatCompletionResponse{
+			ID:      "mock",
+			Object:  "chat.completion",
+			Created: time.Now().Unix(),
+			Model:   req.Model,
+			Choices: []openai.ChatCompletionChoice{{Message: message, FinishReason: finishReason}},
+			Usage:   usage,
+		})
+	}
+
+	stall := faultRng.Float64() < t.config.TimeoutRate
+	includeUsage := req.StreamOptions != nil && req.StreamOptions.IncludeUsage
+
+	pr, pw := io.Pipe()
+	go t.writeStream(r.Context(), pw, req.Model, fnName, output, usage, includeUsage, stall)
+
+	return &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{"Content-Type": []string{"text/event-stream"}},
+		Body:       pr,
+		Request:    r,
+	}, nil
+}
+
+func (t *mockTransport) writeStream(ctx context.Context, pw *io.PipeWriter, modelName, fnName, output string, usage openai.Usage, includeUsage, stall bool) {
+	send := func(res openai.ChatCompletionStreamResponse) error {
+		res.ID = "mock"
+		res.Object = "chat.completion.chunk"
+		res.Model = modelName
+		bytes, err := json.Marshal(res)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(pw, "data: %s\n\n", bytes)
+		return err
+	}
+
+	chunks := splitMockChunks(output, t.config.ChunkSize)
+	delay := time.Duration(t.config.ChunkDelayMs) * time.Millisecond
+	index := 0
+
+	for i, chunk := range chunks {
+		if stall && i == len(chunks)/2 {
+			// hold the connection open without sending anything, like a provider that has stopped responding, until the caller's chunk timeout has given up on it
+			select {
+			case <-ctx.Done():
+			case <-time.After(2 * OPENAI_STREAM_CHUNK_TIMEOUT):
+			}
+			pw.CloseWithError(fmt.Errorf("simulated stalled stream"))
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			pw.CloseWithError(ctx.Err())
+			return
+		case <-time.After(delay):
+		}
+
+		delta := openai.ChatCompletionStreamChoiceDelta{}
+		if fnName != "" {
+			call := openai.ToolCall{Index: &index, Function: openai.FunctionCall{Arguments: chunk}}
+			if i == 0 {
+				call.ID = "call_mock"
+				call.Type = openai.ToolTypeFunction
+				call.Function.Name = fnName
+			}
+			delta.ToolCalls = []openai.ToolCall{call}
+		} else {
+			delta.Content = chunk
+		}
+
+		err := send(openai.ChatCompletionStreamResponse{Choices: []openai.ChatCompletionStreamChoice{{Delta: delta}}})
+		if err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+	}
+
+	finishReason := openai.FinishReasonStop
+	if fnName != "" {
+		finishReason = openai.FinishReasonToolCalls
+	}
+	err := send(openai.ChatCompletionStreamResponse{Choices: []openai.ChatCompletionStreamChoice{{FinishReason: finishReason}}})
+	if err == nil && includeUsage {
+		err = send(openai.ChatCompletionStreamResponse{Choices: []openai.ChatCompletionStreamChoice{}, Usage: &usage})
+	}
+	if err == nil {
+		_, err = io.WriteString(pw, "data: [DONE]\n\n")
+	}
+
+	pw.CloseWithError(err)
+}
+
+// rngsFor returns one source for the content of the reply, which only depends on the seed and messages, and another for injected faults, which also depends on how many times the request has been retried
+func (t *mockTransport) rngsFor(req openai.ChatCompletionRequest) (*rand.Rand, *rand.Rand) {
+	h := fnv.New64a()
+	for _, message := range req.Messages {
+		h.Write([]byte(message.Role))
+		h.Write([]byte(message.Content))
+	}
+	hash := h.Sum64()
+
+	t.mu.Lock()
+	attempt := t.attemptsByHash[hash]
+	t.attemptsByHash[hash]++
+	t.mu.Unlock()
+
+	seed := t.config.Seed ^ int64(hash)
+	return rand.New(rand.NewSource(seed)), rand.New(rand.NewSource(seed + int64(attempt) + 1))
+}
+
+// forcedFunctionName is the function the request requires the model to call, if any. The tool choice has been through JSON, so it's a map rather than an openai.ToolChoice.
+func forcedFunctionName(req openai.ChatCompletionRequest) string {
+	if req.ToolChoice == nil {
+		return ""
+	}
+	bytes, err := json.Marshal(req.ToolChoice)
+	if err != nil {
+		return ""
+	}
+	var choice openai.ToolChoice
+	if json.Unmarshal(bytes, &choice) != nil {
+		return ""
+	}
+	return choice.Function.Name
+}
+
+func (t *mockTransport) functionArgs(fnName string, req openai.ChatCompletionRequest, rng *rand.Rand) string {
+	var prompt string
+	if len(req.Messages) > 0 {
+		prompt = req.Messages[0].Content
+	}
+
+	var args any
+	switch fnName {
+	case prompts.PlanNameFn.Name:
+		args = map[string]string{"planName": "mock-" + mockWords(rng, 1)}
+	case prompts.DescribePlanFn.Name:
+		args = map[string]any{"commitMsg": "Apply mock changes", "commands": []any{}}
+	case prompts.CommitMsgFn.Name:
+		args = map[string]string{"commitMsg": "Apply mock changes"}
+	case prompts.PullRequestFn.Name:
+		args = map[string]string{"title": "Apply mock changes", "body": "Mock pull request."}
+	case prompts.ShouldAutoContinueFn.Name:
+		args = map[string]any{"reasoning": "Mock replies are always complete.", "shouldContinue": false}
+	case prompts.ReviewFn.Name:
+		args = map[string]any{"findings": []any{}}
+	case prompts.WriteFileFn.Name:
+		original := mockOriginalFile(prompt)
+		args = map[string]string{"content": strings.TrimRight(original, "\n") + "\n" + mockLines(rng, mockFilePath(prompt), 3)}
+	case prompts.ListEditsFn.Name:
+		original := mockOriginalFile(prompt)
+		search := mockAnchor(original)
+		args = map[string]any{"edits": []map[string]string{{
+			"summary": "Append mock lines",
+			"search":  search,
+			"replace": search + "\n" + strings.TrimRight(mockLines(rng, mockFilePath(prompt), 3), "\n"),
+		}}}
+	case prompts.ListReplacementsFn.Name:
+		lineNum, line := mockLastNumberedLine(prompt)
+		args = map[string]any{"changes": []map[string]any{{
+			"summary": "Append mock lines",
+			"section": "end of file",
+			"old": map[string]int{
+				"maybeStartLine": lineNum,
+				"maybeEndLine":   lineNum,
+				"startLine":      lineNum,
+				"endLine":        lineNum,
+			},
+			"new": line + "\n" + strings.TrimRight(mockLines(rng, mockFilePath(prompt), 3), "\n"),
+		}}}
+	default:
+		args = map[string]any{}
+	}
+
+	bytes, _ := json.Marshal(args)
+	return string(bytes)
+}
+
+var mockContextFileRegex = regexp.MustCompile("\n- ([^\n|]+):\n\n```")
+
+func (t *mockTransport) replyText(req openai.ChatCompletionRequest, rng *rand.Rand) string {
+	if len(req.Messages) > 0 && req.Messages[len(req.Messages)-1].Content == prompts.PlanSummary {
+		return "Mock summary: " + mockWords(rng, 12) + "."
+	}
+
+	// files in the planner's context are updated first, so builds of existing files get exercised too
+	var paths []string
+	if len(req.Messages) > 0 {
+		for _, match := range mockContextFileRegex.FindAllStringSubmatch(req.Messages[0].Content, -1) {
+			path := match[1]
+			if strings.HasPrefix(path, "content") || strings.Contains(path, "://") || filepath.Ext(path) == "" {
+				continue
+			}
+			paths = append(paths, path)
+		}
+	}
+	rng.Shuffle(len(paths), func(i, j int) { paths[i], paths[j] = paths[j], paths[i] })
+	if len(paths) > t.config.NumFiles {
+		paths = paths[:t.config.NumFiles]
+	}
+	for len(paths) < t.config.NumFiles {
+		paths = append(paths, fmt.Sprintf("mock/%s-%d.txt", mockWords(rng, 1), rng.Intn(1000)))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "This is a simulated reply from the mock model (seed %d). %s.\n\n", t.config.Seed, mockSentence(rng))
+
+	for _, path := range paths {
+		fmt.Fprintf(&b, "%s:\n\n- file: %s\n\n```\n", mockSentence(rng), path)
+		b.WriteString(mockLines(rng, path, t.config.FileLines))
+		b.WriteString("```\n\n")
+	}
+
+	b.WriteString("That's everything for the mock changes.")
+	return b.String()
+}
+
+var mockWordList = strings.Fields("lorem ipsum dolor sit amet consectetur adipiscing elit sed do eiusmod tempor incididunt ut labore et dolore magna aliqua enim minim veniam quis nostrud exercitation ullamco laboris nisi aliquip commodo consequat")
+
+func mockWords(rng *rand.Rand, n int) string {
+	words := make([]string, n)
+	for i := range words {
+		words[i] = mockWordList[rng.Intn(len(mockWordList))]
+	}
+	return strings.Join(words, " ")
+}
+
+func mockSentence(rng *rand.Rand) string {
+	s := mockWords(rng, 4+rng.Intn(8))
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// mockLines writes lines as comments in the file's language where there's a known comment syntax, so builds of code files still pass syntax checks
+func mockLines(rng *rand.Rand, path string, n int) string {
+	prefix := ""
+	switch strings.TrimPrefix(filepath.Ext(path), ".") {
+	case "go", "js", "jsx", "ts", "tsx", "java", "c", "h", "cpp", "hpp", "cc", "cs", "rs", "swift", "kt", "scala", "php", "dart":
+		prefix = "// "
+	case "py", "rb", "sh", "bash", "zsh", "yaml", "yml", "toml", "r", "pl", "ex", "exs":
+		prefix = "# "
+	case "sql", "lua", "hs", "elm":
+		prefix = "-- "
+	}
+
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		b.WriteString(prefix + mockSentence(rng) + "\n")
+	}
+	return b.String()
+}
+
+var mockFilePathRegex = regexp.MustCompile(`The current file is (.+?)\. Original state of the file:`)
+
+func mockFilePath(prompt string) string {
+	if match := mockFilePathRegex.FindStringSubmatch(prompt); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// mockOriginalFile pulls the original file out of a build prompt
+func mockOriginalFile(prompt string) string {
+	const start = "Original state of the file:**\n```\n"
+	i := strings.Index(prompt, start)
+	if i == -1 {
+		return ""
+	}
+	rest := prompt[i+len(start):]
+
+	end := strings.LastIndex(rest, "Proposed updates:")
+	if desc := strings.LastIndex(rest, "Description of the proposed updates"); desc != -1 && desc < end {
+		end = desc
+	}
+	if end == -1 {
+		return ""
+	}
+	rest = rest[:end]
+
+	j := strings.LastIndex(rest, "\n```")
+	if j == -1 {
+		return ""
+	}
+	return rest[:j]
+}
+
+// mockAnchor is the end of the file, up to its last 3 non-empty lines, which a search/replace edit can find
+func mockAnchor(original string) string {
+	lines := strings.Split(strings.TrimRight(original, "\n"), "\n")
+	if len(lines) > 3 {
+		lines = lines[len(lines)-3:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+var mockNumberedLineRegex = regexp.MustCompile(`(?m)^(\d+): (.*)$`)
+
+// mockLastNumberedLine finds the last line of the numbered original file in a listChanges prompt
+func mockLastNumberedLine(prompt string) (int, string) {
+	original := mockOriginalFile(prompt)
+
+	lineNum, line := 1, ""
+	for _, match := range mockNumberedLineRegex.FindAllStringSubmatch(original, -1) {
+		var n int
+		fmt.Sscanf(match[1], "%d", &n)
+		if strings.TrimSpace(match[2]) != "" {
+			lineNum, line = n, match[2]
+		}
+	}
+	return lineNum, line
+}
+
+func splitMockChunks(s string, size int) []string {
+	runes := []rune(s)
+	var chunks []string
+	for len(runes) > 0 {
+		n := size
+		if n > len(runes) {
+			n = len(runes)
+		}
+		chunks = append(chunks, string(runes[:n]))
+		runes = runes[n:]
+	}
+	return chunks
+}
+
+func mockJsonResponse(r *http.Request, status int, v any) (*http.Response, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Response{
+		Status:     fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode: status,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(body)),
+		Request:    r,
+	}, nil
+}
+
+func mockErrorResponse(r *http.Request, status int, msg string) *http.Response {
+	res, _ := mockJsonResponse(r, status, openai.ErrorResponse{Error: &openai.APIError{
+		Message:        msg,
+		Type:           "mock_error",
+		HTTPStatusCode: status,
+	}})
+	return res
+}
@@ -0,0 +1,184 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"plandex-server/model/prompts"
+	"strings"
+	"testing"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestMockAllowed(t *testing.T) {
+	defer func() {
+		serverMockConfig = nil
+		clientMockAllowed = false
+	}()
+
+	t.Setenv("GOENV", "development")
+	if MockAllowed() {
+		t.Error("expected mock calls not to be allowed in development without a flag")
+	}
+
+	AllowClientMock()
+	if !MockAllowed() || MockMode() {
+		t.Error("expected --allow-mock to allow mock calls without simulating every call")
+	}
+
+	clientMockAllowed = false
+	err := EnableMock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !MockAllowed() || !MockMode() {
+		t.Error("expected --mock to allow mock calls")
+	}
+}
+
+func mockChatRequest(content string) openai.ChatCompletionRequest {
+	return openai.ChatCompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleSystem, Content: content}},
+	}
+}
+
+func TestMockReplyIsDeterministic(t *testing.T) {
+	config := shared.MockConfig{Seed: 7, NumFiles: 2, FileLines: 3}
+	prompt := "Files in context:\n\n- src/main.go:\n\n```\npackage main\n```\n"
+
+	reply := func(config shared.MockConfig) string {
+		res, err := NewMockClient(config).CreateChatCompletion(context.Background(), mockChatRequest(prompt))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.Choices[0].Message.Content
+	}
+
+	first := reply(config)
+	if first != reply(config) {
+		t.Error("expected the same seed and prompt to get the same reply")
+	}
+	if !strings.Contains(first, "- file: src/main.go") {
+		t.Errorf("expected the file in context to be updated, got %q", first)
+	}
+	if strings.Count(first, "- file: ") != 2 {
+		t.Errorf("expected 2 file blocks, got %q", first)
+	}
+	if !strings.Contains(first, "// ") {
+		t.Errorf("expected go lines to be written as comments, got %q", first)
+	}
+
+	config.Seed = 8
+	if first == reply(config) {
+		t.Error("expected a different seed to get a different reply")
+	}
+}
+
+func TestMockForcedFunctionCall(t *testing.T) {
+	req := mockChatRequest("name this plan")
+	req.Tools = []openai.Tool{{Type: openai.ToolTypeFunction, Function: &prompts.PlanNameFn}}
+	req.ToolChoice = openai.ToolChoice{Type: openai.ToolTypeFunction, Function: openai.ToolFunction{Name: prompts.PlanNameFn.Name}}
+
+	res, err := NewMockClient(shared.DefaultMockConfig).CreateChatCompletion(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := res.Choices[0].Message.ToolCalls
+	if len(calls) != 1 || calls[0].Function.Name != prompts.PlanNameFn.Name {
+		t.Fatalf("expected a call to %s, got %+v", prompts.PlanNameFn.Name, calls)
+	}
+
+	var args struct {
+		PlanName string `json:"planName"`
+	}
+	err = json.Unmarshal([]byte(calls[0].Function.Arguments), &args)
+	if err != nil || !strings.HasPrefix(args.PlanName, "mock-") {
+		t.Errorf("expected valid args with a mock plan name, got %q, %v", calls[0].Function.Arguments, err)
+	}
+}
+
+func TestMockStream(t *testing.T) {
+	config := shared.MockConfig{ChunkSize: 5, ChunkDelayMs: 1}
+	req := mockChatRequest("say something")
+	req.Stream = true
+	req.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+
+	stream, err := NewMockClient(config).CreateChatCompletionStream(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	var content strings.Builder
+	var finishReason openai.FinishReason
+	var usage *openai.Usage
+	for {
+		res, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Usage != nil {
+			usage = res.Usage
+		}
+		for _, choice := range res.Choices {
+			content.WriteString(choice.Delta.Content)
+			if choice.FinishReason != "" {
+				finishReason = choice.FinishReason
+			}
+		}
+	}
+
+	nonStreamed, err := NewMockClient(config).CreateChatCompletion(context.Background(), mockChatRequest("say something"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content.String() != nonStreamed.Choices[0].Message.Content {
+		t.Errorf("expected the streamed chunks to add up to the full reply, got %q", content.String())
+	}
+	if finishReason != openai.FinishReasonStop {
+		t.Errorf("expected the stream to finish with stop, got %q", finishReason)
+	}
+	if usage == nil || usage.TotalTokens == 0 {
+		t.Errorf("expected usage at the end of the stream, got %+v", usage)
+	}
+}
+
+func TestMockErrorRate(t *testing.T) {
+	_, err := NewMockClient(shared.MockConfig{ErrorRate: 1}).CreateChatCompletion(context.Background(), mockChatRequest("hi"))
+
+	var apiErr *openai.APIError
+	if !errors.As(err, &apiErr) || apiErr.HTTPStatusCode != 500 {
+		t.Errorf("expected a simulated server error, got %v", err)
+	}
+}
+
+func TestMockBuildPromptParsing(t *testing.T) {
+	prompt := "The current file is app.py. Original state of the file:**\n```\n1: def f():\n2:     return 1\n3: \n```\n\nProposed updates:\n..."
+
+	if path := mockFilePath(prompt); path != "app.py" {
+		t.Errorf("expected the file path from the prompt, got %q", path)
+	}
+	if original := mockOriginalFile(prompt); original != "1: def f():\n2:     return 1\n3: " {
+		t.Errorf("expected the original file from the prompt, got %q", original)
+	}
+
+	lineNum, line := mockLastNumberedLine(prompt)
+	if lineNum != 2 || line != "    return 1" {
+		t.Errorf("expected the last non-empty line, got %d %q", lineNum, line)
+	}
+}
+
+func TestSplitMockChunks(t *testing.T) {
+	chunks := splitMockChunks("héllo wörld", 4)
+	if strings.Join(chunks, "|") != "héll|o wö|rld" {
+		t.Errorf("expected chunks split by character, got %q", chunks)
+	}
+}
@@ -10,7 +10,7 @@ import (
 	"github.com/sashabaranov/go-openai"
 )
 
-func GenPlanName(client *openai.Client, config shared.TaskRoleConfig, planContent string) (string, error) {
+func GenPlanName(client *openai.Client, config shared.TaskRoleConfig, meter Meter, planContent string) (string, error) {
 	messages := []openai.ChatCompletionMessage{
 		{
 			Role:    openai.ChatMessageRoleSystem,
@@ -23,15 +23,17 @@ func GenPlanName(client *openai.Client, config shared.TaskRoleConfig, planConten
 		Content: prompts.GetPlanNamePrompt(planContent),
 	})
 
-	resp, err := CreateChatCompletionWithRetries(
+	meter.Phase = shared.UsagePhaseName
+	resp, err := CreateMeteredChatCompletion(
 		client,
 		context.Background(),
+		meter,
 		openai.ChatCompletionRequest{
 			Model: config.BaseModelConfig.ModelName,
 			Tools: []openai.Tool{
 				{
 					Type:     "function",
-					Function: prompts.PlanNameFn,
+					Function: &prompts.PlanNameFn,
 				},
 			},
 			ToolChoice: openai.ToolChoice{
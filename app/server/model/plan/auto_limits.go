@@ -0,0 +1,48 @@
+package plan
+
+import (
+	"errors"
+	"net/http"
+	"plandex-server/db"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// checkAutoRun double-checks an automated run's limits before each reply, using the plan's own descriptions and usage records rather than what the client counted. It returns a *shared.AutoLimitExceededError once the run reaches a limit.
+func checkAutoRun(orgId, planId string, run *shared.AutoRun, descs []*db.ConvoMessageDescription) error {
+	var apiDescs []*shared.ConvoMessageDescription
+	for _, desc := range descs {
+		apiDescs = append(apiDescs, desc.ToApi())
+	}
+
+	progress := shared.GetAutoRunProgress(apiDescs, run.StartedAt)
+
+	records, err := db.GetPlanUsage(orgId, planId)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		if !record.CreatedAt.Before(run.StartedAt) {
+			progress.Usage.Add(record.ModelUsage)
+		}
+	}
+
+	if exceeded := run.Limits.Exceeded(progress); exceeded != nil {
+		return exceeded
+	}
+	return nil
+}
+
+// autoLimitApiError converts an error from checkAutoRun into an error for the client, or returns nil if no limit was reached
+func autoLimitApiError(err error) *shared.ApiError {
+	var limitErr *shared.AutoLimitExceededError
+	if errors.As(err, &limitErr) {
+		return &shared.ApiError{
+			Type:                   shared.ApiErrorTypeAutoLimitExceeded,
+			Status:                 http.StatusForbidden,
+			Msg:                    "Limit reached: " + limitErr.Error(),
+			AutoLimitExceededError: limitErr,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package plan
+
+import (
+	"log"
+	"plandex-server/model"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// checkBudget is called before starting a reply or build, so the client gets a budget or quota error before anything is streamed. The metered model helpers check it again before each call.
+func checkBudget(orgId, planId string, settings *shared.PlanSettings) error {
+	return model.CheckBudget(model.Meter{OrgId: orgId, PlanId: planId, Settings: settings})
+}
+
+// planMeter bills a model call to the plan and sends its usage to the client, along with the budget status if the plan or org has a budget
+func planMeter(orgId, userId, planId, branch string, settings *shared.PlanSettings, phase shared.UsagePhase) model.Meter {
+	return model.Meter{
+		OrgId:    orgId,
+		UserId:   userId,
+		PlanId:   planId,
+		Branch:   branch,
+		Phase:    phase,
+		Settings: settings,
+		OnUsage: func(usage shared.ModelUsage) {
+			streamUsage(orgId, planId, branch, settings, usage, nil)
+		},
+	}
+}
+
+// recordAndStreamUsage stores a streamed model call's usage against the plan and sends it to the client. convoHistory is only set for replies.
+func recordAndStreamUsage(orgId, userId, planId, branch string, settings *shared.PlanSettings, usage shared.ModelUsage, convoHistory *shared.ConvoHistory) {
+	meter := planMeter(orgId, userId, planId, branch, settings, usage.Phase)
+	meter.OnUsage = func(usage shared.ModelUsage) {
+		streamUsage(orgId, planId, branch, settings, usage, convoHistory)
+	}
+	model.RecordUsage(meter, usage)
+}
+
+func streamUsage(orgId, planId, branch string, settings *shared.PlanSettings, usage shared.ModelUsage, convoHistory *shared.ConvoHistory) {
+	msg := shared.StreamMessage{
+		Type:         shared.StreamMessageUsage,
+		Usage:        &usage,
+		ConvoHistory: convoHistory,
+	}
+
+	status, err := model.GetBudgetStatus(orgId, planId, settings)
+	if err != nil {
+		log.Printf("Error getting budget status for plan %s: %v\n", planId, err)
+	} else {
+		msg.Budget = status
+	}
+
+	active := GetActivePlan(planId, branch)
+	if active != nil {
+		active.Stream(msg)
+	}
+}
@@ -0,0 +1,132 @@
+package plan
+
+import (
+	"fmt"
+	"log"
+	"plandex-server/model"
+	"plandex-server/model/lib"
+	"plandex-server/model/prompts"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+)
+
+// a build that hits the model's output limit before finishing its function call is continued this many times before it's retried from scratch
+const MaxBuildContinuations = 3
+
+// continuation output is held back until this much has arrived so any text the model repeats from the end of the truncated output can be trimmed
+const continuationOverlapLookahead = 200
+
+// continueTruncatedBuild asks the model to pick up where a build that was cut off at the output limit left off. The partial output stays in the buffer and the continuation is appended to it.
+func (fileState *activeBuildStreamFileState) continueTruncatedBuild() {
+	filePath := fileState.filePath
+
+	if fileState.numContinuation >= MaxBuildContinuations {
+		fileState.retryOrError(fmt.Errorf("build output for file '%s' was still truncated after %d continuations", filePath, MaxBuildContinuations))
+		return
+	}
+
+	partial, err := fileState.activeBuild.Buffer.String()
+	if err != nil {
+		fileState.onBuildFileError(err)
+		return
+	}
+
+	fileState.numContinuation++
+	fileState.continuationLookahead = ""
+	fileState.continuationTail = partial
+	if len(fileState.continuationTail) > continuationOverlapLookahead {
+		fileState.continuationTail = fileState.continuationTail[len(fileState.continuationTail)-continuationOverlapLookahead:]
+	}
+
+	log.Printf("Build output for file '%s' was truncated, continuing (%d/%d)\n", filePath, fileState.numContinuation, MaxBuildContinuations)
+
+	activePlan := GetActivePlan(fileState.plan.Id, fileState.branch)
+	if activePlan == nil {
+		log.Printf("continueTruncatedBuild - Active plan not found for plan ID %s on branch %s\n", fileState.plan.Id, fileState.branch)
+		return
+	}
+
+	config := fileState.settings.ModelSet.Builder
+
+	// the continuation is requested as plain text rather than a function call, since the arguments it produces won't be valid JSON on their own
+	modelReq := openai.ChatCompletionRequest{
+		Model: config.BaseModelConfig.ModelName,
+		Messages: append(append([]openai.ChatCompletionMessage{}, fileState.buildMessages...),
+			openai.ChatCompletionMessage{
+				Role:    openai.ChatMessageRoleAssistant,
+				Content: partial,
+			},
+			openai.ChatCompletionMessage{
+				Role:    openai.ChatMessageRoleUser,
+				Content: prompts.BuildContinuationPrompt,
+			},
+		),
+		Temperature: config.Temperature,
+		TopP:        config.TopP,
+	}
+
+	meter := planMeter(fileState.currentOrgId, fileState.currentUserId, fileState.plan.Id, fileState.branch, fileState.settings, shared.UsagePhaseBuild)
+
+	stream, err := model.CreateMeteredChatCompletionStream(fileState.client, activePlan.Ctx, meter, modelReq)
+	if err != nil {
+		log.Printf("Error creating continuation stream for path '%s': %v\n", filePath, err)
+		fileState.onBuildFileError(fmt.Errorf("error creating continuation stream for path '%s': %w", filePath, err))
+		return
+	}
+
+	// the continuation's output is counted with the rest of the build's when it finishes, but its prompt is only sent here
+	promptTokens, err := lib.GetMessagesNumTokens(config.BaseModelConfig, modelReq.Messages)
+	if err != nil {
+		log.Printf("File %s: error counting continuation prompt tokens: %v\n", filePath, err)
+	} else {
+		model.RecordUsage(meter, shared.ModelUsage{
+			PromptTokens: promptTokens,
+			ModelName:    config.BaseModelConfig.ModelName,
+		})
+	}
+
+	go fileState.listenStream(stream)
+}
+
+// appendContinuation buffers the start of a continuation until there's enough to check it against the end of the truncated output, trims anything the model repeated, then appends the rest
+func (fileState *activeBuildStreamFileState) appendContinuation(content string, final bool) error {
+	if fileState.continuationTail == "" {
+		return fileState.activeBuild.Buffer.Append(content)
+	}
+
+	fileState.continuationLookahead += content
+	if len(fileState.continuationLookahead) < continuationOverlapLookahead && !final {
+		return nil
+	}
+
+	pending := trimContinuationOverlap(fileState.continuationTail, fileState.continuationLookahead)
+	fileState.continuationTail = ""
+	fileState.continuationLookahead = ""
+
+	return fileState.activeBuild.Buffer.Append(pending)
+}
+
+// trimContinuationOverlap drops the longest prefix of continuation that repeats the end of tail. Only overlaps of a few characters or more count--shorter matches are as likely to be coincidence as repetition.
+func trimContinuationOverlap(tail, continuation string) string {
+	const minOverlap = 8
+
+	for n := len(tail); n >= minOverlap; n-- {
+		if n > len(continuation) {
+			continue
+		}
+		if strings.HasSuffix(tail, continuation[:n]) {
+			return continuation[n:]
+		}
+	}
+	return continuation
+}
+
+func (fileState *activeBuildStreamFileState) resetBuffer() {
+	fileState.activeBuild.Buffer.Reset()
+	fileState.activeBuild.BufferTokens = 0
+	fileState.numContinuation = 0
+	fileState.continuationTail = ""
+	fileState.continuationLookahead = ""
+}
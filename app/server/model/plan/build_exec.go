@@ -1,12 +1,17 @@
 package plan
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"os"
 	"plandex-server/db"
 	"plandex-server/model"
 	"plandex-server/model/prompts"
 	"plandex-server/types"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/plandex/plandex/shared"
 	"github.com/sashabaranov/go-openai"
@@ -17,17 +22,19 @@ func Build(
 	plan *db.Plan,
 	branch string,
 	auth *types.ServerAuth,
+	defaultModelSet *shared.ModelSet,
 ) (int, error) {
 	log.Printf("Build: Called with plan ID %s on branch %s\n", plan.Id, branch)
 	log.Println("Build: Starting Build operation")
 
 	state := activeBuildStreamState{
-		client:        client,
-		auth:          auth,
-		currentOrgId:  auth.OrgId,
-		currentUserId: auth.User.Id,
-		plan:          plan,
-		branch:        branch,
+		client:          client,
+		auth:            auth,
+		currentOrgId:    auth.OrgId,
+		currentUserId:   auth.User.Id,
+		plan:            plan,
+		branch:          branch,
+		defaultModelSet: defaultModelSet,
 	}
 
 	streamDone := func() {
@@ -54,6 +61,18 @@ func Build(
 		return 0, nil
 	}
 
+	err = checkBudget(state.currentOrgId, plan.Id, state.settings)
+	if err != nil {
+		if apiErr := model.BudgetApiError(err); apiErr != nil {
+			active := GetActivePlan(plan.Id, branch)
+			if active != nil {
+				active.StreamDoneCh <- apiErr
+			}
+			return 0, nil
+		}
+		return onErr(fmt.Errorf("error checking budget: %v", err))
+	}
+
 	err = db.SetPlanStatus(plan.Id, branch, shared.PlanStatusBuilding, "")
 
 	if err != nil {
@@ -142,16 +161,70 @@ func (buildState *activeBuildStreamState) execPlanBuild(activeBuild *types.Activ
 		activeBuildStreamState: buildState,
 		filePath:               filePath,
 		activeBuild:            activeBuild,
+		releaseBuildSlot:       func() {},
 	}
+
+	if !fileState.acquireBuildSlot() {
+		log.Printf("Plan stopped while file %s was waiting to build\n", filePath)
+		return
+	}
+
 	err := fileState.loadBuildFile(activeBuild)
 	if err != nil {
 		log.Printf("Error loading build file: %v\n", err)
+		fileState.releaseBuildSlot()
+		return
+	}
+
+	// builds queued behind others can start after earlier ones have used up the budget
+	err = checkBudget(fileState.currentOrgId, planId, fileState.settings)
+	if err != nil {
+		fileState.onBuildFileError(err)
 		return
 	}
 
 	fileState.buildFile()
 }
 
+// acquireBuildSlot waits until fewer files than the plan's max-concurrent-builds are building. The server can set a lower ceiling with PLANDEX_MAX_CONCURRENT_BUILDS. Returns false if the plan is stopped while waiting.
+func (fileState *activeBuildStreamFileState) acquireBuildSlot() bool {
+	limit := fileState.settings.GetMaxConcurrentBuilds()
+	if serverLimit, err := strconv.Atoi(os.Getenv("PLANDEX_MAX_CONCURRENT_BUILDS")); err == nil && serverLimit > 0 && (limit == 0 || serverLimit < limit) {
+		limit = serverLimit
+	}
+	if limit == 0 {
+		return true
+	}
+
+	var slots chan struct{}
+	var ctx context.Context
+	UpdateActivePlan(fileState.plan.Id, fileState.branch, func(ap *types.ActivePlan) {
+		if ap.BuildSlots == nil {
+			ap.BuildSlots = make(chan struct{}, limit)
+		}
+		slots = ap.BuildSlots
+		ctx = ap.Ctx
+	})
+	if slots == nil {
+		// active plan is gone
+		return false
+	}
+
+	select {
+	case slots <- struct{}{}:
+	case <-ctx.Done():
+		return false
+	}
+
+	var once sync.Once
+	fileState.releaseBuildSlot = func() {
+		once.Do(func() {
+			<-slots
+		})
+	}
+	return true
+}
+
 func (fileState *activeBuildStreamFileState) buildFile() {
 	filePath := fileState.filePath
 	activeBuild := fileState.activeBuild
@@ -223,7 +296,7 @@ func (fileState *activeBuildStreamFileState) buildFile() {
 		fileState.onFinishBuildFile(planRes)
 		return
 	} else {
-		currentNumTokens, err := shared.GetNumTokens(currentState)
+		currentNumTokens, err := db.GetPlanNumTokens(planId, shared.GetTokenEncoding(fileState.settings.ModelSet.Builder.BaseModelConfig), currentState)
 
 		if err != nil {
 			log.Printf("Error getting num tokens for current state: %v\n", err)
@@ -236,12 +309,34 @@ func (fileState *activeBuildStreamFileState) buildFile() {
 		activeBuild.CurrentFileTokens = currentNumTokens
 	}
 
+	fileState.sectionSpan = nil
+	if activeBuild.CurrentFileTokens > LargeFileSectionThresholdTokens {
+		span := getSectionSpan(filePath, currentState, activeBuild.FileContent)
+		if span != nil {
+			log.Printf("File %s is large, only building sections:\n%s\n", filePath, strings.Join(span.labels, "\n"))
+			fileState.sectionSpan = span
+			currentState = span.content
+			fileState.currentState = currentState
+		}
+	}
+
 	log.Println("Getting file from model: " + filePath)
 	// log.Println("File context:", fileContext)
 
 	// log.Println("currentState:", currentState)
 
-	sysPrompt := prompts.GetBuildSysPrompt(filePath, currentState, activeBuild.FileDescription, activeBuild.FileContent)
+	var sysPrompt string
+	var buildFn *openai.FunctionDefinition
+	if fileState.isWholeFileFallback {
+		sysPrompt = prompts.GetBuildWholeFileSysPrompt(filePath, currentState, activeBuild.FileDescription, activeBuild.FileContent)
+		buildFn = &prompts.WriteFileFn
+	} else if fileState.settings.GetBuildEditFormat() == shared.BuildEditFormatSearchReplace {
+		sysPrompt = prompts.GetBuildSearchReplaceSysPrompt(filePath, currentState, activeBuild.FileDescription, activeBuild.FileContent)
+		buildFn = &prompts.ListEditsFn
+	} else {
+		sysPrompt = prompts.GetBuildSysPrompt(filePath, currentState, activeBuild.FileDescription, activeBuild.FileContent)
+		buildFn = &prompts.ListReplacementsFn
+	}
 
 	fileMessages := []openai.ChatCompletionMessage{
 		{
@@ -250,6 +345,15 @@ func (fileState *activeBuildStreamFileState) buildFile() {
 		},
 	}
 
+	if fileState.syntaxErr != nil {
+		fileMessages = append(fileMessages, openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleUser,
+			Content: prompts.GetBuildSyntaxErrorPrompt(fileState.syntaxErrBuffer, fileState.syntaxErr.Error()),
+		})
+	}
+
+	fileState.buildMessages = fileMessages
+
 	log.Println("Calling model for file: " + filePath)
 
 	// for _, msg := range fileMessages {
@@ -261,13 +365,13 @@ func (fileState *activeBuildStreamFileState) buildFile() {
 		Tools: []openai.Tool{
 			{
 				Type:     "function",
-				Function: prompts.ListReplacementsFn,
+				Function: buildFn,
 			},
 		},
 		ToolChoice: openai.ToolChoice{
 			Type: "function",
 			Function: openai.ToolFunction{
-				Name: prompts.ListReplacementsFn.Name,
+				Name: buildFn.Name,
 			},
 		},
 		Messages:       fileMessages,
@@ -276,10 +380,13 @@ func (fileState *activeBuildStreamFileState) buildFile() {
 		ResponseFormat: config.OpenAIResponseFormat,
 	}
 
-	stream, err := model.CreateChatCompletionStreamWithRetries(client, activePlan.Ctx, modelReq)
+	// the build's usage is recorded by streamBuildUsage when the file's edits are parsed
+	meter := planMeter(fileState.currentOrgId, fileState.currentUserId, planId, branch, fileState.settings, shared.UsagePhaseBuild)
+
+	stream, err := model.CreateMeteredChatCompletionStream(client, activePlan.Ctx, meter, modelReq)
 	if err != nil {
 		log.Printf("Error creating plan file stream for path '%s': %v\n", filePath, err)
-		fileState.onBuildFileError(fmt.Errorf("error creating plan file stream for path '%s': %v", filePath, err))
+		fileState.onBuildFileError(fmt.Errorf("error creating plan file stream for path '%s': %w", filePath, err))
 		return
 	}
 
@@ -5,6 +5,7 @@ import (
 	"log"
 	"net/http"
 	"plandex-server/db"
+	"plandex-server/model"
 	"plandex-server/types"
 
 	"github.com/plandex/plandex/shared"
@@ -186,6 +187,8 @@ func (state *activeBuildStreamFileState) onFinishBuild() {
 }
 
 func (fileState *activeBuildStreamFileState) onFinishBuildFile(planRes *db.PlanFileResult) {
+	fileState.releaseBuildSlot()
+
 	planId := fileState.plan.Id
 	branch := fileState.branch
 	currentOrgId := fileState.currentOrgId
@@ -263,6 +266,8 @@ func (fileState *activeBuildStreamFileState) onFinishBuildFile(planRes *db.PlanF
 
 	activeBuild.Success = true
 
+	go fileState.indexFileTokens(planRes)
+
 	UpdateActivePlan(planId, branch, func(ap *types.ActivePlan) {
 		ap.BuiltFiles[filePath] = true
 		if ap.BuildFinished() {
@@ -305,7 +310,30 @@ func (fileState *activeBuildStreamFileState) onFinishBuildFile(planRes *db.PlanF
 
 }
 
+// indexFileTokens counts the file's state after the build, so the next build or reply that loads the file finds it in the plan's token index
+func (fileState *activeBuildStreamFileState) indexFileTokens(planRes *db.PlanFileResult) {
+	updated := planRes.Content
+	if len(planRes.Replacements) > 0 {
+		original := fileState.currentState
+		if fileState.sectionSpan != nil {
+			original = fileState.sectionSpan.fullState
+		}
+		updated, _ = shared.ApplyReplacements(original, planRes.Replacements, false)
+	}
+
+	encodings := []shared.TokenEncoding{shared.DefaultTokenEncoding, shared.GetTokenEncoding(fileState.settings.ModelSet.Builder.BaseModelConfig)}
+	for _, encoding := range encodings {
+		_, err := db.GetPlanNumTokens(fileState.plan.Id, encoding, updated)
+		if err != nil {
+			log.Printf("Error indexing tokens for %s: %v\n", fileState.filePath, err)
+			return
+		}
+	}
+}
+
 func (fileState *activeBuildStreamFileState) onBuildFileError(err error) {
+	fileState.releaseBuildSlot()
+
 	planId := fileState.plan.Id
 	branch := fileState.branch
 	filePath := fileState.filePath
@@ -318,8 +346,11 @@ func (fileState *activeBuildStreamFileState) onBuildFileError(err error) {
 
 	activeBuild.Success = false
 	activeBuild.Error = err
+	activeBuild.Buffer.Reset()
 
-	if activePlan != nil {
+	if apiErr := model.BudgetApiError(err); activePlan != nil && apiErr != nil {
+		activePlan.StreamDoneCh <- apiErr
+	} else if activePlan != nil {
 		activePlan.StreamDoneCh <- &shared.ApiError{
 			Type:   shared.ApiErrorTypeOther,
 			Status: http.StatusInternalServerError,
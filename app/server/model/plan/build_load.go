@@ -78,12 +78,15 @@ func (state *activeBuildStreamState) loadPendingBuilds() (map[string][]*types.Ac
 		}()
 
 		go func() {
-			res, err := db.GetPlanSettings(plan, true)
+			res, err := db.GetPlanSettings(plan, state.defaultModelSet == nil)
 			if err != nil {
 				log.Printf("Error getting plan settings: %v\n", err)
 				errCh <- fmt.Errorf("error getting plan settings: %v", err)
 				return
 			}
+			if res.ModelSet == nil {
+				res.ModelSet = state.defaultModelSet
+			}
 
 			settings = res
 			errCh <- nil
@@ -1,6 +1,7 @@
 package plan
 
 import (
+	"fmt"
 	"log"
 	"plandex-server/db"
 	"sort"
@@ -123,3 +124,110 @@ func getPlanResult(params planResultParams) (*db.PlanFileResult, bool) {
 		AnyFailed:      !allSucceeded,
 	}, allSucceeded
 }
+
+// getSearchReplaceResult validates search/replace edits against the original file and converts them to replacements. An error means the edits can't be applied as-is.
+func getSearchReplaceResult(params planResultParams, edits []*shared.StreamedEdit) (*db.PlanFileResult, error) {
+	currentState := params.currentState
+
+	if len(edits) == 0 {
+		return nil, fmt.Errorf("no edits for file '%s'", params.filePath)
+	}
+
+	type located struct {
+		idx  int
+		edit *shared.StreamedEdit
+	}
+
+	var locatedEdits []located
+	for i, edit := range edits {
+		if edit.Search == "" {
+			return nil, fmt.Errorf("edit %d for file '%s' has an empty search block", i+1, params.filePath)
+		}
+
+		count := strings.Count(currentState, edit.Search)
+		if count == 0 {
+			return nil, fmt.Errorf("edit %d for file '%s' search block not found in original file", i+1, params.filePath)
+		} else if count > 1 {
+			return nil, fmt.Errorf("edit %d for file '%s' search block matches %d locations", i+1, params.filePath, count)
+		}
+
+		locatedEdits = append(locatedEdits, located{idx: strings.Index(currentState, edit.Search), edit: edit})
+	}
+
+	sort.Slice(locatedEdits, func(i, j int) bool {
+		return locatedEdits[i].idx < locatedEdits[j].idx
+	})
+
+	var replacements []*shared.Replacement
+	for i, l := range locatedEdits {
+		if i > 0 {
+			prev := locatedEdits[i-1]
+			if prev.idx+len(prev.edit.Search) > l.idx {
+				return nil, fmt.Errorf("overlapping edits for file '%s'", params.filePath)
+			}
+		}
+
+		startLine := strings.Count(currentState[:l.idx], "\n") + 1
+		endLine := startLine + strings.Count(strings.TrimSuffix(l.edit.Search, "\n"), "\n")
+
+		replacements = append(replacements, &shared.Replacement{
+			Old: l.edit.Search,
+			New: l.edit.Replace,
+			StreamedChange: &shared.StreamedChange{
+				Summary: l.edit.Summary,
+				Old: shared.StreamedChangeSection{
+					StartLine: startLine,
+					EndLine:   endLine,
+				},
+				New: l.edit.Replace,
+			},
+		})
+	}
+
+	_, allSucceeded := shared.ApplyReplacements(currentState, replacements, true)
+	if !allSucceeded {
+		return nil, fmt.Errorf("edits failed to apply for file '%s'", params.filePath)
+	}
+
+	for _, replacement := range replacements {
+		replacement.Id = uuid.New().String()
+	}
+
+	return &db.PlanFileResult{
+		OrgId:          params.orgId,
+		PlanId:         params.planId,
+		PlanBuildId:    params.planBuildId,
+		ConvoMessageId: params.convoMessageId,
+		Path:           params.filePath,
+		Replacements:   replacements,
+	}, nil
+}
+
+// getWholeFileResult stores a full rewrite as a single replacement of the original file so it stacks with earlier pending results for the same path
+func getWholeFileResult(params planResultParams, content string) *db.PlanFileResult {
+	currentState := params.currentState
+	numLines := len(strings.Split(currentState, "\n"))
+
+	replacement := &shared.Replacement{
+		Id:  uuid.New().String(),
+		Old: currentState,
+		New: content,
+		StreamedChange: &shared.StreamedChange{
+			Summary: "Rewrite the whole file",
+			Old: shared.StreamedChangeSection{
+				StartLine: 1,
+				EndLine:   numLines,
+			},
+			New: content,
+		},
+	}
+
+	return &db.PlanFileResult{
+		OrgId:          params.orgId,
+		PlanId:         params.planId,
+		PlanBuildId:    params.planBuildId,
+		ConvoMessageId: params.convoMessageId,
+		Path:           params.filePath,
+		Replacements:   []*shared.Replacement{replacement},
+	}
+}
@@ -0,0 +1,352 @@
+package plan
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// files above this size are split into sections so that only the sections a change targets are sent to the builder
+const LargeFileSectionThresholdTokens = 6000
+
+type fileSection struct {
+	label     string
+	name      string
+	startLine int // 1-indexed, inclusive
+	endLine   int // 1-indexed, inclusive
+}
+
+// the span of the original file that's being rebuilt, along with the surrounding content needed to stitch it back together
+type sectionSpan struct {
+	fullState string
+	content   string
+	prefix    string
+	suffix    string
+	startLine int
+	labels    []string
+}
+
+var sectionDeclRegex = regexp.MustCompile(`^(?:export\s+)?(?:pub(?:\([a-z]+\))?\s+)?(?:public\s+|private\s+|protected\s+|static\s+|abstract\s+|async\s+|default\s+)*(?:func|function|def|class|type|interface|struct|enum|trait|impl|module|fn|const|var|let)\s+(?:\([^)]*\)\s*)?([A-Za-z_$][A-Za-z0-9_$]*)`)
+
+// sectionSyntax is the little of a language's syntax that the section splitter needs to tell code from comments and strings
+type sectionSyntax struct {
+	lineComment  string
+	blockComment bool // /* ... */
+	singleQuotes bool // '...' strings or chars. Off for Rust, where ' also starts lifetimes.
+	charLiterals bool // 'x' chars, without treating a lone ' as a string. For Rust.
+	backticks    bool // `...` strings that can span lines, like Go raw strings and JS template literals
+	tripleQuotes bool // """...""" and '''...''' strings that can span lines, like Python docstrings
+}
+
+var (
+	cLikeSectionSyntax  = &sectionSyntax{lineComment: "//", blockComment: true, singleQuotes: true}
+	backtickSyntax      = &sectionSyntax{lineComment: "//", blockComment: true, singleQuotes: true, backticks: true}
+	rustSectionSyntax   = &sectionSyntax{lineComment: "//", blockComment: true, charLiterals: true}
+	pythonSectionSyntax = &sectionSyntax{lineComment: "#", singleQuotes: true, tripleQuotes: true}
+	rubySectionSyntax   = &sectionSyntax{lineComment: "#", singleQuotes: true}
+)
+
+// the languages large files are split into sections for. Other files, like markdown, config, or data, are always built whole.
+var sectionSyntaxByExt = map[string]*sectionSyntax{
+	".go":    backtickSyntax,
+	".js":    backtickSyntax,
+	".jsx":   backtickSyntax,
+	".mjs":   backtickSyntax,
+	".cjs":   backtickSyntax,
+	".ts":    backtickSyntax,
+	".tsx":   backtickSyntax,
+	".java":  cLikeSectionSyntax,
+	".kt":    cLikeSectionSyntax,
+	".scala": cLikeSectionSyntax,
+	".swift": cLikeSectionSyntax,
+	".c":     cLikeSectionSyntax,
+	".h":     cLikeSectionSyntax,
+	".cc":    cLikeSectionSyntax,
+	".cpp":   cLikeSectionSyntax,
+	".hpp":   cLikeSectionSyntax,
+	".cs":    cLikeSectionSyntax,
+	".php":   cLikeSectionSyntax,
+	".rs":    rustSectionSyntax,
+	".py":    pythonSectionSyntax,
+	".rb":    rubySectionSyntax,
+}
+
+// sectionScanner tracks, line by line, whether the start of the next line is inside a multi-line string or comment, and which brackets are open. It also notes the first bracket that doesn't match, which the syntax check reports.
+type sectionScanner struct {
+	syntax *sectionSyntax
+	open   []openBracket
+	// the delimiter that ends the string or comment the scanner is in, or "" in code
+	closer     string
+	closerLine int
+	lineNum    int
+	mismatch   error
+}
+
+type openBracket struct {
+	char byte
+	line int
+}
+
+var closingBrackets = map[byte]byte{'}': '{', ')': '(', ']': '['}
+
+// matches a Rust char literal, like 'x' or '\n', as opposed to a lifetime like 'a
+var rustCharLiteralRegex = regexp.MustCompile(`^'(?:\\[^']+|[^\\'])'`)
+
+// atTopLevel is whether the line about to be scanned starts in code outside any brackets
+func (sc *sectionScanner) atTopLevel() bool {
+	return sc.closer == "" && len(sc.open) == 0
+}
+
+func (sc *sectionScanner) scanLine(line string) {
+	sc.lineNum++
+
+	for i := 0; i < len(line); i++ {
+		rest := line[i:]
+
+		if sc.closer != "" {
+			if sc.closer != "*/" && sc.closer != "`" && rest[0] == '\\' {
+				i++
+				continue
+			}
+			if strings.HasPrefix(rest, sc.closer) {
+				i += len(sc.closer) - 1
+				sc.closer = ""
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(rest, sc.syntax.lineComment):
+			return
+		case sc.syntax.blockComment && strings.HasPrefix(rest, "/*"):
+			sc.openCloser("*/")
+			i++
+		case sc.syntax.tripleQuotes && (strings.HasPrefix(rest, `"""`) || strings.HasPrefix(rest, "'''")):
+			sc.openCloser(rest[:3])
+			i += 2
+		case rest[0] == '"' || (rest[0] == '\'' && sc.syntax.singleQuotes) || (rest[0] == '`' && sc.syntax.backticks):
+			sc.openCloser(rest[:1])
+		case rest[0] == '\'' && sc.syntax.charLiterals:
+			if lit := rustCharLiteralRegex.FindString(rest); lit != "" {
+				i += len(lit) - 1
+			}
+		case strings.ContainsRune("{([", rune(rest[0])):
+			sc.open = append(sc.open, openBracket{char: rest[0], line: sc.lineNum})
+		case strings.ContainsRune("})]", rune(rest[0])):
+			sc.closeBracket(rest[0])
+		}
+	}
+
+	// only backtick strings, triple-quoted strings, and block comments continue onto the next line
+	if sc.closer == `"` || sc.closer == "'" {
+		sc.closer = ""
+	}
+}
+
+func (sc *sectionScanner) openCloser(closer string) {
+	sc.closer = closer
+	sc.closerLine = sc.lineNum
+}
+
+// closeBracket pops the innermost open bracket even if it doesn't match, so one stray bracket doesn't throw off the nesting of the rest of the file
+func (sc *sectionScanner) closeBracket(char byte) {
+	if len(sc.open) == 0 {
+		if sc.mismatch == nil {
+			sc.mismatch = fmt.Errorf("line %d: unexpected '%c' with no open '%c'", sc.lineNum, char, closingBrackets[char])
+		}
+		return
+	}
+
+	top := sc.open[len(sc.open)-1]
+	sc.open = sc.open[:len(sc.open)-1]
+	if top.char != closingBrackets[char] && sc.mismatch == nil {
+		sc.mismatch = fmt.Errorf("line %d: '%c' doesn't match the '%c' opened on line %d", sc.lineNum, char, top.char, top.line)
+	}
+}
+
+// splitFileSections splits a file into its top-level declarations, like functions, classes, and types, for the languages in sectionSyntaxByExt. It returns nil for other files. Comments and decorators directly above a declaration belong to its section.
+//
+// It isn't a full parser for each language. It's a small scanner that skips comments and strings, including multi-line ones like Go raw strings, JS template literals, and Python docstrings, and tracks bracket nesting, so only declarations that start in the first column of top-level code are boundaries. Declarations nested in a block, or that appear inside a string or comment, stay part of the section around them. That's all the build needs: boundaries that are safe to rebuild independently.
+func splitFileSections(path, content string) []*fileSection {
+	syntax := sectionSyntaxByExt[strings.ToLower(filepath.Ext(path))]
+	if syntax == nil {
+		return nil
+	}
+
+	lines := strings.Split(content, "\n")
+	scanner := &sectionScanner{syntax: syntax}
+
+	var sections []*fileSection
+	var current *fileSection
+	commentStart := 0
+
+	for i, line := range lines {
+		lineNum := i + 1
+		trimmed := strings.TrimSpace(line)
+		topLevel := scanner.atTopLevel()
+		inTopLevelComment := scanner.closer == "*/" && len(scanner.open) == 0
+		scanner.scanLine(line)
+
+		if !topLevel {
+			// the rest of a block comment above a declaration still belongs to it
+			if !inTopLevelComment {
+				commentStart = 0
+			}
+			continue
+		}
+
+		isComment := strings.HasPrefix(trimmed, syntax.lineComment) || (syntax.blockComment && (strings.HasPrefix(trimmed, "/*") || strings.HasPrefix(trimmed, "*")))
+		// decorators and annotations belong to the declaration below them, like comments
+		isDecorator := strings.HasPrefix(trimmed, "@")
+
+		if line != "" && line == strings.TrimLeft(line, " \t") && (isComment || isDecorator) {
+			if commentStart == 0 {
+				commentStart = lineNum
+			}
+			continue
+		}
+
+		match := sectionDeclRegex.FindStringSubmatch(line)
+		if match != nil {
+			startLine := lineNum
+			if commentStart > 0 {
+				startLine = commentStart
+			}
+
+			if current != nil {
+				current.endLine = startLine - 1
+			}
+
+			label := trimmed
+			if len(label) > 80 {
+				label = label[:80] + "..."
+			}
+
+			current = &fileSection{
+				label:     label,
+				name:      match[1],
+				startLine: startLine,
+			}
+			sections = append(sections, current)
+		}
+
+		if trimmed != "" {
+			commentStart = 0
+		}
+	}
+
+	if current != nil {
+		current.endLine = len(lines)
+	}
+
+	return sections
+}
+
+// getSectionSpan finds the sections that the proposed changes refer to by name and returns the smallest contiguous span of the file that covers them. It returns nil if the changes can't be matched to specific sections, in which case the whole file is built.
+func getSectionSpan(path, currentState, changes string) *sectionSpan {
+	sections := splitFileSections(path, currentState)
+	if len(sections) < 2 {
+		return nil
+	}
+
+	var touched []*fileSection
+	for _, section := range sections {
+		nameRegex, err := regexp.Compile(`\b` + regexp.QuoteMeta(section.name) + `\b`)
+		if err != nil {
+			continue
+		}
+		if nameRegex.MatchString(changes) {
+			touched = append(touched, section)
+		}
+	}
+
+	if len(touched) == 0 || len(touched) == len(sections) {
+		return nil
+	}
+
+	lines := strings.Split(currentState, "\n")
+	startLine := touched[0].startLine
+	endLine := touched[len(touched)-1].endLine
+
+	// include the line after the span so insertions at the end of the last section have an anchor
+	if endLine < len(lines) {
+		endLine++
+	}
+
+	var labels []string
+	for _, section := range touched {
+		labels = append(labels, section.label)
+	}
+
+	prefix := strings.Join(lines[:startLine-1], "\n")
+	if startLine > 1 {
+		prefix += "\n"
+	}
+	suffix := ""
+	if endLine < len(lines) {
+		suffix = "\n" + strings.Join(lines[endLine:], "\n")
+	}
+
+	return &sectionSpan{
+		fullState: currentState,
+		content:   strings.Join(lines[startLine-1:endLine], "\n"),
+		prefix:    prefix,
+		suffix:    suffix,
+		startLine: startLine,
+		labels:    labels,
+	}
+}
+
+// stitch maps replacements built against the span back onto the full file. If the replacements wouldn't apply to the full file exactly as they did to the span (e.g. because a replaced block also appears earlier in the file), the whole span is stored as a single replacement instead.
+func (span *sectionSpan) stitch(replacements []*shared.Replacement) []*shared.Replacement {
+	spanContent := span.content
+
+	updatedSpan, allSucceeded := shared.ApplyReplacements(spanContent, replacements, false)
+	if !allSucceeded {
+		return replacements
+	}
+
+	expected := span.prefix + updatedSpan + span.suffix
+
+	for _, replacement := range replacements {
+		if replacement.StreamedChange != nil {
+			replacement.StreamedChange.Old.StartLine += span.startLine - 1
+			replacement.StreamedChange.Old.EndLine += span.startLine - 1
+		}
+	}
+
+	updatedFull, allSucceeded := shared.ApplyReplacements(span.fullState, replacements, false)
+	if allSucceeded && updatedFull == expected {
+		return replacements
+	}
+
+	log.Println("Section replacements don't apply cleanly to the full file, storing the section as a single replacement")
+
+	var summaries []string
+	for _, replacement := range replacements {
+		if replacement.StreamedChange != nil && replacement.StreamedChange.Summary != "" {
+			summaries = append(summaries, replacement.StreamedChange.Summary)
+		}
+	}
+
+	return []*shared.Replacement{
+		{
+			Id:  replacements[0].Id,
+			Old: spanContent,
+			New: updatedSpan,
+			StreamedChange: &shared.StreamedChange{
+				Summary: strings.Join(summaries, " "),
+				Section: strings.Join(span.labels, "\n"),
+				Old: shared.StreamedChangeSection{
+					StartLine: span.startLine,
+					EndLine:   span.startLine + strings.Count(spanContent, "\n"),
+				},
+				New: updatedSpan,
+			},
+		},
+	}
+}
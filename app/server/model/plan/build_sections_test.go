@@ -0,0 +1,124 @@
+package plan
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func sectionNames(sections []*fileSection) string {
+	var names []string
+	for _, section := range sections {
+		names = append(names, fmt.Sprintf("%s:%d-%d", section.name, section.startLine, section.endLine))
+	}
+	return strings.Join(names, " ")
+}
+
+func TestSplitFileSections(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		content string
+		want    string
+	}{
+		{
+			name: "go with doc comments",
+			path: "server.go",
+			content: `package main
+
+// Server serves
+type Server struct {
+	addr string
+}
+
+/*
+ * Start starts
+ */
+func (s *Server) Start() error {
+	return nil
+}`,
+			want: "Server:3-7 Start:8-13",
+		},
+		{
+			name: "declarations nested in a block",
+			path: "app.ts",
+			content: `export class App {
+function notTopLevel() {}
+const alsoNested = 1
+}
+
+export function main() {
+  if (true) {
+class Inner {}
+  }
+}`,
+			want: "App:1-5 main:6-10",
+		},
+		{
+			name:    "declarations inside a Go raw string",
+			path:    "prompts.go",
+			content: "package prompts\n\nvar prompt = `\nfunc fake() {\ntype Fake struct\n`\n\nfunc real() {}",
+			want:    "prompt:3-7 real:8-8",
+		},
+		{
+			name:    "declarations inside a JS template literal and a block comment",
+			path:    "gen.js",
+			content: "const tpl = `\nfunction fake() {}\n`\n/*\nfunction commented() {}\n*/\nfunction real() {}",
+			want:    "tpl:1-3 real:4-7",
+		},
+		{
+			name:    "declarations inside a Python docstring, and decorators",
+			path:    "app.py",
+			content: "def first():\n    \"\"\"\ndef fake():\nclass Fake:\n    \"\"\"\n    return 1\n\n@app.route('/')\ndef second():\n    pass",
+			want:    "first:1-7 second:8-10",
+		},
+		{
+			name:    "an unterminated quote doesn't carry over the line",
+			path:    "main.go",
+			content: "package main\n\nvar r = '\"'\nvar s = \"it's\"\n\nfunc main() {}",
+			want:    "r:3-3 s:4-5 main:6-6",
+		},
+		{
+			name:    "rust lifetimes",
+			path:    "lib.rs",
+			content: "fn first<'a>(x: &'a str) -> &'a str {\n    x\n}\n\npub fn second() {}",
+			want:    "first:1-4 second:5-5",
+		},
+		{
+			name:    "files in other languages aren't split",
+			path:    "README.md",
+			content: "# Docs\n\n```go\nfunc fenced() {}\n```\n\nfunc alsoNot() {}",
+			want:    "",
+		},
+	}
+
+	for _, tt := range tests {
+		got := sectionNames(splitFileSections(tt.path, tt.content))
+		if got != tt.want {
+			t.Errorf("%s: got sections %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGetSectionSpanStitch(t *testing.T) {
+	content := "package main\n\nfunc a() {\n\treturn\n}\n\nfunc b() {\n\tprintln(\"b\")\n}\n\nfunc c() {\n}"
+
+	span := getSectionSpan("main.go", content, "func b() {\n\tprintln(\"changed\")\n}")
+	if span == nil {
+		t.Fatal("expected a span for a change to one section")
+	}
+	// the line after the section is included as an anchor for insertions at its end
+	if span.content != "func b() {\n\tprintln(\"b\")\n}\n\nfunc c() {" || span.startLine != 7 {
+		t.Errorf("unexpected span starting at %d: %q", span.startLine, span.content)
+	}
+	if span.prefix+span.content+span.suffix != content {
+		t.Error("expected the span to stitch back into the file")
+	}
+
+	if getSectionSpan("main.go", content, "no names here") != nil {
+		t.Error("expected no span when the changes don't name a section")
+	}
+	if getSectionSpan("notes.txt", content, "func b() {}") != nil {
+		t.Error("expected no span for a file that isn't split")
+	}
+}
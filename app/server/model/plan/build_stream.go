@@ -8,6 +8,7 @@ import (
 	"math"
 	"plandex-server/db"
 	"plandex-server/model"
+	"plandex-server/model/lib"
 	"plandex-server/types"
 	"strings"
 	"time"
@@ -28,6 +29,9 @@ type activeBuildStreamState struct {
 	branch        string
 	settings      *shared.PlanSettings
 	modelContext  []*db.Context
+
+	// model set sent by the client, used if the plan has none stored
+	defaultModelSet *shared.ModelSet
 }
 
 type activeBuildStreamFileState struct {
@@ -39,16 +43,34 @@ type activeBuildStreamFileState struct {
 	activeBuild      *types.ActiveBuild
 	currentState     string
 	numRetry         int
+
+	// set once edits fail to apply, so the file is rebuilt by writing it out in full
+	isWholeFileFallback bool
+
+	// set for large files when only some sections are being rebuilt
+	sectionSpan *sectionSpan
+
+	// set when the last attempt produced a file that doesn't parse, so the builder can be asked to fix it
+	syntaxErr       error
+	syntaxErrBuffer string
+	numSyntaxRetry  int
+
+	// the messages for the current build request, kept so a truncated build can be continued
+	buildMessages []openai.ChatCompletionMessage
+
+	// set while continuing a build that was cut off at the output limit
+	numContinuation       int
+	continuationTail      string
+	continuationLookahead string
+
+	// frees the build slot this file holds, if builds are limited. Safe to call more than once.
+	releaseBuildSlot func()
 }
 
 func (fileState *activeBuildStreamFileState) listenStream(stream *openai.ChatCompletionStream) {
 	filePath := fileState.filePath
-	build := fileState.build
-	currentOrgId := fileState.currentOrgId
 	planId := fileState.plan.Id
 	branch := fileState.branch
-	currentState := fileState.currentState
-	activeBuild := fileState.activeBuild
 
 	activePlan := GetActivePlan(planId, branch)
 
@@ -67,6 +89,7 @@ func (fileState *activeBuildStreamFileState) listenStream(stream *openai.ChatCom
 		select {
 		case <-activePlan.Ctx.Done():
 			// The main context was canceled (not the timer)
+			fileState.activeBuild.Buffer.Reset()
 			return
 		case <-timer.C:
 			// Timer triggered because no new chunk was received in time
@@ -87,7 +110,9 @@ func (fileState *activeBuildStreamFileState) listenStream(stream *openai.ChatCom
 				if err == context.Canceled {
 					log.Printf("File %s: Stream canceled\n", filePath)
 					log.Println("current buffer:")
-					log.Println(fileState.activeBuild.Buffer)
+					buffer, _ := fileState.activeBuild.Buffer.String()
+					log.Println(buffer)
+					fileState.activeBuild.Buffer.Reset()
 					return
 				}
 
@@ -104,8 +129,17 @@ func (fileState *activeBuildStreamFileState) listenStream(stream *openai.ChatCom
 			var content string
 			delta := response.Choices[0].Delta
 
+			hasOutput := false
 			if len(delta.ToolCalls) > 0 {
 				content = delta.ToolCalls[0].Function.Arguments
+				hasOutput = true
+			} else if fileState.numContinuation > 0 && choice.FinishReason == "" {
+				// continuations are plain text rather than a function call
+				content = delta.Content
+				hasOutput = true
+			}
+
+			if hasOutput {
 
 				trimmed := strings.TrimSpace(content)
 				if trimmed == "{%invalidjson%}" || trimmed == "``(no output)``````" {
@@ -127,7 +161,15 @@ func (fileState *activeBuildStreamFileState) listenStream(stream *openai.ChatCom
 					BuildInfo: buildInfo,
 				})
 
-				fileState.activeBuild.Buffer += content
+				if fileState.numContinuation > 0 {
+					err = fileState.appendContinuation(content, false)
+				} else {
+					err = fileState.activeBuild.Buffer.Append(content)
+				}
+				if err != nil {
+					fileState.onBuildFileError(err)
+					return
+				}
 				fileState.activeBuild.BufferTokens++
 
 				// After a reasonable threshhold, if buffer has significantly more tokens than original file + proposed changes, something is wrong
@@ -139,60 +181,77 @@ func (fileState *activeBuildStreamFileState) listenStream(stream *openai.ChatCom
 					log.Printf("Cutoff: %d\n", cutoff)
 					log.Printf("Buffer tokens: %d\n", fileState.activeBuild.BufferTokens)
 					log.Println("Buffer:")
-					log.Println(fileState.activeBuild.Buffer)
+					buffer, _ := fileState.activeBuild.Buffer.String()
+					log.Println(buffer)
 
 					fileState.retryOrError(fmt.Errorf("stream buffer tokens too high for file '%s'", filePath))
 					return
 				}
 			}
 
-			var streamed types.StreamedChanges
-			err = json.Unmarshal([]byte(fileState.activeBuild.Buffer), &streamed)
+			if fileState.numContinuation > 0 && choice.FinishReason != "" {
+				err = fileState.appendContinuation(delta.Content, true)
+				if err != nil {
+					fileState.onBuildFileError(err)
+					return
+				}
+			}
 
-			if err == nil {
-				log.Printf("File %s: Parsed streamed replacements\n", filePath)
-				// spew.Dump(streamed)
-
-				planFileResult, allSucceeded := getPlanResult(
-					planResultParams{
-						orgId:           currentOrgId,
-						planId:          planId,
-						planBuildId:     build.Id,
-						convoMessageId:  build.ConvoMessageId,
-						filePath:        filePath,
-						currentState:    currentState,
-						fileContent:     activeBuild.FileContent,
-						streamedChanges: streamed.Changes,
-					},
-				)
-
-				if !allSucceeded {
-					log.Println("Failed replacements:")
-					for _, replacement := range planFileResult.Replacements {
-						if replacement.Failed {
-							spew.Dump(replacement)
-						}
-					}
+			var planFileResult *db.PlanFileResult
+			var parsed bool
+			var editErr error
 
-					// no retry here as this should never happen
-					fileState.onBuildFileError(fmt.Errorf("replacements failed for file '%s'", filePath))
+			// every format is a single JSON object, so there's no point reading back a spilled buffer until it could be complete
+			if fileState.activeBuild.Buffer.EndsWith('}') {
+				buffer, err := fileState.activeBuild.Buffer.String()
+				if err != nil {
+					fileState.onBuildFileError(err)
 					return
+				}
+				planFileResult, parsed, editErr = fileState.parseBuildBuffer([]byte(buffer))
+			}
 
+			if parsed {
+				log.Printf("File %s: Parsed streamed edits\n", filePath)
+
+				if editErr != nil {
+					fileState.fallbackToWholeFile(editErr)
+					return
+				}
+
+				if fileState.sectionSpan != nil {
+					planFileResult.Replacements = fileState.sectionSpan.stitch(planFileResult.Replacements)
+				}
+
+				syntaxChecked, syntaxErr := fileState.checkBuildSyntax(planFileResult.Replacements)
+				if syntaxErr != nil {
+					if fileState.numSyntaxRetry < MaxBuildSyntaxRetries {
+						fileState.retrySyntaxError(syntaxErr)
+						return
+					}
+					log.Printf("File %s: still has a syntax error after %d retries, keeping the result: %v\n", filePath, fileState.numSyntaxRetry, syntaxErr)
 				}
 
 				buildInfo := &shared.BuildInfo{
-					Path:      filePath,
-					NumTokens: 0,
-					Finished:  true,
+					Path:            filePath,
+					NumTokens:       0,
+					Finished:        true,
+					SyntaxUnchecked: !syntaxChecked,
 				}
 				activePlan.Stream(shared.StreamMessage{
 					Type:      shared.StreamMessageBuildInfo,
 					BuildInfo: buildInfo,
 				})
 
+				fileState.streamBuildUsage()
+
+				fileState.activeBuild.Buffer.Reset()
 				fileState.onFinishBuildFile(planFileResult)
 				return
-			} else if len(delta.ToolCalls) == 0 {
+			} else if choice.FinishReason == openai.FinishReasonLength {
+				fileState.continueTruncatedBuild()
+				return
+			} else if !hasOutput {
 				log.Println("Stream chunk missing function call. Response:")
 				log.Println(spew.Sdump(response))
 				log.Println(spew.Sdump(fileState))
@@ -208,8 +267,7 @@ func (fileState *activeBuildStreamFileState) listenStream(stream *openai.ChatCom
 func (fileState *activeBuildStreamFileState) retryOrError(err error) {
 	if fileState.numRetry < MaxBuildStreamErrorRetries {
 		fileState.numRetry++
-		fileState.activeBuild.Buffer = ""
-		fileState.activeBuild.BufferTokens = 0
+		fileState.resetBuffer()
 		log.Printf("Retrying build file '%s' due to error: %v\n", fileState.filePath, err)
 
 		// Exponential backoff
@@ -220,3 +278,102 @@ func (fileState *activeBuildStreamFileState) retryOrError(err error) {
 		fileState.onBuildFileError(err)
 	}
 }
+
+// parseBuildBuffer tries to parse the buffered function call for the current edit format. parsed is false until the buffer is complete JSON. editErr is set when the edits parsed but can't be applied to the original file.
+func (fileState *activeBuildStreamFileState) parseBuildBuffer(buffer []byte) (planFileResult *db.PlanFileResult, parsed bool, editErr error) {
+	build := fileState.build
+	activeBuild := fileState.activeBuild
+
+	params := planResultParams{
+		orgId:          fileState.currentOrgId,
+		planId:         fileState.plan.Id,
+		planBuildId:    build.Id,
+		convoMessageId: build.ConvoMessageId,
+		filePath:       fileState.filePath,
+		currentState:   fileState.currentState,
+		fileContent:    activeBuild.FileContent,
+	}
+
+	if fileState.isWholeFileFallback {
+		var streamed types.StreamedFile
+		if json.Unmarshal(buffer, &streamed) != nil {
+			return nil, false, nil
+		}
+		return getWholeFileResult(params, streamed.Content), true, nil
+	}
+
+	if fileState.settings.GetBuildEditFormat() == shared.BuildEditFormatSearchReplace {
+		var streamed types.StreamedEdits
+		if json.Unmarshal(buffer, &streamed) != nil {
+			return nil, false, nil
+		}
+		planFileResult, editErr = getSearchReplaceResult(params, streamed.Edits)
+		return planFileResult, true, editErr
+	}
+
+	var streamed types.StreamedChanges
+	if json.Unmarshal(buffer, &streamed) != nil {
+		return nil, false, nil
+	}
+
+	params.streamedChanges = streamed.Changes
+	planFileResult, allSucceeded := getPlanResult(params)
+
+	if !allSucceeded {
+		log.Println("Failed replacements:")
+		for _, replacement := range planFileResult.Replacements {
+			if replacement.Failed {
+				spew.Dump(replacement)
+			}
+		}
+		return nil, true, fmt.Errorf("replacements failed for file '%s'", fileState.filePath)
+	}
+
+	return planFileResult, true, nil
+}
+
+func (fileState *activeBuildStreamFileState) retrySyntaxError(err error) {
+	log.Printf("Build for file '%s' has a syntax error, asking the builder to fix it: %v\n", fileState.filePath, err)
+
+	fileState.numSyntaxRetry++
+	fileState.syntaxErr = err
+	fileState.syntaxErrBuffer, _ = fileState.activeBuild.Buffer.String()
+	fileState.resetBuffer()
+
+	fileState.buildFile()
+}
+
+func (fileState *activeBuildStreamFileState) fallbackToWholeFile(err error) {
+	if fileState.isWholeFileFallback {
+		fileState.onBuildFileError(err)
+		return
+	}
+
+	log.Printf("Edits failed for file '%s', falling back to writing the whole file: %v\n", fileState.filePath, err)
+
+	fileState.isWholeFileFallback = true
+	fileState.numRetry = 0
+	fileState.syntaxErr = nil
+	fileState.syntaxErrBuffer = ""
+	fileState.resetBuffer()
+
+	fileState.buildFile()
+}
+
+// streamBuildUsage records and sends the estimated usage of the request that finished the file's build. Build streams stop reading once the edits parse, before the provider's usage chunk arrives, so the tokens are counted here.
+func (fileState *activeBuildStreamFileState) streamBuildUsage() {
+	builder := fileState.settings.ModelSet.Builder.BaseModelConfig
+
+	promptTokens, err := lib.GetMessagesNumTokens(builder, fileState.buildMessages)
+	if err != nil {
+		log.Printf("File %s: error counting build prompt tokens: %v\n", fileState.filePath, err)
+		return
+	}
+
+	recordAndStreamUsage(fileState.currentOrgId, fileState.currentUserId, fileState.plan.Id, fileState.branch, fileState.settings, shared.ModelUsage{
+		PromptTokens:     promptTokens,
+		CompletionTokens: fileState.activeBuild.BufferTokens,
+		Phase:            shared.UsagePhaseBuild,
+		ModelName:        builder.ModelName,
+	}, nil)
+}
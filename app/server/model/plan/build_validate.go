@@ -0,0 +1,138 @@
+package plan
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+	"gopkg.in/yaml.v3"
+)
+
+// a build that produces a syntax error is sent back to the builder with the error this many times before the result is kept as is
+const MaxBuildSyntaxRetries = 2
+
+type syntaxValidator func(path, content string) error
+
+// validators by file extension; files without one aren't checked, and the build reports them as unchecked
+var syntaxValidators = map[string]syntaxValidator{}
+
+func registerSyntaxValidator(validate syntaxValidator, exts ...string) {
+	for _, ext := range exts {
+		syntaxValidators[ext] = validate
+	}
+}
+
+func init() {
+	// languages with a parser in the standard library, or one we already depend on, get a full parse
+	registerSyntaxValidator(validateGoSyntax, ".go")
+	registerSyntaxValidator(validateJSONSyntax, ".json")
+	registerSyntaxValidator(validateYAMLSyntax, ".yaml", ".yml")
+	registerSyntaxValidator(validateXMLSyntax, ".xml", ".svg")
+
+	// the rest of the languages the section splitter knows get a check that brackets, strings, and block comments are balanced, which catches the most common broken builds: a dropped or duplicated closing brace, or a block cut off partway through
+	for ext := range sectionSyntaxByExt {
+		if _, ok := syntaxValidators[ext]; !ok {
+			registerSyntaxValidator(validateBracketSyntax, ext)
+		}
+	}
+}
+
+func validateGoSyntax(path, content string) error {
+	_, err := parser.ParseFile(token.NewFileSet(), path, content, parser.AllErrors)
+	return err
+}
+
+func validateJSONSyntax(path, content string) error {
+	var v interface{}
+	err := json.Unmarshal([]byte(content), &v)
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return nil
+}
+
+func validateYAMLSyntax(path, content string) error {
+	// a file can hold several documents separated by ---
+	decoder := yaml.NewDecoder(strings.NewReader(content))
+	for {
+		var v interface{}
+		err := decoder.Decode(&v)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+	}
+}
+
+func validateXMLSyntax(path, content string) error {
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	for {
+		_, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+	}
+}
+
+func validateBracketSyntax(path, content string) error {
+	scanner := &sectionScanner{syntax: sectionSyntaxByExt[strings.ToLower(filepath.Ext(path))]}
+	for _, line := range strings.Split(content, "\n") {
+		scanner.scanLine(line)
+	}
+
+	if scanner.mismatch != nil {
+		return fmt.Errorf("%s: %v", path, scanner.mismatch)
+	}
+	if scanner.closer != "" {
+		return fmt.Errorf("%s: line %d: unterminated string or comment, expected a closing %s", path, scanner.closerLine, scanner.closer)
+	}
+	if len(scanner.open) > 0 {
+		unclosed := scanner.open[len(scanner.open)-1]
+		return fmt.Errorf("%s: line %d: '%c' is never closed", path, unclosed.line, unclosed.char)
+	}
+	return nil
+}
+
+// checkBuildSyntax applies a build's replacements to the full file and checks the result with the validator for its language. checked is false if there's no validator for the file's type. Errors are only reported if they were introduced by the build--a file that didn't parse before the build isn't held to a higher standard after it.
+func (fileState *activeBuildStreamFileState) checkBuildSyntax(replacements []*shared.Replacement) (checked bool, err error) {
+	ext := strings.ToLower(filepath.Ext(fileState.filePath))
+	validate, ok := syntaxValidators[ext]
+	if !ok {
+		log.Printf("File %s: no syntax validator for '%s' files, the build result isn't checked\n", fileState.filePath, ext)
+		return false, nil
+	}
+
+	original := fileState.currentState
+	if fileState.sectionSpan != nil {
+		original = fileState.sectionSpan.fullState
+	}
+
+	updated, allSucceeded := shared.ApplyReplacements(original, replacements, false)
+	if !allSucceeded {
+		return false, nil
+	}
+
+	err = validate(fileState.filePath, updated)
+	if err == nil {
+		return true, nil
+	}
+
+	if validate(fileState.filePath, original) != nil {
+		return true, nil
+	}
+
+	return true, err
+}
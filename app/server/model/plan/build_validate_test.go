@@ -0,0 +1,64 @@
+package plan
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSyntaxValidators(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		content string
+		wantErr string // "" for valid
+	}{
+		{"valid yaml", "config.yml", "a: 1\nb:\n  - x\n---\nc: 2\n", ""},
+		{"broken yaml", "config.yaml", "a: 1\n  b: 2\n", "config.yaml"},
+		{"valid xml", "pom.xml", "<project><name>x</name></project>", ""},
+		{"broken xml", "icon.svg", "<svg><g></svg>", "icon.svg"},
+		{"valid ts", "app.ts", "function f(a: string) {\n  return `${a} }`;\n}\n", ""},
+		{"ts brackets in strings and comments", "app.ts", "const s = '{'; // }\n/* ) */\nconst t = \"[\";\n", ""},
+		{"ts missing brace", "app.ts", "function f() {\n  if (x) {\n    y();\n}\n", "line 1: '{' is never closed"},
+		{"ts extra brace", "app.ts", "function f() {\n}\n}\n", "line 3: unexpected '}'"},
+		{"ts mismatched", "app.ts", "f(a, [b);\n", "line 1: ')' doesn't match the '['"},
+		{"unterminated block comment", "Main.java", "class A {}\n/* never\nclosed\n", "line 2: unterminated"},
+		{"python docstring", "app.py", "def f():\n    \"\"\"a ( doc\n    \"\"\"\n    return [1,\n      2]\n", ""},
+		{"unterminated docstring", "app.py", "def f():\n    \"\"\"doc\n", "line 2: unterminated"},
+		{"rust chars and lifetimes", "lib.rs", "fn f<'a>(s: &'a str) -> char {\n    if s.is_empty() { '{' } else { '\\'' }\n}\n", ""},
+		{"rust missing brace", "lib.rs", "fn f() {\n    let c = '}';\n", "'{' is never closed"},
+	}
+
+	for _, tt := range tests {
+		validate, ok := syntaxValidators[filepath.Ext(tt.path)]
+		if !ok {
+			t.Fatalf("%s: no validator for %s", tt.name, tt.path)
+		}
+
+		err := validate(tt.path, tt.content)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: expected no error, got %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: expected an error containing %q, got %v", tt.name, tt.wantErr, err)
+		}
+	}
+}
+
+func TestCheckBuildSyntaxUncheckedTypes(t *testing.T) {
+	fileState := &activeBuildStreamFileState{filePath: "README.md", currentState: "# Title\n"}
+
+	checked, err := fileState.checkBuildSyntax(nil)
+	if checked || err != nil {
+		t.Errorf("expected a file type without a validator to be reported as unchecked, got checked=%v err=%v", checked, err)
+	}
+
+	fileState = &activeBuildStreamFileState{filePath: "app.ts", currentState: "function f() {\n}\n"}
+	checked, err = fileState.checkBuildSyntax(nil)
+	if !checked || err != nil {
+		t.Errorf("expected a valid ts file to be checked without errors, got checked=%v err=%v", checked, err)
+	}
+}
@@ -12,21 +12,46 @@ import (
 	"github.com/sashabaranov/go-openai"
 )
 
-func genPlanDescription(client *openai.Client, config shared.TaskRoleConfig, planId, branch string, ctx context.Context) (*db.ConvoMessageDescription, error) {
+func genPlanDescription(client *openai.Client, settings *shared.PlanSettings, orgId, userId, planId, branch string, ctx context.Context) (*db.ConvoMessageDescription, error) {
 	activePlan := GetActivePlan(planId, branch)
 	if activePlan == nil {
 		return nil, fmt.Errorf("active plan not found")
 	}
 
-	descResp, err := model.CreateChatCompletionWithRetries(
+	config := settings.ModelSet.CommitMsg
+
+	subtasks, err := db.GetPlanSubtasks(orgId, planId)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := []openai.ChatCompletionMessage{
+		{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: prompts.SysDescribe,
+		},
+	}
+	if len(subtasks) > 0 {
+		messages = append(messages, openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: prompts.GetDescribeSubtasksPrompt(subtasks),
+		})
+	}
+	messages = append(messages, openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleAssistant,
+		Content: activePlan.CurrentReplyContent,
+	})
+
+	descResp, err := model.CreateMeteredChatCompletion(
 		client,
 		ctx,
+		planMeter(orgId, userId, planId, branch, settings, shared.UsagePhaseDescription),
 		openai.ChatCompletionRequest{
 			Model: config.BaseModelConfig.ModelName,
 			Tools: []openai.Tool{
 				{
 					Type:     "function",
-					Function: prompts.DescribePlanFn,
+					Function: &prompts.DescribePlanFn,
 				},
 			},
 			ToolChoice: openai.ToolChoice{
@@ -35,16 +60,7 @@ func genPlanDescription(client *openai.Client, config shared.TaskRoleConfig, pla
 					Name: prompts.DescribePlanFn.Name,
 				},
 			},
-			Messages: []openai.ChatCompletionMessage{
-				{
-					Role:    openai.ChatMessageRoleSystem,
-					Content: prompts.SysDescribe,
-				},
-				{
-					Role:    openai.ChatMessageRoleAssistant,
-					Content: activePlan.CurrentReplyContent,
-				},
-			},
+			Messages:       messages,
 			Temperature:    config.Temperature,
 			TopP:           config.TopP,
 			ResponseFormat: config.OpenAIResponseFormat,
@@ -82,7 +98,9 @@ func genPlanDescription(client *openai.Client, config shared.TaskRoleConfig, pla
 	}
 
 	return &db.ConvoMessageDescription{
-		PlanId:    planId,
-		CommitMsg: desc.CommitMsg,
+		PlanId:            planId,
+		CommitMsg:         desc.CommitMsg,
+		Commands:          desc.Commands,
+		CompletedSubtasks: desc.CompletedSubtasks,
 	}, nil
 }
@@ -13,7 +13,7 @@ import (
 	"github.com/sashabaranov/go-openai"
 )
 
-func ExecStatusShouldContinue(client *openai.Client, config shared.TaskRoleConfig, prompt, message string, ctx context.Context) (bool, error) {
+func ExecStatusShouldContinue(client *openai.Client, config shared.TaskRoleConfig, meter model.Meter, prompt, message string, ctx context.Context) (bool, error) {
 	log.Println("Checking if plan should continue based on exec status")
 
 	// First try to determine if the plan should continue based on the last paragraph without calling the model
@@ -46,15 +46,16 @@ func ExecStatusShouldContinue(client *openai.Client, config shared.TaskRoleConfi
 
 	log.Println("Calling model to check if plan should continue")
 
-	resp, err := model.CreateChatCompletionWithRetries(
+	resp, err := model.CreateMeteredChatCompletion(
 		client,
 		ctx,
+		meter,
 		openai.ChatCompletionRequest{
 			Model: config.BaseModelConfig.ModelName,
 			Tools: []openai.Tool{
 				{
 					Type:     "function",
-					Function: prompts.ShouldAutoContinueFn,
+					Function: &prompts.ShouldAutoContinueFn,
 				},
 			},
 			ToolChoice: openai.ToolChoice{
@@ -0,0 +1,62 @@
+package plan
+
+import (
+	"log"
+	"plandex-server/db"
+	"plandex-server/notify"
+	"plandex-server/types"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// notifyStreamDone posts a finished or failed stream to the org's Slack webhook, if it has one, with the files it built and what it cost. The prompt is only included if the org has opted in.
+func notifyStreamDone(active *types.ActivePlan, apiErr *shared.ApiError) {
+	plan, err := db.GetPlan(active.Id)
+	if err != nil {
+		log.Printf("Error getting plan %s for notification: %v\n", active.Id, err)
+		return
+	}
+
+	org, err := db.GetOrg(plan.OrgId)
+	if err != nil {
+		log.Printf("Error getting org for plan %s notification: %v\n", active.Id, err)
+		return
+	}
+	if org.SlackWebhookUrl == nil {
+		return
+	}
+
+	records, err := db.GetPlanUsage(plan.OrgId, active.Id)
+	if err != nil {
+		log.Printf("Error getting plan %s usage for notification: %v\n", active.Id, err)
+		return
+	}
+
+	var usage shared.UsageTotal
+	for _, record := range records {
+		if record.Branch == active.Branch && !record.CreatedAt.Before(active.StartedAt) {
+			usage.Add(record.ModelUsage)
+		}
+	}
+
+	event := notify.PlanEvent{
+		PlanId:   active.Id,
+		PlanName: plan.Name,
+		Branch:   active.Branch,
+		NumFiles: len(active.BuiltFiles),
+		Usage:    usage,
+		Duration: time.Since(active.StartedAt),
+	}
+	if org.SlackIncludePrompts {
+		event.Prompt = active.Prompt
+	}
+	if apiErr != nil {
+		event.Error = apiErr.Msg
+	}
+
+	err = notify.PostPlanEventToSlack(*org.SlackWebhookUrl, event)
+	if err != nil {
+		log.Printf("Error notifying slack for plan %s: %v\n", active.Id, err)
+	}
+}
@@ -53,6 +53,11 @@ func CreateActivePlan(planId, branch, prompt string, buildOnly bool) *types.Acti
 				} else {
 					log.Printf("Error streaming plan %s: %v\n", planId, apiErr)
 
+					// before the client hears of the error, so a retry starts from the replaced prompt
+					if activePlan.RestoreReplacedPrompt != nil {
+						activePlan.RestoreReplacedPrompt()
+					}
+
 					err := db.SetPlanStatus(planId, branch, shared.PlanStatusError, apiErr.Msg)
 					if err != nil {
 						log.Printf("Error setting plan %s status to error: %v\n", planId, err)
@@ -70,6 +75,8 @@ func CreateActivePlan(planId, branch, prompt string, buildOnly bool) *types.Acti
 					time.Sleep(50 * time.Millisecond)
 				}
 
+				go notifyStreamDone(activePlan, apiErr)
+
 				activePlan.CancelFn()
 				DeleteActivePlan(planId, branch)
 				return
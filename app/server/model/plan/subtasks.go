@@ -0,0 +1,54 @@
+package plan
+
+import (
+	"plandex-server/db"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// updatePlanSubtasks marks the subtasks a reply completed, then applies any new breakdown from the reply. A nested breakdown replaces the first unfinished subtask, since that's the one being worked on, and any other breakdown replaces the whole list.
+func updatePlanSubtasks(orgId, planId string, desc *db.ConvoMessageDescription, nested bool) error {
+	if len(desc.Subtasks) == 0 && len(desc.CompletedSubtasks) == 0 {
+		return nil
+	}
+
+	subtasks, err := db.GetPlanSubtasks(orgId, planId)
+	if err != nil {
+		return err
+	}
+
+	for _, num := range desc.CompletedSubtasks {
+		if num >= 1 && num <= len(subtasks) {
+			subtasks[num-1].Done = true
+		}
+	}
+
+	if len(desc.Subtasks) > 0 {
+		var added []*shared.Subtask
+		for _, title := range desc.Subtasks {
+			added = append(added, &shared.Subtask{Title: title})
+		}
+
+		current := -1
+		if nested {
+			for i, subtask := range subtasks {
+				if !subtask.Done {
+					current = i
+					break
+				}
+			}
+		}
+
+		if current == -1 {
+			subtasks = added
+		} else {
+			var res []*shared.Subtask
+			res = append(res, subtasks[:current]...)
+			res = append(res, added...)
+			res = append(res, subtasks[current+1:]...)
+			subtasks = res
+		}
+	}
+
+	return db.StorePlanSubtasks(orgId, planId, subtasks)
+}
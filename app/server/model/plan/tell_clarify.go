@@ -0,0 +1,87 @@
+package plan
+
+import (
+	"log"
+	"net/http"
+	"plandex-server/db"
+	"plandex-server/model/prompts"
+	"plandex-server/types"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// how long a plan waits for answers to its clarifying questions before it stops, so an abandoned plan doesn't stay active
+const clarifyAnswerTimeout = 30 * time.Minute
+
+// canClarify is whether this reply may ask clarifying questions instead of making a plan. Only the first reply to a new prompt can, and only when the client can answer.
+func (state *activeTellStreamState) canClarify() bool {
+	req := state.req
+	return req.AllowClarify && !req.ChatOnly && !req.IsUserContinue && state.iteration == 0 && state.missingFileResponse == ""
+}
+
+// waitForClarification pauses the plan until the client answers the model's questions, then sends the answers as a new prompt in the same stream, so the plan is made with them. The answers can't lead to another round of questions.
+func (state *activeTellStreamState) waitForClarification(questions []string) {
+	planId := state.plan.Id
+	branch := state.branch
+
+	active := GetActivePlan(planId, branch)
+	if active == nil {
+		log.Printf("waitForClarification: Active plan not found for plan ID %s on branch %s\n", planId, branch)
+		return
+	}
+
+	err := db.SetPlanStatus(planId, branch, shared.PlanStatusClarifying, "")
+	if err != nil {
+		log.Printf("Error setting plan %s status to clarifying: %v\n", planId, err)
+		active.StreamDoneCh <- &shared.ApiError{
+			Type:   shared.ApiErrorTypeOther,
+			Status: http.StatusInternalServerError,
+			Msg:    "Error setting plan status to clarifying",
+		}
+		return
+	}
+
+	UpdateActivePlan(planId, branch, func(ap *types.ActivePlan) {
+		ap.ClarifyingQuestions = questions
+	})
+
+	log.Printf("Prompting user to answer %d clarifying questions\n", len(questions))
+
+	active.Stream(shared.StreamMessage{
+		Type:                shared.StreamMessagePromptClarify,
+		ClarifyingQuestions: questions,
+	})
+
+	var answers []string
+	select {
+	case <-active.Ctx.Done():
+		log.Println("Context cancelled while waiting for clarifying answers")
+		return
+	case <-time.After(clarifyAnswerTimeout):
+		log.Printf("Timed out waiting for clarifying answers for plan %s on branch %s\n", planId, branch)
+		active.StreamDoneCh <- &shared.ApiError{
+			Type:   shared.ApiErrorTypeOther,
+			Status: http.StatusRequestTimeout,
+			Msg:    "Timed out waiting for answers to the clarifying questions",
+		}
+		return
+	case answers = <-active.ClarifyResponseCh:
+	}
+
+	UpdateActivePlan(planId, branch, func(ap *types.ActivePlan) {
+		ap.ClarifyingQuestions = nil
+		ap.CurrentReplyContent = ""
+		ap.NumTokens = 0
+		ap.CurrentReplyUsage = nil
+	})
+
+	clarifiedReq := *state.req
+	clarifiedReq.Prompt = prompts.GetClarifiedPrompt(questions, answers)
+	clarifiedReq.AllowClarify = false
+	clarifiedReq.ReplaceLastPrompt = false
+
+	log.Println("Continuing plan with clarifying answers")
+
+	execTellPlan(state.client, state.plan, branch, state.auth, &clarifiedReq, 0, "", false)
+}
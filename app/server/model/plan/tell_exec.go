@@ -35,7 +35,7 @@ func Tell(client *openai.Client, plan *db.Plan, branch string, auth *types.Serve
 		req,
 		0,
 		"",
-		req.BuildMode == shared.BuildModeAuto,
+		req.BuildMode == shared.BuildModeAuto && !req.ChatOnly,
 	)
 
 	log.Printf("Tell: Tell operation completed successfully for plan ID %s on branch %s\n", plan.Id, branch)
@@ -112,6 +112,38 @@ func execTellPlan(
 		return
 	}
 
+	err = checkBudget(currentOrgId, planId, state.settings)
+	if err != nil {
+		if apiErr := model.BudgetApiError(err); apiErr != nil {
+			active.StreamDoneCh <- apiErr
+		} else {
+			log.Printf("Error checking budget for plan %s: %v\n", planId, err)
+			active.StreamDoneCh <- &shared.ApiError{
+				Type:   shared.ApiErrorTypeOther,
+				Status: http.StatusInternalServerError,
+				Msg:    "Error checking budget",
+			}
+		}
+		return
+	}
+
+	if req.AutoRun != nil {
+		err = checkAutoRun(currentOrgId, planId, req.AutoRun, state.autoRunDescs)
+		if err != nil {
+			if apiErr := autoLimitApiError(err); apiErr != nil {
+				active.StreamDoneCh <- apiErr
+			} else {
+				log.Printf("Error checking automated run limits for plan %s: %v\n", planId, err)
+				active.StreamDoneCh <- &shared.ApiError{
+					Type:   shared.ApiErrorTypeOther,
+					Status: http.StatusInternalServerError,
+					Msg:    "Error checking automated run limits",
+				}
+			}
+			return
+		}
+	}
+
 	if iteration == 0 && missingFileResponse == "" {
 		UpdateActivePlan(planId, branch, func(ap *types.ActivePlan) {
 			ap.Contexts = state.modelContext
@@ -127,6 +159,7 @@ func execTellPlan(
 		UpdateActivePlan(planId, branch, func(ap *types.ActivePlan) {
 			ap.CurrentReplyContent = ""
 			ap.NumTokens = 0
+			ap.CurrentReplyUsage = nil
 		})
 	}
 
@@ -160,7 +193,24 @@ func execTellPlan(
 		return
 	}
 
-	systemMessageText := prompts.SysCreate + modelContextText
+	sysPrompt := prompts.SysCreate
+	sysPromptTokens := prompts.CreateSysMsgNumTokens
+	promptWrapperTokens := prompts.PromptWrapperTokens
+	getWrappedPrompt := prompts.GetWrappedPrompt
+	if req.ChatOnly {
+		sysPrompt = prompts.SysChat
+		sysPromptTokens = prompts.ChatSysMsgNumTokens
+		promptWrapperTokens = prompts.ChatPromptWrapperTokens
+		getWrappedPrompt = prompts.GetWrappedChatPrompt
+	}
+
+	systemMessageText := sysPrompt + modelContextText
+	if state.settings.WorkspaceMember != "" {
+		systemMessageText += prompts.GetWorkspaceMemberPrompt(state.settings.WorkspaceMember)
+	}
+	if state.canClarify() {
+		systemMessageText += prompts.ClarifyPrompt
+	}
 	systemMessage := openai.ChatCompletionMessage{
 		Role:    openai.ChatMessageRoleSystem,
 		Content: systemMessageText,
@@ -182,7 +232,7 @@ func execTellPlan(
 		promptTokens    int
 	)
 	if iteration == 0 && missingFileResponse == "" {
-		numPromptTokens, err = shared.GetNumTokens(req.Prompt)
+		numPromptTokens, err = state.tokenCounter.Get("prompt-planner")
 		if err != nil {
 			err = fmt.Errorf("error getting number of tokens in prompt: %v", err)
 			log.Println(err)
@@ -193,13 +243,13 @@ func execTellPlan(
 			}
 			return
 		}
-		promptTokens = prompts.PromptWrapperTokens + numPromptTokens
+		promptTokens = promptWrapperTokens + numPromptTokens
 	}
 
-	state.tokensBeforeConvo = prompts.CreateSysMsgNumTokens + modelContextTokens + promptTokens
+	state.tokensBeforeConvo = sysPromptTokens + modelContextTokens + promptTokens
 
 	// print out breakdown of token usage
-	log.Printf("System message tokens: %d\n", prompts.CreateSysMsgNumTokens)
+	log.Printf("System message tokens: %d\n", sysPromptTokens)
 	log.Printf("Context tokens: %d\n", modelContextTokens)
 	log.Printf("Prompt tokens: %d\n", promptTokens)
 	log.Printf("Total tokens before convo: %d\n", state.tokensBeforeConvo)
@@ -220,6 +270,11 @@ func execTellPlan(
 		return
 	}
 
+	state.replyModel = state.settings.ModelSet.Planner.ModelRoleConfig
+	if req.ChatOnly {
+		state.replyModel = state.getChatModel()
+	}
+
 	state.replyId = uuid.New().String()
 	state.replyParser = types.NewReplyParser()
 
@@ -244,17 +299,15 @@ func execTellPlan(
 				state.messages = state.messages[:len(state.messages)-1]
 				promptMessage = &openai.ChatCompletionMessage{
 					Role:    openai.ChatMessageRoleUser,
-					Content: prompts.GetWrappedPrompt(lastMessage.Content),
+					Content: getWrappedPrompt(lastMessage.Content),
 				}
 			} else {
 				// otherwise we'll use the continue prompt
 				promptMessage = &openai.ChatCompletionMessage{
 					Role:    openai.ChatMessageRoleUser,
-					Content: prompts.GetWrappedPrompt(prompts.UserContinuePrompt),
+					Content: getWrappedPrompt(prompts.GetUserContinuePrompt(state.subtasks)),
 				}
 			}
-
-			state.messages = append(state.messages, *promptMessage)
 		} else {
 			var prompt string
 			if iteration == 0 {
@@ -265,7 +318,7 @@ func execTellPlan(
 
 			promptMessage = &openai.ChatCompletionMessage{
 				Role:    openai.ChatMessageRoleUser,
-				Content: prompts.GetWrappedPrompt(prompt),
+				Content: getWrappedPrompt(prompt),
 			}
 		}
 
@@ -286,7 +339,7 @@ func execTellPlan(
 
 		if missingFileResponse == shared.RespondMissingFileChoiceSkip {
 			replyBeforeCurrentFile := state.replyParser.GetReplyBeforeCurrentPath()
-			numTokens, err = shared.GetNumTokens(replyBeforeCurrentFile)
+			numTokens, err = shared.GetNumTokensForModel(state.settings.ModelSet.Planner.BaseModelConfig, replyBeforeCurrentFile)
 			if err != nil {
 				log.Printf("Error getting num tokens for reply before current file: %v\n", err)
 				active.StreamDoneCh <- &shared.ApiError{
@@ -342,17 +395,28 @@ func execTellPlan(
 	// }
 
 	modelReq := openai.ChatCompletionRequest{
-		Model:       state.settings.ModelSet.Planner.BaseModelConfig.ModelName,
+		Model:       state.replyModel.BaseModelConfig.ModelName,
 		Messages:    state.messages,
 		Stream:      true,
-		Temperature: state.settings.ModelSet.Planner.Temperature,
-		TopP:        state.settings.ModelSet.Planner.TopP,
+		Temperature: state.replyModel.Temperature,
+		TopP:        state.replyModel.TopP,
+		StreamOptions: &openai.StreamOptions{
+			IncludeUsage: true,
+		},
 	}
 
-	stream, err := model.CreateChatCompletionStreamWithRetries(client, active.ModelStreamCtx, modelReq)
+	// the reply's usage is recorded by onReplyUsage when the stream finishes
+	meter := planMeter(state.currentOrgId, state.currentUserId, planId, branch, state.settings, shared.UsagePhaseReply)
+
+	stream, err := model.CreateMeteredChatCompletionStreamOrFallback(client, active.ModelStreamCtx, meter, modelReq)
 	if err != nil {
 		log.Printf("Error starting reply stream: %v\n", err)
 
+		if apiErr := model.BudgetApiError(err); apiErr != nil {
+			active.StreamDoneCh <- apiErr
+			return
+		}
+
 		active.StreamDoneCh <- &shared.ApiError{
 			Type:   shared.ApiErrorTypeOther,
 			Status: http.StatusInternalServerError,
@@ -404,3 +468,21 @@ func execTellPlan(
 
 	go state.listenStream(stream)
 }
+
+// getChatModel routes chat replies to the chat model, which is usually cheaper than the planner. If the context and conversation don't fit in the chat model's context window, the planner answers instead.
+func (state *activeTellStreamState) getChatModel() shared.ModelRoleConfig {
+	chatModel := state.settings.ModelSet.GetChatRoleConfig()
+
+	totalTokens := state.tokensBeforeConvo
+	if state.convoHistory != nil {
+		totalTokens += state.convoHistory.Tokens
+	}
+
+	if totalTokens > chatModel.BaseModelConfig.MaxTokens-state.settings.GetPlannerReservedOutputTokens() {
+		log.Printf("Chat: %d tokens don't fit in %s, using the planner\n", totalTokens, chatModel.BaseModelConfig.ModelName)
+		return state.settings.ModelSet.Planner.ModelRoleConfig
+	}
+
+	log.Printf("Chat: using %s\n", chatModel.BaseModelConfig.ModelName)
+	return chatModel
+}
@@ -0,0 +1,168 @@
+package plan
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"plandex-server/db"
+	"strconv"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// storeMovedFiles stores each move as a pair of results: the file's current content at its new path, and a removal at its old path. Like removals, moves don't need a build.
+func storeMovedFiles(orgId, planId, convoMessageId string, movedFiles []*shared.MovedFile) error {
+	planState, err := db.GetCurrentPlanState(db.CurrentPlanStateParams{
+		OrgId:  orgId,
+		PlanId: planId,
+	})
+
+	if err != nil {
+		return fmt.Errorf("error getting current plan state: %v", err)
+	}
+
+	for _, moved := range movedFiles {
+		content, ok := planState.CurrentPlanFiles.Files[moved.From]
+		if !ok {
+			context := planState.ContextsByPath[moved.From]
+			if context == nil {
+				log.Printf("Skipping move of %s, it isn't in context or the plan\n", moved.From)
+				continue
+			}
+			content = context.Body
+		}
+
+		err = db.StorePlanResult(&db.PlanFileResult{
+			OrgId:          orgId,
+			PlanId:         planId,
+			ConvoMessageId: convoMessageId,
+			Path:           moved.To,
+			Content:        content,
+			MovedFrom:      moved.From,
+		})
+
+		if err != nil {
+			return fmt.Errorf("error storing moved file %s: %v", moved.To, err)
+		}
+
+		err = db.StorePlanResult(&db.PlanFileResult{
+			OrgId:          orgId,
+			PlanId:         planId,
+			ConvoMessageId: convoMessageId,
+			Path:           moved.From,
+			RemovedFile:    true,
+		})
+
+		if err != nil {
+			return fmt.Errorf("error storing removal of %s: %v", moved.From, err)
+		}
+	}
+
+	return nil
+}
+
+func validateRemovedAndMovedPaths(removedFiles []string, movedFiles []*shared.MovedFile) error {
+	for _, path := range removedFiles {
+		err := shared.ValidatePlanPath(path)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, moved := range movedFiles {
+		err := shared.ValidatePlanPath(moved.From)
+		if err != nil {
+			return err
+		}
+		err = shared.ValidatePlanPath(moved.To)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getBaseShas records the sha of each file a reply touches as it was in context when the changes were proposed, so apply can tell if a file changed since
+func (state *activeTellStreamState) getBaseShas(replyFiles, removedFiles []string, movedFiles []*shared.MovedFile) map[string]string {
+	shasByPath := map[string]string{}
+	for _, context := range state.modelContext {
+		if context.ContextType == shared.ContextFileType {
+			shasByPath[context.FilePath] = context.Sha
+		}
+	}
+
+	paths := append(append([]string{}, replyFiles...), removedFiles...)
+	for _, moved := range movedFiles {
+		paths = append(paths, moved.From, moved.To)
+	}
+
+	res := map[string]string{}
+	for _, path := range paths {
+		res[path] = shasByPath[path]
+	}
+	return res
+}
+
+// resolveReplyPath maps a file path from the reply onto the context or project path it refers to, so a file the model writes with different slashes, a leading './', or different case is still built against the right file, and shares its build queue with other changes to it
+func resolveReplyPath(file string, contextsByPath map[string]*db.Context, projectPaths map[string]bool, workspaceMember string) string {
+	if file == "" || contextsByPath[file] != nil || projectPaths[file] {
+		return file
+	}
+
+	knownPaths := make([]string, 0, len(contextsByPath)+len(projectPaths))
+	for path := range contextsByPath {
+		knownPaths = append(knownPaths, path)
+	}
+	for path := range projectPaths {
+		knownPaths = append(knownPaths, path)
+	}
+
+	resolved := shared.ResolvePlanPath(file, knownPaths)
+	if workspaceMember != "" && contextsByPath[resolved] == nil && !projectPaths[resolved] {
+		// a new file named relative to the plan's workspace member belongs inside it
+		resolved = shared.ResolveWorkspaceMemberPath(resolved, workspaceMember, knownPaths)
+	}
+	if resolved != file {
+		log.Printf("Resolved reply path %s to %s\n", file, resolved)
+	}
+	return resolved
+}
+
+// checkPlanFileLimit stops a reply that would take the number of files the plan writes past its limit, counting files with pending changes from earlier replies along with the reply's own. The server can set a lower ceiling than the plan's setting with PLANDEX_MAX_PLAN_FILES.
+func (state *activeTellStreamState) checkPlanFileLimit(replyFiles []string, newFile string) error {
+	limit := state.settings.GetMaxPlanFiles()
+	if serverLimit, err := strconv.Atoi(os.Getenv("PLANDEX_MAX_PLAN_FILES")); err == nil && serverLimit > 0 && serverLimit < limit {
+		limit = serverLimit
+	}
+
+	if state.pendingPlanPaths == nil {
+		planState, err := db.GetCurrentPlanState(db.CurrentPlanStateParams{
+			OrgId:  state.currentOrgId,
+			PlanId: state.plan.Id,
+		})
+		if err != nil {
+			return fmt.Errorf("error getting current plan state: %v", err)
+		}
+
+		state.pendingPlanPaths = map[string]bool{}
+		for path := range planState.CurrentPlanFiles.Files {
+			state.pendingPlanPaths[path] = true
+		}
+	}
+
+	paths := map[string]bool{}
+	for path := range state.pendingPlanPaths {
+		paths[path] = true
+	}
+	for _, path := range replyFiles {
+		paths[path] = true
+	}
+	paths[newFile] = true
+
+	if len(paths) > limit {
+		return &shared.PlanFileLimitError{Limit: limit}
+	}
+
+	return nil
+}
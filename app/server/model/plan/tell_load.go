@@ -1,6 +1,7 @@
 package plan
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
@@ -56,24 +57,83 @@ func (state *activeTellStreamState) loadTellPlan() error {
 		return err
 	}
 
+	isNewPrompt := iteration == 0 && missingFileResponse == ""
+
+	if isNewPrompt && req.ReplaceLastPrompt {
+		// the replaced prompt is kept until a reply to the new one is stored, so a failed stream doesn't lose it
+		var replacedSha string
+		replacedSha, err = db.GitGetHeadSha(currentOrgId, planId)
+		if err == nil {
+			err = db.GitRewindBeforeLastPrompt(currentOrgId, planId, branch)
+		}
+		if err == nil {
+			err = db.SyncPlanTokens(currentOrgId, planId, branch)
+		}
+
+		if err == nil {
+			UpdateActivePlan(planId, branch, func(ap *types.ActivePlan) {
+				ap.RestoreReplacedPrompt = func() {
+					restoreReplacedPrompt(currentOrgId, currentUserId, planId, branch, replacedSha)
+				}
+			})
+		} else {
+			log.Printf("execTellPlan: Error rewinding last prompt for plan ID %s on branch %s: %v\n", plan.Id, branch, err)
+
+			if replacedSha != "" {
+				restoreErr := db.GitRewindToSha(currentOrgId, planId, branch, replacedSha)
+				if restoreErr == nil {
+					restoreErr = db.SyncPlanTokens(currentOrgId, planId, branch)
+				}
+				if restoreErr != nil {
+					log.Printf("Error restoring replaced prompt: %v\n", restoreErr)
+				}
+			}
+
+			unlockErr := db.UnlockRepo(repoLockId)
+			if unlockErr != nil {
+				log.Printf("Error unlocking repo: %v\n", unlockErr)
+			}
+
+			active.StreamDoneCh <- &shared.ApiError{
+				Type:   shared.ApiErrorTypeOther,
+				Status: http.StatusInternalServerError,
+				Msg:    "Error rewinding last prompt",
+			}
+			return err
+		}
+	}
+	state.tokenCounter = db.NewTokenCounter(planId)
+	state.tokenCounter.Add("prompt", shared.DefaultTokenEncoding, req.Prompt)
+
 	errCh := make(chan error)
 	var modelContext []*db.Context
 	var convo []*db.ConvoMessage
 	var summaries []*db.ConvoSummary
 	var settings *shared.PlanSettings
+	var subtasks []*shared.Subtask
+	var autoRunDescs []*db.ConvoMessageDescription
 
 	// get name for plan and rename it's a draft
 	go func() {
-		res, err := db.GetPlanSettings(plan, true)
+		res, err := db.GetPlanSettings(plan, req.ModelSet == nil)
 		if err != nil {
 			log.Printf("Error getting plan settings: %v\n", err)
 			errCh <- fmt.Errorf("error getting plan settings: %v", err)
 			return
 		}
+		if req.ModelSetOverride != nil {
+			res.ModelSet = req.ModelSetOverride
+		} else if res.ModelSet == nil {
+			res.ModelSet = req.ModelSet
+		}
 		settings = res
 
+		if isNewPrompt {
+			state.tokenCounter.Add("prompt-planner", shared.GetTokenEncoding(settings.ModelSet.Planner.BaseModelConfig), req.Prompt)
+		}
+
 		if plan.Name == "draft" {
-			name, err := model.GenPlanName(client, settings.ModelSet.Namer, req.Prompt)
+			name, err := model.GenPlanName(client, settings.ModelSet.Namer, planMeter(currentOrgId, currentUserId, planId, branch, settings, shared.UsagePhaseName), req.Prompt)
 
 			if err != nil {
 				log.Printf("Error generating plan name: %v\n", err)
@@ -140,6 +200,32 @@ func (state *activeTellStreamState) loadTellPlan() error {
 		errCh <- nil
 	}()
 
+	go func() {
+		if isNewPrompt && req.IsUserContinue {
+			res, err := db.GetPlanSubtasks(currentOrgId, planId)
+			if err != nil {
+				log.Printf("Error getting plan subtasks: %v\n", err)
+				errCh <- fmt.Errorf("error getting plan subtasks: %v", err)
+				return
+			}
+			subtasks = res
+		}
+		errCh <- nil
+	}()
+
+	go func() {
+		if req.AutoRun != nil {
+			res, err := db.GetConvoMessageDescriptions(currentOrgId, planId)
+			if err != nil {
+				log.Printf("Error getting plan descriptions: %v\n", err)
+				errCh <- fmt.Errorf("error getting plan descriptions: %v", err)
+				return
+			}
+			autoRunDescs = res
+		}
+		errCh <- nil
+	}()
+
 	go func() {
 		res, err := db.GetPlanConvo(currentOrgId, planId)
 		if err != nil {
@@ -152,7 +238,8 @@ func (state *activeTellStreamState) loadTellPlan() error {
 			ap.MessageNum = len(convo)
 		})
 
-		promptTokens, err := shared.GetNumTokens(req.Prompt)
+		// the user message below is stored with this count, so it has to finish first
+		promptTokens, err := state.tokenCounter.Get("prompt")
 		if err != nil {
 			log.Printf("Error getting prompt num tokens: %v\n", err)
 			errCh <- fmt.Errorf("error getting prompt num tokens: %v", err)
@@ -163,7 +250,7 @@ func (state *activeTellStreamState) loadTellPlan() error {
 		var userMsg *db.ConvoMessage
 
 		go func() {
-			if iteration == 0 && missingFileResponse == "" && !req.IsUserContinue {
+			if iteration == 0 && missingFileResponse == "" && !req.IsUserContinue && !req.Ephemeral {
 				num := len(convo) + 1
 
 				log.Printf("storing user message | len(convo): %d | num: %d\n", len(convo), num)
@@ -255,13 +342,13 @@ func (state *activeTellStreamState) loadTellPlan() error {
 			}
 		}()
 
-		for i := 0; i < 3; i++ {
+		for i := 0; i < 5; i++ {
 			err = <-errCh
 			if err != nil {
 				active.StreamDoneCh <- &shared.ApiError{
 					Type:   shared.ApiErrorTypeOther,
 					Status: http.StatusInternalServerError,
-					Msg:    "Error getting plan, context, convo, or summaries",
+					Msg:    "Error getting plan, context, convo, summaries, or subtasks",
 				}
 				return err
 			}
@@ -278,6 +365,48 @@ func (state *activeTellStreamState) loadTellPlan() error {
 	state.convo = convo
 	state.summaries = summaries
 	state.settings = settings
+	state.subtasks = subtasks
+	state.autoRunDescs = autoRunDescs
 
 	return nil
 }
+
+// restoreReplacedPrompt rewinds the plan back to sha, the commit it was at before a prompt replaced the last one, after the stream for the new prompt failed without storing a reply
+func restoreReplacedPrompt(orgId, userId, planId, branch, sha string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	repoLockId, err := db.LockRepo(
+		db.LockRepoParams{
+			OrgId:    orgId,
+			UserId:   userId,
+			PlanId:   planId,
+			Branch:   branch,
+			Scope:    db.LockScopeWrite,
+			Ctx:      ctx,
+			CancelFn: cancel,
+		},
+	)
+	if err != nil {
+		log.Printf("Error locking repo to restore replaced prompt: %v\n", err)
+		return
+	}
+
+	defer func() {
+		err := db.UnlockRepo(repoLockId)
+		if err != nil {
+			log.Printf("Error unlocking repo: %v\n", err)
+		}
+	}()
+
+	err = db.GitRewindToSha(orgId, planId, branch, sha)
+	if err == nil {
+		err = db.SyncPlanTokens(orgId, planId, branch)
+	}
+	if err != nil {
+		log.Printf("Error restoring replaced prompt for plan %s on branch %s: %v\n", planId, branch, err)
+		return
+	}
+
+	log.Printf("Restored replaced prompt for plan %s on branch %s\n", planId, branch)
+}
@@ -0,0 +1,73 @@
+package plan
+
+import (
+	"log"
+	"plandex-server/model"
+	"plandex-server/model/prompts"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+)
+
+// selfReview has the planner critique the reply it just finished, with the same context and conversation it replied with, and streams the critique to the client. The critique is advisory, so errors are logged and don't fail the reply.
+func (state *activeTellStreamState) selfReview() {
+	planId := state.plan.Id
+	branch := state.branch
+
+	active := GetActivePlan(planId, branch)
+	if active == nil {
+		log.Printf("selfReview: Active plan not found for plan ID %s on branch %s\n", planId, branch)
+		return
+	}
+
+	config := state.replyModel
+
+	messages := append([]openai.ChatCompletionMessage{}, state.messages...)
+	messages = append(messages,
+		openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleAssistant,
+			Content: active.CurrentReplyContent,
+		},
+		openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleUser,
+			Content: prompts.SelfReviewPrompt,
+		},
+	)
+
+	log.Println("Getting self-review of reply")
+
+	resp, err := model.CreateMeteredChatCompletion(
+		state.client,
+		active.Ctx,
+		planMeter(state.currentOrgId, state.currentUserId, planId, branch, state.settings, shared.UsagePhaseSelfReview),
+		openai.ChatCompletionRequest{
+			Model:       config.BaseModelConfig.ModelName,
+			Messages:    messages,
+			Temperature: config.Temperature,
+			TopP:        config.TopP,
+		},
+	)
+
+	if err != nil {
+		log.Printf("Error during self-review model call: %v\n", err)
+		return
+	}
+
+	if len(resp.Choices) == 0 {
+		log.Println("Self-review response had no choices")
+		return
+	}
+
+	critique := strings.TrimSpace(resp.Choices[0].Message.Content)
+	if critique == "" {
+		return
+	}
+
+	log.Printf("Self-review: %s\n", critique)
+
+	active.Stream(shared.StreamMessage{
+		Type:       shared.StreamMessageSelfReview,
+		SelfReview: critique,
+	})
+}
@@ -1,11 +1,14 @@
 package plan
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"plandex-server/db"
 	"plandex-server/model"
+	"plandex-server/model/lib"
 	"plandex-server/types"
 	"strings"
 	"time"
@@ -37,9 +40,25 @@ type activeTellStreamState struct {
 	messages              []openai.ChatCompletionMessage
 	tokensBeforeConvo     int
 	settings              *shared.PlanSettings
+	convoHistory          *shared.ConvoHistory
+
+	// loaded for a user continue, so it can pick up the next unfinished subtask
+	subtasks []*shared.Subtask
+
+	// loaded when the request is part of an automated run, to check the run's limits
+	autoRunDescs []*db.ConvoMessageDescription
+
+	// the planner, or the chat model for chat-only replies
+	replyModel shared.ModelRoleConfig
+
+	// counts the prompt while the plan loads. "prompt" is counted for storage and "prompt-planner" with the planner's encoding for its token limit.
+	tokenCounter *db.TokenCounter
+
+	// paths with pending changes from earlier replies, loaded the first time a file is detected in this reply
+	pendingPlanPaths map[string]bool
 }
 
-func (state *activeTellStreamState) listenStream(stream *openai.ChatCompletionStream) {
+func (state *activeTellStreamState) listenStream(stream model.ChatCompletionStream) {
 	defer stream.Close()
 
 	client := state.client
@@ -70,6 +89,8 @@ func (state *activeTellStreamState) listenStream(stream *openai.ChatCompletionSt
 	replyFiles := []string{}
 	chunksReceived := 0
 	maybeRedundantBacktickContent := ""
+	var finishReason openai.FinishReason
+	var usage *openai.Usage
 
 	// Create a timer that will trigger if no chunk is received within the specified duration
 	timer := time.NewTimer(model.OPENAI_STREAM_CHUNK_TIMEOUT)
@@ -103,24 +124,54 @@ func (state *activeTellStreamState) listenStream(stream *openai.ChatCompletionSt
 					return
 				}
 
-				state.onError(fmt.Errorf("stream error: %v", err), true, "", "")
-				return
-			}
+				// if the provider doesn't send a usage chunk, the stream just ends after the finish reason
+				if !(errors.Is(err, io.EOF) && finishReason != "") {
+					state.onError(fmt.Errorf("stream error: %v", err), true, "", "")
+					return
+				}
+			} else {
+				if response.Usage != nil {
+					usage = response.Usage
+				}
 
-			if len(response.Choices) == 0 {
-				state.onError(fmt.Errorf("stream finished with no choices"), true, "", "")
-				return
-			}
+				if len(response.Choices) > 1 {
+					state.onError(fmt.Errorf("stream finished with more than one choice"), true, "", "")
+					return
+				}
 
-			if len(response.Choices) > 1 {
-				state.onError(fmt.Errorf("stream finished with more than one choice"), true, "", "")
-				return
+				if len(response.Choices) == 0 && finishReason == "" {
+					state.onError(fmt.Errorf("stream finished with no choices"), true, "", "")
+					return
+				}
+
+				if len(response.Choices) == 1 && response.Choices[0].FinishReason != "" {
+					// wait for the usage chunk, which comes after the finish reason
+					finishReason = response.Choices[0].FinishReason
+					continue
+				}
 			}
 
-			choice := response.Choices[0]
+			if finishReason != "" {
+				log.Printf("Model stream finished | finish reason: %s\n", finishReason)
+
+				state.onReplyUsage(usage)
 
-			if choice.FinishReason != "" {
-				log.Println("Model stream finished")
+				if req.Ephemeral {
+					// nothing is stored, so the plan's conversation and history are left as they were
+					log.Println("Ephemeral reply finished")
+
+					active.CurrentReplyDoneCh <- true
+					UpdateActivePlan(planId, branch, func(ap *types.ActivePlan) {
+						ap.CurrentStreamingReplyId = ""
+						ap.CurrentReplyDoneCh = nil
+						ap.RepliesFinished = true
+					})
+
+					active.Stream(shared.StreamMessage{
+						Type: shared.StreamMessageFinished,
+					})
+					return
+				}
 
 				active.Stream(shared.StreamMessage{
 					Type: shared.StreamMessageDescribing,
@@ -131,9 +182,19 @@ func (state *activeTellStreamState) listenStream(stream *openai.ChatCompletionSt
 					state.onError(fmt.Errorf("failed to set plan status to describing: %v", err), true, "", "")
 					return
 				}
+
+				if settings.GetSelfReview() && !req.ChatOnly && len(replyFiles) > 0 {
+					state.selfReview()
+				}
 				// log.Println("summarize convo:", spew.Sdump(convo))
 
-				if len(convo) > 0 {
+				convoTokens := state.replyNumTokens
+				for _, convoMessage := range convo {
+					convoTokens += convoMessage.Tokens
+				}
+
+				// other policies drop old messages instead of summarizing them
+				if len(convo) > 0 && convoTokens >= settings.GetConvoSummaryThresholdTokens() && settings.GetConvoPolicy() == shared.ConvoPolicySummarize {
 					// summarize in the background
 					go summarizeConvo(client, settings.ModelSet.PlanSummary, summarizeConvoParams{
 						planId:        planId,
@@ -142,6 +203,7 @@ func (state *activeTellStreamState) listenStream(stream *openai.ChatCompletionSt
 						summaries:     summaries,
 						promptMessage: promptMessage,
 						currentOrgId:  currentOrgId,
+						meter:         planMeter(currentOrgId, currentUserId, planId, branch, settings, shared.UsagePhaseSummary),
 					}, active.SummaryCtx)
 				}
 
@@ -172,6 +234,7 @@ func (state *activeTellStreamState) listenStream(stream *openai.ChatCompletionSt
 				log.Println("Locked repo for assistant reply and description")
 
 				var shouldContinue bool
+				var questions []string
 				err = func() error {
 					defer func() {
 						if err != nil {
@@ -210,7 +273,7 @@ func (state *activeTellStreamState) listenStream(stream *openai.ChatCompletionSt
 						log.Println("getting description")
 						log.Println("getting description for assistant message: ", assistantMsg.Id)
 
-						if len(replyFiles) == 0 {
+						if req.ChatOnly {
 							description = &db.ConvoMessageDescription{
 								OrgId:                 currentOrgId,
 								PlanId:                planId,
@@ -219,9 +282,50 @@ func (state *activeTellStreamState) listenStream(stream *openai.ChatCompletionSt
 								BuildPathsInvalidated: map[string]bool{},
 								MadePlan:              false,
 							}
+
+							err = db.StoreDescription(description)
+							if err != nil {
+								state.onError(fmt.Errorf("failed to store description: %v", err), false, assistantMsg.Id, convoCommitMsg)
+							}
+							errCh <- err
+							return
+						}
+
+						removedFiles := types.ParseRemovedFiles(assistantMsg.Message)
+						movedFiles := types.ParseMovedFiles(assistantMsg.Message)
+						subtasks, nestedSubtasks := types.ParseSubtasks(assistantMsg.Message)
+
+						policyErr := validateRemovedAndMovedPaths(removedFiles, movedFiles)
+						if policyErr != nil {
+							state.onError(policyErr, false, assistantMsg.Id, convoCommitMsg)
+							errCh <- policyErr
+							return
+						}
+
+						if len(replyFiles) == 0 && len(removedFiles) == 0 && len(movedFiles) == 0 {
+							description = &db.ConvoMessageDescription{
+								OrgId:                 currentOrgId,
+								PlanId:                planId,
+								ConvoMessageId:        assistantMsg.Id,
+								SummarizedToMessageId: summarizedToMessageId,
+								BuildPathsInvalidated: map[string]bool{},
+								MadePlan:              false,
+							}
+
+							// replies without file changes can still ask for commands to be run, like installing a dependency. Commands are optional, so errors here don't fail the reply.
+							if types.HasShellCommands(assistantMsg.Message) {
+								log.Println("Generating description for reply commands")
+								commandsDesc, err := genPlanDescription(client, settings, currentOrgId, currentUserId, planId, branch, active.Ctx)
+								if err != nil {
+									log.Printf("Error getting commands from reply: %v\n", err)
+								} else {
+									description.Commands = commandsDesc.Commands
+									description.CompletedSubtasks = commandsDesc.CompletedSubtasks
+								}
+							}
 						} else {
 							log.Println("Generating plan description")
-							description, err = genPlanDescription(client, settings.ModelSet.CommitMsg, planId, branch, active.Ctx)
+							description, err = genPlanDescription(client, settings, currentOrgId, currentUserId, planId, branch, active.Ctx)
 							if err != nil {
 								state.onError(fmt.Errorf("failed to generate plan description: %v", err), true, assistantMsg.Id, convoCommitMsg)
 								return
@@ -232,8 +336,13 @@ func (state *activeTellStreamState) listenStream(stream *openai.ChatCompletionSt
 							description.SummarizedToMessageId = summarizedToMessageId
 							description.MadePlan = true
 							description.Files = replyFiles
+							description.RemovedFiles = removedFiles
+							description.MovedFiles = movedFiles
+							description.BaseShasByPath = state.getBaseShas(replyFiles, removedFiles, movedFiles)
 						}
 
+						description.Subtasks = subtasks
+
 						log.Println("Storing description")
 						err = db.StoreDescription(description)
 
@@ -244,6 +353,39 @@ func (state *activeTellStreamState) listenStream(stream *openai.ChatCompletionSt
 						}
 
 						log.Println("Description stored")
+
+						err = updatePlanSubtasks(currentOrgId, planId, description, nestedSubtasks)
+						if err != nil {
+							state.onError(fmt.Errorf("failed to update subtasks: %v", err), false, assistantMsg.Id, convoCommitMsg)
+							errCh <- err
+							return
+						}
+
+						// removals don't need a build, so they're stored as results right away
+						for _, path := range removedFiles {
+							err = db.StorePlanResult(&db.PlanFileResult{
+								OrgId:          currentOrgId,
+								PlanId:         planId,
+								ConvoMessageId: assistantMsg.Id,
+								Path:           path,
+								RemovedFile:    true,
+							})
+
+							if err != nil {
+								state.onError(fmt.Errorf("failed to store file removal: %v", err), false, assistantMsg.Id, convoCommitMsg)
+								errCh <- err
+								return
+							}
+						}
+
+						if len(movedFiles) > 0 {
+							err = storeMovedFiles(currentOrgId, planId, assistantMsg.Id, movedFiles)
+							if err != nil {
+								state.onError(fmt.Errorf("failed to store file moves: %v", err), false, assistantMsg.Id, convoCommitMsg)
+								errCh <- err
+								return
+							}
+						}
 						// spew.Dump(description)
 
 						errCh <- nil
@@ -251,6 +393,12 @@ func (state *activeTellStreamState) listenStream(stream *openai.ChatCompletionSt
 
 					go func() {
 						// One of the below is nil occasionally, causing crash
+						if req.ChatOnly {
+							// chat replies answer the prompt in one response
+							errCh <- nil
+							return
+						}
+
 						log.Println("Getting exec status")
 						var prompt string
 						if promptMessage == nil {
@@ -259,7 +407,7 @@ func (state *activeTellStreamState) listenStream(stream *openai.ChatCompletionSt
 							prompt = promptMessage.Content
 						}
 
-						shouldContinue, err = ExecStatusShouldContinue(client, settings.ModelSet.ExecStatus, prompt, assistantMsg.Message, active.Ctx)
+						shouldContinue, err = ExecStatusShouldContinue(client, settings.ModelSet.ExecStatus, planMeter(currentOrgId, currentUserId, planId, branch, settings, shared.UsagePhaseExecStatus), prompt, assistantMsg.Message, active.Ctx)
 						if err != nil {
 							state.onError(fmt.Errorf("failed to get exec status: %v", err), false, assistantMsg.Id, convoCommitMsg)
 							errCh <- err
@@ -278,6 +426,30 @@ func (state *activeTellStreamState) listenStream(stream *openai.ChatCompletionSt
 						}
 					}
 
+					// recorded so clients running steps on their own, like auto-continue, know when to stop
+					if !req.ChatOnly && !shouldContinue && description != nil {
+						description.PlanFinished = true
+						err = db.StoreDescription(description)
+						if err != nil {
+							state.onError(fmt.Errorf("failed to store description: %v", err), false, assistantMsg.Id, convoCommitMsg)
+							return err
+						}
+					}
+
+					// an automated run needs confirmation before changes to protected paths are applied, so it can't be continued past them here
+					if req.AutoRun != nil && description != nil {
+						paths := append(append([]string{}, description.Files...), description.RemovedFiles...)
+						if protected := req.AutoRun.Limits.GetProtectedPaths(paths); len(protected) > 0 {
+							log.Printf("Reply changes protected paths %v, won't auto continue\n", protected)
+							shouldContinue = false
+						}
+					}
+
+					// a reply that only asks questions waits for the answers rather than finishing or continuing
+					if state.canClarify() && len(replyFiles) == 0 {
+						questions = types.ParseClarifyingQuestions(assistantMsg.Message)
+					}
+
 					log.Println("Comitting reply message and description")
 
 					err = db.GitAddAndCommit(currentOrgId, planId, branch, convoCommitMsg)
@@ -288,6 +460,10 @@ func (state *activeTellStreamState) listenStream(stream *openai.ChatCompletionSt
 
 					log.Println("Assistant reply and description committed")
 
+					UpdateActivePlan(planId, branch, func(ap *types.ActivePlan) {
+						ap.RestoreReplacedPrompt = nil
+					})
+
 					return nil
 				}()
 
@@ -306,7 +482,9 @@ func (state *activeTellStreamState) listenStream(stream *openai.ChatCompletionSt
 					ap.CurrentReplyDoneCh = nil
 				})
 
-				if req.AutoContinue && shouldContinue && iteration < MaxAutoContinueIterations {
+				if len(questions) > 0 {
+					state.waitForClarification(questions)
+				} else if req.AutoContinue && shouldContinue && iteration < MaxAutoContinueIterations {
 					log.Println("Auto continue plan")
 					// continue plan
 					execTellPlan(client, plan, branch, auth, req, iteration+1, "", false)
@@ -348,6 +526,8 @@ func (state *activeTellStreamState) listenStream(stream *openai.ChatCompletionSt
 				return
 			}
 
+			choice := response.Choices[0]
+
 			chunksReceived++
 			delta := choice.Delta
 			content := delta.Content
@@ -385,7 +565,13 @@ func (state *activeTellStreamState) listenStream(stream *openai.ChatCompletionSt
 			files := parserRes.Files
 			fileContents := parserRes.FileContents
 			state.replyNumTokens = parserRes.TotalTokens
-			currentFile := parserRes.CurrentFilePath
+
+			if req.ChatOnly {
+				// chat replies are never applied, so file blocks aren't checked against context or built
+				continue
+			}
+
+			currentFile := resolveReplyPath(parserRes.CurrentFilePath, active.ContextsByPath, req.ProjectPaths, settings.WorkspaceMember)
 			fileDescriptions := parserRes.FileDescriptions
 
 			// log.Printf("currentFile: %s\n", currentFile)
@@ -482,7 +668,21 @@ func (state *activeTellStreamState) listenStream(stream *openai.ChatCompletionSt
 						continue
 					}
 
+					file = resolveReplyPath(file, active.ContextsByPath, req.ProjectPaths, settings.WorkspaceMember)
 					log.Printf("Detected file: %s\n", file)
+
+					err := shared.ValidatePlanPath(file)
+					if err != nil {
+						state.onError(err, true, "", "")
+						return
+					}
+
+					err = state.checkPlanFileLimit(replyFiles, file)
+					if err != nil {
+						state.onError(err, true, "", "")
+						return
+					}
+
 					if req.BuildMode == shared.BuildModeAuto {
 						log.Printf("Queuing build for %s\n", file)
 						buildState := &activeBuildStreamState{
@@ -496,7 +696,7 @@ func (state *activeTellStreamState) listenStream(stream *openai.ChatCompletionSt
 							modelContext:  state.modelContext,
 						}
 
-						fileContentTokens, err := shared.GetNumTokens(fileContents[i])
+						fileContentTokens, err := db.GetPlanNumTokens(planId, shared.GetTokenEncoding(settings.ModelSet.Builder.BaseModelConfig), fileContents[i])
 
 						if err != nil {
 							log.Printf("Error getting num tokens for file %s: %v\n", file, err)
@@ -515,6 +715,12 @@ func (state *activeTellStreamState) listenStream(stream *openai.ChatCompletionSt
 					}
 					replyFiles = append(replyFiles, file)
 					UpdateActivePlan(planId, branch, func(ap *types.ActivePlan) {
+						// a file with several blocks in the reply gets a build for each, but is only listed once
+						for _, f := range ap.Files {
+							if f == file {
+								return
+							}
+						}
 						ap.Files = append(ap.Files, file)
 					})
 				}
@@ -552,6 +758,7 @@ func (state *activeTellStreamState) storeAssistantReply() (*db.ConvoMessage, str
 		Tokens:  replyNumTokens,
 		Num:     num,
 		Message: activePlan.CurrentReplyContent,
+		Usage:   activePlan.CurrentReplyUsage,
 	}
 
 	commitMsg, err := db.StoreConvoMessage(&assistantMsg, auth.User.Id, branch, false)
@@ -584,10 +791,31 @@ func (state *activeTellStreamState) onError(streamErr error, storeDesc bool, con
 		return
 	}
 
-	active.StreamDoneCh <- &shared.ApiError{
-		Type:   shared.ApiErrorTypeOther,
-		Status: http.StatusInternalServerError,
-		Msg:    "Stream error: " + streamErr.Error(),
+	var policyErr *shared.PathPolicyError
+	var fileLimitErr *shared.PlanFileLimitError
+	if errors.As(streamErr, &policyErr) {
+		active.StreamDoneCh <- &shared.ApiError{
+			Type:   shared.ApiErrorTypePathPolicy,
+			Status: http.StatusBadRequest,
+			Msg:    "Stream error: " + streamErr.Error(),
+		}
+	} else if errors.As(streamErr, &fileLimitErr) {
+		active.StreamDoneCh <- &shared.ApiError{
+			Type:   shared.ApiErrorTypePlanFileLimit,
+			Status: http.StatusBadRequest,
+			Msg:    "Stream error: " + streamErr.Error(),
+		}
+	} else {
+		active.StreamDoneCh <- &shared.ApiError{
+			Type:   shared.ApiErrorTypeOther,
+			Status: http.StatusInternalServerError,
+			Msg:    "Stream error: " + streamErr.Error(),
+		}
+	}
+
+	// the replaced prompt is restored, which discards anything stored for the new one
+	if active.RestoreReplacedPrompt != nil {
+		return
 	}
 
 	storedMessage := false
@@ -628,3 +856,56 @@ func (state *activeTellStreamState) onError(streamErr error, storeDesc bool, con
 		}
 	}
 }
+
+// onReplyUsage adds the usage for the latest model request to the current reply, which can span multiple requests if the stream was interrupted to prompt for a missing file, and sends the request's usage to the client.
+func (state *activeTellStreamState) onReplyUsage(usage *openai.Usage) {
+	planId := state.plan.Id
+	branch := state.branch
+
+	active := GetActivePlan(planId, branch)
+	if active == nil {
+		log.Printf("onReplyUsage - Active plan not found for plan ID %s on branch %s\n", planId, branch)
+		return
+	}
+
+	var promptTokens, completionTokens int
+	providerReported := usage != nil
+
+	if providerReported {
+		promptTokens = usage.PromptTokens
+		completionTokens = usage.CompletionTokens
+	} else {
+		log.Println("Provider didn't report usage, counting tokens")
+
+		var err error
+		promptTokens, err = lib.GetMessagesNumTokens(state.replyModel.BaseModelConfig, state.messages)
+		if err != nil {
+			// non-fatal, we still have the completion tokens
+			log.Printf("Error counting prompt tokens: %v\n", err)
+		}
+		completionTokens = state.replyNumTokens
+	}
+
+	requestUsage := shared.ModelUsage{
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		ProviderReported: providerReported,
+		Phase:            shared.UsagePhaseReply,
+		ModelName:        state.replyModel.BaseModelConfig.ModelName,
+	}
+
+	var replyUsage shared.ModelUsage
+	UpdateActivePlan(planId, branch, func(ap *types.ActivePlan) {
+		if ap.CurrentReplyUsage == nil {
+			ap.CurrentReplyUsage = &shared.ModelUsage{ProviderReported: providerReported}
+		}
+		ap.CurrentReplyUsage.PromptTokens += promptTokens
+		ap.CurrentReplyUsage.CompletionTokens += completionTokens
+		ap.CurrentReplyUsage.ProviderReported = ap.CurrentReplyUsage.ProviderReported && providerReported
+		replyUsage = *ap.CurrentReplyUsage
+	})
+
+	log.Printf("Reply usage | prompt tokens: %d | completion tokens: %d | provider reported: %v\n", replyUsage.PromptTokens, replyUsage.CompletionTokens, replyUsage.ProviderReported)
+
+	recordAndStreamUsage(state.currentOrgId, state.currentUserId, planId, branch, state.settings, requestUsage, state.convoHistory)
+}
@@ -16,6 +16,11 @@ import (
 )
 
 func (state *activeTellStreamState) summarizeMessagesIfNeeded() bool {
+	switch state.settings.GetConvoPolicy() {
+	case shared.ConvoPolicyKeepLast, shared.ConvoPolicySlidingWindow:
+		return state.truncateMessages()
+	}
+
 	convo := state.convo
 	summaries := state.summaries
 	tokensBeforeConvo := state.tokensBeforeConvo
@@ -101,17 +106,27 @@ func (state *activeTellStreamState) summarizeMessagesIfNeeded() bool {
 		}
 
 		if summary == nil {
-			err := errors.New("couldn't get under token limit with conversation summary")
-			log.Printf("Error: %v\n", err)
-			active.StreamDoneCh <- &shared.ApiError{
-				Type:   shared.ApiErrorTypeOther,
-				Status: http.StatusInternalServerError,
-				Msg:    "Couldn't get under token limit with conversation summary",
+			log.Println("No stored summary gets under the token limit. Summarizing older messages now.")
+
+			var err error
+			summary, err = state.summarizeOlderMessages()
+			if err != nil {
+				log.Printf("Error summarizing older messages: %v\n", err)
+				active.StreamDoneCh <- &shared.ApiError{
+					Type:   shared.ApiErrorTypeOther,
+					Status: http.StatusInternalServerError,
+					Msg:    "Couldn't get under token limit with conversation summary",
+				}
+				return false
 			}
-			return false
 		}
 	}
 
+	state.convoHistory = &shared.ConvoHistory{
+		Policy:      shared.ConvoPolicySummarize,
+		NumMessages: len(convo),
+	}
+
 	if summary == nil {
 		for _, convoMessage := range convo {
 			state.messages = append(state.messages, openai.ChatCompletionMessage{
@@ -119,6 +134,8 @@ func (state *activeTellStreamState) summarizeMessagesIfNeeded() bool {
 				Content: convoMessage.Message,
 			})
 		}
+		state.convoHistory.NumIncluded = len(convo)
+		state.convoHistory.Tokens = conversationTokens
 	} else {
 		if (tokensBeforeConvo + summary.Tokens) > state.settings.GetPlannerEffectiveMaxTokens() {
 			active.StreamDoneCh <- &shared.ApiError{
@@ -133,6 +150,8 @@ func (state *activeTellStreamState) summarizeMessagesIfNeeded() bool {
 			Role:    openai.ChatMessageRoleAssistant,
 			Content: summary.Summary,
 		})
+		state.convoHistory.Summarized = true
+		state.convoHistory.Tokens = summary.Tokens
 
 		// add messages after the last message in the summary
 		for _, convoMessage := range convo {
@@ -141,6 +160,8 @@ func (state *activeTellStreamState) summarizeMessagesIfNeeded() bool {
 					Role:    convoMessage.Role,
 					Content: convoMessage.Message,
 				})
+				state.convoHistory.NumIncluded++
+				state.convoHistory.Tokens += convoMessage.Tokens
 			}
 		}
 	}
@@ -148,6 +169,136 @@ func (state *activeTellStreamState) summarizeMessagesIfNeeded() bool {
 	return true
 }
 
+// truncateMessages sends the most recent messages that fit in the conversation's limits and drops the rest, for the keep-last and sliding-window policies. The latest message is always sent.
+func (state *activeTellStreamState) truncateMessages() bool {
+	convo := state.convo
+	policy := state.settings.GetConvoPolicy()
+
+	maxTokens := state.settings.GetPlannerMaxConvoTokens()
+	if available := state.settings.GetPlannerEffectiveMaxTokens() - state.tokensBeforeConvo; available < maxTokens {
+		maxTokens = available
+	}
+
+	maxMessages := len(convo)
+	if policy == shared.ConvoPolicyKeepLast {
+		maxMessages = state.settings.GetConvoKeepLast()
+	}
+
+	start := len(convo)
+	tokens := 0
+	for start > 0 {
+		next := convo[start-1]
+		numIncluded := len(convo) - start
+		if numIncluded > 0 && (numIncluded >= maxMessages || tokens+next.Tokens > maxTokens) {
+			break
+		}
+		start--
+		tokens += next.Tokens
+	}
+
+	if start > 0 {
+		log.Printf("Conversation policy %s: sending the last %d of %d messages (%d tokens)\n", policy, len(convo)-start, len(convo), tokens)
+	}
+
+	for _, convoMessage := range convo[start:] {
+		state.messages = append(state.messages, openai.ChatCompletionMessage{
+			Role:    convoMessage.Role,
+			Content: convoMessage.Message,
+		})
+	}
+
+	state.convoHistory = &shared.ConvoHistory{
+		Policy:      policy,
+		NumMessages: len(convo),
+		NumIncluded: len(convo) - start,
+		Tokens:      tokens,
+	}
+
+	return true
+}
+
+// summarizeOlderMessages summarizes all but the most recent messages before the model is called, for when none of the summaries made in the background get the conversation under its limits, like when they failed or haven't caught up. Recent messages are kept as they are, up to half of what the conversation can use.
+func (state *activeTellStreamState) summarizeOlderMessages() (*db.ConvoSummary, error) {
+	convo := state.convo
+	if len(convo) < 2 {
+		return nil, errors.New("not enough messages to summarize")
+	}
+
+	active := GetActivePlan(state.plan.Id, state.branch)
+	if active == nil {
+		return nil, errors.New("active plan not found")
+	}
+
+	keepTokens := state.settings.GetPlannerMaxConvoTokens()
+	if available := state.settings.GetPlannerEffectiveMaxTokens() - state.tokensBeforeConvo; available < keepTokens {
+		keepTokens = available
+	}
+	keepTokens /= 2
+
+	// the latest message is the prompt being replied to, so it's always kept
+	split := len(convo) - 1
+	recentTokens := convo[split].Tokens
+	for split > 1 && recentTokens+convo[split-1].Tokens <= keepTokens {
+		split--
+		recentTokens += convo[split].Tokens
+	}
+
+	older := convo[:split]
+	latest := older[len(older)-1]
+
+	// build on the newest stored summary that the older messages extend, so what it already covers isn't resent
+	var base *db.ConvoSummary
+	for _, s := range state.summaries {
+		if !s.LatestConvoMessageCreatedAt.After(latest.CreatedAt) {
+			base = s
+		}
+	}
+
+	var messages []*openai.ChatCompletionMessage
+	numMessages := 1
+	if base != nil {
+		messages = append(messages, &openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleAssistant,
+			Content: base.Summary,
+		})
+		numMessages = base.NumMessages + 1
+	}
+	for _, convoMessage := range older {
+		if base != nil && !convoMessage.CreatedAt.After(base.LatestConvoMessageCreatedAt) {
+			continue
+		}
+		messages = append(messages, &openai.ChatCompletionMessage{
+			Role:    convoMessage.Role,
+			Content: convoMessage.Message,
+		})
+	}
+
+	log.Printf("Summarizing %d older messages, keeping %d recent ones\n", len(messages), len(convo)-split)
+
+	meter := planMeter(state.currentOrgId, state.currentUserId, state.plan.Id, state.branch, state.settings, shared.UsagePhaseSummary)
+	summary, err := model.PlanSummary(state.client, state.settings.ModelSet.PlanSummary, meter, model.PlanSummaryParams{
+		Conversation:                messages,
+		LatestConvoMessageId:        latest.Id,
+		LatestConvoMessageCreatedAt: latest.CreatedAt,
+		NumMessages:                 numMessages,
+		OrgId:                       state.currentOrgId,
+		PlanId:                      state.plan.Id,
+	}, active.Ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error generating summary: %v", err)
+	}
+
+	err = db.StoreSummary(summary)
+	if err != nil {
+		// the summary can still be used for this reply
+		log.Printf("Error storing summary for plan %s: %v\n", state.plan.Id, err)
+	}
+
+	state.summaries = append(state.summaries, summary)
+
+	return summary, nil
+}
+
 type summarizeConvoParams struct {
 	planId        string
 	branch        string
@@ -155,6 +306,7 @@ type summarizeConvoParams struct {
 	summaries     []*db.ConvoSummary
 	promptMessage *openai.ChatCompletionMessage
 	currentOrgId  string
+	meter         model.Meter
 }
 
 func summarizeConvo(client *openai.Client, config shared.ModelRoleConfig, params summarizeConvoParams, ctx context.Context) error {
@@ -225,7 +377,7 @@ func summarizeConvo(client *openai.Client, config shared.ModelRoleConfig, params
 
 	log.Printf("Calling model for plan summary. Summarizing %d messages\n", len(summaryMessages))
 
-	summary, err := model.PlanSummary(client, config, model.PlanSummaryParams{
+	summary, err := model.PlanSummary(client, config, params.meter, model.PlanSummaryParams{
 		Conversation:                summaryMessages,
 		LatestConvoMessageId:        latestMessageId,
 		LatestConvoMessageCreatedAt: latestMessageSummarizedAt,
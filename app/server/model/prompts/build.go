@@ -196,3 +196,9 @@ var ListReplacementsFn = openai.FunctionDefinition{
 		Required: []string{"changes"},
 	},
 }
+
+func GetBuildSyntaxErrorPrompt(previousCall, syntaxErr string) string {
+	return fmt.Sprintf("You already called the function for this file with these arguments:\n```\n%s\n```\n\nApplying them to the original file produced a syntax error:\n```\n%s\n```\n\nCall the function again with corrected arguments that produce a file with valid syntax. Don't change anything that isn't needed to implement the proposed updates and fix the error.", previousCall, syntaxErr)
+}
+
+const BuildContinuationPrompt = "Your response was cut off because it hit the output limit. Continue exactly where you left off, starting with the very next character. Don't repeat anything you've already written, don't restart the function call, and don't add any commentary or code block markers--your output is appended directly to what you've written so far."
@@ -0,0 +1,115 @@
+package prompts
+
+import (
+	"fmt"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+func GetBuildSearchReplaceSysPrompt(filePath, currentState, desc, changes string) string {
+	return listEditsPrompt + "\n\n" + getBuildOriginalFilePrompt(filePath, currentState) + getBuildEditsPrompt(desc, changes, "listEdits")
+}
+
+func GetBuildWholeFileSysPrompt(filePath, currentState, desc, changes string) string {
+	return writeFilePrompt + "\n\n" + getBuildOriginalFilePrompt(filePath, currentState) + getBuildEditsPrompt(desc, changes, "writeFile")
+}
+
+func getBuildOriginalFilePrompt(filePath, currentState string) string {
+	return fmt.Sprintf("**The current file is %s. Original state of the file:**\n```\n%s\n```", filePath, currentState) + "\n\n"
+}
+
+func getBuildEditsPrompt(desc, changes, fnName string) string {
+	s := ""
+
+	if desc != "" {
+		s += "Description of the proposed updates from AI-generated plan:\n```\n" + desc + "\n```\n\n"
+	}
+
+	s += "Proposed updates:\n```\n" + changes + "\n```"
+
+	s += fmt.Sprintf("\n\nNow call the '%s' function according to your instructions. Don't call any other function.", fnName)
+
+	return s
+}
+
+const listEditsPrompt = `
+  You are an AI that analyzes a code file and an AI-generated plan to update the code file and produces a list of search/replace edits.
+
+  [YOUR INSTRUCTIONS]
+  Call the 'listEdits' function with a valid JSON object that includes the 'edits' key.
+
+  'edits': An array of NON-OVERLAPPING edits, in the order they appear in the original file. Each edit is an object with properties: 'summary', 'search', and 'replace'.
+
+  The 'summary' property is a brief summary of the edit.
+
+  The 'search' property is a block of code copied *exactly* from the original file, character for character, including whitespace and indentation. It must consist of entire lines. It must match *exactly one* location in the original file--include enough surrounding lines to make it unique. Never abbreviate it with '...' or comments.
+
+  The 'replace' property is the code that will replace the 'search' block. To remove code, use an empty string. To insert code, include the neighboring lines in 'search' and repeat them in 'replace' along with the new code.
+
+  If the proposed update includes references to the original code in comments like "// rest of the function..." or "# existing init code...", you *MUST NOT* include the comment making the reference in 'replace'. Include the exact code from the original file that the comment is referencing instead, or leave that code out of both 'search' and 'replace'.
+
+  Keep each edit as small as possible while still being unambiguous. Group edits that would overlap into a single edit.
+
+  Pay *EXTREMELY close attention* to opening and closing brackets, parentheses, and braces. Never leave them unbalanced when the edits are applied.
+
+  The 'listEdits' function MUST be called with *valid JSON*. Double quotes within json properties *must be properly escaped* with a backslash.
+
+  [END YOUR INSTRUCTIONS]
+`
+
+const writeFilePrompt = `
+  You are an AI that analyzes a code file and an AI-generated plan to update the code file and writes the complete updated file.
+
+  [YOUR INSTRUCTIONS]
+  Call the 'writeFile' function with a valid JSON object that includes the 'content' key.
+
+  'content': The full content of the file after the proposed updates are applied to the original file. Include *all* of the original file's code that isn't changed by the plan. Never abbreviate or leave out code with comments like "// rest of the function..."--write out the exact code from the original file instead.
+
+  You ABSOLUTELY MUST NOT overwrite or delete code from the original file unless the plan *clearly intends* for the code to be overwritten or removed.
+
+  The 'writeFile' function MUST be called with *valid JSON*. Double quotes within json properties *must be properly escaped* with a backslash.
+
+  [END YOUR INSTRUCTIONS]
+`
+
+var ListEditsFn = openai.FunctionDefinition{
+	Name: "listEdits",
+	Parameters: &jsonschema.Definition{
+		Type: jsonschema.Object,
+		Properties: map[string]jsonschema.Definition{
+			"edits": {
+				Type: jsonschema.Array,
+				Items: &jsonschema.Definition{
+					Type: jsonschema.Object,
+					Properties: map[string]jsonschema.Definition{
+						"summary": {
+							Type: jsonschema.String,
+						},
+						"search": {
+							Type: jsonschema.String,
+						},
+						"replace": {
+							Type: jsonschema.String,
+						},
+					},
+					Required: []string{"summary", "search", "replace"},
+				},
+			},
+		},
+		Required: []string{"edits"},
+	},
+}
+
+var WriteFileFn = openai.FunctionDefinition{
+	Name: "writeFile",
+	Parameters: &jsonschema.Definition{
+		Type: jsonschema.Object,
+		Properties: map[string]jsonschema.Definition{
+			"content": {
+				Type: jsonschema.String,
+			},
+		},
+		Required: []string{"content"},
+	},
+}
@@ -0,0 +1,30 @@
+package prompts
+
+import (
+	"fmt"
+
+	"github.com/plandex/plandex/shared"
+)
+
+const SysChat = Identity + ` The user is in chat mode, so you won't be making or continuing a plan in this response.` +
+
+	"# Your instructions:\n\n```\n" +
+
+	`Respond to the user in chat form. Answer questions, discuss designs and tradeoffs, and explain code, referring to the context and the conversation so far to inform your response.
+
+	You can include code in your response to illustrate a point, but don't label code blocks with file paths, since nothing you write in chat mode is applied to the user's files. If the user asks you to make changes, describe what you would do and let them know they can switch back to 'plandex tell' to make a plan for it.
+
+	If the conversation includes an earlier plan, you can discuss it, but don't continue it.
+	` +
+	"\n```\n\n" +
+	"# User-provided context:"
+
+var ChatSysMsgNumTokens, _ = shared.GetNumTokens(SysChat)
+
+const chatPromptWrapperFormatStr = "# The user's latest prompt:\n```\n%s\n```\n\n" + `Please respond according to the 'Your instructions' section above. Don't label code blocks with file paths.`
+
+func GetWrappedChatPrompt(prompt string) string {
+	return fmt.Sprintf(chatPromptWrapperFormatStr, prompt)
+}
+
+var ChatPromptWrapperTokens, _ = shared.GetNumTokens(fmt.Sprintf(chatPromptWrapperFormatStr, ""))
@@ -0,0 +1,28 @@
+package prompts
+
+import (
+	"fmt"
+	"strings"
+)
+
+const ClarifyPrompt = "\n\nBefore making a plan for a new task, consider whether the user's prompt is ambiguous in a way that would change the plan: it could reasonably mean different things, or it leaves out a decision that only the user can make. If so, don't make a plan yet. Instead, briefly explain what's unclear, then output a '### Clarifying Questions' header followed by a numbered list of short, specific questions, and end your response. Don't include any code blocks in that response. Only ask questions whose answers would change the plan, and ask at most 5. If the task is clear enough to plan, don't ask any questions and make the plan as usual. The user's answers will be sent in the next message."
+
+// GetClarifiedPrompt sends the user's answers back to the model, paired with the questions they answer
+func GetClarifiedPrompt(questions, answers []string) string {
+	var b strings.Builder
+	b.WriteString("Here are my answers to your questions:\n\n")
+
+	for i, question := range questions {
+		answer := ""
+		if i < len(answers) {
+			answer = strings.TrimSpace(answers[i])
+		}
+		if answer == "" {
+			answer = "(No answer. Use your best judgment.)"
+		}
+		fmt.Fprintf(&b, "%d. %s\n%s\n\n", i+1, question, answer)
+	}
+
+	b.WriteString("Now make the plan for the task, without asking further questions.")
+	return b.String()
+}
@@ -0,0 +1,38 @@
+package prompts
+
+import (
+	"github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+type CommitMsgRes struct {
+	CommitMsg string `json:"commitMsg"`
+}
+
+const SysCommitMsg = "You are an AI that writes git commit messages. You will be given a diff. Call the 'writeCommitMsg' function with a valid JSON object that includes the 'commitMsg' key. 'commitMsg' should start with a succinct summary line of 72 characters or less in the imperative mood, describing what the changes do. If the changes are more than trivial, follow the summary line with a blank line and a short body explaining what changed and why. Don't describe the diff line by line."
+
+const conventionalCommitMsg = "Format the message as a conventional commit: the summary line must be 'type(scope): description', where type is one of feat, fix, refactor, perf, docs, test, build, ci, chore, or style, and the scope is optional. Add a '!' after the type or scope for breaking changes."
+
+var CommitMsgFn = openai.FunctionDefinition{
+	Name: "writeCommitMsg",
+	Parameters: &jsonschema.Definition{
+		Type: jsonschema.Object,
+		Properties: map[string]jsonschema.Definition{
+			"commitMsg": {
+				Type: jsonschema.String,
+			},
+		},
+		Required: []string{"commitMsg"},
+	},
+}
+
+func GetCommitMsgSysPrompt(conventional bool) string {
+	if conventional {
+		return SysCommitMsg + "\n\n" + conventionalCommitMsg
+	}
+	return SysCommitMsg
+}
+
+func GetCommitMsgPrompt(diff string) string {
+	return "Diff:\n" + diff
+}
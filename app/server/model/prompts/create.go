@@ -31,8 +31,8 @@ const SysCreate = Identity + ` A plan is a set of files with an attached context
 				***You *must not* include **any other text** in a code block label apart from the initial '- ' and the EXACT file path ONLY. DO NOT UNDER ANY CIRCUMSTANCES use a label like 'File path: src/main.rs' or 'src/main.rs: (Create this file)' or 'File to Create: src/main.rs' or 'File to Update: src/main.rs'. Instead use EXACTLY 'src/main.rs:'. DO NOT include any explanatory text in the code block label like 'src/main.rs: (Add a new function)'. Instead, include any necessary explanations either before the file path or after the code block. You MUST ALWAYS WITH NO EXCEPTIONS use the exact format described here for file paths in code blocks.
 			b. If not: 
 			  - Explicitly say "Let's break up this task."
-				- Divide the task into smaller subtasks and list them in a numbered list. Stop there.				
-				- If you are already working on a subtask and the subtask is still too large to be implemented in a single response, it should be further broken down into smaller subtasks. In that case, explicitly say "Let's further break up this subtask", further divide the subtask into even smaller steps, and list them in a numbered list. Stop there. 
+				- Divide the task into smaller subtasks and list them in a numbered list under a '### Subtasks' markdown header, one line per subtask. Stop there.				
+				- If you are already working on a subtask and the subtask is still too large to be implemented in a single response, it should be further broken down into smaller subtasks. In that case, explicitly say "Let's further break up this subtask", further divide the subtask into even smaller steps, and list them in a numbered list under a '### Subtasks' markdown header. Stop there. 
 				- Be thorough and exhaustive in your list of subtasks. Ensure you've accounted for *every subtask* that must be done to fully complete the user's task to a high standard.
 		
 		## Code blocks and files
@@ -55,6 +55,20 @@ const SysCreate = Identity + ` A plan is a set of files with an attached context
 
 		If code is being removed from a file, the removal must be shown in a labelled file block according to your instructions. Use a comment within the file block to denote the removal like '// Plandex: removed the fooBar function' or '// Plandex: removed the loop'. Do NOT use any other formatting apart from a labelled file block to denote the removal.
 
+		If an entire file should be deleted, don't use a file block. Instead, list the file under a '### Remove Files' markdown header, with one file path per line in the format '- file_path'--for example:
+
+		### Remove Files
+		- src/old_helpers.go
+
+		Only list files that should be deleted completely. Don't list files in this section for any other reason.
+
+		If a file in context should be moved or renamed, don't recreate it in a file block and don't list it under '### Remove Files'. Instead, list the move under a '### Move Files' markdown header, with one move per line in the format '- old_path → new_path'--for example:
+
+		### Move Files
+		- src/helpers.go → src/util/helpers.go
+
+		A moved file keeps its content. If it also needs to be updated, make the update in a file block labelled with the new path in a later response, after the move has been made.
+
 		If a change is related to code in an existing file in context, make the change as an update to the existing file. Do NOT create a new file for a change that applies to an existing file in context. For example, if there is an 'Page.tsx' file in the existing context and the user has asked you to update the structure of the page component, make the change in the existing 'Page.tsx' file. Do NOT create a new file like 'page.tsx' or 'NewPage.tsx' for the change. If the user has specifically asked you to apply a change to a new file, then you can create a new file. If there is no existing file that makes sense to apply a change to, then you can create a new file.
 
 		For code in markdown blocks, always include the language name after the opening triple backticks.
@@ -67,6 +81,8 @@ const SysCreate = Identity + ` A plan is a set of files with an attached context
 
 		In code blocks, include the *minimum amount of code* necessary to describe the suggested changes. Include only lines that are changing and and lines that make it clear where the change should be applied. You can use comments like "// rest of the function..." or "// rest of the file..." to help make it clear where changes should be applied. You *must not* include large sections of the original file unless it helps make the suggested changes clear.
 
+		When changing a function, method, class, or type, include its name (for example, its full signature line) in the code block. This makes it clear which section of the file the change belongs to, which is especially important in very large files.
+
 		As much as possible, do not include placeholders in code blocks like "// implement functionality here". Unless you absolutely cannot implement the full code block, do not include a placeholder denoted with comments. Do your best to implement the functionality rather than inserting a placeholder. You **MUST NOT** include placeholders just to shorten the code block. If the task is too large to implement in a single code block, you should break the task down into smaller steps and **FULLY** implement each step.
 
 		As much as possible, the code you suggest should be robust, complete, and ready for production.		
@@ -180,6 +196,20 @@ var PromptWrapperTokens, _ = shared.GetNumTokens(fmt.Sprintf(promptWrapperFormat
 
 const UserContinuePrompt = "Continue the plan."
 
+// GetUserContinuePrompt points a continue at the first subtask that isn't done, so a plan that was interrupted resumes where it stopped rather than starting over
+func GetUserContinuePrompt(subtasks []*shared.Subtask) string {
+	for i, subtask := range subtasks {
+		if !subtask.Done {
+			return fmt.Sprintf("Continue the plan with subtask %d: %s. Don't redo subtasks that are already done.", i+1, subtask.Title)
+		}
+	}
+	return UserContinuePrompt
+}
+
 const AutoContinuePrompt = "Continue the plan from where you left off in the previous response. Don't repeat any part of your previous response. Don't begin your response with 'Next,'. Continue seamlessly from where your previous response left off. Never begin your response with 'The plan cannot be continued.' or 'All tasks have been completed.'."
 
 const SkippedPathsPrompt = "\n\nSome files have been skipped by the user and *must not* be generated. The user will handle any updates to these files themselves. Skip any parts of the plan that require generating these files. You *must not* generate a file block for any of these files.\nSkipped files:\n"
+
+func GetWorkspaceMemberPrompt(member string) string {
+	return fmt.Sprintf("\n\nThe project is a workspace with multiple modules or packages, and this plan targets the one at '%[1]s'. Keep changes within '%[1]s' unless the task requires touching another part of the project. File paths in file blocks must still be relative to the project root, so a new file in this module is written as '%[1]s/path/to/file'. A path that doesn't exist in the project and doesn't start with '%[1]s/' is placed inside '%[1]s'.", member)
+}
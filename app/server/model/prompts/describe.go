@@ -1,11 +1,28 @@
 package prompts
 
 import (
+	"fmt"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
 	"github.com/sashabaranov/go-openai"
 	"github.com/sashabaranov/go-openai/jsonschema"
 )
 
-const SysDescribe = "You are an AI parser. You turn an AI's plan for a programming task into a structured description. Call the 'describePlan' function with a valid JSON object that includes the 'commitMsg' key. 'commitMsg' should be a good, succinct commit message for the changes proposed."
+const SysDescribe = "You are an AI parser. You turn an AI's plan for a programming task into a structured description. Call the 'describePlan' function with a valid JSON object that includes the 'commitMsg' and 'commands' keys. 'commitMsg' should be a good, succinct commit message for the changes proposed. 'commands' lists the shell commands the plan tells the user to run, like installing dependencies, running migrations, or building, in the order they should be run. Each has a 'command' key with the exact command to type and a 'reason' key with a few words on why it's needed. Leave out commands that only show how the code could be used and commands for things the plan's file changes already do. If there are none, 'commands' is an empty array. If the plan's subtasks are listed, 'completedSubtasks' has the numbers of the subtasks that the changes complete, and is otherwise an empty array."
+
+func GetDescribeSubtasksPrompt(subtasks []*shared.Subtask) string {
+	var b strings.Builder
+	b.WriteString("The plan's subtasks:\n\n")
+	for i, subtask := range subtasks {
+		status := ""
+		if subtask.Done {
+			status = " (done)"
+		}
+		fmt.Fprintf(&b, "%d. %s%s\n", i+1, subtask.Title, status)
+	}
+	return b.String()
+}
 
 var DescribePlanFn = openai.FunctionDefinition{
 	Name: "describePlan",
@@ -15,7 +32,28 @@ var DescribePlanFn = openai.FunctionDefinition{
 			"commitMsg": {
 				Type: jsonschema.String,
 			},
+			"commands": {
+				Type: jsonschema.Array,
+				Items: &jsonschema.Definition{
+					Type: jsonschema.Object,
+					Properties: map[string]jsonschema.Definition{
+						"command": {
+							Type: jsonschema.String,
+						},
+						"reason": {
+							Type: jsonschema.String,
+						},
+					},
+					Required: []string{"command", "reason"},
+				},
+			},
+			"completedSubtasks": {
+				Type: jsonschema.Array,
+				Items: &jsonschema.Definition{
+					Type: jsonschema.Integer,
+				},
+			},
 		},
-		Required: []string{"commitMsg"},
+		Required: []string{"commitMsg", "commands"},
 	},
 }
@@ -0,0 +1,59 @@
+package prompts
+
+import (
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+type PullRequestRes struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+const SysPullRequest = "You are an AI that writes GitHub pull requests. You will be given the name of the plan the changes were made in, the user's prompts that led to them, summaries of the changes, and the diff. Call the 'writePullRequest' function with a valid JSON object that includes the 'title' and 'body' keys. 'title' should be a succinct summary of 72 characters or less in the imperative mood. 'body' is markdown: start with a short paragraph on what the pull request does and why, then describe the notable changes, and anything a reviewer should pay attention to, like behavior changes, risks, or follow-up work. Write for a reviewer who hasn't seen the prompts. Don't quote the prompts, don't describe the diff line by line, and don't invent anything the changes don't show."
+
+var PullRequestFn = openai.FunctionDefinition{
+	Name: "writePullRequest",
+	Parameters: &jsonschema.Definition{
+		Type: jsonschema.Object,
+		Properties: map[string]jsonschema.Definition{
+			"title": {
+				Type: jsonschema.String,
+			},
+			"body": {
+				Type: jsonschema.String,
+			},
+		},
+		Required: []string{"title", "body"},
+	},
+}
+
+// GetPullRequestContext is everything in the prompt but the diff
+func GetPullRequestContext(planName string, changes, userPrompts []string) string {
+	var b strings.Builder
+
+	b.WriteString("Plan: " + planName + "\n\n")
+
+	if len(userPrompts) > 0 {
+		b.WriteString("Prompts:\n\n")
+		for _, prompt := range userPrompts {
+			b.WriteString("> " + strings.ReplaceAll(prompt, "\n", "\n> ") + "\n\n")
+		}
+	}
+
+	if len(changes) > 0 {
+		b.WriteString("Changes:\n\n")
+		for _, change := range changes {
+			b.WriteString("- " + strings.ReplaceAll(change, "\n", "\n  ") + "\n")
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+func GetPullRequestPrompt(context, diff string) string {
+	return context + "Diff:\n" + diff
+}
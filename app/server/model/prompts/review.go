@@ -0,0 +1,93 @@
+package prompts
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+type ReviewRes struct {
+	Findings []*shared.ReviewFinding `json:"findings"`
+}
+
+var SysReview = fmt.Sprintf(`You are an expert code reviewer. You will be given a diff of changes to a project, and possibly the full content of the changed files and other related files for context. Review the changes for bugs, security problems, data loss, race conditions, broken error handling, and other real problems the changes introduce. Only report problems in the changed code, not pre-existing ones, and don't report style nitpicks.
+
+Call the 'reportFindings' function with a valid JSON object that includes the 'findings' key, a list of findings. Each finding has:
+
+- 'path': the file the problem is in
+- 'line': the line number in the new version of the file, or 0 if it doesn't apply to a single line
+- 'severity': one of %s. 'critical' is for problems that will cause outages, security holes, or data loss. 'high' is for clear bugs. 'medium' is for likely bugs and risky patterns. 'low' is for minor problems. 'info' is for observations that may not need changes.
+- 'message': a succinct description of the problem
+- 'suggestion': how to fix it, if it's clear
+
+If there are no problems, call 'reportFindings' with an empty list.`, reviewSeverityList())
+
+func reviewSeverityList() string {
+	var severities []string
+	for _, s := range shared.ReviewSeverities {
+		severities = append(severities, "'"+string(s)+"'")
+	}
+	return strings.Join(severities, ", ")
+}
+
+var ReviewFn = openai.FunctionDefinition{
+	Name: "reportFindings",
+	Parameters: &jsonschema.Definition{
+		Type: jsonschema.Object,
+		Properties: map[string]jsonschema.Definition{
+			"findings": {
+				Type: jsonschema.Array,
+				Items: &jsonschema.Definition{
+					Type: jsonschema.Object,
+					Properties: map[string]jsonschema.Definition{
+						"path": {
+							Type: jsonschema.String,
+						},
+						"line": {
+							Type: jsonschema.Integer,
+						},
+						"severity": {
+							Type: jsonschema.String,
+						},
+						"message": {
+							Type: jsonschema.String,
+						},
+						"suggestion": {
+							Type: jsonschema.String,
+						},
+					},
+					Required: []string{"path", "line", "severity", "message"},
+				},
+			},
+		},
+		Required: []string{"findings"},
+	},
+}
+
+// GetReviewPrompt includes related and changed files first so the diff comes last, closest to the instructions
+func GetReviewPrompt(diff string, files, related map[string]string) string {
+	var b strings.Builder
+
+	writeFiles := func(label string, files map[string]string) {
+		var paths []string
+		for path := range files {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+
+		for _, path := range paths {
+			fmt.Fprintf(&b, "%s: %s\n```\n%s\n```\n\n", label, path, files[path])
+		}
+	}
+
+	writeFiles("Related file", related)
+	writeFiles("File", files)
+
+	b.WriteString("Diff:\n" + diff)
+
+	return b.String()
+}
@@ -0,0 +1,71 @@
+package prompts
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+type SecurityScanRes struct {
+	Flags []*shared.SecurityFlag `json:"flags"`
+}
+
+var SysSecurityScan = fmt.Sprintf(`You are a security reviewer. You will be given a diff of changes an AI coding assistant drafted for a project, before they're applied. Flag the risky operations the changes add, so the user can check them before applying:
+
+- 'network': new network calls, like HTTP requests, sockets, or connections to external services, and changes to the URLs or hosts the code talks to
+- 'shell': running shell commands or other programs, or evaluating code from strings
+- 'credentials': reading, storing, logging, sending, or hardcoding passwords, tokens, keys, or other secrets, and changes to authentication or permissions
+- 'dependency': new or changed third-party dependencies, including install commands and scripts that run on install
+
+Only flag operations the diff adds or changes, not ones that were already there.
+
+Call the 'reportSecurityFlags' function with a valid JSON object that includes the 'flags' key, a list of flags. Each flag has:
+
+- 'path': the file the operation is in
+- 'line': the line number in the new version of the file, or 0 if it doesn't apply to a single line
+- 'risk': one of %s
+- 'message': a succinct description of the operation and why it's risky
+
+If nothing is risky, call 'reportSecurityFlags' with an empty list.`, securityRiskList())
+
+func securityRiskList() string {
+	var risks []string
+	for _, r := range shared.SecurityRisks {
+		risks = append(risks, "'"+string(r)+"'")
+	}
+	return strings.Join(risks, ", ")
+}
+
+var SecurityScanFn = openai.FunctionDefinition{
+	Name: "reportSecurityFlags",
+	Parameters: &jsonschema.Definition{
+		Type: jsonschema.Object,
+		Properties: map[string]jsonschema.Definition{
+			"flags": {
+				Type: jsonschema.Array,
+				Items: &jsonschema.Definition{
+					Type: jsonschema.Object,
+					Properties: map[string]jsonschema.Definition{
+						"path": {
+							Type: jsonschema.String,
+						},
+						"line": {
+							Type: jsonschema.Integer,
+						},
+						"risk": {
+							Type: jsonschema.String,
+						},
+						"message": {
+							Type: jsonschema.String,
+						},
+					},
+					Required: []string{"path", "line", "risk", "message"},
+				},
+			},
+		},
+		Required: []string{"flags"},
+	},
+}
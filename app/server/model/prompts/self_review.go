@@ -0,0 +1,9 @@
+package prompts
+
+const SelfReviewPrompt = `Now critique the plan you just wrote, before it's applied. Check it against the context and the rest of the conversation for:
+
+- files that need changes to make the plan work but weren't included, like callers of a changed function, imports, tests, or configuration
+- inconsistencies between the changes, like a name, signature, or type that's defined one way in one file and used another way in another
+- incorrect APIs: functions, methods, options, or packages that don't exist or are used with the wrong arguments, based on the code in context and your knowledge of the libraries it uses
+
+List each problem as a short bullet that names the file it's in and says how to fix it. Only list real problems that would make the plan fail or behave incorrectly, not style preferences or optional improvements. Don't rewrite the plan or include code blocks. If you don't find any problems, respond only with 'No problems found.'`
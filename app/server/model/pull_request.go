@@ -0,0 +1,97 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"plandex-server/model/prompts"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+)
+
+// room left in the model's context for the system prompt and the reply, which is longer than a commit message
+const pullRequestReservedTokens = 4000
+
+func GenPullRequest(client *openai.Client, config shared.TaskRoleConfig, meter Meter, req shared.GenPullRequestRequest) (*prompts.PullRequestRes, error) {
+	prContext := prompts.GetPullRequestContext(req.PlanName, req.Changes, req.Prompts)
+
+	contextTokens, err := shared.GetNumTokensForModel(config.BaseModelConfig, prContext)
+	if err != nil {
+		return nil, fmt.Errorf("error counting pull request context tokens: %v", err)
+	}
+
+	diff, err := truncateDiffForModel(config.BaseModelConfig, req.Diff, config.BaseModelConfig.MaxTokens-pullRequestReservedTokens-contextTokens)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := []openai.ChatCompletionMessage{
+		{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: prompts.SysPullRequest,
+		},
+		{
+			Role:    openai.ChatMessageRoleUser,
+			Content: prompts.GetPullRequestPrompt(prContext, diff),
+		},
+	}
+
+	meter.Phase = shared.UsagePhasePullRequest
+	resp, err := CreateMeteredChatCompletion(
+		client,
+		context.Background(),
+		meter,
+		openai.ChatCompletionRequest{
+			Model: config.BaseModelConfig.ModelName,
+			Tools: []openai.Tool{
+				{
+					Type:     "function",
+					Function: &prompts.PullRequestFn,
+				},
+			},
+			ToolChoice: openai.ToolChoice{
+				Type: "function",
+				Function: openai.ToolFunction{
+					Name: prompts.PullRequestFn.Name,
+				},
+			},
+			Temperature:    config.Temperature,
+			TopP:           config.TopP,
+			Messages:       messages,
+			ResponseFormat: config.OpenAIResponseFormat,
+		},
+	)
+
+	if err != nil {
+		fmt.Printf("Error during pull request model call: %v\n", err)
+		return nil, err
+	}
+
+	var res string
+	for _, choice := range resp.Choices {
+		if len(choice.Message.ToolCalls) == 1 &&
+			choice.Message.ToolCalls[0].Function.Name == prompts.PullRequestFn.Name {
+			res = choice.Message.ToolCalls[0].Function.Arguments
+			break
+		}
+	}
+
+	if res == "" {
+		return nil, fmt.Errorf("no writePullRequest function call found in response")
+	}
+
+	var prRes prompts.PullRequestRes
+	err = json.Unmarshal([]byte(res), &prRes)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling pull request response: %v", err)
+	}
+
+	prRes.Title = strings.TrimSpace(prRes.Title)
+	if prRes.Title == "" {
+		return nil, fmt.Errorf("pull request response has no title")
+	}
+
+	return &prRes, nil
+}
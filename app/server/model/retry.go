@@ -0,0 +1,152 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+)
+
+type RetryPolicy struct {
+	MaxRetries        int     `json:"maxRetries"`
+	InitialBackoffMs  int     `json:"initialBackoffMs"`
+	BackoffMultiplier float64 `json:"backoffMultiplier"`
+	MaxBackoffMs      int     `json:"maxBackoffMs"` // 0 for no max
+
+	// status codes like "502" or classes like "5xx"
+	RetryableStatuses  []string `json:"retryableStatuses"`
+	RetryNetworkErrors bool     `json:"retryNetworkErrors"`
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries:         5,
+	InitialBackoffMs:   1000,
+	BackoffMultiplier:  2,
+	RetryableStatuses:  []string{"4xx", "5xx"},
+	RetryNetworkErrors: true,
+}
+
+var retryPoliciesByProvider = map[shared.ModelProvider]RetryPolicy{}
+
+// LoadRetryPolicies reads per-provider overrides from the RETRY_POLICIES env var, e.g.
+// {"openai": {"maxRetries": 3, "retryableStatuses": ["429", "5xx"]}}
+// Fields that aren't set keep their default values.
+func LoadRetryPolicies() error {
+	s := os.Getenv("RETRY_POLICIES")
+	if s == "" {
+		return nil
+	}
+
+	var raw map[shared.ModelProvider]json.RawMessage
+	err := json.Unmarshal([]byte(s), &raw)
+	if err != nil {
+		return fmt.Errorf("error parsing RETRY_POLICIES: %v", err)
+	}
+
+	for provider, msg := range raw {
+		policy := DefaultRetryPolicy
+		err = json.Unmarshal(msg, &policy)
+		if err != nil {
+			return fmt.Errorf("error parsing retry policy for provider %s: %v", provider, err)
+		}
+
+		for _, status := range policy.RetryableStatuses {
+			if !isValidStatusPattern(status) {
+				return fmt.Errorf("invalid retryable status for provider %s: %s", provider, status)
+			}
+		}
+
+		retryPoliciesByProvider[provider] = policy
+		log.Printf("Loaded retry policy for provider %s: %+v\n", provider, policy)
+	}
+
+	return nil
+}
+
+func getRetryPolicy(modelName string) RetryPolicy {
+	provider := shared.ModelProviderOpenAI
+	if config, ok := shared.AvailableModelsByName[modelName]; ok {
+		provider = config.Provider
+	}
+
+	if policy, ok := retryPoliciesByProvider[provider]; ok {
+		return policy
+	}
+
+	return DefaultRetryPolicy
+}
+
+func (p RetryPolicy) shouldRetry(err error, numRetry int) bool {
+	if numRetry >= p.MaxRetries {
+		log.Println("Max retries reached - no retry")
+		return false
+	}
+
+	if isNonRetriableErr(err) {
+		return false
+	}
+
+	status := getErrStatus(err)
+	if status == 0 {
+		return p.RetryNetworkErrors
+	}
+
+	for _, pattern := range p.RetryableStatuses {
+		if statusMatches(status, pattern) {
+			return true
+		}
+	}
+
+	log.Printf("Status %d isn't retryable - no retry\n", status)
+	return false
+}
+
+func (p RetryPolicy) waitBackoff(numRetry int) {
+	ms := float64(p.InitialBackoffMs) * math.Pow(p.BackoffMultiplier, float64(numRetry))
+	if p.MaxBackoffMs > 0 && ms > float64(p.MaxBackoffMs) {
+		ms = float64(p.MaxBackoffMs)
+	}
+
+	d := time.Duration(ms) * time.Millisecond
+	log.Printf("Retrying in %v\n", d)
+	time.Sleep(d)
+}
+
+func getErrStatus(err error) int {
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.HTTPStatusCode
+	}
+
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) {
+		return reqErr.HTTPStatusCode
+	}
+
+	return 0
+}
+
+func isValidStatusPattern(pattern string) bool {
+	if len(pattern) == 3 && strings.HasSuffix(strings.ToLower(pattern), "xx") {
+		return pattern[0] >= '1' && pattern[0] <= '5'
+	}
+
+	_, err := strconv.Atoi(pattern)
+	return err == nil
+}
+
+func statusMatches(status int, pattern string) bool {
+	if strings.HasSuffix(strings.ToLower(pattern), "xx") {
+		return strconv.Itoa(status/100) == pattern[:1]
+	}
+
+	return strconv.Itoa(status) == pattern
+}
@@ -0,0 +1,139 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"plandex-server/model/prompts"
+	"sort"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+)
+
+// room left in the model's context for the instructions and findings
+const reviewReservedTokens = 4000
+
+// Review reviews a diff. files holds the full content of the changed files and related holds other files that may help, like the plan's context. Both are dropped file by file if they don't fit alongside the diff, changed files first.
+func Review(client *openai.Client, config shared.TaskRoleConfig, meter Meter, diff string, files, related map[string]string) ([]*shared.ReviewFinding, error) {
+	maxTokens := config.BaseModelConfig.MaxTokens - reviewReservedTokens
+
+	diff, err := truncateDiffForModel(config.BaseModelConfig, diff, maxTokens)
+	if err != nil {
+		return nil, err
+	}
+
+	// files are only context, so they're dropped rather than truncated if they don't all fit alongside the diff
+	numTokens, err := shared.GetNumTokensForModel(config.BaseModelConfig, diff)
+	if err != nil {
+		return nil, fmt.Errorf("error counting diff tokens: %v", err)
+	}
+	includeFiles := func(files map[string]string) (map[string]string, error) {
+		var paths []string
+		for path := range files {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+
+		included := map[string]string{}
+		for _, path := range paths {
+			content := files[path]
+			fileTokens, err := shared.GetNumTokensForModel(config.BaseModelConfig, content)
+			if err != nil {
+				return nil, fmt.Errorf("error counting tokens for %s: %v", path, err)
+			}
+			if numTokens+fileTokens > maxTokens {
+				log.Printf("Review: skipping %s, which doesn't fit in the model's context\n", path)
+				continue
+			}
+			numTokens += fileTokens
+			included[path] = content
+		}
+		return included, nil
+	}
+
+	includedFiles, err := includeFiles(files)
+	if err != nil {
+		return nil, err
+	}
+	includedRelated, err := includeFiles(related)
+	if err != nil {
+		return nil, err
+	}
+
+	meter.Phase = shared.UsagePhaseReview
+	resp, err := CreateMeteredChatCompletion(
+		client,
+		context.Background(),
+		meter,
+		openai.ChatCompletionRequest{
+			Model: config.BaseModelConfig.ModelName,
+			Tools: []openai.Tool{
+				{
+					Type:     "function",
+					Function: &prompts.ReviewFn,
+				},
+			},
+			ToolChoice: openai.ToolChoice{
+				Type: "function",
+				Function: openai.ToolFunction{
+					Name: prompts.ReviewFn.Name,
+				},
+			},
+			Messages: []openai.ChatCompletionMessage{
+				{
+					Role:    openai.ChatMessageRoleSystem,
+					Content: prompts.SysReview,
+				},
+				{
+					Role:    openai.ChatMessageRoleUser,
+					Content: prompts.GetReviewPrompt(diff, includedFiles, includedRelated),
+				},
+			},
+			Temperature:    config.Temperature,
+			TopP:           config.TopP,
+			ResponseFormat: config.OpenAIResponseFormat,
+		},
+	)
+
+	if err != nil {
+		fmt.Printf("Error during review model call: %v\n", err)
+		return nil, err
+	}
+
+	var res string
+	for _, choice := range resp.Choices {
+		if len(choice.Message.ToolCalls) == 1 &&
+			choice.Message.ToolCalls[0].Function.Name == prompts.ReviewFn.Name {
+			res = choice.Message.ToolCalls[0].Function.Arguments
+			break
+		}
+	}
+
+	if res == "" {
+		return nil, fmt.Errorf("no reportFindings function call found in response")
+	}
+
+	var reviewRes prompts.ReviewRes
+	err = json.Unmarshal([]byte(res), &reviewRes)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling review response: %v", err)
+	}
+
+	// an unknown severity from the model is treated as medium rather than dropped
+	findings := []*shared.ReviewFinding{}
+	for _, finding := range reviewRes.Findings {
+		if finding == nil {
+			continue
+		}
+		severity, ok := shared.ParseReviewSeverity(string(finding.Severity))
+		if !ok {
+			severity = shared.ReviewSeverityMedium
+		}
+		finding.Severity = severity
+		findings = append(findings, finding)
+	}
+
+	return findings, nil
+}
@@ -0,0 +1,94 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"plandex-server/model/prompts"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
+)
+
+// SecurityScan flags the risky operations a plan's drafted changes add. Flags with a risk the model made up are dropped.
+func SecurityScan(client *openai.Client, config shared.TaskRoleConfig, meter Meter, diff string) ([]*shared.SecurityFlag, error) {
+	diff, err := truncateDiffForModel(config.BaseModelConfig, diff, config.BaseModelConfig.MaxTokens-reviewReservedTokens)
+	if err != nil {
+		return nil, err
+	}
+
+	meter.Phase = shared.UsagePhaseSecurity
+	resp, err := CreateMeteredChatCompletion(
+		client,
+		context.Background(),
+		meter,
+		openai.ChatCompletionRequest{
+			Model: config.BaseModelConfig.ModelName,
+			Tools: []openai.Tool{
+				{
+					Type:     "function",
+					Function: &prompts.SecurityScanFn,
+				},
+			},
+			ToolChoice: openai.ToolChoice{
+				Type: "function",
+				Function: openai.ToolFunction{
+					Name: prompts.SecurityScanFn.Name,
+				},
+			},
+			Messages: []openai.ChatCompletionMessage{
+				{
+					Role:    openai.ChatMessageRoleSystem,
+					Content: prompts.SysSecurityScan,
+				},
+				{
+					Role:    openai.ChatMessageRoleUser,
+					Content: "Diff:\n" + diff,
+				},
+			},
+			Temperature:    config.Temperature,
+			TopP:           config.TopP,
+			ResponseFormat: config.OpenAIResponseFormat,
+		},
+	)
+
+	if err != nil {
+		fmt.Printf("Error during security scan model call: %v\n", err)
+		return nil, err
+	}
+
+	var res string
+	for _, choice := range resp.Choices {
+		if len(choice.Message.ToolCalls) == 1 &&
+			choice.Message.ToolCalls[0].Function.Name == prompts.SecurityScanFn.Name {
+			res = choice.Message.ToolCalls[0].Function.Arguments
+			break
+		}
+	}
+
+	if res == "" {
+		return nil, fmt.Errorf("no reportSecurityFlags function call found in response")
+	}
+
+	var scanRes prompts.SecurityScanRes
+	err = json.Unmarshal([]byte(res), &scanRes)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling security scan response: %v", err)
+	}
+
+	known := map[shared.SecurityRisk]bool{}
+	for _, risk := range shared.SecurityRisks {
+		known[risk] = true
+	}
+
+	flags := []*shared.SecurityFlag{}
+	for _, flag := range scanRes.Flags {
+		if flag == nil || !known[flag.Risk] {
+			continue
+		}
+		flag.Source = shared.SecurityFlagSourceModel
+		flags = append(flags, flag)
+	}
+
+	return flags, nil
+}
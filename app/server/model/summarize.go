@@ -20,7 +20,7 @@ type PlanSummaryParams struct {
 	PlanId                      string
 }
 
-func PlanSummary(client *openai.Client, config shared.ModelRoleConfig, params PlanSummaryParams, ctx context.Context) (*db.ConvoSummary, error) {
+func PlanSummary(client *openai.Client, config shared.ModelRoleConfig, meter Meter, params PlanSummaryParams, ctx context.Context) (*db.ConvoSummary, error) {
 	messages := []openai.ChatCompletionMessage{
 		{
 			Role:    openai.ChatMessageRoleSystem,
@@ -40,15 +40,17 @@ func PlanSummary(client *openai.Client, config shared.ModelRoleConfig, params Pl
 	// fmt.Println("summarizing messages:")
 	// spew.Dump(messages)
 
-	resp, err := CreateChatCompletionWithRetries(
+	meter.Phase = shared.UsagePhaseSummary
+	resp, err := CreateMeteredChatCompletion(
 		client,
 		ctx,
-		openai.ChatCompletionRequest{
+		meter,
+		AdaptRequestToModel(openai.ChatCompletionRequest{
 			Model:       config.BaseModelConfig.ModelName,
 			Messages:    messages,
 			Temperature: config.Temperature,
 			TopP:        config.TopP,
-		},
+		}),
 	)
 
 	if err != nil {
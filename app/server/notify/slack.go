@@ -0,0 +1,140 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// PlanEvent is a plan stream that finished building or failed
+type PlanEvent struct {
+	PlanId   string
+	PlanName string
+	Branch   string
+	// only set if the org has chosen to include prompts in notifications
+	Prompt string
+	// empty if the stream finished successfully
+	Error    string
+	NumFiles int
+	Usage    shared.UsageTotal
+	Duration time.Duration
+}
+
+// redirects aren't followed, so a webhook can't send the server's post on to a host that wasn't checked
+var slackClient = &http.Client{
+	Timeout: 10 * time.Second,
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// slackWebhookHosts are the hosts Slack serves incoming webhooks from, including GovSlack's
+var slackWebhookHosts = map[string]bool{
+	"hooks.slack.com":     true,
+	"hooks.slack-gov.com": true,
+}
+
+// ValidateSlackWebhookUrl checks that the url is a Slack incoming webhook. The server posts to it, so it's limited to Slack's webhook hosts rather than any url, which could point the server at internal addresses.
+func ValidateSlackWebhookUrl(webhookUrl string) error {
+	u, err := url.Parse(webhookUrl)
+	if err != nil || u.Scheme != "https" || u.User != nil || u.Port() != "" || !slackWebhookHosts[strings.ToLower(u.Hostname())] {
+		return errors.New("slack webhook url must be an https url on hooks.slack.com")
+	}
+	return nil
+}
+
+// slackMinDuration skips notifications for streams shorter than SLACK_NOTIFY_MIN_SECONDS, so only long builds that someone may have stepped away from are posted
+func slackMinDuration() time.Duration {
+	seconds, err := strconv.Atoi(os.Getenv("SLACK_NOTIFY_MIN_SECONDS"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// PostPlanEventToSlack posts the event to the org's Slack incoming webhook
+func PostPlanEventToSlack(webhookUrl string, event PlanEvent) error {
+	if event.Duration < slackMinDuration() {
+		return nil
+	}
+
+	// urls saved before they were limited to Slack's hosts are checked here too
+	err := ValidateSlackWebhookUrl(webhookUrl)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(map[string]any{"text": slackText(event)})
+	if err != nil {
+		return fmt.Errorf("error marshalling slack message: %v", err)
+	}
+
+	res, err := slackClient.Post(webhookUrl, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error posting to slack: %v", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		resBody, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("slack responded with %d: %s", res.StatusCode, strings.TrimSpace(string(resBody)))
+	}
+
+	return nil
+}
+
+func slackText(event PlanEvent) string {
+	var b strings.Builder
+
+	if event.Error == "" {
+		fmt.Fprintf(&b, ":white_check_mark: *%s* (%s) finished building\n", slackEscape(event.PlanName), slackEscape(event.Branch))
+	} else {
+		fmt.Fprintf(&b, ":x: *%s* (%s) failed: %s\n", slackEscape(event.PlanName), slackEscape(event.Branch), slackEscape(event.Error))
+	}
+
+	if event.Prompt != "" {
+		prompt := event.Prompt
+		if runes := []rune(prompt); len(runes) > 200 {
+			prompt = string(runes[:200]) + "…"
+		}
+		fmt.Fprintf(&b, ">%s\n", slackEscape(strings.ReplaceAll(prompt, "\n", " ")))
+	}
+
+	suffix := ""
+	if event.NumFiles != 1 {
+		suffix = "s"
+	}
+	fmt.Fprintf(&b, "%d file%s built · %d tokens · %s · %s\n",
+		event.NumFiles, suffix,
+		event.Usage.PromptTokens+event.Usage.CompletionTokens,
+		event.Usage.FormatCost(),
+		event.Duration.Round(time.Second))
+
+	if event.Error == "" && event.NumFiles > 0 {
+		fmt.Fprintf(&b, "Review with `plandex cd %s && plandex checkout %s && plandex changes`", slackEscape(shellQuote(event.PlanName)), slackEscape(shellQuote(event.Branch)))
+	}
+
+	return b.String()
+}
+
+// slackEscape escapes the characters Slack treats as control characters in message text
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+func shellQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t'\"\\$`!*?;&|<>()[]{}#~") {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,29 @@
+package notify
+
+import "testing"
+
+func TestValidateSlackWebhookUrl(t *testing.T) {
+	for _, webhookUrl := range []string{
+		"https://hooks.slack.com/services/T000/B000/XXXX",
+		"https://hooks.slack-gov.com/services/T000/B000/XXXX",
+	} {
+		if err := ValidateSlackWebhookUrl(webhookUrl); err != nil {
+			t.Errorf("expected %s to be allowed, got %v", webhookUrl, err)
+		}
+	}
+
+	for _, webhookUrl := range []string{
+		"http://hooks.slack.com/services/T000/B000/XXXX",
+		"https://169.254.169.254/latest/meta-data/",
+		"https://10.0.0.5/",
+		"https://localhost/",
+		"https://hooks.slack.com.evil.example/",
+		"https://hooks.slack.com:8443/services/T000",
+		"https://user@hooks.slack.com/services/T000",
+		"not a url",
+	} {
+		if err := ValidateSlackWebhookUrl(webhookUrl); err == nil {
+			t.Errorf("expected %s to be rejected", webhookUrl)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package openapi
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+)
+
+type Schema map[string]any
+
+// Generate builds an OpenAPI 3 document for the operations, with schemas derived from their request and response types
+func Generate(version string, ops []shared.ApiOperation) map[string]any {
+	g := &generator{schemas: map[string]Schema{}}
+
+	paths := map[string]map[string]any{}
+	for _, op := range ops {
+		if paths[op.Path] == nil {
+			paths[op.Path] = map[string]any{}
+		}
+		paths[op.Path][strings.ToLower(op.Method)] = g.operation(op)
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "Plandex Server API",
+			"version": version,
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": g.schemas,
+			"securitySchemes": map[string]any{
+				"bearerAuth": map[string]any{
+					"type":        "http",
+					"scheme":      "bearer",
+					"description": "base64-encoded JSON with the user's auth token and org id: {\"token\": \"...\", \"orgId\": \"...\"}",
+				},
+			},
+		},
+		"security": []map[string][]string{{"bearerAuth": {}}},
+	}
+}
+
+type generator struct {
+	schemas map[string]Schema
+}
+
+func (g *generator) operation(op shared.ApiOperation) map[string]any {
+	var params []map[string]any
+	for _, name := range op.PathParams() {
+		params = append(params, map[string]any{
+			"name":     name,
+			"in":       "path",
+			"required": true,
+			"schema":   Schema{"type": "string"},
+		})
+	}
+	for _, name := range op.Query {
+		params = append(params, map[string]any{
+			"name":    name,
+			"in":      "query",
+			"explode": true,
+			"schema":  Schema{"type": "array", "items": Schema{"type": "string"}},
+		})
+	}
+
+	res := map[string]any{
+		"operationId": op.Id,
+		"summary":     op.Summary,
+		"description": op.Description,
+	}
+	if len(params) > 0 {
+		res["parameters"] = params
+	}
+	if op.Unauthenticated {
+		// overrides the document's bearerAuth requirement
+		res["security"] = []map[string][]string{}
+	}
+
+	if op.Request != nil {
+		res["requestBody"] = map[string]any{
+			"required": true,
+			"content": map[string]any{
+				"application/json": map[string]any{"schema": g.schemaFor(reflect.TypeOf(op.Request))},
+			},
+		}
+	}
+
+	success := map[string]any{"description": "OK"}
+	if op.Response != nil {
+		schema := g.schemaFor(reflect.TypeOf(op.Response))
+		if op.Streaming {
+			success["description"] = "A stream of messages, each separated by '" + shared.STREAM_MESSAGE_SEPARATOR + "'"
+			success["content"] = map[string]any{
+				"text/plain": map[string]any{"schema": schema},
+			}
+		} else {
+			success["content"] = map[string]any{
+				"application/json": map[string]any{"schema": schema},
+			}
+		}
+	}
+
+	res["responses"] = map[string]any{
+		"200": success,
+		"default": map[string]any{
+			"description": "An error, as JSON or plain text",
+			"content": map[string]any{
+				"application/json": map[string]any{"schema": g.schemaFor(reflect.TypeOf(shared.ApiError{}))},
+				"text/plain":       map[string]any{"schema": Schema{"type": "string"}},
+			},
+		},
+	}
+
+	return res
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaFor returns an inline schema for basic types and a reference for named structs, which are added to the components
+func (g *generator) schemaFor(t reflect.Type) Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return Schema{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Schema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}
+	case reflect.String:
+		return Schema{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return Schema{"type": "string", "format": "byte"}
+		}
+		return Schema{"type": "array", "items": g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return Schema{"type": "object", "additionalProperties": g.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		ref := Schema{"$ref": "#/components/schemas/" + t.Name()}
+		if _, ok := g.schemas[t.Name()]; !ok {
+			// set first so recursive types refer back to it instead of looping
+			g.schemas[t.Name()] = Schema{}
+			g.schemas[t.Name()] = g.structSchema(t)
+		}
+		return ref
+	}
+
+	// interfaces and anything else can hold any value
+	return Schema{}
+}
+
+func (g *generator) structSchema(t reflect.Type) Schema {
+	properties := map[string]any{}
+	var required []string
+
+	var addFields func(t reflect.Type)
+	addFields = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			tag := field.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name, opts, _ := strings.Cut(tag, ",")
+
+			// embedded structs without a name are flattened into the parent, like encoding/json does
+			if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+				addFields(field.Type)
+				continue
+			}
+
+			if name == "" {
+				name = field.Name
+			}
+
+			properties[name] = g.schemaFor(field.Type)
+			if !strings.Contains(opts, "omitempty") && field.Type.Kind() != reflect.Pointer {
+				required = append(required, name)
+			}
+		}
+	}
+	addFields(t)
+
+	schema := Schema{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// Handler serves the document for the operations as JSON. The version is read for each request, since it can change when the server is updated in place.
+func Handler(version func() string, ops []shared.ApiOperation) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		bytes, err := json.MarshalIndent(Generate(version(), ops), "", "  ")
+		if err != nil {
+			log.Printf("Error marshalling OpenAPI document: %v\n", err)
+			http.Error(w, "Error marshalling OpenAPI document", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(bytes)
+	}
+}
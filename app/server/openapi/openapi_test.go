@@ -0,0 +1,172 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+)
+
+type testItem struct {
+	Name      string     `json:"name"`
+	Note      string     `json:"note,omitempty"`
+	Parent    *testItem  `json:"parent"`
+	CreatedAt time.Time  `json:"createdAt"`
+	Tags      []string   `json:"tags"`
+	Raw       []byte     `json:"raw"`
+	Children  []testItem `json:"children"`
+	Ignored   string     `json:"-"`
+}
+
+type testRequest struct {
+	Item  testItem `json:"item"`
+	Force bool     `json:"force"`
+}
+
+var testOps = []shared.ApiOperation{
+	{
+		Id:       "getItem",
+		Method:   http.MethodGet,
+		Path:     "/items/{itemId}",
+		Query:    []string{"expand"},
+		Response: testItem{},
+	},
+	{
+		Id:       "updateItem",
+		Method:   http.MethodPut,
+		Path:     "/items/{itemId}",
+		Request:  testRequest{},
+		Response: testItem{},
+	},
+	{
+		Id:        "watchItem",
+		Method:    http.MethodPatch,
+		Path:      "/items/{itemId}/watch",
+		Response:  shared.StreamMessage{},
+		Streaming: true,
+	},
+	{
+		Id:              "signIn",
+		Method:          http.MethodPost,
+		Path:            "/sign_in",
+		Unauthenticated: true,
+	},
+}
+
+// roundTrip converts the document to plain JSON values so the test reads it the way clients do
+func roundTrip(t *testing.T, doc map[string]any) map[string]any {
+	t.Helper()
+	bytes, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("marshalling document: %v", err)
+	}
+	var res map[string]any
+	err = json.Unmarshal(bytes, &res)
+	if err != nil {
+		t.Fatalf("unmarshalling document: %v", err)
+	}
+	return res
+}
+
+func get(t *testing.T, v any, keys ...string) any {
+	t.Helper()
+	for _, key := range keys {
+		m, ok := v.(map[string]any)
+		if !ok {
+			t.Fatalf("expected an object at %q in %v", key, keys)
+		}
+		v, ok = m[key]
+		if !ok {
+			t.Fatalf("missing %q in %v", key, keys)
+		}
+	}
+	return v
+}
+
+func TestGeneratePaths(t *testing.T) {
+	doc := roundTrip(t, Generate("1.2.3", testOps))
+
+	if v := get(t, doc, "info", "version"); v != "1.2.3" {
+		t.Errorf("expected version 1.2.3, got %v", v)
+	}
+
+	// operations on the same path share its entry
+	if id := get(t, doc, "paths", "/items/{itemId}", "get", "operationId"); id != "getItem" {
+		t.Errorf("expected getItem, got %v", id)
+	}
+	if id := get(t, doc, "paths", "/items/{itemId}", "put", "operationId"); id != "updateItem" {
+		t.Errorf("expected updateItem, got %v", id)
+	}
+
+	params := get(t, doc, "paths", "/items/{itemId}", "get", "parameters").([]any)
+	if len(params) != 2 {
+		t.Fatalf("expected a path and a query param, got %v", params)
+	}
+	if get(t, params[0], "in") != "path" || get(t, params[0], "name") != "itemId" || get(t, params[0], "required") != true {
+		t.Errorf("unexpected path param %v", params[0])
+	}
+	if get(t, params[1], "in") != "query" || get(t, params[1], "name") != "expand" {
+		t.Errorf("unexpected query param %v", params[1])
+	}
+
+	if ref := get(t, doc, "paths", "/items/{itemId}", "put", "requestBody", "content", "application/json", "schema", "$ref"); ref != "#/components/schemas/testRequest" {
+		t.Errorf("unexpected request schema %v", ref)
+	}
+
+	streamContent := get(t, doc, "paths", "/items/{itemId}/watch", "patch", "responses", "200", "content").(map[string]any)
+	if _, ok := streamContent["text/plain"]; !ok {
+		t.Errorf("expected a streaming response to be text/plain, got %v", streamContent)
+	}
+}
+
+func TestGenerateSecurity(t *testing.T) {
+	doc := roundTrip(t, Generate("1.2.3", testOps))
+
+	if security := get(t, doc, "security").([]any); len(security) != 1 {
+		t.Errorf("expected bearerAuth for the document, got %v", security)
+	}
+
+	signIn := get(t, doc, "paths", "/sign_in", "post").(map[string]any)
+	security, ok := signIn["security"].([]any)
+	if !ok || len(security) != 0 {
+		t.Errorf("expected an empty security requirement for an unauthenticated operation, got %v", signIn["security"])
+	}
+
+	getItem := get(t, doc, "paths", "/items/{itemId}", "get").(map[string]any)
+	if _, ok := getItem["security"]; ok {
+		t.Errorf("expected an authenticated operation to use the document's security, got %v", getItem["security"])
+	}
+}
+
+func TestGenerateSchemas(t *testing.T) {
+	doc := roundTrip(t, Generate("1.2.3", testOps))
+
+	item := get(t, doc, "components", "schemas", "testItem").(map[string]any)
+	props := item["properties"].(map[string]any)
+
+	if _, ok := props["Ignored"]; ok {
+		t.Errorf("expected fields tagged json:\"-\" to be left out")
+	}
+	if ref := get(t, props, "parent", "$ref"); ref != "#/components/schemas/testItem" {
+		t.Errorf("expected a recursive field to refer back to its type, got %v", ref)
+	}
+	if format := get(t, props, "createdAt", "format"); format != "date-time" {
+		t.Errorf("expected times to be date-time strings, got %v", format)
+	}
+	if format := get(t, props, "raw", "format"); format != "byte" {
+		t.Errorf("expected byte slices to be base64 strings, got %v", format)
+	}
+	if typ := get(t, props, "tags", "items", "type"); typ != "string" {
+		t.Errorf("expected an array of strings, got %v", typ)
+	}
+
+	required := map[string]bool{}
+	for _, name := range item["required"].([]any) {
+		required[name.(string)] = true
+	}
+	if !required["name"] || required["note"] || required["parent"] {
+		t.Errorf("expected omitempty and pointer fields to be optional, got required %v", item["required"])
+	}
+}
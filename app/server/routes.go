@@ -5,8 +5,11 @@ import (
 	"net/http"
 	"os"
 	"plandex-server/handlers"
+	"plandex-server/openapi"
+	"strings"
 
 	"github.com/gorilla/mux"
+	"github.com/plandex/plandex/shared"
 )
 
 func routes() *mux.Router {
@@ -28,75 +31,113 @@ func routes() *mux.Router {
 		fmt.Fprint(w, string(bytes))
 	})
 
-	r.HandleFunc("/accounts/start_trial", handlers.StartTrialHandler).Methods("POST")
-	r.HandleFunc("/accounts/email_verifications", handlers.CreateEmailVerificationHandler).Methods("POST")
-	r.HandleFunc("/accounts/sign_in", handlers.SignInHandler).Methods("POST")
-	r.HandleFunc("/accounts/sign_out", handlers.SignOutHandler).Methods("POST")
-	r.HandleFunc("/accounts", handlers.CreateAccountHandler).Methods("POST")
-	r.HandleFunc("/accounts/convert_trial", handlers.ConvertTrialHandler).Methods("POST")
-
-	r.HandleFunc("/orgs/session", handlers.GetOrgSessionHandler).Methods("GET")
-	r.HandleFunc("/orgs", handlers.ListOrgsHandler).Methods("GET")
-	r.HandleFunc("/orgs", handlers.CreateOrgHandler).Methods("POST")
-
-	r.HandleFunc("/users", handlers.ListUsersHandler).Methods("GET")
-	r.HandleFunc("/orgs/users/{userId}", handlers.DeleteOrgUserHandler).Methods("DELETE")
-	r.HandleFunc("/orgs/roles", handlers.ListOrgRolesHandler).Methods("GET")
-
-	r.HandleFunc("/invites", handlers.InviteUserHandler).Methods("POST")
-	r.HandleFunc("/invites/pending", handlers.ListPendingInvitesHandler).Methods("GET")
-	r.HandleFunc("/invites/accepted", handlers.ListAcceptedInvitesHandler).Methods("GET")
-	r.HandleFunc("/invites/all", handlers.ListAllInvitesHandler).Methods("GET")
-	r.HandleFunc("/invites/{inviteId}", handlers.DeleteInviteHandler).Methods("DELETE")
-
-	r.HandleFunc("/projects", handlers.CreateProjectHandler).Methods("POST")
-	r.HandleFunc("/projects", handlers.ListProjectsHandler).Methods("GET")
-	r.HandleFunc("/projects/{projectId}/set_plan", handlers.ProjectSetPlanHandler).Methods("PUT")
-	r.HandleFunc("/projects/{projectId}/rename", handlers.RenameProjectHandler).Methods("PUT")
-
-	r.HandleFunc("/projects/{projectId}/plans/current_branches", handlers.GetCurrentBranchByPlanIdHandler).Methods("POST")
-
-	r.HandleFunc("/plans", handlers.ListPlansHandler).Methods("GET")
-	r.HandleFunc("/plans/archive", handlers.ListArchivedPlansHandler).Methods("GET")
-	r.HandleFunc("/plans/ps", handlers.ListPlansRunningHandler).Methods("GET")
-
-	r.HandleFunc("/projects/{projectId}/plans", handlers.CreatePlanHandler).Methods("POST")
-
-	r.HandleFunc("/projects/{projectId}/plans", handlers.CreatePlanHandler).Methods("DELETE")
-
-	r.HandleFunc("/plans/{planId}", handlers.GetPlanHandler).Methods("GET")
-	r.HandleFunc("/plans/{planId}", handlers.DeletePlanHandler).Methods("DELETE")
-
-	r.HandleFunc("/plans/{planId}/{branch}/tell", handlers.TellPlanHandler).Methods("POST")
-
-	r.HandleFunc("/plans/{planId}/{branch}/respond_missing_file", handlers.RespondMissingFileHandler).Methods("POST")
-
-	r.HandleFunc("/plans/{planId}/{branch}/build", handlers.BuildPlanHandler).Methods("PATCH")
-	r.HandleFunc("/plans/{planId}/{branch}/connect", handlers.ConnectPlanHandler).Methods("PATCH")
-	r.HandleFunc("/plans/{planId}/{branch}/stop", handlers.StopPlanHandler).Methods("DELETE")
-
-	r.HandleFunc("/plans/{planId}/{branch}/current_plan", handlers.CurrentPlanHandler).Methods("GET")
-	r.HandleFunc("/plans/{planId}/{branch}/apply", handlers.ApplyPlanHandler).Methods("PATCH")
-	r.HandleFunc("/plans/{planId}/{branch}/archive", handlers.ArchivePlanHandler).Methods("PATCH")
-	r.HandleFunc("/plans/{planId}/{branch}/reject_all", handlers.RejectAllChangesHandler).Methods("PATCH")
-	r.HandleFunc("/plans/{planId}/{branch}/reject_file", handlers.RejectFileHandler).Methods("PATCH")
-
-	r.HandleFunc("/plans/{planId}/{branch}/context", handlers.ListContextHandler).Methods("GET")
-	r.HandleFunc("/plans/{planId}/{branch}/context", handlers.LoadContextHandler).Methods("POST")
-	r.HandleFunc("/plans/{planId}/{branch}/context", handlers.UpdateContextHandler).Methods("PUT")
-	r.HandleFunc("/plans/{planId}/{branch}/context", handlers.DeleteContextHandler).Methods("DELETE")
-
-	r.HandleFunc("/plans/{planId}/{branch}/convo", handlers.ListConvoHandler).Methods("GET")
-	r.HandleFunc("/plans/{planId}/{branch}/rewind", handlers.RewindPlanHandler).Methods("PATCH")
-	r.HandleFunc("/plans/{planId}/{branch}/logs", handlers.ListLogsHandler).Methods("GET")
-
-	r.HandleFunc("/plans/{planId}/branches", handlers.ListBranchesHandler).Methods("GET")
-	r.HandleFunc("/plans/{planId}/branches/{branch}", handlers.DeleteBranchHandler).Methods("DELETE")
-	r.HandleFunc("/plans/{planId}/{branch}/branches", handlers.CreateBranchHandler).Methods("POST")
-
-	r.HandleFunc("/plans/{planId}/{branch}/settings", handlers.GetSettingsHandler).Methods("GET")
-	r.HandleFunc("/plans/{planId}/{branch}/settings", handlers.UpdateSettingsHandler).Methods("PUT")
+	r.HandleFunc("/openapi.json", openapi.Handler(func() string {
+		bytes, _ := os.ReadFile("version.txt")
+		return strings.TrimSpace(string(bytes))
+	}, shared.ApiOperations)).Methods("GET")
+
+	// every other endpoint is described by shared.ApiOperations, and registered from the same table the OpenAPI document is generated from
+	handlersByOperationId := map[string]http.HandlerFunc{
+		shared.ApiOperationProposePlan.Id:       handlers.TellPlanHandler,
+		shared.ApiOperationConfirmPlan.Id:       handlers.ApplyPlanHandler,
+		shared.ApiOperationAbortPlan.Id:         handlers.StopPlanHandler,
+		shared.ApiOperationGetPlanStatus.Id:     handlers.CurrentPlanHandler,
+		shared.ApiOperationListPlans.Id:         handlers.ListPlansHandler,
+		shared.ApiOperationListConvoMessages.Id: handlers.ListConvoMessagesHandler,
+		shared.ApiOperationListPlanEvents.Id:    handlers.ListPlanEventsHandler,
+
+		shared.ApiOperationStartTrial.Id:              handlers.StartTrialHandler,
+		shared.ApiOperationCreateEmailVerification.Id: handlers.CreateEmailVerificationHandler,
+		shared.ApiOperationSignIn.Id:                  handlers.SignInHandler,
+		shared.ApiOperationSignOut.Id:                 handlers.SignOutHandler,
+		shared.ApiOperationCreateAccount.Id:           handlers.CreateAccountHandler,
+		shared.ApiOperationConvertTrial.Id:            handlers.ConvertTrialHandler,
+
+		shared.ApiOperationGetOrgSession.Id: handlers.GetOrgSessionHandler,
+		shared.ApiOperationListOrgs.Id:      handlers.ListOrgsHandler,
+		shared.ApiOperationCreateOrg.Id:     handlers.CreateOrgHandler,
+
+		shared.ApiOperationListUsers.Id:              handlers.ListUsersHandler,
+		shared.ApiOperationDeleteOrgUser.Id:          handlers.DeleteOrgUserHandler,
+		shared.ApiOperationListOrgRoles.Id:           handlers.ListOrgRolesHandler,
+		shared.ApiOperationGetOrgUsage.Id:            handlers.GetOrgUsageHandler,
+		shared.ApiOperationGetOrgSlackSettings.Id:    handlers.GetOrgSlackSettingsHandler,
+		shared.ApiOperationUpdateOrgSlackSettings.Id: handlers.UpdateOrgSlackSettingsHandler,
+		shared.ApiOperationGetOrgBudget.Id:           handlers.GetOrgBudgetHandler,
+		shared.ApiOperationUpdateOrgBudget.Id:        handlers.UpdateOrgBudgetHandler,
+
+		shared.ApiOperationInviteUser.Id:          handlers.InviteUserHandler,
+		shared.ApiOperationListPendingInvites.Id:  handlers.ListPendingInvitesHandler,
+		shared.ApiOperationListAcceptedInvites.Id: handlers.ListAcceptedInvitesHandler,
+		shared.ApiOperationListAllInvites.Id:      handlers.ListAllInvitesHandler,
+		shared.ApiOperationDeleteInvite.Id:        handlers.DeleteInviteHandler,
+
+		shared.ApiOperationCreateProject.Id:            handlers.CreateProjectHandler,
+		shared.ApiOperationListProjects.Id:             handlers.ListProjectsHandler,
+		shared.ApiOperationSetProjectPlan.Id:           handlers.ProjectSetPlanHandler,
+		shared.ApiOperationRenameProject.Id:            handlers.RenameProjectHandler,
+		shared.ApiOperationGetCurrentBranchByPlanId.Id: handlers.GetCurrentBranchByPlanIdHandler,
+
+		shared.ApiOperationCheckModels.Id:    handlers.CheckModelsHandler,
+		shared.ApiOperationDoctor.Id:         handlers.DoctorHandler,
+		shared.ApiOperationGenCommitMsg.Id:   handlers.GenCommitMsgHandler,
+		shared.ApiOperationGenPullRequest.Id: handlers.GenPullRequestHandler,
+		shared.ApiOperationReview.Id:         handlers.ReviewHandler,
+		shared.ApiOperationSecurityScan.Id:   handlers.SecurityScanHandler,
+
+		shared.ApiOperationListArchivedPlans.Id: handlers.ListArchivedPlansHandler,
+		shared.ApiOperationListPlansRunning.Id:  handlers.ListPlansRunningHandler,
+		shared.ApiOperationListSharedPlans.Id:   handlers.ListSharedPlansHandler,
+
+		shared.ApiOperationCreatePlan.Id:       handlers.CreatePlanHandler,
+		shared.ApiOperationDeleteAllPlans.Id:   handlers.DeleteAllPlansHandler,
+		shared.ApiOperationImportPlanBundle.Id: handlers.ImportPlanBundleHandler,
+
+		shared.ApiOperationGetPlan.Id:               handlers.GetPlanHandler,
+		shared.ApiOperationExportPlanBundle.Id:      handlers.ExportPlanBundleHandler,
+		shared.ApiOperationGetPlanUsage.Id:          handlers.GetPlanUsageHandler,
+		shared.ApiOperationListPlanPullRequests.Id:  handlers.ListPlanPullRequestsHandler,
+		shared.ApiOperationCreatePlanPullRequest.Id: handlers.CreatePlanPullRequestHandler,
+		shared.ApiOperationDeletePlan.Id:            handlers.DeletePlanHandler,
+		shared.ApiOperationSharePlan.Id:             handlers.SharePlanHandler,
+		shared.ApiOperationUnsharePlan.Id:           handlers.UnsharePlanHandler,
+		shared.ApiOperationArchivePlan.Id:           handlers.ArchivePlanHandler,
+
+		shared.ApiOperationRespondMissingFile.Id: handlers.RespondMissingFileHandler,
+		shared.ApiOperationRespondClarify.Id:     handlers.RespondClarifyHandler,
+
+		shared.ApiOperationBuildPlan.Id:   handlers.BuildPlanHandler,
+		shared.ApiOperationConnectPlan.Id: handlers.ConnectPlanHandler,
+
+		shared.ApiOperationRejectAllChanges.Id:    handlers.RejectAllChangesHandler,
+		shared.ApiOperationRejectFile.Id:          handlers.RejectFileHandler,
+		shared.ApiOperationRecordCommandResult.Id: handlers.RecordCommandResultHandler,
+
+		shared.ApiOperationListContext.Id:   handlers.ListContextHandler,
+		shared.ApiOperationLoadContext.Id:   handlers.LoadContextHandler,
+		shared.ApiOperationUpdateContext.Id: handlers.UpdateContextHandler,
+		shared.ApiOperationDeleteContext.Id: handlers.DeleteContextHandler,
+
+		shared.ApiOperationListConvo.Id:  handlers.ListConvoHandler,
+		shared.ApiOperationRewindPlan.Id: handlers.RewindPlanHandler,
+		shared.ApiOperationListLogs.Id:   handlers.ListLogsHandler,
+
+		shared.ApiOperationListBranches.Id: handlers.ListBranchesHandler,
+		shared.ApiOperationDeleteBranch.Id: handlers.DeleteBranchHandler,
+		shared.ApiOperationCreateBranch.Id: handlers.CreateBranchHandler,
+
+		shared.ApiOperationGetSettings.Id:    handlers.GetSettingsHandler,
+		shared.ApiOperationUpdateSettings.Id: handlers.UpdateSettingsHandler,
+	}
+
+	// gorilla/mux matches routes in the order they're added, so paths with literal segments like /plans/archive come before /plans/{planId} in the table
+	for _, op := range shared.ApiOperations {
+		handler, ok := handlersByOperationId[op.Id]
+		if !ok {
+			panic(fmt.Sprintf("no handler for API operation %s", op.Id))
+		}
+		r.HandleFunc(op.Path, handler).Methods(op.Method)
+	}
 
 	return r
-
 }
@@ -21,7 +21,7 @@ type ActiveBuild struct {
 	CurrentFileTokens int
 	Path              string
 	Idx               int
-	Buffer            string
+	Buffer            BuildBuffer
 	BufferTokens      int
 	Success           bool
 	Error             error
@@ -43,6 +43,7 @@ type ActivePlan struct {
 	Branch                  string
 	Prompt                  string
 	BuildOnly               bool
+	StartedAt               time.Time
 	Ctx                     context.Context
 	CancelFn                context.CancelFunc
 	ModelStreamCtx          context.Context
@@ -56,6 +57,7 @@ type ActivePlan struct {
 	IsBuildingByPath        map[string]bool
 	CurrentReplyContent     string
 	NumTokens               int
+	CurrentReplyUsage       *shared.ModelUsage
 	MessageNum              int
 	BuildQueuesByPath       map[string][]*ActiveBuild
 	RepliesFinished         bool
@@ -63,12 +65,18 @@ type ActivePlan struct {
 	ModelStreamId           string
 	MissingFilePath         string
 	MissingFileResponseCh   chan shared.RespondMissingFileChoice
+	ClarifyingQuestions     []string
+	ClarifyResponseCh       chan []string
 	AllowOverwritePaths     map[string]bool
 	SkippedPaths            map[string]bool
 	StoredReplyIds          []string
-	streamCh                chan string
-	subscriptions           map[string]*subscription
-	subscriptionMu          sync.Mutex
+	// set while a prompt that replaced the last one has no stored reply, to bring back the replaced prompt and its replies if the stream fails
+	RestoreReplacedPrompt func()
+	// limits how many files build at once, or nil for no limit. Created by the first build.
+	BuildSlots     chan struct{}
+	streamCh       chan string
+	subscriptions  map[string]*subscription
+	subscriptionMu sync.Mutex
 }
 
 func NewActivePlan(planId, branch, prompt string, buildOnly bool) *ActivePlan {
@@ -84,6 +92,7 @@ func NewActivePlan(planId, branch, prompt string, buildOnly bool) *ActivePlan {
 		BuildOnly:             buildOnly,
 		Branch:                branch,
 		Prompt:                prompt,
+		StartedAt:             time.Now(),
 		Ctx:                   ctx,
 		CancelFn:              cancel,
 		ModelStreamCtx:        modelStreamCtx,
@@ -98,6 +107,7 @@ func NewActivePlan(planId, branch, prompt string, buildOnly bool) *ActivePlan {
 		IsBuildingByPath:      map[string]bool{},
 		StreamDoneCh:          make(chan *shared.ApiError),
 		MissingFileResponseCh: make(chan shared.RespondMissingFileChoice),
+		ClarifyResponseCh:     make(chan []string),
 		AllowOverwritePaths:   map[string]bool{},
 		SkippedPaths:          map[string]bool{},
 		streamCh:              make(chan string),
@@ -67,7 +67,7 @@ func (ap *ActivePlan) PendingBuildsByPath(orgId, userId string, convoMessagesArg
 
 				fileContent := parserRes.FileContents[i]
 
-				numTokens, err := shared.GetNumTokens(fileContent)
+				numTokens, err := db.GetPlanNumTokens(ap.Id, shared.DefaultTokenEncoding, fileContent)
 
 				if err != nil {
 					log.Printf("Error getting num tokens for file content: %v\n", err)
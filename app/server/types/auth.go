@@ -46,4 +46,6 @@ const (
 	PermissionDeleteAnyPlan         Permission = "delete_any_plan"
 	PermissionUpdateAnyPlan         Permission = "update_any_plan"
 	PermissionArchiveAnyPlan        Permission = "archive_any_plan"
+	PermissionManageIntegrations    Permission = "manage_integrations"
+	PermissionViewServerDiagnostics Permission = "view_server_diagnostics"
 )
@@ -0,0 +1,103 @@
+package types
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// builds larger than this are moved out of memory into a temp file
+const BuildBufferSpillBytes = 256 * 1024
+
+// BuildBuffer accumulates the streamed output for a file build. It starts out in memory, and spills to a temp file once it passes BuildBufferSpillBytes, so a plan that's building many large files at once doesn't hold all of them in memory. The zero value is ready to use. It isn't safe for concurrent use--each build streams from a single goroutine.
+type BuildBuffer struct {
+	mem  strings.Builder
+	file *os.File
+	size int
+
+	// last non-whitespace byte written, so callers can cheaply check whether the output could be complete without reading it back
+	lastByte byte
+}
+
+func (b *BuildBuffer) Append(s string) error {
+	if s == "" {
+		return nil
+	}
+
+	trimmed := strings.TrimRight(s, " \t\r\n")
+	if trimmed != "" {
+		b.lastByte = trimmed[len(trimmed)-1]
+	}
+
+	b.size += len(s)
+
+	if b.file == nil && b.size > BuildBufferSpillBytes {
+		err := b.spill()
+		if err != nil {
+			return err
+		}
+	}
+
+	if b.file != nil {
+		_, err := b.file.WriteString(s)
+		if err != nil {
+			return fmt.Errorf("error writing build buffer to %s: %v", b.file.Name(), err)
+		}
+		return nil
+	}
+
+	b.mem.WriteString(s)
+	return nil
+}
+
+func (b *BuildBuffer) spill() error {
+	file, err := os.CreateTemp("", "plandex-build-*")
+	if err != nil {
+		return fmt.Errorf("error creating build buffer file: %v", err)
+	}
+
+	_, err = file.WriteString(b.mem.String())
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return fmt.Errorf("error writing build buffer to %s: %v", file.Name(), err)
+	}
+
+	b.file = file
+	b.mem.Reset()
+	return nil
+}
+
+// String returns the full buffered output, reading it back from disk if it was spilled
+func (b *BuildBuffer) String() (string, error) {
+	if b.file == nil {
+		return b.mem.String(), nil
+	}
+
+	bytes, err := os.ReadFile(b.file.Name())
+	if err != nil {
+		return "", fmt.Errorf("error reading build buffer from %s: %v", b.file.Name(), err)
+	}
+	return string(bytes), nil
+}
+
+func (b *BuildBuffer) Len() int {
+	return b.size
+}
+
+// EndsWith reports whether the last non-whitespace byte written is c
+func (b *BuildBuffer) EndsWith(c byte) bool {
+	return b.size > 0 && b.lastByte == c
+}
+
+// Reset empties the buffer and removes its temp file, if any
+func (b *BuildBuffer) Reset() {
+	if b.file != nil {
+		b.file.Close()
+		os.Remove(b.file.Name())
+		b.file = nil
+	}
+	b.mem.Reset()
+	b.size = 0
+	b.lastByte = 0
+}
@@ -12,3 +12,7 @@ type StreamedChanges struct {
 	References string                   `json:"references"`
 	Changes    []*shared.StreamedChange `json:"changes"`
 }
+
+type StreamedEdits struct {
+	Edits []*shared.StreamedEdit `json:"edits"`
+}
@@ -1,7 +1,10 @@
 package types
 
 import (
+	"regexp"
 	"strings"
+
+	"github.com/plandex/plandex/shared"
 )
 
 type parserRes struct {
@@ -257,5 +260,167 @@ func extractFilePath(line string) string {
 		p = split[0]
 	}
 
-	return p
+	return shared.NormalizePlanPath(p)
+}
+
+// ParseRemovedFiles finds the files the model proposed deleting, listed as bullets under a '### Remove Files' heading
+func ParseRemovedFiles(reply string) []string {
+	var removed []string
+	for _, item := range parseListSection(reply, "remove files") {
+		path := strings.Trim(item, "`'\"")
+		if path != "" {
+			removed = append(removed, path)
+		}
+	}
+	return removed
+}
+
+// ParseMovedFiles finds the files the model proposed moving or renaming, listed as 'from → to' bullets under a '### Move Files' heading
+func ParseMovedFiles(reply string) []*shared.MovedFile {
+	var moved []*shared.MovedFile
+	for _, item := range parseListSection(reply, "move files") {
+		var parts []string
+		for _, sep := range []string{"→", "->"} {
+			if strings.Contains(item, sep) {
+				parts = strings.SplitN(item, sep, 2)
+				break
+			}
+		}
+		if len(parts) != 2 {
+			continue
+		}
+
+		from := strings.Trim(strings.TrimSpace(parts[0]), "`'\"")
+		to := strings.Trim(strings.TrimSpace(parts[1]), "`'\"")
+		if from == "" || to == "" || from == to {
+			continue
+		}
+
+		moved = append(moved, &shared.MovedFile{From: from, To: to})
+	}
+	return moved
+}
+
+var numberedItemRegex = regexp.MustCompile(`^\d+[.)]\s+`)
+
+// ParseSubtasks finds the steps the model broke a task into, as a numbered list under a '### Subtasks' heading. Details nested under an item are left out. nested is set when the model broke up the subtask it was working on rather than the whole task.
+func ParseSubtasks(reply string) (subtasks []string, nested bool) {
+	subtasks = parseNumberedSection(reply, "subtasks")
+	if len(subtasks) == 0 {
+		return nil, false
+	}
+	return subtasks, strings.Contains(strings.ToLower(reply), "further break up")
+}
+
+// ParseClarifyingQuestions finds the questions the model asked before making a plan, as a numbered list under a '### Clarifying Questions' heading
+func ParseClarifyingQuestions(reply string) []string {
+	return parseNumberedSection(reply, "clarifying questions")
+}
+
+// parseNumberedSection returns the top-level items of the first numbered list under heading. Items indented under another item are left out.
+func parseNumberedSection(reply, heading string) []string {
+	var items []string
+	inSection := false
+	indent := -1
+
+	for _, line := range strings.Split(reply, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "#") {
+			if len(items) > 0 {
+				break
+			}
+			h := strings.ToLower(strings.TrimSpace(strings.TrimLeft(trimmed, "#")))
+			inSection = strings.TrimSuffix(h, ":") == heading
+			continue
+		}
+
+		if !inSection {
+			continue
+		}
+
+		loc := numberedItemRegex.FindStringIndex(trimmed)
+		if loc == nil {
+			continue
+		}
+
+		lineIndent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent == -1 {
+			indent = lineIndent
+		} else if lineIndent > indent {
+			continue
+		}
+
+		if item := strings.TrimSpace(trimmed[loc[1]:]); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+var shellFenceLangs = map[string]bool{
+	"bash":       true,
+	"sh":         true,
+	"shell":      true,
+	"zsh":        true,
+	"console":    true,
+	"powershell": true,
+	"ps1":        true,
+	"cmd":        true,
+	"bat":        true,
+}
+
+// HasShellCommands is whether the reply includes a shell code block, which is where models put commands for the user to run
+func HasShellCommands(reply string) bool {
+	for _, line := range strings.Split(reply, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "```") {
+			continue
+		}
+		lang := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(trimmed, "```")))
+		if shellFenceLangs[lang] {
+			return true
+		}
+	}
+	return false
+}
+
+// parseListSection returns the bullet items directly under a markdown heading, matched case-insensitively
+func parseListSection(reply, heading string) []string {
+	var items []string
+	inSection := false
+
+	for _, line := range strings.Split(reply, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "#") {
+			h := strings.ToLower(strings.TrimSpace(strings.TrimLeft(trimmed, "#")))
+			inSection = strings.TrimSuffix(h, ":") == heading
+			continue
+		}
+
+		if !inSection {
+			continue
+		}
+
+		if trimmed == "" {
+			if len(items) > 0 {
+				inSection = false
+			}
+			continue
+		}
+
+		if !(strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "* ")) {
+			inSection = false
+			continue
+		}
+
+		item := strings.TrimSpace(trimmed[2:])
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
 }
@@ -111,3 +111,100 @@ func TestReplyTokenCounter(t *testing.T) {
 		}
 	}
 }
+
+func TestParseRemovedFiles(t *testing.T) {
+	reply := "Let's clean up the old helpers.\n\n### Remove Files\n\n- `lib/old_helpers.go`\n- lib/unused.go\n\nThat's all for this step.\n\n- not/a/removal.go\n"
+
+	removed := ParseRemovedFiles(reply)
+
+	expected := []string{"lib/old_helpers.go", "lib/unused.go"}
+	if len(removed) != len(expected) {
+		t.Fatalf("Expected %d removed files, got %d: %v", len(expected), len(removed), removed)
+	}
+	for i, path := range expected {
+		if removed[i] != path {
+			t.Errorf("Expected %s, got %s", path, removed[i])
+		}
+	}
+}
+
+func TestParseMovedFiles(t *testing.T) {
+	reply := "First, move the helpers into their own package.\n\n### Move Files\n- `lib/helpers.go` → `lib/helpers/helpers.go`\n- lib/util.go -> lib/helpers/util.go\n- lib/same.go -> lib/same.go\n"
+
+	moved := ParseMovedFiles(reply)
+
+	expected := [][2]string{
+		{"lib/helpers.go", "lib/helpers/helpers.go"},
+		{"lib/util.go", "lib/helpers/util.go"},
+	}
+	if len(moved) != len(expected) {
+		t.Fatalf("Expected %d moved files, got %d: %v", len(expected), len(moved), moved)
+	}
+	for i, pair := range expected {
+		if moved[i].From != pair[0] || moved[i].To != pair[1] {
+			t.Errorf("Expected %s → %s, got %s → %s", pair[0], pair[1], moved[i].From, moved[i].To)
+		}
+	}
+}
+
+func TestParseSubtasks(t *testing.T) {
+	reply := "Let's break up this task.\n\n### Subtasks\n\n1. Add the user model\n   - include an email field\n2. Add the signup handler\n\n3) Write tests for signup\n\n## Next\n\n4. not a subtask\n"
+
+	subtasks, nested := ParseSubtasks(reply)
+
+	expected := []string{"Add the user model", "Add the signup handler", "Write tests for signup"}
+	if nested {
+		t.Errorf("Expected a top-level breakdown")
+	}
+	if len(subtasks) != len(expected) {
+		t.Fatalf("Expected %d subtasks, got %d: %v", len(expected), len(subtasks), subtasks)
+	}
+	for i, title := range expected {
+		if subtasks[i] != title {
+			t.Errorf("Expected %s, got %s", title, subtasks[i])
+		}
+	}
+
+	_, nested = ParseSubtasks("Let's further break up this subtask.\n\n### Subtasks\n1. First part\n2. Second part\n")
+	if !nested {
+		t.Errorf("Expected a nested breakdown")
+	}
+}
+
+func TestParseClarifyingQuestions(t *testing.T) {
+	reply := "Before I make a plan, I need to know a couple of things.\n\n### Clarifying Questions:\n1. Should sessions expire?\n2. Which database should store them?\n"
+
+	questions := ParseClarifyingQuestions(reply)
+
+	expected := []string{"Should sessions expire?", "Which database should store them?"}
+	if len(questions) != len(expected) {
+		t.Fatalf("Expected %d questions, got %d: %v", len(expected), len(questions), questions)
+	}
+	for i, question := range expected {
+		if questions[i] != question {
+			t.Errorf("Expected %s, got %s", question, questions[i])
+		}
+	}
+
+	if len(ParseClarifyingQuestions("### Subtasks\n1. Add the model\n")) != 0 {
+		t.Errorf("Expected no questions outside a clarifying questions section")
+	}
+}
+
+func TestReplyParserNormalizesFilePaths(t *testing.T) {
+	reply := "Update the handler.\n\n- ./server\\handlers\\api.go:\n```go\npackage handlers\n```\n\nAnd the router.\n\n- server//router.go:\n```go\npackage server\n```\n"
+
+	parser := NewReplyParser()
+	parser.AddChunk(reply, true)
+	res := parser.FinishAndRead()
+
+	expected := []string{"server/handlers/api.go", "server/router.go"}
+	if len(res.Files) != len(expected) {
+		t.Fatalf("Expected %d files, got %d: %v", len(expected), len(res.Files), res.Files)
+	}
+	for i, path := range expected {
+		if res.Files[i] != path {
+			t.Errorf("Expected %s, got %s", path, res.Files[i])
+		}
+	}
+}
@@ -4,6 +4,12 @@ import (
 	"github.com/sashabaranov/go-openai"
 )
 
+// reasoning models
+const (
+	ModelNameO1Preview = "o1-preview"
+	ModelNameO1Mini    = "o1-mini"
+)
+
 var AvailableModels = []BaseModelConfig{
 	{
 		Provider:  ModelProviderOpenAI,
@@ -25,6 +31,16 @@ var AvailableModels = []BaseModelConfig{
 		ModelName: openai.GPT4,
 		MaxTokens: 8000,
 	},
+	{
+		Provider:  ModelProviderOpenAI,
+		ModelName: ModelNameO1Preview,
+		MaxTokens: 128000,
+	},
+	{
+		Provider:  ModelProviderOpenAI,
+		ModelName: ModelNameO1Mini,
+		MaxTokens: 128000,
+	},
 	{
 		Provider:  ModelProviderOpenAI,
 		ModelName: openai.GPT3Dot5Turbo,
@@ -59,6 +75,14 @@ var PlannerModelConfigByName = map[string]PlannerModelConfig{
 		MaxConvoTokens:       2500,
 		ReservedOutputTokens: 1000,
 	},
+	ModelNameO1Preview: {
+		MaxConvoTokens:       10000,
+		ReservedOutputTokens: 32768,
+	},
+	ModelNameO1Mini: {
+		MaxConvoTokens:       10000,
+		ReservedOutputTokens: 65536,
+	},
 	openai.GPT3Dot5Turbo: {
 		MaxConvoTokens:       5000,
 		ReservedOutputTokens: 2000,
@@ -86,6 +110,12 @@ var TaskModelConfigByName = map[string]TaskModelConfig{
 	openai.GPT4: {
 		OpenAIResponseFormat: nil,
 	},
+	ModelNameO1Preview: {
+		OpenAIResponseFormat: nil,
+	},
+	ModelNameO1Mini: {
+		OpenAIResponseFormat: nil,
+	},
 	openai.GPT3Dot5Turbo: {
 		OpenAIResponseFormat: &openai.ChatCompletionResponseFormat{Type: "json_object"},
 	},
@@ -97,6 +127,50 @@ var TaskModelConfigByName = map[string]TaskModelConfig{
 	},
 }
 
+// USD per 1M tokens
+var ModelPricingByName = map[string]ModelPricing{
+	openai.GPT4TurboPreview:  {InputPerMillion: 10, OutputPerMillion: 30},
+	openai.GPT4Turbo0125:     {InputPerMillion: 10, OutputPerMillion: 30},
+	openai.GPT4Turbo1106:     {InputPerMillion: 10, OutputPerMillion: 30},
+	openai.GPT4:              {InputPerMillion: 30, OutputPerMillion: 60},
+	ModelNameO1Preview:       {InputPerMillion: 15, OutputPerMillion: 60},
+	ModelNameO1Mini:          {InputPerMillion: 3, OutputPerMillion: 12},
+	openai.GPT3Dot5Turbo:     {InputPerMillion: 0.5, OutputPerMillion: 1.5},
+	openai.GPT3Dot5Turbo0125: {InputPerMillion: 0.5, OutputPerMillion: 1.5},
+	openai.GPT3Dot5Turbo1106: {InputPerMillion: 1, OutputPerMillion: 2},
+}
+
+// EstimateCost prices usage with the model's published per-token rates. Returns false if the model isn't in the pricing table.
+func EstimateCost(usage ModelUsage) (float64, bool) {
+	pricing, ok := ModelPricingByName[usage.ModelName]
+	if !ok {
+		return 0, false
+	}
+	return pricing.Cost(usage.PromptTokens, usage.CompletionTokens), true
+}
+
+// reasoning models can't stream, call tools, take system messages, or set temperature/top-p
+var ModelCapabilitiesByName = map[string]ModelCapabilities{
+	openai.GPT4TurboPreview:  {Streaming: true, ToolCalls: true, JsonMode: true, SystemMessages: true, SamplingParams: true},
+	openai.GPT4Turbo0125:     {Streaming: true, ToolCalls: true, JsonMode: true, SystemMessages: true, SamplingParams: true},
+	openai.GPT4Turbo1106:     {Streaming: true, ToolCalls: true, JsonMode: true, SystemMessages: true, SamplingParams: true},
+	openai.GPT4:              {Streaming: true, ToolCalls: true, JsonMode: false, SystemMessages: true, SamplingParams: true},
+	openai.GPT3Dot5Turbo:     {Streaming: true, ToolCalls: true, JsonMode: true, SystemMessages: true, SamplingParams: true},
+	openai.GPT3Dot5Turbo0125: {Streaming: true, ToolCalls: true, JsonMode: true, SystemMessages: true, SamplingParams: true},
+	openai.GPT3Dot5Turbo1106: {Streaming: true, ToolCalls: true, JsonMode: true, SystemMessages: true, SamplingParams: true},
+	ModelNameO1Preview:       {},
+	ModelNameO1Mini:          {},
+}
+
+// GetModelCapabilities assumes models missing from the matrix support everything, as all models did before reasoning models
+func GetModelCapabilities(modelName string) ModelCapabilities {
+	capabilities, ok := ModelCapabilitiesByName[modelName]
+	if !ok {
+		return ModelCapabilities{Streaming: true, ToolCalls: true, JsonMode: true, SystemMessages: true, SamplingParams: true}
+	}
+	return capabilities
+}
+
 var AvailableModelsByName = map[string]BaseModelConfig{}
 var DefaultModelSet ModelSet
 
@@ -157,5 +231,20 @@ func init() {
 			},
 			TaskModelConfig: TaskModelConfigByName[openai.GPT4TurboPreview],
 		},
+		Chat: ModelRoleConfig{
+			Role:            ModelRoleChat,
+			BaseModelConfig: AvailableModelsByName[openai.GPT3Dot5Turbo],
+			Temperature:     0.5,
+			TopP:            0.5,
+		},
+		Reviewer: TaskRoleConfig{
+			ModelRoleConfig: ModelRoleConfig{
+				Role:            ModelRoleReviewer,
+				BaseModelConfig: AvailableModelsByName[openai.GPT4TurboPreview],
+				Temperature:     0.1,
+				TopP:            0.1,
+			},
+			TaskModelConfig: TaskModelConfigByName[openai.GPT4TurboPreview],
+		},
 	}
 }
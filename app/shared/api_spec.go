@@ -0,0 +1,821 @@
+package shared
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ApiOperation describes a server endpoint. The server registers its handlers and generates its OpenAPI document from these, and the CLI builds its requests from them, so the three can't drift apart.
+type ApiOperation struct {
+	Id     string
+	Method string
+	// path parameters are in braces, like {planId}
+	Path        string
+	Summary     string
+	Description string
+
+	// names of the query parameters the operation accepts, which may repeat
+	Query []string
+
+	// zero values of the request and response body types, or nil if there's no body
+	Request  any
+	Response any
+
+	// the response is a stream of StreamMessage values separated by STREAM_MESSAGE_SEPARATOR, rather than a single JSON body
+	Streaming bool
+
+	// the operation is called without a session, like signing in
+	Unauthenticated bool
+}
+
+var (
+	ApiOperationProposePlan = ApiOperation{
+		Id:          "proposePlan",
+		Method:      http.MethodPost,
+		Path:        "/plans/{planId}/{branch}/tell",
+		Summary:     "Send a prompt to a plan",
+		Description: "Sends a prompt to the plan, which replies with proposed changes that are then built into pending file updates. Unless connectStream is false, the reply, build progress, and usage are streamed back as they happen.",
+		Request:     TellPlanRequest{},
+		Response:    StreamMessage{},
+		Streaming:   true,
+	}
+
+	ApiOperationConfirmPlan = ApiOperation{
+		Id:          "confirmPlan",
+		Method:      http.MethodPatch,
+		Path:        "/plans/{planId}/{branch}/apply",
+		Summary:     "Mark a plan's pending changes as applied",
+		Description: "Called after the client writes the plan's pending changes to the project, so they're no longer pending.",
+	}
+
+	ApiOperationAbortPlan = ApiOperation{
+		Id:          "abortPlan",
+		Method:      http.MethodDelete,
+		Path:        "/plans/{planId}/{branch}/stop",
+		Summary:     "Stop a plan's active stream",
+		Description: "Stops the reply and any builds in progress. The partial reply is kept.",
+	}
+
+	ApiOperationGetPlanStatus = ApiOperation{
+		Id:          "getPlanStatus",
+		Method:      http.MethodGet,
+		Path:        "/plans/{planId}/{branch}/current_plan",
+		Summary:     "Get a plan's current state",
+		Description: "Returns the plan's pending and applied file changes, the descriptions of its replies, its context, and its subtasks.",
+		Response:    CurrentPlanState{},
+	}
+
+	ApiOperationListPlans = ApiOperation{
+		Id:          "listPlans",
+		Method:      http.MethodGet,
+		Path:        "/plans",
+		Summary:     "List plans",
+		Description: "Lists the plans in the given projects that the user can access.",
+		Query:       []string{"projectId"},
+		Response:    []*Plan{},
+	}
+
+	ApiOperationListConvoMessages = ApiOperation{
+		Id:          "listConvoMessages",
+		Method:      http.MethodGet,
+		Path:        "/plans/{planId}/{branch}/history/convo",
+		Summary:     "Search a plan's conversation",
+		Description: "Returns a page of the prompts and replies on the plan's branch, oldest first unless order is desc. q is a case-insensitive text match, since and until are RFC 3339 times, and role is user or assistant. Pages hold 50 messages unless limit is set, up to 500; pass nextOffset as offset for the next one.",
+		Query:       []string{"q", "since", "until", "role", "offset", "limit", "order"},
+		Response:    ConvoPage{},
+	}
+
+	ApiOperationListPlanEvents = ApiOperation{
+		Id:          "listPlanEvents",
+		Method:      http.MethodGet,
+		Path:        "/plans/{planId}/{branch}/history/events",
+		Summary:     "Search a plan's events",
+		Description: "Returns a page of the changes to the plan's branch, like prompts, replies, builds, and context updates, oldest first unless order is desc. Each event's sha can be passed to 'plandex rewind'. Takes the same filters and paging as listConvoMessages, other than role.",
+		Query:       []string{"q", "since", "until", "offset", "limit", "order"},
+		Response:    PlanEventPage{},
+	}
+	ApiOperationStartTrial = ApiOperation{
+		Id:              "startTrial",
+		Method:          http.MethodPost,
+		Path:            "/accounts/start_trial",
+		Summary:         "Start a trial account",
+		Description:     "Creates an anonymous trial user and org, and returns a session for them.",
+		Response:        StartTrialResponse{},
+		Unauthenticated: true,
+	}
+
+	ApiOperationCreateEmailVerification = ApiOperation{
+		Id:              "createEmailVerification",
+		Method:          http.MethodPost,
+		Path:            "/accounts/email_verifications",
+		Summary:         "Send an email verification pin",
+		Description:     "Emails a pin that's passed to signIn or createAccount, and says whether a user with the email already exists.",
+		Request:         CreateEmailVerificationRequest{},
+		Response:        CreateEmailVerificationResponse{},
+		Unauthenticated: true,
+	}
+
+	ApiOperationSignIn = ApiOperation{
+		Id:              "signIn",
+		Method:          http.MethodPost,
+		Path:            "/accounts/sign_in",
+		Summary:         "Sign in",
+		Description:     "Signs in with an email and the pin from createEmailVerification.",
+		Request:         SignInRequest{},
+		Response:        SessionResponse{},
+		Unauthenticated: true,
+	}
+
+	ApiOperationSignOut = ApiOperation{
+		Id:          "signOut",
+		Method:      http.MethodPost,
+		Path:        "/accounts/sign_out",
+		Summary:     "Sign out",
+		Description: "Invalidates the session's auth token.",
+	}
+
+	ApiOperationCreateAccount = ApiOperation{
+		Id:              "createAccount",
+		Method:          http.MethodPost,
+		Path:            "/accounts",
+		Summary:         "Create an account",
+		Description:     "Creates a user with an email and the pin from createEmailVerification.",
+		Request:         CreateAccountRequest{},
+		Response:        SessionResponse{},
+		Unauthenticated: true,
+	}
+
+	ApiOperationConvertTrial = ApiOperation{
+		Id:          "convertTrial",
+		Method:      http.MethodPost,
+		Path:        "/accounts/convert_trial",
+		Summary:     "Convert a trial account",
+		Description: "Turns the trial user and org into a full account, keeping their plans.",
+		Request:     ConvertTrialRequest{},
+		Response:    SessionResponse{},
+	}
+
+	ApiOperationGetOrgSession = ApiOperation{
+		Id:          "getOrgSession",
+		Method:      http.MethodGet,
+		Path:        "/orgs/session",
+		Summary:     "Check the session's org",
+		Description: "Succeeds if the session's auth token is valid and the user belongs to its org.",
+	}
+
+	ApiOperationListOrgs = ApiOperation{
+		Id:          "listOrgs",
+		Method:      http.MethodGet,
+		Path:        "/orgs",
+		Summary:     "List orgs",
+		Description: "Lists the orgs the user belongs to.",
+		Response:    []*Org{},
+	}
+
+	ApiOperationCreateOrg = ApiOperation{
+		Id:          "createOrg",
+		Method:      http.MethodPost,
+		Path:        "/orgs",
+		Summary:     "Create an org",
+		Description: "Creates an org owned by the user.",
+		Request:     CreateOrgRequest{},
+		Response:    CreateOrgResponse{},
+	}
+
+	ApiOperationListUsers = ApiOperation{
+		Id:          "listUsers",
+		Method:      http.MethodGet,
+		Path:        "/users",
+		Summary:     "List the org's users",
+		Description: "Lists the org's members along with their roles.",
+		Response:    ListUsersResponse{},
+	}
+
+	ApiOperationDeleteOrgUser = ApiOperation{
+		Id:          "deleteOrgUser",
+		Method:      http.MethodDelete,
+		Path:        "/orgs/users/{userId}",
+		Summary:     "Remove a user from the org",
+		Description: "Removes the user's membership in the org. Their plans are kept.",
+	}
+
+	ApiOperationListOrgRoles = ApiOperation{
+		Id:          "listOrgRoles",
+		Method:      http.MethodGet,
+		Path:        "/orgs/roles",
+		Summary:     "List org roles",
+		Description: "Lists the roles that org members can be given.",
+		Response:    []*OrgRole{},
+	}
+
+	ApiOperationGetOrgUsage = ApiOperation{
+		Id:          "getOrgUsage",
+		Method:      http.MethodGet,
+		Path:        "/orgs/usage",
+		Summary:     "Get the org's usage",
+		Description: "Returns the org's model usage records from since (an RFC 3339 time) onward. Users who can manage billing see every member's usage, and others see only their own.",
+		Query:       []string{"since"},
+		Response:    OrgUsageResponse{},
+	}
+
+	ApiOperationGetOrgSlackSettings = ApiOperation{
+		Id:          "getOrgSlackSettings",
+		Method:      http.MethodGet,
+		Path:        "/orgs/slack",
+		Summary:     "Get the org's Slack settings",
+		Description: "Says whether the org's finished and failed plans are posted to Slack, and whether prompts are included.",
+		Response:    OrgSlackSettings{},
+	}
+
+	ApiOperationUpdateOrgSlackSettings = ApiOperation{
+		Id:          "updateOrgSlackSettings",
+		Method:      http.MethodPut,
+		Path:        "/orgs/slack",
+		Summary:     "Update the org's Slack settings",
+		Description: "Sets the Slack incoming webhook the org's plans are posted to, or turns posting off with an empty url. Needs the manage_integrations permission.",
+		Request:     UpdateOrgSlackSettingsRequest{},
+	}
+
+	ApiOperationGetOrgBudget = ApiOperation{
+		Id:          "getOrgBudget",
+		Method:      http.MethodGet,
+		Path:        "/orgs/budget",
+		Summary:     "Get the org's daily budget",
+		Description: "Returns the most the org's plans can spend on model calls per day (UTC), if it's set.",
+		Response:    OrgBudget{},
+	}
+
+	ApiOperationUpdateOrgBudget = ApiOperation{
+		Id:          "updateOrgBudget",
+		Method:      http.MethodPut,
+		Path:        "/orgs/budget",
+		Summary:     "Update the org's daily budget",
+		Description: "Sets or removes the org's daily budget. Needs the manage_billing permission.",
+		Request:     UpdateOrgBudgetRequest{},
+	}
+
+	ApiOperationInviteUser = ApiOperation{
+		Id:          "inviteUser",
+		Method:      http.MethodPost,
+		Path:        "/invites",
+		Summary:     "Invite a user",
+		Description: "Emails an invite to join the org with the given role.",
+		Request:     InviteRequest{},
+	}
+
+	ApiOperationListPendingInvites = ApiOperation{
+		Id:          "listPendingInvites",
+		Method:      http.MethodGet,
+		Path:        "/invites/pending",
+		Summary:     "List pending invites",
+		Description: "Lists the org's invites that haven't been accepted.",
+		Response:    []*Invite{},
+	}
+
+	ApiOperationListAcceptedInvites = ApiOperation{
+		Id:          "listAcceptedInvites",
+		Method:      http.MethodGet,
+		Path:        "/invites/accepted",
+		Summary:     "List accepted invites",
+		Description: "Lists the org's invites that have been accepted.",
+		Response:    []*Invite{},
+	}
+
+	ApiOperationListAllInvites = ApiOperation{
+		Id:          "listAllInvites",
+		Method:      http.MethodGet,
+		Path:        "/invites/all",
+		Summary:     "List all invites",
+		Description: "Lists all of the org's invites.",
+		Response:    []*Invite{},
+	}
+
+	ApiOperationDeleteInvite = ApiOperation{
+		Id:          "deleteInvite",
+		Method:      http.MethodDelete,
+		Path:        "/invites/{inviteId}",
+		Summary:     "Revoke an invite",
+		Description: "Deletes a pending invite so it can no longer be accepted.",
+	}
+
+	ApiOperationCreateProject = ApiOperation{
+		Id:          "createProject",
+		Method:      http.MethodPost,
+		Path:        "/projects",
+		Summary:     "Create a project",
+		Description: "Creates a project in the org.",
+		Request:     CreateProjectRequest{},
+		Response:    CreateProjectResponse{},
+	}
+
+	ApiOperationListProjects = ApiOperation{
+		Id:          "listProjects",
+		Method:      http.MethodGet,
+		Path:        "/projects",
+		Summary:     "List projects",
+		Description: "Lists the org's projects.",
+		Response:    []*Project{},
+	}
+
+	ApiOperationSetProjectPlan = ApiOperation{
+		Id:          "setProjectPlan",
+		Method:      http.MethodPut,
+		Path:        "/projects/{projectId}/set_plan",
+		Summary:     "Set a project's current plan",
+		Description: "Sets the plan the user is working on in the project.",
+		Request:     SetProjectPlanRequest{},
+	}
+
+	ApiOperationRenameProject = ApiOperation{
+		Id:          "renameProject",
+		Method:      http.MethodPut,
+		Path:        "/projects/{projectId}/rename",
+		Summary:     "Rename a project",
+		Request:     RenameProjectRequest{},
+		Description: "Changes the project's name.",
+	}
+
+	ApiOperationGetCurrentBranchByPlanId = ApiOperation{
+		Id:          "getCurrentBranchByPlanId",
+		Method:      http.MethodPost,
+		Path:        "/projects/{projectId}/plans/current_branches",
+		Summary:     "Get plans' current branches",
+		Description: "Returns the current branch of each of the given plans, keyed by plan id.",
+		Request:     GetCurrentBranchByPlanIdRequest{},
+		Response:    map[string]*Branch{},
+	}
+
+	ApiOperationCheckModels = ApiOperation{
+		Id:          "checkModels",
+		Method:      http.MethodPost,
+		Path:        "/models/check",
+		Summary:     "Check models",
+		Description: "Checks that the api key is valid and each role's model is available with it.",
+		Request:     CheckModelsRequest{},
+		Response:    CheckModelsResponse{},
+	}
+
+	ApiOperationDoctor = ApiOperation{
+		Id:          "doctor",
+		Method:      http.MethodGet,
+		Path:        "/doctor",
+		Summary:     "Diagnose the server",
+		Description: "Checks the server's database and storage, and the given plan's state if planId and branch are set.",
+		Query:       []string{"planId", "branch"},
+		Response:    DoctorResponse{},
+	}
+
+	ApiOperationGenCommitMsg = ApiOperation{
+		Id:          "genCommitMsg",
+		Method:      http.MethodPost,
+		Path:        "/commit_msg",
+		Summary:     "Write a commit message",
+		Description: "Writes a commit message for a diff.",
+		Request:     GenCommitMsgRequest{},
+		Response:    GenCommitMsgResponse{},
+	}
+
+	ApiOperationGenPullRequest = ApiOperation{
+		Id:          "genPullRequest",
+		Method:      http.MethodPost,
+		Path:        "/pull_request_content",
+		Summary:     "Write a pull request",
+		Description: "Writes a pull request title and description for a diff, from the plan's applied changes and prompts.",
+		Request:     GenPullRequestRequest{},
+		Response:    GenPullRequestResponse{},
+	}
+
+	ApiOperationReview = ApiOperation{
+		Id:          "review",
+		Method:      http.MethodPost,
+		Path:        "/review",
+		Summary:     "Review changes",
+		Description: "Reviews a diff for bugs and other problems.",
+		Request:     ReviewRequest{},
+		Response:    ReviewResponse{},
+	}
+
+	ApiOperationSecurityScan = ApiOperation{
+		Id:          "securityScan",
+		Method:      http.MethodPost,
+		Path:        "/security_scan",
+		Summary:     "Scan changes for security risks",
+		Description: "Flags the risky operations a diff adds, like network calls, shell commands, and hard-coded credentials.",
+		Request:     SecurityScanRequest{},
+		Response:    SecurityScanResponse{},
+	}
+
+	ApiOperationListArchivedPlans = ApiOperation{
+		Id:          "listArchivedPlans",
+		Method:      http.MethodGet,
+		Path:        "/plans/archive",
+		Summary:     "List archived plans",
+		Description: "Lists the archived plans in the given projects.",
+		Query:       []string{"projectId"},
+		Response:    []*Plan{},
+	}
+
+	ApiOperationListPlansRunning = ApiOperation{
+		Id:          "listPlansRunning",
+		Method:      http.MethodGet,
+		Path:        "/plans/ps",
+		Summary:     "List active plan streams",
+		Description: "Lists the plans in the given projects with an active stream, and recently finished ones if recent is true.",
+		Query:       []string{"projectId", "recent"},
+		Response:    ListPlansRunningResponse{},
+	}
+
+	ApiOperationListSharedPlans = ApiOperation{
+		Id:          "listSharedPlans",
+		Method:      http.MethodGet,
+		Path:        "/plans/shared",
+		Summary:     "List shared plans",
+		Description: "Lists the plans teammates have shared with the org.",
+		Response:    []*Plan{},
+	}
+
+	ApiOperationCreatePlan = ApiOperation{
+		Id:          "createPlan",
+		Method:      http.MethodPost,
+		Path:        "/projects/{projectId}/plans",
+		Summary:     "Create a plan",
+		Description: "Creates a plan in the project, named automatically if no name is given.",
+		Request:     CreatePlanRequest{},
+		Response:    CreatePlanResponse{},
+	}
+
+	ApiOperationDeleteAllPlans = ApiOperation{
+		Id:          "deleteAllPlans",
+		Method:      http.MethodDelete,
+		Path:        "/projects/{projectId}/plans",
+		Summary:     "Delete all plans",
+		Description: "Deletes all of the user's plans in the project.",
+	}
+
+	ApiOperationImportPlanBundle = ApiOperation{
+		Id:          "importPlanBundle",
+		Method:      http.MethodPost,
+		Path:        "/projects/{projectId}/plans/import",
+		Summary:     "Import a plan",
+		Description: "Creates a plan in the project from a bundle made by exportPlanBundle.",
+		Request:     ImportPlanRequest{},
+		Response:    CreatePlanResponse{},
+	}
+
+	ApiOperationGetPlan = ApiOperation{
+		Id:          "getPlan",
+		Method:      http.MethodGet,
+		Path:        "/plans/{planId}",
+		Summary:     "Get a plan",
+		Description: "Returns the plan's details.",
+		Response:    Plan{},
+	}
+
+	ApiOperationExportPlanBundle = ApiOperation{
+		Id:          "exportPlanBundle",
+		Method:      http.MethodGet,
+		Path:        "/plans/{planId}/bundle",
+		Summary:     "Export a plan",
+		Description: "Returns a bundle of the plan's history, context, and settings that importPlanBundle can recreate it from.",
+		Response:    PlanBundle{},
+	}
+
+	ApiOperationGetPlanUsage = ApiOperation{
+		Id:          "getPlanUsage",
+		Method:      http.MethodGet,
+		Path:        "/plans/{planId}/usage",
+		Summary:     "Get a plan's usage",
+		Description: "Returns the plan's model usage records from since (an RFC 3339 time) onward, on all branches.",
+		Query:       []string{"since"},
+		Response:    PlanUsageResponse{},
+	}
+
+	ApiOperationListPlanPullRequests = ApiOperation{
+		Id:          "listPlanPullRequests",
+		Method:      http.MethodGet,
+		Path:        "/plans/{planId}/pull_requests",
+		Summary:     "List a plan's pull requests",
+		Description: "Returns the pull requests opened from the plan, oldest first.",
+		Response:    []*PlanPullRequest{},
+	}
+
+	ApiOperationCreatePlanPullRequest = ApiOperation{
+		Id:          "createPlanPullRequest",
+		Method:      http.MethodPost,
+		Path:        "/plans/{planId}/pull_requests",
+		Summary:     "Record a pull request",
+		Description: "Records a pull request opened from the plan.",
+		Request:     CreatePlanPullRequestRequest{},
+		Response:    PlanPullRequest{},
+	}
+
+	ApiOperationDeletePlan = ApiOperation{
+		Id:          "deletePlan",
+		Method:      http.MethodDelete,
+		Path:        "/plans/{planId}",
+		Summary:     "Delete a plan",
+		Description: "Deletes the plan and all its branches.",
+	}
+
+	ApiOperationSharePlan = ApiOperation{
+		Id:          "sharePlan",
+		Method:      http.MethodPatch,
+		Path:        "/plans/{planId}/share",
+		Summary:     "Share a plan",
+		Description: "Lets the org's other members see and work on the plan.",
+	}
+
+	ApiOperationUnsharePlan = ApiOperation{
+		Id:          "unsharePlan",
+		Method:      http.MethodPatch,
+		Path:        "/plans/{planId}/unshare",
+		Summary:     "Stop sharing a plan",
+		Description: "Makes the plan visible only to its owner again.",
+	}
+
+	ApiOperationArchivePlan = ApiOperation{
+		Id:          "archivePlan",
+		Method:      http.MethodPatch,
+		Path:        "/plans/{planId}/archive",
+		Summary:     "Archive a plan",
+		Description: "Moves the plan out of the plan list and into the archive.",
+	}
+
+	ApiOperationRespondMissingFile = ApiOperation{
+		Id:          "respondMissingFile",
+		Method:      http.MethodPost,
+		Path:        "/plans/{planId}/{branch}/respond_missing_file",
+		Summary:     "Answer a missing file prompt",
+		Description: "Tells a stream waiting on a file the plan needs whether to load it, skip it, or overwrite it.",
+		Request:     RespondMissingFileRequest{},
+	}
+
+	ApiOperationRespondClarify = ApiOperation{
+		Id:          "respondClarify",
+		Method:      http.MethodPost,
+		Path:        "/plans/{planId}/{branch}/respond_clarify",
+		Summary:     "Answer clarifying questions",
+		Description: "Sends the answers to a stream waiting on the planner's clarifying questions.",
+		Request:     RespondClarifyRequest{},
+	}
+
+	ApiOperationBuildPlan = ApiOperation{
+		Id:          "buildPlan",
+		Method:      http.MethodPatch,
+		Path:        "/plans/{planId}/{branch}/build",
+		Summary:     "Build a plan's pending changes",
+		Description: "Builds the plan's proposed changes that haven't been built yet into pending file updates, streaming progress as it goes.",
+		Request:     BuildPlanRequest{},
+		Response:    StreamMessage{},
+		Streaming:   true,
+	}
+
+	ApiOperationConnectPlan = ApiOperation{
+		Id:          "connectPlan",
+		Method:      http.MethodPatch,
+		Path:        "/plans/{planId}/{branch}/connect",
+		Summary:     "Connect to a plan's active stream",
+		Description: "Streams the rest of an active reply or build, starting with what's been sent so far.",
+		Response:    StreamMessage{},
+		Streaming:   true,
+	}
+
+	ApiOperationRejectAllChanges = ApiOperation{
+		Id:          "rejectAllChanges",
+		Method:      http.MethodPatch,
+		Path:        "/plans/{planId}/{branch}/reject_all",
+		Summary:     "Reject all pending changes",
+		Description: "Discards all of the plan's pending changes.",
+	}
+
+	ApiOperationRejectFile = ApiOperation{
+		Id:          "rejectFile",
+		Method:      http.MethodPatch,
+		Path:        "/plans/{planId}/{branch}/reject_file",
+		Summary:     "Reject a file's pending changes",
+		Description: "Discards the plan's pending changes to one file.",
+		Request:     RejectFileRequest{},
+	}
+
+	ApiOperationRecordCommandResult = ApiOperation{
+		Id:          "recordCommandResult",
+		Method:      http.MethodPost,
+		Path:        "/plans/{planId}/{branch}/commands/result",
+		Summary:     "Record a command's result",
+		Description: "Adds the output of a command the user ran to the plan's conversation.",
+		Request:     RecordCommandResultRequest{},
+	}
+
+	ApiOperationListContext = ApiOperation{
+		Id:          "listContext",
+		Method:      http.MethodGet,
+		Path:        "/plans/{planId}/{branch}/context",
+		Summary:     "List a plan's context",
+		Description: "Lists the files, urls, notes, and other context loaded into the plan.",
+		Response:    []*Context{},
+	}
+
+	ApiOperationLoadContext = ApiOperation{
+		Id:          "loadContext",
+		Method:      http.MethodPost,
+		Path:        "/plans/{planId}/{branch}/context",
+		Summary:     "Load context",
+		Description: "Adds context to the plan.",
+		Request:     LoadContextRequest{},
+		Response:    LoadContextResponse{},
+	}
+
+	ApiOperationUpdateContext = ApiOperation{
+		Id:          "updateContext",
+		Method:      http.MethodPut,
+		Path:        "/plans/{planId}/{branch}/context",
+		Summary:     "Update context",
+		Description: "Replaces the bodies of context that has changed since it was loaded.",
+		Request:     UpdateContextRequest{},
+		Response:    UpdateContextResponse{},
+	}
+
+	ApiOperationDeleteContext = ApiOperation{
+		Id:          "deleteContext",
+		Method:      http.MethodDelete,
+		Path:        "/plans/{planId}/{branch}/context",
+		Summary:     "Remove context",
+		Description: "Removes context from the plan.",
+		Request:     DeleteContextRequest{},
+		Response:    DeleteContextResponse{},
+	}
+
+	ApiOperationListConvo = ApiOperation{
+		Id:          "listConvo",
+		Method:      http.MethodGet,
+		Path:        "/plans/{planId}/{branch}/convo",
+		Summary:     "List a plan's conversation",
+		Description: "Returns all of the prompts and replies on the plan's branch, oldest first. Use listConvoMessages to search or page through them.",
+		Response:    []*ConvoMessage{},
+	}
+
+	ApiOperationRewindPlan = ApiOperation{
+		Id:          "rewindPlan",
+		Method:      http.MethodPatch,
+		Path:        "/plans/{planId}/{branch}/rewind",
+		Summary:     "Rewind a plan",
+		Description: "Resets the plan's branch to an earlier point in its history.",
+		Request:     RewindPlanRequest{},
+		Response:    RewindPlanResponse{},
+	}
+
+	ApiOperationListLogs = ApiOperation{
+		Id:          "listLogs",
+		Method:      http.MethodGet,
+		Path:        "/plans/{planId}/{branch}/logs",
+		Summary:     "List a plan's history",
+		Description: "Returns the log of changes to the plan's branch.",
+		Response:    LogResponse{},
+	}
+
+	ApiOperationListBranches = ApiOperation{
+		Id:          "listBranches",
+		Method:      http.MethodGet,
+		Path:        "/plans/{planId}/branches",
+		Summary:     "List a plan's branches",
+		Response:    []*Branch{},
+		Description: "Lists the plan's branches.",
+	}
+
+	ApiOperationDeleteBranch = ApiOperation{
+		Id:          "deleteBranch",
+		Method:      http.MethodDelete,
+		Path:        "/plans/{planId}/branches/{branch}",
+		Summary:     "Delete a branch",
+		Description: "Deletes one of the plan's branches. The main branch can't be deleted.",
+	}
+
+	ApiOperationCreateBranch = ApiOperation{
+		Id:          "createBranch",
+		Method:      http.MethodPost,
+		Path:        "/plans/{planId}/{branch}/branches",
+		Summary:     "Create a branch",
+		Description: "Creates a branch of the plan from the current state of branch.",
+		Request:     CreateBranchRequest{},
+	}
+
+	ApiOperationGetSettings = ApiOperation{
+		Id:          "getSettings",
+		Method:      http.MethodGet,
+		Path:        "/plans/{planId}/{branch}/settings",
+		Summary:     "Get a plan's settings",
+		Description: "Returns the branch's model settings and policy.",
+		Response:    PlanSettings{},
+	}
+
+	ApiOperationUpdateSettings = ApiOperation{
+		Id:          "updateSettings",
+		Method:      http.MethodPut,
+		Path:        "/plans/{planId}/{branch}/settings",
+		Summary:     "Update a plan's settings",
+		Description: "Replaces the branch's model settings and policy. The change is versioned like the plan's other changes.",
+		Request:     UpdateSettingsRequest{},
+		Response:    UpdateSettingsResponse{},
+	}
+)
+
+var ApiOperations = []ApiOperation{
+	ApiOperationProposePlan,
+	ApiOperationConfirmPlan,
+	ApiOperationAbortPlan,
+	ApiOperationGetPlanStatus,
+	ApiOperationListPlans,
+	ApiOperationListConvoMessages,
+	ApiOperationListPlanEvents,
+	ApiOperationStartTrial,
+	ApiOperationCreateEmailVerification,
+	ApiOperationSignIn,
+	ApiOperationSignOut,
+	ApiOperationCreateAccount,
+	ApiOperationConvertTrial,
+	ApiOperationGetOrgSession,
+	ApiOperationListOrgs,
+	ApiOperationCreateOrg,
+	ApiOperationListUsers,
+	ApiOperationDeleteOrgUser,
+	ApiOperationListOrgRoles,
+	ApiOperationGetOrgUsage,
+	ApiOperationGetOrgSlackSettings,
+	ApiOperationUpdateOrgSlackSettings,
+	ApiOperationGetOrgBudget,
+	ApiOperationUpdateOrgBudget,
+	ApiOperationInviteUser,
+	ApiOperationListPendingInvites,
+	ApiOperationListAcceptedInvites,
+	ApiOperationListAllInvites,
+	ApiOperationDeleteInvite,
+	ApiOperationCreateProject,
+	ApiOperationListProjects,
+	ApiOperationSetProjectPlan,
+	ApiOperationRenameProject,
+	ApiOperationGetCurrentBranchByPlanId,
+	ApiOperationCheckModels,
+	ApiOperationDoctor,
+	ApiOperationGenCommitMsg,
+	ApiOperationGenPullRequest,
+	ApiOperationReview,
+	ApiOperationSecurityScan,
+	ApiOperationListArchivedPlans,
+	ApiOperationListPlansRunning,
+	ApiOperationListSharedPlans,
+	ApiOperationCreatePlan,
+	ApiOperationDeleteAllPlans,
+	ApiOperationImportPlanBundle,
+	ApiOperationGetPlan,
+	ApiOperationExportPlanBundle,
+	ApiOperationGetPlanUsage,
+	ApiOperationListPlanPullRequests,
+	ApiOperationCreatePlanPullRequest,
+	ApiOperationDeletePlan,
+	ApiOperationSharePlan,
+	ApiOperationUnsharePlan,
+	ApiOperationArchivePlan,
+	ApiOperationRespondMissingFile,
+	ApiOperationRespondClarify,
+	ApiOperationBuildPlan,
+	ApiOperationConnectPlan,
+	ApiOperationRejectAllChanges,
+	ApiOperationRejectFile,
+	ApiOperationRecordCommandResult,
+	ApiOperationListContext,
+	ApiOperationLoadContext,
+	ApiOperationUpdateContext,
+	ApiOperationDeleteContext,
+	ApiOperationListConvo,
+	ApiOperationRewindPlan,
+	ApiOperationListLogs,
+	ApiOperationListBranches,
+	ApiOperationDeleteBranch,
+	ApiOperationCreateBranch,
+	ApiOperationGetSettings,
+	ApiOperationUpdateSettings,
+}
+
+// PathParams returns the names of the operation's path parameters, in order
+func (op ApiOperation) PathParams() []string {
+	var params []string
+	for _, part := range strings.Split(op.Path, "/") {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			params = append(params, strings.Trim(part, "{}"))
+		}
+	}
+	return params
+}
+
+// ResolvePath fills in the operation's path parameters with values, in order
+func (op ApiOperation) ResolvePath(values ...string) string {
+	parts := strings.Split(op.Path, "/")
+	i := 0
+	for j, part := range parts {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") && i < len(values) {
+			parts[j] = values[i]
+			i++
+		}
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,62 @@
+package shared
+
+import "testing"
+
+func TestApiOperationPathParams(t *testing.T) {
+	params := ApiOperationDeleteBranch.PathParams()
+	if len(params) != 2 || params[0] != "planId" || params[1] != "branch" {
+		t.Fatalf("expected [planId branch], got %v", params)
+	}
+
+	if params := ApiOperationListPlans.PathParams(); len(params) != 0 {
+		t.Errorf("expected no path params for %s, got %v", ApiOperationListPlans.Path, params)
+	}
+}
+
+func TestApiOperationResolvePath(t *testing.T) {
+	tests := []struct {
+		op     ApiOperation
+		values []string
+		want   string
+	}{
+		{ApiOperationProposePlan, []string{"p1", "main"}, "/plans/p1/main/tell"},
+		{ApiOperationDeleteBranch, []string{"p1", "feature"}, "/plans/p1/branches/feature"},
+		{ApiOperationArchivePlan, []string{"p1"}, "/plans/p1/archive"},
+		{ApiOperationListProjects, nil, "/projects"},
+
+		// missing values leave the rest of the template in place
+		{ApiOperationProposePlan, []string{"p1"}, "/plans/p1/{branch}/tell"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.op.ResolvePath(tt.values...); got != tt.want {
+			t.Errorf("%s.ResolvePath(%v) = %q, want %q", tt.op.Id, tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestApiOperationsAreUnique(t *testing.T) {
+	ids := map[string]bool{}
+	routes := map[string]bool{}
+
+	for _, op := range ApiOperations {
+		if op.Id == "" || op.Method == "" || op.Path == "" {
+			t.Errorf("operation %+v is missing its id, method, or path", op)
+		}
+
+		if ids[op.Id] {
+			t.Errorf("duplicate operation id %s", op.Id)
+		}
+		ids[op.Id] = true
+
+		route := op.Method + " " + op.Path
+		if routes[route] {
+			t.Errorf("duplicate route %s", route)
+		}
+		routes[route] = true
+
+		if op.Streaming && op.Response == nil {
+			t.Errorf("streaming operation %s has no message type", op.Id)
+		}
+	}
+}
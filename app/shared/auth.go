@@ -15,6 +15,14 @@ const (
 
 	ApiErrorTypeContinueNoMessages ApiErrorType = "continue_no_messages"
 
+	ApiErrorTypePathPolicy    ApiErrorType = "path_policy"
+	ApiErrorTypePlanFileLimit ApiErrorType = "plan_file_limit"
+
+	ApiErrorTypeBudgetExceeded ApiErrorType = "budget_exceeded"
+	ApiErrorTypeQuotaExceeded  ApiErrorType = "quota_exceeded"
+
+	ApiErrorTypeAutoLimitExceeded ApiErrorType = "auto_limit_exceeded"
+
 	ApiErrorTypeOther ApiErrorType = "other"
 )
 
@@ -36,4 +44,13 @@ type ApiError struct {
 
 	// only used for trial messages exceeded error
 	TrialMessagesExceededError *TrialMessagesExceededError `json:"trialMessagesExceededError,omitempty"`
+
+	// only used for budget exceeded error
+	BudgetExceededError *BudgetExceededError `json:"budgetExceededError,omitempty"`
+
+	// only used for quota exceeded error
+	QuotaExceededError *QuotaExceededError `json:"quotaExceededError,omitempty"`
+
+	// only used for auto limit exceeded error
+	AutoLimitExceededError *AutoLimitExceededError `json:"autoLimitExceededError,omitempty"`
 }
@@ -0,0 +1,126 @@
+package shared
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// AutoLimits are stop conditions for automated runs like 'plandex tell --auto-continue', which reply, apply, and continue without the user. Zero means no limit.
+type AutoLimits struct {
+	// replies in the run, counting replies that fix verification failures
+	MaxSteps  int     `json:"maxSteps,omitempty"`
+	MaxTokens int     `json:"maxTokens,omitempty"`
+	MaxCost   float64 `json:"maxCost,omitempty"`
+	// distinct files the run's replies change
+	MaxFiles int `json:"maxFiles,omitempty"`
+
+	// globs relative to the project root. Changes to a matching file, or to anything under a matching directory, aren't applied without confirmation.
+	ProtectedPaths []string `json:"protectedPaths,omitempty"`
+}
+
+// AutoRun is sent with each request an automated run makes, so the server can check the run's limits against everything done since it started
+type AutoRun struct {
+	Limits    AutoLimits `json:"limits"`
+	StartedAt time.Time  `json:"startedAt"`
+}
+
+// AutoRunProgress is what an automated run has done so far
+type AutoRunProgress struct {
+	Steps int
+	Files []string
+	Usage UsageTotal
+}
+
+type AutoLimitExceededError struct {
+	Reason string `json:"reason"`
+}
+
+func (e *AutoLimitExceededError) Error() string {
+	return "automated run stopped: " + e.Reason
+}
+
+// GetAutoRunProgress counts the replies made since startedAt and the files they change. Usage is added by the caller, since the client and server track it differently.
+func GetAutoRunProgress(descs []*ConvoMessageDescription, startedAt time.Time) AutoRunProgress {
+	var progress AutoRunProgress
+	seen := map[string]bool{}
+
+	for _, desc := range descs {
+		if desc.CreatedAt.Before(startedAt) {
+			continue
+		}
+		progress.Steps++
+
+		for _, paths := range [][]string{desc.Files, desc.RemovedFiles} {
+			for _, p := range paths {
+				if !seen[p] {
+					seen[p] = true
+					progress.Files = append(progress.Files, p)
+				}
+			}
+		}
+	}
+
+	return progress
+}
+
+// Exceeded returns an error for the first limit the run has reached, or nil if it can keep going
+func (l AutoLimits) Exceeded(progress AutoRunProgress) *AutoLimitExceededError {
+	if l.MaxSteps > 0 && progress.Steps >= l.MaxSteps {
+		return &AutoLimitExceededError{Reason: fmt.Sprintf("reached the limit of %d replies", l.MaxSteps)}
+	}
+
+	tokens := progress.Usage.PromptTokens + progress.Usage.CompletionTokens
+	if l.MaxTokens > 0 && tokens >= l.MaxTokens {
+		return &AutoLimitExceededError{Reason: fmt.Sprintf("used %d tokens, reaching the limit of %d", tokens, l.MaxTokens)}
+	}
+
+	if l.MaxCost > 0 && progress.Usage.Cost >= l.MaxCost {
+		return &AutoLimitExceededError{Reason: fmt.Sprintf("spent an estimated $%.4f, reaching the limit of $%.2f", progress.Usage.Cost, l.MaxCost)}
+	}
+
+	if l.MaxFiles > 0 && len(progress.Files) > l.MaxFiles {
+		return &AutoLimitExceededError{Reason: fmt.Sprintf("changed %d files, more than the limit of %d", len(progress.Files), l.MaxFiles)}
+	}
+
+	return nil
+}
+
+// GetProtectedPaths returns the paths that match one of the limits' protected paths
+func (l AutoLimits) GetProtectedPaths(paths []string) []string {
+	var res []string
+	for _, p := range paths {
+		if l.IsProtected(p) {
+			res = append(res, p)
+		}
+	}
+	return res
+}
+
+// IsProtected checks a project-relative path against the protected globs. A glob that matches one of the path's parent directories protects everything under it.
+func (l AutoLimits) IsProtected(p string) bool {
+	normalized := NormalizePlanPath(p)
+
+	for _, pattern := range l.ProtectedPaths {
+		pattern = strings.TrimSuffix(NormalizePlanPath(pattern), "/")
+		if pattern == "" {
+			continue
+		}
+
+		// like .gitignore, a glob without a slash matches a file or directory name at any depth
+		anyDepth := !strings.Contains(pattern, "/")
+
+		for current := normalized; current != "." && current != "/"; current = path.Dir(current) {
+			name := current
+			if anyDepth {
+				name = path.Base(current)
+			}
+			if matched, _ := path.Match(pattern, name); matched {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,94 @@
+package shared
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAutoLimitsExceeded(t *testing.T) {
+	limits := AutoLimits{MaxSteps: 3, MaxTokens: 1000, MaxCost: 0.5, MaxFiles: 2}
+
+	tests := []struct {
+		name     string
+		limits   AutoLimits
+		progress AutoRunProgress
+		exceeded bool
+	}{
+		{"nothing done", limits, AutoRunProgress{}, false},
+		{"under every limit", limits, AutoRunProgress{Steps: 2, Files: []string{"a"}, Usage: UsageTotal{PromptTokens: 400, CompletionTokens: 500, Cost: 0.4}}, false},
+		{"steps reached", limits, AutoRunProgress{Steps: 3}, true},
+		{"tokens reached", limits, AutoRunProgress{Usage: UsageTotal{PromptTokens: 600, CompletionTokens: 400}}, true},
+		{"cost reached", limits, AutoRunProgress{Usage: UsageTotal{Cost: 0.5}}, true},
+		// files are a limit on what's changed, so reaching it is fine and only going over stops the run
+		{"files at the limit", limits, AutoRunProgress{Files: []string{"a", "b"}}, false},
+		{"files over the limit", limits, AutoRunProgress{Files: []string{"a", "b", "c"}}, true},
+		{"no limits", AutoLimits{}, AutoRunProgress{Steps: 100, Files: []string{"a", "b", "c"}, Usage: UsageTotal{PromptTokens: 1e6, Cost: 100}}, false},
+	}
+
+	for _, tt := range tests {
+		err := tt.limits.Exceeded(tt.progress)
+		if (err != nil) != tt.exceeded {
+			t.Errorf("%s: Exceeded() = %v, want exceeded %v", tt.name, err, tt.exceeded)
+		}
+		if err != nil && err.Reason == "" {
+			t.Errorf("%s: expected a reason", tt.name)
+		}
+	}
+}
+
+func TestAutoLimitsIsProtected(t *testing.T) {
+	limits := AutoLimits{ProtectedPaths: []string{".github", "migrations/", "*.lock", "config/prod.*", "./secrets/"}}
+
+	tests := []struct {
+		path      string
+		protected bool
+	}{
+		{".github/workflows/ci.yml", true},
+		{".github", true},
+		{"migrations/001_init.sql", true},
+		// globs without a slash match names at any depth
+		{"db/migrations/001_init.sql", true},
+		{"yarn.lock", true},
+		{"web/package.lock", true},
+		{"config/prod.yml", true},
+		// globs with a slash are relative to the project root
+		{"app/config/prod.yml", false},
+		{"config/dev.yml", false},
+		{"secrets/key.pem", true},
+		{"./secrets/key.pem", true},
+		{"main.go", false},
+		{"github/readme.md", false},
+		{"migrations_old/a.sql", false},
+	}
+
+	for _, tt := range tests {
+		if got := limits.IsProtected(tt.path); got != tt.protected {
+			t.Errorf("IsProtected(%q) = %v, want %v", tt.path, got, tt.protected)
+		}
+	}
+
+	if got := limits.GetProtectedPaths([]string{"main.go", "yarn.lock", ".github/ci.yml"}); len(got) != 2 || got[0] != "yarn.lock" || got[1] != ".github/ci.yml" {
+		t.Errorf("GetProtectedPaths() = %v", got)
+	}
+
+	if (AutoLimits{}).IsProtected(".github/ci.yml") {
+		t.Error("nothing should be protected without protected paths")
+	}
+}
+
+func TestGetAutoRunProgress(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	descs := []*ConvoMessageDescription{
+		{CreatedAt: start.Add(-time.Minute), Files: []string{"old.go"}},
+		{CreatedAt: start, Files: []string{"a.go", "b.go"}},
+		{CreatedAt: start.Add(time.Minute), Files: []string{"b.go"}, RemovedFiles: []string{"c.go"}},
+	}
+
+	progress := GetAutoRunProgress(descs, start)
+	if progress.Steps != 2 {
+		t.Errorf("Steps = %d, want 2", progress.Steps)
+	}
+	if len(progress.Files) != 3 {
+		t.Errorf("Files = %v, want a.go, b.go, and c.go", progress.Files)
+	}
+}
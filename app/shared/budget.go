@@ -0,0 +1,95 @@
+package shared
+
+import (
+	"fmt"
+	"time"
+)
+
+type BudgetKind string
+
+const (
+	BudgetKindPlan  BudgetKind = "plan"
+	BudgetKindDaily BudgetKind = "daily"
+)
+
+// fractions of a budget that the CLI warns about once spending reaches them
+var BudgetWarnThresholds = []float64{0.5, 0.8, 0.9}
+
+type BudgetExceededError struct {
+	Kind  BudgetKind `json:"kind"`
+	Limit float64    `json:"limit"`
+	Spent float64    `json:"spent"`
+}
+
+func (e *BudgetExceededError) Error() string {
+	if e.Kind == BudgetKindDaily {
+		return fmt.Sprintf("your org has spent an estimated $%.4f today (UTC), reaching its daily budget of $%.2f. Raise it with 'plandex set-policy max-daily-cost'", e.Spent, e.Limit)
+	}
+	return fmt.Sprintf("this plan has spent an estimated $%.4f, reaching its budget of $%.2f. Raise it with 'plandex set-policy max-plan-cost'", e.Spent, e.Limit)
+}
+
+// BudgetStatus is a plan's estimated spending against its own budget, and its org's spending today (UTC) against the org's daily budget. Spending only counts models with pricing, so usage of unpriced models doesn't count toward either budget.
+type BudgetStatus struct {
+	MaxPlanCost  *float64 `json:"maxPlanCost,omitempty"`
+	MaxDailyCost *float64 `json:"maxDailyCost,omitempty"`
+	PlanSpent    float64  `json:"planSpent"`
+	DailySpent   float64  `json:"dailySpent"`
+}
+
+// OrgBudget is the org's daily spending limit, shared by all its plans
+type OrgBudget struct {
+	MaxDailyCost *float64 `json:"maxDailyCost,omitempty"`
+}
+
+// Exceeded returns an error for the first budget that spending has reached, or nil if there's room left in both
+func (s BudgetStatus) Exceeded() *BudgetExceededError {
+	if s.MaxPlanCost != nil && s.PlanSpent >= *s.MaxPlanCost {
+		return &BudgetExceededError{Kind: BudgetKindPlan, Limit: *s.MaxPlanCost, Spent: s.PlanSpent}
+	}
+	if s.MaxDailyCost != nil && s.DailySpent >= *s.MaxDailyCost {
+		return &BudgetExceededError{Kind: BudgetKindDaily, Limit: *s.MaxDailyCost, Spent: s.DailySpent}
+	}
+	return nil
+}
+
+// Warnings describes each budget that spending has reached a warning threshold of
+func (s BudgetStatus) Warnings() []string {
+	var warnings []string
+
+	warn := func(label string, limit *float64, spent float64) {
+		if limit == nil || *limit <= 0 {
+			return
+		}
+		frac := spent / *limit
+		var reached float64
+		for _, threshold := range BudgetWarnThresholds {
+			if frac >= threshold {
+				reached = threshold
+			}
+		}
+		if reached == 0 {
+			return
+		}
+		if frac >= 1 {
+			warnings = append(warnings, fmt.Sprintf("%s of $%.2f reached (~$%.4f spent). New model calls will be refused", label, *limit, spent))
+		} else {
+			warnings = append(warnings, fmt.Sprintf("over %d%% of %s of $%.2f used (~$%.4f spent)", int(reached*100), label, *limit, spent))
+		}
+	}
+
+	warn("plan budget", s.MaxPlanCost, s.PlanSpent)
+	warn("org daily budget", s.MaxDailyCost, s.DailySpent)
+
+	return warnings
+}
+
+// QuotaExceededError is returned when an org has used up the monthly token quota set on the server
+type QuotaExceededError struct {
+	Limit    int       `json:"limit"`
+	Used     int       `json:"used"`
+	ResetsAt time.Time `json:"resetsAt"`
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("your org has used %d tokens this month, reaching the server's monthly quota of %d. It resets on %s", e.Used, e.Limit, e.ResetsAt.Local().Format("Jan 2"))
+}
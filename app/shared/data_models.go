@@ -1,6 +1,7 @@
 package shared
 
 import (
+	"os"
 	"time"
 
 	"github.com/sashabaranov/go-openai"
@@ -92,19 +93,53 @@ type Context struct {
 	NumTokens       int         `json:"numTokens"`
 	Body            string      `json:"body,omitempty"`
 	ForceSkipIgnore bool        `json:"forceSkipIgnore"`
+	FileMode        os.FileMode `json:"fileMode,omitempty"`
+	Encoding        string      `json:"encoding,omitempty"`
+	SymlinkTarget   string      `json:"symlinkTarget,omitempty"`
 	CreatedAt       time.Time   `json:"createdAt"`
 	UpdatedAt       time.Time   `json:"updatedAt"`
 }
 
+type ModelUsage struct {
+	PromptTokens     int `json:"promptTokens"`
+	CompletionTokens int `json:"completionTokens"`
+
+	// false if the server had to count tokens itself for any part of the reply
+	ProviderReported bool `json:"providerReported"`
+
+	// what the tokens were spent on and the model that spent them, so cost can be estimated
+	Phase     UsagePhase `json:"phase,omitempty"`
+	ModelName string     `json:"modelName,omitempty"`
+}
+
+type UsagePhase string
+
+const (
+	UsagePhaseReply       UsagePhase = "reply"
+	UsagePhaseDescription UsagePhase = "description"
+	UsagePhaseBuild       UsagePhase = "build"
+	UsagePhaseSelfReview  UsagePhase = "self-review"
+	UsagePhaseName        UsagePhase = "name"
+	UsagePhaseSummary     UsagePhase = "summary"
+	UsagePhaseExecStatus  UsagePhase = "exec-status"
+	UsagePhaseCommitMsg   UsagePhase = "commit-msg"
+	UsagePhaseReview      UsagePhase = "review"
+	UsagePhaseSecurity    UsagePhase = "security-scan"
+	UsagePhasePullRequest UsagePhase = "pull-request"
+)
+
+var UsagePhases = []UsagePhase{UsagePhaseName, UsagePhaseReply, UsagePhaseSelfReview, UsagePhaseSummary, UsagePhaseExecStatus, UsagePhaseDescription, UsagePhaseBuild, UsagePhaseCommitMsg, UsagePhasePullRequest, UsagePhaseReview, UsagePhaseSecurity}
+
 type ConvoMessage struct {
-	Id        string    `json:"id"`
-	UserId    string    `json:"userId"`
-	Role      string    `json:"role"`
-	Tokens    int       `json:"tokens"`
-	Num       int       `json:"num"`
-	Message   string    `json:"message"`
-	Stopped   bool      `json:"stopped"`
-	CreatedAt time.Time `json:"createdAt"`
+	Id        string      `json:"id"`
+	UserId    string      `json:"userId"`
+	Role      string      `json:"role"`
+	Tokens    int         `json:"tokens"`
+	Num       int         `json:"num"`
+	Message   string      `json:"message"`
+	Stopped   bool        `json:"stopped"`
+	Usage     *ModelUsage `json:"usage,omitempty"`
+	CreatedAt time.Time   `json:"createdAt"`
 }
 
 type ConvoSummary struct {
@@ -118,18 +153,47 @@ type ConvoSummary struct {
 }
 
 type ConvoMessageDescription struct {
-	Id                    string          `json:"id"`
-	ConvoMessageId        string          `json:"convoMessageId"`
-	SummarizedToMessageId string          `json:"summarizedToMessageId"`
-	MadePlan              bool            `json:"madePlan"`
-	CommitMsg             string          `json:"commitMsg"`
-	Files                 []string        `json:"files"`
+	Id                    string       `json:"id"`
+	ConvoMessageId        string       `json:"convoMessageId"`
+	SummarizedToMessageId string       `json:"summarizedToMessageId"`
+	MadePlan              bool         `json:"madePlan"`
+	CommitMsg             string       `json:"commitMsg"`
+	Files                 []string     `json:"files"`
+	RemovedFiles          []string     `json:"removedFiles,omitempty"`
+	MovedFiles            []*MovedFile `json:"movedFiles,omitempty"`
+
+	// sha of each touched file's context when the changes were proposed, empty for files that didn't exist yet
+	BaseShasByPath map[string]string `json:"baseShasByPath,omitempty"`
+	// shell commands the reply asked the user to run, in order
+	Commands              []*PlanCommand  `json:"commands,omitempty"`
 	DidBuild              bool            `json:"didBuild"`
 	BuildPathsInvalidated map[string]bool `json:"buildPathsInvalidated"`
-	Error                 string          `json:"error"`
-	AppliedAt             *time.Time      `json:"appliedAt,omitempty"`
-	CreatedAt             time.Time       `json:"createdAt"`
-	UpdatedAt             time.Time       `json:"updatedAt"`
+	// steps the reply broke the task into, in order
+	Subtasks []string `json:"subtasks,omitempty"`
+	// numbers of the plan's subtasks, counting from 1, that the reply's changes complete
+	CompletedSubtasks []int `json:"completedSubtasks,omitempty"`
+	// the reply left nothing more to do without the user, either because the task is complete or because it's waiting on an answer
+	PlanFinished bool       `json:"planFinished,omitempty"`
+	Error        string     `json:"error"`
+	AppliedAt    *time.Time `json:"appliedAt,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+}
+
+// Subtask is a step of a task that's too large for a single reply, listed by the plan when it breaks the task up
+type Subtask struct {
+	Title string `json:"title"`
+	Done  bool   `json:"done"`
+}
+
+// PlanCommand is a shell command a reply suggests running, like installing a dependency or running a migration
+type PlanCommand struct {
+	Command string `json:"command"`
+	Reason  string `json:"reason,omitempty"`
+
+	// set once the command has been run with 'plandex commands'
+	RanAt    *time.Time `json:"ranAt,omitempty"`
+	ExitCode int        `json:"exitCode,omitempty"`
 }
 
 type PlanBuild struct {
@@ -156,6 +220,8 @@ type PlanFileResult struct {
 	PlanBuildId    string         `json:"planBuildId"`
 	Path           string         `json:"path"`
 	Content        string         `json:"content"`
+	RemovedFile    bool           `json:"removedFile,omitempty"`
+	MovedFrom      string         `json:"movedFrom,omitempty"`
 	AnyFailed      bool           `json:"anyFailed"`
 	AppliedAt      *time.Time     `json:"appliedAt,omitempty"`
 	RejectedAt     *time.Time     `json:"rejectedAt,omitempty"`
@@ -164,8 +230,27 @@ type PlanFileResult struct {
 	UpdatedAt      time.Time      `json:"updatedAt"`
 }
 
+// a pull request opened from the plan with 'plandex pr'. It's stored on the server so teammates and other machines can find it.
+type PlanPullRequest struct {
+	Id        string    `json:"id"`
+	PlanId    string    `json:"planId"`
+	UserId    string    `json:"userId"`
+	Url       string    `json:"url"`
+	Number    int       `json:"number"`
+	GitBranch string    `json:"gitBranch"`
+	Base      string    `json:"base"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type MovedFile struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
 type CurrentPlanFiles struct {
 	Files           map[string]string    `json:"files"`
+	Removed         map[string]bool      `json:"removed,omitempty"`
+	MovedFrom       map[string]string    `json:"movedFrom,omitempty"`
 	UpdatedAtByPath map[string]time.Time `json:"updatedAtByPath"`
 }
 
@@ -183,6 +268,10 @@ type CurrentPlanState struct {
 	CurrentPlanFiles         *CurrentPlanFiles          `json:"currentPlanFiles"`
 	ConvoMessageDescriptions []*ConvoMessageDescription `json:"convoMessageDescriptions"`
 	ContextsByPath           map[string]*Context        `json:"contextsByPath"`
+	Subtasks                 []*Subtask                 `json:"subtasks,omitempty"`
+
+	// when the server loaded the state, so clients can compare the server's CreatedAt timestamps against a time from the same clock
+	ServerTime time.Time `json:"serverTime,omitempty"`
 }
 
 type OrgRole struct {
@@ -197,6 +286,27 @@ type BaseModelConfig struct {
 	BaseUrl   string        `json:"baseUrl"`
 	ModelName string        `json:"modelName"`
 	MaxTokens int           `json:"maxTokens"`
+
+	// overrides the encoding picked from the model's name when counting its tokens
+	TokenEncoding TokenEncoding `json:"tokenEncoding,omitempty"`
+}
+
+type ModelPricing struct {
+	InputPerMillion  float64 `json:"inputPerMillion"`
+	OutputPerMillion float64 `json:"outputPerMillion"`
+}
+
+// Cost is in USD
+func (p ModelPricing) Cost(promptTokens, completionTokens int) float64 {
+	return (float64(promptTokens)*p.InputPerMillion + float64(completionTokens)*p.OutputPerMillion) / 1e6
+}
+
+type ModelCapabilities struct {
+	Streaming      bool `json:"streaming"`
+	ToolCalls      bool `json:"toolCalls"`
+	JsonMode       bool `json:"jsonMode"`
+	SystemMessages bool `json:"systemMessages"`
+	SamplingParams bool `json:"samplingParams"`
 }
 
 type PlannerModelConfig struct {
@@ -232,16 +342,120 @@ type ModelSet struct {
 	Namer       TaskRoleConfig    `json:"namer"`
 	CommitMsg   TaskRoleConfig    `json:"commitMsg"`
 	ExecStatus  TaskRoleConfig    `json:"execStatus"`
+
+	// answers prompts in chat mode. Model sets saved before the role existed leave it empty, and chat falls back to the planner.
+	Chat ModelRoleConfig `json:"chat"`
+
+	// checks changes before they're applied. Model sets saved before the role existed leave it empty, and use the default reviewer.
+	Reviewer TaskRoleConfig `json:"reviewer"`
+}
+
+// GetChatRoleConfig returns the model used for chat replies, or the planner if no chat model is set
+func (ms ModelSet) GetChatRoleConfig() ModelRoleConfig {
+	if ms.Chat.BaseModelConfig.ModelName == "" {
+		return ms.Planner.ModelRoleConfig
+	}
+	return ms.Chat
+}
+
+// GetReviewerRoleConfig returns the model used to check changes, or the default reviewer if no reviewer model is set. Unlike chat, it doesn't fall back to the planner, since the planner may not support the tool calls it needs.
+func (ms ModelSet) GetReviewerRoleConfig() TaskRoleConfig {
+	if ms.Reviewer.BaseModelConfig.ModelName == "" {
+		return DefaultModelSet.Reviewer
+	}
+	return ms.Reviewer
 }
 
+// SetRoleModel switches a role to another model, along with the model's planner or task settings for roles that have them
+func (ms *ModelSet) SetRoleModel(role ModelRole, model BaseModelConfig) {
+	switch role {
+	case ModelRolePlanner:
+		ms.Planner.BaseModelConfig = model
+		ms.Planner.PlannerModelConfig = PlannerModelConfigByName[model.ModelName]
+	case ModelRolePlanSummary:
+		ms.PlanSummary.BaseModelConfig = model
+	case ModelRoleBuilder:
+		ms.Builder.BaseModelConfig = model
+		ms.Builder.TaskModelConfig = TaskModelConfigByName[model.ModelName]
+	case ModelRoleName:
+		ms.Namer.BaseModelConfig = model
+		ms.Namer.TaskModelConfig = TaskModelConfigByName[model.ModelName]
+	case ModelRoleCommitMsg:
+		ms.CommitMsg.BaseModelConfig = model
+		ms.CommitMsg.TaskModelConfig = TaskModelConfigByName[model.ModelName]
+	case ModelRoleExecStatus:
+		ms.ExecStatus.BaseModelConfig = model
+		ms.ExecStatus.TaskModelConfig = TaskModelConfigByName[model.ModelName]
+	case ModelRoleChat:
+		if ms.Chat.BaseModelConfig.ModelName == "" {
+			ms.Chat = ms.GetChatRoleConfig()
+			ms.Chat.Role = ModelRoleChat
+		}
+		ms.Chat.BaseModelConfig = model
+	case ModelRoleReviewer:
+		if ms.Reviewer.BaseModelConfig.ModelName == "" {
+			ms.Reviewer = ms.GetReviewerRoleConfig()
+		}
+		ms.Reviewer.BaseModelConfig = model
+		ms.Reviewer.TaskModelConfig = TaskModelConfigByName[model.ModelName]
+	}
+}
+
+type BuildEditFormat string
+
+const (
+	BuildEditFormatLineRanges    BuildEditFormat = "line-ranges"
+	BuildEditFormatSearchReplace BuildEditFormat = "search-replace"
+)
+
+var BuildEditFormats = []BuildEditFormat{BuildEditFormatLineRanges, BuildEditFormatSearchReplace}
+
 type ModelOverrides struct {
-	MaxConvoTokens       *int `json:"maxConvoTokens"`
-	MaxTokens            *int `json:"maxContextTokens"`
-	ReservedOutputTokens *int `json:"maxOutputTokens"`
+	MaxConvoTokens       *int             `json:"maxConvoTokens"`
+	MaxTokens            *int             `json:"maxContextTokens"`
+	ReservedOutputTokens *int             `json:"maxOutputTokens"`
+	BuildEditFormat      *BuildEditFormat `json:"buildEditFormat,omitempty"`
+}
+
+// PlanPolicy holds a plan's limits and behavior settings that aren't tied to its models. They're set with 'plandex set-policy'.
+type PlanPolicy struct {
+	MaxPlanFiles        *int         `json:"maxPlanFiles,omitempty"`
+	MaxPlanCost         *float64     `json:"maxPlanCost,omitempty"`
+	ConvoPolicy         *ConvoPolicy `json:"convoPolicy,omitempty"`
+	ConvoKeepLast       *int         `json:"convoKeepLast,omitempty"`
+	MaxConcurrentBuilds *int         `json:"maxConcurrentBuilds,omitempty"`
+	SelfReview          *bool        `json:"selfReview,omitempty"`
+}
+
+// ConvoPolicy is how much of the conversation is sent to the planner with each prompt
+type ConvoPolicy string
+
+const (
+	// older messages are replaced with a summary once the conversation passes max-convo-tokens
+	ConvoPolicySummarize ConvoPolicy = "summarize"
+	// only the last convo-keep-last messages are sent, and fewer if they don't fit
+	ConvoPolicyKeepLast ConvoPolicy = "keep-last"
+	// as many of the most recent messages as fit in max-convo-tokens are sent, and older ones are dropped
+	ConvoPolicySlidingWindow ConvoPolicy = "sliding-window"
+)
+
+var ConvoPolicies = []ConvoPolicy{ConvoPolicySummarize, ConvoPolicyKeepLast, ConvoPolicySlidingWindow}
+
+// ConvoHistory describes the conversation history sent with a prompt
+type ConvoHistory struct {
+	Policy      ConvoPolicy `json:"policy"`
+	NumMessages int         `json:"numMessages"`
+	NumIncluded int         `json:"numIncluded"`
+	Tokens      int         `json:"tokens"`
+	Summarized  bool        `json:"summarized"`
 }
 
 type PlanSettings struct {
 	ModelOverrides ModelOverrides `json:"modelOverrides"`
 	ModelSet       *ModelSet      `json:"modelSet"`
+	Policy         PlanPolicy     `json:"policy"`
 	UpdatedAt      time.Time      `json:"updatedAt"`
+
+	// project-relative root of the workspace member (a go.work module, a package in an npm/pnpm workspace, etc.) the plan targets, or empty for the whole project
+	WorkspaceMember string `json:"workspaceMember,omitempty"`
 }
@@ -0,0 +1,166 @@
+package shared
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConvoPage is a page of a plan's conversation
+type ConvoPage struct {
+	Messages []*ConvoMessage `json:"messages"`
+	// messages matching the query across all pages
+	Total int `json:"total"`
+	// offset of the next page, or 0 if this is the last one
+	NextOffset int `json:"nextOffset,omitempty"`
+}
+
+// PlanEvent is a commit to a plan's branch, like a prompt, a reply, a build, or a context update
+type PlanEvent struct {
+	Sha       string    `json:"sha"`
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// PlanEventPage is a page of a plan's events
+type PlanEventPage struct {
+	Events     []*PlanEvent `json:"events"`
+	Total      int          `json:"total"`
+	NextOffset int          `json:"nextOffset,omitempty"`
+}
+
+const (
+	DefaultHistoryPageSize = 50
+	MaxHistoryPageSize     = 500
+)
+
+// HistoryQuery filters and pages a plan's conversation or events. It's sent as the query parameters of ListConvoMessages and ListPlanEvents.
+type HistoryQuery struct {
+	// case-insensitive text match
+	Text  string
+	Since time.Time
+	Until time.Time
+	// "user" or "assistant"; conversation only
+	Role   string
+	Offset int
+	Limit  int
+	// newest first
+	Desc bool
+}
+
+// Values encodes the query as query parameters
+func (q HistoryQuery) Values() url.Values {
+	values := url.Values{}
+	if q.Text != "" {
+		values.Set("q", q.Text)
+	}
+	if !q.Since.IsZero() {
+		values.Set("since", q.Since.UTC().Format(time.RFC3339))
+	}
+	if !q.Until.IsZero() {
+		values.Set("until", q.Until.UTC().Format(time.RFC3339))
+	}
+	if q.Role != "" {
+		values.Set("role", q.Role)
+	}
+	if q.Offset > 0 {
+		values.Set("offset", strconv.Itoa(q.Offset))
+	}
+	if q.Limit > 0 {
+		values.Set("limit", strconv.Itoa(q.Limit))
+	}
+	if q.Desc {
+		values.Set("order", "desc")
+	}
+	return values
+}
+
+// ParseHistoryQuery decodes query parameters, defaulting the page size and capping it at MaxHistoryPageSize
+func ParseHistoryQuery(values url.Values) (HistoryQuery, error) {
+	q := HistoryQuery{
+		Text:  values.Get("q"),
+		Role:  values.Get("role"),
+		Limit: DefaultHistoryPageSize,
+	}
+
+	var err error
+	if s := values.Get("since"); s != "" {
+		q.Since, err = time.Parse(time.RFC3339, s)
+		if err != nil {
+			return q, fmt.Errorf("invalid since, expected an RFC 3339 time: %v", err)
+		}
+	}
+	if s := values.Get("until"); s != "" {
+		q.Until, err = time.Parse(time.RFC3339, s)
+		if err != nil {
+			return q, fmt.Errorf("invalid until, expected an RFC 3339 time: %v", err)
+		}
+	}
+
+	if q.Role != "" && q.Role != "user" && q.Role != "assistant" {
+		return q, fmt.Errorf("invalid role %s, expected user or assistant", q.Role)
+	}
+
+	if s := values.Get("offset"); s != "" {
+		q.Offset, err = strconv.Atoi(s)
+		if err != nil || q.Offset < 0 {
+			return q, fmt.Errorf("invalid offset %s", s)
+		}
+	}
+	if s := values.Get("limit"); s != "" {
+		q.Limit, err = strconv.Atoi(s)
+		if err != nil || q.Limit < 1 {
+			return q, fmt.Errorf("invalid limit %s", s)
+		}
+	}
+	if q.Limit > MaxHistoryPageSize {
+		q.Limit = MaxHistoryPageSize
+	}
+
+	switch order := values.Get("order"); order {
+	case "", "asc":
+	case "desc":
+		q.Desc = true
+	default:
+		return q, fmt.Errorf("invalid order %s, expected asc or desc", order)
+	}
+
+	return q, nil
+}
+
+// Matches is true if an entry created at createdAt with text passes the time and text filters
+func (q HistoryQuery) Matches(createdAt time.Time, text string) bool {
+	if !q.Since.IsZero() && createdAt.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !createdAt.Before(q.Until) {
+		return false
+	}
+	if q.Text != "" && !strings.Contains(strings.ToLower(text), strings.ToLower(q.Text)) {
+		return false
+	}
+	return true
+}
+
+// PageHistory returns the entries on the query's page and the offset of the next page, which is 0 on the last one. entries are in ascending order.
+func PageHistory[T any](q HistoryQuery, entries []T) ([]T, int) {
+	if q.Desc {
+		reversed := make([]T, len(entries))
+		for i, entry := range entries {
+			reversed[len(entries)-1-i] = entry
+		}
+		entries = reversed
+	}
+
+	if q.Offset >= len(entries) {
+		return []T{}, 0
+	}
+
+	end := q.Offset + q.Limit
+	if end >= len(entries) {
+		return entries[q.Offset:], 0
+	}
+	return entries[q.Offset:end], end
+}
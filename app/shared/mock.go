@@ -0,0 +1,49 @@
+package shared
+
+// MockConfig controls the simulated model provider used with --mock, which runs replies and builds through the whole pipeline without calling a real model or spending tokens
+type MockConfig struct {
+	// the same seed and prompt always produce the same reply and edits
+	Seed int64 `json:"seed"`
+
+	// files written in each reply. Files already in context are updated first, then new files are created under mock/
+	NumFiles int `json:"numFiles"`
+	// lines in each file block of a reply
+	FileLines int `json:"fileLines"`
+
+	// characters per streamed chunk, about 4 per real token
+	ChunkSize    int `json:"chunkSize"`
+	ChunkDelayMs int `json:"chunkDelayMs"`
+
+	// fraction of calls that fail with a server error before streaming, to exercise retries
+	ErrorRate float64 `json:"errorRate"`
+	// fraction of streams that stall partway through until they time out
+	TimeoutRate float64 `json:"timeoutRate"`
+}
+
+var DefaultMockConfig = MockConfig{
+	Seed:         1,
+	NumFiles:     2,
+	FileLines:    20,
+	ChunkSize:    4,
+	ChunkDelayMs: 10,
+}
+
+// WithDefaults fills in unset fields from DefaultMockConfig
+func (c MockConfig) WithDefaults() MockConfig {
+	if c.Seed == 0 {
+		c.Seed = DefaultMockConfig.Seed
+	}
+	if c.NumFiles == 0 {
+		c.NumFiles = DefaultMockConfig.NumFiles
+	}
+	if c.FileLines == 0 {
+		c.FileLines = DefaultMockConfig.FileLines
+	}
+	if c.ChunkSize == 0 {
+		c.ChunkSize = DefaultMockConfig.ChunkSize
+	}
+	if c.ChunkDelayMs == 0 {
+		c.ChunkDelayMs = DefaultMockConfig.ChunkDelayMs
+	}
+	return c
+}
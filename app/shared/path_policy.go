@@ -0,0 +1,104 @@
+package shared
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// plans can't write anywhere under these directories, wherever they appear in a path
+var ProtectedPathDirs = []string{".git", ".ssh", ".gnupg", ".aws", ".plandex", ".plandex-dev"}
+
+type PathPolicyError struct {
+	Path   string
+	Reason string
+}
+
+func (e *PathPolicyError) Error() string {
+	return fmt.Sprintf("path '%s' isn't allowed: %s", e.Path, e.Reason)
+}
+
+type PlanFileLimitError struct {
+	Limit int
+}
+
+func (e *PlanFileLimitError) Error() string {
+	return fmt.Sprintf("this plan would write more than %d files. Try splitting the task into smaller steps, or raise the limit with 'plandex set-policy max-plan-files'", e.Limit)
+}
+
+// ValidatePlanPath checks that a path the model wants to write, remove, or move stays inside the project and out of protected directories. Paths are always relative to the project root.
+func ValidatePlanPath(p string) error {
+	if strings.TrimSpace(p) == "" {
+		return &PathPolicyError{Path: p, Reason: "path is empty"}
+	}
+
+	// normalize windows separators so the same checks apply whichever OS the path came from
+	slashed := strings.ReplaceAll(p, "\\", "/")
+
+	if strings.HasPrefix(slashed, "/") || (len(slashed) >= 2 && slashed[1] == ':') {
+		return &PathPolicyError{Path: p, Reason: "absolute paths are not allowed"}
+	}
+
+	if strings.ContainsRune(slashed, 0) {
+		return &PathPolicyError{Path: p, Reason: "path contains a null byte"}
+	}
+
+	cleaned := path.Clean(slashed)
+	if cleaned == "." {
+		return &PathPolicyError{Path: p, Reason: "path is the project root"}
+	}
+	if cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return &PathPolicyError{Path: p, Reason: "path is outside the project"}
+	}
+
+	for _, part := range strings.Split(cleaned, "/") {
+		for _, protected := range ProtectedPathDirs {
+			if strings.EqualFold(part, protected) {
+				return &PathPolicyError{Path: p, Reason: fmt.Sprintf("writing to %s is not allowed", protected)}
+			}
+		}
+	}
+
+	return nil
+}
+
+// NormalizePlanPath puts a path from the model into a canonical form--forward slashes, no redundant or leading './' segments--so the same file is recognized however the model happens to write it. Paths that ValidatePlanPath would reject are normalized too, but stay rejected.
+func NormalizePlanPath(p string) string {
+	p = strings.TrimSpace(p)
+	if p == "" {
+		return ""
+	}
+	return path.Clean(strings.ReplaceAll(p, "\\", "/"))
+}
+
+// ResolvePlanPath matches a normalized path against the project's known paths, returning the known path if one refers to the same file. An exact match after normalization wins; otherwise a match that differs only by case is used, but only if it's unambiguous, since the project may be on a case-sensitive filesystem.
+func ResolvePlanPath(p string, knownPaths []string) string {
+	normalized := NormalizePlanPath(p)
+
+	var caseMatches []string
+	for _, known := range knownPaths {
+		normalizedKnown := NormalizePlanPath(known)
+		if normalizedKnown == normalized {
+			return known
+		}
+		if strings.EqualFold(normalizedKnown, normalized) {
+			caseMatches = append(caseMatches, known)
+		}
+	}
+
+	if len(caseMatches) == 1 {
+		return caseMatches[0]
+	}
+
+	return normalized
+}
+
+// ResolveWorkspaceMemberPath places a path the model wrote relative to the plan's workspace member inside that member. Paths already inside the member are left alone, and the joined path is matched against the known paths like ResolvePlanPath.
+func ResolveWorkspaceMemberPath(p, member string, knownPaths []string) string {
+	normalized := NormalizePlanPath(p)
+	member = NormalizePlanPath(member)
+	if member == "" || member == "." || normalized == member || strings.HasPrefix(normalized, member+"/") {
+		return normalized
+	}
+	return ResolvePlanPath(path.Join(member, normalized), knownPaths)
+}
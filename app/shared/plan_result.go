@@ -23,7 +23,7 @@ func (res *PlanFileResult) NumPendingReplacements() int {
 }
 
 func (res *PlanFileResult) IsPending() bool {
-	return res.AppliedAt == nil && res.RejectedAt == nil && (res.Content != "" || res.NumPendingReplacements() > 0)
+	return res.AppliedAt == nil && res.RejectedAt == nil && (res.Content != "" || res.RemovedFile || res.NumPendingReplacements() > 0)
 }
 
 func (p PlanFileResultsByPath) SetApplied(t time.Time) {
@@ -159,3 +159,25 @@ func (c *CurrentPlanState) NumBuildsPendingByPath() map[string]int {
 func (c *CurrentPlanState) HasPendingBuilds() bool {
 	return len(c.NumBuildsPendingByPath()) > 0
 }
+
+// GetBaseSha returns the sha of a file's content when the first pending change to it was proposed. ok is false if no pending change recorded one. An empty sha means the file didn't exist yet.
+func (state *CurrentPlanState) GetBaseSha(path string) (sha string, ok bool) {
+	var earliest *ConvoMessageDescription
+	for _, desc := range state.ConvoMessageDescriptions {
+		if desc.AppliedAt != nil {
+			continue
+		}
+		if _, has := desc.BaseShasByPath[path]; !has {
+			continue
+		}
+		if earliest == nil || desc.CreatedAt.Before(earliest.CreatedAt) {
+			earliest = desc
+		}
+	}
+
+	if earliest == nil {
+		return "", false
+	}
+
+	return earliest.BaseShasByPath[path], true
+}
@@ -146,13 +146,19 @@ func (state *CurrentPlanState) pendingChangesSummary(forApply bool) string {
 		}
 
 		pendingNewFilesSet := make(map[string]bool)
+		pendingRemovedFilesSet := make(map[string]bool)
+		pendingMovedFromByPath := make(map[string]string)
 		pendingReplacementPathsSet := make(map[string]bool)
 		pendingReplacementsByPath := make(map[string][]*Replacement)
 
 		for _, result := range ch.results {
 
 			if result.IsPending() {
-				if len(result.Replacements) == 0 && result.Content != "" {
+				if result.RemovedFile {
+					pendingRemovedFilesSet[result.Path] = true
+				} else if result.MovedFrom != "" {
+					pendingMovedFromByPath[result.Path] = result.MovedFrom
+				} else if len(result.Replacements) == 0 && result.Content != "" {
 					pendingNewFilesSet[result.Path] = true
 				} else {
 					pendingReplacementPathsSet[result.Path] = true
@@ -161,7 +167,12 @@ func (state *CurrentPlanState) pendingChangesSummary(forApply bool) string {
 			}
 		}
 
-		if len(pendingNewFilesSet) == 0 && len(pendingReplacementPathsSet) == 0 {
+		// the source of a move is removed as part of the move, so it isn't listed separately
+		for _, from := range pendingMovedFromByPath {
+			delete(pendingRemovedFilesSet, from)
+		}
+
+		if len(pendingNewFilesSet) == 0 && len(pendingReplacementPathsSet) == 0 && len(pendingRemovedFilesSet) == 0 && len(pendingMovedFromByPath) == 0 {
 			continue
 		}
 
@@ -197,6 +208,26 @@ func (state *CurrentPlanState) pendingChangesSummary(forApply bool) string {
 
 		}
 
+		var pendingMovedPaths []string
+		for path := range pendingMovedFromByPath {
+			pendingMovedPaths = append(pendingMovedPaths, path)
+		}
+		sort.Strings(pendingMovedPaths)
+
+		for _, path := range pendingMovedPaths {
+			msgs = append(msgs, fmt.Sprintf("    • move → %s to %s", pendingMovedFromByPath[path], path))
+		}
+
+		var pendingRemovedFiles []string
+		for path := range pendingRemovedFilesSet {
+			pendingRemovedFiles = append(pendingRemovedFiles, path)
+		}
+		sort.Strings(pendingRemovedFiles)
+
+		for _, path := range pendingRemovedFiles {
+			msgs = append(msgs, fmt.Sprintf("    • delete → %s", path))
+		}
+
 	}
 	return strings.Join(msgs, "\n")
 }
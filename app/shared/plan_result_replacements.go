@@ -99,6 +99,8 @@ func (planState *CurrentPlanState) GetFilesBeforeReplacement(
 	planRes := planState.PlanResult
 
 	files := make(map[string]string)
+	removed := make(map[string]bool)
+	movedFrom := make(map[string]string)
 	shas := make(map[string]string)
 	updatedAtByPath := make(map[string]time.Time)
 
@@ -116,6 +118,15 @@ func (planState *CurrentPlanState) GetFilesBeforeReplacement(
 				continue
 			}
 
+			if planRes.RemovedFile {
+				updated = ""
+				delete(files, path)
+				delete(movedFrom, path)
+				removed[path] = true
+				updatedAtByPath[path] = planRes.CreatedAt
+				continue
+			}
+
 			if len(planRes.Replacements) == 0 {
 				if updated != "" {
 					return nil, fmt.Errorf("plan updates out of order: %s", path)
@@ -123,6 +134,10 @@ func (planState *CurrentPlanState) GetFilesBeforeReplacement(
 
 				updated = planRes.Content
 				files[path] = updated
+				delete(removed, path)
+				if planRes.MovedFrom != "" {
+					movedFrom[path] = planRes.MovedFrom
+				}
 				updatedAtByPath[path] = planRes.CreatedAt
 
 				// log.Println("No replacements for plan result -- creating file and continuing loop")
@@ -179,8 +194,19 @@ func (planState *CurrentPlanState) GetFilesBeforeReplacement(
 
 		// log.Println("Setting updated content for path: ", path)
 
+		if removed[path] {
+			continue
+		}
+
 		files[path] = updated
 	}
 
-	return &CurrentPlanFiles{Files: files, UpdatedAtByPath: updatedAtByPath}, nil
+	// a move only carries over if its source is still being removed
+	for path, from := range movedFrom {
+		if !removed[from] {
+			delete(movedFrom, path)
+		}
+	}
+
+	return &CurrentPlanFiles{Files: files, Removed: removed, MovedFrom: movedFrom, UpdatedAtByPath: updatedAtByPath}, nil
 }
@@ -1,5 +1,7 @@
 package shared
 
+import "fmt"
+
 type ModelProvider string
 
 const ModelProviderOpenAI ModelProvider = "openai"
@@ -13,9 +15,11 @@ const (
 	ModelRoleName        ModelRole = "names"
 	ModelRoleCommitMsg   ModelRole = "commit-messages"
 	ModelRoleExecStatus  ModelRole = "auto-continue"
+	ModelRoleChat        ModelRole = "chat"
+	ModelRoleReviewer    ModelRole = "reviewer"
 )
 
-var AllModelRoles = []ModelRole{ModelRolePlanner, ModelRolePlanSummary, ModelRoleBuilder, ModelRoleName, ModelRoleCommitMsg, ModelRoleExecStatus}
+var AllModelRoles = []ModelRole{ModelRolePlanner, ModelRolePlanSummary, ModelRoleBuilder, ModelRoleName, ModelRoleCommitMsg, ModelRoleExecStatus, ModelRoleChat, ModelRoleReviewer}
 var ModelRoleDescriptions = map[ModelRole]string{
 	ModelRolePlanner:     "replies to prompts and makes plans",
 	ModelRolePlanSummary: "summarizes conversations exceeding max-convo-tokens",
@@ -23,14 +27,53 @@ var ModelRoleDescriptions = map[ModelRole]string{
 	ModelRoleName:        "names plans",
 	ModelRoleCommitMsg:   "writes commit messages",
 	ModelRoleExecStatus:  "determines whether to auto-continue",
+	ModelRoleChat:        "answers questions in chat mode",
+	ModelRoleReviewer:    "reviews changes for bugs, and scans them for security risks before they're applied",
+}
+
+// ModelRolesWithoutToolCalls reply in plain text, so they can use models without tool call support. The other roles, like the builder and the reviewer, get structured output through tool calls.
+var ModelRolesWithoutToolCalls = []ModelRole{ModelRolePlanner, ModelRolePlanSummary, ModelRoleChat}
+
+// CheckRoleModel returns an error if the model lacks a capability the role needs
+func CheckRoleModel(role ModelRole, modelName string) error {
+	if GetModelCapabilities(modelName).ToolCalls {
+		return nil
+	}
+	for _, r := range ModelRolesWithoutToolCalls {
+		if r == role {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s doesn't support tool calls, so it can only be used for the %s, %s and %s roles", modelName, ModelRolePlanner, ModelRolePlanSummary, ModelRoleChat)
 }
+
 var SettingDescriptions = map[string]string{
 	"max-convo-tokens":       "max conversation 🪙 before summarization",
 	"max-tokens":             "overall 🪙 limit",
 	"reserved-output-tokens": "🪙 reserved for model output",
+	"build-edit-format":      "how the builder edits files: line-ranges or search-replace",
+	"max-plan-files":         "max files a plan can write",
+	"max-plan-cost":          "max estimated USD the plan can spend on model calls",
+	"max-daily-cost":         "max estimated USD the whole org can spend on model calls per day (UTC)",
+	"convo-policy":           "how much conversation history is sent: summarize, keep-last, or sliding-window",
+	"convo-keep-last":        "messages of conversation history sent with the keep-last policy",
+	"max-concurrent-builds":  "max files built at once; lower it to avoid rate limits",
+	"self-review":            "have the planner critique each plan reply for missed files, inconsistencies, and incorrect APIs",
 }
 
-var ModelOverridePropsDasherized = []string{"max-convo-tokens", "max-tokens", "reserved-output-tokens"}
+var ModelOverridePropsDasherized = []string{"max-convo-tokens", "max-tokens", "reserved-output-tokens", "build-edit-format"}
+
+var PlanPolicyPropsDasherized = []string{"max-plan-files", "max-plan-cost", "convo-policy", "convo-keep-last", "max-concurrent-builds", "self-review"}
+
+// OrgPolicyPropsDasherized are set with 'plandex set-policy' like plan policies, but apply to every plan in the org
+var OrgPolicyPropsDasherized = []string{"max-daily-cost"}
+
+const DefaultMaxPlanFiles = 50
+
+// conversations are summarized in the background once they reach this fraction of max-convo-tokens, so a summary is ready before the limit is hit
+const ConvoSummaryThreshold = 0.5
+
+const DefaultConvoKeepLast = 10
 
 func (ps PlanSettings) GetPlannerMaxTokens() int {
 	if ps.ModelOverrides.MaxTokens == nil {
@@ -68,6 +111,50 @@ func (ps PlanSettings) GetPlannerReservedOutputTokens() int {
 	}
 }
 
+func (ps PlanSettings) GetConvoSummaryThresholdTokens() int {
+	return int(float64(ps.GetPlannerMaxConvoTokens()) * ConvoSummaryThreshold)
+}
+
 func (ps PlanSettings) GetPlannerEffectiveMaxTokens() int {
 	return ps.GetPlannerMaxTokens() - ps.GetPlannerReservedOutputTokens()
 }
+
+func (ps PlanSettings) GetBuildEditFormat() BuildEditFormat {
+	if ps.ModelOverrides.BuildEditFormat == nil {
+		return BuildEditFormatLineRanges
+	}
+	return *ps.ModelOverrides.BuildEditFormat
+}
+
+func (ps PlanSettings) GetMaxPlanFiles() int {
+	if ps.Policy.MaxPlanFiles == nil {
+		return DefaultMaxPlanFiles
+	}
+	return *ps.Policy.MaxPlanFiles
+}
+
+func (ps PlanSettings) GetConvoPolicy() ConvoPolicy {
+	if ps.Policy.ConvoPolicy == nil {
+		return ConvoPolicySummarize
+	}
+	return *ps.Policy.ConvoPolicy
+}
+
+func (ps PlanSettings) GetConvoKeepLast() int {
+	if ps.Policy.ConvoKeepLast == nil {
+		return DefaultConvoKeepLast
+	}
+	return *ps.Policy.ConvoKeepLast
+}
+
+// GetMaxConcurrentBuilds returns the most files the plan builds at once, or 0 for no limit
+func (ps PlanSettings) GetMaxConcurrentBuilds() int {
+	if ps.Policy.MaxConcurrentBuilds == nil {
+		return 0
+	}
+	return *ps.Policy.MaxConcurrentBuilds
+}
+
+func (ps PlanSettings) GetSelfReview() bool {
+	return ps.Policy.SelfReview != nil && *ps.Policy.SelfReview
+}
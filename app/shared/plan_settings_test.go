@@ -0,0 +1,27 @@
+package shared
+
+import (
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestCheckRoleModel(t *testing.T) {
+	for _, role := range AllModelRoles {
+		if err := CheckRoleModel(role, openai.GPT4TurboPreview); err != nil {
+			t.Errorf("expected %s to accept a model with tool calls, got %v", role, err)
+		}
+	}
+
+	for _, role := range []ModelRole{ModelRolePlanner, ModelRolePlanSummary, ModelRoleChat} {
+		if err := CheckRoleModel(role, ModelNameO1Preview); err != nil {
+			t.Errorf("expected %s to accept a model without tool calls, got %v", role, err)
+		}
+	}
+
+	for _, role := range []ModelRole{ModelRoleBuilder, ModelRoleName, ModelRoleCommitMsg, ModelRoleExecStatus, ModelRoleReviewer} {
+		if err := CheckRoleModel(role, ModelNameO1Preview); err == nil {
+			t.Errorf("expected %s to reject a model without tool calls", role)
+		}
+	}
+}
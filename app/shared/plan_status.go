@@ -8,6 +8,7 @@ const (
 	PlanStatusDescribing  PlanStatus = "describing"
 	PlanStatusBuilding    PlanStatus = "building"
 	PlanStatusMissingFile PlanStatus = "missingFile"
+	PlanStatusClarifying  PlanStatus = "clarifying"
 	PlanStatusFinished    PlanStatus = "finished"
 	PlanStatusStopped     PlanStatus = "stopped"
 	PlanStatusError       PlanStatus = "error"
@@ -1,6 +1,9 @@
 package shared
 
-import "time"
+import (
+	"os"
+	"time"
+)
 
 type StartTrialResponse struct {
 	UserId   string `json:"userId"`
@@ -44,6 +47,23 @@ type CreateOrgRequest struct {
 	AutoAddDomainUsers bool   `json:"autoAddDomainUsers"`
 }
 
+// OrgSlackSettings is where the org's plan notifications go. The webhook url is a secret, so the server only says whether one is set.
+type OrgSlackSettings struct {
+	Enabled        bool `json:"enabled"`
+	IncludePrompts bool `json:"includePrompts"`
+}
+
+// UpdateOrgSlackSettingsRequest sets the org's Slack webhook. An empty WebhookUrl turns notifications off.
+type UpdateOrgSlackSettingsRequest struct {
+	WebhookUrl     string `json:"webhookUrl"`
+	IncludePrompts bool   `json:"includePrompts"`
+}
+
+// UpdateOrgBudgetRequest sets the org's daily budget. A nil MaxDailyCost removes it.
+type UpdateOrgBudgetRequest struct {
+	MaxDailyCost *float64 `json:"maxDailyCost"`
+}
+
 type ConvertTrialRequest struct {
 	Email                 string `json:"email"`
 	Pin                   string `json:"pin"`
@@ -114,12 +134,34 @@ type TellPlanRequest struct {
 	IsUserContinue bool            `json:"isUserContinue"`
 	ApiKey         string          `json:"apiKey"`
 	ProjectPaths   map[string]bool `json:"projectPaths"`
+
+	// replies answer in chat form using the plan's context and conversation, without writing files or building
+	ChatOnly bool `json:"chatOnly,omitempty"`
+	// with ChatOnly, the prompt and reply aren't added to the plan's conversation
+	Ephemeral bool `json:"ephemeral,omitempty"`
+	// the model can ask clarifying questions before making a plan, pausing the stream until the client responds. Only set when someone is there to answer.
+	AllowClarify bool `json:"allowClarify,omitempty"`
+	// the plan is rewound to before the last prompt, so this prompt replaces it and the replies, builds, and context changes that followed it
+	ReplaceLastPrompt bool `json:"replaceLastPrompt,omitempty"`
+
+	// client's global default model set, used when the plan has no model settings of its own
+	ModelSet *ModelSet `json:"modelSet,omitempty"`
+	// used for this prompt and the builds it starts in place of the plan's model settings, e.g. for a preset
+	ModelSetOverride *ModelSet `json:"modelSetOverride,omitempty"`
+
+	// set to simulate model calls rather than calling the provider. Only honored by servers that allow it.
+	Mock *MockConfig `json:"mock,omitempty"`
+
+	// set for each request an automated run makes, so its limits are checked on the server too
+	AutoRun *AutoRun `json:"autoRun,omitempty"`
 }
 
 type BuildPlanRequest struct {
 	ConnectStream bool            `json:"connectStream"`
 	ApiKey        string          `json:"apiKey"`
 	ProjectPaths  map[string]bool `json:"projectPaths"`
+	ModelSet      *ModelSet       `json:"modelSet,omitempty"`
+	Mock          *MockConfig     `json:"mock,omitempty"`
 }
 
 const NoBuildsErr string = "No builds"
@@ -138,6 +180,11 @@ type RespondMissingFileRequest struct {
 	Body     string                   `json:"body"`
 }
 
+// RespondClarifyRequest answers the model's clarifying questions in order. An empty answer leaves that question to the model's judgment.
+type RespondClarifyRequest struct {
+	Answers []string `json:"answers"`
+}
+
 type LoadContextParams struct {
 	ContextType     ContextType `json:"contextType"`
 	Name            string      `json:"name"`
@@ -145,6 +192,9 @@ type LoadContextParams struct {
 	FilePath        string      `json:"file_path"`
 	Body            string      `json:"body"`
 	ForceSkipIgnore bool        `json:"forceSkipIgnore"`
+	FileMode        os.FileMode `json:"fileMode,omitempty"`
+	Encoding        string      `json:"encoding,omitempty"`
+	SymlinkTarget   string      `json:"symlinkTarget,omitempty"`
 }
 
 type LoadContextRequest []*LoadContextParams
@@ -159,6 +209,13 @@ type LoadContextResponse struct {
 
 type UpdateContextParams struct {
 	Body string `json:"body"`
+
+	// nil leaves the context's encoding as is
+	Encoding *string `json:"encoding,omitempty"`
+
+	// nil leaves the context's mode and symlink target as is; an empty target means the file is no longer a link
+	FileMode      *os.FileMode `json:"fileMode,omitempty"`
+	SymlinkTarget *string      `json:"symlinkTarget,omitempty"`
 }
 
 type UpdateContextRequest map[string]*UpdateContextParams
@@ -179,6 +236,14 @@ type RejectFileRequest struct {
 	FilePath string `json:"filePath"`
 }
 
+// RecordCommandResultRequest reports the result of running one of a reply's suggested commands, so its output is added to the conversation
+type RecordCommandResultRequest struct {
+	ConvoMessageId string `json:"convoMessageId"`
+	CommandIndex   int    `json:"commandIndex"`
+	ExitCode       int    `json:"exitCode"`
+	Output         string `json:"output"`
+}
+
 type RewindPlanRequest struct {
 	Sha string `json:"sha"`
 }
@@ -209,3 +274,131 @@ type ListUsersResponse struct {
 	Users            []*User             `json:"users"`
 	OrgUsersByUserId map[string]*OrgUser `json:"orgUsersByUserId"`
 }
+
+type CheckModelsRequest struct {
+	ApiKey   string    `json:"apiKey"`
+	ModelSet *ModelSet `json:"modelSet,omitempty"`
+}
+
+type GenCommitMsgRequest struct {
+	ApiKey   string    `json:"apiKey"`
+	ModelSet *ModelSet `json:"modelSet,omitempty"`
+	Diff     string    `json:"diff"`
+
+	// format the message as a conventional commit, e.g. 'fix(parser): ...'
+	Conventional bool `json:"conventional"`
+}
+
+type GenCommitMsgResponse struct {
+	CommitMsg string `json:"commitMsg"`
+}
+
+type GenPullRequestRequest struct {
+	ApiKey   string    `json:"apiKey"`
+	ModelSet *ModelSet `json:"modelSet,omitempty"`
+	// the diff between the pull request's base and its branch
+	Diff     string `json:"diff"`
+	PlanName string `json:"planName"`
+	// the commit messages of the plan's applied changes, oldest first
+	Changes []string `json:"changes,omitempty"`
+	// the prompts in the plan's conversation, oldest first
+	Prompts []string `json:"prompts,omitempty"`
+}
+
+type GenPullRequestResponse struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+type CreatePlanPullRequestRequest struct {
+	Url       string `json:"url"`
+	Number    int    `json:"number"`
+	GitBranch string `json:"gitBranch"`
+	Base      string `json:"base"`
+}
+
+type ReviewRequest struct {
+	ApiKey   string    `json:"apiKey"`
+	ModelSet *ModelSet `json:"modelSet,omitempty"`
+	Diff     string    `json:"diff"`
+
+	// full content of the changed files by path, included as context when there's room
+	Files map[string]string `json:"files,omitempty"`
+
+	// the current plan, if any. Its file context is included after the changed files when there's room.
+	PlanId string `json:"planId,omitempty"`
+	Branch string `json:"branch,omitempty"`
+
+	// the plan's project directory relative to the repo root, with a trailing slash, or "" if the project is the repo root. The diff and Files use repo-relative paths, while the plan's context paths are relative to the project, so they're joined with this to match.
+	ProjectPrefix string `json:"projectPrefix,omitempty"`
+}
+
+type ReviewResponse struct {
+	Findings []*ReviewFinding `json:"findings"`
+}
+
+type SecurityScanRequest struct {
+	ApiKey   string    `json:"apiKey"`
+	ModelSet *ModelSet `json:"modelSet,omitempty"`
+	// a unified diff of the plan's drafted changes
+	Diff string `json:"diff"`
+}
+
+type SecurityScanResponse struct {
+	Flags []*SecurityFlag `json:"flags"`
+}
+
+type ModelAvailability struct {
+	Role      ModelRole `json:"role"`
+	ModelName string    `json:"modelName"`
+	Available bool      `json:"available"`
+}
+
+type CheckModelsResponse struct {
+	Provider    ModelProvider       `json:"provider"`
+	BaseUrl     string              `json:"baseUrl"`
+	ApiKeyValid bool                `json:"apiKeyValid"`
+	QuotaOk     bool                `json:"quotaOk"`
+	Models      []ModelAvailability `json:"models"`
+	Errors      []string            `json:"errors"`
+}
+
+// DoctorCheck is one of the probes run by 'plandex doctor'. Fix is an action that would resolve a failed check.
+type DoctorCheck struct {
+	Name    string `json:"name"`
+	Ok      bool   `json:"ok"`
+	Message string `json:"message"`
+	Fix     string `json:"fix,omitempty"`
+
+	// set on a check that's ok but may cause problems. It's shown with its fix, but doesn't make doctor fail.
+	Warning bool `json:"warning,omitempty"`
+}
+
+type DoctorResponse struct {
+	Version string         `json:"version"`
+	Checks  []*DoctorCheck `json:"checks"`
+}
+
+const PlanBundleVersion = 1
+
+// PlanBundle is a plan packed up to move it to another server, with its conversation, context, and pending changes on every branch
+type PlanBundle struct {
+	Version  int                 `json:"version"`
+	Name     string              `json:"name"`
+	Branches []*PlanBundleBranch `json:"branches"`
+	// a git bundle of the plan's repo with all its branches
+	GitBundle  []byte          `json:"gitBundle"`
+	Summaries  []*ConvoSummary `json:"summaries,omitempty"`
+	ExportedAt time.Time       `json:"exportedAt"`
+}
+
+type PlanBundleBranch struct {
+	Name         string `json:"name"`
+	ParentBranch string `json:"parentBranch,omitempty"`
+}
+
+type ImportPlanRequest struct {
+	// the bundle's name is used if empty; either is suffixed if a plan with that name exists
+	Name   string      `json:"name"`
+	Bundle *PlanBundle `json:"bundle"`
+}
@@ -0,0 +1,45 @@
+package shared
+
+import "strings"
+
+type ReviewSeverity string
+
+const (
+	ReviewSeverityInfo     ReviewSeverity = "info"
+	ReviewSeverityLow      ReviewSeverity = "low"
+	ReviewSeverityMedium   ReviewSeverity = "medium"
+	ReviewSeverityHigh     ReviewSeverity = "high"
+	ReviewSeverityCritical ReviewSeverity = "critical"
+)
+
+// ReviewSeverities is ordered from least to most severe
+var ReviewSeverities = []ReviewSeverity{
+	ReviewSeverityInfo,
+	ReviewSeverityLow,
+	ReviewSeverityMedium,
+	ReviewSeverityHigh,
+	ReviewSeverityCritical,
+}
+
+// Rank returns the severity's position in ReviewSeverities, or -1 if it isn't a known severity
+func (s ReviewSeverity) Rank() int {
+	for i, severity := range ReviewSeverities {
+		if severity == s {
+			return i
+		}
+	}
+	return -1
+}
+
+func ParseReviewSeverity(s string) (ReviewSeverity, bool) {
+	severity := ReviewSeverity(strings.ToLower(strings.TrimSpace(s)))
+	return severity, severity.Rank() >= 0
+}
+
+type ReviewFinding struct {
+	Path       string         `json:"path"`
+	Line       int            `json:"line,omitempty"`
+	Severity   ReviewSeverity `json:"severity"`
+	Message    string         `json:"message"`
+	Suggestion string         `json:"suggestion,omitempty"`
+}
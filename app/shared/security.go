@@ -0,0 +1,133 @@
+package shared
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+type SecurityRisk string
+
+const (
+	SecurityRiskNetwork     SecurityRisk = "network"
+	SecurityRiskShell       SecurityRisk = "shell"
+	SecurityRiskCredentials SecurityRisk = "credentials"
+	SecurityRiskDependency  SecurityRisk = "dependency"
+)
+
+var SecurityRisks = []SecurityRisk{SecurityRiskNetwork, SecurityRiskShell, SecurityRiskCredentials, SecurityRiskDependency}
+
+const (
+	SecurityFlagSourceRules = "rules"
+	SecurityFlagSourceModel = "model"
+)
+
+// SecurityFlag is a risky operation in a plan's drafted change to a file. Flagged files aren't applied without confirmation.
+type SecurityFlag struct {
+	Path string `json:"path"`
+	// the line in the drafted file, or 0 if it doesn't apply to a single line
+	Line    int          `json:"line,omitempty"`
+	Risk    SecurityRisk `json:"risk"`
+	Message string       `json:"message"`
+	// SecurityFlagSourceRules or SecurityFlagSourceModel
+	Source string `json:"source"`
+}
+
+var securityRiskPatterns = []struct {
+	risk    SecurityRisk
+	desc    string
+	pattern *regexp.Regexp
+}{
+	{
+		risk:    SecurityRiskNetwork,
+		desc:    "makes network calls",
+		pattern: regexp.MustCompile(`\bhttp\.(Get|Post|PostForm|Head|NewRequest(WithContext)?)\(|\bnet\.Dial|\bfetch\(|\baxios\b|\bXMLHttpRequest\b|\bWebSocket\(|\brequests\.(get|post|put|patch|delete|request|Session)\b|\burllib\b|\burlopen\(|\bhttpx\.|\bsocket\.(socket|create_connection)\(|\breqwest::|\bHttpClient\b|\b(curl|wget)\s+\S`),
+	},
+	{
+		risk:    SecurityRiskShell,
+		desc:    "runs shell commands",
+		pattern: regexp.MustCompile(`\bexec\.Command(Context)?\(|"os/exec"|\bsubprocess\.|\bos\.(system|popen|exec[lv]p?e?)\(|\bchild_process\b|\bexecSync\(|\bspawn(Sync)?\(|\bRuntime\.getRuntime\(\)\.exec\(|\bProcessBuilder\(|\bprocess::Command\b|\bshell\s*=\s*True\b|\beval\(`),
+	},
+	{
+		// a credential-named key or variable assigned a string literal long enough to be a real secret, a private key, or a token in a well-known provider format. Reading credentials from the environment or a request isn't flagged.
+		risk:    SecurityRiskCredentials,
+		desc:    "contains a hard-coded credential",
+		pattern: regexp.MustCompile(`(?i:\b\w*(password|passwd|secret|api_?key|access_?key|private_?key|auth_?token)\w*["']?\s*(:=|=|:)\s*["'][^"'\s]{8,}["'])|-----BEGIN [A-Z ]*PRIVATE KEY-----|\bAKIA[0-9A-Z]{16}\b|\bgh[pousr]_[A-Za-z0-9]{36}\b|\bxox[abpr]-[A-Za-z0-9-]{10,}|\bsk-[A-Za-z0-9]{32,}\b`),
+	},
+}
+
+// dependency manifests by file name. Any change to one of them can add a dependency.
+var dependencyManifests = map[string]bool{
+	"go.mod":           true,
+	"package.json":     true,
+	"requirements.txt": true,
+	"Pipfile":          true,
+	"pyproject.toml":   true,
+	"setup.py":         true,
+	"Cargo.toml":       true,
+	"Gemfile":          true,
+	"composer.json":    true,
+	"pom.xml":          true,
+	"build.gradle":     true,
+	"build.gradle.kts": true,
+	"Package.swift":    true,
+	"mix.exs":          true,
+	"pubspec.yaml":     true,
+}
+
+// FlagSecurityRisks checks the lines a drafted file adds for risky operations: network calls, shell commands, credential handling, and dependency changes. It returns at most one flag per risk, at the first line that matched.
+func FlagSecurityRisks(filePath, original, drafted string) []*SecurityFlag {
+	var flags []*SecurityFlag
+
+	// lines that were already in the file, counted so a moved or duplicated line is still treated as added
+	existing := map[string]int{}
+	for _, line := range strings.Split(original, "\n") {
+		existing[strings.TrimSpace(line)]++
+	}
+
+	flagged := map[SecurityRisk]bool{}
+	isManifest := dependencyManifests[path.Base(NormalizePlanPath(filePath))]
+
+	for i, line := range strings.Split(drafted, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if existing[trimmed] > 0 {
+			existing[trimmed]--
+			continue
+		}
+		if trimmed == "" {
+			continue
+		}
+
+		if isManifest && !flagged[SecurityRiskDependency] {
+			flagged[SecurityRiskDependency] = true
+			flags = append(flags, &SecurityFlag{
+				Path:    filePath,
+				Line:    i + 1,
+				Risk:    SecurityRiskDependency,
+				Message: fmt.Sprintf("changes dependencies: %s", trimmed),
+				Source:  SecurityFlagSourceRules,
+			})
+		}
+
+		for _, p := range securityRiskPatterns {
+			if flagged[p.risk] {
+				continue
+			}
+			match := p.pattern.FindString(line)
+			if match == "" {
+				continue
+			}
+			flagged[p.risk] = true
+			flags = append(flags, &SecurityFlag{
+				Path:    filePath,
+				Line:    i + 1,
+				Risk:    p.risk,
+				Message: fmt.Sprintf("%s: %s", p.desc, strings.TrimSpace(match)),
+				Source:  SecurityFlagSourceRules,
+			})
+		}
+	}
+
+	return flags
+}
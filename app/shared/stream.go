@@ -6,6 +6,8 @@ type BuildInfo struct {
 	Path      string `json:"path"`
 	NumTokens int    `json:"numTokens"`
 	Finished  bool   `json:"finished"`
+	// set on a finished build when the file's type has no syntax validator, so the result wasn't checked for syntax errors
+	SyntaxUnchecked bool `json:"syntaxUnchecked,omitempty"`
 }
 
 type StreamMessageType string
@@ -15,9 +17,12 @@ const (
 	StreamMessageConnectActive     StreamMessageType = "connectActive"
 	StreamMessageReply             StreamMessageType = "reply"
 	StreamMessageDescribing        StreamMessageType = "describing"
+	StreamMessageSelfReview        StreamMessageType = "selfReview"
 	StreamMessageRepliesFinished   StreamMessageType = "repliesFinished"
+	StreamMessageUsage             StreamMessageType = "usage"
 	StreamMessageBuildInfo         StreamMessageType = "buildInfo"
 	StreamMessagePromptMissingFile StreamMessageType = "promptMissingFile"
+	StreamMessagePromptClarify     StreamMessageType = "promptClarify"
 	StreamMessageAborted           StreamMessageType = "aborted"
 	StreamMessageFinished          StreamMessageType = "finished"
 	StreamMessageError             StreamMessageType = "error"
@@ -32,7 +37,16 @@ type StreamMessage struct {
 	Description     *ConvoMessageDescription `json:"description,omitempty"`
 	Error           *ApiError                `json:"error,omitempty"`
 	MissingFilePath string                   `json:"missingFilePath,omitempty"`
-	ModelStreamId   string                   `json:"modelStreamId,omitempty"`
+	// questions the model asked before making a plan, which the client answers with RespondClarify
+	ClarifyingQuestions []string    `json:"clarifyingQuestions,omitempty"`
+	ModelStreamId       string      `json:"modelStreamId,omitempty"`
+	Usage               *ModelUsage `json:"usage,omitempty"`
+	// sent with usage when the plan has a budget, counting the usage it's sent with
+	Budget *BudgetStatus `json:"budget,omitempty"`
+	// sent with the reply's usage
+	ConvoHistory *ConvoHistory `json:"convoHistory,omitempty"`
+	// the planner's critique of the reply, sent before it's described when self-review is on
+	SelfReview string `json:"selfReview,omitempty"`
 
 	InitPrompt    string   `json:"initPrompt,omitempty"`
 	InitReplies   []string `json:"initReplies,omitempty"`
@@ -24,3 +24,10 @@ type StreamedChange struct {
 	// New            StreamedChangeSection `json:"new"`
 	New string `json:"new"`
 }
+
+// StreamedEdit is an anchored search/replace edit, used by the search-replace build edit format
+type StreamedEdit struct {
+	Summary string `json:"summary"`
+	Search  string `json:"search"`
+	Replace string `json:"replace"`
+}
@@ -2,15 +2,115 @@ package shared
 
 import (
 	"fmt"
+	"math"
+	"strings"
+	"sync"
 
 	"github.com/pkoukk/tiktoken-go"
 )
 
+type TokenEncoding string
+
+const (
+	TokenEncodingCl100k TokenEncoding = "cl100k_base"
+	TokenEncodingP50k   TokenEncoding = "p50k_base"
+	TokenEncodingR50k   TokenEncoding = "r50k_base"
+
+	// Anthropic and Google don't publish local tokenizers, so their models are estimated from the cl100k count
+	TokenEncodingClaudeEstimate TokenEncoding = "claude-estimate"
+	TokenEncodingGeminiEstimate TokenEncoding = "gemini-estimate"
+)
+
+var TokenEncodings = []TokenEncoding{TokenEncodingCl100k, TokenEncodingP50k, TokenEncodingR50k, TokenEncodingClaudeEstimate, TokenEncodingGeminiEstimate}
+
+// used when the model isn't known, like for context that's shared by every model in a plan
+const DefaultTokenEncoding = TokenEncodingCl100k
+
+// how many more tokens the estimated encodings produce for the same text than cl100k. They lean high so budget checks err on the safe side.
+var tokenEstimateFactors = map[TokenEncoding]float64{
+	TokenEncodingClaudeEstimate: 1.2,
+	TokenEncodingGeminiEstimate: 1.1,
+}
+
+var tokenizersMu sync.Mutex
+var tokenizers = map[TokenEncoding]*tiktoken.Tiktoken{}
+
+// GetTokenEncoding picks the encoding used to count tokens for a model. The model's own TokenEncoding wins if it's set; otherwise it's chosen from the model's name. Newer OpenAI models use o200k, which the tiktoken package doesn't have yet, so they're counted with cl100k, which comes within a few percent.
+func GetTokenEncoding(model BaseModelConfig) TokenEncoding {
+	if model.TokenEncoding != "" {
+		return model.TokenEncoding
+	}
+
+	name := strings.ToLower(model.ModelName)
+	// names can include a provider prefix, like anthropic/claude-3-5-sonnet
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	switch {
+	case strings.HasPrefix(name, "claude"):
+		return TokenEncodingClaudeEstimate
+	case strings.HasPrefix(name, "gemini"):
+		return TokenEncodingGeminiEstimate
+	}
+
+	encoding, ok := tiktoken.MODEL_TO_ENCODING[name]
+	if !ok {
+		for prefix, prefixEncoding := range tiktoken.MODEL_PREFIX_TO_ENCODING {
+			if strings.HasPrefix(name, prefix) {
+				encoding = prefixEncoding
+				break
+			}
+		}
+	}
+
+	switch TokenEncoding(encoding) {
+	case TokenEncodingP50k, TokenEncodingR50k:
+		return TokenEncoding(encoding)
+	}
+	return DefaultTokenEncoding
+}
+
+// GetNumTokens counts tokens with the default encoding, for text that isn't sent to a particular model
 func GetNumTokens(text string) (int, error) {
-	tkm, err := tiktoken.EncodingForModel("gpt-4")
+	return GetNumTokensWithEncoding(DefaultTokenEncoding, text)
+}
+
+// GetNumTokensForModel counts tokens with the encoding for the model the text will be sent to
+func GetNumTokensForModel(model BaseModelConfig, text string) (int, error) {
+	return GetNumTokensWithEncoding(GetTokenEncoding(model), text)
+}
+
+func GetNumTokensWithEncoding(encoding TokenEncoding, text string) (int, error) {
+	if factor, ok := tokenEstimateFactors[encoding]; ok {
+		numTokens, err := GetNumTokensWithEncoding(TokenEncodingCl100k, text)
+		if err != nil {
+			return 0, err
+		}
+		return int(math.Ceil(float64(numTokens) * factor)), nil
+	}
+
+	tkm, err := getTokenizer(encoding)
 	if err != nil {
-		err = fmt.Errorf("error getting encoding for model: %v", err)
 		return 0, err
 	}
 	return len(tkm.Encode(text, nil, nil)), nil
 }
+
+// getTokenizer builds each encoding's tokenizer once, since building it compiles its regexes and loads its ranks
+func getTokenizer(encoding TokenEncoding) (*tiktoken.Tiktoken, error) {
+	tokenizersMu.Lock()
+	defer tokenizersMu.Unlock()
+
+	if tkm, ok := tokenizers[encoding]; ok {
+		return tkm, nil
+	}
+
+	tkm, err := tiktoken.GetEncoding(string(encoding))
+	if err != nil {
+		return nil, fmt.Errorf("error getting %s encoding: %v", encoding, err)
+	}
+	tokenizers[encoding] = tkm
+
+	return tkm, nil
+}
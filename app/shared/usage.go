@@ -0,0 +1,77 @@
+package shared
+
+import (
+	"fmt"
+	"time"
+)
+
+// UsageTotal adds up token usage along with its estimated cost in USD
+type UsageTotal struct {
+	PromptTokens     int     `json:"promptTokens"`
+	CompletionTokens int     `json:"completionTokens"`
+	Cost             float64 `json:"cost"`
+
+	// set if some of the usage was for models missing from the pricing table, so Cost is a lower bound
+	Unpriced bool `json:"unpriced,omitempty"`
+}
+
+func (t *UsageTotal) Add(usage ModelUsage) {
+	t.PromptTokens += usage.PromptTokens
+	t.CompletionTokens += usage.CompletionTokens
+
+	cost, ok := EstimateCost(usage)
+	if ok {
+		t.Cost += cost
+	} else {
+		t.Unpriced = true
+	}
+}
+
+func (t *UsageTotal) AddTotal(other UsageTotal) {
+	t.PromptTokens += other.PromptTokens
+	t.CompletionTokens += other.CompletionTokens
+	t.Cost += other.Cost
+	t.Unpriced = t.Unpriced || other.Unpriced
+}
+
+// FormatCost shows the estimated cost, marked as a lower bound if some usage couldn't be priced
+func (t UsageTotal) FormatCost() string {
+	if t.Unpriced && t.Cost == 0 {
+		return "unknown cost"
+	}
+
+	var s string
+	if t.Cost >= 1 {
+		s = fmt.Sprintf("~$%.2f", t.Cost)
+	} else {
+		s = fmt.Sprintf("~$%.4f", t.Cost)
+	}
+
+	if t.Unpriced {
+		s = "≥ " + s
+	}
+	return s
+}
+
+// UsageRecord is a model call's usage as recorded by the server
+type UsageRecord struct {
+	ModelUsage
+	PlanId    string    `json:"planId"`
+	Branch    string    `json:"branch"`
+	UserId    string    `json:"userId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type PlanUsageResponse struct {
+	Records []*UsageRecord `json:"records"`
+}
+
+type OrgUsageResponse struct {
+	// only the requesting user's records unless they can manage billing for the org
+	Records []*UsageRecord `json:"records"`
+
+	// the org's monthly token quota, or 0 if the server doesn't set one
+	MonthlyTokenQuota int `json:"monthlyTokenQuota,omitempty"`
+	// tokens the whole org has used since the start of the month, in UTC
+	MonthTokens int `json:"monthTokens"`
+}